@@ -423,8 +423,13 @@ func (fb *filterBackend) ChainDb() ethdb.Database  { return fb.db }
 func (fb *filterBackend) EventMux() *event.TypeMux { panic("not supported") }
 
 func (fb *filterBackend) HeaderByNumber(ctx context.Context, block rpc.BlockNumber) (*types.Header, error) {
-	if block == rpc.LatestBlockNumber {
+	switch block {
+	case rpc.LatestBlockNumber:
 		return fb.bc.CurrentHeader(), nil
+	case rpc.SafeBlockNumber:
+		return fb.bc.CurrentSafeBlock().Header(), nil
+	case rpc.FinalizedBlockNumber:
+		return fb.bc.CurrentFinalizedBlock().Header(), nil
 	}
 	return fb.bc.GetHeaderByNumber(uint64(block.Int64())), nil
 }
@@ -451,9 +456,18 @@ func (fb *filterBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Su
 		return nil
 	})
 }
+func (fb *filterBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
 func (fb *filterBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return fb.bc.SubscribeChainEvent(ch)
 }
+func (fb *filterBackend) SubscribeFinalizedHeadEvent(ch chan<- core.FinalizedHeadEvent) event.Subscription {
+	return fb.bc.SubscribeFinalizedHeadEvent(ch)
+}
 func (fb *filterBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return fb.bc.SubscribeRemovedLogsEvent(ch)
 }
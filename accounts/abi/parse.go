@@ -0,0 +1,151 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+package abi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/common/math"
+)
+
+// ParseValue converts the textual representation of a value into the Go type
+// expected when packing an argument of the given ABI type, allowing arguments
+// to be taken from command lines or configuration files.
+//
+// Numbers may be given in decimal or 0x-prefixed hexadecimal, bytes in hex and
+// arrays as JSON lists of such values (e.g. ["0x01", "0x02"] or [1, 2]).
+func ParseValue(t Type, input string) (interface{}, error) {
+	switch t.T {
+	case IntTy, UintTy:
+		return parseInteger(t, input)
+
+	case BoolTy:
+		return strconv.ParseBool(input)
+
+	case StringTy:
+		return input, nil
+
+	case AddressTy:
+		if !common.IsHexAddress(input) {
+			return nil, fmt.Errorf("invalid address %q", input)
+		}
+		return common.HexToAddress(input), nil
+
+	case BytesTy:
+		return hexutil.Decode(input)
+
+	case FixedBytesTy:
+		blob, err := hexutil.Decode(input)
+		if err != nil {
+			return nil, err
+		}
+		if len(blob) != t.Size {
+			return nil, fmt.Errorf("invalid %s: have %d bytes, want %d", t, len(blob), t.Size)
+		}
+		value := reflect.New(t.Type).Elem()
+		reflect.Copy(value, reflect.ValueOf(blob))
+		return value.Interface(), nil
+
+	case SliceTy, ArrayTy:
+		items, err := splitList(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", t, err)
+		}
+		var value reflect.Value
+		if t.T == SliceTy {
+			value = reflect.MakeSlice(t.Type, len(items), len(items))
+		} else {
+			if len(items) != t.Size {
+				return nil, fmt.Errorf("invalid %s: have %d items, want %d", t, len(items), t.Size)
+			}
+			value = reflect.New(t.Type).Elem()
+		}
+		for i, item := range items {
+			elem, err := ParseValue(*t.Elem, item)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %v", i, err)
+			}
+			value.Index(i).Set(reflect.ValueOf(elem))
+		}
+		return value.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported argument type %s", t)
+}
+
+// parseInteger parses a signed or unsigned integer of the given ABI type, making
+// sure it fits into its bit size.
+func parseInteger(t Type, input string) (interface{}, error) {
+	negative := strings.HasPrefix(input, "-")
+	if negative && t.T == UintTy {
+		return nil, fmt.Errorf("invalid %s %q: negative", t, input)
+	}
+	number, ok := math.ParseBig256(strings.TrimPrefix(input, "-"))
+	if !ok {
+		return nil, fmt.Errorf("invalid %s %q", t, input)
+	}
+	if negative {
+		number.Neg(number)
+	}
+	// Ensure the number is within the range of the type
+	limit := new(big.Int).Lsh(common.Big1, uint(t.Size))
+	if t.T == IntTy {
+		limit.Rsh(limit, 1)
+		if number.Cmp(limit) >= 0 || number.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, fmt.Errorf("invalid %s %q: out of range", t, input)
+		}
+	} else if number.Cmp(limit) >= 0 {
+		return nil, fmt.Errorf("invalid %s %q: out of range", t, input)
+	}
+	// Convert into the Go type the packer expects
+	if t.Type == big_t {
+		return number, nil
+	}
+	value := reflect.New(t.Type).Elem()
+	if t.T == IntTy {
+		value.SetInt(number.Int64())
+	} else {
+		value.SetUint(number.Uint64())
+	}
+	return value.Interface(), nil
+}
+
+// splitList splits a JSON list into the textual representation of its items,
+// keeping strings unquoted and numbers and nested lists verbatim.
+func splitList(input string) ([]string, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(input), &raw); err != nil {
+		return nil, err
+	}
+	items := make([]string, len(raw))
+	for i, item := range raw {
+		if bytes.HasPrefix(bytes.TrimSpace(item), []byte(`"`)) {
+			if err := json.Unmarshal(item, &items[i]); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		items[i] = string(bytes.TrimSpace(item))
+	}
+	return items, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+package abi
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+)
+
+// Tests that textual values are converted into the Go types the packer expects.
+func TestParseValue(t *testing.T) {
+	tests := []struct {
+		typ   string
+		input string
+		want  interface{}
+		fail  bool
+	}{
+		{typ: "uint8", input: "255", want: uint8(255)},
+		{typ: "uint8", input: "256", fail: true},
+		{typ: "uint8", input: "-1", fail: true},
+		{typ: "int8", input: "-128", want: int8(-128)},
+		{typ: "int8", input: "128", fail: true},
+		{typ: "uint256", input: "0x10", want: big.NewInt(16)},
+		{typ: "int256", input: "-5", want: big.NewInt(-5)},
+		{typ: "uint64", input: "hello", fail: true},
+		{typ: "bool", input: "true", want: true},
+		{typ: "string", input: "hello", want: "hello"},
+		{typ: "address", input: "0x0102030405060708090a0b0c0d0e0f1011121314", want: common.HexToAddress("0x0102030405060708090a0b0c0d0e0f1011121314")},
+		{typ: "address", input: "0x01", fail: true},
+		{typ: "bytes", input: "0x0102", want: []byte{1, 2}},
+		{typ: "bytes2", input: "0x0102", want: [2]byte{1, 2}},
+		{typ: "bytes2", input: "0x01", fail: true},
+		{typ: "uint16[]", input: `[1, "0x02"]`, want: []uint16{1, 2}},
+		{typ: "bool[2]", input: `[true, false]`, want: [2]bool{true, false}},
+		{typ: "bool[2]", input: `[true]`, fail: true},
+		{typ: "string[]", input: `["a", "b"]`, want: []string{"a", "b"}},
+		{typ: "uint8[][]", input: `[[1], [2, 3]]`, want: [][]uint8{{1}, {2, 3}}},
+	}
+	for i, tt := range tests {
+		typ, err := NewType(tt.typ)
+		if err != nil {
+			t.Fatalf("test %d: failed to create type %s: %v", i, tt.typ, err)
+		}
+		value, err := ParseValue(typ, tt.input)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: parsed invalid %s %q into %v", i, tt.typ, tt.input, value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to parse %s %q: %v", i, tt.typ, tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(value, tt.want) {
+			t.Errorf("test %d: value mismatch: have %#v, want %#v", i, value, tt.want)
+		}
+		// Make sure the value is accepted by the packer
+		if _, err := (Arguments{{Type: typ}}).Pack(value); err != nil {
+			t.Errorf("test %d: failed to pack %s: %v", i, tt.typ, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+package accounts
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/log"
+)
+
+// SignRequest is a signing operation awaiting the approval of the operator.
+type SignRequest struct {
+	ID          uint64             `json:"id"`
+	Account     common.Address     `json:"account"`
+	Transaction *types.Transaction `json:"transaction,omitempty"` // Transaction to sign, if any
+	Hash        *common.Hash       `json:"hash,omitempty"`        // Hash to sign, if not a transaction
+	Requested   time.Time          `json:"requested"`
+	Deadline    *time.Time         `json:"deadline,omitempty"` // Time when the timeout policy decides, if any
+}
+
+// pendingSign is a queued signing request with the channel its decision is
+// delivered on.
+type pendingSign struct {
+	req      *SignRequest
+	decision chan bool
+}
+
+// ApprovalQueue holds signing requests until the operator approves or rejects
+// them, or until a timeout policy decides in their stead.
+type ApprovalQueue struct {
+	timeout time.Duration // Time after which pending requests are decided automatically (0 = never)
+	approve bool          // Whether requests timing out are approved instead of rejected
+
+	pending map[uint64]*pendingSign
+	nonce   uint64
+	lock    sync.Mutex
+}
+
+// NewApprovalQueue creates a queue of signing requests awaiting approval. If the
+// timeout is non-zero, requests not decided upon in time are approved or rejected
+// according to the given policy.
+func NewApprovalQueue(timeout time.Duration, approveOnTimeout bool) *ApprovalQueue {
+	return &ApprovalQueue{
+		timeout: timeout,
+		approve: approveOnTimeout,
+		pending: make(map[uint64]*pendingSign),
+	}
+}
+
+// Wrap returns a wallet whose signing methods block until the operator approved
+// the request. All other methods are passed through to the wrapped wallet.
+func (q *ApprovalQueue) Wrap(wallet Wallet) Wallet {
+	return &approvalWallet{Wallet: wallet, queue: q}
+}
+
+// Pending retrieves the signing requests currently awaiting a decision, oldest
+// ones first.
+func (q *ApprovalQueue) Pending() []*SignRequest {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	reqs := make([]*SignRequest, 0, len(q.pending))
+	for _, pend := range q.pending {
+		reqs = append(reqs, pend.req)
+	}
+	sort.Slice(reqs, func(i, j int) bool { return reqs[i].ID < reqs[j].ID })
+	return reqs
+}
+
+// Approve allows a pending signing request to proceed.
+func (q *ApprovalQueue) Approve(id uint64) error {
+	return q.decide(id, true)
+}
+
+// Reject denies a pending signing request, failing it with ErrSignRejected.
+func (q *ApprovalQueue) Reject(id uint64) error {
+	return q.decide(id, false)
+}
+
+// decide delivers the decision about a pending request to its waiting signer.
+func (q *ApprovalQueue) decide(id uint64, approve bool) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	pend, ok := q.pending[id]
+	if !ok {
+		return ErrUnknownSignRequest
+	}
+	delete(q.pending, id)
+	pend.decision <- approve
+
+	log.Info("Signing request decided", "id", id, "account", pend.req.Account, "approved", approve)
+	return nil
+}
+
+// wait queues a signing request and blocks until it's decided upon.
+func (q *ApprovalQueue) wait(req *SignRequest) error {
+	pend := &pendingSign{req: req, decision: make(chan bool, 1)}
+
+	q.lock.Lock()
+	q.nonce++
+	req.ID, req.Requested = q.nonce, time.Now()
+
+	var timeout <-chan time.Time
+	if q.timeout > 0 {
+		deadline := req.Requested.Add(q.timeout)
+		req.Deadline = &deadline
+
+		timer := time.NewTimer(q.timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	q.pending[req.ID] = pend
+	q.lock.Unlock()
+
+	log.Info("Signing request awaiting approval", "id", req.ID, "account", req.Account)
+
+	var approved bool
+	select {
+	case approved = <-pend.decision:
+	case <-timeout:
+		// Timed out, but the operator might have decided concurrently
+		q.lock.Lock()
+		if _, ok := q.pending[req.ID]; ok {
+			delete(q.pending, req.ID)
+			pend.decision <- q.approve
+			log.Info("Signing request timed out", "id", req.ID, "account", req.Account, "approved", q.approve)
+		}
+		q.lock.Unlock()
+		approved = <-pend.decision
+	}
+	if !approved {
+		return ErrSignRejected
+	}
+	return nil
+}
+
+// approvalWallet is a wallet subjecting all its signing operations to operator
+// approval through an approval queue.
+type approvalWallet struct {
+	Wallet
+	queue *ApprovalQueue
+}
+
+// SignHash implements Wallet, awaiting approval before signing.
+func (w *approvalWallet) SignHash(account Account, hash []byte) ([]byte, error) {
+	if err := w.queue.wait(&SignRequest{Account: account.Address, Hash: hashRef(hash)}); err != nil {
+		return nil, err
+	}
+	return w.Wallet.SignHash(account, hash)
+}
+
+// SignTx implements Wallet, awaiting approval before signing.
+func (w *approvalWallet) SignTx(account Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if err := w.queue.wait(&SignRequest{Account: account.Address, Transaction: tx}); err != nil {
+		return nil, err
+	}
+	return w.Wallet.SignTx(account, tx, chainID)
+}
+
+// SignHashWithPassphrase implements Wallet, awaiting approval before signing.
+func (w *approvalWallet) SignHashWithPassphrase(account Account, passphrase string, hash []byte) ([]byte, error) {
+	if err := w.queue.wait(&SignRequest{Account: account.Address, Hash: hashRef(hash)}); err != nil {
+		return nil, err
+	}
+	return w.Wallet.SignHashWithPassphrase(account, passphrase, hash)
+}
+
+// SignTxWithPassphrase implements Wallet, awaiting approval before signing.
+func (w *approvalWallet) SignTxWithPassphrase(account Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if err := w.queue.wait(&SignRequest{Account: account.Address, Transaction: tx}); err != nil {
+		return nil, err
+	}
+	return w.Wallet.SignTxWithPassphrase(account, passphrase, tx, chainID)
+}
+
+// hashRef converts a hash to sign into a reference for reporting.
+func hashRef(hash []byte) *common.Hash {
+	ref := common.BytesToHash(hash)
+	return &ref
+}
@@ -0,0 +1,93 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+package accounts
+
+import (
+	"testing"
+	"time"
+)
+
+// signingWallet is a wallet stub signing hashes by echoing them back.
+type signingWallet struct {
+	Wallet
+}
+
+func (w *signingWallet) SignHash(account Account, hash []byte) ([]byte, error) {
+	return hash, nil
+}
+
+// waitPending waits until the queue holds the given number of requests.
+func waitPending(t *testing.T, queue *ApprovalQueue, count int) []*SignRequest {
+	for i := 0; i < 100; i++ {
+		if pending := queue.Pending(); len(pending) == count {
+			return pending
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("pending request count mismatch: have %d, want %d", len(queue.Pending()), count)
+	return nil
+}
+
+// Tests that signing requests block until approved or rejected by the operator.
+func TestApprovalQueueDecisions(t *testing.T) {
+	queue := NewApprovalQueue(0, false)
+	wallet := queue.Wrap(&signingWallet{})
+
+	errc := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func(i int) {
+			_, err := wallet.SignHash(Account{}, []byte{byte(i)})
+			errc <- err
+		}(i)
+	}
+	pending := waitPending(t, queue, 2)
+	if pending[0].ID >= pending[1].ID {
+		t.Errorf("pending requests not ordered: %d before %d", pending[0].ID, pending[1].ID)
+	}
+	if err := queue.Approve(pending[0].ID); err != nil {
+		t.Fatalf("failed to approve request: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("approved request failed: %v", err)
+	}
+	if err := queue.Reject(pending[1].ID); err != nil {
+		t.Fatalf("failed to reject request: %v", err)
+	}
+	if err := <-errc; err != ErrSignRejected {
+		t.Errorf("rejected request error mismatch: have %v, want %v", err, ErrSignRejected)
+	}
+	if err := queue.Approve(pending[1].ID); err != ErrUnknownSignRequest {
+		t.Errorf("decided request error mismatch: have %v, want %v", err, ErrUnknownSignRequest)
+	}
+}
+
+// Tests that requests not decided upon in time fall back to the timeout policy.
+func TestApprovalQueueTimeout(t *testing.T) {
+	for _, approve := range []bool{false, true} {
+		queue := NewApprovalQueue(50*time.Millisecond, approve)
+
+		_, err := queue.Wrap(&signingWallet{}).SignHash(Account{}, []byte{0x01})
+		if approve && err != nil {
+			t.Errorf("timed out request not approved: %v", err)
+		}
+		if !approve && err != ErrSignRejected {
+			t.Errorf("timed out request error mismatch: have %v, want %v", err, ErrSignRejected)
+		}
+		if pending := queue.Pending(); len(pending) != 0 {
+			t.Errorf("timed out request still pending")
+		}
+	}
+}
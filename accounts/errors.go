@@ -45,6 +45,14 @@ var ErrWalletAlreadyOpen = errors.New("wallet already open")
 // secodn time.
 var ErrWalletClosed = errors.New("wallet closed")
 
+// ErrSignRejected is returned if a signing request awaiting operator approval
+// was rejected, either explicitly or by the timeout policy.
+var ErrSignRejected = errors.New("signing request rejected")
+
+// ErrUnknownSignRequest is returned if an approval decision is made about a
+// signing request that is not (or no longer) pending.
+var ErrUnknownSignRequest = errors.New("unknown signing request")
+
 // AuthNeededError is returned by backends for signing requests where the user
 // is required to provide further authentication before signing can succeed.
 //
@@ -0,0 +1,85 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/usechain/go-usechain/accounts"
+	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/crypto"
+)
+
+// errInvalidHDKey is returned in the astronomically unlikely case that a BIP-32
+// derivation step produces a key outside of the secp256k1 group order.
+var errInvalidHDKey = errors.New("derived key is invalid, use a different index")
+
+// hdMasterKeySalt is the HMAC key used by BIP-32 to derive the master key from
+// a wallet seed.
+var hdMasterKeySalt = []byte("Bitcoin seed")
+
+// DeriveHDKey derives the secp256k1 private key found at the given BIP-32 path
+// of the HD wallet rooted at seed.
+func DeriveHDKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	curveN := crypto.S256().Params().N
+
+	digest := hmacSHA512(hdMasterKeySalt, seed)
+	key, chainCode := new(big.Int).SetBytes(digest[:32]), digest[32:]
+	if key.Sign() == 0 || key.Cmp(curveN) >= 0 {
+		return nil, errInvalidHDKey
+	}
+	for _, component := range path {
+		// Hardened children commit to the private key, normal ones to the public
+		var data []byte
+		if component >= 0x80000000 {
+			data = append([]byte{0x00}, math.PaddedBigBytes(key, 32)...)
+		} else {
+			priv, err := crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+			if err != nil {
+				return nil, err
+			}
+			data = crypto.CompressPubkey(&priv.PublicKey)
+		}
+		var index [4]byte
+		binary.BigEndian.PutUint32(index[:], component)
+
+		digest = hmacSHA512(chainCode, data, index[:])
+		tweak := new(big.Int).SetBytes(digest[:32])
+		if tweak.Cmp(curveN) >= 0 {
+			return nil, errInvalidHDKey
+		}
+		key = tweak.Add(tweak, key).Mod(tweak, curveN)
+		if key.Sign() == 0 {
+			return nil, errInvalidHDKey
+		}
+		chainCode = digest[32:]
+	}
+	return crypto.ToECDSA(math.PaddedBigBytes(key, 32))
+}
+
+// DeriveMnemonicKey stretches a BIP-39 mnemonic into a seed and derives the
+// private key found at the given BIP-44 path.
+func DeriveMnemonicKey(mnemonic, seedPassphrase string, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	seed, err := MnemonicToSeed(mnemonic, seedPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveHDKey(seed, path)
+}
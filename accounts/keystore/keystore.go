@@ -67,6 +67,7 @@ type KeyStore struct {
 	cache    *accountCache                // In-memory account cache over the filesystem storage
 	changes  chan struct{}                // Channel receiving change notifications from the cache
 	unlocked map[common.Address]*unlocked // Currently unlocked account (decrypted private keys)
+	memos    *MemoStore                   // Transaction memos and address labels kept with the keys
 
 	wallets     []accounts.Wallet       // Wallet wrappers around the individual key files
 	updateFeed  event.Feed              // Event feed to notify wallet additions/removals
@@ -84,7 +85,16 @@ type unlocked struct {
 // NewKeyStore creates a keystore for the given directory.
 func NewKeyStore(keydir string, scryptN, scryptP int) *KeyStore {
 	keydir, _ = filepath.Abs(keydir)
-	ks := &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP}}
+	ks := &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP, nil}}
+	ks.init(keydir)
+	return ks
+}
+
+// NewKeyStoreArgon2 creates a keystore for the given directory, encrypting new
+// keys with the argon2id KDF instead of scrypt.
+func NewKeyStoreArgon2(keydir string, params Argon2Params) *KeyStore {
+	keydir, _ = filepath.Abs(keydir)
+	ks := &KeyStore{storage: &keyStorePassphrase{keysDirPath: keydir, argon2: &params}}
 	ks.init(keydir)
 	return ks
 }
@@ -106,6 +116,7 @@ func (ks *KeyStore) init(keydir string) {
 	// Initialize the set of unlocked keys and the account cache
 	ks.unlocked = make(map[common.Address]*unlocked)
 	ks.cache, ks.changes = newAccountCache(keydir)
+	ks.memos = NewMemoStore(filepath.Join(keydir, memoFileName))
 
 	// TODO: In order for this finalizer to work, there must be no references
 	// to ks. addressCache doesn't keep a reference but unlocked keys do,
@@ -229,6 +240,12 @@ func (ks *KeyStore) HasAddress(addr common.Address) bool {
 	return ks.cache.hasAddress(addr)
 }
 
+// Memos returns the store of transaction memos and address labels kept in the
+// keystore directory.
+func (ks *KeyStore) Memos() *MemoStore {
+	return ks.memos
+}
+
 // Accounts returns all key files present in the directory.
 func (ks *KeyStore) Accounts() []accounts.Account {
 	return ks.cache.accounts()
@@ -441,13 +458,10 @@ func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string)
 	if err != nil {
 		return nil, err
 	}
-	var N, P int
 	if store, ok := ks.storage.(*keyStorePassphrase); ok {
-		N, P = store.scryptN, store.scryptP
-	} else {
-		N, P = StandardScryptN, StandardScryptP
+		return store.encryptKey(key, newPassphrase)
 	}
-	return EncryptKey(key, newPassphrase, N, P)
+	return EncryptKey(key, newPassphrase, StandardScryptN, StandardScryptP)
 }
 
 // Import stores the given encrypted JSON key into the key directory.
@@ -471,6 +485,18 @@ func (ks *KeyStore) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (acco
 	return ks.importKey(key, passphrase)
 }
 
+// ImportMnemonic derives the key found at path of the HD wallet described by a
+// BIP-39 mnemonic and optional seed passphrase, and stores it into the key
+// directory, encrypting it with the passphrase.
+func (ks *KeyStore) ImportMnemonic(mnemonic, seedPassphrase string, path accounts.DerivationPath, passphrase string) (accounts.Account, error) {
+	priv, err := DeriveMnemonicKey(mnemonic, seedPassphrase, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	defer zeroKey(priv)
+	return ks.ImportECDSA(priv, passphrase)
+}
+
 func (ks *KeyStore) importKey(key *Key, passphrase string) (accounts.Account, error) {
 	a := accounts.Account{Address: key.Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: ks.storage.JoinPath(keyFileName(key.Address))}}
 	if err := ks.storage.StoreKey(a.URL.Path, key, passphrase); err != nil {
@@ -41,12 +41,14 @@ import (
 	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/crypto/randentropy"
 	"github.com/pborman/uuid"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/scrypt"
 )
 
 const (
-	keyHeaderKDF = "scrypt"
+	keyHeaderKDF         = "scrypt"
+	keyHeaderKDFArgon2id = "argon2id"
 
 	// StandardScryptN is the N parameter of Scrypt encryption algorithm, using 256MB
 	// memory and taking approximately 1s CPU time on a modern processor.
@@ -64,14 +66,29 @@ const (
 	// memory and taking approximately 100ms CPU time on a modern processor.
 	LightScryptP = 6
 
+	// StandardArgon2Time, StandardArgon2Memory and StandardArgon2Threads are the
+	// parameters of the argon2id encryption algorithm, using 64MB memory and
+	// taking approximately 200ms CPU time on a modern processor.
+	StandardArgon2Time    = 3
+	StandardArgon2Memory  = 64 * 1024
+	StandardArgon2Threads = 4
+
 	scryptR     = 8
 	scryptDKLen = 32
 )
 
+// Argon2Params contains the tunable cost parameters of the argon2id KDF.
+type Argon2Params struct {
+	Time    uint32 // Number of passes over the memory
+	Memory  uint32 // Memory usage in KiB
+	Threads uint8  // Degree of parallelism
+}
+
 type keyStorePassphrase struct {
 	keysDirPath string
 	scryptN     int
 	scryptP     int
+	argon2      *Argon2Params // Argon2id parameters, nil if scrypt is used
 }
 
 /////////////////////////////////////greg add /////////////////
@@ -99,18 +116,33 @@ func (ks keyStorePassphrase) GetKey(addr common.Address, filename, auth string)
 
 // StoreKey generates a key, encrypts with 'auth' and stores in the given directory
 func StoreKey(dir, auth string, scryptN, scryptP int) (common.Address, error) {
-	_, a, err := storeNewKey(&keyStorePassphrase{dir, scryptN, scryptP}, crand.Reader, auth)
+	_, a, err := storeNewKey(&keyStorePassphrase{dir, scryptN, scryptP, nil}, crand.Reader, auth)
+	return a.Address, err
+}
+
+// StoreKeyArgon2 generates a key, encrypts with 'auth' using the argon2id KDF
+// and stores in the given directory.
+func StoreKeyArgon2(dir, auth string, params Argon2Params) (common.Address, error) {
+	_, a, err := storeNewKey(&keyStorePassphrase{keysDirPath: dir, argon2: &params}, crand.Reader, auth)
 	return a.Address, err
 }
 
 func (ks keyStorePassphrase) StoreKey(filename string, key *Key, auth string) error {
-	keyjson, err := EncryptKey(key, auth, ks.scryptN, ks.scryptP)
+	keyjson, err := ks.encryptKey(key, auth)
 	if err != nil {
 		return err
 	}
 	return writeKeyFile(filename, keyjson)
 }
 
+// encryptKey encrypts a key with the KDF the key store was configured with.
+func (ks keyStorePassphrase) encryptKey(key *Key, auth string) ([]byte, error) {
+	if ks.argon2 != nil {
+		return EncryptKeyArgon2(key, auth, *ks.argon2)
+	}
+	return EncryptKey(key, auth, ks.scryptN, ks.scryptP)
+}
+
 //////////////////////////////////////////////////////////////////////////
 var (
 	ErrABaddressFieldNotExist = errors.New("It seems that this account doesn't include a valid wanchain address field, please update your keyfile version")
@@ -174,12 +206,43 @@ func (ks keyStorePassphrase) JoinPath(filename string) string {
 // EncryptKey encrypts a key using the specified scrypt parameters into a json
 // blob that can be decrypted later on.
 func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
-	authArray := []byte(auth)
 	salt := randentropy.GetEntropyCSPRNG(32)
-	derivedKey, err := scrypt.Key(authArray, salt, scryptN, scryptR, scryptP, scryptDKLen)
+	derivedKey, err := scrypt.Key([]byte(auth), salt, scryptN, scryptR, scryptP, scryptDKLen)
 	if err != nil {
 		return nil, err
 	}
+	scryptParamsJSON := make(map[string]interface{}, 5)
+	scryptParamsJSON["n"] = scryptN
+	scryptParamsJSON["r"] = scryptR
+	scryptParamsJSON["p"] = scryptP
+	scryptParamsJSON["dklen"] = scryptDKLen
+	scryptParamsJSON["salt"] = hex.EncodeToString(salt)
+
+	return encryptKeyWithDerivedKey(key, derivedKey, keyHeaderKDF, scryptParamsJSON)
+}
+
+// EncryptKeyArgon2 encrypts a key using the specified argon2id parameters into
+// a json blob that can be decrypted later on.
+func EncryptKeyArgon2(key *Key, auth string, params Argon2Params) ([]byte, error) {
+	if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return nil, fmt.Errorf("invalid argon2id parameters: %+v", params)
+	}
+	salt := randentropy.GetEntropyCSPRNG(32)
+	derivedKey := argon2.IDKey([]byte(auth), salt, params.Time, params.Memory, params.Threads, scryptDKLen)
+
+	argonParamsJSON := make(map[string]interface{}, 5)
+	argonParamsJSON["t"] = params.Time
+	argonParamsJSON["m"] = params.Memory
+	argonParamsJSON["p"] = params.Threads
+	argonParamsJSON["dklen"] = scryptDKLen
+	argonParamsJSON["salt"] = hex.EncodeToString(salt)
+
+	return encryptKeyWithDerivedKey(key, derivedKey, keyHeaderKDFArgon2id, argonParamsJSON)
+}
+
+// encryptKeyWithDerivedKey seals the private key with a KDF derived key and
+// assembles the version 3 json key file.
+func encryptKeyWithDerivedKey(key *Key, derivedKey []byte, kdf string, kdfParams map[string]interface{}) ([]byte, error) {
 	encryptKey := derivedKey[:16]
 	keyBytes := math.PaddedBigBytes(key.PrivateKey.D, 32)
 
@@ -190,13 +253,6 @@ func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 	}
 	mac := crypto.Keccak256(derivedKey[16:32], cipherText)
 
-	scryptParamsJSON := make(map[string]interface{}, 5)
-	scryptParamsJSON["n"] = scryptN
-	scryptParamsJSON["r"] = scryptR
-	scryptParamsJSON["p"] = scryptP
-	scryptParamsJSON["dklen"] = scryptDKLen
-	scryptParamsJSON["salt"] = hex.EncodeToString(salt)
-
 	cipherParamsJSON := cipherparamsJSON{
 		IV: hex.EncodeToString(iv),
 	}
@@ -205,8 +261,8 @@ func EncryptKey(key *Key, auth string, scryptN, scryptP int) ([]byte, error) {
 		Cipher:       "aes-128-ctr",
 		CipherText:   hex.EncodeToString(cipherText),
 		CipherParams: cipherParamsJSON,
-		KDF:          keyHeaderKDF,
-		KDFParams:    scryptParamsJSON,
+		KDF:          kdf,
+		KDFParams:    kdfParams,
 		MAC:          hex.EncodeToString(mac),
 	}
 
@@ -348,6 +404,15 @@ func getKDFKey(cryptoJSON cryptoJSON, auth string) ([]byte, error) {
 		p := ensureInt(cryptoJSON.KDFParams["p"])
 		return scrypt.Key(authArray, salt, n, r, p, dkLen)
 
+	} else if cryptoJSON.KDF == keyHeaderKDFArgon2id {
+		t := ensureInt(cryptoJSON.KDFParams["t"])
+		m := ensureInt(cryptoJSON.KDFParams["m"])
+		p := ensureInt(cryptoJSON.KDFParams["p"])
+		if t <= 0 || m <= 0 || p <= 0 || p > 255 {
+			return nil, fmt.Errorf("Invalid argon2id parameters: t=%d m=%d p=%d", t, m, p)
+		}
+		return argon2.IDKey(authArray, salt, uint32(t), uint32(m), uint8(p), uint32(dkLen)), nil
+
 	} else if cryptoJSON.KDF == "pbkdf2" {
 		c := ensureInt(cryptoJSON.KDFParams["c"])
 		prf := cryptoJSON.KDFParams["prf"].(string)
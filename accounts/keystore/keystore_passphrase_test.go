@@ -17,6 +17,7 @@
 package keystore
 
 import (
+	crand "crypto/rand"
 	"io/ioutil"
 	"testing"
 
@@ -58,3 +59,31 @@ func TestKeyEncryptDecrypt(t *testing.T) {
 		}
 	}
 }
+
+// Tests that a key encrypted with argon2id can be decrypted and that the KDF
+// parameters are validated.
+func TestKeyEncryptDecryptArgon2(t *testing.T) {
+	key, err := newKey(crand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := Argon2Params{Time: 1, Memory: 64, Threads: 1}
+
+	keyjson, err := EncryptKeyArgon2(key, "foo", params)
+	if err != nil {
+		t.Fatalf("failed to encrypt key: %v", err)
+	}
+	if _, err := DecryptKey(keyjson, "bar"); err != ErrDecrypt {
+		t.Errorf("decryption with bad password error mismatch: have %v, want %v", err, ErrDecrypt)
+	}
+	dec, err := DecryptKey(keyjson, "foo")
+	if err != nil {
+		t.Fatalf("failed to decrypt key: %v", err)
+	}
+	if dec.Address != key.Address {
+		t.Errorf("key address mismatch: have %x, want %x", dec.Address, key.Address)
+	}
+	if _, err := EncryptKeyArgon2(key, "foo", Argon2Params{}); err == nil {
+		t.Errorf("encryption with empty argon2id parameters succeeded")
+	}
+}
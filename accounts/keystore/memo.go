@@ -0,0 +1,181 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+)
+
+// memoFileName is the name of the file within the keystore directory holding
+// the memos. It's hidden so that the account cache doesn't treat it as a key.
+const memoFileName = ".memos.json"
+
+// memoFile is the on-disk format of a memo store.
+type memoFile struct {
+	Transactions map[common.Hash]string    `json:"transactions,omitempty"`
+	Addresses    map[common.Address]string `json:"addresses,omitempty"`
+}
+
+// MemoStore keeps private annotations of transactions and labels of addresses,
+// persisted alongside the keys. Changes made to the file by other processes, or
+// brought in by syncing the keystore directory, are picked up on the next access.
+type MemoStore struct {
+	path    string
+	memos   memoFile
+	modTime time.Time // Modification time of the file when last loaded
+	lock    sync.Mutex
+}
+
+// NewMemoStore creates a memo store persisted to the given file.
+func NewMemoStore(path string) *MemoStore {
+	return &MemoStore{
+		path: path,
+		memos: memoFile{
+			Transactions: make(map[common.Hash]string),
+			Addresses:    make(map[common.Address]string),
+		},
+	}
+}
+
+// TransactionMemo retrieves the memo attached to a transaction, if any.
+func (ms *MemoStore) TransactionMemo(hash common.Hash) (string, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if err := ms.reload(); err != nil {
+		return "", err
+	}
+	return ms.memos.Transactions[hash], nil
+}
+
+// SetTransactionMemo attaches a memo to a transaction, replacing any previous
+// one. An empty memo removes the annotation.
+func (ms *MemoStore) SetTransactionMemo(hash common.Hash, memo string) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if err := ms.reload(); err != nil {
+		return err
+	}
+	if memo == "" {
+		delete(ms.memos.Transactions, hash)
+	} else {
+		ms.memos.Transactions[hash] = memo
+	}
+	return ms.save()
+}
+
+// AddressLabel retrieves the label given to an address, if any.
+func (ms *MemoStore) AddressLabel(addr common.Address) (string, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if err := ms.reload(); err != nil {
+		return "", err
+	}
+	return ms.memos.Addresses[addr], nil
+}
+
+// SetAddressLabel labels an address, replacing any previous label. An empty
+// label removes it.
+func (ms *MemoStore) SetAddressLabel(addr common.Address, label string) error {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if err := ms.reload(); err != nil {
+		return err
+	}
+	if label == "" {
+		delete(ms.memos.Addresses, addr)
+	} else {
+		ms.memos.Addresses[addr] = label
+	}
+	return ms.save()
+}
+
+// Memos returns a copy of all the transaction memos and address labels.
+func (ms *MemoStore) Memos() (map[common.Hash]string, map[common.Address]string, error) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	if err := ms.reload(); err != nil {
+		return nil, nil, err
+	}
+	txs := make(map[common.Hash]string, len(ms.memos.Transactions))
+	for hash, memo := range ms.memos.Transactions {
+		txs[hash] = memo
+	}
+	addrs := make(map[common.Address]string, len(ms.memos.Addresses))
+	for addr, label := range ms.memos.Addresses {
+		addrs[addr] = label
+	}
+	return txs, addrs, nil
+}
+
+// reload reads the memo file if it changed since it was last loaded. A missing
+// file is treated as an empty store.
+func (ms *MemoStore) reload() error {
+	fi, err := os.Stat(ms.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(ms.modTime) {
+		return nil
+	}
+	blob, err := ioutil.ReadFile(ms.path)
+	if err != nil {
+		return err
+	}
+	var memos memoFile
+	if err := json.Unmarshal(blob, &memos); err != nil {
+		return err
+	}
+	if memos.Transactions == nil {
+		memos.Transactions = make(map[common.Hash]string)
+	}
+	if memos.Addresses == nil {
+		memos.Addresses = make(map[common.Address]string)
+	}
+	ms.memos, ms.modTime = memos, fi.ModTime()
+	return nil
+}
+
+// save atomically writes the memos to disk.
+func (ms *MemoStore) save() error {
+	blob, err := json.MarshalIndent(ms.memos, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeKeyFile(ms.path, blob); err != nil {
+		return err
+	}
+	fi, err := os.Stat(ms.path)
+	if err != nil {
+		return err
+	}
+	ms.modTime = fi.ModTime()
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+)
+
+// Tests that memos are persisted, removed by empty updates and shared between
+// stores operating on the same file.
+func TestMemoStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "usechain-memos-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, memoFileName)
+
+	var (
+		hash = common.HexToHash("0x01")
+		addr = common.HexToAddress("0x02")
+	)
+	first := NewMemoStore(path)
+	if err := first.SetTransactionMemo(hash, "invoice #42"); err != nil {
+		t.Fatalf("failed to set memo: %v", err)
+	}
+	if err := first.SetAddressLabel(addr, "exchange"); err != nil {
+		t.Fatalf("failed to set label: %v", err)
+	}
+	// A fresh store must load the persisted memos
+	second := NewMemoStore(path)
+	if memo, err := second.TransactionMemo(hash); err != nil || memo != "invoice #42" {
+		t.Fatalf("memo mismatch: have %q (%v), want %q", memo, err, "invoice #42")
+	}
+	if label, err := second.AddressLabel(addr); err != nil || label != "exchange" {
+		t.Fatalf("label mismatch: have %q (%v), want %q", label, err, "exchange")
+	}
+	// Changes through one store must be picked up by the other
+	time.Sleep(10 * time.Millisecond) // ensure a distinct modification time
+	if err := second.SetTransactionMemo(hash, ""); err != nil {
+		t.Fatalf("failed to remove memo: %v", err)
+	}
+	txs, addrs, err := first.Memos()
+	if err != nil {
+		t.Fatalf("failed to list memos: %v", err)
+	}
+	if len(txs) != 0 {
+		t.Errorf("removed memo still present: %v", txs)
+	}
+	if addrs[addr] != "exchange" {
+		t.Errorf("label lost: have %v", addrs)
+	}
+}
+
+// Tests that the memo file in the keystore directory isn't mistaken for a key.
+func TestKeyStoreMemos(t *testing.T) {
+	dir, ks := tmpKeyStore(t, true)
+	defer os.RemoveAll(dir)
+
+	if err := ks.Memos().SetAddressLabel(common.HexToAddress("0x01"), "treasury"); err != nil {
+		t.Fatalf("failed to set label: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, memoFileName)); err != nil {
+		t.Fatalf("memo file missing: %v", err)
+	}
+	ks.cache.maybeReload()
+	if accs := ks.Accounts(); len(accs) != 0 {
+		t.Errorf("memo file listed as account: %v", accs)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/usechain/go-usechain/crypto/randentropy"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+var (
+	// ErrInvalidMnemonic is returned if a mnemonic contains unknown words, has an
+	// unsupported length or fails its checksum.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrInvalidEntropySize is returned if the requested mnemonic entropy is not
+	// a multiple of 32 bits in the [128, 256] range mandated by BIP-39.
+	ErrInvalidEntropySize = errors.New("entropy size must be a multiple of 32 in [128, 256]")
+)
+
+// DefaultMnemonicBits is the entropy size of generated mnemonics, resulting in a
+// 24 word sentence.
+const DefaultMnemonicBits = 256
+
+// NewMnemonic generates a fresh BIP-39 mnemonic sentence from bits of entropy
+// read from the system's secure random source.
+func NewMnemonic(bits int) (string, error) {
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return "", ErrInvalidEntropySize
+	}
+	return entropyToMnemonic(randentropy.GetEntropyCSPRNG(bits / 8))
+}
+
+// entropyToMnemonic converts raw entropy into a mnemonic sentence, appending the
+// checksum bits derived from its SHA256 hash.
+func entropyToMnemonic(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if bits%32 != 0 || bits < 128 || bits > 256 {
+		return "", ErrInvalidEntropySize
+	}
+	checksumBits := uint(bits / 32)
+	hash := sha256.Sum256(entropy)
+
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, checksumBits)
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	words := make([]string, (bits+int(checksumBits))/11)
+	mask := big.NewInt(2047)
+	for i := len(words) - 1; i >= 0; i-- {
+		words[i] = mnemonicWordList[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// mnemonicToEntropy validates a mnemonic sentence and returns the entropy it
+// encodes.
+func mnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words)%3 != 0 || len(words) < 12 || len(words) > 24 {
+		return nil, ErrInvalidMnemonic
+	}
+	data := new(big.Int)
+	for _, word := range words {
+		index, ok := mnemonicWordIndex[strings.ToLower(word)]
+		if !ok {
+			return nil, fmt.Errorf("%v: unknown word %q", ErrInvalidMnemonic, word)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(index)))
+	}
+	checksumBits := uint(len(words) * 11 / 33)
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<checksumBits-1)))
+	data.Rsh(data, checksumBits)
+
+	entropy := make([]byte, len(words)*11*32/33/8)
+	raw := data.Bytes()
+	copy(entropy[len(entropy)-len(raw):], raw)
+
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum.Int64() {
+		return nil, fmt.Errorf("%v: checksum mismatch", ErrInvalidMnemonic)
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic checks whether a sentence is a well formed BIP-39 mnemonic.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := mnemonicToEntropy(mnemonic)
+	return err
+}
+
+// MnemonicToSeed validates a mnemonic and stretches it, together with the
+// optional BIP-39 passphrase, into the 64 byte seed of an HD wallet.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	normalized := strings.ToLower(strings.Join(strings.Fields(mnemonic), " "))
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
+
+// hmacSHA512 is a helper to compute the HMAC-SHA512 digest used throughout the
+// BIP-32 key derivation.
+func hmacSHA512(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	for _, b := range data {
+		mac.Write(b)
+	}
+	return mac.Sum(nil)
+}
@@ -0,0 +1,139 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package keystore
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/usechain/go-usechain/accounts"
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/crypto"
+)
+
+// Tests the mnemonic encoding and seed derivation against the official BIP-39
+// test vectors.
+func TestMnemonicVectors(t *testing.T) {
+	tests := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			"00000000000000000000000000000000",
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			"ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			"zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			"dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	}
+	for i, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		mnemonic, err := entropyToMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("test %d: failed to encode entropy: %v", i, err)
+		}
+		if mnemonic != tt.mnemonic {
+			t.Errorf("test %d: mnemonic mismatch: have %q, want %q", i, mnemonic, tt.mnemonic)
+		}
+		decoded, err := mnemonicToEntropy(mnemonic)
+		if err != nil {
+			t.Fatalf("test %d: failed to decode mnemonic: %v", i, err)
+		}
+		if hex.EncodeToString(decoded) != tt.entropy {
+			t.Errorf("test %d: entropy mismatch: have %x, want %s", i, decoded, tt.entropy)
+		}
+		seed, err := MnemonicToSeed(mnemonic, "TREZOR")
+		if err != nil {
+			t.Fatalf("test %d: failed to derive seed: %v", i, err)
+		}
+		if hex.EncodeToString(seed) != tt.seed {
+			t.Errorf("test %d: seed mismatch: have %x, want %s", i, seed, tt.seed)
+		}
+	}
+}
+
+// Tests that generated mnemonics are valid and malformed ones are rejected.
+func TestMnemonicValidation(t *testing.T) {
+	for _, bits := range []int{128, 160, 192, 224, 256} {
+		mnemonic, err := NewMnemonic(bits)
+		if err != nil {
+			t.Fatalf("failed to generate %d bit mnemonic: %v", bits, err)
+		}
+		if err := ValidateMnemonic(mnemonic); err != nil {
+			t.Errorf("generated %d bit mnemonic invalid: %v", bits, err)
+		}
+	}
+	if _, err := NewMnemonic(100); err != ErrInvalidEntropySize {
+		t.Errorf("invalid entropy size error mismatch: have %v, want %v", err, ErrInvalidEntropySize)
+	}
+	invalid := []string{
+		"",
+		"abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon usechain",
+	}
+	for i, mnemonic := range invalid {
+		if err := ValidateMnemonic(mnemonic); err == nil {
+			t.Errorf("test %d: invalid mnemonic %q accepted", i, mnemonic)
+		}
+	}
+}
+
+// Tests the HD key derivation against the BIP-32 test vectors and a well known
+// BIP-44 account.
+func TestDeriveHDKey(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	tests := []struct {
+		path string
+		key  string
+	}{
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0'/1/2'", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+	}
+	for i, tt := range tests {
+		path, err := accounts.ParseDerivationPath(tt.path)
+		if err != nil {
+			t.Fatalf("test %d: failed to parse path: %v", i, err)
+		}
+		key, err := DeriveHDKey(seed, path)
+		if err != nil {
+			t.Fatalf("test %d: failed to derive key: %v", i, err)
+		}
+		if have := hex.EncodeToString(crypto.FromECDSA(key)); have != tt.key {
+			t.Errorf("test %d: key mismatch: have %s, want %s", i, have, tt.key)
+		}
+	}
+	mnemonic := "test test test test test test test test test test test junk"
+	key, err := DeriveMnemonicKey(mnemonic, "", accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatalf("failed to derive mnemonic key: %v", err)
+	}
+	want := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	if addr := crypto.PubkeyToAddress(key.PublicKey); addr != want {
+		t.Errorf("address mismatch: have %x, want %x", addr, want)
+	}
+}
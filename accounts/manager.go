@@ -34,6 +34,8 @@ type Manager struct {
 
 	feed event.Feed // Wallet feed notifying of arrivals/departures
 
+	approvals *ApprovalQueue // Queue of RPC signing requests awaiting approval (nil = disabled)
+
 	quit chan chan error
 	lock sync.RWMutex
 }
@@ -115,6 +117,18 @@ func (am *Manager) update() {
 	}
 }
 
+// SetApprovalQueue subjects signing requests made over RPC to operator approval
+// through the given queue. It must be called before any APIs are served.
+func (am *Manager) SetApprovalQueue(queue *ApprovalQueue) {
+	am.approvals = queue
+}
+
+// ApprovalQueue retrieves the queue of signing requests awaiting operator
+// approval, or nil if signing requests need no approval.
+func (am *Manager) ApprovalQueue() *ApprovalQueue {
+	return am.approvals
+}
+
 // Backends retrieves the backend(s) with the given type from the account manager.
 func (am *Manager) Backends(kind reflect.Type) []Backend {
 	return am.backends[kind]
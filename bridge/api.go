@@ -0,0 +1,112 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// maxDeposits is the maximum number of deposits returned by a single query.
+const maxDeposits = 256
+
+// PublicBridgeAPI exposes the external chain tracked by the bridge and lets
+// relayers submit headers and deposit proofs.
+type PublicBridgeAPI struct {
+	bridge *Bridge
+}
+
+// NewPublicBridgeAPI creates a new bridge API.
+func NewPublicBridgeAPI(bridge *Bridge) *PublicBridgeAPI {
+	return &PublicBridgeAPI{bridge}
+}
+
+// Head returns the head header of the canonical external chain.
+func (api *PublicBridgeAPI) Head() *Header {
+	return api.bridge.chain.Head()
+}
+
+// HeaderByNumber returns a canonical external header.
+func (api *PublicBridgeAPI) HeaderByNumber(number hexutil.Uint64) *Header {
+	return api.bridge.chain.HeaderByNumber(uint64(number))
+}
+
+// HeaderByHash returns a verified external header.
+func (api *PublicBridgeAPI) HeaderByHash(hash common.Hash) *Header {
+	return api.bridge.chain.HeaderByHash(hash)
+}
+
+// Signers returns the signers authorized at the head of the external chain.
+func (api *PublicBridgeAPI) Signers() []common.Address {
+	return api.bridge.chain.Signers()
+}
+
+// SubmitHeaders verifies and imports a batch of external headers, returning
+// the number of headers imported before the first invalid one.
+func (api *PublicBridgeAPI) SubmitHeaders(headers []*Header) (hexutil.Uint64, error) {
+	n, err := api.bridge.chain.Insert(headers)
+	return hexutil.Uint64(n), err
+}
+
+// SubmitDeposit verifies a deposit proof, returning the deposits it contains.
+func (api *PublicBridgeAPI) SubmitDeposit(proof DepositProof) ([]*Deposit, error) {
+	return api.bridge.SubmitDeposit(&proof)
+}
+
+// Deposit returns a verified deposit by its identifier.
+func (api *PublicBridgeAPI) Deposit(id common.Hash) *Deposit {
+	return api.bridge.Deposit(id)
+}
+
+// Deposits returns the verified deposits in the order of their verification,
+// starting with the given sequence number.
+func (api *PublicBridgeAPI) Deposits(from hexutil.Uint64, count hexutil.Uint64) []*Deposit {
+	if count > maxDeposits {
+		count = maxDeposits
+	}
+	return api.bridge.Deposits(uint64(from), int(count))
+}
+
+// NewDeposits sends a notification for every newly verified deposit.
+func (api *PublicBridgeAPI) NewDeposits(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		deposits := make(chan *Deposit)
+		sub := api.bridge.SubscribeDeposits(deposits)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case deposit := <-deposits:
+				notifier.Notify(rpcSub.ID, deposit)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
@@ -0,0 +1,225 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package bridge implements the relay side of the cross-chain bridge. It tracks
+// the header chain of an external proof-of-authority chain with light client
+// verification, checks the deposit proofs submitted by relayers against it and
+// publishes the verified deposits for the Usechain bridge contract.
+package bridge
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/event"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/node"
+	"github.com/usechain/go-usechain/p2p"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+var (
+	depositCountKey = []byte("DepositCount") // Number of deposits verified so far
+	depositPrefix   = []byte("d")            // depositPrefix + id -> deposit
+	sequencePrefix  = []byte("s")            // sequencePrefix + seq (uint64 big endian) -> id
+)
+
+// Bridge is the service tracking the external chain and verifying deposits
+// made on it.
+type Bridge struct {
+	config *Config
+	db     ethdb.Database
+	chain  *LightClient
+	relay  *relay // Follower of an external node, nil if not configured
+
+	depositFeed event.Feed
+	scope       event.SubscriptionScope
+	lock        sync.Mutex // Serialises the deposit bookkeeping
+}
+
+// New creates a bridge service from the given configuration.
+func New(ctx *node.ServiceContext, config *Config) (*Bridge, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	db, err := ctx.OpenDatabase("bridge", 16, 16)
+	if err != nil {
+		return nil, err
+	}
+	chain, err := NewLightClient(config, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	b := &Bridge{
+		config: config,
+		db:     db,
+		chain:  chain,
+	}
+	if config.Endpoint != "" {
+		b.relay = newRelay(config.Endpoint, time.Duration(config.PollInterval)*time.Second, chain)
+	}
+	return b, nil
+}
+
+// Protocols implements node.Service, returning no p2p protocols.
+func (b *Bridge) Protocols() []p2p.Protocol { return nil }
+
+// APIs implements node.Service, returning the RPC API endpoints of the bridge.
+func (b *Bridge) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "bridge",
+			Version:   "1.0",
+			Service:   NewPublicBridgeAPI(b),
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service, starting to follow the external node if one
+// is configured.
+func (b *Bridge) Start(server *p2p.Server) error {
+	if b.relay != nil {
+		b.relay.start()
+	}
+	head := b.chain.Head()
+	log.Info("Cross-chain bridge started", "number", head.Number, "hash", head.Hash(), "endpoint", b.config.Endpoint)
+	return nil
+}
+
+// Stop implements node.Service, terminating the bridge.
+func (b *Bridge) Stop() error {
+	if b.relay != nil {
+		b.relay.stop()
+	}
+	b.scope.Close()
+	b.db.Close()
+
+	log.Info("Cross-chain bridge stopped")
+	return nil
+}
+
+// Chain returns the light client tracking the external chain.
+func (b *Bridge) Chain() *LightClient {
+	return b.chain
+}
+
+// SubmitDeposit verifies a deposit proof against a sufficiently confirmed
+// canonical external block and returns the deposits it contains. Deposits seen
+// the first time are stored and announced to the subscribers.
+func (b *Bridge) SubmitDeposit(proof *DepositProof) ([]*Deposit, error) {
+	header := b.chain.HeaderByHash(proof.BlockHash)
+	if header == nil {
+		return nil, errUnknownBlock
+	}
+	confirmations, canonical := b.chain.Confirmations(proof.BlockHash)
+	if !canonical {
+		return nil, errNotCanonical
+	}
+	if confirmations < b.config.Confirmations {
+		return nil, errTooFewConfirmed
+	}
+	deposits, err := verifyDeposits(b.config, header, proof)
+	if err != nil {
+		return nil, err
+	}
+	b.lock.Lock()
+	var fresh []*Deposit
+	for _, deposit := range deposits {
+		if ok, _ := b.db.Has(append(depositPrefix, deposit.ID[:]...)); ok {
+			continue
+		}
+		if err := b.store(deposit); err != nil {
+			b.lock.Unlock()
+			return nil, err
+		}
+		fresh = append(fresh, deposit)
+	}
+	b.lock.Unlock()
+
+	for _, deposit := range fresh {
+		log.Info("Verified bridge deposit", "id", deposit.ID, "block", uint64(deposit.BlockNumber), "tx", uint64(deposit.TxIndex))
+		b.depositFeed.Send(deposit)
+	}
+	return deposits, nil
+}
+
+// Deposit retrieves a verified deposit by its identifier.
+func (b *Bridge) Deposit(id common.Hash) *Deposit {
+	blob, err := b.db.Get(append(depositPrefix, id[:]...))
+	if err != nil {
+		return nil
+	}
+	deposit := new(Deposit)
+	if err := json.Unmarshal(blob, deposit); err != nil {
+		log.Error("Invalid bridge deposit", "id", id, "err", err)
+		return nil
+	}
+	return deposit
+}
+
+// Deposits retrieves at most count verified deposits in the order of their
+// verification, starting with the given sequence number.
+func (b *Bridge) Deposits(from uint64, count int) []*Deposit {
+	var deposits []*Deposit
+	for seq := from; len(deposits) < count; seq++ {
+		id, err := b.db.Get(sequenceKey(seq))
+		if err != nil {
+			break
+		}
+		if deposit := b.Deposit(common.BytesToHash(id)); deposit != nil {
+			deposits = append(deposits, deposit)
+		}
+	}
+	return deposits
+}
+
+// SubscribeDeposits registers a subscription for newly verified deposits.
+func (b *Bridge) SubscribeDeposits(ch chan<- *Deposit) event.Subscription {
+	return b.scope.Track(b.depositFeed.Subscribe(ch))
+}
+
+// store saves a newly verified deposit under the next sequence number.
+func (b *Bridge) store(deposit *Deposit) error {
+	var seq uint64
+	if blob, _ := b.db.Get(depositCountKey); len(blob) == 8 {
+		seq = binary.BigEndian.Uint64(blob)
+	}
+	blob, err := json.Marshal(deposit)
+	if err != nil {
+		return err
+	}
+	var count [8]byte
+	binary.BigEndian.PutUint64(count[:], seq+1)
+
+	batch := b.db.NewBatch()
+	batch.Put(append(depositPrefix, deposit.ID[:]...), blob)
+	batch.Put(sequenceKey(seq), deposit.ID[:])
+	batch.Put(depositCountKey, count[:])
+	return batch.Write()
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, len(sequencePrefix)+8)
+	copy(key, sequencePrefix)
+	binary.BigEndian.PutUint64(key[len(sequencePrefix):], seq)
+	return key
+}
@@ -0,0 +1,128 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/trie"
+)
+
+// receiptProof builds the receipt trie of a block and proves the receipt at
+// the given index.
+func receiptProof(t *testing.T, receipts []*types.Receipt, index uint64) (common.Hash, []hexutil.Bytes) {
+	db, _ := ethdb.NewMemDatabase()
+	tr, _ := trie.New(common.Hash{}, trie.NewDatabase(db))
+	for i, receipt := range receipts {
+		key, _ := rlp.EncodeToBytes(uint64(i))
+		value, _ := rlp.EncodeToBytes(receipt)
+		tr.Update(key, value)
+	}
+	nodes, _ := ethdb.NewMemDatabase()
+	key, _ := rlp.EncodeToBytes(index)
+	if err := tr.Prove(key, 0, nodes); err != nil {
+		t.Fatalf("failed to prove receipt: %v", err)
+	}
+	var proof []hexutil.Bytes
+	for _, key := range nodes.Keys() {
+		node, _ := nodes.Get(key)
+		proof = append(proof, node)
+	}
+	return tr.Hash(), proof
+}
+
+// Tests that deposits are only accepted from confirmed canonical blocks with a
+// valid receipt proof, and announced only once.
+func TestSubmitDeposit(t *testing.T) {
+	tc := newTestChain(3, 100)
+	db := newTestDB()
+	chain, _ := NewLightClient(tc.config, db)
+	b := &Bridge{config: tc.config, db: db, chain: chain}
+
+	deposit := &types.Log{
+		Address: tc.config.Contract,
+		Topics:  []common.Hash{tc.config.DepositTopic, common.HexToHash("0x5e4de7")},
+		Data:    []byte{0x0d, 0xe9},
+	}
+	receipts := []*types.Receipt{
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{{Address: tc.config.Contract}}},
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{
+			{Address: common.HexToAddress("0x07e4"), Topics: []common.Hash{tc.config.DepositTopic}},
+			deposit,
+		}},
+	}
+	root, proof := receiptProof(t, receipts, 1)
+
+	block := tc.child(tc.config.Checkpoint, 1, func(h *Header) { h.ReceiptHash = root })
+	if _, err := chain.Insert([]*Header{block}); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	req := &DepositProof{BlockHash: block.Hash(), TxIndex: 1, Proof: proof}
+	if _, err := b.SubmitDeposit(req); err != errTooFewConfirmed {
+		t.Fatalf("error mismatch: have %v, want %v", err, errTooFewConfirmed)
+	}
+	if _, err := chain.Insert(tc.chain(block, 2)); err != nil {
+		t.Fatalf("failed to insert confirmations: %v", err)
+	}
+	deposits := make(chan *Deposit, 1)
+	sub := b.SubscribeDeposits(deposits)
+	defer sub.Unsubscribe()
+
+	verified, err := b.SubmitDeposit(req)
+	if err != nil {
+		t.Fatalf("failed to verify deposit: %v", err)
+	}
+	if len(verified) != 1 || verified[0].LogIndex != 1 || verified[0].BlockNumber != 1 || string(verified[0].Data) != string(deposit.Data) {
+		t.Fatalf("verified deposits mismatch: %+v", verified)
+	}
+	select {
+	case announced := <-deposits:
+		if announced.ID != verified[0].ID {
+			t.Fatalf("announced deposit mismatch: have %x, want %x", announced.ID, verified[0].ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("deposit not announced")
+	}
+	// Resubmissions are accepted, but not announced again
+	if _, err := b.SubmitDeposit(req); err != nil {
+		t.Fatalf("failed to verify deposit again: %v", err)
+	}
+	select {
+	case <-deposits:
+		t.Fatalf("deposit announced twice")
+	case <-time.After(50 * time.Millisecond):
+	}
+	if stored := b.Deposits(0, 10); len(stored) != 1 || stored[0].ID != verified[0].ID {
+		t.Fatalf("stored deposits mismatch: %+v", stored)
+	}
+	// Proofs of other receipts or with missing nodes are rejected
+	if _, err := b.SubmitDeposit(&DepositProof{BlockHash: block.Hash(), TxIndex: 0, Proof: proof}); err == nil {
+		t.Fatalf("proof accepted for another receipt")
+	}
+	if _, err := b.SubmitDeposit(&DepositProof{BlockHash: block.Hash(), TxIndex: 1, Proof: proof[1:]}); err == nil {
+		t.Fatalf("incomplete proof accepted")
+	}
+	if _, err := b.SubmitDeposit(&DepositProof{BlockHash: common.Hash{1}, TxIndex: 1, Proof: proof}); err != errUnknownBlock {
+		t.Fatalf("error mismatch: have %v, want %v", err, errUnknownBlock)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/usechain/go-usechain/common"
+)
+
+// Config describes the external chain tracked by the bridge and the deposit
+// events relayed from it.
+type Config struct {
+	// Checkpoint is a trusted checkpoint header of the external chain, the
+	// light client follows the chain from there on. Its extra-data must list
+	// the authorized signers, as every epoch transition header does.
+	Checkpoint *Header `json:"checkpoint"`
+
+	Period uint64 `json:"period"` // Minimum seconds between two external blocks
+	Epoch  uint64 `json:"epoch"`  // Number of blocks after which signer votes are reset
+
+	Contract      common.Address `json:"contract"`      // Bridge contract on the external chain
+	DepositTopic  common.Hash    `json:"depositTopic"`  // Signature hash of the deposit event
+	Confirmations uint64         `json:"confirmations"` // Blocks a deposit needs to be buried under
+
+	Endpoint     string `json:"endpoint,omitempty"`     // RPC endpoint of an external node to follow
+	PollInterval uint64 `json:"pollInterval,omitempty"` // Seconds between polls of the endpoint for new headers
+}
+
+// DefaultConfig contains the default settings of the optional fields.
+var DefaultConfig = Config{
+	Epoch:         30000,
+	Confirmations: 12,
+	PollInterval:  5,
+}
+
+// LoadConfig reads a bridge configuration from a JSON file, filling in the
+// defaults of the omitted fields.
+func LoadConfig(file string) (*Config, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config := DefaultConfig
+	if err := json.NewDecoder(f).Decode(&config); err != nil {
+		return nil, fmt.Errorf("invalid bridge config %s: %v", file, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// validate checks that the configuration is usable.
+func (c *Config) validate() error {
+	if c.Checkpoint == nil {
+		return errors.New("bridge checkpoint header missing")
+	}
+	if c.Epoch == 0 {
+		return errors.New("bridge epoch length must be positive")
+	}
+	if c.Checkpoint.Number.Uint64()%c.Epoch != 0 {
+		return fmt.Errorf("bridge checkpoint %d is not an epoch transition", c.Checkpoint.Number)
+	}
+	if _, err := checkpointSigners(c.Checkpoint); err != nil {
+		return err
+	}
+	if c.Contract == (common.Address{}) || c.DepositTopic == (common.Hash{}) {
+		return errors.New("bridge contract or deposit topic missing")
+	}
+	return nil
+}
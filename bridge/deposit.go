@@ -0,0 +1,111 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/trie"
+)
+
+var (
+	errUnknownBlock    = errors.New("unknown external block")
+	errNotCanonical    = errors.New("external block not canonical")
+	errMissingReceipt  = errors.New("receipt not included in the block")
+	errNoDeposits      = errors.New("receipt contains no deposit events")
+	errTooFewConfirmed = errors.New("external block not confirmed yet")
+)
+
+// DepositProof is submitted by relayers to prove that a transaction executed
+// on the external chain emitted deposit events. The proof holds the receipt
+// trie nodes on the path from the receipt root of the block to the receipt.
+type DepositProof struct {
+	BlockHash common.Hash     `json:"blockHash"`
+	TxIndex   hexutil.Uint64  `json:"txIndex"`
+	Proof     []hexutil.Bytes `json:"proof"`
+}
+
+// Deposit is a verified deposit event of the external bridge contract, ready to
+// be consumed by the Usechain bridge contract. The topics and data are the ones
+// emitted on the external chain.
+type Deposit struct {
+	ID          common.Hash    `json:"id"` // Unique identifier of the deposit
+	BlockHash   common.Hash    `json:"blockHash"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	TxIndex     hexutil.Uint64 `json:"txIndex"`
+	LogIndex    hexutil.Uint64 `json:"logIndex"` // Index of the log within the receipt
+	Topics      []common.Hash  `json:"topics"`
+	Data        hexutil.Bytes  `json:"data"`
+}
+
+// depositID derives the unique identifier of a deposit from its position on
+// the external chain.
+func depositID(block common.Hash, tx, index uint64) common.Hash {
+	var pos [16]byte
+	binary.BigEndian.PutUint64(pos[:8], tx)
+	binary.BigEndian.PutUint64(pos[8:], index)
+	return crypto.Keccak256Hash(block[:], pos[:])
+}
+
+// verifyDeposits checks the receipt proof against the receipt root of an
+// already verified header and extracts the deposit events of the receipt.
+func verifyDeposits(config *Config, header *Header, proof *DepositProof) ([]*Deposit, error) {
+	nodes := make([][]byte, len(proof.Proof))
+	for i, node := range proof.Proof {
+		nodes[i] = node
+	}
+	key, _ := rlp.EncodeToBytes(uint64(proof.TxIndex))
+	blob, err, _ := trie.VerifyProof(header.ReceiptHash, key, trie.NewProofReader(nodes))
+	if err != nil {
+		return nil, fmt.Errorf("invalid receipt proof: %v", err)
+	}
+	if blob == nil {
+		return nil, errMissingReceipt
+	}
+	receipt := new(types.Receipt)
+	if err := rlp.DecodeBytes(blob, receipt); err != nil {
+		return nil, fmt.Errorf("invalid receipt: %v", err)
+	}
+	// Logs of failed transactions are never part of the receipt, no need to
+	// check the status
+	var deposits []*Deposit
+	for i, log := range receipt.Logs {
+		if log.Address != config.Contract || len(log.Topics) == 0 || log.Topics[0] != config.DepositTopic {
+			continue
+		}
+		deposits = append(deposits, &Deposit{
+			ID:          depositID(proof.BlockHash, uint64(proof.TxIndex), uint64(i)),
+			BlockHash:   proof.BlockHash,
+			BlockNumber: hexutil.Uint64(header.Number.Uint64()),
+			TxIndex:     proof.TxIndex,
+			LogIndex:    hexutil.Uint64(i),
+			Topics:      log.Topics,
+			Data:        log.Data,
+		})
+	}
+	if len(deposits) == 0 {
+		return nil, errNoDeposits
+	}
+	return deposits, nil
+}
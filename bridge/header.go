@@ -0,0 +1,143 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/crypto/sha3"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+const (
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for signer vanity
+	extraSeal   = 65 // Fixed number of extra-data suffix bytes reserved for signer seal
+)
+
+var (
+	errMissingVanity    = errors.New("extra-data 32 byte vanity prefix missing")
+	errMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
+	errInvalidSigners   = errors.New("invalid signer list on checkpoint header")
+)
+
+//go:generate gencodec -type Header -field-override headerMarshaling -out gen_header_json.go
+
+// Header is a block header of the external chain. It follows the plain
+// Ethereum layout, which differs from the Usechain one, so the hashes and
+// seals computed over it match the ones of the external chain.
+type Header struct {
+	ParentHash  common.Hash      `json:"parentHash"       gencodec:"required"`
+	UncleHash   common.Hash      `json:"sha3Uncles"       gencodec:"required"`
+	Coinbase    common.Address   `json:"miner"            gencodec:"required"`
+	Root        common.Hash      `json:"stateRoot"        gencodec:"required"`
+	TxHash      common.Hash      `json:"transactionsRoot" gencodec:"required"`
+	ReceiptHash common.Hash      `json:"receiptsRoot"     gencodec:"required"`
+	Bloom       types.Bloom      `json:"logsBloom"        gencodec:"required"`
+	Difficulty  *big.Int         `json:"difficulty"       gencodec:"required"`
+	Number      *big.Int         `json:"number"           gencodec:"required"`
+	GasLimit    uint64           `json:"gasLimit"         gencodec:"required"`
+	GasUsed     uint64           `json:"gasUsed"          gencodec:"required"`
+	Time        *big.Int         `json:"timestamp"        gencodec:"required"`
+	Extra       []byte           `json:"extraData"        gencodec:"required"`
+	MixDigest   common.Hash      `json:"mixHash"          gencodec:"required"`
+	Nonce       types.BlockNonce `json:"nonce"            gencodec:"required"`
+}
+
+// field type overrides for gencodec
+type headerMarshaling struct {
+	Difficulty *hexutil.Big
+	Number     *hexutil.Big
+	GasLimit   hexutil.Uint64
+	GasUsed    hexutil.Uint64
+	Time       *hexutil.Big
+	Extra      hexutil.Bytes
+	Hash       common.Hash `json:"hash"` // adds call to Hash() in MarshalJSON
+}
+
+// Hash returns the keccak256 hash of the header's RLP encoding, the block hash
+// on the external chain.
+func (h *Header) Hash() common.Hash {
+	return rlpHash(h)
+}
+
+// sealHash returns the hash signed by the block producer, covering the entire
+// header apart from the seal at the end of the extra-data.
+func sealHash(h *Header) common.Hash {
+	return rlpHash([]interface{}{
+		h.ParentHash,
+		h.UncleHash,
+		h.Coinbase,
+		h.Root,
+		h.TxHash,
+		h.ReceiptHash,
+		h.Bloom,
+		h.Difficulty,
+		h.Number,
+		h.GasLimit,
+		h.GasUsed,
+		h.Time,
+		h.Extra[:len(h.Extra)-extraSeal],
+		h.MixDigest,
+		h.Nonce,
+	})
+}
+
+// signer recovers the address of the block producer from the seal.
+func signer(h *Header) (common.Address, error) {
+	if len(h.Extra) < extraVanity {
+		return common.Address{}, errMissingVanity
+	}
+	if len(h.Extra) < extraVanity+extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	pubkey, err := crypto.Ecrecover(sealHash(h).Bytes(), h.Extra[len(h.Extra)-extraSeal:])
+	if err != nil {
+		return common.Address{}, err
+	}
+	var addr common.Address
+	copy(addr[:], crypto.Keccak256(pubkey[1:])[12:])
+	return addr, nil
+}
+
+// checkpointSigners extracts the signer list from the extra-data of an epoch
+// transition header.
+func checkpointSigners(h *Header) ([]common.Address, error) {
+	if len(h.Extra) < extraVanity+extraSeal {
+		return nil, errMissingSignature
+	}
+	list := h.Extra[extraVanity : len(h.Extra)-extraSeal]
+	if len(list) == 0 || len(list)%common.AddressLength != 0 {
+		return nil, errInvalidSigners
+	}
+	signers := make([]common.Address, len(list)/common.AddressLength)
+	for i := range signers {
+		copy(signers[i][:], list[i*common.AddressLength:])
+	}
+	return signers, nil
+}
+
+func rlpHash(x interface{}) (h common.Hash) {
+	hw := sha3.NewKeccak256()
+	rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
@@ -0,0 +1,332 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/log"
+)
+
+// allowedFutureBlockTime is the maximum time an external header may be ahead
+// of the local clock.
+const allowedFutureBlockTime = 15 * time.Second
+
+var (
+	headKey         = []byte("LastHeader") // Hash of the current head header
+	recordPrefix    = []byte("h")          // recordPrefix + hash -> header record
+	canonicalPrefix = []byte("n")          // canonicalPrefix + num (uint64 big endian) -> hash
+
+	diffInTurn = big.NewInt(2) // Block difficulty for in-turn signatures
+	diffNoTurn = big.NewInt(1) // Block difficulty for out-of-turn signatures
+)
+
+var (
+	errUnknownParent      = errors.New("unknown parent")
+	errInvalidNumber      = errors.New("invalid block number")
+	errInvalidTimestamp   = errors.New("invalid timestamp")
+	errFutureBlock        = errors.New("block in the future")
+	errInvalidCheckpoint  = errors.New("invalid checkpoint header")
+	errExtraSigners       = errors.New("non-checkpoint header contains extra signer list")
+	errInvalidMixDigest   = errors.New("non-zero mix digest")
+	errInvalidUncleHash   = errors.New("non empty uncle hash")
+	errInvalidDifficulty  = errors.New("invalid difficulty")
+	errCheckpointMismatch = errors.New("bridge database initialized with a different checkpoint")
+)
+
+// record is a verified external header along with the data needed to verify
+// its descendants.
+type record struct {
+	Header *Header   `json:"header"`
+	TD     *big.Int  `json:"td"`
+	Snap   *snapshot `json:"snapshot"`
+}
+
+// LightClient follows the header chain of the external chain from a trusted
+// checkpoint, verifying every header with the proof-of-authority rules of the
+// chain and picking the heaviest fork as canonical.
+type LightClient struct {
+	config *Config
+	db     ethdb.Database
+	head   *record
+	lock   sync.RWMutex
+}
+
+// NewLightClient creates a light client following the external chain, either
+// resuming from the head stored in the database or from the checkpoint.
+func NewLightClient(config *Config, db ethdb.Database) (*LightClient, error) {
+	lc := &LightClient{
+		config: config,
+		db:     db,
+	}
+	checkpoint := config.Checkpoint
+	if hash, _ := db.Get(headKey); len(hash) > 0 {
+		if lc.canonicalHash(checkpoint.Number.Uint64()) != checkpoint.Hash() {
+			return nil, errCheckpointMismatch
+		}
+		if lc.head = lc.read(common.BytesToHash(hash)); lc.head == nil {
+			return nil, fmt.Errorf("missing head header %x", hash)
+		}
+		return lc, nil
+	}
+	signers, err := checkpointSigners(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	rec := &record{
+		Header: checkpoint,
+		TD:     new(big.Int).Set(checkpoint.Difficulty),
+		Snap:   newSnapshot(signers),
+	}
+	if err := lc.write(rec); err != nil {
+		return nil, err
+	}
+	if err := lc.setHead(rec); err != nil {
+		return nil, err
+	}
+	return lc, nil
+}
+
+// Head returns the head header of the canonical external chain.
+func (lc *LightClient) Head() *Header {
+	lc.lock.RLock()
+	defer lc.lock.RUnlock()
+
+	return lc.head.Header
+}
+
+// HeaderByHash retrieves a verified header, canonical or not.
+func (lc *LightClient) HeaderByHash(hash common.Hash) *Header {
+	if rec := lc.read(hash); rec != nil {
+		return rec.Header
+	}
+	return nil
+}
+
+// HeaderByNumber retrieves a canonical header.
+func (lc *LightClient) HeaderByNumber(number uint64) *Header {
+	lc.lock.RLock()
+	defer lc.lock.RUnlock()
+
+	hash := lc.canonicalHash(number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return lc.HeaderByHash(hash)
+}
+
+// Confirmations returns the number of canonical headers built on top of the
+// given one, or false if the header is not canonical.
+func (lc *LightClient) Confirmations(hash common.Hash) (uint64, bool) {
+	lc.lock.RLock()
+	defer lc.lock.RUnlock()
+
+	header := lc.HeaderByHash(hash)
+	if header == nil || lc.canonicalHash(header.Number.Uint64()) != hash {
+		return 0, false
+	}
+	return lc.head.Header.Number.Uint64() - header.Number.Uint64(), true
+}
+
+// Signers returns the signers authorized at the head of the chain.
+func (lc *LightClient) Signers() []common.Address {
+	lc.lock.RLock()
+	defer lc.lock.RUnlock()
+
+	return lc.head.Snap.signers()
+}
+
+// Has returns whether a header is already verified.
+func (lc *LightClient) Has(hash common.Hash) bool {
+	ok, _ := lc.db.Has(append(recordPrefix, hash[:]...))
+	return ok
+}
+
+// Insert verifies a batch of headers and adds them to the chain, the parent
+// of every header must be known or come earlier in the batch. It returns the
+// number of headers imported before the first failure.
+func (lc *LightClient) Insert(headers []*Header) (int, error) {
+	lc.lock.Lock()
+	defer lc.lock.Unlock()
+
+	for i, header := range headers {
+		hash := header.Hash()
+		if lc.Has(hash) {
+			continue
+		}
+		parent := lc.read(header.ParentHash)
+		if parent == nil {
+			return i, fmt.Errorf("header #%d [%x…]: %v", header.Number, hash[:4], errUnknownParent)
+		}
+		rec, err := lc.verify(parent, header)
+		if err != nil {
+			return i, fmt.Errorf("header #%d [%x…]: %v", header.Number, hash[:4], err)
+		}
+		if err := lc.write(rec); err != nil {
+			return i, err
+		}
+		if rec.TD.Cmp(lc.head.TD) > 0 {
+			if err := lc.setHead(rec); err != nil {
+				return i, err
+			}
+		}
+	}
+	return len(headers), nil
+}
+
+// verify checks a header against the proof-of-authority rules, returning the
+// record to store for it.
+func (lc *LightClient) verify(parent *record, header *Header) (*record, error) {
+	number := header.Number.Uint64()
+	if number != parent.Header.Number.Uint64()+1 {
+		return nil, errInvalidNumber
+	}
+	if header.Time.Uint64() < parent.Header.Time.Uint64()+lc.config.Period {
+		return nil, errInvalidTimestamp
+	}
+	if header.Time.Cmp(big.NewInt(time.Now().Add(allowedFutureBlockTime).Unix())) > 0 {
+		return nil, errFutureBlock
+	}
+	if len(header.Extra) < extraVanity {
+		return nil, errMissingVanity
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return nil, errMissingSignature
+	}
+	if number%lc.config.Epoch == 0 {
+		if header.Coinbase != (common.Address{}) || header.Nonce != (types.BlockNonce{}) {
+			return nil, errInvalidCheckpoint
+		}
+		signers, err := checkpointSigners(header)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(signers, parent.Snap.signers()) {
+			return nil, errInvalidSigners
+		}
+	} else if len(header.Extra) != extraVanity+extraSeal {
+		return nil, errExtraSigners
+	}
+	if header.MixDigest != (common.Hash{}) {
+		return nil, errInvalidMixDigest
+	}
+	if header.UncleHash != types.EmptyUncleHash {
+		return nil, errInvalidUncleHash
+	}
+	author, err := signer(header)
+	if err != nil {
+		return nil, err
+	}
+	want := diffNoTurn
+	if parent.Snap.inturn(number, author) {
+		want = diffInTurn
+	}
+	if header.Difficulty == nil || header.Difficulty.Cmp(want) != 0 {
+		return nil, errInvalidDifficulty
+	}
+	snap, err := parent.Snap.apply(header, author, lc.config.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	return &record{
+		Header: header,
+		TD:     new(big.Int).Add(parent.TD, header.Difficulty),
+		Snap:   snap,
+	}, nil
+}
+
+// setHead makes the record the head of the chain, rewriting the canonical
+// number index back to the common ancestor with the previous head.
+func (lc *LightClient) setHead(rec *record) error {
+	batch := lc.db.NewBatch()
+	for cur := rec; cur != nil; cur = lc.read(cur.Header.ParentHash) {
+		hash := cur.Header.Hash()
+		if lc.canonicalHash(cur.Header.Number.Uint64()) == hash {
+			break
+		}
+		if err := batch.Put(canonicalKey(cur.Header.Number.Uint64()), hash[:]); err != nil {
+			return err
+		}
+	}
+	hash := rec.Header.Hash()
+	if err := batch.Put(headKey, hash[:]); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	// Drop the canonical entries above the new head left by a longer old chain
+	if lc.head != nil {
+		for n := rec.Header.Number.Uint64() + 1; n <= lc.head.Header.Number.Uint64(); n++ {
+			if err := lc.db.Delete(canonicalKey(n)); err != nil {
+				return err
+			}
+		}
+	}
+	if lc.head != nil && lc.head.Header.Hash() != rec.Header.ParentHash {
+		log.Warn("External chain reorganised", "number", rec.Header.Number, "hash", hash, "old", lc.head.Header.Hash())
+	}
+	lc.head = rec
+	return nil
+}
+
+// read retrieves a header record from the database.
+func (lc *LightClient) read(hash common.Hash) *record {
+	blob, err := lc.db.Get(append(recordPrefix, hash[:]...))
+	if err != nil {
+		return nil
+	}
+	rec := new(record)
+	if err := json.Unmarshal(blob, rec); err != nil {
+		log.Error("Invalid bridge header record", "hash", hash, "err", err)
+		return nil
+	}
+	return rec
+}
+
+// write stores a header record in the database.
+func (lc *LightClient) write(rec *record) error {
+	blob, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	hash := rec.Header.Hash()
+	return lc.db.Put(append(recordPrefix, hash[:]...), blob)
+}
+
+// canonicalHash retrieves the hash of the canonical header at a height.
+func (lc *LightClient) canonicalHash(number uint64) common.Hash {
+	hash, _ := lc.db.Get(canonicalKey(number))
+	return common.BytesToHash(hash)
+}
+
+func canonicalKey(number uint64) []byte {
+	key := make([]byte, len(canonicalPrefix)+8)
+	copy(key, canonicalPrefix)
+	binary.BigEndian.PutUint64(key[len(canonicalPrefix):], number)
+	return key
+}
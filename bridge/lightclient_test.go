@@ -0,0 +1,271 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// testChain is a set of external chain signers producing headers.
+type testChain struct {
+	keys   []*ecdsa.PrivateKey // Signer keys, sorted by address
+	config *Config
+}
+
+func newTestChain(signers int, epoch uint64) *testChain {
+	keys := make([]*ecdsa.PrivateKey, signers)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(crypto.PubkeyToAddress(keys[i].PublicKey).Bytes(), crypto.PubkeyToAddress(keys[j].PublicKey).Bytes()) < 0
+	})
+	tc := &testChain{keys: keys}
+
+	checkpoint := &Header{
+		UncleHash:  types.EmptyUncleHash,
+		Difficulty: big.NewInt(1),
+		Number:     big.NewInt(0),
+		Time:       big.NewInt(time.Now().Add(-time.Hour).Unix()),
+		Extra:      tc.extra(true),
+	}
+	tc.config = &Config{
+		Checkpoint:    checkpoint,
+		Period:        1,
+		Epoch:         epoch,
+		Contract:      common.HexToAddress("0xb41d6e"),
+		DepositTopic:  crypto.Keccak256Hash([]byte("Deposit(address,uint256)")),
+		Confirmations: 2,
+	}
+	return tc
+}
+
+// extra assembles the extra-data of a header, listing the signers if needed.
+func (tc *testChain) extra(checkpoint bool) []byte {
+	extra := make([]byte, extraVanity)
+	if checkpoint {
+		for _, key := range tc.keys {
+			extra = append(extra, crypto.PubkeyToAddress(key.PublicKey).Bytes()...)
+		}
+	}
+	return append(extra, make([]byte, extraSeal)...)
+}
+
+// child creates a header on top of the parent signed by the given signer, the
+// modifier can change the header before sealing.
+func (tc *testChain) child(parent *Header, signer int, modify func(*Header)) *Header {
+	number := parent.Number.Uint64() + 1
+
+	header := &Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Difficulty: new(big.Int).Set(diffNoTurn),
+		Number:     new(big.Int).SetUint64(number),
+		Time:       new(big.Int).Add(parent.Time, big.NewInt(1)),
+		Extra:      tc.extra(number%tc.config.Epoch == 0),
+	}
+	if number%uint64(len(tc.keys)) == uint64(signer) {
+		header.Difficulty = new(big.Int).Set(diffInTurn)
+	}
+	if modify != nil {
+		modify(header)
+	}
+	sig, _ := crypto.Sign(sealHash(header).Bytes(), tc.keys[signer])
+	copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+	return header
+}
+
+// chain creates a number of in-turn headers on top of the parent.
+func (tc *testChain) chain(parent *Header, n int) []*Header {
+	headers := make([]*Header, n)
+	for i := range headers {
+		headers[i] = tc.child(parent, int((parent.Number.Uint64()+1)%uint64(len(tc.keys))), nil)
+		parent = headers[i]
+	}
+	return headers
+}
+
+func newTestDB() ethdb.Database {
+	db, _ := ethdb.NewMemDatabase()
+	return db
+}
+
+// Tests that valid headers are imported and indexed, crossing epoch transitions.
+func TestLightClientInsert(t *testing.T) {
+	tc := newTestChain(3, 4)
+	lc, err := NewLightClient(tc.config, newTestDB())
+	if err != nil {
+		t.Fatalf("failed to create light client: %v", err)
+	}
+	headers := tc.chain(tc.config.Checkpoint, 10)
+	if n, err := lc.Insert(headers); err != nil {
+		t.Fatalf("failed to insert headers: %d %v", n, err)
+	}
+	if head := lc.Head(); head.Hash() != headers[9].Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", head.Number, headers[9].Number)
+	}
+	if header := lc.HeaderByNumber(5); header == nil || header.Hash() != headers[4].Hash() {
+		t.Fatalf("canonical header #5 mismatch")
+	}
+	if confirmations, ok := lc.Confirmations(headers[4].Hash()); !ok || confirmations != 5 {
+		t.Fatalf("confirmations mismatch: have %d/%v, want 5/true", confirmations, ok)
+	}
+}
+
+// Tests that headers violating the proof-of-authority rules are rejected.
+func TestLightClientInvalid(t *testing.T) {
+	tc := newTestChain(3, 100)
+	outsider, _ := crypto.GenerateKey()
+
+	tests := []struct {
+		name   string
+		header func(parent *Header) *Header
+		err    error
+	}{
+		{"unauthorized", func(parent *Header) *Header {
+			other := &testChain{keys: []*ecdsa.PrivateKey{outsider}, config: tc.config}
+			return other.child(parent, 0, func(h *Header) { h.Difficulty = big.NewInt(1) })
+		}, errUnauthorized},
+		{"recent", func(parent *Header) *Header {
+			return tc.child(parent, int(parent.Number.Uint64()%3), nil)
+		}, errRecentSigner},
+		{"difficulty", func(parent *Header) *Header {
+			return tc.child(parent, int((parent.Number.Uint64()+1)%3), func(h *Header) { h.Difficulty = big.NewInt(1) })
+		}, errInvalidDifficulty},
+		{"timestamp", func(parent *Header) *Header {
+			return tc.child(parent, int((parent.Number.Uint64()+1)%3), func(h *Header) { h.Time = parent.Time })
+		}, errInvalidTimestamp},
+		{"future", func(parent *Header) *Header {
+			return tc.child(parent, int((parent.Number.Uint64()+1)%3), func(h *Header) { h.Time = big.NewInt(time.Now().Add(time.Hour).Unix()) })
+		}, errFutureBlock},
+		{"signers", func(parent *Header) *Header {
+			return tc.child(parent, int((parent.Number.Uint64()+1)%3), func(h *Header) { h.Extra = tc.extra(true) })
+		}, errExtraSigners},
+		{"vote", func(parent *Header) *Header {
+			return tc.child(parent, int((parent.Number.Uint64()+1)%3), func(h *Header) { h.Nonce = types.BlockNonce{1} })
+		}, errInvalidVote},
+	}
+	for _, tt := range tests {
+		lc, _ := NewLightClient(tc.config, newTestDB())
+		headers := tc.chain(tc.config.Checkpoint, 2)
+		if _, err := lc.Insert(headers); err != nil {
+			t.Fatalf("%s: failed to insert headers: %v", tt.name, err)
+		}
+		n, err := lc.Insert([]*Header{tt.header(headers[1])})
+		if n != 0 || err == nil || !strings.HasSuffix(err.Error(), tt.err.Error()) {
+			t.Errorf("%s: error mismatch: have %d/%v, want %v", tt.name, n, err, tt.err)
+		}
+	}
+}
+
+// Tests that the heaviest fork becomes canonical and the index follows it.
+func TestLightClientReorg(t *testing.T) {
+	tc := newTestChain(3, 100)
+	lc, _ := NewLightClient(tc.config, newTestDB())
+
+	shared := tc.chain(tc.config.Checkpoint, 3)
+	if _, err := lc.Insert(shared); err != nil {
+		t.Fatalf("failed to insert headers: %v", err)
+	}
+	// Block 4 is signed out of turn by the signer of block 2, so an in-turn
+	// sibling outweighs it
+	light := tc.child(shared[2], 2, nil)
+	heavy := tc.child(shared[2], 1, nil)
+
+	if _, err := lc.Insert([]*Header{light}); err != nil {
+		t.Fatalf("failed to insert light header: %v", err)
+	}
+	if _, ok := lc.Confirmations(light.Hash()); !ok {
+		t.Fatalf("light header not canonical")
+	}
+	if _, err := lc.Insert([]*Header{heavy}); err != nil {
+		t.Fatalf("failed to insert heavy header: %v", err)
+	}
+	if head := lc.Head(); head.Hash() != heavy.Hash() {
+		t.Fatalf("heavy fork not chosen")
+	}
+	if _, ok := lc.Confirmations(light.Hash()); ok {
+		t.Fatalf("reorged header still canonical")
+	}
+	if lc.HeaderByHash(light.Hash()) == nil {
+		t.Fatalf("reorged header forgotten")
+	}
+}
+
+// Tests that signer votes are tallied and checkpoints must list the result.
+func TestLightClientVoting(t *testing.T) {
+	tc := newTestChain(3, 4)
+	lc, _ := NewLightClient(tc.config, newTestDB())
+
+	candidate := common.HexToAddress("0xca4d1da7e")
+	vote := func(h *Header) {
+		h.Coinbase = candidate
+		copy(h.Nonce[:], nonceAuthVote)
+	}
+	first := tc.child(tc.config.Checkpoint, 1, vote)
+	second := tc.child(first, 2, vote)
+	if _, err := lc.Insert([]*Header{first, second}); err != nil {
+		t.Fatalf("failed to insert votes: %v", err)
+	}
+	if signers := lc.Signers(); len(signers) != 4 {
+		t.Fatalf("vote not passed: %d signers", len(signers))
+	}
+	// The checkpoint listing the old signer set is invalid now. Neither header
+	// is in turn with the new set.
+	outOfTurn := func(h *Header) { h.Difficulty = big.NewInt(1) }
+	third := tc.child(second, 0, outOfTurn)
+	if _, err := lc.Insert([]*Header{third}); err != nil {
+		t.Fatalf("failed to insert header: %v", err)
+	}
+	if _, err := lc.Insert([]*Header{tc.child(third, 1, outOfTurn)}); err == nil || !strings.HasSuffix(err.Error(), errInvalidSigners.Error()) {
+		t.Fatalf("stale checkpoint signer list accepted: %v", err)
+	}
+}
+
+// Tests that the light client resumes from the stored head, but refuses a
+// database initialized with a different checkpoint.
+func TestLightClientReopen(t *testing.T) {
+	tc := newTestChain(3, 100)
+	db := newTestDB()
+
+	lc, _ := NewLightClient(tc.config, db)
+	headers := tc.chain(tc.config.Checkpoint, 5)
+	if _, err := lc.Insert(headers); err != nil {
+		t.Fatalf("failed to insert headers: %v", err)
+	}
+	lc, err := NewLightClient(tc.config, db)
+	if err != nil {
+		t.Fatalf("failed to reopen light client: %v", err)
+	}
+	if head := lc.Head(); head.Hash() != headers[4].Hash() {
+		t.Fatalf("head not restored: have #%d, want #%d", head.Number, headers[4].Number)
+	}
+	if _, err := NewLightClient(newTestChain(3, 100).config, db); err != errCheckpointMismatch {
+		t.Fatalf("error mismatch: have %v, want %v", err, errCheckpointMismatch)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// relayBatch is the maximum number of headers requested from the external
+	// node in a single batch.
+	relayBatch = 192
+
+	// maxReorgDepth is the maximum number of headers walked back looking for
+	// the common ancestor with the external node.
+	maxReorgDepth = 1024
+
+	// relayTimeout is the maximum time a single request to the external node
+	// may take.
+	relayTimeout = 30 * time.Second
+)
+
+var errNoAncestor = errors.New("no common ancestor with the external node")
+
+// relay follows an external node over RPC, feeding its headers into the light
+// client. Nothing fetched is trusted, every header is verified on insertion.
+type relay struct {
+	endpoint string
+	interval time.Duration
+	chain    *LightClient
+	client   *rpc.Client
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// newRelay creates a relay following the external node at the endpoint.
+func newRelay(endpoint string, interval time.Duration, chain *LightClient) *relay {
+	return &relay{
+		endpoint: endpoint,
+		interval: interval,
+		chain:    chain,
+		quit:     make(chan struct{}),
+	}
+}
+
+// start begins polling the external node.
+func (r *relay) start() {
+	r.wg.Add(1)
+	go r.loop()
+}
+
+// stop terminates the polling and closes the connection.
+func (r *relay) stop() {
+	close(r.quit)
+	r.wg.Wait()
+
+	if r.client != nil {
+		r.client.Close()
+	}
+}
+
+// loop periodically syncs with the external node.
+func (r *relay) loop() {
+	defer r.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			if err := r.sync(); err != nil {
+				log.Warn("Failed to sync external chain", "endpoint", r.endpoint, "err", err)
+			}
+			timer.Reset(r.interval)
+		case <-r.quit:
+			return
+		}
+	}
+}
+
+// sync imports the headers the external node has but the light client does
+// not, from the common ancestor up to the latest one.
+func (r *relay) sync() error {
+	if r.client == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), relayTimeout)
+		client, err := rpc.DialContext(ctx, r.endpoint)
+		cancel()
+		if err != nil {
+			return err
+		}
+		r.client = client
+	}
+	latest, err := r.headerByNumber("latest")
+	if err != nil {
+		return err
+	}
+	if r.chain.Has(latest.Hash()) {
+		return nil
+	}
+	// Walk back from the lower of the two heads to the common ancestor
+	number := r.chain.Head().Number.Uint64()
+	if remote := latest.Number.Uint64(); remote < number {
+		number = remote
+	}
+	checkpoint := r.chain.config.Checkpoint.Number.Uint64()
+	for depth := 0; ; depth++ {
+		if number <= checkpoint {
+			number = checkpoint
+			break
+		}
+		if depth == maxReorgDepth {
+			return errNoAncestor
+		}
+		header, err := r.headerByNumber(hexutil.EncodeUint64(number))
+		if err != nil {
+			return err
+		}
+		if r.chain.Has(header.Hash()) {
+			break
+		}
+		number--
+	}
+	// Import everything above the ancestor in batches
+	for number < latest.Number.Uint64() {
+		count := latest.Number.Uint64() - number
+		if count > relayBatch {
+			count = relayBatch
+		}
+		headers, err := r.headersByNumber(number+1, int(count))
+		if err != nil {
+			return err
+		}
+		if _, err := r.chain.Insert(headers); err != nil {
+			return err
+		}
+		number += uint64(len(headers))
+	}
+	head := r.chain.Head()
+	log.Debug("Synced external chain", "number", head.Number, "hash", head.Hash())
+	return nil
+}
+
+// headerByNumber retrieves a single header from the external node.
+func (r *relay) headerByNumber(number string) (*Header, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), relayTimeout)
+	defer cancel()
+
+	var header *Header
+	if err := r.client.CallContext(ctx, &header, "eth_getBlockByNumber", number, false); err != nil {
+		return nil, err
+	}
+	if header == nil {
+		return nil, fmt.Errorf("external block %s not found", number)
+	}
+	return header, nil
+}
+
+// headersByNumber retrieves a batch of consecutive headers from the external
+// node.
+func (r *relay) headersByNumber(from uint64, count int) ([]*Header, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), relayTimeout)
+	defer cancel()
+
+	headers := make([]*Header, count)
+	reqs := make([]rpc.BatchElem, count)
+	for i := range reqs {
+		reqs[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []interface{}{hexutil.EncodeUint64(from + uint64(i)), false},
+			Result: &headers[i],
+		}
+	}
+	if err := r.client.BatchCallContext(ctx, reqs); err != nil {
+		return nil, err
+	}
+	for i, req := range reqs {
+		if req.Error != nil {
+			return nil, req.Error
+		}
+		if headers[i] == nil {
+			return nil, fmt.Errorf("external block %d not found", from+uint64(i))
+		}
+	}
+	return headers, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package bridge
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+)
+
+var (
+	nonceAuthVote = hexutil.MustDecode("0xffffffffffffffff") // Magic nonce number to vote on adding a new signer
+	nonceDropVote = hexutil.MustDecode("0x0000000000000000") // Magic nonce number to vote on removing a signer
+
+	errUnauthorized = errors.New("unauthorized signer")
+	errRecentSigner = errors.New("signer signed recently")
+	errInvalidVote  = errors.New("vote nonce not 0x00..0 or 0xff..f")
+)
+
+// vote is a single vote an authorized signer cast to modify the signer list.
+type vote struct {
+	Signer    common.Address `json:"signer"`
+	Address   common.Address `json:"address"`
+	Authorize bool           `json:"authorize"`
+}
+
+// tally is a simple vote tally to keep the current score of votes.
+type tally struct {
+	Authorize bool `json:"authorize"`
+	Votes     int  `json:"votes"`
+}
+
+// snapshot is the state of the signer voting of the external chain at a given
+// header, replicating the rules of the clique engine.
+type snapshot struct {
+	Signers map[common.Address]struct{} `json:"signers"`
+	Recents map[uint64]common.Address   `json:"recents"`
+	Votes   []*vote                     `json:"votes"`
+	Tally   map[common.Address]tally    `json:"tally"`
+}
+
+// newSnapshot creates a snapshot with the given authorized signers.
+func newSnapshot(signers []common.Address) *snapshot {
+	snap := &snapshot{
+		Signers: make(map[common.Address]struct{}),
+		Recents: make(map[uint64]common.Address),
+		Tally:   make(map[common.Address]tally),
+	}
+	for _, signer := range signers {
+		snap.Signers[signer] = struct{}{}
+	}
+	return snap
+}
+
+// copy creates a deep copy of the snapshot.
+func (s *snapshot) copy() *snapshot {
+	cpy := &snapshot{
+		Signers: make(map[common.Address]struct{}, len(s.Signers)),
+		Recents: make(map[uint64]common.Address, len(s.Recents)),
+		Votes:   make([]*vote, len(s.Votes)),
+		Tally:   make(map[common.Address]tally, len(s.Tally)),
+	}
+	for signer := range s.Signers {
+		cpy.Signers[signer] = struct{}{}
+	}
+	for block, signer := range s.Recents {
+		cpy.Recents[block] = signer
+	}
+	for address, t := range s.Tally {
+		cpy.Tally[address] = t
+	}
+	copy(cpy.Votes, s.Votes)
+	return cpy
+}
+
+// signers retrieves the list of authorized signers in ascending order.
+func (s *snapshot) signers() []common.Address {
+	signers := make([]common.Address, 0, len(s.Signers))
+	for signer := range s.Signers {
+		signers = append(signers, signer)
+	}
+	sort.Slice(signers, func(i, j int) bool {
+		return bytes.Compare(signers[i][:], signers[j][:]) < 0
+	})
+	return signers
+}
+
+// inturn returns whether a signer is the expected producer of a block.
+func (s *snapshot) inturn(number uint64, signer common.Address) bool {
+	signers := s.signers()
+	for i, addr := range signers {
+		if addr == signer {
+			return number%uint64(len(signers)) == uint64(i)
+		}
+	}
+	return false
+}
+
+// apply creates the snapshot following a header produced by the given signer
+// on top of the one the receiver is the snapshot of.
+func (s *snapshot) apply(header *Header, signer common.Address, epoch uint64) (*snapshot, error) {
+	snap := s.copy()
+
+	number := header.Number.Uint64()
+	if number%epoch == 0 {
+		snap.Votes = nil
+		snap.Tally = make(map[common.Address]tally)
+	}
+	// Delete the oldest signer from the recent list to allow it signing again
+	if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+		delete(snap.Recents, number-limit)
+	}
+	if _, ok := snap.Signers[signer]; !ok {
+		return nil, errUnauthorized
+	}
+	for _, recent := range snap.Recents {
+		if recent == signer {
+			return nil, errRecentSigner
+		}
+	}
+	snap.Recents[number] = signer
+
+	// Checkpoint headers can't carry votes
+	if number%epoch == 0 {
+		return snap, nil
+	}
+	var authorize bool
+	switch {
+	case bytes.Equal(header.Nonce[:], nonceAuthVote):
+		authorize = true
+	case bytes.Equal(header.Nonce[:], nonceDropVote):
+		authorize = false
+	default:
+		return nil, errInvalidVote
+	}
+	// Discard any previous vote from the signer on the same account
+	for i, v := range snap.Votes {
+		if v.Signer == signer && v.Address == header.Coinbase {
+			snap.uncast(v.Address, v.Authorize)
+			snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+			break
+		}
+	}
+	if snap.cast(header.Coinbase, authorize) {
+		snap.Votes = append(snap.Votes, &vote{Signer: signer, Address: header.Coinbase, Authorize: authorize})
+	}
+	// If the vote passed, update the list of signers
+	if t := snap.Tally[header.Coinbase]; t.Votes > len(snap.Signers)/2 {
+		if t.Authorize {
+			snap.Signers[header.Coinbase] = struct{}{}
+		} else {
+			delete(snap.Signers, header.Coinbase)
+
+			// Signer list shrunk, delete any leftover recent caches
+			if limit := uint64(len(snap.Signers)/2 + 1); number >= limit {
+				delete(snap.Recents, number-limit)
+			}
+			// Discard any previous votes the deauthorized signer cast
+			for i := 0; i < len(snap.Votes); i++ {
+				if snap.Votes[i].Signer == header.Coinbase {
+					snap.uncast(snap.Votes[i].Address, snap.Votes[i].Authorize)
+					snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+					i--
+				}
+			}
+		}
+		// Discard any previous votes around the just changed account
+		for i := 0; i < len(snap.Votes); i++ {
+			if snap.Votes[i].Address == header.Coinbase {
+				snap.Votes = append(snap.Votes[:i], snap.Votes[i+1:]...)
+				i--
+			}
+		}
+		delete(snap.Tally, header.Coinbase)
+	}
+	return snap, nil
+}
+
+// cast adds a new vote into the tally, returning whether it was meaningful.
+func (s *snapshot) cast(address common.Address, authorize bool) bool {
+	// Ensure the vote is meaningful
+	if _, signer := s.Signers[address]; (signer && authorize) || (!signer && !authorize) {
+		return false
+	}
+	if old, ok := s.Tally[address]; ok {
+		old.Votes++
+		s.Tally[address] = old
+	} else {
+		s.Tally[address] = tally{Authorize: authorize, Votes: 1}
+	}
+	return true
+}
+
+// uncast removes a previously cast vote from the tally.
+func (s *snapshot) uncast(address common.Address, authorize bool) {
+	t, ok := s.Tally[address]
+	if !ok || t.Authorize != authorize {
+		return
+	}
+	if t.Votes > 1 {
+		t.Votes--
+		s.Tally[address] = t
+	} else {
+		delete(s.Tally, address)
+	}
+}
@@ -49,6 +49,7 @@ var (
 // returned from docker inspect, parsed into a form easily usable by puppeth.
 type containerInfos struct {
 	running bool              // Flag whether the container is running currently
+	image   string            // Digest of the image the container was created from
 	envvars map[string]string // Collection of environmental variables set on the container
 	portmap map[string]int    // Port mapping from internal port/proto combos to host binds
 	volumes map[string]string // Volume mount points from container to host directories
@@ -63,6 +64,7 @@ func inspectContainer(client *sshClient, container string) (*containerInfos, err
 	}
 	// If yes, extract various configuration options
 	type inspection struct {
+		Image string
 		State struct {
 			Running bool
 		}
@@ -86,6 +88,7 @@ func inspectContainer(client *sshClient, container string) (*containerInfos, err
 	// Infos retrieved, parse the above into something meaningful
 	infos := &containerInfos{
 		running: inspect.State.Running,
+		image:   inspect.Image,
 		envvars: make(map[string]string),
 		portmap: make(map[string]int),
 		volumes: make(map[string]string),
@@ -0,0 +1,133 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrServiceManaged is returned if a container is requested to be adopted into
+// a service slot that puppeth already manages on the same machine.
+var ErrServiceManaged = errors.New("service already managed")
+
+// adoptableServices is the list of service kinds puppeth knows how to manage,
+// in the order they are offered to the user during adoption.
+var adoptableServices = []string{"nginx", "ethstats", "bootnode", "sealnode", "explorer", "wallet", "faucet", "dashboard"}
+
+// containerSummary is a short description of a docker container running on a
+// remote machine, used to offer unmanaged containers for adoption.
+type containerSummary struct {
+	name    string // Name of the container as known by docker
+	image   string // Image the container was created from
+	command string // Command line the container was started with
+	running bool   // Flag whether the container is running currently
+}
+
+// listContainers retrieves all the containers on a remote machine that are not
+// yet part of puppeth's service model for the specified network.
+func listContainers(client *sshClient, network string) ([]*containerSummary, error) {
+	out, err := client.Run("docker ps -a --no-trunc --format '{{.Names}}\t{{.Image}}\t{{.Status}}\t{{.Command}}'")
+	if err != nil {
+		return nil, err
+	}
+	managed := make(map[string]bool)
+	for _, service := range adoptableServices {
+		managed[fmt.Sprintf("%s_%s_1", network, service)] = true
+	}
+	var containers []*containerSummary
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		parts := strings.SplitN(line, "\t", 4)
+		if len(parts) != 4 || managed[parts[0]] {
+			continue
+		}
+		containers = append(containers, &containerSummary{
+			name:    parts[0],
+			image:   parts[1],
+			command: strings.Trim(parts[3], "\""),
+			running: strings.HasPrefix(parts[2], "Up"),
+		})
+	}
+	return containers, nil
+}
+
+// guessService tries to figure out which puppeth service a hand-built container
+// corresponds to, based on the image it runs and the command it was started with.
+// An empty string is returned if the container cannot be classified.
+func guessService(container *containerSummary) string {
+	image, command := strings.ToLower(container.image), strings.ToLower(container.command)
+
+	for _, service := range []string{"nginx", "ethstats", "explorer", "wallet", "faucet", "dashboard"} {
+		if strings.Contains(image, service) {
+			return service
+		}
+	}
+	for _, binary := range []string{"used", "geth", "client-go"} {
+		if strings.Contains(image, binary) || strings.Contains(command, binary) {
+			if strings.Contains(command, "--mine") || strings.Contains(command, "--unlock") {
+				return "sealnode"
+			}
+			return "bootnode"
+		}
+	}
+	return ""
+}
+
+// adoptContainer takes over the management of an existing container by renaming
+// it into puppeth's naming scheme for the given network and service. The running
+// container is not touched otherwise, so no redeployment takes place.
+func adoptContainer(client *sshClient, network string, container string, service string) ([]byte, error) {
+	target := fmt.Sprintf("%s_%s_1", network, service)
+	if _, err := inspectContainer(client, target); err == nil {
+		return nil, ErrServiceManaged
+	}
+	return client.Run(fmt.Sprintf("docker rename %s %s", container, target))
+}
+
+// releaseContainer reverts an adoption, renaming a managed service container back
+// to its original name.
+func releaseContainer(client *sshClient, network string, service string, container string) ([]byte, error) {
+	return client.Run(fmt.Sprintf("docker rename %s_%s_1 %s", network, service, container))
+}
+
+// checkService runs the health-check of a particular service kind, reporting
+// whether puppeth is able to interpret and monitor it.
+func checkService(client *sshClient, network string, service string) error {
+	var err error
+	switch service {
+	case "nginx":
+		_, err = checkNginx(client, network)
+	case "ethstats":
+		_, err = checkEthstats(client, network)
+	case "bootnode":
+		_, err = checkNode(client, network, true)
+	case "sealnode":
+		_, err = checkNode(client, network, false)
+	case "explorer":
+		_, err = checkExplorer(client, network)
+	case "wallet":
+		_, err = checkWallet(client, network)
+	case "faucet":
+		_, err = checkFaucet(client, network)
+	case "dashboard":
+		_, err = checkDashboard(client, network)
+	default:
+		err = ErrServiceUnknown
+	}
+	return err
+}
@@ -0,0 +1,125 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
+)
+
+// ErrSyncTimeout is returned if a redeployed node did not finish syncing with the
+// network within the allowed time.
+var ErrSyncTimeout = errors.New("node sync timed out")
+
+// serviceHealth is the result of a health-check against a single managed service
+// container, compared to what puppeth last deployed onto the machine.
+type serviceHealth struct {
+	service string      // Kind of service inspected (bootnode, sealnode, ...)
+	running bool        // Flag whether the container is running currently
+	image   string      // Digest of the image the container was created from
+	genesis common.Hash // Genesis hash the node was initialized with (nodes only)
+	drift   []string    // Human readable deviations from the cached configuration
+}
+
+// healthy returns whether the service is running exactly as deployed.
+func (h *serviceHealth) healthy() bool {
+	return h.running && len(h.drift) == 0
+}
+
+// node returns whether the service is a boot or seal node.
+func (h *serviceHealth) node() bool {
+	return h.service == "bootnode" || h.service == "sealnode"
+}
+
+// checkHealth inspects a managed service container on a remote machine and
+// compares its image digest and - for nodes - its genesis block against the
+// expected ones. Empty expectations are not verified.
+func checkHealth(client *sshClient, network string, service string, image string, genesis common.Hash) (*serviceHealth, error) {
+	container := fmt.Sprintf("%s_%s_1", network, service)
+
+	infos, err := inspectContainer(client, container)
+	if err != nil {
+		return nil, err
+	}
+	health := &serviceHealth{
+		service: service,
+		running: infos.running,
+		image:   infos.image,
+	}
+	if image != "" && infos.image != image {
+		health.drift = append(health.drift, fmt.Sprintf("image %s, deployed %s", shortDigest(infos.image), shortDigest(image)))
+	}
+	// Nodes are only usable if they run the network's genesis, verify it
+	if health.node() && health.running {
+		out, err := client.Run(fmt.Sprintf("docker exec %s cat /genesis.json", container))
+		if err != nil {
+			return nil, ErrServiceUnreachable
+		}
+		spec := new(core.Genesis)
+		if err := json.Unmarshal(bytes.TrimSpace(out), spec); err != nil {
+			return nil, err
+		}
+		health.genesis = spec.ToBlock(nil).Hash()
+		if genesis != (common.Hash{}) && health.genesis != genesis {
+			health.drift = append(health.drift, fmt.Sprintf("genesis %x, expected %x", health.genesis[:4], genesis[:4]))
+		}
+	}
+	return health, nil
+}
+
+// restartService starts a stopped service container without rebuilding it.
+func restartService(client *sshClient, network string, service string) ([]byte, error) {
+	return client.Run(fmt.Sprintf("docker start %s_%s_1", network, service))
+}
+
+// waitNodeSync polls a boot or seal node until it has peers and is no longer
+// synchronising, or until the timeout expires.
+func waitNodeSync(client *sshClient, network string, service string, timeout time.Duration) error {
+	container := fmt.Sprintf("%s_%s_1", network, service)
+
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(5 * time.Second) {
+		out, err := client.Run(fmt.Sprintf("docker exec %s geth --exec 'net.peerCount' attach", container))
+		if err != nil {
+			continue // Node is probably still booting
+		}
+		if peers, _ := strconv.Atoi(string(bytes.TrimSpace(out))); peers == 0 {
+			continue
+		}
+		if out, err = client.Run(fmt.Sprintf("docker exec %s geth --exec 'eth.syncing' attach", container)); err != nil {
+			continue
+		}
+		if string(bytes.TrimSpace(out)) == "false" {
+			return nil
+		}
+	}
+	return ErrSyncTimeout
+}
+
+// shortDigest truncates an image digest into something displayable.
+func shortDigest(digest string) string {
+	if len(digest) > 19 {
+		return digest[:19]
+	}
+	return digest
+}
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"math/rand"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"text/template"
@@ -42,7 +43,7 @@ ADD genesis.json /genesis.json
 RUN \
   echo 'geth --cache 512 init /genesis.json' > geth.sh && \{{if .Unlock}}
 	echo 'mkdir -p /root/.ethereum/keystore/ && cp /signer.json /root/.ethereum/keystore/' >> geth.sh && \{{end}}
-	echo $'geth --networkid {{.NetworkID}} --cache 512 --port {{.Port}} --maxpeers {{.Peers}} {{.LightFlag}} --ethstats \'{{.Ethstats}}\' {{if .Bootnodes}}--bootnodes {{.Bootnodes}}{{end}} {{if .Usebase}}--usebase {{.Usebase}} --mine --minerthreads 1{{end}} {{if .Unlock}}--unlock 0 --password /signer.pass --mine{{end}} --targetgaslimit {{.GasTarget}} --gasprice {{.GasPrice}}' >> geth.sh
+	echo $'geth --networkid {{.NetworkID}} --cache 512 --port {{.Port}} --maxpeers {{.Peers}} {{.LightFlag}} --ethstats \'{{.Ethstats}}\' {{if .Bootnodes}}--bootnodes {{.Bootnodes}}{{end}} {{if .Usebase}}--usebase {{.Usebase}} --mine --minerthreads 1{{end}} {{if .Unlock}}--unlock 0 --password /signer.pass --mine{{end}} --targetgaslimit {{.GasTarget}} --gasprice {{.GasPrice}}{{if .ExtraFlags}} {{.ExtraFlags}}{{end}}' >> geth.sh
 
 ENTRYPOINT ["/bin/sh", "geth.sh"]
 `
@@ -68,7 +69,9 @@ services:
       - STATS_NAME={{.Ethstats}}
       - MINER_NAME={{.Usebase}}
       - GAS_TARGET={{.GasTarget}}
-      - GAS_PRICE={{.GasPrice}}
+      - GAS_PRICE={{.GasPrice}}{{if .ExtraFlags}}
+      - EXTRA_FLAGS={{.ExtraFlags}}{{end}}{{range $key, $value := .ExtraEnv}}
+      - {{$key}}={{$value}}{{end}}
     logging:
       driver: "json-file"
       options:
@@ -96,16 +99,17 @@ func deployNode(client *sshClient, network string, bootnodes []string, config *n
 	}
 	dockerfile := new(bytes.Buffer)
 	template.Must(template.New("").Parse(nodeDockerfile)).Execute(dockerfile, map[string]interface{}{
-		"NetworkID": config.network,
-		"Port":      config.port,
-		"Peers":     config.peersTotal,
-		"LightFlag": lightFlag,
-		"Bootnodes": strings.Join(bootnodes, ","),
-		"Ethstats":  config.ethstats,
-		"Usebase": config.usebase,
-		"GasTarget": uint64(1000000 * config.gasTarget),
-		"GasPrice":  uint64(1000000000 * config.gasPrice),
-		"Unlock":    config.keyJSON != "",
+		"NetworkID":  config.network,
+		"Port":       config.port,
+		"Peers":      config.peersTotal,
+		"LightFlag":  lightFlag,
+		"Bootnodes":  strings.Join(bootnodes, ","),
+		"Ethstats":   config.ethstats,
+		"Usebase":    config.usebase,
+		"GasTarget":  uint64(1000000 * config.gasTarget),
+		"GasPrice":   uint64(1000000000 * config.gasPrice),
+		"Unlock":     config.keyJSON != "",
+		"ExtraFlags": strings.Join(config.extraFlags, " "),
 	})
 	files[filepath.Join(workdir, "Dockerfile")] = dockerfile.Bytes()
 
@@ -120,9 +124,11 @@ func deployNode(client *sshClient, network string, bootnodes []string, config *n
 		"Light":      config.peersLight > 0,
 		"LightPeers": config.peersLight,
 		"Ethstats":   config.ethstats[:strings.Index(config.ethstats, ":")],
-		"Usebase":  config.usebase,
+		"Usebase":    config.usebase,
 		"GasTarget":  config.gasTarget,
 		"GasPrice":   config.gasPrice,
+		"ExtraFlags": strings.Replace(strings.Join(config.extraFlags, " "), "$", "$$", -1),
+		"ExtraEnv":   composeEscape(config.extraEnv),
 	})
 	files[filepath.Join(workdir, "docker-compose.yaml")] = composefile.Bytes()
 
@@ -156,11 +162,14 @@ type nodeInfos struct {
 	enode      string
 	peersTotal int
 	peersLight int
-	usebase  string
+	usebase    string
 	keyJSON    string
 	keyPass    string
 	gasTarget  float64
 	gasPrice   float64
+
+	extraFlags []string          // Operator supplied flags appended to the command line
+	extraEnv   map[string]string // Operator supplied environment variables
 }
 
 // Report converts the typed struct into a plain string->string map, containing
@@ -173,6 +182,9 @@ func (info *nodeInfos) Report() map[string]string {
 		"Peer count (light nodes)": strconv.Itoa(info.peersLight),
 		"Ethstats username":        info.ethstats,
 	}
+	if len(info.extraFlags) > 0 {
+		report["Extra flags"] = strings.Join(info.extraFlags, " ")
+	}
 	if info.gasTarget > 0 {
 		// Miner or signer node
 		report["Gas limit (baseline target)"] = fmt.Sprintf("%0.3f MGas", info.gasTarget)
@@ -240,6 +252,10 @@ func checkNode(client *sshClient, network string, boot bool) (*nodeInfos, error)
 	}
 	// Run a sanity check to see if the devp2p is reachable
 	port := infos.portmap[infos.envvars["PORT"]]
+	if port == 0 {
+		// Adopted, hand-built nodes might not advertise their port, use the default
+		port = infos.portmap["30303/tcp"]
+	}
 	if err = checkPort(client.server, port); err != nil {
 		log.Warn(fmt.Sprintf("%s devp2p port seems unreachable", strings.Title(kind)), "server", client.server, "port", port, "err", err)
 	}
@@ -252,7 +268,8 @@ func checkNode(client *sshClient, network string, boot bool) (*nodeInfos, error)
 		peersTotal: totalPeers,
 		peersLight: lightPeers,
 		ethstats:   infos.envvars["STATS_NAME"],
-		usebase:  infos.envvars["MINER_NAME"],
+		usebase:    infos.envvars["MINER_NAME"],
+		extraFlags: strings.Fields(infos.envvars["EXTRA_FLAGS"]),
 		keyJSON:    keyJSON,
 		keyPass:    keyPass,
 		gasTarget:  gasTarget,
@@ -262,3 +279,55 @@ func checkNode(client *sshClient, network string, boot bool) (*nodeInfos, error)
 
 	return stats, nil
 }
+
+// reservedNodeEnvs are the environment variables puppeth sets on node containers
+// itself, which operators may not override.
+var reservedNodeEnvs = map[string]bool{
+	"PORT": true, "TOTAL_PEERS": true, "LIGHT_PEERS": true, "STATS_NAME": true,
+	"MINER_NAME": true, "GAS_TARGET": true, "GAS_PRICE": true, "EXTRA_FLAGS": true,
+}
+
+// nodeEnvName matches the environment variable names accepted in templates.
+var nodeEnvName = regexp.MustCompile("^[A-Za-z_][A-Za-z0-9_]*$")
+
+// nodeTemplate is an operator customisation of a node type (bootnode or sealnode),
+// rendered into the container definitions on every deploy of such a node.
+type nodeTemplate struct {
+	Flags []string          `json:"flags,omitempty"` // Extra command line flags of the node
+	Env   map[string]string `json:"env,omitempty"`   // Extra environment variables of the container
+}
+
+// validate checks that the customisations can be safely rendered into the
+// generated shell script and compose file.
+func (t *nodeTemplate) validate() error {
+	if len(t.Flags) > 0 && !strings.HasPrefix(t.Flags[0], "-") {
+		return fmt.Errorf("invalid flag %q: not starting with a dash", t.Flags[0])
+	}
+	for _, flag := range t.Flags {
+		if strings.ContainsAny(flag, "'\\\n") {
+			return fmt.Errorf("invalid flag %q: quotes, backslashes and newlines are not supported", flag)
+		}
+	}
+	for key, value := range t.Env {
+		if !nodeEnvName.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name %q", key)
+		}
+		if reservedNodeEnvs[key] {
+			return fmt.Errorf("environment variable %s is managed by puppeth", key)
+		}
+		if strings.ContainsAny(value, "\n") {
+			return fmt.Errorf("invalid value of %s: newlines are not supported", key)
+		}
+	}
+	return nil
+}
+
+// composeEscape escapes environment variable values against the variable
+// substitution of docker-compose.
+func composeEscape(env map[string]string) map[string]string {
+	escaped := make(map[string]string, len(env))
+	for key, value := range env {
+		escaped[key] = strings.Replace(value, "$", "$$", -1)
+	}
+	return escaped
+}
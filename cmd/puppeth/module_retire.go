@@ -0,0 +1,90 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// objectStore is an S3 compatible bucket the archives of a dismantled network
+// are uploaded to.
+type objectStore struct {
+	url       string // Destination prefix in s3://bucket/path form
+	endpoint  string // Endpoint of an S3 compatible service, empty for AWS
+	accessKey string
+	secretKey string
+}
+
+// retirement records when a network was dismantled and where its archives went.
+type retirement struct {
+	Time     time.Time `json:"time"`
+	Archives []string  `json:"archives,omitempty"` // Object storage URLs of the uploaded archives
+}
+
+// stopService stops a service container without removing it.
+func stopService(client *sshClient, network string, service string) ([]byte, error) {
+	return client.Run(fmt.Sprintf("docker stop %s_%s_1", network, service))
+}
+
+// archiveService archives all the data volumes of a stopped service container
+// into the backup directory of the remote machine, returning the archive path.
+func archiveService(client *sshClient, network string, service string, when time.Time) (string, []byte, error) {
+	container := fmt.Sprintf("%s_%s_1", network, service)
+
+	volumes, err := containerVolumes(client, container)
+	if err != nil {
+		return "", nil, err
+	}
+	archive := fmt.Sprintf("%s/%s_%s.tar.gz", backupDir, container, when.UTC().Format("20060102-150405"))
+	out, err := client.Run(fmt.Sprintf("mkdir -p %s && docker run --rm --volumes-from %s -v $(pwd)/%s:/backup alpine tar czf /backup/%s %s",
+		backupDir, container, backupDir, path.Base(archive), strings.Join(volumes, " ")))
+	return archive, out, err
+}
+
+// exportChain exports the chain of a stopped node container into a compressed
+// RLP file in the backup directory of the remote machine, returning its path.
+func exportChain(client *sshClient, network string, service string, when time.Time) (string, []byte, error) {
+	container := fmt.Sprintf("%s_%s_1", network, service)
+
+	archive := fmt.Sprintf("%s/%s_chain_%s.rlp.gz", backupDir, network, when.UTC().Format("20060102-150405"))
+	out, err := client.Run(fmt.Sprintf("mkdir -p %s && docker run --rm --volumes-from %s -v $(pwd)/%s:/backup --entrypoint geth %s/%s export /backup/%s",
+		backupDir, container, backupDir, network, service, path.Base(archive)))
+	return archive, out, err
+}
+
+// uploadArchive copies an archive from the backup directory of the remote
+// machine into the object store, returning the URL it was uploaded to.
+func uploadArchive(client *sshClient, store *objectStore, network string, archive string) (string, []byte, error) {
+	url := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(store.url, "/"), network, path.Base(archive))
+
+	endpoint := ""
+	if store.endpoint != "" {
+		endpoint = fmt.Sprintf("--endpoint-url '%s' ", store.endpoint)
+	}
+	out, err := client.Run(fmt.Sprintf("docker run --rm -e AWS_ACCESS_KEY_ID='%s' -e AWS_SECRET_ACCESS_KEY='%s' -v $(pwd)/%s:/backup amazon/aws-cli %ss3 cp /backup/%s '%s'",
+		store.accessKey, store.secretKey, backupDir, endpoint, path.Base(archive), url))
+	return url, out, err
+}
+
+// removeNetworks deletes the docker networks created for a puppeth network,
+// along with the firewall rules docker maintains for them.
+func removeNetworks(client *sshClient, network string) ([]byte, error) {
+	return client.Run(fmt.Sprintf("docker network ls -q --filter label=com.docker.compose.project=%s | xargs -r docker network rm", network))
+}
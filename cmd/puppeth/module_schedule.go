@@ -0,0 +1,74 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupDir is the directory on the remote machines, relative to the home of
+// the SSH user, where service backups are archived.
+const backupDir = "puppeth-backups"
+
+// errNoVolumes is returned when backing up a service which keeps no data.
+var errNoVolumes = errors.New("service has no data volumes")
+
+// rebootService restarts a service container without rebuilding it.
+func rebootService(client *sshClient, network string, service string) ([]byte, error) {
+	return client.Run(fmt.Sprintf("docker restart %s_%s_1", network, service))
+}
+
+// backupService stops a service container, archives all its data volumes into
+// the backup directory of the remote machine and starts the container back up,
+// even if archiving failed. The path of the archive is returned.
+func backupService(client *sshClient, network string, service string, when time.Time) (string, []byte, error) {
+	container := fmt.Sprintf("%s_%s_1", network, service)
+
+	volumes, err := containerVolumes(client, container)
+	if err != nil {
+		return "", nil, err
+	}
+	// Archive from within a helper container, the volumes might not be readable
+	// by the SSH user
+	archive := fmt.Sprintf("%s/%s_%s.tar.gz", backupDir, container, when.UTC().Format("20060102-150405"))
+	out, err := client.Run(fmt.Sprintf("mkdir -p %s && docker stop %s && docker run --rm --volumes-from %s -v $(pwd)/%s:/backup alpine tar czf /backup/%s %s; status=$?; docker start %s; exit $status",
+		backupDir, container, container, backupDir, strings.TrimPrefix(archive, backupDir+"/"), strings.Join(volumes, " "), container))
+	return archive, out, err
+}
+
+// containerVolumes retrieves the sorted mount points of the data volumes of a
+// container, failing if it keeps no data.
+func containerVolumes(client *sshClient, container string) ([]string, error) {
+	infos, err := inspectContainer(client, container)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos.volumes) == 0 {
+		return nil, errNoVolumes
+	}
+	volumes := make([]string, 0, len(infos.volumes))
+	for volume := range infos.volumes {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+
+	return volumes, nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// upgradableServices maps the auxiliary services puppeth can upgrade in place
+// to the Dockerfiles they are built from, which pin their base images.
+var upgradableServices = map[string]string{
+	"ethstats": ethstatsDockerfile,
+	"explorer": explorerDockerfile,
+	"faucet":   faucetDockerfile,
+}
+
+// serviceUpgrade is the result of checking an auxiliary service for a newer
+// release of its base image.
+type serviceUpgrade struct {
+	service   string // Kind of service inspected (ethstats, explorer, faucet)
+	image     string // Digest of the image the container runs
+	base      string // Base image the service is built from
+	latest    string // Digest of the newest release of the base image
+	available bool   // Flag whether the container isn't built on the newest base
+}
+
+// baseImage extracts the image a Dockerfile template builds on.
+func baseImage(dockerfile string) string {
+	for _, line := range strings.Split(dockerfile, "\n") {
+		if fields := strings.Fields(line); len(fields) >= 2 && strings.ToUpper(fields[0]) == "FROM" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// imageLayers retrieves the content digests of the filesystem layers of an
+// image on a remote machine.
+func imageLayers(client *sshClient, image string) (string, []string, error) {
+	out, err := client.Run(fmt.Sprintf("docker image inspect --format '{{.Id}} {{json .RootFS.Layers}}' %s", image))
+	if err != nil {
+		return "", nil, err
+	}
+	parts := strings.SplitN(strings.TrimSpace(string(out)), " ", 2)
+	if len(parts) != 2 {
+		return "", nil, fmt.Errorf("invalid image inspection: %s", out)
+	}
+	var layers []string
+	if err := json.Unmarshal([]byte(parts[1]), &layers); err != nil {
+		return "", nil, err
+	}
+	return parts[0], layers, nil
+}
+
+// checkUpgrade pulls the newest release of the base image of an auxiliary
+// service and reports whether the running container is built on top of it.
+// The pinned tag is pulled, so any release found is compatible with the
+// Dockerfile puppeth deploys. Running containers are not touched.
+func checkUpgrade(client *sshClient, network string, service string) (*serviceUpgrade, error) {
+	dockerfile, ok := upgradableServices[service]
+	if !ok {
+		return nil, fmt.Errorf("%s cannot be upgraded", service)
+	}
+	infos, err := inspectContainer(client, fmt.Sprintf("%s_%s_1", network, service))
+	if err != nil {
+		return nil, err
+	}
+	upgrade := &serviceUpgrade{
+		service: service,
+		image:   infos.image,
+		base:    baseImage(dockerfile),
+	}
+	if out, err := client.Run(fmt.Sprintf("docker pull %s", upgrade.base)); err != nil {
+		return nil, remoteError(out, err)
+	}
+	latest, baseLayers, err := imageLayers(client, upgrade.base)
+	if err != nil {
+		return nil, err
+	}
+	_, layers, err := imageLayers(client, infos.image)
+	if err != nil {
+		return nil, err
+	}
+	upgrade.latest = latest
+
+	// The service image extends its base, so it must start with all its layers
+	if len(layers) < len(baseLayers) {
+		upgrade.available = true
+		return upgrade, nil
+	}
+	for i, layer := range baseLayers {
+		if layers[i] != layer {
+			upgrade.available = true
+			break
+		}
+	}
+	return upgrade, nil
+}
@@ -41,12 +41,24 @@ func main() {
 			Value: 3,
 			Usage: "log level to emit to the screen",
 		},
+		cli.BoolFlag{
+			Name:  "daemon",
+			Usage: "run unattended, executing the scheduled maintenance of the network",
+		},
 	}
 	app.Action = func(c *cli.Context) error {
 		// Set up the logger to print everything and the random generator
 		log.Root().SetHandler(log.LvlFilterHandler(log.Lvl(c.Int("loglevel")), log.StreamHandler(os.Stdout, log.TerminalFormat(true))))
 		rand.Seed(time.Now().UnixNano())
 
+		// Run the maintenance daemon if requested, it needs an existing network
+		if c.Bool("daemon") {
+			if c.String("network") == "" {
+				log.Crit("Maintenance daemon needs a --network to administer")
+			}
+			makeWizard(c.String("network")).daemon()
+			return nil
+		}
 		// Start the wizard and relinquish control
 		makeWizard(c.String("network")).run()
 		return nil
@@ -43,8 +43,16 @@ type config struct {
 	bootnodes []string // Bootnodes to always connect to by all nodes
 	ethstats  string   // Ethstats settings to cache for node deploys
 
-	Genesis *core.Genesis     `json:"genesis,omitempty"` // Genesis block to cache for node deploys
-	Servers map[string][]byte `json:"servers,omitempty"`
+	Genesis *core.Genesis                `json:"genesis,omitempty"` // Genesis block to cache for node deploys
+	Servers map[string][]byte            `json:"servers,omitempty"`
+	Images  map[string]map[string]string `json:"images,omitempty"` // Image digests of the services deployed per server
+
+	Templates map[string]*nodeTemplate `json:"templates,omitempty"` // Customisations of the node types (bootnode, sealnode)
+
+	Schedule []*maintenance `json:"schedule,omitempty"` // Maintenance operations executed by the daemon
+	Webhook  string         `json:"webhook,omitempty"`  // URL the maintenance results are posted to
+
+	Retired *retirement `json:"retired,omitempty"` // Set once the network has been dismantled
 }
 
 // servers retrieves an alphabetically sorted list of servers.
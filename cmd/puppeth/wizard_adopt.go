@@ -0,0 +1,115 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/usechain/go-usechain/log"
+)
+
+// adoptComponent lists the containers running on a remote machine which are not
+// yet managed by puppeth and takes over the one chosen by the user, reconciling
+// it into the wizard's service model without redeploying it.
+func (w *wizard) adoptComponent() {
+	// Select the server to interact with
+	server := w.selectServer()
+	if server == "" {
+		return
+	}
+	client := w.servers[server]
+
+	containers, err := listContainers(client, w.network)
+	if err != nil {
+		log.Error("Failed to list remote containers", "server", server, "err", err)
+		return
+	}
+	if len(containers) == 0 {
+		log.Info("No unmanaged containers found", "server", server)
+		return
+	}
+	// Ask the user which container to adopt
+	fmt.Println()
+	fmt.Println("Which container do you want puppeth to take over?")
+	for i, container := range containers {
+		state := "running"
+		if !container.running {
+			state = "stopped"
+		}
+		fmt.Printf(" %d. %s (%s, %s)\n", i+1, container.name, container.image, state)
+	}
+	choice := w.readInt()
+	if choice <= 0 || choice > len(containers) {
+		log.Error("Invalid container choice, aborting")
+		return
+	}
+	container := containers[choice-1]
+
+	// Figure out what kind of service the container provides
+	fmt.Println()
+	guess := guessService(container)
+	if guess == "" {
+		fmt.Println("What kind of service does the container provide?")
+	} else {
+		fmt.Printf("What kind of service does the container provide? (default = %s)\n", guess)
+	}
+	def := 0
+	for i, service := range adoptableServices {
+		if service == guess {
+			def = i + 1
+		}
+		fmt.Printf(" %d. %s\n", i+1, service)
+	}
+	if def == 0 {
+		choice = w.readInt()
+	} else {
+		choice = w.readDefaultInt(def)
+	}
+	if choice <= 0 || choice > len(adoptableServices) {
+		log.Error("Invalid service choice, aborting")
+		return
+	}
+	service := adoptableServices[choice-1]
+
+	// Take over the container and ensure puppeth can actually monitor it
+	if out, err := adoptContainer(client, w.network, container.name, service); err != nil {
+		log.Error("Failed to adopt container", "container", container.name, "service", service, "err", err)
+		if len(out) > 0 {
+			fmt.Printf("%s\n", out)
+		}
+		return
+	}
+	if err := checkService(client, w.network, service); err != nil {
+		log.Warn("Adopted container fails health-check", "service", service, "err", err)
+
+		fmt.Println()
+		fmt.Println("Keep managing the container regardless (y/n)? (default = no)")
+		if w.readDefaultString("n") != "y" {
+			if out, err := releaseContainer(client, w.network, service, container.name); err != nil {
+				log.Error("Failed to release container", "container", container.name, "err", err)
+				if len(out) > 0 {
+					fmt.Printf("%s\n", out)
+				}
+			}
+			return
+		}
+	}
+	w.trackImage(client, service)
+
+	log.Info("Adopted existing component", "server", server, "container", container.name, "service", service)
+	w.networkStats()
+}
@@ -153,6 +153,8 @@ func (w *wizard) deployDashboard() {
 		}
 		return
 	}
+	w.trackImage(client, "dashboard")
+
 	// All ok, run a network scan to pick any changes up
 	w.networkStats()
 }
@@ -121,6 +121,8 @@ func (w *wizard) deployEthstats() {
 		}
 		return
 	}
+	w.trackImage(client, "ethstats")
+
 	// All ok, run a network scan to pick any changes up
 	w.networkStats()
 }
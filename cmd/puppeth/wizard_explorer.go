@@ -109,6 +109,8 @@ func (w *wizard) deployExplorer() {
 		}
 		return
 	}
+	w.trackImage(client, "explorer")
+
 	// All ok, run a network scan to pick any changes up
 	log.Info("Waiting for node to finish booting")
 	time.Sleep(3 * time.Second)
@@ -175,6 +175,8 @@ func (w *wizard) deployFaucet() {
 		}
 		return
 	}
+	w.trackImage(client, "faucet")
+
 	// All ok, run a network scan to pick any changes up
 	w.networkStats()
 }
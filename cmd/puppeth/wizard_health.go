@@ -0,0 +1,237 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/log"
+)
+
+// nodeSyncTimeout is the maximum time to wait for a redeployed node to catch up
+// with the network before moving on to the next one in a rolling redeploy.
+const nodeSyncTimeout = 10 * time.Minute
+
+// unhealthyService is a service found failing its health-check during a network
+// check, scheduled for recovery.
+type unhealthyService struct {
+	server string
+	health *serviceHealth
+}
+
+// trackImage records the image digest of a freshly deployed service, so that
+// later health-checks can detect containers drifting away from it.
+func (w *wizard) trackImage(client *sshClient, service string) {
+	infos, err := inspectContainer(client, fmt.Sprintf("%s_%s_1", w.network, service))
+	if err != nil {
+		log.Warn("Failed to track deployed image", "service", service, "err", err)
+		return
+	}
+	for server, conn := range w.servers {
+		if conn == client {
+			w.setImage(server, service, infos.image)
+		}
+	}
+	w.conf.flush()
+}
+
+// setImage updates the cached image digest of a service on a given server. An
+// empty digest drops the service from the cache.
+func (w *wizard) setImage(server string, service string, image string) {
+	if image == "" {
+		delete(w.conf.Images[server], service)
+		if len(w.conf.Images[server]) == 0 {
+			delete(w.conf.Images, server)
+		}
+		return
+	}
+	if w.conf.Images == nil {
+		w.conf.Images = make(map[string]map[string]string)
+	}
+	if w.conf.Images[server] == nil {
+		w.conf.Images[server] = make(map[string]string)
+	}
+	w.conf.Images[server][service] = image
+}
+
+// checkNetwork runs a health-check against every managed service on all tracked
+// servers, verifying that they are running the images and genesis puppeth has
+// deployed, and offers to recover the failing ones one at a time.
+func (w *wizard) checkNetwork() {
+	if len(w.servers) == 0 {
+		log.Info("No remote machines to check")
+		return
+	}
+	var genesis common.Hash
+	if w.conf.Genesis != nil {
+		genesis = w.conf.Genesis.ToBlock(nil).Hash()
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Server", "Service", "State", "Image", "Drift"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	var unhealthy []*unhealthyService
+	for _, server := range w.conf.servers() {
+		client := w.servers[server]
+		if client == nil {
+			table.Append([]string{server, "", "unreachable", "", ""})
+			continue
+		}
+		for _, service := range adoptableServices {
+			health, err := checkHealth(client, w.network, service, w.conf.Images[server][service], genesis)
+			if err == ErrServiceUnknown {
+				continue
+			}
+			if err != nil {
+				table.Append([]string{server, service, "failing", "", err.Error()})
+				continue
+			}
+			// Services deployed before image tracking become the baseline
+			if w.conf.Images[server][service] == "" {
+				w.setImage(server, service, health.image)
+				w.conf.flush()
+			}
+			state := "running"
+			if !health.running {
+				state = "stopped"
+			}
+			table.Append([]string{server, service, state, shortDigest(health.image), strings.Join(health.drift, ", ")})
+
+			if !health.healthy() {
+				unhealthy = append(unhealthy, &unhealthyService{server: server, health: health})
+			}
+		}
+	}
+	table.Render()
+
+	if len(unhealthy) == 0 {
+		log.Info("All network components are healthy")
+		return
+	}
+	fmt.Println()
+	fmt.Printf("Recover the %d unhealthy components one at a time (y/n)? (default = no)\n", len(unhealthy))
+	if w.readDefaultString("n") != "y" {
+		return
+	}
+	w.rollingRedeploy(unhealthy)
+}
+
+// rollingRedeploy recovers a list of unhealthy services one by one: stopped
+// containers are restarted and drifted nodes are redeployed with the cached
+// configuration, waiting for each node to sync before touching the next one.
+func (w *wizard) rollingRedeploy(services []*unhealthyService) {
+	for i, failing := range services {
+		logger := log.New("server", failing.server, "service", failing.health.service)
+
+		err := w.recoverService(failing)
+		if err == nil {
+			err = w.waitRecovery(failing)
+		}
+		if err == nil {
+			logger.Info("Recovered network component")
+			continue
+		}
+		logger.Error("Failed to recover component", "err", err)
+		if i < len(services)-1 {
+			fmt.Println()
+			fmt.Println("Continue with the remaining components (y/n)? (default = no)")
+			if w.readDefaultString("n") != "y" {
+				break
+			}
+		}
+	}
+	w.networkStats()
+}
+
+// recoverService restarts or redeploys a single unhealthy service.
+func (w *wizard) recoverService(failing *unhealthyService) error {
+	client, service := w.servers[failing.server], failing.health.service
+
+	// Stopped containers are simply started back up if nothing else changed
+	if !failing.health.running && len(failing.health.drift) == 0 {
+		out, err := restartService(client, w.network, service)
+		if err != nil && len(out) > 0 {
+			fmt.Printf("%s\n", out)
+		}
+		return err
+	}
+	// Drifted nodes and auxiliary services are redeployed with their current
+	// configuration, other web services need their own wizard
+	_, upgradable := upgradableServices[service]
+	if !failing.health.node() && !upgradable {
+		return fmt.Errorf("%s needs a manual redeploy", service)
+	}
+	if !failing.health.running {
+		if out, err := restartService(client, w.network, service); err != nil {
+			if len(out) > 0 {
+				fmt.Printf("%s\n", out)
+			}
+			return err
+		}
+	}
+	if upgradable {
+		return w.redeployService(client, service)
+	}
+	return w.redeployNode(client, service, false)
+}
+
+// redeployNode redeploys a running boot or seal node with its current settings
+// and the cached genesis and ethstats configuration, optionally rebuilding the
+// image from scratch to pick up a new client release.
+func (w *wizard) redeployNode(client *sshClient, service string, nocache bool) error {
+	if w.conf.Genesis == nil {
+		return errors.New("no genesis block configured")
+	}
+	if w.conf.ethstats == "" {
+		return errors.New("no ethstats server configured")
+	}
+	infos, err := checkNode(client, w.network, service == "bootnode")
+	if err != nil {
+		return err
+	}
+	infos.genesis, _ = json.MarshalIndent(w.conf.Genesis, "", "  ")
+	infos.network = w.conf.Genesis.Config.ChainId.Int64()
+	infos.ethstats = infos.ethstats + ":" + w.conf.ethstats
+	w.applyTemplate(service, infos)
+
+	if out, err := deployNode(client, w.network, w.conf.bootnodes, infos, nocache); err != nil {
+		if len(out) > 0 {
+			fmt.Printf("%s\n", out)
+		}
+		return err
+	}
+	w.trackImage(client, service)
+	return nil
+}
+
+// waitRecovery blocks until a recovered node caught up with the network. Other
+// services are given a few seconds to boot.
+func (w *wizard) waitRecovery(failing *unhealthyService) error {
+	if !failing.health.node() {
+		time.Sleep(3 * time.Second)
+		return nil
+	}
+	log.Info("Waiting for node to sync", "server", failing.server, "service", failing.health.service)
+	return waitNodeSync(w.servers[failing.server], w.network, failing.health.service, nodeSyncTimeout)
+}
@@ -42,6 +42,41 @@ func makeWizard(network string) *wizard {
 	}
 }
 
+// load loads the configurations of the network from previous runs and dials all
+// the servers known from them, returning whether any were found.
+func (w *wizard) load() bool {
+	w.conf.path = filepath.Join(os.Getenv("HOME"), ".puppeth", w.network)
+
+	blob, err := ioutil.ReadFile(w.conf.path)
+	if err != nil {
+		log.Warn("No previous configurations found", "path", w.conf.path)
+		return false
+	}
+	if err := json.Unmarshal(blob, &w.conf); err != nil {
+		log.Crit("Previous configuration corrupted", "path", w.conf.path, "err", err)
+	}
+	// Dial all previously known servers concurrently
+	var pend sync.WaitGroup
+	for server, pubkey := range w.conf.Servers {
+		pend.Add(1)
+
+		go func(server string, pubkey []byte) {
+			defer pend.Done()
+
+			log.Info("Dialing previously configured server", "server", server)
+			client, err := dial(server, pubkey)
+			if err != nil {
+				log.Error("Previous server unreachable", "server", server, "err", err)
+			}
+			w.lock.Lock()
+			w.servers[server] = client
+			w.lock.Unlock()
+		}(server, pubkey)
+	}
+	pend.Wait()
+	return true
+}
+
 // run displays some useful infos to the user, starting on the journey of
 // setting up a new or managing an existing Ethereum private network.
 func (w *wizard) run() {
@@ -74,33 +109,10 @@ func (w *wizard) run() {
 	log.Info("Administering Ethereum network", "name", w.network)
 
 	// Load initial configurations and connect to all live servers
-	w.conf.path = filepath.Join(os.Getenv("HOME"), ".puppeth", w.network)
-
-	blob, err := ioutil.ReadFile(w.conf.path)
-	if err != nil {
-		log.Warn("No previous configurations found", "path", w.conf.path)
-	} else if err := json.Unmarshal(blob, &w.conf); err != nil {
-		log.Crit("Previous configuration corrupted", "path", w.conf.path, "err", err)
-	} else {
-		// Dial all previously known servers concurrently
-		var pend sync.WaitGroup
-		for server, pubkey := range w.conf.Servers {
-			pend.Add(1)
-
-			go func(server string, pubkey []byte) {
-				defer pend.Done()
-
-				log.Info("Dialing previously configured server", "server", server)
-				client, err := dial(server, pubkey)
-				if err != nil {
-					log.Error("Previous server unreachable", "server", server, "err", err)
-				}
-				w.lock.Lock()
-				w.servers[server] = client
-				w.lock.Unlock()
-			}(server, pubkey)
+	if w.load() {
+		if w.conf.Retired != nil {
+			log.Warn("Network was dismantled", "time", w.conf.Retired.Time, "archives", len(w.conf.Retired.Archives))
 		}
-		pend.Wait()
 		w.networkStats()
 	}
 	// Basics done, loop ad infinitum about what to do
@@ -123,6 +135,11 @@ func (w *wizard) run() {
 		} else {
 			fmt.Println(" 4. Manage network components")
 		}
+		fmt.Println(" 5. Adopt existing components")
+		fmt.Println(" 6. Check network health")
+		fmt.Println(" 7. Schedule maintenance")
+		fmt.Println(" 8. Dismantle network")
+		fmt.Println(" 9. Upgrade auxiliary services")
 
 		choice := w.read()
 		switch {
@@ -149,6 +166,20 @@ func (w *wizard) run() {
 			} else {
 				w.manageComponents()
 			}
+		case choice == "5":
+			w.adoptComponent()
+
+		case choice == "6":
+			w.checkNetwork()
+
+		case choice == "7":
+			w.manageSchedule()
+
+		case choice == "8":
+			w.dismantleNetwork()
+
+		case choice == "9":
+			w.upgradeServices()
 
 		default:
 			log.Error("That's not something I can do")
@@ -50,6 +50,7 @@ func (w *wizard) manageServers() {
 			client.Close()
 		}
 		delete(w.conf.Servers, server)
+		delete(w.conf.Images, server)
 		w.conf.flush()
 
 		log.Info("Disconnected existing server", "server", server)
@@ -149,6 +150,9 @@ func (w *wizard) manageComponents() {
 			return
 		}
 		// Clean up any references to it from out state
+		w.setImage(server, service, "")
+		w.conf.flush()
+
 		services := w.services[server]
 		for i, name := range services {
 			if name == service {
@@ -55,6 +55,8 @@ func (w *wizard) ensureVirtualHost(client *sshClient, port int, def string) (str
 			}
 			return "", err
 		}
+		w.trackImage(client, "nginx")
+
 		// Reverse proxy deployed, ask again for the virtual-host
 		fmt.Println()
 		fmt.Printf("Proxy deployed, which domain to assign? (default = %s)\n", def)
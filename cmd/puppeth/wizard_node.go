@@ -19,6 +19,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/usechain/go-usechain/accounts/keystore"
@@ -156,6 +157,14 @@ func (w *wizard) deployNode(boot bool) {
 		fmt.Printf("What gas price should the signer require (GHui)? (default = %0.3f)\n", infos.gasPrice)
 		infos.gasPrice = w.readDefaultFloat(infos.gasPrice)
 	}
+	kind := "sealnode"
+	if boot {
+		kind = "bootnode"
+	}
+	// Offer to customise the command line and environment of all such nodes
+	w.configureTemplate(kind)
+	w.applyTemplate(kind, infos)
+
 	// Try to deploy the full node on the host
 	nocache := false
 	if existed {
@@ -170,9 +179,77 @@ func (w *wizard) deployNode(boot bool) {
 		}
 		return
 	}
+	w.trackImage(client, kind)
+
 	// All ok, run a network scan to pick any changes up
 	log.Info("Waiting for node to finish booting")
 	time.Sleep(3 * time.Second)
 
 	w.networkStats()
 }
+
+// configureTemplate displays the customisations of a node type and allows the
+// user to change them. They apply to every subsequent deploy of such nodes.
+func (w *wizard) configureTemplate(kind string) {
+	tmpl := w.conf.Templates[kind]
+	if tmpl == nil {
+		tmpl = new(nodeTemplate)
+	}
+	fmt.Println()
+	if len(tmpl.Flags) == 0 && len(tmpl.Env) == 0 {
+		fmt.Printf("Customise the command line or environment of %ss (y/n)? (default = no)\n", kind)
+	} else {
+		fmt.Printf("Current %s flags: %s\n", kind, strings.Join(tmpl.Flags, " "))
+		for key, value := range tmpl.Env {
+			fmt.Printf("Current %s environment: %s=%s\n", kind, key, value)
+		}
+		fmt.Printf("Change the customisations of %ss (y/n)? (default = no)\n", kind)
+	}
+	if w.readDefaultString("n") != "y" {
+		return
+	}
+	for {
+		update := &nodeTemplate{Env: make(map[string]string)}
+
+		fmt.Println()
+		fmt.Printf("Which extra flags should %ss run with? (space separated, empty = none)\n", kind)
+		update.Flags = strings.Fields(w.read())
+
+		fmt.Println()
+		fmt.Println("Which extra environment variables should be set? (KEY=value per line, empty line to finish)")
+		for {
+			line := w.read()
+			if line == "" {
+				break
+			}
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) != 2 {
+				log.Error("Invalid environment variable, expected KEY=value")
+				continue
+			}
+			update.Env[parts[0]] = parts[1]
+		}
+		if err := update.validate(); err != nil {
+			log.Error("Invalid customisation, please try again", "err", err)
+			continue
+		}
+		if w.conf.Templates == nil {
+			w.conf.Templates = make(map[string]*nodeTemplate)
+		}
+		if len(update.Flags) == 0 && len(update.Env) == 0 {
+			delete(w.conf.Templates, kind)
+		} else {
+			w.conf.Templates[kind] = update
+		}
+		w.conf.flush()
+		return
+	}
+}
+
+// applyTemplate sets the customisations of a node type on a node to deploy.
+func (w *wizard) applyTemplate(kind string, infos *nodeInfos) {
+	infos.extraFlags, infos.extraEnv = nil, nil
+	if tmpl := w.conf.Templates[kind]; tmpl != nil {
+		infos.extraFlags, infos.extraEnv = tmpl.Flags, tmpl.Env
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/usechain/go-usechain/log"
+)
+
+// dismantleNetwork stops every service of the network across all the tracked
+// servers, optionally archives the node data and a final chain export into an
+// object store, then removes all the containers and marks the network retired.
+//
+// Nothing is removed unless every service could be stopped and, if requested,
+// archived, so a failed dismantling can simply be retried.
+func (w *wizard) dismantleNetwork() {
+	fmt.Println()
+	fmt.Printf("This stops and removes every component of %s on all servers!\n", w.network)
+	fmt.Println("Type the network name to confirm:")
+	if w.readString() != w.network {
+		log.Error("Network name mismatch, aborting")
+		return
+	}
+	var store *objectStore
+
+	fmt.Println()
+	fmt.Println("Archive the node data and a final chain export to object storage? (y/n)")
+	if w.readDefaultString("y") == "y" {
+		store = new(objectStore)
+
+		fmt.Println()
+		fmt.Println("Where should the archives be uploaded to? (s3://bucket/path)")
+		for {
+			if store.url = w.readString(); strings.HasPrefix(store.url, "s3://") {
+				break
+			}
+			log.Error("Object store must be an s3:// URL")
+		}
+		fmt.Println()
+		fmt.Println("What's the endpoint of the S3 compatible service? (default = AWS)")
+		store.endpoint = w.readDefaultString("")
+
+		fmt.Println()
+		fmt.Println("What's the access key ID of the bucket?")
+		store.accessKey = w.readString()
+
+		fmt.Println()
+		fmt.Println("What's the secret access key of the bucket? (won't be echoed)")
+		store.secretKey = w.readPassword()
+	}
+	// Every server needs to be reachable to leave nothing running behind
+	servers := w.conf.servers()
+	for _, server := range servers {
+		if w.servers[server] == nil {
+			log.Error("Server unreachable, aborting", "server", server)
+			return
+		}
+	}
+	services := make(map[string][]string)
+	for server, names := range w.services {
+		services[server] = append([]string{}, names...)
+		sort.Strings(services[server])
+	}
+	// Stop everything first, so no node keeps producing blocks after the export
+	for _, server := range servers {
+		for _, service := range services[server] {
+			if out, err := stopService(w.servers[server], w.network, service); err != nil {
+				log.Error("Failed to stop service, aborting", "server", server, "service", service, "err", remoteError(out, err))
+				return
+			}
+			log.Info("Stopped service", "server", server, "service", service)
+		}
+	}
+	retired := &retirement{Time: time.Now()}
+	if store != nil {
+		exported := false
+		for _, server := range servers {
+			client := w.servers[server]
+			for _, service := range services[server] {
+				if !isNodeService(service) {
+					continue
+				}
+				archives := make([]string, 0, 2)
+
+				archive, out, err := archiveService(client, w.network, service, retired.Time)
+				if err != nil {
+					log.Error("Failed to archive node data, aborting", "server", server, "service", service, "err", remoteError(out, err))
+					return
+				}
+				archives = append(archives, archive)
+
+				if !exported {
+					archive, out, err := exportChain(client, w.network, service, retired.Time)
+					if err != nil {
+						log.Error("Failed to export chain, aborting", "server", server, "service", service, "err", remoteError(out, err))
+						return
+					}
+					archives = append(archives, archive)
+					exported = true
+				}
+				for _, archive := range archives {
+					url, out, err := uploadArchive(client, store, w.network, archive)
+					if err != nil {
+						log.Error("Failed to upload archive, aborting", "server", server, "archive", archive, "err", remoteError(out, err))
+						return
+					}
+					log.Info("Uploaded archive", "server", server, "url", url)
+					retired.Archives = append(retired.Archives, url)
+				}
+			}
+		}
+		if !exported {
+			log.Warn("No node to export the chain from")
+		}
+	}
+	// Everything safe, remove the containers and the networks they used
+	failed := false
+	for _, server := range servers {
+		client := w.servers[server]
+		for _, service := range services[server] {
+			if out, err := tearDown(client, w.network, service, true); err != nil {
+				log.Error("Failed to tear down service", "server", server, "service", service, "err", remoteError(out, err))
+				failed = true
+				continue
+			}
+			w.setImage(server, service, "")
+		}
+		if out, err := removeNetworks(client, w.network); err != nil {
+			log.Error("Failed to remove docker networks", "server", server, "err", remoteError(out, err))
+			failed = true
+		}
+	}
+	w.conf.flush()
+	w.networkStats()
+
+	if failed {
+		log.Error("Network partially dismantled, retry to finish")
+		return
+	}
+	// Scheduled maintenance no longer has anything to operate on
+	for _, maint := range w.conf.Schedule {
+		if maint.Status == maintPending {
+			maint.Status, maint.Result = maintMissed, "network retired"
+		}
+	}
+	w.conf.Retired = retired
+	w.conf.flush()
+
+	log.Info("Network dismantled and retired", "network", w.network, "archives", len(retired.Archives))
+}
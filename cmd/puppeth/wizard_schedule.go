@@ -0,0 +1,411 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/usechain/go-usechain/log"
+)
+
+// Maintenance operations puppeth can schedule.
+const (
+	opRestart = "restart" // Restart the service container
+	opUpgrade = "upgrade" // Rebuild a node from the latest client image
+	opBackup  = "backup"  // Archive the data volumes of the service
+)
+
+// Lifecycle states of a scheduled maintenance.
+const (
+	maintPending = "pending"
+	maintRunning = "running"
+	maintDone    = "done"
+	maintFailed  = "failed"
+	maintMissed  = "missed"
+)
+
+const (
+	// schedulePollInterval is the period at which the daemon checks for pending
+	// maintenance operations whose window opened.
+	schedulePollInterval = 30 * time.Second
+
+	// webhookTimeout is the maximum time allowed to report a result.
+	webhookTimeout = 10 * time.Second
+
+	// scheduleTimeLayout is the format maintenance windows are entered in.
+	scheduleTimeLayout = "2006-01-02 15:04"
+)
+
+// maintenance is an operation on a single service, scheduled to be executed by
+// the puppeth daemon within a time window.
+type maintenance struct {
+	ID        int        `json:"id"`
+	Server    string     `json:"server"`
+	Service   string     `json:"service"`
+	Operation string     `json:"operation"`
+	Start     time.Time  `json:"start"` // Opening of the maintenance window
+	End       time.Time  `json:"end"`   // Closing of the window, after which the operation is missed
+	Status    string     `json:"status"`
+	Result    string     `json:"result,omitempty"`   // Outcome details or failure reason
+	Executed  *time.Time `json:"executed,omitempty"` // Time the execution started at
+	Finished  *time.Time `json:"finished,omitempty"` // Time the execution finished at
+}
+
+// maintenanceReport is the payload posted to the webhook when a maintenance
+// operation concluded, one way or another.
+type maintenanceReport struct {
+	Network string `json:"network"`
+	*maintenance
+}
+
+// manageSchedule displays the scheduled maintenance operations and allows the
+// user to schedule new ones, cancel pending ones and configure the webhook.
+func (w *wizard) manageSchedule() {
+	w.printSchedule()
+
+	webhook := w.conf.Webhook
+	if webhook == "" {
+		webhook = "none"
+	}
+	fmt.Println()
+	fmt.Println("What would you like to do? (default = none)")
+	fmt.Println(" 1. Schedule maintenance")
+	fmt.Println(" 2. Cancel pending maintenance")
+	fmt.Printf(" 3. Configure result webhook (current = %s)\n", webhook)
+
+	switch w.read() {
+	case "1":
+		w.scheduleMaintenance()
+	case "2":
+		w.cancelMaintenance()
+	case "3":
+		fmt.Println()
+		fmt.Println("Which URL should maintenance results be posted to? (empty = disable)")
+		w.conf.Webhook = w.read()
+		w.conf.flush()
+	}
+}
+
+// printSchedule renders the scheduled maintenance operations into a table.
+func (w *wizard) printSchedule() {
+	if len(w.conf.Schedule) == 0 {
+		log.Info("No maintenance scheduled")
+		return
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"ID", "Server", "Service", "Operation", "Window", "Status", "Result"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	for _, maint := range w.conf.Schedule {
+		window := fmt.Sprintf("%s - %s", maint.Start.Local().Format(scheduleTimeLayout), maint.End.Local().Format(scheduleTimeLayout))
+		table.Append([]string{strconv.Itoa(maint.ID), maint.Server, maint.Service, maint.Operation, window, maint.Status, maint.Result})
+	}
+	table.Render()
+}
+
+// scheduleMaintenance asks the user for an operation to run on one of the known
+// services and the window to run it in, adding it to the schedule.
+func (w *wizard) scheduleMaintenance() {
+	type target struct{ server, service string }
+
+	var targets []target
+	for _, server := range w.conf.servers() {
+		for _, service := range w.services[server] {
+			targets = append(targets, target{server, service})
+		}
+	}
+	if len(targets) == 0 {
+		log.Error("No network components to maintain")
+		return
+	}
+	fmt.Println()
+	fmt.Println("Which component do you want to maintain?")
+	for i, t := range targets {
+		fmt.Printf(" %d. %s on %s\n", i+1, t.service, t.server)
+	}
+	choice := w.readInt()
+	if choice < 1 || choice > len(targets) {
+		log.Error("Invalid component choice, aborting")
+		return
+	}
+	maint := &maintenance{Server: targets[choice-1].server, Service: targets[choice-1].service, Status: maintPending}
+
+	operations := []string{opRestart, opBackup}
+	if _, ok := upgradableServices[maint.Service]; ok || isNodeService(maint.Service) {
+		operations = append(operations, opUpgrade)
+	}
+	fmt.Println()
+	fmt.Println("Which operation should be executed?")
+	for i, op := range operations {
+		fmt.Printf(" %d. %s\n", i+1, op)
+	}
+	if choice = w.readInt(); choice < 1 || choice > len(operations) {
+		log.Error("Invalid operation choice, aborting")
+		return
+	}
+	maint.Operation = operations[choice-1]
+
+	for {
+		fmt.Println()
+		fmt.Printf("When should the maintenance window open? (%s, local time)\n", scheduleTimeLayout)
+		start, err := time.ParseInLocation(scheduleTimeLayout, w.readString(), time.Local)
+		if err != nil {
+			log.Error("Invalid time", "err", err)
+			continue
+		}
+		maint.Start = start
+		break
+	}
+	fmt.Println()
+	fmt.Println("How many minutes should the maintenance window last? (default = 60)")
+	maint.End = maint.Start.Add(time.Duration(w.readDefaultInt(60)) * time.Minute)
+	if !maint.End.After(time.Now()) {
+		log.Error("Maintenance window already closed, aborting")
+		return
+	}
+	for _, other := range w.conf.Schedule {
+		if other.ID >= maint.ID {
+			maint.ID = other.ID + 1
+		}
+	}
+	w.conf.Schedule = append(w.conf.Schedule, maint)
+	sort.SliceStable(w.conf.Schedule, func(i, j int) bool { return w.conf.Schedule[i].Start.Before(w.conf.Schedule[j].Start) })
+	w.conf.flush()
+
+	log.Info("Scheduled maintenance", "id", maint.ID, "server", maint.Server, "service", maint.Service, "operation", maint.Operation, "start", maint.Start)
+	log.Info("Maintenance is executed by the puppeth daemon", "command", fmt.Sprintf("puppeth --network %s --daemon", w.network))
+}
+
+// cancelMaintenance drops a pending maintenance operation from the schedule.
+func (w *wizard) cancelMaintenance() {
+	fmt.Println()
+	fmt.Println("Which pending maintenance do you want to cancel? (ID)")
+	id := w.readInt()
+
+	for i, maint := range w.conf.Schedule {
+		if maint.ID != id {
+			continue
+		}
+		if maint.Status != maintPending {
+			log.Error("Maintenance not pending", "id", id, "status", maint.Status)
+			return
+		}
+		w.conf.Schedule = append(w.conf.Schedule[:i], w.conf.Schedule[i+1:]...)
+		w.conf.flush()
+
+		log.Info("Cancelled maintenance", "id", id)
+		return
+	}
+	log.Error("Unknown maintenance", "id", id)
+}
+
+// daemon runs puppeth non-interactively, executing the scheduled maintenance
+// operations as their windows open and reporting the results to the webhook.
+func (w *wizard) daemon() {
+	if !w.load() {
+		log.Crit("No network configured to maintain", "network", w.network)
+	}
+	if w.conf.Retired != nil {
+		log.Crit("Network dismantled, nothing to maintain", "network", w.network, "time", w.conf.Retired.Time)
+	}
+	w.networkStats()
+
+	// Operations in progress when a previous daemon died have unknown outcomes
+	for _, maint := range w.conf.Schedule {
+		if maint.Status == maintRunning {
+			w.conclude(maint.ID, maintFailed, "interrupted")
+		}
+	}
+	log.Info("Maintenance daemon running", "network", w.network, "webhook", w.conf.Webhook)
+	for {
+		w.runSchedule(time.Now())
+		time.Sleep(schedulePollInterval)
+	}
+}
+
+// runSchedule executes all the pending maintenance operations whose window is
+// open, and gives up on the ones whose window closed.
+func (w *wizard) runSchedule(now time.Time) {
+	w.reload()
+
+	var due []*maintenance
+	for _, maint := range w.conf.Schedule {
+		if maint.Status != maintPending {
+			continue
+		}
+		switch {
+		case now.After(maint.End):
+			w.conclude(maint.ID, maintMissed, "maintenance window closed")
+		case !now.Before(maint.Start):
+			due = append(due, maint)
+		}
+	}
+	for _, maint := range due {
+		logger := log.New("id", maint.ID, "server", maint.Server, "service", maint.Service, "operation", maint.Operation)
+
+		started := time.Now()
+		maint.Status, maint.Executed = maintRunning, &started
+		w.conf.flush()
+
+		logger.Info("Executing scheduled maintenance")
+		if result, err := w.execute(maint); err != nil {
+			logger.Error("Scheduled maintenance failed", "err", err)
+			w.conclude(maint.ID, maintFailed, err.Error())
+		} else {
+			logger.Info("Scheduled maintenance done", "result", result)
+			w.conclude(maint.ID, maintDone, result)
+		}
+	}
+}
+
+// execute runs a single maintenance operation, returning its outcome.
+func (w *wizard) execute(maint *maintenance) (string, error) {
+	client := w.servers[maint.Server]
+	if client == nil {
+		pubkey, ok := w.conf.Servers[maint.Server]
+		if !ok {
+			return "", errors.New("server no longer tracked")
+		}
+		var err error
+		if client, err = dial(maint.Server, pubkey); err != nil {
+			return "", err
+		}
+		w.servers[maint.Server] = client
+	}
+	switch maint.Operation {
+	case opRestart:
+		if out, err := rebootService(client, w.network, maint.Service); err != nil {
+			return "", remoteError(out, err)
+		}
+		if isNodeService(maint.Service) {
+			if err := waitNodeSync(client, w.network, maint.Service, nodeSyncTimeout); err != nil {
+				return "", err
+			}
+		}
+		return "restarted", nil
+
+	case opUpgrade:
+		if _, ok := upgradableServices[maint.Service]; ok {
+			if err := w.redeployService(client, maint.Service); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("running image %s", shortDigest(w.conf.Images[maint.Server][maint.Service])), nil
+		}
+		if !isNodeService(maint.Service) {
+			return "", fmt.Errorf("%s cannot be upgraded unattended", maint.Service)
+		}
+		// Refresh the network settings the node is redeployed with
+		w.networkStats()
+		if err := w.redeployNode(client, maint.Service, true); err != nil {
+			return "", err
+		}
+		if err := waitNodeSync(client, w.network, maint.Service, nodeSyncTimeout); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("running image %s", shortDigest(w.conf.Images[maint.Server][maint.Service])), nil
+
+	case opBackup:
+		archive, out, err := backupService(client, w.network, maint.Service, time.Now())
+		if err != nil {
+			return "", remoteError(out, err)
+		}
+		return fmt.Sprintf("archived into %s", archive), nil
+	}
+	return "", fmt.Errorf("unknown operation %q", maint.Operation)
+}
+
+// conclude records the outcome of a maintenance operation and reports it to the
+// webhook, if one is configured.
+func (w *wizard) conclude(id int, status string, result string) {
+	w.reload()
+
+	for _, maint := range w.conf.Schedule {
+		if maint.ID != id {
+			continue
+		}
+		finished := time.Now()
+		maint.Status, maint.Result, maint.Finished = status, result, &finished
+		w.conf.flush()
+
+		if err := w.report(maint); err != nil {
+			log.Warn("Failed to report maintenance result", "id", id, "webhook", w.conf.Webhook, "err", err)
+		}
+		return
+	}
+}
+
+// report posts the outcome of a maintenance operation to the webhook.
+func (w *wizard) report(maint *maintenance) error {
+	if w.conf.Webhook == "" {
+		return nil
+	}
+	blob, err := json.Marshal(&maintenanceReport{Network: w.network, maintenance: maint})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: webhookTimeout}
+
+	res, err := client.Post(w.conf.Webhook, "application/json", bytes.NewReader(blob))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded %s", res.Status)
+	}
+	return nil
+}
+
+// reload refreshes the configurations from disk, picking up the changes done by
+// interactive puppeth sessions since they were last loaded.
+func (w *wizard) reload() {
+	blob, err := ioutil.ReadFile(w.conf.path)
+	if err != nil {
+		log.Warn("Failed to reload puppeth configs", "file", w.conf.path, "err", err)
+		return
+	}
+	conf := config{path: w.conf.path, bootnodes: w.conf.bootnodes, ethstats: w.conf.ethstats}
+	if err := json.Unmarshal(blob, &conf); err != nil {
+		log.Warn("Failed to reload puppeth configs", "file", w.conf.path, "err", err)
+		return
+	}
+	w.conf = conf
+}
+
+// isNodeService returns whether a service is a boot or seal node.
+func isNodeService(service string) bool {
+	return service == "bootnode" || service == "sealnode"
+}
+
+// remoteError decorates a failed remote command with its output.
+func remoteError(out []byte, err error) error {
+	if out = bytes.TrimSpace(out); len(out) > 0 {
+		return fmt.Errorf("%v: %s", err, out)
+	}
+	return err
+}
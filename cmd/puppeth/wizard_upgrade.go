@@ -0,0 +1,162 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/usechain/go-usechain/log"
+)
+
+// upgradeServices checks every auxiliary service on the tracked servers for a
+// newer release of its base image, and offers to redeploy the outdated ones one
+// by one with their current configuration.
+func (w *wizard) upgradeServices() {
+	if len(w.servers) == 0 {
+		log.Info("No remote machines to check")
+		return
+	}
+	services := make([]string, 0, len(upgradableServices))
+	for service := range upgradableServices {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Server", "Service", "Image", "Base", "Status"})
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+
+	type outdated struct {
+		server  string
+		upgrade *serviceUpgrade
+	}
+	var upgrades []*outdated
+	for _, server := range w.conf.servers() {
+		client := w.servers[server]
+		if client == nil {
+			continue
+		}
+		for _, service := range services {
+			upgrade, err := checkUpgrade(client, w.network, service)
+			if err == ErrServiceUnknown {
+				continue
+			}
+			if err != nil {
+				table.Append([]string{server, service, "", "", err.Error()})
+				continue
+			}
+			status := "up to date"
+			if upgrade.available {
+				status = fmt.Sprintf("%s available", shortDigest(upgrade.latest))
+				upgrades = append(upgrades, &outdated{server: server, upgrade: upgrade})
+			}
+			table.Append([]string{server, service, shortDigest(upgrade.image), upgrade.base, status})
+		}
+	}
+	table.Render()
+
+	if len(upgrades) == 0 {
+		log.Info("All auxiliary services are up to date")
+		return
+	}
+	for _, outdated := range upgrades {
+		fmt.Println()
+		fmt.Printf("Redeploy %s on %s with its current configuration (y/n)? (default = yes)\n", outdated.upgrade.service, outdated.server)
+		if w.readDefaultString("y") != "y" {
+			continue
+		}
+		if err := w.redeployService(w.servers[outdated.server], outdated.upgrade.service); err != nil {
+			log.Error("Failed to upgrade service", "server", outdated.server, "service", outdated.upgrade.service, "err", err)
+			continue
+		}
+		log.Info("Upgraded service", "server", outdated.server, "service", outdated.upgrade.service, "image", shortDigest(w.conf.Images[outdated.server][outdated.upgrade.service]))
+	}
+	w.networkStats()
+}
+
+// redeployService rebuilds an auxiliary service from the newest release of its
+// base image, preserving the configuration of the running container along with
+// the cached genesis and ethstats settings.
+func (w *wizard) redeployService(client *sshClient, service string) error {
+	switch service {
+	case "ethstats":
+		infos, err := checkEthstats(client, w.network)
+		if err != nil {
+			return err
+		}
+		trusted := make([]string, 0, len(w.servers))
+		for _, client := range w.servers {
+			if client != nil {
+				trusted = append(trusted, client.address)
+			}
+		}
+		if out, err := deployEthstats(client, w.network, infos.port, infos.secret, infos.host, trusted, infos.banned, true); err != nil {
+			return remoteError(out, err)
+		}
+
+	case "explorer":
+		if w.conf.Genesis == nil {
+			return errors.New("no genesis block configured")
+		}
+		if w.conf.ethstats == "" {
+			return errors.New("no ethstats server configured")
+		}
+		infos, err := checkExplorer(client, w.network)
+		if err != nil {
+			return err
+		}
+		chainspec, err := newParityChainSpec(w.network, w.conf.Genesis, w.conf.bootnodes)
+		if err != nil {
+			return err
+		}
+		chain, _ := json.MarshalIndent(chainspec, "", "  ")
+		infos.ethstats = infos.ethstats + ":" + w.conf.ethstats
+
+		if out, err := deployExplorer(client, w.network, chain, infos, true); err != nil {
+			return remoteError(out, err)
+		}
+
+	case "faucet":
+		if w.conf.Genesis == nil {
+			return errors.New("no genesis block configured")
+		}
+		if w.conf.ethstats == "" {
+			return errors.New("no ethstats server configured")
+		}
+		infos, err := checkFaucet(client, w.network)
+		if err != nil {
+			return err
+		}
+		infos.node.genesis, _ = json.MarshalIndent(w.conf.Genesis, "", "  ")
+		infos.node.network = w.conf.Genesis.Config.ChainId.Int64()
+		infos.node.ethstats = infos.node.ethstats + ":" + w.conf.ethstats
+
+		if out, err := deployFaucet(client, w.network, w.conf.bootnodes, infos, true); err != nil {
+			return remoteError(out, err)
+		}
+
+	default:
+		return fmt.Errorf("%s cannot be upgraded", service)
+	}
+	w.trackImage(client, service)
+	return nil
+}
@@ -105,6 +105,8 @@ func (w *wizard) deployWallet() {
 		}
 		return
 	}
+	w.trackImage(client, "wallet")
+
 	// All ok, run a network scan to pick any changes up
 	log.Info("Waiting for node to finish booting")
 	time.Sleep(3 * time.Second)
@@ -197,7 +197,7 @@ func cmdLineOverride(currentConfig *bzzapi.Config, ctx *cli.Context) *bzzapi.Con
 
 	currentConfig.SwapApi = ctx.GlobalString(SwarmSwapAPIFlag.Name)
 	if currentConfig.SwapEnabled && currentConfig.SwapApi == "" {
-		utils.Fatalf(SWARM_ERR_SWAP_SET_NO_API)
+		utils.Fatalf("%s", SWARM_ERR_SWAP_SET_NO_API)
 	}
 
 	if ctx.GlobalIsSet(EnsAPIFlag.Name) {
@@ -273,7 +273,7 @@ func envVarsOverride(currentConfig *bzzapi.Config) (config *bzzapi.Config) {
 	}
 
 	if currentConfig.SwapEnabled && currentConfig.SwapApi == "" {
-		utils.Fatalf(SWARM_ERR_SWAP_SET_NO_API)
+		utils.Fatalf("%s", SWARM_ERR_SWAP_SET_NO_API)
 	}
 
 	if ensapi := os.Getenv(SWARM_ENV_ENS_API); ensapi != "" {
@@ -300,7 +300,7 @@ func envVarsOverride(currentConfig *bzzapi.Config) (config *bzzapi.Config) {
 func dumpConfig(ctx *cli.Context) error {
 	cfg, err := buildConfig(ctx)
 	if err != nil {
-		utils.Fatalf(fmt.Sprintf("Uh oh - dumpconfig triggered an error %v", err))
+		utils.Fatalf("Uh oh - dumpconfig triggered an error %v", err)
 	}
 	comment := ""
 	out, err := tomlSettings.Marshal(&cfg)
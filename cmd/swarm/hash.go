@@ -33,7 +33,7 @@ func hash(ctx *cli.Context) {
 	}
 	f, err := os.Open(args[0])
 	if err != nil {
-		utils.Fatalf("Error opening file " + args[1])
+		utils.Fatalf("Error opening file %s", args[1])
 	}
 	defer f.Close()
 
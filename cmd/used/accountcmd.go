@@ -38,6 +38,7 @@ import (
 	"github.com/usechain/go-usechain/accounts"
 	"github.com/usechain/go-usechain/accounts/keystore"
 	"github.com/usechain/go-usechain/cmd/utils"
+	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/console"
 	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/log"
@@ -142,6 +143,14 @@ Print a short summary of all accounts`,
 					utils.KeyStoreDirFlag,
 					utils.PasswordFileFlag,
 					utils.LightKDFFlag,
+					utils.KeyStoreKDFFlag,
+					utils.KeyStoreScryptNFlag,
+					utils.KeyStoreScryptPFlag,
+					utils.KeyStoreArgon2TimeFlag,
+					utils.KeyStoreArgon2MemoryFlag,
+					utils.KeyStoreArgon2ThreadsFlag,
+					utils.HDWalletFlag,
+					utils.HDPathFlag,
 				},
 				Description: `
     used account new
@@ -156,6 +165,13 @@ For non-interactive use the passphrase can be specified with the --password flag
 
 Note, this is meant to be used for testing only, it is a bad idea to save your
 password to file or expose in any other way.
+
+    used account new --hd [--hd.path <path>]
+
+Derives the account from a BIP-39 mnemonic instead of a random key. You are
+prompted for an existing mnemonic to import; if left empty a new one is
+generated and printed. The account is derived at the given BIP-44 path,
+m/44'/60'/0'/0/0 by default, so seed phrases of other wallets can be migrated.
 `,
 			},
 			{
@@ -336,11 +352,21 @@ func accountCreate(ctx *cli.Context) error {
 	if err != nil {
 		utils.Fatalf("Failed to read configuration: %v", err)
 	}
-
+	argon2, err := cfg.Node.Argon2Config()
+	if err != nil {
+		utils.Fatalf("Failed to read configuration: %v", err)
+	}
+	if ctx.Bool(utils.HDWalletFlag.Name) {
+		return accountCreateHD(ctx)
+	}
 	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
 
-	address, err := keystore.StoreKey(keydir, password, scryptN, scryptP)
-
+	var address common.Address
+	if argon2 != nil {
+		address, err = keystore.StoreKeyArgon2(keydir, password, *argon2)
+	} else {
+		address, err = keystore.StoreKey(keydir, password, scryptN, scryptP)
+	}
 	if err != nil {
 		utils.Fatalf("Failed to create account: %v", err)
 	}
@@ -348,6 +374,43 @@ func accountCreate(ctx *cli.Context) error {
 	return nil
 }
 
+// accountCreateHD derives a new account from a user supplied or freshly
+// generated BIP-39 mnemonic.
+func accountCreateHD(ctx *cli.Context) error {
+	path, err := accounts.ParseDerivationPath(ctx.String(utils.HDPathFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid derivation path: %v", err)
+	}
+	mnemonic, err := console.Stdin.PromptInput("Mnemonic to import (leave empty to generate a new one): ")
+	if err != nil {
+		utils.Fatalf("Failed to read mnemonic: %v", err)
+	}
+	if mnemonic = strings.TrimSpace(mnemonic); mnemonic == "" {
+		if mnemonic, err = keystore.NewMnemonic(keystore.DefaultMnemonicBits); err != nil {
+			utils.Fatalf("Failed to generate mnemonic: %v", err)
+		}
+		fmt.Printf("Your new mnemonic is:\n\n%s\n\n", mnemonic)
+		fmt.Println("Write it down and keep it safe, it is the only way to recover your account without the key file.")
+	} else if err := keystore.ValidateMnemonic(mnemonic); err != nil {
+		utils.Fatalf("Invalid mnemonic: %v", err)
+	}
+	seedPassphrase, err := console.Stdin.PromptPassword("Mnemonic passphrase (optional): ")
+	if err != nil {
+		utils.Fatalf("Failed to read mnemonic passphrase: %v", err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	password := getPassPhrase("Your new account is locked with a password. Please give a password. Do not forget this password.", true, 0, utils.MakePasswordList(ctx))
+
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account, err := ks.ImportMnemonic(mnemonic, seedPassphrase, path, password)
+	if err != nil {
+		utils.Fatalf("Failed to create account: %v", err)
+	}
+	fmt.Printf("Address: {%x}\n", account.Address)
+	fmt.Printf("Path: %s\n", path)
+	return nil
+}
+
 // accountUpdate transitions an account from a previous format to the current
 // one, also providing the possibility to change the pass-phrase.
 func accountUpdate(ctx *cli.Context) error {
@@ -598,7 +661,7 @@ func savePublicPEMKey(fileName string, pubkey rsa.PublicKey) {
 
 func checkError(err error) {
 	if err != nil {
-		utils.Fatalf("Fatal error ", err.Error())
+		utils.Fatalf("Fatal error: %v", err)
 		os.Exit(1)
 	}
 }
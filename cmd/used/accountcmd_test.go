@@ -17,7 +17,9 @@
 package main
 
 import (
+	"encoding/json"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -90,6 +92,54 @@ Fatal: Passphrases do not match
 `)
 }
 
+func TestAccountNewKDF(t *testing.T) {
+	tests := []struct {
+		args   []string
+		kdf    string
+		params map[string]float64
+	}{
+		{nil, "scrypt", nil},
+		{[]string{"--keystore.scryptn", "4", "--keystore.scryptp", "2"}, "scrypt", map[string]float64{"n": 4, "p": 2}},
+		{[]string{"--keystore.kdf", "argon2id", "--keystore.argon2.time", "1", "--keystore.argon2.memory", "1024", "--keystore.argon2.threads", "2"}, "argon2id", map[string]float64{"t": 1, "m": 1024, "p": 2}},
+	}
+	for i, tt := range tests {
+		datadir := tmpdir(t)
+		defer os.RemoveAll(datadir)
+
+		args := append([]string{"account", "new", "--lightkdf", "--datadir", datadir, "--password", "testdata/passwords.txt"}, tt.args...)
+		used := runused(t, args...)
+		used.ExpectRegexp(`Address: \{[0-9a-f]{40}\}\n`)
+		used.ExpectExit()
+
+		// Check the key derivation function the key file was written with
+		files, err := filepath.Glob(filepath.Join(datadir, "keystore", "UTC--*"))
+		if err != nil || len(files) != 1 {
+			t.Fatalf("test %d: key file mismatch: have %v (%v), want 1", i, files, err)
+		}
+		blob, err := ioutil.ReadFile(files[0])
+		if err != nil {
+			t.Fatalf("test %d: failed to read key file: %v", i, err)
+		}
+		var key struct {
+			Crypto struct {
+				KDF       string                 `json:"kdf"`
+				KDFParams map[string]interface{} `json:"kdfparams"`
+			} `json:"crypto"`
+		}
+		if err := json.Unmarshal(blob, &key); err != nil {
+			t.Fatalf("test %d: failed to decode key file: %v", i, err)
+		}
+		if key.Crypto.KDF != tt.kdf {
+			t.Errorf("test %d: kdf mismatch: have %s, want %s", i, key.Crypto.KDF, tt.kdf)
+		}
+		for name, want := range tt.params {
+			if have := key.Crypto.KDFParams[name]; have != want {
+				t.Errorf("test %d: kdf param %s mismatch: have %v, want %v", i, name, have, want)
+			}
+		}
+	}
+}
+
 func TestAccountUpdate(t *testing.T) {
 	datadir := tmpDatadirWithKeystore(t)
 	used := runused(t, "account", "update",
@@ -141,6 +141,28 @@ Remove blockchain and state databases`,
 The arguments are interpreted as block numbers or hashes.
 Use "ethereum dump 0" to dump the genesis block.`,
 	}
+	identityFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: `Output format of the identity registry export ("json", "jsonld" or "csv")`,
+		Value: "json",
+	}
+	dumpIdentitiesCommand = cli.Command{
+		Action:    utils.MigrateFlags(dumpIdentities),
+		Name:      "dump-identities",
+		Usage:     "Export the identity registry state at a specific block",
+		ArgsUsage: "[<blockHash> | <blockNum>]",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			identityFormatFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The identity registry (committee, main, sub and one time addresses) is read from
+the identity contract storage at the given block, or at the head block if none
+is specified, and written to stdout as JSON, JSON-LD or CSV. The contract keeps
+no per record timestamps, the block timestamp dates the whole export.`,
+	}
 )
 
 // initGenesis will initialise the given JSON format genesis file and writes it as
@@ -217,14 +239,16 @@ func importChain(ctx *cli.Context) error {
 	chain.Stop()
 	fmt.Printf("Import done in %v.\n\n", time.Since(start))
 
-	// Output pre-compaction stats mostly to see the import trashing
-	db := chainDb.(*ethdb.LDBDatabase)
-
-	stats, err := db.LDB().GetProperty("leveldb.stats")
-	if err != nil {
-		utils.Fatalf("Failed to read database stats: %v", err)
+	// Output pre-compaction stats mostly to see the import trashing, these are
+	// only available for LevelDB
+	db, isLDB := chainDb.(*ethdb.LDBDatabase)
+	if isLDB {
+		stats, err := db.LDB().GetProperty("leveldb.stats")
+		if err != nil {
+			utils.Fatalf("Failed to read database stats: %v", err)
+		}
+		fmt.Println(stats)
 	}
-	fmt.Println(stats)
 	fmt.Printf("Trie cache misses:  %d\n", trie.CacheMisses())
 	fmt.Printf("Trie cache unloads: %d\n\n", trie.CacheUnloads())
 
@@ -237,19 +261,19 @@ func importChain(ctx *cli.Context) error {
 	fmt.Printf("Allocations:   %.3f million\n", float64(mem.Mallocs)/1000000)
 	fmt.Printf("GC pause:      %v\n\n", time.Duration(mem.PauseTotalNs))
 
-	if ctx.GlobalIsSet(utils.NoCompactionFlag.Name) {
+	if ctx.GlobalIsSet(utils.NoCompactionFlag.Name) || !isLDB {
 		return nil
 	}
 
 	// Compact the entire database to more accurately measure disk io and print the stats
 	start = time.Now()
 	fmt.Println("Compacting entire database...")
-	if err = db.LDB().CompactRange(util.Range{}); err != nil {
+	if err := db.LDB().CompactRange(util.Range{}); err != nil {
 		utils.Fatalf("Compaction failed: %v", err)
 	}
 	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
-	stats, err = db.LDB().GetProperty("leveldb.stats")
+	stats, err := db.LDB().GetProperty("leveldb.stats")
 	if err != nil {
 		utils.Fatalf("Failed to read database stats: %v", err)
 	}
@@ -303,7 +327,7 @@ func copyDb(ctx *cli.Context) error {
 	dl := downloader.New(syncmode, chainDb, new(event.TypeMux), chain, nil, nil)
 
 	// Create a source peer to satisfy downloader requests from
-	db, err := ethdb.NewLDBDatabase(ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name), 256)
+	db, err := ethdb.Open("", ctx.Args().First(), ctx.GlobalInt(utils.CacheFlag.Name), 256, true)
 	if err != nil {
 		return err
 	}
@@ -328,12 +352,14 @@ func copyDb(ctx *cli.Context) error {
 	fmt.Printf("Database copy done in %v\n", time.Since(start))
 
 	// Compact the entire database to remove any sync overhead
-	start = time.Now()
-	fmt.Println("Compacting entire database...")
-	if err = chainDb.(*ethdb.LDBDatabase).LDB().CompactRange(util.Range{}); err != nil {
-		utils.Fatalf("Compaction failed: %v", err)
+	if ldb, ok := chainDb.(*ethdb.LDBDatabase); ok {
+		start = time.Now()
+		fmt.Println("Compacting entire database...")
+		if err = ldb.LDB().CompactRange(util.Range{}); err != nil {
+			utils.Fatalf("Compaction failed: %v", err)
+		}
+		fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 	}
-	fmt.Printf("Compaction done in %v.\n\n", time.Since(start))
 
 	return nil
 }
@@ -393,6 +419,48 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+func dumpIdentities(ctx *cli.Context) error {
+	format := ctx.String(identityFormatFlag.Name)
+	if format != "json" && format != "jsonld" && format != "csv" {
+		utils.Fatalf("Unknown export format %q", format)
+	}
+	stack := makeFullNode(ctx)
+	chain, chainDb := utils.MakeChain(ctx, stack)
+	defer chainDb.Close()
+
+	block := chain.CurrentBlock()
+	if arg := ctx.Args().First(); arg != "" {
+		if hashish(arg) {
+			block = chain.GetBlockByHash(common.HexToHash(arg))
+		} else {
+			num, _ := strconv.Atoi(arg)
+			block = chain.GetBlockByNumber(uint64(num))
+		}
+	}
+	if block == nil {
+		utils.Fatalf("block not found")
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(chainDb))
+	if err != nil {
+		utils.Fatalf("could not create new state: %v", err)
+	}
+	export := core.ExportIdentities(statedb, block.Header())
+	log.Info("Exported identity registry", "number", export.Number, "hash", export.Hash, "records", len(export.Records))
+
+	switch format {
+	case "csv":
+		return export.WriteCSV(os.Stdout)
+	case "jsonld":
+		return export.WriteJSONLD(os.Stdout)
+	}
+	out, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", out)
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
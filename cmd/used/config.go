@@ -21,8 +21,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"reflect"
+	"sort"
+	"strings"
 	"unicode"
 
 	cli "gopkg.in/urfave/cli.v1"
@@ -74,11 +77,16 @@ type ethstatsConfig struct {
 	URL string `toml:",omitempty"`
 }
 
+type bridgeConfig struct {
+	ConfigFile string `toml:",omitempty"`
+}
+
 type usedConfig struct {
 	Eth       eth.Config
 	Shh       whisper.Config
 	Node      node.Config
 	Ethstats  ethstatsConfig
+	Bridge    bridgeConfig
 	Dashboard dashboard.Config
 }
 
@@ -129,6 +137,7 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, usedConfig) {
 
 	// Apply flags.
 	utils.SetNodeConfig(ctx, &cfg.Node)
+	utils.SetReplicaP2PConfig(ctx, &cfg.Node.P2P, &cfg.Eth)
 	stack, err := node.New(&cfg.Node)
 	if err != nil {
 		utils.Fatalf("Failed to create the protocol stack: %v", err)
@@ -137,6 +146,9 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, usedConfig) {
 	if ctx.GlobalIsSet(utils.EthStatsURLFlag.Name) {
 		cfg.Ethstats.URL = ctx.GlobalString(utils.EthStatsURLFlag.Name)
 	}
+	if ctx.GlobalIsSet(utils.BridgeConfigFlag.Name) {
+		cfg.Bridge.ConfigFile = ctx.GlobalString(utils.BridgeConfigFlag.Name)
+	}
 
 	utils.SetShhConfig(ctx, stack, &cfg.Shh)
 	utils.SetDashboardConfig(ctx, &cfg.Dashboard)
@@ -179,6 +191,16 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	if cfg.Ethstats.URL != "" {
 		utils.RegisterEthStatsService(stack, cfg.Ethstats.URL)
 	}
+	// Add the cross-chain bridge if requested.
+	if cfg.Bridge.ConfigFile != "" {
+		utils.RegisterBridgeService(stack, cfg.Bridge.ConfigFile)
+	}
+	// Record the resolved configuration for admin_effectiveConfig
+	effective, err := effectiveConfig(ctx.GlobalString(configFileFlag.Name), cfg)
+	if err != nil {
+		utils.Fatalf("Failed to resolve effective configuration: %v", err)
+	}
+	stack.SetEffectiveConfig(effective)
 	return stack
 }
 
@@ -200,3 +222,120 @@ func dumpConfig(ctx *cli.Context) error {
 	os.Stdout.Write(out)
 	return nil
 }
+
+// redactedValue replaces secrets in configuration reports.
+const redactedValue = "<redacted>"
+
+// effectiveConfig assembles the fully resolved configuration of the node along
+// with the settings where it deviates from the given configuration file.
+func effectiveConfig(file string, cfg usedConfig) (*node.EffectiveConfig, error) {
+	// The genesis block is not a setting, leave it out of the report
+	cfg.Eth.Genesis = nil
+
+	out, err := tomlSettings.Marshal(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make(map[string]interface{})
+	if err := tomlSettings.Unmarshal(out, &resolved); err != nil {
+		return nil, err
+	}
+	redactConfig("", resolved)
+
+	report := &node.EffectiveConfig{
+		File:   file,
+		Config: resolved,
+		Diff:   []node.ConfigDiff{},
+	}
+	if file == "" {
+		return report, nil
+	}
+	// Compare every setting in the configuration file against its effective value
+	blob, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	loaded := make(map[string]interface{})
+	if err := tomlSettings.Unmarshal(blob, &loaded); err != nil {
+		return nil, err
+	}
+	redactConfig("", loaded)
+
+	have, want := flattenConfig("", resolved), flattenConfig("", loaded)
+	keys := make([]string, 0, len(want))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		// Zero values of omitted fields are not emitted, skip them
+		value, ok := have[key]
+		if !ok {
+			continue
+		}
+		if fmt.Sprint(value) != fmt.Sprint(want[key]) {
+			report.Diff = append(report.Diff, node.ConfigDiff{Key: key, File: want[key], Effective: value})
+		}
+	}
+	return report, nil
+}
+
+// redactConfig replaces all secret values in a decoded configuration tree.
+func redactConfig(prefix string, cfg map[string]interface{}) {
+	for key, value := range cfg {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		switch {
+		case path == "Ethstats.URL":
+			// Ethstats URLs are of the form nodename:secret@host:port
+			if url, ok := value.(string); ok {
+				if at := strings.LastIndex(url, "@"); at >= 0 {
+					if colon := strings.Index(url[:at], ":"); colon >= 0 {
+						cfg[key] = url[:colon+1] + redactedValue + url[at:]
+					}
+				}
+			}
+		case configSecret(key):
+			cfg[key] = redactedValue
+		default:
+			if table, ok := value.(map[string]interface{}); ok {
+				redactConfig(path, table)
+			}
+		}
+	}
+}
+
+// configSecret reports whether a configuration key holds a value that must
+// never be exposed over RPC.
+func configSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range []string{"password", "passphrase", "secret", "privatekey"} {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenConfig converts a decoded configuration tree into a map of dotted
+// setting names to their values.
+func flattenConfig(prefix string, cfg map[string]interface{}) map[string]interface{} {
+	flat := make(map[string]interface{})
+	for key, value := range cfg {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		if table, ok := value.(map[string]interface{}); ok {
+			for k, v := range flattenConfig(path, table) {
+				flat[k] = v
+			}
+			continue
+		}
+		flat[path] = value
+	}
+	return flat
+}
@@ -0,0 +1,150 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/usechain/go-usechain/dashboard"
+	"github.com/usechain/go-usechain/eth"
+	whisper "github.com/usechain/go-usechain/whisper/whisperv5"
+)
+
+var redactConfigTests = []struct {
+	config map[string]interface{}
+	result map[string]interface{}
+}{
+	// Plain settings are reported as is
+	{
+		config: map[string]interface{}{"Eth": map[string]interface{}{"NetworkId": int64(1)}},
+		result: map[string]interface{}{"Eth": map[string]interface{}{"NetworkId": int64(1)}},
+	},
+	// Every class of secret is redacted, regardless of capitalisation and depth
+	{
+		config: map[string]interface{}{"Node": map[string]interface{}{"KeystorePassword": "hunter2"}},
+		result: map[string]interface{}{"Node": map[string]interface{}{"KeystorePassword": redactedValue}},
+	},
+	{
+		config: map[string]interface{}{"Eth": map[string]interface{}{"unlockpassphrase": "hunter2"}},
+		result: map[string]interface{}{"Eth": map[string]interface{}{"unlockpassphrase": redactedValue}},
+	},
+	{
+		config: map[string]interface{}{"Eth": map[string]interface{}{"ReplicaSecret": "hunter2", "NetworkId": int64(1)}},
+		result: map[string]interface{}{"Eth": map[string]interface{}{"ReplicaSecret": redactedValue, "NetworkId": int64(1)}},
+	},
+	{
+		config: map[string]interface{}{"Node": map[string]interface{}{"P2P": map[string]interface{}{"PrivateKey": "0badc0de"}}},
+		result: map[string]interface{}{"Node": map[string]interface{}{"P2P": map[string]interface{}{"PrivateKey": redactedValue}}},
+	},
+	// Tables named as secrets are redacted as a whole
+	{
+		config: map[string]interface{}{"Secrets": map[string]interface{}{"Token": "hunter2"}},
+		result: map[string]interface{}{"Secrets": redactedValue},
+	},
+	// Ethstats URLs only have their secret redacted
+	{
+		config: map[string]interface{}{"Ethstats": map[string]interface{}{"URL": "node:hunter2@stats.example.com:3000"}},
+		result: map[string]interface{}{"Ethstats": map[string]interface{}{"URL": "node:" + redactedValue + "@stats.example.com:3000"}},
+	},
+	{
+		config: map[string]interface{}{"Ethstats": map[string]interface{}{"URL": "node@stats.example.com:3000"}},
+		result: map[string]interface{}{"Ethstats": map[string]interface{}{"URL": "node@stats.example.com:3000"}},
+	},
+}
+
+// Tests that secrets are redacted from configuration reports.
+func TestRedactConfig(t *testing.T) {
+	for i, tt := range redactConfigTests {
+		redactConfig("", tt.config)
+		if !reflect.DeepEqual(tt.config, tt.result) {
+			t.Errorf("test %d: config mismatch: have %v, want %v", i, tt.config, tt.result)
+		}
+	}
+}
+
+// Tests that the effective configuration is redacted and only reports the
+// settings deviating from the configuration file.
+func TestEffectiveConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "used-config-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "config.toml")
+	blob := `
+[Eth]
+NetworkId = 5
+ReplicaSecret = "file-secret"
+
+[Node]
+DataDir = "/tmp/used"
+
+[Ethstats]
+URL = "node:file-secret@stats.example.com:3000"
+`
+	if err := ioutil.WriteFile(file, []byte(blob), 0600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	cfg := usedConfig{
+		Eth:       eth.DefaultConfig,
+		Shh:       whisper.DefaultConfig,
+		Node:      defaultNodeConfig(),
+		Dashboard: dashboard.DefaultConfig,
+	}
+	if err := loadConfig(file, &cfg); err != nil {
+		t.Fatalf("failed to load config file: %v", err)
+	}
+	// Override a plain setting and the secrets, as command line flags would
+	cfg.Eth.NetworkId = 7
+	cfg.Eth.ReplicaSecret = "flag-secret"
+	cfg.Ethstats.URL = "node:flag-secret@stats.example.com:3000"
+
+	report, err := effectiveConfig(file, cfg)
+	if err != nil {
+		t.Fatalf("failed to resolve effective config: %v", err)
+	}
+	if report.File != file {
+		t.Errorf("config file mismatch: have %s, want %s", report.File, file)
+	}
+	if secret := report.Config["Eth"].(map[string]interface{})["ReplicaSecret"]; secret != redactedValue {
+		t.Errorf("replica secret not redacted: %v", secret)
+	}
+	if url := report.Config["Ethstats"].(map[string]interface{})["URL"]; url != "node:"+redactedValue+"@stats.example.com:3000" {
+		t.Errorf("ethstats secret not redacted: %v", url)
+	}
+	// Only the overridden plain setting may be reported, secrets compare redacted
+	if len(report.Diff) != 1 {
+		t.Fatalf("diff length mismatch: have %d, want 1: %v", len(report.Diff), report.Diff)
+	}
+	diff := report.Diff[0]
+	if diff.Key != "Eth.NetworkId" || fmt.Sprint(diff.File) != "5" || fmt.Sprint(diff.Effective) != "7" {
+		t.Errorf("diff mismatch: have %s %v -> %v, want Eth.NetworkId 5 -> 7", diff.Key, diff.File, diff.Effective)
+	}
+	// Without a configuration file there is nothing to compare against
+	if report, err = effectiveConfig("", cfg); err != nil {
+		t.Fatalf("failed to resolve effective config: %v", err)
+	}
+	if len(report.Diff) != 0 {
+		t.Errorf("diff reported without config file: %v", report.Diff)
+	}
+}
@@ -31,8 +31,8 @@ import (
 )
 
 const (
-	ipcAPIs  = "admin:1.0 debug:1.0 usechain:1.0 miner:1.0 net:1.0 personal:1.0 rpc:1.0 shh:1.0 txpool:1.0 web3:1.0"
-	httpAPIs = "usechain:1.0 net:1.0 rpc:1.0 web3:1.0"
+	ipcAPIs  = "admin:1.0 builder:1.0 committee:1.0 debug:1.0 eth:1.0 miner:1.0 net:1.0 personal:1.0 private:1.0 rpc:1.0 shh:1.0 txpool:1.0 use:1.0 usx:1.0 web3:1.0 webhook:1.0"
+	httpAPIs = "eth:1.0 net:1.0 rpc:1.0 use:1.0 web3:1.0"
 )
 
 // Tests that a node embedded within a console can be started up properly and
@@ -56,7 +56,7 @@ func TestConsoleWelcome(t *testing.T) {
 
 	// Verify the actual welcome message to the required template
 	used.Expect(`
-Welcome to the used JavaScript console!
+Welcome to the Used JavaScript console!
 
 instance: used/v{{usedver}}/{{goos}}-{{goarch}}/{{gover}}
 coinbase: {{.Usebase}}
@@ -142,7 +142,7 @@ func testAttachWelcome(t *testing.T, used *testused, endpoint, apis string) {
 
 	// Verify the actual welcome message to the required template
 	attach.Expect(`
-Welcome to the used JavaScript console!
+Welcome to the Used JavaScript console!
 
 instance: used/v{{usedver}}/{{goos}}-{{goarch}}/{{gover}}
 coinbase: {{usebase}}
@@ -0,0 +1,489 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"reflect"
+	"strconv"
+	"time"
+
+	ethereum "github.com/usechain/go-usechain"
+	"github.com/usechain/go-usechain/accounts/abi"
+	"github.com/usechain/go-usechain/accounts/keystore"
+	"github.com/usechain/go-usechain/cmd/utils"
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/ethclient"
+	"github.com/usechain/go-usechain/node"
+	"github.com/usechain/go-usechain/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// receiptTimeout is the maximum time to wait for a sent transaction to be mined.
+const receiptTimeout = 5 * time.Minute
+
+var (
+	contractAttachFlag = cli.StringFlag{
+		Name:  "attach",
+		Value: node.DefaultIPCEndpoint(clientIdentifier),
+		Usage: "API endpoint to attach to",
+	}
+	contractABIFlag = cli.StringFlag{
+		Name:  "abi",
+		Usage: "File containing the JSON ABI of the contract",
+	}
+	contractAddressFlag = cli.StringFlag{
+		Name:  "address",
+		Usage: "Address of the contract",
+	}
+	contractFromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Account to call or send from",
+	}
+	contractBlockFlag = cli.StringFlag{
+		Name:  "block",
+		Value: "latest",
+		Usage: "Block to call the contract at (number, \"latest\" or \"pending\")",
+	}
+	contractValueFlag = cli.StringFlag{
+		Name:  "value",
+		Value: "0",
+		Usage: "Amount of wei to send along",
+	}
+	contractGasFlag = cli.Uint64Flag{
+		Name:  "gas",
+		Usage: "Gas limit of the transaction (0 = estimate)",
+	}
+	contractGasPriceFlag = cli.StringFlag{
+		Name:  "gasprice",
+		Usage: "Gas price of the transaction in wei (default = suggested by the node)",
+	}
+	contractChainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain id to sign the transaction for (0 = network id of the node)",
+	}
+	contractNodeSignFlag = cli.BoolFlag{
+		Name:  "nodesign",
+		Usage: "Have the attached node sign with its accounts instead of the local keystore",
+	}
+	contractWaitFlag = cli.BoolFlag{
+		Name:  "wait",
+		Usage: "Wait for the transaction to be mined and print the emitted events",
+	}
+
+	contractCommand = cli.Command{
+		Name:     "contract",
+		Usage:    "Interact with deployed contracts",
+		Category: "CONTRACT COMMANDS",
+		Description: `
+Call contract methods and send transactions to them by name, with the arguments
+encoded and the results decoded according to the contract's JSON ABI.
+
+Arguments are given in their textual form: numbers in decimal or 0x-prefixed
+hex, bytes in hex and arrays as JSON lists (e.g. '[1, 2]').`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "call",
+				Usage:     "Call a contract method without creating a transaction",
+				Action:    utils.MigrateFlags(contractCall),
+				ArgsUsage: "<method> [arguments...]",
+				Flags: []cli.Flag{
+					contractAttachFlag,
+					contractABIFlag,
+					contractAddressFlag,
+					contractFromFlag,
+					contractBlockFlag,
+				},
+				Description: `
+    used contract call --abi <file> --address <address> <method> [arguments...]
+
+Executes a contract method locally on the attached node and prints the decoded
+return values.`,
+			},
+			{
+				Name:      "send",
+				Usage:     "Send a transaction invoking a contract method",
+				Action:    utils.MigrateFlags(contractSend),
+				ArgsUsage: "<method> [arguments...]",
+				Flags: []cli.Flag{
+					contractAttachFlag,
+					contractABIFlag,
+					contractAddressFlag,
+					contractFromFlag,
+					contractValueFlag,
+					contractGasFlag,
+					contractGasPriceFlag,
+					contractChainIDFlag,
+					contractNodeSignFlag,
+					contractWaitFlag,
+					utils.DataDirFlag,
+					utils.KeyStoreDirFlag,
+					utils.PasswordFileFlag,
+					utils.TestnetFlag,
+					utils.MoonetFlag,
+				},
+				Description: `
+    used contract send --abi <file> --address <address> --from <account> <method> [arguments...]
+
+Signs a transaction invoking a contract method with an account of the local
+keystore, unlocked interactively or via --password, and submits it through the
+attached node. With --nodesign the transaction is signed by the node instead,
+subject to its account unlocking and signing approval rules.
+
+With --wait the command waits for the transaction to be mined and prints the
+decoded events emitted by the contract.`,
+			},
+		},
+	}
+)
+
+// boundContract is a contract ABI bound to an address, along with the method
+// invoked on it and its parsed arguments.
+type boundContract struct {
+	abi     abi.ABI
+	address common.Address
+	method  abi.Method
+	args    []interface{}
+}
+
+// parseInvocation loads the contract ABI and parses the invoked method along
+// with its arguments from the command line.
+func parseInvocation(ctx *cli.Context) *boundContract {
+	file := ctx.String(contractABIFlag.Name)
+	if file == "" {
+		utils.Fatalf("Contract ABI must be given with --%s", contractABIFlag.Name)
+	}
+	in, err := os.Open(file)
+	if err != nil {
+		utils.Fatalf("Failed to open ABI: %v", err)
+	}
+	defer in.Close()
+
+	parsed, err := abi.JSON(in)
+	if err != nil {
+		utils.Fatalf("Failed to parse ABI: %v", err)
+	}
+	address := ctx.String(contractAddressFlag.Name)
+	if !common.IsHexAddress(address) {
+		utils.Fatalf("Invalid contract address %q", address)
+	}
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("No contract method specified")
+	}
+	method, ok := parsed.Methods[ctx.Args().First()]
+	if !ok {
+		utils.Fatalf("Unknown contract method %q", ctx.Args().First())
+	}
+	inputs := ctx.Args().Tail()
+	if len(inputs) != len(method.Inputs) {
+		utils.Fatalf("Argument count mismatch for %s: have %d, want %d", method.Sig(), len(inputs), len(method.Inputs))
+	}
+	args := make([]interface{}, len(inputs))
+	for i, input := range inputs {
+		if args[i], err = abi.ParseValue(method.Inputs[i].Type, input); err != nil {
+			utils.Fatalf("Invalid argument %s: %v", method.Inputs[i].Name, err)
+		}
+	}
+	return &boundContract{
+		abi:     parsed,
+		address: common.HexToAddress(address),
+		method:  method,
+		args:    args,
+	}
+}
+
+// calldata packs the invoked method and its arguments into transaction input.
+func (c *boundContract) calldata() []byte {
+	input, err := c.abi.Pack(c.method.Name, c.args...)
+	if err != nil {
+		utils.Fatalf("Failed to encode arguments: %v", err)
+	}
+	return input
+}
+
+// contractCall executes a contract method without a transaction and prints the
+// decoded return values.
+func contractCall(ctx *cli.Context) error {
+	contract := parseInvocation(ctx)
+
+	client, err := dialRPC(ctx.String(contractAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to node: %v", err)
+	}
+	defer client.Close()
+
+	msg := ethereum.CallMsg{To: &contract.address, Data: contract.calldata()}
+	if from := ctx.String(contractFromFlag.Name); from != "" {
+		if !common.IsHexAddress(from) {
+			utils.Fatalf("Invalid sender address %q", from)
+		}
+		msg.From = common.HexToAddress(from)
+	}
+	var (
+		ec     = ethclient.NewClient(client)
+		output []byte
+	)
+	switch block := ctx.String(contractBlockFlag.Name); block {
+	case "latest":
+		output, err = ec.CallContract(context.Background(), msg, nil)
+	case "pending":
+		output, err = ec.PendingCallContract(context.Background(), msg)
+	default:
+		number, perr := strconv.ParseUint(block, 0, 64)
+		if perr != nil {
+			utils.Fatalf("Invalid block %q", block)
+		}
+		output, err = ec.CallContract(context.Background(), msg, new(big.Int).SetUint64(number))
+	}
+	if err != nil {
+		utils.Fatalf("Contract call failed: %v", err)
+	}
+	values, err := contract.method.Outputs.UnpackValues(output)
+	if err != nil {
+		utils.Fatalf("Failed to decode return values: %v", err)
+	}
+	printValues(contract.method.Outputs, values)
+	return nil
+}
+
+// contractSend signs and submits a transaction invoking a contract method,
+// optionally waiting for it to be mined.
+func contractSend(ctx *cli.Context) error {
+	contract := parseInvocation(ctx)
+
+	from := ctx.String(contractFromFlag.Name)
+	if !common.IsHexAddress(from) {
+		utils.Fatalf("Sender account must be given with --%s", contractFromFlag.Name)
+	}
+	value, ok := math.ParseBig256(ctx.String(contractValueFlag.Name))
+	if !ok {
+		utils.Fatalf("Invalid value %q", ctx.String(contractValueFlag.Name))
+	}
+	var gasPrice *big.Int
+	if price := ctx.String(contractGasPriceFlag.Name); price != "" {
+		if gasPrice, ok = math.ParseBig256(price); !ok {
+			utils.Fatalf("Invalid gas price %q", price)
+		}
+	}
+	client, err := dialRPC(ctx.String(contractAttachFlag.Name))
+	if err != nil {
+		utils.Fatalf("Unable to attach to node: %v", err)
+	}
+	defer client.Close()
+
+	var (
+		ec    = ethclient.NewClient(client)
+		input = contract.calldata()
+		hash  common.Hash
+	)
+	if ctx.Bool(contractNodeSignFlag.Name) {
+		hash = sendNodeSigned(client, common.HexToAddress(from), contract.address, value, ctx.Uint64(contractGasFlag.Name), gasPrice, input)
+	} else {
+		hash = sendKeystoreSigned(ctx, ec, from, contract.address, value, ctx.Uint64(contractGasFlag.Name), gasPrice, input)
+	}
+	fmt.Printf("Transaction: %s\n", hash.Hex())
+
+	if !ctx.Bool(contractWaitFlag.Name) {
+		return nil
+	}
+	receipt := waitReceipt(ec, hash)
+	fmt.Printf("Status:      %d\n", receipt.Status)
+	fmt.Printf("Gas used:    %d\n", receipt.GasUsed)
+
+	printEvents(contract, receipt.Logs)
+	return nil
+}
+
+// sendNodeSigned submits an unsigned transaction to the attached node, which
+// signs it with one of its own accounts.
+func sendNodeSigned(client *rpc.Client, from, to common.Address, value *big.Int, gas uint64, gasPrice *big.Int, input []byte) common.Hash {
+	args := map[string]interface{}{
+		"from":  from,
+		"to":    to,
+		"value": (*hexutil.Big)(value),
+		"data":  hexutil.Bytes(input),
+	}
+	if gas != 0 {
+		args["gas"] = hexutil.Uint64(gas)
+	}
+	if gasPrice != nil {
+		args["gasPrice"] = (*hexutil.Big)(gasPrice)
+	}
+	var hash common.Hash
+	if err := client.CallContext(context.Background(), &hash, "eth_sendTransaction", args); err != nil {
+		utils.Fatalf("Failed to send transaction: %v", err)
+	}
+	return hash
+}
+
+// sendKeystoreSigned assembles a transaction, signs it with an account from the
+// local keystore and submits it to the attached node.
+func sendKeystoreSigned(ctx *cli.Context, ec *ethclient.Client, from string, to common.Address, value *big.Int, gas uint64, gasPrice *big.Int, input []byte) common.Hash {
+	stack, _ := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+	account, password := unlockAccount(ctx, ks, from, 0, utils.MakePasswordList(ctx))
+
+	background := context.Background()
+	nonce, err := ec.PendingNonceAt(background, account.Address)
+	if err != nil {
+		utils.Fatalf("Failed to retrieve account nonce: %v", err)
+	}
+	if gasPrice == nil {
+		if gasPrice, err = ec.SuggestGasPrice(background); err != nil {
+			utils.Fatalf("Failed to suggest gas price: %v", err)
+		}
+	}
+	if gas == 0 {
+		msg := ethereum.CallMsg{From: account.Address, To: &to, Value: value, Data: input}
+		if gas, err = ec.EstimateGas(background, msg); err != nil {
+			utils.Fatalf("Failed to estimate gas: %v", err)
+		}
+	}
+	chainID := new(big.Int).SetUint64(ctx.Uint64(contractChainIDFlag.Name))
+	if chainID.Sign() == 0 {
+		if chainID, err = ec.NetworkID(background); err != nil {
+			utils.Fatalf("Failed to retrieve network id: %v", err)
+		}
+	}
+	tx := types.NewTransaction(nonce, to, value, gas, gasPrice, input)
+	signed, err := ks.SignTxWithPassphrase(account, password, tx, chainID)
+	if err != nil {
+		utils.Fatalf("Failed to sign transaction: %v", err)
+	}
+	if err := ec.SendTransaction(background, signed); err != nil {
+		utils.Fatalf("Failed to send transaction: %v", err)
+	}
+	return signed.Hash()
+}
+
+// waitReceipt polls the node until the transaction is mined.
+func waitReceipt(ec *ethclient.Client, hash common.Hash) *types.Receipt {
+	deadline := time.Now().Add(receiptTimeout)
+	for time.Now().Before(deadline) {
+		receipt, err := ec.TransactionReceipt(context.Background(), hash)
+		if err == nil && receipt != nil {
+			return receipt
+		}
+		if err != nil && err != ethereum.NotFound {
+			utils.Fatalf("Failed to retrieve receipt: %v", err)
+		}
+		time.Sleep(time.Second)
+	}
+	utils.Fatalf("Transaction not mined within %v", receiptTimeout)
+	return nil
+}
+
+// printEvents decodes and prints the logs emitted by the contract which match
+// an event of its ABI.
+func printEvents(contract *boundContract, logs []*types.Log) {
+	for _, log := range logs {
+		if log.Address != contract.address || len(log.Topics) == 0 {
+			continue
+		}
+		for _, event := range contract.abi.Events {
+			if event.Anonymous || event.Id() != log.Topics[0] {
+				continue
+			}
+			fmt.Printf("Event %s:\n", event.Name)
+
+			data, err := event.Inputs.NonIndexed().UnpackValues(log.Data)
+			if err != nil {
+				fmt.Printf("  failed to decode: %v\n", err)
+				break
+			}
+			topics := log.Topics[1:]
+			for _, input := range event.Inputs {
+				var value interface{}
+				if input.Indexed {
+					if len(topics) == 0 {
+						break
+					}
+					value, topics = decodeTopic(input, topics[0]), topics[1:]
+				} else {
+					value, data = data[0], data[1:]
+				}
+				fmt.Printf("  %s: %s\n", input.Name, formatValue(value))
+			}
+			break
+		}
+	}
+}
+
+// decodeTopic decodes an indexed event argument. Dynamic values are only known
+// by their hash.
+func decodeTopic(arg abi.Argument, topic common.Hash) interface{} {
+	switch arg.Type.T {
+	case abi.StringTy, abi.BytesTy, abi.SliceTy, abi.ArrayTy:
+		return topic
+	}
+	values, err := abi.Arguments{{Type: arg.Type}}.UnpackValues(topic[:])
+	if err != nil {
+		return topic
+	}
+	return values[0]
+}
+
+// printValues prints decoded values along with the names of their arguments.
+func printValues(args abi.Arguments, values []interface{}) {
+	for i, value := range values {
+		name := args[i].Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		fmt.Printf("%s (%s): %s\n", name, args[i].Type, formatValue(value))
+	}
+}
+
+// formatValue formats a decoded ABI value, printing bytes in hex.
+func formatValue(value interface{}) string {
+	switch value := value.(type) {
+	case []byte:
+		return hexutil.Encode(value)
+	case common.Address:
+		return value.Hex()
+	case common.Hash:
+		return value.Hex()
+	case *big.Int:
+		return value.String()
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			blob := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(blob), v)
+			return hexutil.Encode(blob)
+		}
+		fallthrough
+	case reflect.Slice:
+		out := "["
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				out += ", "
+			}
+			out += formatValue(v.Index(i).Interface())
+		}
+		return out + "]"
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,422 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/usechain/go-usechain/accounts"
+	"github.com/usechain/go-usechain/accounts/keystore"
+	"github.com/usechain/go-usechain/cmd/utils"
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/eth"
+	"github.com/usechain/go-usechain/eth/downloader"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/node"
+	"github.com/usechain/go-usechain/p2p"
+	"github.com/usechain/go-usechain/p2p/discover"
+	"github.com/usechain/go-usechain/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	devnetNodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Value: 3,
+		Usage: "Number of nodes to run in the local network",
+	}
+	devnetPeriodFlag = cli.DurationFlag{
+		Name:  "period",
+		Value: 5 * time.Second,
+		Usage: "Interval between blocks, each produced by the next node in turn",
+	}
+	devnetSubprocessFlag = cli.BoolFlag{
+		Name:  "subprocess",
+		Usage: "Run each node as a separate used process instead of in-process",
+	}
+	devnetCommand = cli.Command{
+		Action:    utils.MigrateFlags(devnet),
+		Name:      "devnet",
+		Usage:     "Launch a local multi-node development network",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			devnetNodesFlag,
+			devnetPeriodFlag,
+			devnetSubprocessFlag,
+			utils.DataDirFlag,
+			utils.NetworkIdFlag,
+			utils.ListenPortFlag,
+			utils.RPCEnabledFlag,
+			utils.RPCPortFlag,
+		},
+		Category: "DEVELOPER COMMANDS",
+		Description: `
+The devnet command starts a private network of N nodes on the local machine,
+either inside this process or as one used subprocess per node. Each node gets
+its own data directory (<datadir>/node<i>) holding a freshly generated miner
+account, unlocked with an empty password.
+
+The nodes share a generated genesis block in which the system contracts are
+deployed and every node's account is pre-registered in the RPOW miner list, so
+the committee is ready to mine from the first block. Nodes are connected to
+each other in a full mesh and produce blocks in turn, one every --period, with
+proof-of-work verification disabled.
+
+Node i listens on --port + i, and if --rpc is set, serves HTTP-RPC on
+--rpcport + i. Existing data directories are reused, so a devnet can be stopped
+and resumed as long as the node count stays the same.`,
+	}
+)
+
+// devnetNode is a member of a local development network, running either
+// in-process or as a subprocess.
+type devnetNode struct {
+	index   int
+	datadir string
+	account accounts.Account
+	client  *rpc.Client
+
+	stack *node.Node // Set if the node runs in-process
+	cmd   *exec.Cmd  // Set if the node runs as a subprocess
+}
+
+// devnetConfig contains the settings shared by all nodes of a devnet.
+type devnetConfig struct {
+	datadir    string
+	networkID  uint64
+	port       int
+	rpcPort    int // Zero if HTTP-RPC is disabled
+	subprocess bool
+}
+
+// devnet launches a local multi-node network and drives block production
+// until interrupted.
+func devnet(ctx *cli.Context) error {
+	config := &devnetConfig{
+		datadir:    filepath.Join(node.DefaultDataDir(), "devnet"),
+		networkID:  1337,
+		port:       ctx.GlobalInt(utils.ListenPortFlag.Name),
+		subprocess: ctx.Bool(devnetSubprocessFlag.Name),
+	}
+	if ctx.GlobalIsSet(utils.DataDirFlag.Name) {
+		config.datadir = ctx.GlobalString(utils.DataDirFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.NetworkIdFlag.Name) {
+		config.networkID = ctx.GlobalUint64(utils.NetworkIdFlag.Name)
+	}
+	if ctx.GlobalBool(utils.RPCEnabledFlag.Name) {
+		config.rpcPort = ctx.GlobalInt(utils.RPCPortFlag.Name)
+	}
+	count := ctx.Int(devnetNodesFlag.Name)
+	if count < 1 {
+		utils.Fatalf("Devnet needs at least one node")
+	}
+	period := ctx.Duration(devnetPeriodFlag.Name)
+	if period < time.Second {
+		utils.Fatalf("Block period must be at least one second")
+	}
+	nodes, err := startDevnet(config, count)
+	if err != nil {
+		utils.Fatalf("Failed to start devnet: %v", err)
+	}
+	defer stopDevnet(nodes)
+
+	if err := connectDevnet(nodes); err != nil {
+		utils.Fatalf("Failed to connect devnet: %v", err)
+	}
+	for _, n := range nodes {
+		fmt.Printf("Node %d: miner %s, datadir %s\n", n.index, n.account.Address.Hex(), n.datadir)
+	}
+	// Produce blocks in turn until the user interrupts
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := produceDevnetBlock(nodes, period); err != nil {
+				log.Warn("Devnet block production failed", "err", err)
+			}
+		case <-sigc:
+			log.Info("Shutting down devnet", "nodes", len(nodes))
+			return nil
+		}
+	}
+}
+
+// startDevnet creates or reuses the miner accounts of count nodes, generates
+// the shared genesis block and boots all the nodes on top of it.
+func startDevnet(config *devnetConfig, count int) ([]*devnetNode, error) {
+	nodes := make([]*devnetNode, count)
+	miners := make([]common.Address, count)
+	for i := range nodes {
+		datadir := filepath.Join(config.datadir, fmt.Sprintf("node%d", i))
+		account, err := devnetAccount(datadir)
+		if err != nil {
+			return nil, err
+		}
+		nodes[i] = &devnetNode{index: i, datadir: datadir, account: account}
+		miners[i] = account.Address
+	}
+	genesis := core.DevnetGenesisBlock(config.networkID, miners)
+
+	for i, n := range nodes {
+		var err error
+		if config.subprocess {
+			err = n.startSubprocess(config, genesis)
+		} else {
+			err = n.startInProcess(config, genesis)
+		}
+		if err != nil {
+			stopDevnet(nodes[:i])
+			return nil, fmt.Errorf("node %d: %v", i, err)
+		}
+		log.Info("Started devnet node", "index", i, "miner", n.account.Address, "datadir", n.datadir)
+	}
+	return nodes, nil
+}
+
+// stopDevnet terminates all the given nodes.
+func stopDevnet(nodes []*devnetNode) {
+	for _, n := range nodes {
+		if err := n.stop(); err != nil {
+			log.Warn("Failed to stop devnet node", "index", n.index, "err", err)
+		}
+	}
+}
+
+// devnetAccount returns the miner account stored in a node's data directory,
+// creating one protected by an empty password if there is none yet.
+func devnetAccount(datadir string) (accounts.Account, error) {
+	ks := keystore.NewKeyStore(filepath.Join(datadir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	if accs := ks.Accounts(); len(accs) > 0 {
+		return accs[0], nil
+	}
+	return ks.NewAccount("")
+}
+
+// startInProcess boots the node within the current process.
+func (n *devnetNode) startInProcess(config *devnetConfig, genesis *core.Genesis) error {
+	cfg := defaultNodeConfig()
+	cfg.DataDir = n.datadir
+	cfg.UseLightweightKDF = true
+	cfg.P2P.ListenAddr = fmt.Sprintf("127.0.0.1:%d", config.port+n.index)
+	cfg.P2P.NoDiscovery = true
+	if config.rpcPort != 0 {
+		cfg.HTTPHost = node.DefaultHTTPHost
+		cfg.HTTPPort = config.rpcPort + n.index
+	}
+	stack, err := node.New(&cfg)
+	if err != nil {
+		return err
+	}
+	ethcfg := eth.DefaultConfig
+	ethcfg.NetworkId = config.networkID
+	ethcfg.Genesis = genesis
+	ethcfg.SyncMode = downloader.FullSync
+	ethcfg.Usebase = n.account.Address
+	ethcfg.Ethash.PowMode = ethash.ModeFake
+	utils.RegisterEthService(stack, &ethcfg)
+
+	if err := stack.Start(); err != nil {
+		return err
+	}
+	n.stack = stack
+
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	if err := ks.Unlock(n.account, ""); err != nil {
+		return err
+	}
+	n.client, err = stack.Attach()
+	return err
+}
+
+// startSubprocess initializes the node's database with the genesis block and
+// runs it as a separate process of the current executable, logging to the
+// node.log file of its data directory.
+func (n *devnetNode) startSubprocess(config *devnetConfig, genesis *core.Genesis) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	blob, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		return err
+	}
+	genesisPath := filepath.Join(n.datadir, "genesis.json")
+	if err := ioutil.WriteFile(genesisPath, blob, 0644); err != nil {
+		return err
+	}
+	passwordPath := filepath.Join(n.datadir, "password")
+	if err := ioutil.WriteFile(passwordPath, nil, 0600); err != nil {
+		return err
+	}
+	if out, err := exec.Command(exe, "--datadir", n.datadir, "init", genesisPath).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to initialize: %v\n%s", err, out)
+	}
+	args := []string{
+		"--datadir", n.datadir,
+		"--networkid", strconv.FormatUint(config.networkID, 10),
+		"--port", strconv.Itoa(config.port + n.index),
+		"--syncmode", "full",
+		"--nodiscover", "--fakepow", "--lightkdf",
+		"--usebase", n.account.Address.Hex(),
+		"--unlock", n.account.Address.Hex(),
+		"--password", passwordPath,
+	}
+	if config.rpcPort != 0 {
+		args = append(args, "--rpc", "--rpcport", strconv.Itoa(config.rpcPort+n.index))
+	}
+	logfile, err := os.Create(filepath.Join(n.datadir, "node.log"))
+	if err != nil {
+		return err
+	}
+	defer logfile.Close()
+
+	cmd := exec.Command(exe, args...)
+	cmd.Stdout, cmd.Stderr = logfile, logfile
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	n.cmd = cmd
+
+	// Wait for the node to open its IPC endpoint
+	endpoint := filepath.Join(n.datadir, "used.ipc")
+	for deadline := time.Now().Add(30 * time.Second); ; {
+		if n.client, err = rpc.Dial(endpoint); err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("IPC endpoint %s unavailable: %v", endpoint, err)
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// stop closes the node's RPC client and shuts the node down, killing it if it
+// runs as a subprocess and doesn't exit in time.
+func (n *devnetNode) stop() error {
+	if n.client != nil {
+		n.client.Close()
+	}
+	if n.stack != nil {
+		return n.stack.Stop()
+	}
+	if n.cmd == nil {
+		return nil
+	}
+	if err := n.cmd.Process.Signal(os.Interrupt); err != nil {
+		return n.cmd.Process.Kill()
+	}
+	done := make(chan error, 1)
+	go func() { done <- n.cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(10 * time.Second):
+		return n.cmd.Process.Kill()
+	}
+}
+
+// connectDevnet links all nodes into a full mesh and waits until every node
+// sees all the others.
+func connectDevnet(nodes []*devnetNode) error {
+	enodes := make([]string, len(nodes))
+	for i, n := range nodes {
+		var info p2p.NodeInfo
+		if err := n.client.Call(&info, "admin_nodeInfo"); err != nil {
+			return err
+		}
+		self, err := discover.ParseNode(info.Enode)
+		if err != nil {
+			return err
+		}
+		// Nodes listen locally, dial them via the loopback interface
+		enodes[i] = discover.NewNode(self.ID, net.IPv4(127, 0, 0, 1), self.UDP, self.TCP).String()
+	}
+	for i, n := range nodes {
+		for _, enode := range enodes[i+1:] {
+			if err := n.client.Call(nil, "admin_addPeer", enode); err != nil {
+				return err
+			}
+		}
+	}
+	for _, n := range nodes {
+		for deadline := time.Now().Add(30 * time.Second); ; {
+			var peers []*p2p.PeerInfo
+			if err := n.client.Call(&peers, "admin_peers"); err != nil {
+				return err
+			}
+			if len(peers) >= len(nodes)-1 {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("node %d connected to %d of %d peers", n.index, len(peers), len(nodes)-1)
+			}
+			time.Sleep(250 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// produceDevnetBlock has the node whose turn it is mine exactly one block on
+// top of the current head, waiting at most timeout for it to be sealed.
+func produceDevnetBlock(nodes []*devnetNode, timeout time.Duration) error {
+	var head hexutil.Uint64
+	if err := nodes[0].client.Call(&head, "eth_blockNumber"); err != nil {
+		return err
+	}
+	producer := nodes[(uint64(head)+1)%uint64(len(nodes))]
+
+	if err := producer.client.Call(nil, "miner_start", 1); err != nil {
+		return err
+	}
+	defer producer.client.Call(nil, "miner_stop")
+
+	for deadline := time.Now().Add(timeout); ; {
+		var number hexutil.Uint64
+		if err := producer.client.Call(&number, "eth_blockNumber"); err != nil {
+			return err
+		}
+		if number > head {
+			log.Info("Devnet block produced", "number", uint64(number), "node", producer.index)
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("node %d failed to seal block %d", producer.index, uint64(head)+1)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,282 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/usechain/go-usechain/cmd/utils"
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/params"
+	"github.com/usechain/go-usechain/rpc"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	verifyGenesisSignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "Address expected to have signed the genesis spec",
+	}
+	verifyGenesisSignatureFlag = cli.StringFlag{
+		Name:  "signature",
+		Usage: "File containing the hex signature of the spec (default = <specPath>.sig)",
+	}
+	verifyGenesisBlockFlag = cli.StringFlag{
+		Name:  "block",
+		Value: "latest",
+		Usage: "Block number or tag to verify the system contracts at",
+	}
+	verifyGenesisCommand = cli.Command{
+		Action:    utils.MigrateFlags(verifyGenesis),
+		Name:      "verify-genesis",
+		Usage:     "Cross-check running nodes against a signed genesis spec",
+		ArgsUsage: "<specPath> (<endpoint 1> ... <endpoint N>)",
+		Flags: []cli.Flag{
+			verifyGenesisSignerFlag,
+			verifyGenesisSignatureFlag,
+			verifyGenesisBlockFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The verify-genesis command checks a genesis spec signature, then connects to
+each of the given API endpoints (or the local node if none) and verifies that
+the network runs the genesis block, chain configuration and system contract
+code and storage defined by the spec, reporting any drift.
+
+The signature is expected in the format produced by 'ethkey signmessage' over
+the contents of the spec file.`,
+	}
+)
+
+// verifyGenesis checks the signature of a genesis spec and verifies a set of
+// running nodes against it.
+func verifyGenesis(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("Must supply path to genesis spec")
+	}
+	specPath := ctx.Args().First()
+	blob, err := ioutil.ReadFile(specPath)
+	if err != nil {
+		utils.Fatalf("Failed to read genesis spec: %v", err)
+	}
+	// Ensure the spec was signed by the expected authority
+	if !common.IsHexAddress(ctx.String(verifyGenesisSignerFlag.Name)) {
+		utils.Fatalf("Must supply the spec signer address (--%s)", verifyGenesisSignerFlag.Name)
+	}
+	sigPath := ctx.String(verifyGenesisSignatureFlag.Name)
+	if sigPath == "" {
+		sigPath = specPath + ".sig"
+	}
+	signature, err := readSpecSignature(sigPath)
+	if err != nil {
+		utils.Fatalf("Failed to read genesis spec signature: %v", err)
+	}
+	signer := common.HexToAddress(ctx.String(verifyGenesisSignerFlag.Name))
+	if err := core.VerifyGenesisSpec(blob, signature, signer); err != nil {
+		utils.Fatalf("Failed to verify genesis spec signature: %v", err)
+	}
+	genesis := new(core.Genesis)
+	if err := json.Unmarshal(blob, genesis); err != nil {
+		utils.Fatalf("Invalid genesis spec: %v", err)
+	}
+	fmt.Printf("Genesis spec signed by %s\n", signer.Hex())
+
+	// Verify every requested endpoint against the spec
+	endpoints := ctx.Args().Tail()
+	if len(endpoints) == 0 {
+		endpoints = []string{""}
+	}
+	failed := 0
+	for _, endpoint := range endpoints {
+		name := endpoint
+		if name == "" {
+			name = "local node"
+		}
+		client, err := dialRPC(endpoint)
+		if err != nil {
+			fmt.Printf("\n%s: unreachable: %v\n", name, err)
+			failed++
+			continue
+		}
+		drift, warnings := verifyNetwork(client, genesis, ctx.String(verifyGenesisBlockFlag.Name))
+		client.Close()
+
+		fmt.Printf("\n%s:\n", name)
+		for _, warning := range warnings {
+			fmt.Printf("  warning: %s\n", warning)
+		}
+		for _, mismatch := range drift {
+			fmt.Printf("  drift: %s\n", mismatch)
+		}
+		if len(drift) > 0 {
+			failed++
+			continue
+		}
+		fmt.Println("  matches the genesis spec")
+	}
+	if failed > 0 {
+		utils.Fatalf("\nGenesis verification failed on %d of %d endpoints", failed, len(endpoints))
+	}
+	return nil
+}
+
+// readSpecSignature reads the hex signature of a genesis spec from a signature
+// file as produced by 'ethkey signmessage'.
+func readSpecSignature(sigPath string) ([]byte, error) {
+	text, err := ioutil.ReadFile(sigPath)
+	if err != nil {
+		return nil, err
+	}
+	encoded := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(string(text)), "Signature:"))
+	signature, err := hex.DecodeString(strings.TrimPrefix(encoded, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding: %v", err)
+	}
+	return signature, nil
+}
+
+// verifyNetwork compares the genesis block, chain configuration and system
+// contracts of a node against a genesis spec, returning the detected deviations
+// and any checks which could not be done.
+func verifyNetwork(client *rpc.Client, genesis *core.Genesis, block string) (drift []string, warnings []string) {
+	// Verify the genesis block itself, detailing the header fields on mismatch
+	want := genesis.ToBlock(nil).Header()
+
+	var have *types.Header
+	if err := client.Call(&have, "eth_getBlockByNumber", "0x0", false); err != nil || have == nil {
+		return []string{fmt.Sprintf("genesis block unavailable: %v", err)}, nil
+	}
+	if have.Hash() != want.Hash() {
+		drift = append(drift, fmt.Sprintf("genesis hash %s, want %s", have.Hash().Hex(), want.Hash().Hex()))
+		drift = append(drift, diffHeaders(have, want)...)
+	}
+	// Verify the chain configuration, which is only exposed via the admin API
+	var info struct {
+		Protocols struct {
+			Eth *struct {
+				Config *params.ChainConfig `json:"config"`
+			} `json:"eth"`
+		} `json:"protocols"`
+	}
+	switch err := client.Call(&info, "admin_nodeInfo"); {
+	case err != nil:
+		warnings = append(warnings, fmt.Sprintf("chain config not verified: %v", err))
+	case info.Protocols.Eth == nil || info.Protocols.Eth.Config == nil:
+		warnings = append(warnings, "chain config not verified: not reported by node")
+	case genesis.Config == nil:
+		warnings = append(warnings, "chain config not verified: not defined by spec")
+	default:
+		drift = append(drift, diffChainConfigs(info.Protocols.Eth.Config, genesis.Config)...)
+	}
+	// Verify the code and storage of all the system contracts in the allocation
+	addresses := make([]common.Address, 0, len(genesis.Alloc))
+	for addr, account := range genesis.Alloc {
+		if len(account.Code) > 0 {
+			addresses = append(addresses, addr)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool { return bytes.Compare(addresses[i][:], addresses[j][:]) < 0 })
+
+	for _, addr := range addresses {
+		account := genesis.Alloc[addr]
+
+		var code hexutil.Bytes
+		if err := client.Call(&code, "eth_getCode", addr, block); err != nil {
+			drift = append(drift, fmt.Sprintf("contract %s: code unavailable: %v", addr.Hex(), err))
+			continue
+		}
+		if !bytes.Equal(code, account.Code) {
+			drift = append(drift, fmt.Sprintf("contract %s: code hash %x, want %x", addr.Hex(), crypto.Keccak256(code), crypto.Keccak256(account.Code)))
+		}
+		slots := make([]common.Hash, 0, len(account.Storage))
+		for slot := range account.Storage {
+			slots = append(slots, slot)
+		}
+		sort.Slice(slots, func(i, j int) bool { return bytes.Compare(slots[i][:], slots[j][:]) < 0 })
+
+		for _, slot := range slots {
+			var value hexutil.Bytes
+			if err := client.Call(&value, "eth_getStorageAt", addr, slot.Hex(), block); err != nil {
+				drift = append(drift, fmt.Sprintf("contract %s: slot %s unavailable: %v", addr.Hex(), slot.Hex(), err))
+				continue
+			}
+			if stored := common.BytesToHash(value); stored != account.Storage[slot] {
+				drift = append(drift, fmt.Sprintf("contract %s: slot %s is %s, want %s", addr.Hex(), slot.Hex(), stored.Hex(), account.Storage[slot].Hex()))
+			}
+		}
+	}
+	return drift, warnings
+}
+
+// diffHeaders lists the genesis header fields which differ between two blocks.
+func diffHeaders(have, want *types.Header) []string {
+	var diff []string
+	check := func(field string, have, want interface{}) {
+		if h, w := fmt.Sprint(have), fmt.Sprint(want); h != w {
+			diff = append(diff, fmt.Sprintf("genesis %s %s, want %s", field, h, w))
+		}
+	}
+	check("state root", have.Root.Hex(), want.Root.Hex())
+	check("parent hash", have.ParentHash.Hex(), want.ParentHash.Hex())
+	check("coinbase", have.Coinbase.Hex(), want.Coinbase.Hex())
+	check("difficulty", have.Difficulty, want.Difficulty)
+	check("gas limit", have.GasLimit, want.GasLimit)
+	check("timestamp", have.Time, want.Time)
+	check("extra data", hexutil.Bytes(have.Extra), hexutil.Bytes(want.Extra))
+	check("mix digest", have.MixDigest.Hex(), want.MixDigest.Hex())
+	check("nonce", have.Nonce.Uint64(), want.Nonce.Uint64())
+	return diff
+}
+
+// diffChainConfigs lists the chain configuration fields which differ between a
+// node's configuration and the expected one.
+func diffChainConfigs(have, want *params.ChainConfig) []string {
+	flatten := func(config *params.ChainConfig) map[string]interface{} {
+		blob, _ := json.Marshal(config)
+		fields := make(map[string]interface{})
+		json.Unmarshal(blob, &fields)
+		return flattenConfig("", fields)
+	}
+	haveFields, wantFields := flatten(have), flatten(want)
+
+	keys := make(map[string]bool)
+	for key := range haveFields {
+		keys[key] = true
+	}
+	for key := range wantFields {
+		keys[key] = true
+	}
+	var diff []string
+	for key := range keys {
+		h, w := haveFields[key], wantFields[key]
+		if fmt.Sprint(h) != fmt.Sprint(w) {
+			diff = append(diff, fmt.Sprintf("chain config %s is %v, want %v", key, h, w))
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
@@ -74,6 +74,9 @@ var (
 		utils.DataDirFlag,
 		utils.KeyStoreDirFlag,
 		utils.NoUSBFlag,
+		utils.SignerApprovalFlag,
+		utils.SignerApprovalTimeoutFlag,
+		utils.SignerApproveOnTimeoutFlag,
 		utils.DashboardEnabledFlag,
 		utils.DashboardAddrFlag,
 		utils.DashboardPortFlag,
@@ -94,14 +97,46 @@ var (
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolAccountPendingFlag,
+		utils.TxPoolLocalSlotsFlag,
+		utils.TxPoolNonceDepthFlag,
 		utils.TxPoolLifetimeFlag,
+		utils.TxPoolQueueLifetimeFlag,
+		utils.TxPoolQueueEvictionFlag,
+		utils.TxPoolSystemExemptFlag,
+		utils.TxPoolSystemSlotsFlag,
+		utils.TxPoolAccountSystemSlotsFlag,
+		utils.TxPoolRebroadcastFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.DBEngineFlag,
+		utils.DBReadOnlyFlag,
+		utils.CrashDirFlag,
+		utils.SafeDepthFlag,
+		utils.FinalityDepthFlag,
+		utils.ActivityBloomFlag,
+		utils.BalanceIndexFlag,
+		utils.HistoryRetainFlag,
+		utils.PrivateStateFlag,
+		utils.PrivateNodesFlag,
+		utils.BreakerFlag,
+		utils.BreakerBalanceFloorFlag,
+		utils.BreakerMinCommitteeFlag,
+		utils.BreakerMaxCommitteeFlag,
+		utils.ReplicaLeaderFlag,
+		utils.ReplicaSecretFlag,
+		utils.ReplicaVerifyFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
+		utils.KeyStoreKDFFlag,
+		utils.KeyStoreScryptNFlag,
+		utils.KeyStoreScryptPFlag,
+		utils.KeyStoreArgon2TimeFlag,
+		utils.KeyStoreArgon2MemoryFlag,
+		utils.KeyStoreArgon2ThreadsFlag,
 		utils.CacheFlag,
 		utils.CacheDatabaseFlag,
 		utils.CacheGCFlag,
@@ -109,9 +144,12 @@ var (
 		utils.ListenPortFlag,
 		utils.MaxPeersFlag,
 		utils.MaxPendingPeersFlag,
+		utils.MaxEgressBytesFlag,
 		utils.UsebaseFlag,
 		utils.GasPriceFlag,
 		utils.CommitteeEnabledFlag,
+		utils.CommitteeHeartbeatFlag,
+		utils.CommitteeMembersFlag,
 		utils.MinerThreadsFlag,
 		utils.MiningEnabledFlag,
 		utils.TargetGasLimitFlag,
@@ -119,6 +157,8 @@ var (
 		utils.NoDiscoverFlag,
 		utils.DiscoveryV5Flag,
 		utils.NetrestrictFlag,
+		utils.MinClientVersionFlag,
+		utils.RequiredCapsFlag,
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DeveloperFlag,
@@ -130,12 +170,18 @@ var (
 		utils.RPCCORSDomainFlag,
 		utils.RPCVirtualHostsFlag,
 		utils.EthStatsURLFlag,
+		utils.BridgeConfigFlag,
 		utils.MetricsEnabledFlag,
 		utils.FakePoWFlag,
 		utils.NoCompactionFlag,
 		utils.GpoBlocksFlag,
 		utils.GpoPercentileFlag,
+		utils.GpoMaxPriceFlag,
 		utils.ExtraDataFlag,
+		utils.MinerMinTxsFlag,
+		utils.MinerMinFeesFlag,
+		utils.MinerDeadlineFlag,
+		utils.MinerRecommitFlag,
 		configFileFlag,
 	}
 
@@ -144,6 +190,8 @@ var (
 		utils.RPCListenAddrFlag,
 		utils.RPCPortFlag,
 		utils.RPCApiFlag,
+		utils.RPCLogChunkFlag,
+		utils.RPCLogLimitFlag,
 		utils.WSEnabledFlag,
 		utils.WSListenAddrFlag,
 		utils.WSPortFlag,
@@ -173,8 +221,17 @@ func init() {
 		copydbCommand,
 		removedbCommand,
 		dumpCommand,
+		dumpIdentitiesCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
+		// See genesiscmd.go:
+		verifyGenesisCommand,
+		// See devnetcmd.go:
+		devnetCommand,
 		// See monitorcmd.go:
 		monitorCommand,
+		// See contractcmd.go:
+		contractCommand,
 		// See accountcmd.go:
 		accountCommand,
 		verifyCommand,
@@ -230,7 +287,15 @@ func main() {
 // It creates a default node based on the command line arguments and runs it in
 // blocking mode, waiting for it to be shut down.
 func geth(ctx *cli.Context) error {
+	if ctx.GlobalBool(utils.DBReadOnlyFlag.Name) {
+		utils.Fatalf("--%s is only supported by the database tooling commands", utils.DBReadOnlyFlag.Name)
+	}
 	node := makeFullNode(ctx)
+	defer node.RecoverCrash()
+
+	utils.SetFatalHook(func(msg string) {
+		node.CrashDump("fatal: "+msg, nil)
+	})
 	startNode(ctx, node)
 	node.Wait()
 	return nil
@@ -0,0 +1,163 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/usechain/go-usechain/cmd/utils"
+	"github.com/usechain/go-usechain/ethdb/clone"
+	"github.com/usechain/go-usechain/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotSecretFlag = cli.StringFlag{
+		Name:   "snapshot.secret",
+		Usage:  "Shared secret authenticating snapshot imports with the exporter",
+		EnvVar: "USED_SNAPSHOT_SECRET",
+	}
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Clone the chain database of a node over the network",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Stream a consistent snapshot of the chain database from one node to another, to
+stand up new nodes without copying the database files by hand. Both sides must
+use the same secret, preferably passed through the USED_SNAPSHOT_SECRET
+environment variable.`,
+		Subcommands: []cli.Command{
+			{
+				Action:    utils.MigrateFlags(exportSnapshot),
+				Name:      "export",
+				Usage:     "Serve a snapshot of the chain database to importing nodes",
+				ArgsUsage: "<listenaddr>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+					utils.DBEngineFlag,
+					snapshotSecretFlag,
+				},
+				Description: `
+    used snapshot export --snapshot.secret <secret> :8600
+
+Takes a snapshot of the chain database and serves it over HTTP on the given
+address until interrupted, any number of importers cloning it meanwhile. The
+database is opened read-only, so the snapshot can be taken from the data of a
+running node.`,
+			},
+			{
+				Action:    utils.MigrateFlags(importSnapshot),
+				Name:      "import",
+				Usage:     "Clone the chain database served by an exporting node",
+				ArgsUsage: "<url>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.LightModeFlag,
+					utils.DBEngineFlag,
+					snapshotSecretFlag,
+				},
+				Description: `
+    used snapshot import --snapshot.secret <secret> http://10.0.0.1:8600
+
+Imports the snapshot served by "used snapshot export" into an empty datadir.
+Interrupted transfers resume where they left off, also when running the command
+again, as long as the exporter still serves the same snapshot. The node must not
+be started on the datadir before the import completes.`,
+			},
+		},
+	}
+)
+
+// exportSnapshot serves a snapshot of the chain database until interrupted.
+func exportSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the listen address as argument.")
+	}
+	secret := ctx.String(snapshotSecretFlag.Name)
+	if secret == "" {
+		utils.Fatalf("Exporting a snapshot requires --%s", snapshotSecretFlag.Name)
+	}
+	ctx.GlobalSet(utils.DBReadOnlyFlag.Name, "true")
+
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	server, err := clone.NewServer(chainDb, secret)
+	if err != nil {
+		utils.Fatalf("Failed to snapshot database: %v", err)
+	}
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", ctx.Args().First())
+	if err != nil {
+		utils.Fatalf("Failed to listen: %v", err)
+	}
+	httpsrv := &http.Server{Handler: server}
+	go httpsrv.Serve(listener)
+
+	log.Info("Serving database snapshot", "addr", listener.Addr(), "id", server.ID())
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	<-sigc
+
+	log.Info("Got interrupt, shutting down...")
+	httpsrv.Close()
+	return nil
+}
+
+// importSnapshot clones the chain database served by an exporting node.
+func importSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("This command requires the exporter URL as argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	// Stop importing on interrupt, the progress made is kept for resuming
+	cctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigc)
+	go func() {
+		if _, ok := <-sigc; ok {
+			log.Info("Got interrupt, stopping import...")
+			cancel()
+		}
+	}()
+	start := time.Now()
+	if err := clone.Import(cctx, ctx.Args().First(), ctx.String(snapshotSecretFlag.Name), chainDb); err != nil {
+		utils.Fatalf("Snapshot import failed: %v", err)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
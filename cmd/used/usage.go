@@ -76,7 +76,22 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MoonetFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.DBEngineFlag,
+			utils.DBReadOnlyFlag,
+			utils.CrashDirFlag,
+			utils.SafeDepthFlag,
+			utils.FinalityDepthFlag,
+			utils.ActivityBloomFlag,
+			utils.BalanceIndexFlag,
+			utils.HistoryRetainFlag,
+			utils.PrivateStateFlag,
+			utils.PrivateNodesFlag,
+			utils.BreakerFlag,
+			utils.BreakerBalanceFloorFlag,
+			utils.BreakerMinCommitteeFlag,
+			utils.BreakerMaxCommitteeFlag,
 			utils.EthStatsURLFlag,
+			utils.BridgeConfigFlag,
 			utils.IdentityFlag,
 			utils.VerifyIdFlag,
 			utils.VerifyPhotoFlag,
@@ -84,6 +99,20 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.LightServFlag,
 			utils.LightPeersFlag,
 			utils.LightKDFFlag,
+			utils.KeyStoreKDFFlag,
+			utils.KeyStoreScryptNFlag,
+			utils.KeyStoreScryptPFlag,
+			utils.KeyStoreArgon2TimeFlag,
+			utils.KeyStoreArgon2MemoryFlag,
+			utils.KeyStoreArgon2ThreadsFlag,
+		},
+	},
+	{
+		Name: "REPLICA",
+		Flags: []cli.Flag{
+			utils.ReplicaLeaderFlag,
+			utils.ReplicaSecretFlag,
+			utils.ReplicaVerifyFlag,
 		},
 	},
 	{Name: "DEVELOPER CHAIN",
@@ -125,7 +154,16 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolAccountPendingFlag,
+			utils.TxPoolLocalSlotsFlag,
+			utils.TxPoolNonceDepthFlag,
 			utils.TxPoolLifetimeFlag,
+			utils.TxPoolQueueLifetimeFlag,
+			utils.TxPoolQueueEvictionFlag,
+			utils.TxPoolSystemExemptFlag,
+			utils.TxPoolSystemSlotsFlag,
+			utils.TxPoolAccountSystemSlotsFlag,
+			utils.TxPoolRebroadcastFlag,
 		},
 	},
 	{
@@ -142,6 +180,9 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.UnlockedAccountFlag,
 			utils.PasswordFileFlag,
+			utils.SignerApprovalFlag,
+			utils.SignerApprovalTimeoutFlag,
+			utils.SignerApproveOnTimeoutFlag,
 		},
 	},
 	{
@@ -160,6 +201,8 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IPCPathFlag,
 			utils.RPCCORSDomainFlag,
 			utils.RPCVirtualHostsFlag,
+			utils.RPCLogChunkFlag,
+			utils.RPCLogLimitFlag,
 			utils.JSpathFlag,
 			utils.ExecFlag,
 			utils.PreloadJSFlag,
@@ -174,10 +217,13 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.ListenPortFlag,
 			utils.MaxPeersFlag,
 			utils.MaxPendingPeersFlag,
+			utils.MaxEgressBytesFlag,
 			utils.NATFlag,
 			utils.NoDiscoverFlag,
 			utils.DiscoveryV5Flag,
 			utils.NetrestrictFlag,
+			utils.MinClientVersionFlag,
+			utils.RequiredCapsFlag,
 			utils.NodeKeyFileFlag,
 			utils.NodeKeyHexFlag,
 		},
@@ -186,12 +232,18 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "MINER",
 		Flags: []cli.Flag{
 			utils.CommitteeEnabledFlag,
+			utils.CommitteeHeartbeatFlag,
+			utils.CommitteeMembersFlag,
 			utils.MiningEnabledFlag,
 			utils.MinerThreadsFlag,
 			utils.UsebaseFlag,
 			utils.TargetGasLimitFlag,
 			utils.GasPriceFlag,
 			utils.ExtraDataFlag,
+			utils.MinerMinTxsFlag,
+			utils.MinerMinFeesFlag,
+			utils.MinerDeadlineFlag,
+			utils.MinerRecommitFlag,
 		},
 	},
 	{
@@ -199,6 +251,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Flags: []cli.Flag{
 			utils.GpoBlocksFlag,
 			utils.GpoPercentileFlag,
+			utils.GpoMaxPriceFlag,
 		},
 	},
 	{
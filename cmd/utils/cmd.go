@@ -43,6 +43,7 @@ const (
 // The message is also printed to standard output if standard error
 // is redirected to a different file.
 func Fatalf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
 	w := io.MultiWriter(os.Stdout, os.Stderr)
 	if runtime.GOOS == "windows" {
 		// The SameFile check below doesn't work on Windows.
@@ -55,10 +56,22 @@ func Fatalf(format string, args ...interface{}) {
 			w = os.Stderr
 		}
 	}
-	fmt.Fprintf(w, "Fatal: "+format+"\n", args...)
+	fmt.Fprintf(w, "Fatal: %s\n", msg)
+	if fatalHook != nil {
+		fatalHook(msg)
+	}
 	os.Exit(1)
 }
 
+// fatalHook is run by Fatalf before terminating the process.
+var fatalHook func(msg string)
+
+// SetFatalHook registers a function to be run by Fatalf with the error message
+// before terminating the process, e.g. to write a crash report.
+func SetFatalHook(hook func(msg string)) {
+	fatalHook = hook
+}
+
 func StartNode(stack *node.Node) {
 	if err := stack.Start(); err != nil {
 		Fatalf("Error starting protocol stack: %v", err)
@@ -79,6 +92,7 @@ func StartNode(stack *node.Node) {
 		debug.Exit() // ensure trace and CPU profile data is flushed.
 		debug.LoudPanic("boom")
 	}()
+	watchUpgrade(stack)
 }
 
 func ImportChain(chain *core.BlockChain, fn string) error {
@@ -21,6 +21,7 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"io/ioutil"
+	"math"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -30,6 +31,7 @@ import (
 
 	"github.com/usechain/go-usechain/accounts"
 	"github.com/usechain/go-usechain/accounts/keystore"
+	"github.com/usechain/go-usechain/bridge"
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/common/fdlimit"
 	"github.com/usechain/go-usechain/consensus"
@@ -43,6 +45,8 @@ import (
 	"github.com/usechain/go-usechain/eth"
 	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/eth/gasprice"
+	"github.com/usechain/go-usechain/eth/heartbeat"
+	"github.com/usechain/go-usechain/eth/replica"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/ethstats"
 	"github.com/usechain/go-usechain/les"
@@ -126,6 +130,18 @@ var (
 		Name:  "nousb",
 		Usage: "Disables monitoring for and managing USB hardware wallets",
 	}
+	SignerApprovalFlag = cli.BoolFlag{
+		Name:  "signer.approval",
+		Usage: "Queue signing requests made over RPC until approved via personal_approve",
+	}
+	SignerApprovalTimeoutFlag = cli.DurationFlag{
+		Name:  "signer.approvaltimeout",
+		Usage: "Time after which unapproved signing requests are decided automatically (0 = wait indefinitely)",
+	}
+	SignerApproveOnTimeoutFlag = cli.BoolFlag{
+		Name:  "signer.approveontimeout",
+		Usage: "Approve signing requests timing out instead of rejecting them",
+	}
 	NetworkIdFlag = cli.Uint64Flag{
 		Name:  "networkid",
 		Usage: "Network identifier (integer, 1=Frontier, 2=Moonet)",
@@ -175,6 +191,64 @@ var (
 		Usage: `Blockchain garbage collection mode ("full", "archive")`,
 		Value: "full",
 	}
+	DBEngineFlag = cli.StringFlag{
+		Name:  "db.engine",
+		Usage: `Storage engine of new databases ("leveldb", "pebble"), existing ones keep theirs`,
+	}
+	DBReadOnlyFlag = cli.BoolFlag{
+		Name:  "db.readonly",
+		Usage: "Open the databases read-only, safe to use on the data of a running node (tooling commands only)",
+	}
+	CrashDirFlag = DirectoryFlag{
+		Name:  "crash.dir",
+		Usage: "Directory for the crash reports written on panics and fatal errors (default = inside the datadir)",
+	}
+	SafeDepthFlag = cli.Uint64Flag{
+		Name:  "finality.safedepth",
+		Usage: `Number of confirmations after which a block is reported as "safe"`,
+		Value: eth.DefaultConfig.SafeDepth,
+	}
+	FinalityDepthFlag = cli.Uint64Flag{
+		Name:  "finality.depth",
+		Usage: `Number of confirmations after which a block is reported as "finalized"`,
+		Value: eth.DefaultConfig.FinalityDepth,
+	}
+	ActivityBloomFlag = cli.BoolFlag{
+		Name:  "activitybloom",
+		Usage: "Index the accounts touched by each imported block (enables usx_getActivityBlocks)",
+	}
+	BalanceIndexFlag = cli.BoolFlag{
+		Name:  "balanceindex",
+		Usage: "Index the balance changes of each imported block (enables eth_getBalanceChanges)",
+	}
+	HistoryRetainFlag = cli.Uint64Flag{
+		Name:  "history.retain",
+		Usage: "Number of recent blocks whose bodies and receipts are kept, older ones are pruned (0 = keep all)",
+	}
+	PrivateStateFlag = cli.BoolFlag{
+		Name:  "privatestate",
+		Usage: "Execute private transactions whose payload was distributed to this node on a separate private state",
+	}
+	PrivateNodesFlag = cli.StringFlag{
+		Name:  "privatestate.nodes",
+		Usage: "Comma separated enode URLs or IDs of the nodes authorized to distribute private payloads to this one",
+	}
+	BreakerFlag = cli.BoolFlag{
+		Name:  "breaker",
+		Usage: "Halt block production and relay when a block violates the chain invariants (supply accounting always checked)",
+	}
+	BreakerBalanceFloorFlag = BigFlag{
+		Name:  "breaker.balancefloor",
+		Usage: "Minimum balance (wei) of each system contract checked by the circuit breaker",
+	}
+	BreakerMinCommitteeFlag = cli.Uint64Flag{
+		Name:  "breaker.mincommittee",
+		Usage: "Minimum number of registered miners checked by the circuit breaker (0 = unchecked)",
+	}
+	BreakerMaxCommitteeFlag = cli.Uint64Flag{
+		Name:  "breaker.maxcommittee",
+		Usage: "Maximum number of registered miners checked by the circuit breaker (0 = unchecked)",
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -189,6 +263,40 @@ var (
 		Name:  "lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
 	}
+	KeyStoreKDFFlag = cli.StringFlag{
+		Name:  "keystore.kdf",
+		Usage: `Key-derivation function used to encrypt new keys ("scrypt" or "argon2id")`,
+		Value: "scrypt",
+	}
+	KeyStoreScryptNFlag = cli.IntFlag{
+		Name:  "keystore.scryptn",
+		Usage: "Scrypt N (CPU/memory cost) parameter of new keys (0 = default)",
+	}
+	KeyStoreScryptPFlag = cli.IntFlag{
+		Name:  "keystore.scryptp",
+		Usage: "Scrypt P (parallelization) parameter of new keys (0 = default)",
+	}
+	KeyStoreArgon2TimeFlag = cli.UintFlag{
+		Name:  "keystore.argon2.time",
+		Usage: "Argon2id number of passes over the memory (0 = default)",
+	}
+	KeyStoreArgon2MemoryFlag = cli.UintFlag{
+		Name:  "keystore.argon2.memory",
+		Usage: "Argon2id memory usage in KiB (0 = default)",
+	}
+	KeyStoreArgon2ThreadsFlag = cli.UintFlag{
+		Name:  "keystore.argon2.threads",
+		Usage: "Argon2id degree of parallelism (0 = default)",
+	}
+	HDWalletFlag = cli.BoolFlag{
+		Name:  "hd",
+		Usage: "Derive the account from a BIP-39 mnemonic (generating a new one if none given)",
+	}
+	HDPathFlag = cli.StringFlag{
+		Name:  "hd.path",
+		Usage: "BIP-44 derivation path of the account within the HD wallet",
+		Value: accounts.DefaultBaseDerivationPath.String(),
+	}
 	// Dashboard settings
 	DashboardEnabledFlag = cli.BoolFlag{
 		Name:  "dashboard",
@@ -289,11 +397,69 @@ var (
 		Usage: "Maximum number of non-executable transaction slots for all accounts",
 		Value: eth.DefaultConfig.TxPool.GlobalQueue,
 	}
+	TxPoolAccountPendingFlag = cli.Uint64Flag{
+		Name:  "txpool.accountpending",
+		Usage: "Maximum number of executable transaction slots per remote account (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.AccountPending,
+	}
+	TxPoolLocalSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.localslots",
+		Usage: "Number of executable transaction slots reserved for local transactions",
+		Value: eth.DefaultConfig.TxPool.LocalSlots,
+	}
+	TxPoolNonceDepthFlag = cli.Uint64Flag{
+		Name:  "txpool.noncedepth",
+		Usage: "Maximum nonce distance of remote transactions ahead of their account (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.NonceDepth,
+	}
 	TxPoolLifetimeFlag = cli.DurationFlag{
 		Name:  "txpool.lifetime",
 		Usage: "Maximum amount of time non-executable transaction are queued",
 		Value: eth.DefaultConfig.TxPool.Lifetime,
 	}
+	TxPoolQueueLifetimeFlag = cli.DurationFlag{
+		Name:  "txpool.queuelifetime",
+		Usage: "Maximum amount of time a single transaction is queued, even for active accounts (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.QueueLifetime,
+	}
+	TxPoolQueueEvictionFlag = cli.StringFlag{
+		Name:  "txpool.queueeviction",
+		Usage: `Accounts losing their queued transactions first on overflow ("heartbeat" or "largest")`,
+		Value: string(eth.DefaultConfig.TxPool.QueueEviction),
+	}
+	TxPoolSystemExemptFlag = cli.BoolFlag{
+		Name:  "txpool.systemexempt",
+		Usage: "Exempt identity and governance system transactions from the gas price limits",
+	}
+	TxPoolSystemSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.systemslots",
+		Usage: "Maximum number of price exempted system transactions in the pool",
+		Value: eth.DefaultConfig.TxPool.SystemSlots,
+	}
+	TxPoolAccountSystemSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.accountsystemslots",
+		Usage: "Maximum number of price exempted system transactions per account",
+		Value: eth.DefaultConfig.TxPool.AccountSystemSlots,
+	}
+	TxPoolRebroadcastFlag = cli.Uint64Flag{
+		Name:  "txpool.rebroadcast",
+		Usage: "Rebroadcast local transactions not mined within this many blocks, resubmitting lost ones (0 = disabled)",
+	}
+	// Replica settings
+	ReplicaLeaderFlag = cli.StringFlag{
+		Name:  "replica.leader",
+		Usage: "RPC endpoint of a trusted leader to follow instead of syncing from the network",
+	}
+	ReplicaSecretFlag = cli.StringFlag{
+		Name:  "replica.secret",
+		Usage: "Shared secret authenticating replica followers with their leader",
+	}
+	defaultReplicaVerify = eth.DefaultConfig.ReplicaVerify
+	ReplicaVerifyFlag    = TextMarshalerFlag{
+		Name:  "replica.verify",
+		Usage: `Verification of blocks received from the replica leader ("full" or "noseal")`,
+		Value: &defaultReplicaVerify,
+	}
 	// Performance tuning settings
 	CacheFlag = cli.IntFlag{
 		Name:  "cache",
@@ -332,6 +498,15 @@ var (
 		Name:  "committee",
 		Usage: "Enable committing",
 	}
+	CommitteeHeartbeatFlag = cli.DurationFlag{
+		Name:  "committee.heartbeat",
+		Usage: "Interval between liveness heartbeats signed by the committee account",
+		Value: heartbeat.DefaultInterval,
+	}
+	CommitteeMembersFlag = cli.StringFlag{
+		Name:  "committee.members",
+		Usage: "Comma separated committee accounts to report liveness for even if never seen",
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -361,6 +536,23 @@ var (
 		Name:  "extradata",
 		Usage: "Block extra data set by the miner (default = client version)",
 	}
+	MinerMinTxsFlag = cli.IntFlag{
+		Name:  "miner.mintxs",
+		Usage: "Minimum number of transactions before sealing a block",
+	}
+	MinerMinFeesFlag = BigFlag{
+		Name:  "miner.minfees",
+		Usage: "Minimum total fees (wei) before sealing a block",
+		Value: new(big.Int),
+	}
+	MinerDeadlineFlag = cli.DurationFlag{
+		Name:  "miner.deadline",
+		Usage: "Time after which a block is sealed even below the minimums (0 = 1 minute)",
+	}
+	MinerRecommitFlag = cli.DurationFlag{
+		Name:  "miner.recommit",
+		Usage: "Interval of rebuilding the sealed block with better paying transactions (0 = on new heads only)",
+	}
 	// Account settings
 	UnlockedAccountFlag = cli.StringFlag{
 		Name:  "unlock",
@@ -382,6 +574,10 @@ var (
 		Name:  "ethstats",
 		Usage: "Reporting URL of a ethstats service (nodename:secret@host:port)",
 	}
+	BridgeConfigFlag = cli.StringFlag{
+		Name:  "bridge.config",
+		Usage: "JSON file describing the external chain to relay bridge deposits from",
+	}
 	MetricsEnabledFlag = cli.BoolFlag{
 		Name:  metrics.MetricsEnabledFlag,
 		Usage: "Enable metrics collection and reporting",
@@ -424,6 +620,16 @@ var (
 		Usage: "API's offered over the HTTP-RPC interface",
 		Value: "",
 	}
+	RPCLogChunkFlag = cli.Uint64Flag{
+		Name:  "rpc.logchunk",
+		Usage: "Number of blocks searched at once by eth_getLogs (0 = whole range)",
+		Value: eth.DefaultConfig.LogChunkSize,
+	}
+	RPCLogLimitFlag = cli.IntFlag{
+		Name:  "rpc.loglimit",
+		Usage: "Maximum number of logs returned by eth_getLogs (0 = unlimited)",
+		Value: eth.DefaultConfig.LogQueryLimit,
+	}
 	IPCDisabledFlag = cli.BoolFlag{
 		Name:  "ipcdisable",
 		Usage: "Disable the IPC-RPC server",
@@ -476,6 +682,11 @@ var (
 		Usage: "Maximum number of pending connection attempts (defaults used if set to 0)",
 		Value: 0,
 	}
+	MaxEgressBytesFlag = cli.Uint64Flag{
+		Name:  "p2p.maxbytes",
+		Usage: "Maximum upload bandwidth used by peer-to-peer traffic in bytes per second (0 = unlimited)",
+		Value: 0,
+	}
 	ListenPortFlag = cli.IntFlag{
 		Name:  "port",
 		Usage: "Network listening port",
@@ -521,6 +732,14 @@ var (
 		Name:  "netrestrict",
 		Usage: "Restricts network communication to the given IP networks (CIDR masks)",
 	}
+	MinClientVersionFlag = cli.StringFlag{
+		Name:  "p2p.minversion",
+		Usage: "Comma separated minimum peer client versions as name/version (e.g. used/0.4.0)",
+	}
+	RequiredCapsFlag = cli.StringFlag{
+		Name:  "p2p.requirecaps",
+		Usage: "Comma separated protocol capabilities peers must advertise as name/version (e.g. eth/63)",
+	}
 
 	// ATM the url is left to the user and deployment to
 	JSpathFlag = cli.StringFlag{
@@ -540,6 +759,11 @@ var (
 		Usage: "Suggested gas price is the given percentile of a set of recent transaction gas prices",
 		Value: eth.DefaultConfig.GPO.Percentile,
 	}
+	GpoMaxPriceFlag = BigFlag{
+		Name:  "gpomaxprice",
+		Usage: "Maximum gas price that will be recommended by the gas price oracle",
+		Value: eth.DefaultConfig.GPO.MaxPrice,
+	}
 	WhisperEnabledFlag = cli.BoolFlag{
 		Name:  "shh",
 		Usage: "Enable Whisper",
@@ -856,6 +1080,9 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.GlobalIsSet(MaxPendingPeersFlag.Name) {
 		cfg.MaxPendingPeers = ctx.GlobalInt(MaxPendingPeersFlag.Name)
 	}
+	if ctx.GlobalIsSet(MaxEgressBytesFlag.Name) {
+		cfg.MaxEgressBytes = ctx.GlobalUint64(MaxEgressBytesFlag.Name)
+	}
 	if ctx.GlobalIsSet(NoDiscoverFlag.Name) || lightClient {
 		cfg.NoDiscovery = true
 	}
@@ -877,6 +1104,13 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		}
 		cfg.NetRestrict = list
 	}
+	if ctx.GlobalIsSet(MinClientVersionFlag.Name) || ctx.GlobalIsSet(RequiredCapsFlag.Name) {
+		policy, err := p2p.ParseClientPolicy(ctx.GlobalString(MinClientVersionFlag.Name), ctx.GlobalString(RequiredCapsFlag.Name))
+		if err != nil {
+			Fatalf("Invalid peer client policy: %v", err)
+		}
+		cfg.ClientPolicy = policy
+	}
 
 	if ctx.GlobalBool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
@@ -887,6 +1121,24 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	}
 }
 
+// SetReplicaP2PConfig disconnects replica followers from the public network, as
+// they are fed by their leader instead. The leader is resolved from the eth
+// config, so it takes effect whether set on the command line or in a config file.
+func SetReplicaP2PConfig(ctx *cli.Context, cfg *p2p.Config, ethcfg *eth.Config) {
+	if ctx.GlobalIsSet(ReplicaLeaderFlag.Name) {
+		ethcfg.ReplicaLeader = ctx.GlobalString(ReplicaLeaderFlag.Name)
+	}
+	if ethcfg.ReplicaLeader == "" {
+		return
+	}
+	if !ctx.GlobalIsSet(MaxPeersFlag.Name) {
+		cfg.MaxPeers = 0
+	}
+	cfg.NoDiscovery = true
+
+	log.Info("Following replica leader, public networking disabled", "leader", ethcfg.ReplicaLeader, "peers", cfg.MaxPeers)
+}
+
 // SetNodeConfig applies node-related command line flags to the config.
 func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	SetP2PConfig(ctx, &cfg.P2P)
@@ -912,9 +1164,57 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 	if ctx.GlobalIsSet(LightKDFFlag.Name) {
 		cfg.UseLightweightKDF = ctx.GlobalBool(LightKDFFlag.Name)
 	}
+	if ctx.GlobalIsSet(KeyStoreKDFFlag.Name) {
+		cfg.KeyStoreKDF = ctx.GlobalString(KeyStoreKDFFlag.Name)
+	}
+	if ctx.GlobalIsSet(KeyStoreScryptNFlag.Name) {
+		cfg.ScryptN = ctx.GlobalInt(KeyStoreScryptNFlag.Name)
+	}
+	if ctx.GlobalIsSet(KeyStoreScryptPFlag.Name) {
+		cfg.ScryptP = ctx.GlobalInt(KeyStoreScryptPFlag.Name)
+	}
+	if ctx.GlobalIsSet(KeyStoreArgon2TimeFlag.Name) {
+		time := ctx.GlobalUint(KeyStoreArgon2TimeFlag.Name)
+		if time > math.MaxUint32 {
+			Fatalf("--%s must not exceed %d, got %d", KeyStoreArgon2TimeFlag.Name, uint64(math.MaxUint32), time)
+		}
+		cfg.Argon2Time = uint32(time)
+	}
+	if ctx.GlobalIsSet(KeyStoreArgon2MemoryFlag.Name) {
+		memory := ctx.GlobalUint(KeyStoreArgon2MemoryFlag.Name)
+		if memory > math.MaxUint32 {
+			Fatalf("--%s must not exceed %d, got %d", KeyStoreArgon2MemoryFlag.Name, uint64(math.MaxUint32), memory)
+		}
+		cfg.Argon2Memory = uint32(memory)
+	}
+	if ctx.GlobalIsSet(KeyStoreArgon2ThreadsFlag.Name) {
+		threads := ctx.GlobalUint(KeyStoreArgon2ThreadsFlag.Name)
+		if threads == 0 || threads > math.MaxUint8 {
+			Fatalf("--%s must be between 1 and %d, got %d", KeyStoreArgon2ThreadsFlag.Name, math.MaxUint8, threads)
+		}
+		cfg.Argon2Threads = uint8(threads)
+	}
 	if ctx.GlobalIsSet(NoUSBFlag.Name) {
 		cfg.NoUSB = ctx.GlobalBool(NoUSBFlag.Name)
 	}
+	if ctx.GlobalIsSet(SignerApprovalFlag.Name) {
+		cfg.SignerApproval = ctx.GlobalBool(SignerApprovalFlag.Name)
+	}
+	if ctx.GlobalIsSet(SignerApprovalTimeoutFlag.Name) {
+		cfg.SignerApprovalTimeout = ctx.GlobalDuration(SignerApprovalTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(SignerApproveOnTimeoutFlag.Name) {
+		cfg.SignerApproveOnTimeout = ctx.GlobalBool(SignerApproveOnTimeoutFlag.Name)
+	}
+	if ctx.GlobalIsSet(DBEngineFlag.Name) {
+		cfg.DBEngine = ctx.GlobalString(DBEngineFlag.Name)
+	}
+	if ctx.GlobalIsSet(DBReadOnlyFlag.Name) {
+		cfg.DBReadOnly = ctx.GlobalBool(DBReadOnlyFlag.Name)
+	}
+	if ctx.GlobalIsSet(CrashDirFlag.Name) {
+		cfg.CrashDir = ctx.GlobalString(CrashDirFlag.Name)
+	}
 }
 
 func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
@@ -924,6 +1224,9 @@ func setGPO(ctx *cli.Context, cfg *gasprice.Config) {
 	if ctx.GlobalIsSet(GpoPercentileFlag.Name) {
 		cfg.Percentile = ctx.GlobalInt(GpoPercentileFlag.Name)
 	}
+	if ctx.GlobalIsSet(GpoMaxPriceFlag.Name) {
+		cfg.MaxPrice = GlobalBig(ctx, GpoMaxPriceFlag.Name)
+	}
 }
 
 func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
@@ -954,9 +1257,33 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolAccountPendingFlag.Name) {
+		cfg.AccountPending = ctx.GlobalUint64(TxPoolAccountPendingFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolLocalSlotsFlag.Name) {
+		cfg.LocalSlots = ctx.GlobalUint64(TxPoolLocalSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolNonceDepthFlag.Name) {
+		cfg.NonceDepth = ctx.GlobalUint64(TxPoolNonceDepthFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolQueueLifetimeFlag.Name) {
+		cfg.QueueLifetime = ctx.GlobalDuration(TxPoolQueueLifetimeFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolQueueEvictionFlag.Name) {
+		cfg.QueueEviction = core.QueueEvictionPolicy(ctx.GlobalString(TxPoolQueueEvictionFlag.Name))
+	}
+	if ctx.GlobalIsSet(TxPoolSystemExemptFlag.Name) {
+		cfg.SystemPriceExempt = ctx.GlobalBool(TxPoolSystemExemptFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolSystemSlotsFlag.Name) {
+		cfg.SystemSlots = ctx.GlobalUint64(TxPoolSystemSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolAccountSystemSlotsFlag.Name) {
+		cfg.AccountSystemSlots = ctx.GlobalUint64(TxPoolAccountSystemSlotsFlag.Name)
+	}
 }
 
 func setEthash(ctx *cli.Context, cfg *eth.Config) {
@@ -978,6 +1305,9 @@ func setEthash(ctx *cli.Context, cfg *eth.Config) {
 	if ctx.GlobalIsSet(EthashDatasetsOnDiskFlag.Name) {
 		cfg.Ethash.DatasetsOnDisk = ctx.GlobalInt(EthashDatasetsOnDiskFlag.Name)
 	}
+	if ctx.GlobalBool(FakePoWFlag.Name) {
+		cfg.Ethash.PowMode = ethash.ModeFake
+	}
 }
 
 // checkExclusive verifies that only a single isntance of the provided flags was
@@ -1042,6 +1372,10 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	setTxPool(ctx, &cfg.TxPool)
 	setEthash(ctx, cfg)
 
+	if ctx.GlobalIsSet(TxPoolRebroadcastFlag.Name) {
+		cfg.TxRebroadcast = ctx.GlobalUint64(TxPoolRebroadcastFlag.Name)
+	}
+
 	switch {
 	case ctx.GlobalIsSet(SyncModeFlag.Name):
 		cfg.SyncMode = *GlobalTextMarshaler(ctx, SyncModeFlag.Name).(*downloader.SyncMode)
@@ -1070,6 +1404,77 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
 
+	if ctx.GlobalIsSet(ReplicaLeaderFlag.Name) {
+		cfg.ReplicaLeader = ctx.GlobalString(ReplicaLeaderFlag.Name)
+	}
+	if ctx.GlobalIsSet(ReplicaSecretFlag.Name) {
+		cfg.ReplicaSecret = ctx.GlobalString(ReplicaSecretFlag.Name)
+	}
+	if ctx.GlobalIsSet(ReplicaVerifyFlag.Name) {
+		cfg.ReplicaVerify = *GlobalTextMarshaler(ctx, ReplicaVerifyFlag.Name).(*replica.VerifyMode)
+	}
+	if ctx.GlobalBool(CommitteeEnabledFlag.Name) {
+		cfg.CommitteeHeartbeat = ctx.GlobalDuration(CommitteeHeartbeatFlag.Name)
+	}
+	if ctx.GlobalIsSet(CommitteeMembersFlag.Name) {
+		cfg.CommitteeMembers = nil
+		for _, member := range strings.Split(ctx.GlobalString(CommitteeMembersFlag.Name), ",") {
+			if member = strings.TrimSpace(member); !common.IsHexAddress(member) {
+				Fatalf("Invalid committee member address %q", member)
+			}
+			cfg.CommitteeMembers = append(cfg.CommitteeMembers, common.HexToAddress(member))
+		}
+	}
+	if ctx.GlobalIsSet(SafeDepthFlag.Name) {
+		cfg.SafeDepth = ctx.GlobalUint64(SafeDepthFlag.Name)
+	}
+	if ctx.GlobalIsSet(FinalityDepthFlag.Name) {
+		cfg.FinalityDepth = ctx.GlobalUint64(FinalityDepthFlag.Name)
+	}
+	if cfg.SafeDepth > cfg.FinalityDepth {
+		Fatalf("--%s must not exceed --%s", SafeDepthFlag.Name, FinalityDepthFlag.Name)
+	}
+	if ctx.GlobalIsSet(ActivityBloomFlag.Name) {
+		cfg.ActivityBloom = ctx.GlobalBool(ActivityBloomFlag.Name)
+	}
+	if ctx.GlobalIsSet(BalanceIndexFlag.Name) {
+		cfg.BalanceIndex = ctx.GlobalBool(BalanceIndexFlag.Name)
+	}
+	if ctx.GlobalIsSet(HistoryRetainFlag.Name) {
+		cfg.HistoryRetain = ctx.GlobalUint64(HistoryRetainFlag.Name)
+	}
+	if ctx.GlobalIsSet(PrivateStateFlag.Name) {
+		cfg.PrivateState = ctx.GlobalBool(PrivateStateFlag.Name)
+	}
+	if ctx.GlobalIsSet(PrivateNodesFlag.Name) {
+		cfg.PrivateNodes = nil
+		for _, url := range strings.Split(ctx.GlobalString(PrivateNodesFlag.Name), ",") {
+			node, err := discover.ParseNode(strings.TrimSpace(url))
+			if err != nil {
+				Fatalf("Invalid private state node %q: %v", url, err)
+			}
+			cfg.PrivateNodes = append(cfg.PrivateNodes, node.ID)
+		}
+	}
+	if ctx.GlobalIsSet(BreakerFlag.Name) {
+		cfg.Invariants.Enabled = ctx.GlobalBool(BreakerFlag.Name)
+	}
+	if ctx.GlobalIsSet(BreakerBalanceFloorFlag.Name) {
+		cfg.Invariants.BalanceFloor = GlobalBig(ctx, BreakerBalanceFloorFlag.Name)
+	}
+	if ctx.GlobalIsSet(BreakerMinCommitteeFlag.Name) {
+		cfg.Invariants.MinCommittee = ctx.GlobalUint64(BreakerMinCommitteeFlag.Name)
+	}
+	if ctx.GlobalIsSet(BreakerMaxCommitteeFlag.Name) {
+		cfg.Invariants.MaxCommittee = ctx.GlobalUint64(BreakerMaxCommitteeFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogChunkFlag.Name) {
+		cfg.LogChunkSize = ctx.GlobalUint64(RPCLogChunkFlag.Name)
+	}
+	if ctx.GlobalIsSet(RPCLogLimitFlag.Name) {
+		cfg.LogQueryLimit = ctx.GlobalInt(RPCLogLimitFlag.Name)
+	}
+
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheGCFlag.Name) {
 		cfg.TrieCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheGCFlag.Name) / 100
 	}
@@ -1085,6 +1490,18 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *eth.Config) {
 	if ctx.GlobalIsSet(GasPriceFlag.Name) {
 		cfg.GasPrice = GlobalBig(ctx, GasPriceFlag.Name)
 	}
+	if ctx.GlobalIsSet(MinerMinTxsFlag.Name) {
+		cfg.Miner.MinTxs = ctx.GlobalInt(MinerMinTxsFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerMinFeesFlag.Name) {
+		cfg.Miner.MinFees = GlobalBig(ctx, MinerMinFeesFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerDeadlineFlag.Name) {
+		cfg.Miner.Deadline = ctx.GlobalDuration(MinerDeadlineFlag.Name)
+	}
+	if ctx.GlobalIsSet(MinerRecommitFlag.Name) {
+		cfg.Miner.Recommit = ctx.GlobalDuration(MinerRecommitFlag.Name)
+	}
 	if ctx.GlobalIsSet(VMEnableDebugFlag.Name) {
 		// TODO(fjl): force-enable this in --dev mode
 		cfg.EnablePreimageRecording = ctx.GlobalBool(VMEnableDebugFlag.Name)
@@ -1195,6 +1612,20 @@ func RegisterEthStatsService(stack *node.Node, url string) {
 	}
 }
 
+// RegisterBridgeService configures the cross-chain bridge from the given
+// configuration file and adds it to the given node.
+func RegisterBridgeService(stack *node.Node, file string) {
+	config, err := bridge.LoadConfig(file)
+	if err != nil {
+		Fatalf("Failed to load the bridge configuration: %v", err)
+	}
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		return bridge.New(ctx, config)
+	}); err != nil {
+		Fatalf("Failed to register the bridge service: %v", err)
+	}
+}
+
 // SetupNetwork configures the system for either the main net or some test network.
 func SetupNetwork(ctx *cli.Context) {
 	// TODO(fjl): move target gas limit into config
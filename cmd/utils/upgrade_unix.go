@@ -0,0 +1,54 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of go-usechain.
+//
+// go-usechain is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-usechain is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-usechain. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !windows
+
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/node"
+)
+
+// watchUpgrade hands the node over to the binary it was started from, run with
+// the same arguments, whenever SIGUSR2 is received. Replacing the binary on disk
+// followed by the signal upgrades the node without refusing RPC connections.
+func watchUpgrade(stack *node.Node) {
+	go func() {
+		sigc := make(chan os.Signal, 1)
+		signal.Notify(sigc, syscall.SIGUSR2)
+		defer signal.Stop(sigc)
+
+		for range sigc {
+			binary, err := os.Executable()
+			if err != nil {
+				log.Error("Failed to locate upgraded binary", "err", err)
+				continue
+			}
+			pid, err := stack.Handover(binary, os.Args[1:])
+			if err != nil {
+				log.Error("Failed to hand over to upgraded binary", "err", err)
+				continue
+			}
+			log.Info("Got upgrade signal, handed over", "pid", pid)
+			return
+		}
+	}()
+}
@@ -220,7 +220,7 @@ func SendCommitteeMsg(ethereum *eth.Ethereum, msg string) bool {
 	tx := types.NewTransaction(pendingStat.GetNonce(coinbase), common.HexToAddress(OneVerifierAddress), big.NewInt(0), 3000000, big.NewInt(500000000000), msgEncrypted)
 	signedTx, err := wallet.SignTx(account, tx, ethereum.ChainID())
 	if err != nil {
-		utils.Fatalf("Please please unlock the committee account, sign the committee Msg failed ", "err", err)
+		utils.Fatalf("Please please unlock the committee account, sign the committee Msg failed: %v", err)
 	}
 	err = ethereum.TxPool().AddLocal(signedTx)
 	if err != nil {
@@ -51,6 +51,15 @@ type ChainReader interface {
 	//State() (*state.StateDB, error)
 }
 
+// ChainStateReader is a ChainReader which can also open the state of its blocks,
+// needed by engines whose rules depend on the state a block is built on.
+type ChainStateReader interface {
+	ChainReader
+
+	// StateAt retrieves the state with the given root from the database.
+	StateAt(root common.Hash) (*state.StateDB, error)
+}
+
 // Engine is an algorithm agnostic consensus engine.
 type Engine interface {
 	// Author retrieves the Ethereum address of the account that minted the given
@@ -107,3 +116,10 @@ type PoW interface {
 	// Hashrate returns the current mining hashrate of a PoW consensus engine.
 	Hashrate() float64
 }
+
+// Issuer is a consensus engine minting new currency when finalising blocks.
+type Issuer interface {
+	// Issuance returns the amount of currency minted by finalising a block with
+	// the given header and uncles.
+	Issuance(header *types.Header, uncles []*types.Header) *big.Int
+}
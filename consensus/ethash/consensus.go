@@ -28,6 +28,7 @@ import (
 	"github.com/usechain/go-usechain/common/math"
 	"github.com/usechain/go-usechain/consensus"
 	"github.com/usechain/go-usechain/consensus/misc"
+	"github.com/usechain/go-usechain/contracts/delegation"
 	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/params"
@@ -63,6 +64,8 @@ var (
 	errInvalidDifficulty = errors.New("non-positive difficulty")
 	errInvalidMixDigest  = errors.New("invalid mix digest")
 	errInvalidPoW        = errors.New("invalid proof-of-work")
+	errNotElected        = errors.New("coinbase not elected for the epoch")
+	errNoParentState     = errors.New("parent state unavailable")
 )
 
 // Author implements consensus.Engine, returning the header's coinbase as the
@@ -496,6 +499,12 @@ func (ethash *Ethash) Prepare(chain consensus.ChainReader, header *types.Header)
 // Finalize implements consensus.Engine, accumulating the block and uncle rewards,
 // setting the final state and assembling the block.
 func (ethash *Ethash) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	// Ensure the coinbase was elected, unless the elected miners are unresponsive
+	if chain.Config().IsDelegation(header.Number) {
+		if err := verifyElected(chain, header); err != nil {
+			return nil, err
+		}
+	}
 	// Accumulate any block and uncle rewards and commit the final state root
 	accumulateRewards(chain.Config(), state, header, uncles)
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
@@ -504,6 +513,29 @@ func (ethash *Ethash) Finalize(chain consensus.ChainReader, header *types.Header
 	return types.NewBlock(header, txs, uncles, receipts), nil
 }
 
+// verifyElected checks that the coinbase of a header was elected for the epoch,
+// or that the elected miners left the chain without a block for long enough to
+// let anyone stand in. The election is read from the state of the parent, as
+// the transactions of the block itself must not affect who may produce it.
+func verifyElected(chain consensus.ChainReader, header *types.Header) error {
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	reader, ok := chain.(consensus.ChainStateReader)
+	if !ok {
+		return errNoParentState
+	}
+	statedb, err := reader.StateAt(parent.Root)
+	if err != nil {
+		return errNoParentState
+	}
+	if !delegation.IsElected(statedb, header.Coinbase) && header.Time.Uint64() < parent.Time.Uint64()+delegation.StandbyDelay {
+		return errNotElected
+	}
+	return nil
+}
+
 // Some weird constants to avoid constant memory allocs for them.
 var (
 	big8  = big.NewInt(8)
@@ -512,23 +544,51 @@ var (
 
 // AccumulateRewards credits the coinbase of the given block with the mining
 // reward. The total reward consists of the static block reward and rewards for
-// included uncles. The coinbase of each uncle block is also rewarded.
+// included uncles. The coinbase of each uncle block is also rewarded. From the
+// delegation fork on, the delegators of the coinbase get their share of its
+// reward, with the delegation weights being updated at every epoch boundary.
 func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header *types.Header, uncles []*types.Header) {
+	reward, uncleRewards := blockRewards(header, uncles)
+	for i, uncle := range uncles {
+		state.AddBalance(uncle.Coinbase, uncleRewards[i])
+	}
+	if config.IsDelegation(header.Number) {
+		if header.Number.Uint64()%config.DelegationEpochLength() == 0 {
+			delegation.ApplyEpoch(state)
+		}
+		reward = delegation.DistributeReward(state, header.Coinbase, reward)
+	}
+	state.AddBalance(header.Coinbase, reward)
+}
+
+// blockRewards calculates the reward of the miner of a block, including the
+// share for the included uncles, and the rewards of the uncle miners.
+func blockRewards(header *types.Header, uncles []*types.Header) (*big.Int, []*big.Int) {
 	// Select the correct block reward based on chain progression
 	blockReward := SapphireBlockReward
 
 	// Accumulate the rewards for the miner and any included uncles
 	reward := new(big.Int).Set(blockReward)
-	r := new(big.Int)
-	for _, uncle := range uncles {
-		r.Add(uncle.Number, big8)
+	uncleRewards := make([]*big.Int, len(uncles))
+	for i, uncle := range uncles {
+		r := new(big.Int).Add(uncle.Number, big8)
 		r.Sub(r, header.Number)
 		r.Mul(r, blockReward)
 		r.Div(r, big8)
-		state.AddBalance(uncle.Coinbase, r)
+		uncleRewards[i] = r
 
-		r.Div(blockReward, big32)
-		reward.Add(reward, r)
+		reward.Add(reward, new(big.Int).Div(blockReward, big32))
 	}
-	state.AddBalance(header.Coinbase, reward)
+	return reward, uncleRewards
+}
+
+// Issuance implements consensus.Issuer, returning the sum of the block and uncle
+// rewards minted when finalising a block.
+func (ethash *Ethash) Issuance(header *types.Header, uncles []*types.Header) *big.Int {
+	reward, uncleRewards := blockRewards(header, uncles)
+	issuance := new(big.Int).Set(reward)
+	for _, r := range uncleRewards {
+		issuance.Add(issuance, r)
+	}
+	return issuance
 }
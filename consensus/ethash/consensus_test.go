@@ -18,13 +18,20 @@ package ethash
 
 import (
 	"encoding/json"
+	"errors"
 	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/consensus"
+	"github.com/usechain/go-usechain/contracts/delegation"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/params"
 )
 
@@ -84,3 +91,126 @@ func TestCalcDifficulty(t *testing.T) {
 		}
 	}
 }
+
+// Tests that block rewards are split with the delegators of the miner from the
+// delegation fork on, using the weights of the last epoch boundary.
+func TestDelegatedRewards(t *testing.T) {
+	var (
+		miner  = common.Address{0x01}
+		holder = common.Address{0x10}
+	)
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetState(common.HexToAddress(minerlist.MinerListContract), minerlist.MinerKey(miner), common.BigToHash(common.Big1))
+
+	config := *params.TestChainConfig
+	config.DelegationBlock = big.NewInt(10)
+	config.DelegationEpoch = 4
+
+	delegation.Delegate(statedb, holder, miner, delegation.MinDelegation)
+	delegation.SetCommission(statedb, miner, 2500)
+
+	// Returns the rewards paid to the miner and the delegator in the given block
+	mine := func(number int64) (*big.Int, *big.Int) {
+		minerBalance, holderBalance := statedb.GetBalance(miner), statedb.GetBalance(holder)
+		accumulateRewards(&config, statedb, &types.Header{Number: big.NewInt(number), Coinbase: miner}, nil)
+		return new(big.Int).Sub(statedb.GetBalance(miner), minerBalance), new(big.Int).Sub(statedb.GetBalance(holder), holderBalance)
+	}
+	quarter := new(big.Int).Div(SapphireBlockReward, big.NewInt(4))
+
+	// The miner keeps the whole reward before the fork and until the first epoch
+	for _, number := range []int64{9, 10, 11} {
+		if minerReward, holderReward := mine(number); minerReward.Cmp(SapphireBlockReward) != 0 || holderReward.Sign() != 0 {
+			t.Errorf("block %d: reward mismatch: miner %v, delegator %v", number, minerReward, holderReward)
+		}
+	}
+	// From the epoch boundary on the delegator earns all but the commission
+	for _, number := range []int64{12, 13} {
+		minerReward, holderReward := mine(number)
+		if minerReward.Cmp(quarter) != 0 || holderReward.Cmp(new(big.Int).Sub(SapphireBlockReward, quarter)) != 0 {
+			t.Errorf("block %d: reward mismatch: miner %v, delegator %v", number, minerReward, holderReward)
+		}
+	}
+}
+
+// electionChain is a chain reader serving a single parent header and its state.
+type electionChain struct {
+	consensus.ChainReader
+
+	config *params.ChainConfig
+	parent *types.Header
+	state  *state.StateDB
+}
+
+func (c *electionChain) Config() *params.ChainConfig { return c.config }
+
+func (c *electionChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if hash == c.parent.Hash() && number == c.parent.Number.Uint64() {
+		return c.parent
+	}
+	return nil
+}
+
+func (c *electionChain) StateAt(root common.Hash) (*state.StateDB, error) {
+	if root != c.parent.Root {
+		return nil, errors.New("unknown state")
+	}
+	return c.state.Copy(), nil
+}
+
+// Tests that from the delegation fork on only the elected miners may produce
+// blocks, unless they left the chain without a block for the standby delay.
+// The election is the one in the state of the parent, regardless of changes
+// made to it by the block itself.
+func TestElectedCoinbase(t *testing.T) {
+	var (
+		elected = common.Address{0x01}
+		standby = common.Address{0x02}
+	)
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for _, miner := range []common.Address{elected, standby} {
+		statedb.SetState(common.HexToAddress(minerlist.MinerListContract), minerlist.MinerKey(miner), common.BigToHash(common.Big1))
+	}
+	delegation.Delegate(statedb, common.Address{0x10}, elected, delegation.MinDelegation)
+	delegation.ApplyEpoch(statedb)
+	root := statedb.IntermediateRoot(false)
+
+	// The block being finalized elects the standby miner in place of the elected one
+	blockdb := statedb.Copy()
+	delegation.Undelegate(blockdb, common.Address{0x10}, elected, delegation.MinDelegation)
+	delegation.Delegate(blockdb, common.Address{0x10}, standby, delegation.MinDelegation)
+	delegation.ApplyEpoch(blockdb)
+	if delegation.IsElected(blockdb, elected) || !delegation.IsElected(blockdb, standby) {
+		t.Fatalf("block state failed to change the election")
+	}
+
+	config := *params.TestChainConfig
+	config.DelegationBlock = big.NewInt(10)
+
+	tests := []struct {
+		number   int64
+		coinbase common.Address
+		delay    uint64
+		err      error
+	}{
+		{9, standby, 1, nil},
+		{10, elected, 1, nil},
+		{10, standby, 1, errNotElected},
+		{10, standby, delegation.StandbyDelay - 1, errNotElected},
+		{10, standby, delegation.StandbyDelay, nil},
+	}
+	for i, tt := range tests {
+		parent := &types.Header{Root: root, Number: big.NewInt(tt.number - 1), Time: big.NewInt(1000)}
+		header := &types.Header{
+			ParentHash: parent.Hash(),
+			Number:     big.NewInt(tt.number),
+			Time:       new(big.Int).SetUint64(1000 + tt.delay),
+			Coinbase:   tt.coinbase,
+		}
+		chain := &electionChain{config: &config, parent: parent, state: statedb}
+		if _, err := NewFaker().Finalize(chain, header, blockdb.Copy(), nil, nil, nil); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"math/rand"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/consensus"
@@ -36,6 +37,17 @@ func (ethash *Ethash) Seal(chain consensus.ChainReader, block *types.Block, stop
 	// If we're running a fake PoW, simply return a 0 nonce immediately
 	if ethash.config.PowMode == ModeFake || ethash.config.PowMode == ModeFullFake {
 		header := block.Header()
+		if ethash.config.PowMode == ModeFake {
+			// Don't seal ahead of the block's timestamp, capping fake miners at a
+			// block per second instead of bursting out blocks from the future
+			if delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now()); delay > 0 {
+				select {
+				case <-stop:
+					return nil, nil
+				case <-time.After(delay):
+				}
+			}
+		}
 		header.Nonce, header.MixDigest = types.BlockNonce{}, common.Hash{}
 		return block.WithSeal(header), nil
 	}
@@ -28,6 +28,8 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/usechain/go-usechain/accounts/abi"
+	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/internal/jsre"
 	"github.com/usechain/go-usechain/internal/web3ext"
 	"github.com/usechain/go-usechain/rpc"
@@ -71,6 +73,7 @@ type Console struct {
 	histPath string       // Absolute path to the console scrollback history
 	history  []string     // Scroll history maintained by the console
 	printer  io.Writer    // Output writer to serialize any display strings to
+	docRoot  string       // Filesystem path from where to load ABI files from
 }
 
 func New(config Config) (*Console, error) {
@@ -91,6 +94,7 @@ func New(config Config) (*Console, error) {
 		prompt:   config.Prompt,
 		prompter: config.Prompter,
 		printer:  config.Printer,
+		docRoot:  config.DocRoot,
 		histPath: filepath.Join(config.DataDir, HistoryFile),
 	}
 	if err := os.MkdirAll(config.DataDir, 0700); err != nil {
@@ -135,7 +139,6 @@ func (c *Console) init(preload []string) error {
 	if err != nil {
 		return fmt.Errorf("api modules: %v", err)
 	}
-	flatten := "var eth = web3.eth; var personal = web3.personal; "
 	for api := range apis {
 		if api == "web3" {
 			continue // manually mapped or ignore
@@ -145,15 +148,35 @@ func (c *Console) init(preload []string) error {
 			if err = c.jsre.Compile(fmt.Sprintf("%s.js", api), file); err != nil {
 				return fmt.Errorf("%s.js: %v", api, err)
 			}
-			flatten += fmt.Sprintf("var %s = web3.%s; ", api, api)
-		} else if obj, err := c.jsre.Run("web3." + api); err == nil && obj.IsObject() {
-			// Enable web3.js built-in extension if available.
+		}
+	}
+	// Bind every remaining method the node serves, so new APIs are usable even
+	// without a hand-written extension. Older nodes lack rpc_methods, skip them.
+	if methods, err := c.client.SupportedMethods(); err == nil {
+		for api, callbacks := range methods {
+			if _, ok := apis[api]; !ok || api == "web3" {
+				continue
+			}
+			if err := c.bindMethods(api, callbacks); err != nil {
+				return err
+			}
+		}
+	}
+	flatten := "var eth = web3.eth; var personal = web3.personal; "
+	for api := range apis {
+		if api == "web3" {
+			continue // manually mapped or ignore
+		}
+		if obj, err := c.jsre.Run("web3." + api); err == nil && obj.IsObject() {
 			flatten += fmt.Sprintf("var %s = web3.%s; ", api, api)
 		}
 	}
 	if _, err = c.jsre.Run(flatten); err != nil {
 		return fmt.Errorf("namespace flattening: %v", err)
 	}
+	// Offer binding deployed contracts straight from their ABI files
+	c.jsre.Set("loadContract", c.loadContract)
+
 	// Initialize the global name register (disabled for now)
 	//c.jsre.Run(`var GlobalRegistrar = eth.contract(` + registrar.GlobalRegistrarAbi + `);   registrar = GlobalRegistrar.at("` + registrar.GlobalRegistrarAddr + `");`)
 
@@ -235,6 +258,62 @@ func (c *Console) clearHistory() {
 	}
 }
 
+// loadContract reads a JSON ABI file and returns a web3 contract object for it,
+// bound to the given address if one is specified. Relative paths are resolved
+// against the console's document root.
+func (c *Console) loadContract(call otto.FunctionCall) otto.Value {
+	if len(call.ArgumentList) == 0 || len(call.ArgumentList) > 2 || !call.Argument(0).IsString() {
+		throwJSException("usage: loadContract(<abi file>[, address])")
+	}
+	file, _ := call.Argument(0).ToString()
+	blob, err := ioutil.ReadFile(common.AbsolutePath(c.docRoot, file))
+	if err != nil {
+		throwJSException(err.Error())
+	}
+	if _, err := abi.JSON(strings.NewReader(string(blob))); err != nil {
+		throwJSException(fmt.Sprintf("invalid ABI: %v", err))
+	}
+	script := fmt.Sprintf("eth.contract(%s)", blob)
+	if len(call.ArgumentList) == 2 {
+		address, _ := call.Argument(1).ToString()
+		if !common.IsHexAddress(address) {
+			throwJSException(fmt.Sprintf("invalid address: %s", address))
+		}
+		script += fmt.Sprintf(".at(%q)", address)
+	}
+	contract, err := call.Otto.Run(script)
+	if err != nil {
+		throwJSException(err.Error())
+	}
+	return contract
+}
+
+// bindMethods generates web3.js bindings for the methods of an RPC namespace
+// which neither web3.js nor the hand-written extensions already define.
+func (c *Console) bindMethods(api string, methods map[string]int) error {
+	unbound := make(map[string]int)
+	if obj, err := c.jsre.Run("web3." + api); err == nil && obj.IsObject() {
+		// Probe with 'in' so that property getters don't fire RPC calls
+		for name, params := range methods {
+			if defined, err := c.jsre.Run(fmt.Sprintf("'%s' in web3.%s", name, api)); err == nil {
+				if ok, _ := defined.ToBoolean(); ok {
+					continue
+				}
+			}
+			unbound[name] = params
+		}
+	} else {
+		unbound = methods
+	}
+	if len(unbound) == 0 {
+		return nil
+	}
+	if err := c.jsre.Compile(fmt.Sprintf("%s.gen.js", api), web3ext.Generate(api, unbound)); err != nil {
+		return fmt.Errorf("%s.gen.js: %v", api, err)
+	}
+	return nil
+}
+
 // consoleOutput is an override for the console.log and console.error methods to
 // stream the output into the configured output stream instead of stdout.
 func (c *Console) consoleOutput(call otto.FunctionCall) otto.Value {
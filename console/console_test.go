@@ -193,6 +193,24 @@ func TestEvaluate(t *testing.T) {
 	}
 }
 
+// Tests that RPC methods without a hand-written extension are bound into the
+// console, while the existing web3.js definitions are left intact.
+func TestGeneratedBindings(t *testing.T) {
+	tester := newTester(t, nil)
+	defer tester.Close(t)
+
+	tester.console.Evaluate("typeof use.getBalanceChanges")
+	if output := tester.output.String(); !strings.Contains(output, "function") {
+		t.Fatalf("generated binding missing: have %s, want %s", output, "function")
+	}
+	tester.output.Reset()
+
+	tester.console.Evaluate("typeof eth.accounts")
+	if output := tester.output.String(); !strings.Contains(output, "object") {
+		t.Fatalf("built-in property overridden: have %s, want %s", output, "object")
+	}
+}
+
 // Tests that the console can be used in interactive mode.
 func TestInteractive(t *testing.T) {
 	// Create a tester and run an interactive console in the background
@@ -248,6 +266,20 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+// Tests that contract objects can be loaded from ABI files in the asset path.
+func TestLoadContract(t *testing.T) {
+	tester := newTester(t, nil)
+	defer tester.Close(t)
+
+	tester.console.Evaluate("token = loadContract('token.abi', '0x0000000000000000000000000000000000000001')")
+	tester.output.Reset()
+
+	tester.console.Evaluate("[token.address, typeof token.balanceOf]")
+	if output := tester.output.String(); !strings.Contains(output, "0x0000000000000000000000000000000000000001") || !strings.Contains(output, "function") {
+		t.Fatalf("contract not bound: have %s", output)
+	}
+}
+
 // Tests that the JavaScript objects returned by statement executions are properly
 // pretty printed instead of just displaing "[object]".
 func TestPrettyPrint(t *testing.T) {
@@ -0,0 +1,447 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package delegation implements the stake delegation registry, through which
+// token holders back candidate miners with their stake in exchange for a share
+// of the block rewards.
+//
+// Stake is held by the registry contract. Increases of it count towards the
+// reward split from the next epoch boundary on, while withdrawals stop earning
+// right away. Miners charge a commission on the rewards of their delegators,
+// changes of which also take effect at the next epoch boundary.
+//
+// At every epoch boundary the registered miners backed by the most stake are
+// elected to produce the blocks of the epoch. Once a miner has been elected,
+// others may only step in if no block was produced for StandbyDelay seconds.
+// Until then, the plain miner list rules apply.
+package delegation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/crypto"
+)
+
+const (
+	DelegationContract = "0xfffffffffffffffffffffffffffffffff0000003"
+
+	MaxCommission = 10000 // Commission rates are expressed in basis points of the rewards
+	MaxDelegators = 64    // Maximum number of delegators backing a single candidate
+	MaxElected    = 21    // Maximum number of miners elected to produce the blocks of an epoch
+	StandbyDelay  = 60    // Seconds after its parent from which an unelected miner may produce a block
+)
+
+var (
+	// Address is the address of the delegation registry contract.
+	Address = common.HexToAddress(DelegationContract)
+
+	// MinDelegation is the smallest stake a delegator may hold with a candidate,
+	// preventing the delegator lists from being filled up with dust.
+	MinDelegation = big.NewInt(1e18)
+)
+
+var (
+	ErrNotMiner          = errors.New("candidate is not a registered miner")
+	ErrStakeTooLow       = errors.New("stake below the minimum delegation")
+	ErrInsufficientStake = errors.New("insufficient delegated stake")
+	ErrTooManyDelegators = errors.New("too many delegators, stake must exceed the smallest one")
+	ErrInvalidCommission = errors.New("commission rate above 100%")
+)
+
+// StateReader is the state access needed to read the delegation table.
+type StateReader interface {
+	GetState(common.Address, common.Hash) common.Hash
+}
+
+// StateDB is the state access needed to update the delegation table and pay
+// out the delegators.
+type StateDB interface {
+	StateReader
+	SetState(common.Address, common.Hash, common.Hash)
+	AddBalance(common.Address, *big.Int)
+	SubBalance(common.Address, *big.Int)
+}
+
+// candidatesList is the prefix of the list of candidates ever delegated to or
+// having set a commission.
+var candidatesList = []byte("candidates")
+
+// electedList is the prefix of the list of miners elected for the current epoch.
+var electedList = []byte("elected")
+
+// delegatorsList returns the prefix of the list of delegators of a candidate.
+func delegatorsList(candidate common.Address) []byte {
+	return append([]byte("delegators"), candidate.Bytes()...)
+}
+
+// slot derives the storage slot of a registry field from its name and keys.
+func slot(field string, keys ...[]byte) common.Hash {
+	return crypto.Keccak256Hash(append([][]byte{[]byte(field)}, keys...)...)
+}
+
+func stakeSlot(candidate, delegator common.Address) common.Hash {
+	return slot("stake", candidate.Bytes(), delegator.Bytes())
+}
+
+func weightSlot(candidate, delegator common.Address) common.Hash {
+	return slot("weight", candidate.Bytes(), delegator.Bytes())
+}
+
+func totalWeightSlot(candidate common.Address) common.Hash {
+	return slot("totalWeight", candidate.Bytes())
+}
+
+func commissionSlot(candidate common.Address) common.Hash {
+	return slot("commission", candidate.Bytes())
+}
+
+// pendingCommissionSlot holds the commission rate taking effect at the next
+// epoch boundary, offset by one so that a zero rate can be told apart from none.
+func pendingCommissionSlot(candidate common.Address) common.Hash {
+	return slot("pendingCommission", candidate.Bytes())
+}
+
+func getBig(db StateReader, key common.Hash) *big.Int {
+	return db.GetState(Address, key).Big()
+}
+
+func setBig(db StateDB, key common.Hash, value *big.Int) {
+	db.SetState(Address, key, common.BigToHash(value))
+}
+
+func encodeIndex(i uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, i)
+	return enc
+}
+
+// listLen returns the number of addresses in a storage list.
+func listLen(db StateReader, list []byte) uint64 {
+	return getBig(db, slot("length", list)).Uint64()
+}
+
+// listAt returns the address at the given position of a storage list.
+func listAt(db StateReader, list []byte, i uint64) common.Address {
+	return common.BytesToAddress(db.GetState(Address, slot("entry", list, encodeIndex(i))).Bytes())
+}
+
+// listContains returns whether an address is in a storage list.
+func listContains(db StateReader, list []byte, addr common.Address) bool {
+	return getBig(db, slot("position", list, addr.Bytes())).Sign() != 0
+}
+
+// listAdd appends an address to a storage list.
+func listAdd(db StateDB, list []byte, addr common.Address) {
+	n := listLen(db, list)
+	db.SetState(Address, slot("entry", list, encodeIndex(n)), addr.Hash())
+	setBig(db, slot("position", list, addr.Bytes()), new(big.Int).SetUint64(n+1))
+	setBig(db, slot("length", list), new(big.Int).SetUint64(n+1))
+}
+
+// listRemove removes an address from a storage list, moving the last one into
+// its position.
+func listRemove(db StateDB, list []byte, addr common.Address) {
+	pos := getBig(db, slot("position", list, addr.Bytes())).Uint64()
+	if pos == 0 {
+		return
+	}
+	n := listLen(db, list)
+	if pos != n {
+		last := listAt(db, list, n-1)
+		db.SetState(Address, slot("entry", list, encodeIndex(pos-1)), last.Hash())
+		setBig(db, slot("position", list, last.Bytes()), new(big.Int).SetUint64(pos))
+	}
+	db.SetState(Address, slot("entry", list, encodeIndex(n-1)), common.Hash{})
+	db.SetState(Address, slot("position", list, addr.Bytes()), common.Hash{})
+	setBig(db, slot("length", list), new(big.Int).SetUint64(n-1))
+}
+
+// Stake returns the stake a delegator holds with a candidate.
+func Stake(db StateReader, candidate, delegator common.Address) *big.Int {
+	return getBig(db, stakeSlot(candidate, delegator))
+}
+
+// Weight returns the stake of a delegator counting towards the reward split of
+// the current epoch.
+func Weight(db StateReader, candidate, delegator common.Address) *big.Int {
+	return getBig(db, weightSlot(candidate, delegator))
+}
+
+// TotalWeight returns the stake of all delegators of a candidate counting
+// towards the reward split of the current epoch.
+func TotalWeight(db StateReader, candidate common.Address) *big.Int {
+	return getBig(db, totalWeightSlot(candidate))
+}
+
+// Commission returns the commission rate a candidate charges in the current
+// epoch, in basis points.
+func Commission(db StateReader, candidate common.Address) uint64 {
+	return getBig(db, commissionSlot(candidate)).Uint64()
+}
+
+// IsElected returns whether a miner was elected to produce the blocks of the
+// current epoch. If no election took place yet, every miner is.
+func IsElected(db StateReader, miner common.Address) bool {
+	return listLen(db, electedList) == 0 || listContains(db, electedList, miner)
+}
+
+// Elected returns the miners elected for the current epoch, heaviest first.
+func Elected(db StateReader) []common.Address {
+	n := listLen(db, electedList)
+	elected := make([]common.Address, 0, n)
+	for i := uint64(0); i < n; i++ {
+		elected = append(elected, listAt(db, electedList, i))
+	}
+	return elected
+}
+
+// Delegate adds amount to the stake a delegator holds with a candidate. The
+// funds must have been transferred to the registry contract already. If the
+// candidate has no room for another delegator, the one with the smallest stake
+// is evicted and refunded, provided the new stake exceeds it.
+func Delegate(db StateDB, delegator, candidate common.Address, amount *big.Int) error {
+	if !minerlist.IsMiner(db, candidate) {
+		return ErrNotMiner
+	}
+	stake := new(big.Int).Add(Stake(db, candidate, delegator), amount)
+	if amount.Sign() <= 0 || stake.Cmp(MinDelegation) < 0 {
+		return ErrStakeTooLow
+	}
+	delegators := delegatorsList(candidate)
+	if !listContains(db, delegators, delegator) {
+		if listLen(db, delegators) >= MaxDelegators {
+			smallest, smallestStake := smallestDelegator(db, candidate)
+			if stake.Cmp(smallestStake) <= 0 {
+				return ErrTooManyDelegators
+			}
+			evict(db, candidate, smallest)
+		}
+		listAdd(db, delegators, delegator)
+	}
+	if !listContains(db, candidatesList, candidate) {
+		listAdd(db, candidatesList, candidate)
+	}
+	setBig(db, stakeSlot(candidate, delegator), stake)
+	return nil
+}
+
+// Undelegate withdraws amount from the stake a delegator holds with a candidate.
+// The withdrawn stake stops earning immediately, paying it out of the registry
+// contract is left to the caller.
+func Undelegate(db StateDB, delegator, candidate common.Address, amount *big.Int) error {
+	stake := Stake(db, candidate, delegator)
+	if amount.Sign() <= 0 || amount.Cmp(stake) > 0 {
+		return ErrInsufficientStake
+	}
+	stake.Sub(stake, amount)
+	if stake.Sign() > 0 && stake.Cmp(MinDelegation) < 0 {
+		return ErrStakeTooLow
+	}
+	if weight := Weight(db, candidate, delegator); weight.Cmp(stake) > 0 {
+		total := TotalWeight(db, candidate)
+		setBig(db, totalWeightSlot(candidate), total.Sub(total, weight.Sub(weight, stake)))
+		setBig(db, weightSlot(candidate, delegator), stake)
+	}
+	setBig(db, stakeSlot(candidate, delegator), stake)
+	if stake.Sign() == 0 {
+		listRemove(db, delegatorsList(candidate), delegator)
+	}
+	return nil
+}
+
+// smallestDelegator returns the delegator of a candidate holding the smallest
+// stake, the earliest listed one on ties.
+func smallestDelegator(db StateReader, candidate common.Address) (common.Address, *big.Int) {
+	var (
+		smallest      common.Address
+		smallestStake *big.Int
+	)
+	delegators := delegatorsList(candidate)
+	for i, n := uint64(0), listLen(db, delegators); i < n; i++ {
+		delegator := listAt(db, delegators, i)
+		if stake := Stake(db, candidate, delegator); smallestStake == nil || stake.Cmp(smallestStake) < 0 {
+			smallest, smallestStake = delegator, stake
+		}
+	}
+	return smallest, smallestStake
+}
+
+// evict withdraws the whole stake of a delegator of a candidate, refunding it
+// from the registry contract.
+func evict(db StateDB, candidate, delegator common.Address) {
+	stake := Stake(db, candidate, delegator)
+	if err := Undelegate(db, delegator, candidate, stake); err != nil {
+		panic(err) // can't happen, the whole stake is always withdrawable
+	}
+	db.SubBalance(Address, stake)
+	db.AddBalance(delegator, stake)
+}
+
+// SetCommission schedules the commission rate a candidate charges its delegators,
+// in basis points, from the next epoch boundary on.
+func SetCommission(db StateDB, candidate common.Address, rate uint64) error {
+	if !minerlist.IsMiner(db, candidate) {
+		return ErrNotMiner
+	}
+	if rate > MaxCommission {
+		return ErrInvalidCommission
+	}
+	if !listContains(db, candidatesList, candidate) {
+		listAdd(db, candidatesList, candidate)
+	}
+	setBig(db, pendingCommissionSlot(candidate), new(big.Int).SetUint64(rate+1))
+	return nil
+}
+
+// ApplyEpoch starts a new epoch, making the current stakes the weights of the
+// reward split and the scheduled commission rates effective, and electing the
+// miners of the epoch.
+func ApplyEpoch(db StateDB) {
+	var (
+		ranked  []common.Address
+		weights = make(map[common.Address]*big.Int)
+	)
+	for i, n := uint64(0), listLen(db, candidatesList); i < n; i++ {
+		candidate := listAt(db, candidatesList, i)
+
+		if pending := getBig(db, pendingCommissionSlot(candidate)); pending.Sign() != 0 {
+			setBig(db, commissionSlot(candidate), pending.Sub(pending, common.Big1))
+			db.SetState(Address, pendingCommissionSlot(candidate), common.Hash{})
+		}
+		total := new(big.Int)
+		delegators := delegatorsList(candidate)
+		for j, m := uint64(0), listLen(db, delegators); j < m; j++ {
+			delegator := listAt(db, delegators, j)
+			stake := Stake(db, candidate, delegator)
+			setBig(db, weightSlot(candidate, delegator), stake)
+			total.Add(total, stake)
+		}
+		setBig(db, totalWeightSlot(candidate), total)
+
+		if total.Sign() > 0 && minerlist.IsMiner(db, candidate) {
+			ranked = append(ranked, candidate)
+			weights[candidate] = total
+		}
+	}
+	elect(db, ranked, weights)
+}
+
+// elect replaces the miners elected for the current epoch with the heaviest of
+// the given candidates, breaking ties by address.
+func elect(db StateDB, candidates []common.Address, weights map[common.Address]*big.Int) {
+	sort.Slice(candidates, func(i, j int) bool {
+		if cmp := weights[candidates[i]].Cmp(weights[candidates[j]]); cmp != 0 {
+			return cmp > 0
+		}
+		return bytes.Compare(candidates[i].Bytes(), candidates[j].Bytes()) < 0
+	})
+	if len(candidates) > MaxElected {
+		candidates = candidates[:MaxElected]
+	}
+	for n := listLen(db, electedList); n > 0; n-- {
+		listRemove(db, electedList, listAt(db, electedList, n-1))
+	}
+	for _, miner := range candidates {
+		listAdd(db, electedList, miner)
+	}
+}
+
+// DistributeReward pays the delegators of a candidate their share of a block
+// reward proportionally to their weight, after deducting the commission of the
+// candidate. The remainder of the reward due to the candidate is returned.
+func DistributeReward(db StateDB, candidate common.Address, reward *big.Int) *big.Int {
+	total := TotalWeight(db, candidate)
+	if total.Sign() == 0 {
+		return reward
+	}
+	pool := new(big.Int).Mul(reward, new(big.Int).SetUint64(MaxCommission-Commission(db, candidate)))
+	pool.Div(pool, big.NewInt(MaxCommission))
+
+	remainder := new(big.Int).Set(reward)
+	delegators := delegatorsList(candidate)
+	for i, n := uint64(0), listLen(db, delegators); i < n; i++ {
+		delegator := listAt(db, delegators, i)
+		weight := Weight(db, candidate, delegator)
+		if weight.Sign() == 0 {
+			continue
+		}
+		share := new(big.Int).Mul(pool, weight)
+		share.Div(share, total)
+
+		db.AddBalance(delegator, share)
+		remainder.Sub(remainder, share)
+	}
+	return remainder
+}
+
+// Delegator is the stake a delegator holds with a candidate.
+type Delegator struct {
+	Address common.Address `json:"address"`
+	Stake   *hexutil.Big   `json:"stake"`
+	Weight  *hexutil.Big   `json:"weight"` // Stake counting towards the current epoch
+}
+
+// Candidate is the delegation table entry of a candidate miner.
+type Candidate struct {
+	Address           common.Address  `json:"address"`
+	Commission        hexutil.Uint64  `json:"commission"`                  // Basis points charged in the current epoch
+	PendingCommission *hexutil.Uint64 `json:"pendingCommission,omitempty"` // Basis points charged from the next epoch
+	Weight            *hexutil.Big    `json:"weight"`
+	Elected           bool            `json:"elected"` // Whether elected to produce blocks in the current epoch
+	Delegators        []*Delegator    `json:"delegators"`
+}
+
+// ReadCandidate retrieves the delegation table entry of a candidate.
+func ReadCandidate(db StateReader, candidate common.Address) *Candidate {
+	entry := &Candidate{
+		Address:    candidate,
+		Commission: hexutil.Uint64(Commission(db, candidate)),
+		Weight:     (*hexutil.Big)(TotalWeight(db, candidate)),
+		Elected:    listContains(db, electedList, candidate),
+		Delegators: []*Delegator{},
+	}
+	if pending := getBig(db, pendingCommissionSlot(candidate)); pending.Sign() != 0 {
+		rate := hexutil.Uint64(pending.Uint64() - 1)
+		entry.PendingCommission = &rate
+	}
+	delegators := delegatorsList(candidate)
+	for i, n := uint64(0), listLen(db, delegators); i < n; i++ {
+		delegator := listAt(db, delegators, i)
+		entry.Delegators = append(entry.Delegators, &Delegator{
+			Address: delegator,
+			Stake:   (*hexutil.Big)(Stake(db, candidate, delegator)),
+			Weight:  (*hexutil.Big)(Weight(db, candidate, delegator)),
+		})
+	}
+	return entry
+}
+
+// ReadCandidates retrieves the delegation table entries of all candidates.
+func ReadCandidates(db StateReader) []*Candidate {
+	n := listLen(db, candidatesList)
+	candidates := make([]*Candidate, 0, n)
+	for i := uint64(0); i < n; i++ {
+		candidates = append(candidates, ReadCandidate(db, listAt(db, candidatesList, i)))
+	}
+	return candidates
+}
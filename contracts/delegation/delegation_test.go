@@ -0,0 +1,256 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package delegation
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// newTestState creates an empty state with the given miners registered.
+func newTestState(miners ...common.Address) *state.StateDB {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for _, miner := range miners {
+		statedb.SetState(common.HexToAddress(minerlist.MinerListContract), minerlist.MinerKey(miner), common.BigToHash(common.Big1))
+	}
+	return statedb
+}
+
+// ether converts a number of whole tokens into their smallest unit.
+func ether(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), MinDelegation)
+}
+
+// Tests that delegations are validated against the miner list, the minimum
+// stake and the delegator allowance of a candidate.
+func TestDelegateValidation(t *testing.T) {
+	var (
+		miner    = common.Address{0x01}
+		stranger = common.Address{0x02}
+		holder   = common.Address{0x10}
+	)
+	statedb := newTestState(miner)
+
+	if err := Delegate(statedb, holder, stranger, ether(1)); err != ErrNotMiner {
+		t.Errorf("delegation to non-miner: have %v, want %v", err, ErrNotMiner)
+	}
+	if err := Delegate(statedb, holder, miner, big.NewInt(1)); err != ErrStakeTooLow {
+		t.Errorf("dust delegation: have %v, want %v", err, ErrStakeTooLow)
+	}
+	for i := 0; i < MaxDelegators; i++ {
+		if err := Delegate(statedb, common.Address{0x10, byte(i)}, miner, ether(1)); err != nil {
+			t.Fatalf("delegator %d: failed to delegate: %v", i, err)
+		}
+	}
+	if err := Delegate(statedb, common.Address{0x20}, miner, ether(1)); err != ErrTooManyDelegators {
+		t.Errorf("delegation beyond allowance: have %v, want %v", err, ErrTooManyDelegators)
+	}
+	// Existing delegators may still top up their stake
+	if err := Delegate(statedb, common.Address{0x10, 0x00}, miner, ether(1)); err != nil {
+		t.Errorf("failed to top up stake: %v", err)
+	}
+	if stake := Stake(statedb, miner, common.Address{0x10, 0x00}); stake.Cmp(ether(2)) != 0 {
+		t.Errorf("stake mismatch: have %v, want %v", stake, ether(2))
+	}
+	if err := SetCommission(statedb, miner, MaxCommission+1); err != ErrInvalidCommission {
+		t.Errorf("excessive commission: have %v, want %v", err, ErrInvalidCommission)
+	}
+	if err := SetCommission(statedb, stranger, 100); err != ErrNotMiner {
+		t.Errorf("commission of non-miner: have %v, want %v", err, ErrNotMiner)
+	}
+}
+
+// Tests that added stake and commission changes only count from the next epoch
+// on, whereas withdrawals stop earning immediately.
+func TestDelegationEpochs(t *testing.T) {
+	var (
+		miner = common.Address{0x01}
+		alice = common.Address{0x10}
+		bob   = common.Address{0x20}
+	)
+	statedb := newTestState(miner)
+
+	Delegate(statedb, alice, miner, ether(1))
+	Delegate(statedb, bob, miner, ether(3))
+	SetCommission(statedb, miner, 1000)
+
+	if reward := DistributeReward(statedb, miner, ether(100)); reward.Cmp(ether(100)) != 0 {
+		t.Fatalf("reward split before epoch: have %v, want %v", reward, ether(100))
+	}
+	ApplyEpoch(statedb)
+
+	if weight := TotalWeight(statedb, miner); weight.Cmp(ether(4)) != 0 {
+		t.Fatalf("total weight mismatch: have %v, want %v", weight, ether(4))
+	}
+	// 10% commission, the remaining 90 split 1:3
+	if reward := DistributeReward(statedb, miner, ether(100)); reward.Cmp(ether(10)) != 0 {
+		t.Errorf("miner reward mismatch: have %v, want %v", reward, ether(10))
+	}
+	if balance := statedb.GetBalance(alice); balance.Cmp(new(big.Int).Div(ether(90), big.NewInt(4))) != 0 {
+		t.Errorf("alice reward mismatch: have %v", balance)
+	}
+	if balance := statedb.GetBalance(bob); balance.Cmp(new(big.Int).Div(ether(270), big.NewInt(4))) != 0 {
+		t.Errorf("bob reward mismatch: have %v", balance)
+	}
+	// Withdrawing everything takes the delegator out of the split right away
+	if err := Undelegate(statedb, bob, miner, ether(3)); err != nil {
+		t.Fatalf("failed to undelegate: %v", err)
+	}
+	if weight := TotalWeight(statedb, miner); weight.Cmp(ether(1)) != 0 {
+		t.Errorf("total weight after withdrawal: have %v, want %v", weight, ether(1))
+	}
+	entry := ReadCandidate(statedb, miner)
+	if len(entry.Delegators) != 1 || entry.Delegators[0].Address != alice {
+		t.Errorf("delegators after withdrawal mismatch: %v", entry.Delegators)
+	}
+	if err := Undelegate(statedb, alice, miner, ether(2)); err != ErrInsufficientStake {
+		t.Errorf("over-withdrawal: have %v, want %v", err, ErrInsufficientStake)
+	}
+	if err := Undelegate(statedb, alice, miner, big.NewInt(1)); err != ErrStakeTooLow {
+		t.Errorf("withdrawal leaving dust: have %v, want %v", err, ErrStakeTooLow)
+	}
+	// Scheduled commissions are reported until they take effect
+	SetCommission(statedb, miner, 0)
+	if entry := ReadCandidate(statedb, miner); entry.Commission != 1000 || entry.PendingCommission == nil || *entry.PendingCommission != 0 {
+		t.Errorf("scheduled commission mismatch: have %d, pending %v", entry.Commission, entry.PendingCommission)
+	}
+	ApplyEpoch(statedb)
+	if entry := ReadCandidate(statedb, miner); entry.Commission != 0 || entry.PendingCommission != nil {
+		t.Errorf("applied commission mismatch: have %d, pending %v", entry.Commission, entry.PendingCommission)
+	}
+	if candidates := ReadCandidates(statedb); len(candidates) != 1 || candidates[0].Address != miner {
+		t.Errorf("candidates mismatch: %v", candidates)
+	}
+}
+
+// Tests that a candidate without room for more delegators accepts a newcomer by
+// evicting and refunding the smallest stake, if the newcomer's stake exceeds it.
+func TestDelegatorEviction(t *testing.T) {
+	miner := common.Address{0x01}
+	statedb := newTestState(miner)
+
+	for i := 0; i < MaxDelegators; i++ {
+		stake := ether(2)
+		if i == 10 {
+			stake = ether(1)
+		}
+		statedb.AddBalance(Address, stake)
+		if err := Delegate(statedb, common.Address{0x10, byte(i)}, miner, stake); err != nil {
+			t.Fatalf("delegator %d: failed to delegate: %v", i, err)
+		}
+	}
+	ApplyEpoch(statedb)
+
+	var (
+		smallest = common.Address{0x10, 10}
+		newcomer = common.Address{0x20}
+	)
+	if err := Delegate(statedb, newcomer, miner, ether(1)); err != ErrTooManyDelegators {
+		t.Errorf("newcomer matching smallest stake: have %v, want %v", err, ErrTooManyDelegators)
+	}
+	statedb.AddBalance(Address, ether(2))
+	if err := Delegate(statedb, newcomer, miner, ether(2)); err != nil {
+		t.Fatalf("failed to evict smallest stake: %v", err)
+	}
+	if stake := Stake(statedb, miner, smallest); stake.Sign() != 0 {
+		t.Errorf("evicted stake left: %v", stake)
+	}
+	if balance := statedb.GetBalance(smallest); balance.Cmp(ether(1)) != 0 {
+		t.Errorf("evicted refund mismatch: have %v, want %v", balance, ether(1))
+	}
+	if weight := TotalWeight(statedb, miner); weight.Cmp(ether(2*(MaxDelegators-1))) != 0 {
+		t.Errorf("total weight after eviction: have %v, want %v", weight, ether(2*(MaxDelegators-1)))
+	}
+	if entry := ReadCandidate(statedb, miner); len(entry.Delegators) != MaxDelegators {
+		t.Errorf("delegator count mismatch: have %d, want %d", len(entry.Delegators), MaxDelegators)
+	}
+	// Ties evict the earliest listed delegator
+	statedb.AddBalance(Address, ether(3))
+	if err := Delegate(statedb, common.Address{0x30}, miner, ether(3)); err != nil {
+		t.Fatalf("failed to evict on ties: %v", err)
+	}
+	if stake := Stake(statedb, miner, common.Address{0x10, 0}); stake.Sign() != 0 {
+		t.Errorf("earliest delegator not evicted, stake %v", stake)
+	}
+}
+
+// Tests that the registered miners backed by the most stake are elected at the
+// epoch boundaries, and that every miner is eligible until the first election.
+func TestMinerElection(t *testing.T) {
+	var miners []common.Address
+	for i := 0; i < MaxElected+2; i++ {
+		miners = append(miners, common.Address{0x01, byte(i)})
+	}
+	statedb := newTestState(miners...)
+	holder := common.Address{0x10}
+
+	ApplyEpoch(statedb)
+	for _, miner := range miners {
+		if !IsElected(statedb, miner) {
+			t.Fatalf("miner %x not eligible before the first election", miner)
+		}
+	}
+	// Back all but the first miner, the second one the least
+	for i, miner := range miners[1:] {
+		stake := ether(int64(10 + i))
+		if i == 0 {
+			stake = ether(1)
+		}
+		if err := Delegate(statedb, holder, miner, stake); err != nil {
+			t.Fatalf("failed to delegate to miner %d: %v", i+1, err)
+		}
+	}
+	// Stakes are only counted at the epoch boundary
+	if elected := Elected(statedb); len(elected) != 0 {
+		t.Fatalf("miners elected before the epoch boundary: %v", elected)
+	}
+	ApplyEpoch(statedb)
+
+	elected := Elected(statedb)
+	if len(elected) != MaxElected {
+		t.Fatalf("elected count mismatch: have %d, want %d", len(elected), MaxElected)
+	}
+	for i, miner := range elected {
+		if want := miners[len(miners)-1-i]; miner != want {
+			t.Errorf("elected miner %d mismatch: have %x, want %x", i, miner, want)
+		}
+	}
+	for _, miner := range miners[:2] {
+		if IsElected(statedb, miner) {
+			t.Errorf("miner %x elected without enough stake", miner)
+		}
+	}
+	if entry := ReadCandidate(statedb, miners[2]); !entry.Elected {
+		t.Errorf("elected miner not reported as such")
+	}
+	// Miners leaving the miner list are not elected any more
+	statedb.SetState(common.HexToAddress(minerlist.MinerListContract), minerlist.MinerKey(miners[len(miners)-1]), common.Hash{})
+	ApplyEpoch(statedb)
+
+	if IsElected(statedb, miners[len(miners)-1]) {
+		t.Errorf("unregistered miner elected")
+	}
+	if !IsElected(statedb, miners[1]) {
+		t.Errorf("runner-up not elected after a miner left")
+	}
+}
@@ -21,7 +21,6 @@ import (
 	"fmt"
 	"math/big"
 	"github.com/usechain/go-usechain/common"
-	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/crypto/sha3"
 )
 
@@ -29,6 +28,12 @@ const (
 	MinerListContract = "0xfffffffffffffffffffffffffffffffff0000002"
 )
 
+// StateReader is the state access needed to read the miner list, satisfied by
+// both the state database and the EVM view of it.
+type StateReader interface {
+	GetState(common.Address, common.Hash) common.Hash
+}
+
 func decodeHex(s string) []byte {
 	b, err := hex.DecodeString(s)
 	if err != nil {
@@ -50,7 +55,7 @@ func formatData64bytes(_data string) string{
 	return _data
 }
 
-func ReadMinerNum(statedb *state.StateDB) *big.Int {
+func ReadMinerNum(statedb StateReader) *big.Int {
 	paramIndex := "0000000000000000000000000000000000000000000000000000000000000000"
 
 	// get data from the contract statedb
@@ -59,7 +64,9 @@ func ReadMinerNum(statedb *state.StateDB) *big.Int {
 }
 
 
-func IsMiner(statedb *state.StateDB, miner common.Address) bool {
+// MinerKey returns the storage slot of the miner list contract holding the
+// registration flag of the given miner.
+func MinerKey(miner common.Address) common.Hash {
 	key := formatData64bytes(miner.Hex()[2:])
 	paramIndex := "0000000000000000000000000000000000000000000000000000000000000001"
 
@@ -70,8 +77,12 @@ func IsMiner(statedb *state.StateDB, miner common.Address) bool {
 	hash.Write(decodeHex(web3key))
 	keyIndex = hash.Sum(keyIndex)
 
+	return common.BytesToHash(keyIndex)
+}
+
+func IsMiner(statedb StateReader, miner common.Address) bool {
 	// get data from the contract statedb
-	res := statedb.GetState(common.HexToAddress(MinerListContract), common.HexToHash(hex.EncodeToString(keyIndex)))
+	res := statedb.GetState(common.HexToAddress(MinerListContract), MinerKey(miner))
 
 	return res.Big().Cmp(big.NewInt(1)) == 0
 }
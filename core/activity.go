@@ -0,0 +1,58 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+)
+
+// CreateActivityBloom creates a bloom filter over all the accounts touched by a
+// block: the ones modified while processing it (senders, recipients, internal
+// transfers, the coinbase), the transaction recipients and the log emitters.
+// The state must be the one resulting from the block, not yet committed.
+func CreateActivityBloom(block *types.Block, receipts types.Receipts, statedb *state.StateDB) types.Bloom {
+	var bloom types.Bloom
+
+	add := func(addr common.Address) {
+		bloom.Add(new(big.Int).SetBytes(addr.Bytes()))
+	}
+	for _, addr := range statedb.DirtyAddresses() {
+		add(addr)
+	}
+	add(block.Coinbase())
+	for _, tx := range block.Transactions() {
+		if to := tx.To(); to != nil {
+			add(*to)
+		}
+	}
+	for _, receipt := range receipts {
+		for _, log := range receipt.Logs {
+			add(log.Address)
+		}
+	}
+	return bloom
+}
+
+// ActivityBloomMatch checks whether an account may have been touched by a block
+// with the given activity bloom.
+func ActivityBloomMatch(bloom types.Bloom, addr common.Address) bool {
+	return bloom.TestBytes(addr.Bytes())
+}
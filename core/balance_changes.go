@@ -0,0 +1,148 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+)
+
+// BalanceChangeReason tells why the balance of an account changed.
+type BalanceChangeReason uint8
+
+const (
+	BalanceChangeTx       BalanceChangeReason = iota // Value transferred by a transaction
+	BalanceChangeFee                                 // Gas fee paid by a sender or collected by the miner
+	BalanceChangeReward                              // Block or uncle reward credited at finalisation
+	BalanceChangeInternal                            // Anything else, e.g. value moved by contract calls
+)
+
+var balanceChangeReasons = [...]string{
+	BalanceChangeTx:       "tx",
+	BalanceChangeFee:      "fee",
+	BalanceChangeReward:   "reward",
+	BalanceChangeInternal: "internal",
+}
+
+func (r BalanceChangeReason) String() string {
+	if int(r) < len(balanceChangeReasons) {
+		return balanceChangeReasons[r]
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(r))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (r BalanceChangeReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// BalanceChange is a single attributed change to the balance of an account.
+type BalanceChange struct {
+	Address common.Address
+	TxHash  common.Hash // Transaction causing the change, zero for block rewards
+	Reason  BalanceChangeReason
+	Amount  *big.Int // Signed amount, negative for debits
+}
+
+// balanceChangeRLP is the storage encoding of a balance change, RLP being unable
+// to encode negative numbers.
+type balanceChangeRLP struct {
+	Address common.Address
+	TxHash  common.Hash
+	Reason  BalanceChangeReason
+	Debit   bool
+	Amount  *big.Int
+}
+
+// CreateBalanceChanges attributes the net balance changes recorded while
+// processing a block to their reasons. The parts of a transaction's changes
+// explained by its value transfer and gas fee are reported as such, whatever
+// remains as internal transfers. Changes done outside of any transaction are
+// block rewards.
+func CreateBalanceChanges(signer types.Signer, block *types.Block, receipts types.Receipts, diffs []state.BalanceDiff) []*BalanceChange {
+	byTx := make(map[common.Hash][]state.BalanceDiff)
+	for _, diff := range diffs {
+		byTx[diff.TxHash] = append(byTx[diff.TxHash], diff)
+	}
+	var changes []*BalanceChange
+	for i, tx := range block.Transactions() {
+		var (
+			hash  = tx.Hash()
+			order []common.Address
+			delta = make(map[common.Address]*big.Int)
+			parts = make(map[common.Address][]*BalanceChange)
+		)
+		add := func(addr common.Address, reason BalanceChangeReason, amount *big.Int) {
+			if _, ok := delta[addr]; !ok {
+				delta[addr] = new(big.Int)
+				order = append(order, addr)
+			}
+			for _, part := range parts[addr] {
+				if part.Reason == reason {
+					part.Amount.Add(part.Amount, amount)
+					return
+				}
+			}
+			parts[addr] = append(parts[addr], &BalanceChange{Address: addr, TxHash: hash, Reason: reason, Amount: new(big.Int).Set(amount)})
+		}
+		for _, diff := range byTx[hash] {
+			if _, ok := delta[diff.Address]; !ok {
+				delta[diff.Address] = new(big.Int)
+				order = append(order, diff.Address)
+			}
+			delta[diff.Address].Add(delta[diff.Address], new(big.Int).Sub(diff.New, diff.Prev))
+		}
+		// Explain what the transaction itself accounts for
+		if from, err := types.Sender(signer, tx); err == nil && i < len(receipts) {
+			receipt := receipts[i]
+
+			fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), tx.GasPrice())
+			add(from, BalanceChangeFee, new(big.Int).Neg(fee))
+			add(block.Coinbase(), BalanceChangeFee, fee)
+
+			if receipt.Status == types.ReceiptStatusSuccessful && tx.Value().Sign() > 0 {
+				to := receipt.ContractAddress
+				if tx.To() != nil {
+					to = *tx.To()
+				}
+				add(from, BalanceChangeTx, new(big.Int).Neg(tx.Value()))
+				add(to, BalanceChangeTx, tx.Value())
+			}
+		}
+		// Report the explained parts, leaving the rest as internal transfers
+		for _, addr := range order {
+			rest := new(big.Int).Set(delta[addr])
+			for _, part := range parts[addr] {
+				if part.Amount.Sign() != 0 {
+					changes = append(changes, part)
+					rest.Sub(rest, part.Amount)
+				}
+			}
+			if rest.Sign() != 0 {
+				changes = append(changes, &BalanceChange{Address: addr, TxHash: hash, Reason: BalanceChangeInternal, Amount: rest})
+			}
+		}
+	}
+	for _, diff := range byTx[common.Hash{}] {
+		changes = append(changes, &BalanceChange{Address: diff.Address, Reason: BalanceChangeReward, Amount: new(big.Int).Sub(diff.New, diff.Prev)})
+	}
+	return changes
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// Tests that the balance changes of imported blocks are indexed and attributed
+// to value transfers, fees, rewards and internal transfers.
+func TestBalanceChanges(t *testing.T) {
+	var (
+		db, _     = ethdb.NewMemDatabase()
+		key, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender    = crypto.PubkeyToAddress(key.PublicKey)
+		recipient = common.HexToAddress("0xaaaa")
+		forwarder = common.HexToAddress("0xbbbb")
+		payee     = common.HexToAddress("0xcccc")
+		miner     = common.HexToAddress("0xdddd")
+		price     = big.NewInt(2)
+	)
+	// The forwarder passes the received value on to the payee:
+	// CALL(GAS, payee, CALLVALUE, 0, 0, 0, 0)
+	code := append([]byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x34, 0x73}, payee.Bytes()...)
+	code = append(code, 0x5a, 0xf1, 0x00)
+
+	gspec := DefaultRPOWTestingGenesisBlock()
+	gspec.Alloc = GenesisAlloc{
+		sender:    {Balance: big.NewInt(1000000000)},
+		forwarder: {Balance: new(big.Int), Code: code},
+	}
+	genesis := gspec.MustCommit(db)
+	signer := types.NewEIP155Signer(gspec.Config.ChainId)
+	engine := ethash.NewFakerUsechain(db)
+
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	defer blockchain.Stop()
+	blockchain.SetBalanceIndex(true)
+
+	var txs []*types.Transaction
+	chain, receipts := GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(miner)
+
+		tx1, _ := types.SignTx(types.NewTransaction(0, recipient, big.NewInt(1000), 21000, price, nil), signer, key)
+		tx2, _ := types.SignTx(types.NewTransaction(1, forwarder, big.NewInt(500), 100000, price, nil), signer, key)
+		gen.AddTx(tx1)
+		gen.AddTx(tx2)
+		txs = append(txs, tx1, tx2)
+	})
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	block := chain[0]
+	changes, ok := GetBalanceChanges(db, block.Hash(), block.NumberU64())
+	if !ok {
+		t.Fatalf("balance changes not indexed")
+	}
+	fee1 := new(big.Int).Mul(new(big.Int).SetUint64(receipts[0][0].GasUsed), price)
+	fee2 := new(big.Int).Mul(new(big.Int).SetUint64(receipts[0][1].GasUsed), price)
+
+	want := []*BalanceChange{
+		{Address: sender, TxHash: txs[0].Hash(), Reason: BalanceChangeFee, Amount: new(big.Int).Neg(fee1)},
+		{Address: sender, TxHash: txs[0].Hash(), Reason: BalanceChangeTx, Amount: big.NewInt(-1000)},
+		{Address: recipient, TxHash: txs[0].Hash(), Reason: BalanceChangeTx, Amount: big.NewInt(1000)},
+		{Address: miner, TxHash: txs[0].Hash(), Reason: BalanceChangeFee, Amount: fee1},
+		{Address: sender, TxHash: txs[1].Hash(), Reason: BalanceChangeFee, Amount: new(big.Int).Neg(fee2)},
+		{Address: sender, TxHash: txs[1].Hash(), Reason: BalanceChangeTx, Amount: big.NewInt(-500)},
+		{Address: payee, TxHash: txs[1].Hash(), Reason: BalanceChangeInternal, Amount: big.NewInt(500)},
+		{Address: miner, TxHash: txs[1].Hash(), Reason: BalanceChangeFee, Amount: fee2},
+		{Address: forwarder, TxHash: txs[1].Hash(), Reason: BalanceChangeTx, Amount: big.NewInt(500)},
+		{Address: forwarder, TxHash: txs[1].Hash(), Reason: BalanceChangeInternal, Amount: big.NewInt(-500)},
+	}
+	// Whatever the engine rewards follows the transactions
+	var rewards []*BalanceChange
+	for i, change := range changes {
+		if change.Reason == BalanceChangeReward {
+			rewards = changes[i:]
+			changes = changes[:i]
+			break
+		}
+	}
+	if len(changes) != len(want) {
+		for _, change := range changes {
+			t.Logf("%x %x %v %v", change.Address, change.TxHash[:4], change.Reason, change.Amount)
+		}
+		t.Fatalf("change count mismatch: have %d, want %d", len(changes), len(want))
+	}
+	for i := range want {
+		have := changes[i]
+		if have.Address != want[i].Address || have.TxHash != want[i].TxHash || have.Reason != want[i].Reason || have.Amount.Cmp(want[i].Amount) != 0 {
+			t.Errorf("change %d mismatch: have %x %v %v, want %x %v %v", i, have.Address, have.Reason, have.Amount, want[i].Address, want[i].Reason, want[i].Amount)
+		}
+	}
+	for _, reward := range rewards {
+		if reward.Reason != BalanceChangeReward || reward.TxHash != (common.Hash{}) || reward.Amount.Sign() <= 0 {
+			t.Errorf("invalid reward change: %x %v %v", reward.Address, reward.Reason, reward.Amount)
+		}
+	}
+}
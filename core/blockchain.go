@@ -61,6 +61,14 @@ const (
 
 	// BlockChainVersion ensures that an incompatible database forces a resync from scratch.
 	BlockChainVersion = 3
+
+	// DefaultSafeDepth is the number of confirmations after which a block is
+	// reported under the "safe" tag.
+	DefaultSafeDepth = 6
+
+	// DefaultFinalityDepth is the number of confirmations after which a block
+	// is reported under the "finalized" tag.
+	DefaultFinalityDepth = 30
 )
 
 // CacheConfig contains the configuration values for the trie caching/pruning
@@ -98,6 +106,8 @@ type BlockChain struct {
 	chainFeed     event.Feed
 	chainSideFeed event.Feed
 	chainHeadFeed event.Feed
+	finalizedFeed event.Feed
+	haltFeed      event.Feed
 	logsFeed      event.Feed
 	scope         event.SubscriptionScope
 	genesisBlock  *types.Block
@@ -110,7 +120,23 @@ type BlockChain struct {
 	currentBlock     atomic.Value // Current head of the block chain
 	currentFastBlock atomic.Value // Current head of the fast-sync chain (may be above the block chain!)
 
+	safeDepth     uint64       // Confirmations needed for the "safe" tag (atomic access)
+	finalityDepth uint64       // Confirmations needed for the "finalized" tag (atomic access)
+	activityBloom uint32       // Whether to index the accounts touched by blocks (atomic access)
+	balanceIndex  uint32       // Whether to index the balance changes of blocks (atomic access)
+	privateState  uint32       // Whether to execute private transactions on the private state (atomic access)
+	historyRetain uint64       // Number of recent blocks whose bodies and receipts are kept, 0 = all (atomic access)
+	historyTail   uint64       // Oldest block whose body and receipts are still stored (atomic access)
+	finalmu       sync.Mutex   // Lock protecting the last announced finalized block
+	lastFinalized *types.Block // Last finalized block announced on the finalized feed
+
+	breakermu  sync.Mutex          // Lock protecting the circuit breaker fields
+	invariants *InvariantConfig    // Invariants checked on every written block (nil = disabled)
+	halted     *InvariantViolation // Violation which tripped the circuit breaker, if any
+
 	stateCache   state.Database // State database to reuse between imports (contains state cache)
+	privateDb    ethdb.Database // Separate database of the private state and payloads, never shared with peers
+	privateCache state.Database // Private state database on top of privateDb, kept apart from the consensus state
 	bodyCache    *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
 	blockCache   *lru.Cache     // Cache for the most recent entire blocks
@@ -147,23 +173,31 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	badBlocks, _ := lru.New(badBlockLimit)
 
 	bc := &BlockChain{
-		chainConfig:  chainConfig,
-		cacheConfig:  cacheConfig,
-		db:           db,
-		triegc:       prque.New(),
-		stateCache:   state.NewDatabase(db),
-		quit:         make(chan struct{}),
-		bodyCache:    bodyCache,
-		bodyRLPCache: bodyRLPCache,
-		blockCache:   blockCache,
-		futureBlocks: futureBlocks,
-		engine:       engine,
-		vmConfig:     vmConfig,
-		badBlocks:    badBlocks,
+		chainConfig:   chainConfig,
+		cacheConfig:   cacheConfig,
+		db:            db,
+		triegc:        prque.New(),
+		stateCache:    state.NewDatabase(db),
+		safeDepth:     DefaultSafeDepth,
+		finalityDepth: DefaultFinalityDepth,
+		quit:          make(chan struct{}),
+		bodyCache:     bodyCache,
+		bodyRLPCache:  bodyRLPCache,
+		blockCache:    blockCache,
+		futureBlocks:  futureBlocks,
+		engine:        engine,
+		vmConfig:      vmConfig,
+		badBlocks:     badBlocks,
 	}
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
 
+	if err := recoverImportJournal(db); err != nil {
+		return nil, err
+	}
+	if err := recoverStateFlush(db, bc.stateCache); err != nil {
+		return nil, err
+	}
 	var err error
 	bc.hc, err = NewHeaderChain(db, chainConfig, engine, bc.getProcInterrupt)
 	if err != nil {
@@ -176,6 +210,7 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 	if err := bc.loadLastState(); err != nil {
 		return nil, err
 	}
+	bc.historyTail = GetHistoryTail(db)
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for hash := range BadHashes {
 		if header := bc.GetHeaderByHash(hash); header != nil {
@@ -262,11 +297,21 @@ func (bc *BlockChain) loadLastState() error {
 // though, the head may be further rewound if block bodies are missing (non-archive
 // nodes after a fast sync).
 func (bc *BlockChain) SetHead(head uint64) error {
-	log.Warn("Rewinding blockchain", "target", head)
-
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
 
+	// Blocks below the history tail have no bodies left to rewind onto, only a
+	// full reset to genesis is possible there
+	if tail := bc.HistoryTail(); head > 0 && head < tail {
+		return fmt.Errorf("rewind target #%d below pruned history tail #%d", head, tail)
+	}
+	log.Warn("Rewinding blockchain", "target", head)
+
+	if head == 0 && bc.HistoryTail() > 0 {
+		WriteHistoryTail(bc.db, 0)
+		atomic.StoreUint64(&bc.historyTail, 0)
+	}
+
 	// Rewind the header chain, deleting all block bodies until then
 	delFn := func(hash common.Hash, num uint64) {
 		DeleteBody(bc.db, hash, num)
@@ -328,6 +373,10 @@ func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
 	bc.currentBlock.Store(block)
 	bc.mu.Unlock()
 
+	if err := writeStateFlush(bc.db, block.Hash(), block.NumberU64()); err != nil {
+		return err
+	}
+
 	log.Info("Committed new head block", "number", block.Number(), "hash", hash)
 	return nil
 }
@@ -349,6 +398,100 @@ func (bc *BlockChain) CurrentFastBlock() *types.Block {
 	return bc.currentFastBlock.Load().(*types.Block)
 }
 
+// FinalityMarker returns the number of the block buried depth confirmations
+// below the given head, clamping to the genesis block on short chains.
+func FinalityMarker(head, depth uint64) uint64 {
+	if head < depth {
+		return 0
+	}
+	return head - depth
+}
+
+// SetFinalityDepth sets the number of confirmations after which canonical
+// blocks are reported under the "safe" and "finalized" tags.
+func (bc *BlockChain) SetFinalityDepth(safe, final uint64) {
+	atomic.StoreUint64(&bc.safeDepth, safe)
+	atomic.StoreUint64(&bc.finalityDepth, final)
+}
+
+// SetActivityBloom toggles indexing a bloom filter over the accounts touched by
+// every block written with its state.
+func (bc *BlockChain) SetActivityBloom(enabled bool) {
+	var flag uint32
+	if enabled {
+		flag = 1
+	}
+	atomic.StoreUint32(&bc.activityBloom, flag)
+}
+
+// SetBalanceIndex toggles indexing the attributed balance changes of every block
+// written with its state.
+func (bc *BlockChain) SetBalanceIndex(enabled bool) {
+	var flag uint32
+	if enabled {
+		flag = 1
+	}
+	atomic.StoreUint32(&bc.balanceIndex, flag)
+}
+
+// NeedsBalanceDiffs reports whether the states of the blocks written into the
+// chain must record their balance changes, either to be indexed or to check the
+// invariants on.
+func (bc *BlockChain) NeedsBalanceDiffs() bool {
+	if atomic.LoadUint32(&bc.balanceIndex) == 1 {
+		return true
+	}
+	bc.breakermu.Lock()
+	defer bc.breakermu.Unlock()
+
+	return bc.invariants != nil
+}
+
+// SetPrivateState enables executing the private transactions of every block
+// written with its state on a private state kept in the given database, apart
+// from the chain database so it is never exported or served to peers. A nil
+// database disables the private state.
+func (bc *BlockChain) SetPrivateState(db ethdb.Database) {
+	if db == nil {
+		atomic.StoreUint32(&bc.privateState, 0)
+		return
+	}
+	bc.privateDb, bc.privateCache = db, state.NewDatabase(db)
+	atomic.StoreUint32(&bc.privateState, 1)
+}
+
+// CurrentSafeBlock retrieves the most recent canonical block that is buried
+// under at least the configured safe depth of confirmations.
+func (bc *BlockChain) CurrentSafeBlock() *types.Block {
+	head := bc.CurrentBlock().NumberU64()
+	return bc.GetBlockByNumber(FinalityMarker(head, atomic.LoadUint64(&bc.safeDepth)))
+}
+
+// CurrentFinalizedBlock retrieves the most recent canonical block that is
+// buried under at least the configured finality depth of confirmations.
+func (bc *BlockChain) CurrentFinalizedBlock() *types.Block {
+	head := bc.CurrentBlock().NumberU64()
+	return bc.GetBlockByNumber(FinalityMarker(head, atomic.LoadUint64(&bc.finalityDepth)))
+}
+
+// postFinalizedHead announces the finalized block implied by a new chain head,
+// provided it advanced past the previously announced one.
+func (bc *BlockChain) postFinalizedHead(head *types.Block) {
+	bc.finalmu.Lock()
+	defer bc.finalmu.Unlock()
+
+	number := FinalityMarker(head.NumberU64(), atomic.LoadUint64(&bc.finalityDepth))
+	if bc.lastFinalized != nil && number <= bc.lastFinalized.NumberU64() {
+		return
+	}
+	block := bc.GetBlockByNumber(number)
+	if block == nil {
+		return
+	}
+	bc.lastFinalized = block
+	bc.finalizedFeed.Send(FinalizedHeadEvent{Block: block})
+}
+
 // SetProcessor sets the processor required for making state modifications.
 func (bc *BlockChain) SetProcessor(processor Processor) {
 	bc.procmu.Lock()
@@ -433,7 +576,11 @@ func (bc *BlockChain) repair(head **types.Block) error {
 			return nil
 		}
 		// Otherwise rewind one block and recheck state availability there
-		(*head) = bc.GetBlock((*head).ParentHash(), (*head).NumberU64()-1)
+		parent := bc.GetBlock((*head).ParentHash(), (*head).NumberU64()-1)
+		if parent == nil {
+			return fmt.Errorf("no state to repair onto above pruned history tail #%d", (*head).NumberU64())
+		}
+		(*head) = parent
 	}
 }
 
@@ -660,6 +807,11 @@ func (bc *BlockChain) Stop() {
 				}
 			}
 		}
+		if head := bc.CurrentBlock(); head.NumberU64() > 0 {
+			if err := writeStateFlush(bc.db, head.Hash(), head.NumberU64()); err != nil {
+				log.Error("Failed to store state flush marker", "err", err)
+			}
+		}
 		for !bc.triegc.Empty() {
 			triedb.Dereference(bc.triegc.PopItem().(common.Hash), common.Hash{})
 		}
@@ -882,6 +1034,11 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	localTd := bc.GetTd(currentBlock.Hash(), currentBlock.NumberU64())
 	externTd := new(big.Int).Add(block.Difficulty(), ptd)
 
+	// Journal the import so a crash mid-commit can be recovered at startup
+	entry := &importEntry{Hash: block.Hash(), Number: block.NumberU64(), ParentHash: block.ParentHash(), PrevHead: currentBlock.Hash(), Stage: importWriting}
+	if err := writeImportJournal(bc.db, entry); err != nil {
+		return NonStatTy, err
+	}
 	// Irrelevant of the canonical status, write the block itself to the database
 	if err := bc.hc.WriteTd(block.Hash(), block.NumberU64(), externTd); err != nil {
 		return NonStatTy, err
@@ -891,6 +1048,26 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	if err := WriteBlock(batch, block); err != nil {
 		return NonStatTy, err
 	}
+	if atomic.LoadUint32(&bc.activityBloom) == 1 {
+		if err := WriteActivityBloom(batch, block.Hash(), block.NumberU64(), CreateActivityBloom(block, receipts, state)); err != nil {
+			return NonStatTy, err
+		}
+	}
+	if atomic.LoadUint32(&bc.balanceIndex) == 1 {
+		changes := CreateBalanceChanges(types.MakeSigner(bc.chainConfig, block.Number()), block, receipts, state.BalanceDiffs())
+		if err := WriteBalanceChanges(batch, block.Hash(), block.NumberU64(), changes); err != nil {
+			return NonStatTy, err
+		}
+	}
+	if atomic.LoadUint32(&bc.privateState) == 1 {
+		if err := bc.writePrivateState(block, receipts); err != nil {
+			return NonStatTy, err
+		}
+	}
+	// Check the invariants before committing, but only act on them if the block
+	// becomes canonical, side chains must not halt a healthy chain
+	violation := bc.checkBreaker(block, state)
+
 	root, err := state.Commit(bc.chainConfig.IsEIP158(block.Number()))
 	if err != nil {
 		return NonStatTy, err
@@ -931,7 +1108,12 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				}
 				// If optimum or critical limits reached, write to disk
 				if chosen >= lastWrite+triesInMemory || size >= 2*limit || bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
-					triedb.Commit(header.Root, true)
+					if err := triedb.Commit(header.Root, true); err != nil {
+						return NonStatTy, err
+					}
+					if err := writeStateFlush(bc.db, header.Hash(), chosen); err != nil {
+						return NonStatTy, err
+					}
 					lastWrite = chosen
 					bc.gcproc = 0
 				}
@@ -950,6 +1132,9 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	if err := WriteBlockReceipts(batch, block.Hash(), block.NumberU64(), receipts); err != nil {
 		return NonStatTy, err
 	}
+	if err := WriteTxCategories(batch, receipts); err != nil {
+		return NonStatTy, err
+	}
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
@@ -974,6 +1159,12 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		if err := WritePreimages(bc.db, block.NumberU64(), state.Preimages()); err != nil {
 			return NonStatTy, err
 		}
+		// Archive nodes flushed the state above, mark it complete with the block
+		if bc.cacheConfig.Disabled {
+			if err := writeStateFlush(batch, block.Hash(), block.NumberU64()); err != nil {
+				return NonStatTy, err
+			}
+		}
 		status = CanonStatTy
 	} else {
 		status = SideStatTy
@@ -984,8 +1175,18 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 
 	// Set new head.
 	if status == CanonStatTy {
+		if violation != nil {
+			bc.tripBreaker(violation)
+		}
+		entry.Stage = importPromoting
+		if err := writeImportJournal(bc.db, entry); err != nil {
+			return NonStatTy, err
+		}
 		bc.insert(block)
 	}
+	if err := deleteImportJournal(bc.db); err != nil {
+		return NonStatTy, err
+	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
 }
@@ -997,7 +1198,16 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 //
 // After insertion is done, all accumulated events will be fired.
 func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
-	n, events, logs, err := bc.insertChain(chain)
+	n, events, logs, err := bc.insertChain(chain, true)
+	bc.PostChainEvents(events, logs)
+	return n, err
+}
+
+// InsertChainTrusted is like InsertChain, but skips verifying the consensus seals
+// of the blocks. It is meant for importing blocks streamed from a trusted source
+// which already verified them; headers and state transitions are still checked.
+func (bc *BlockChain) InsertChainTrusted(chain types.Blocks) (int, error) {
+	n, events, logs, err := bc.insertChain(chain, false)
 	bc.PostChainEvents(events, logs)
 	return n, err
 }
@@ -1005,7 +1215,7 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (int, error) {
 // insertChain will execute the actual chain insertion and event aggregation. The
 // only reason this method exists as a separate one is to make locking cleaner
 // with deferred statements.
-func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*types.Log, error) {
+func (bc *BlockChain) insertChain(chain types.Blocks, verifySeals bool) (int, []interface{}, []*types.Log, error) {
 	// Do a sanity check that the provided chain is actually ordered and linked
 	for i := 1; i < len(chain); i++ {
 		if chain[i].NumberU64() != chain[i-1].NumberU64()+1 || chain[i].ParentHash() != chain[i-1].Hash() {
@@ -1039,7 +1249,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 
 	for i, block := range chain {
 		headers[i] = block.Header()
-		seals[i] = true
+		seals[i] = verifySeals
 	}
 	abort, results := bc.engine.VerifyHeaders(bc, headers, seals)
 	defer close(abort)
@@ -1113,7 +1323,7 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 			}
 			// Import all the pruned blocks to make the state available
 			bc.chainmu.Unlock()
-			_, evs, logs, err := bc.insertChain(winner)
+			_, evs, logs, err := bc.insertChain(winner, verifySeals)
 			bc.chainmu.Lock()
 			events, coalescedLogs = evs, logs
 
@@ -1137,6 +1347,9 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		if err != nil {
 			return i, events, coalescedLogs, err
 		}
+		if bc.NeedsBalanceDiffs() {
+			state.RecordBalanceDiffs()
+		}
 		// Process block using the parent state as reference point.
 		receipts, logs, usedGas, err := bc.processor.Process(block, state, bc.vmConfig)
 		if err != nil {
@@ -1362,6 +1575,7 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []*types.Log) {
 
 		case ChainHeadEvent:
 			bc.chainHeadFeed.Send(ev)
+			bc.postFinalizedHead(ev.Block)
 
 		case ChainSideEvent:
 			bc.chainSideFeed.Send(ev)
@@ -1372,10 +1586,16 @@ func (bc *BlockChain) PostChainEvents(events []interface{}, logs []*types.Log) {
 func (bc *BlockChain) update() {
 	futureTimer := time.NewTicker(5 * time.Second)
 	defer futureTimer.Stop()
+
+	historyTimer := time.NewTicker(historyPruneInterval)
+	defer historyTimer.Stop()
+
 	for {
 		select {
 		case <-futureTimer.C:
 			bc.procFutureBlocks()
+		case <-historyTimer.C:
+			bc.pruneHistory()
 		case <-bc.quit:
 			return
 		}
@@ -1548,6 +1768,11 @@ func (bc *BlockChain) SubscribeChainHeadEvent(ch chan<- ChainHeadEvent) event.Su
 	return bc.scope.Track(bc.chainHeadFeed.Subscribe(ch))
 }
 
+// SubscribeFinalizedHeadEvent registers a subscription of FinalizedHeadEvent.
+func (bc *BlockChain) SubscribeFinalizedHeadEvent(ch chan<- FinalizedHeadEvent) event.Subscription {
+	return bc.scope.Track(bc.finalizedFeed.Subscribe(ch))
+}
+
 // SubscribeChainSideEvent registers a subscription of ChainSideEvent.
 func (bc *BlockChain) SubscribeChainSideEvent(ch chan<- ChainSideEvent) event.Subscription {
 	return bc.scope.Track(bc.chainSideFeed.Subscribe(ch))
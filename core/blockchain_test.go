@@ -1383,3 +1383,118 @@ func TestLargeReorgTrieGC(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the safe and finalized markers trail the chain head by the
+// configured depths and that finalized heads are announced as they advance.
+func TestFinalityMarkers(t *testing.T) {
+	// Generate a canonical chain to act as the main dataset
+	engine := ethash.NewFaker()
+
+	db, _ := ethdb.NewMemDatabase()
+	genesis := new(Genesis).MustCommit(db)
+	blocks, _ := GenerateChain(params.TestChainConfig, genesis, engine, db, 10, func(i int, b *BlockGen) {})
+
+	diskdb, _ := ethdb.NewMemDatabase()
+	new(Genesis).MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, nil, params.TestChainConfig, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+	chain.SetFinalityDepth(2, 4)
+
+	finalized := make(chan FinalizedHeadEvent, 16)
+	sub := chain.SubscribeFinalizedHeadEvent(finalized)
+	defer sub.Unsubscribe()
+
+	// Short chains should clamp both markers to the genesis block
+	if _, err := chain.InsertChain(blocks[:2]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if n := chain.CurrentFinalizedBlock().NumberU64(); n != 0 {
+		t.Errorf("finalized block mismatch: have %d, want %d", n, 0)
+	}
+	// Longer chains should have the markers trail the head
+	if _, err := chain.InsertChain(blocks[2:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if n := chain.CurrentSafeBlock().NumberU64(); n != 8 {
+		t.Errorf("safe block mismatch: have %d, want %d", n, 8)
+	}
+	if n := chain.CurrentFinalizedBlock().NumberU64(); n != 6 {
+		t.Errorf("finalized block mismatch: have %d, want %d", n, 6)
+	}
+	// Each head event should have announced an advancing finalized block
+	var last uint64
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-finalized:
+			if i > 0 && ev.Block.NumberU64() <= last {
+				t.Errorf("event %d: finalized block did not advance: %d after %d", i, ev.Block.NumberU64(), last)
+			}
+			last = ev.Block.NumberU64()
+		case <-time.After(time.Second):
+			t.Fatalf("event %d: timeout waiting for finalized head", i)
+		}
+	}
+	if last != 6 {
+		t.Errorf("last finalized announcement mismatch: have %d, want %d", last, 6)
+	}
+}
+
+// Tests that blocks imported with activity indexing enabled store a bloom
+// matching the accounts they touched, and that none is stored otherwise.
+func TestActivityBloom(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		receiver = common.Address{0x01, 0x02}
+		coinbase = common.Address{0xc0}
+		idle     = common.Address{0xff, 0xee}
+		db, _    = ethdb.NewMemDatabase()
+		gspec    = &Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  GenesisAlloc{sender: {Balance: big.NewInt(1000000)}},
+		}
+		genesis = gspec.MustCommit(db)
+		signer  = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(coinbase)
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(sender), receiver, big.NewInt(1000), params.TxGas, nil, nil), signer, key)
+		gen.AddTx(tx)
+	})
+	diskdb, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(diskdb)
+
+	chain, err := NewBlockChain(diskdb, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create tester chain: %v", err)
+	}
+	defer chain.Stop()
+
+	// Import the first block without indexing, the second one with it
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.SetActivityBloom(true)
+	if _, err := chain.InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if _, ok := GetActivityBloom(diskdb, blocks[0].Hash(), 1); ok {
+		t.Errorf("activity bloom stored with indexing disabled")
+	}
+	bloom, ok := GetActivityBloom(diskdb, blocks[1].Hash(), 2)
+	if !ok {
+		t.Fatalf("activity bloom missing with indexing enabled")
+	}
+	for _, addr := range []common.Address{sender, receiver, coinbase} {
+		if !ActivityBloomMatch(bloom, addr) {
+			t.Errorf("touched account %x not matched", addr)
+		}
+	}
+	if ActivityBloomMatch(bloom, idle) {
+		t.Errorf("untouched account %x matched", idle)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/event"
+	"github.com/usechain/go-usechain/log"
+)
+
+// InvariantConfig configures the chain invariants checked on every written
+// block. Supply accounting is always checked, the other invariants only if set.
+type InvariantConfig struct {
+	Enabled      bool
+	BalanceFloor *big.Int `toml:",omitempty"` // Minimum balance of each system contract (nil = unchecked)
+	MinCommittee uint64   // Minimum size of the miner list (0 = unchecked)
+	MaxCommittee uint64   // Maximum size of the miner list (0 = unchecked)
+}
+
+// InvariantViolation describes the invariant broken by a block, which tripped
+// the circuit breaker of the chain.
+type InvariantViolation struct {
+	Number    uint64      `json:"number"`
+	Hash      common.Hash `json:"hash"`
+	Invariant string      `json:"invariant"`
+	Reason    string      `json:"reason"`
+	Time      time.Time   `json:"time"`
+}
+
+func (v *InvariantViolation) Error() string {
+	return fmt.Sprintf("block #%d [%x…] violates %s invariant: %s", v.Number, v.Hash[:4], v.Invariant, v.Reason)
+}
+
+// ChainHaltEvent is posted when an invariant violation trips the circuit breaker.
+type ChainHaltEvent struct{ Violation *InvariantViolation }
+
+// checkInvariants verifies the state resulting from processing a block against
+// the configured invariants, returning the first one violated.
+func checkInvariants(config *InvariantConfig, engine consensus.Engine, block *types.Block, statedb *state.StateDB) *InvariantViolation {
+	violation := func(invariant string, format string, args ...interface{}) *InvariantViolation {
+		return &InvariantViolation{
+			Number:    block.NumberU64(),
+			Hash:      block.Hash(),
+			Invariant: invariant,
+			Reason:    fmt.Sprintf(format, args...),
+			Time:      time.Now(),
+		}
+	}
+	// The block may mint at most the issuance of the consensus engine, anything
+	// above means currency was created out of thin air. Less is fine, as value
+	// can be burnt by self destructs.
+	issuance := new(big.Int)
+	if issuer, ok := engine.(consensus.Issuer); ok {
+		issuance = issuer.Issuance(block.Header(), block.Uncles())
+	}
+	minted := new(big.Int)
+	for _, diff := range statedb.BalanceDiffs() {
+		minted.Add(minted, new(big.Int).Sub(diff.New, diff.Prev))
+	}
+	if minted.Cmp(issuance) > 0 {
+		return violation("supply", "minted %v, issuance only %v", minted, issuance)
+	}
+	// The system contracts must retain their configured balance
+	if config.BalanceFloor != nil {
+		for _, addr := range []common.Address{identityContract, common.HexToAddress(minerlist.MinerListContract)} {
+			if balance := statedb.GetBalance(addr); balance.Cmp(config.BalanceFloor) < 0 {
+				return violation("balance floor", "system contract %x holds %v, floor %v", addr, balance, config.BalanceFloor)
+			}
+		}
+	}
+	// The mining committee must stay within its bounds
+	if config.MinCommittee > 0 || config.MaxCommittee > 0 {
+		size := minerlist.ReadMinerNum(statedb)
+		if config.MinCommittee > 0 && size.Cmp(new(big.Int).SetUint64(config.MinCommittee)) < 0 {
+			return violation("committee size", "%v miners, minimum %d", size, config.MinCommittee)
+		}
+		if config.MaxCommittee > 0 && size.Cmp(new(big.Int).SetUint64(config.MaxCommittee)) > 0 {
+			return violation("committee size", "%v miners, maximum %d", size, config.MaxCommittee)
+		}
+	}
+	return nil
+}
+
+// SetInvariants enables checking the given invariants on every block written
+// with its state, tripping the circuit breaker on the first violation. A nil
+// or disabled config turns the checks off.
+func (bc *BlockChain) SetInvariants(config *InvariantConfig) {
+	bc.breakermu.Lock()
+	defer bc.breakermu.Unlock()
+
+	if config != nil && !config.Enabled {
+		config = nil
+	}
+	bc.invariants = config
+}
+
+// Halted returns the invariant violation which tripped the circuit breaker, or
+// nil if the chain is healthy. Local block production and relay should cease
+// while the breaker is tripped.
+func (bc *BlockChain) Halted() *InvariantViolation {
+	bc.breakermu.Lock()
+	defer bc.breakermu.Unlock()
+
+	return bc.halted
+}
+
+// ResetHalt resets a tripped circuit breaker, resuming block production after
+// the operator investigated the violation. The invariants keep being checked
+// for the blocks to come.
+func (bc *BlockChain) ResetHalt() {
+	bc.breakermu.Lock()
+	defer bc.breakermu.Unlock()
+
+	if bc.halted != nil {
+		log.Warn("Chain circuit breaker reset", "number", bc.halted.Number, "hash", bc.halted.Hash, "invariant", bc.halted.Invariant)
+	}
+	bc.halted = nil
+}
+
+// SubscribeHaltEvent registers a subscription of ChainHaltEvent.
+func (bc *BlockChain) SubscribeHaltEvent(ch chan<- ChainHaltEvent) event.Subscription {
+	return bc.scope.Track(bc.haltFeed.Subscribe(ch))
+}
+
+// checkBreaker checks the invariants on the state of a freshly processed block,
+// returning the violated one, if any. Nothing is checked while the breaker is
+// disabled or already tripped.
+func (bc *BlockChain) checkBreaker(block *types.Block, statedb *state.StateDB) *InvariantViolation {
+	bc.breakermu.Lock()
+	defer bc.breakermu.Unlock()
+
+	if bc.invariants == nil || bc.halted != nil {
+		return nil
+	}
+	return checkInvariants(bc.invariants, bc.engine, block, statedb)
+}
+
+// tripBreaker halts block production and relay on an invariant violation of a
+// canonical block, unless already halted.
+func (bc *BlockChain) tripBreaker(violation *InvariantViolation) {
+	bc.breakermu.Lock()
+	defer bc.breakermu.Unlock()
+
+	if bc.halted != nil {
+		return
+	}
+	bc.halted = violation
+	log.Error("Chain invariant violated, halting block production", "number", violation.Number, "hash", violation.Hash, "invariant", violation.Invariant, "reason", violation.Reason)
+	go bc.haltFeed.Send(ChainHaltEvent{Violation: violation})
+}
@@ -0,0 +1,208 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/params"
+)
+
+// counterfeitEngine is a faulty consensus engine minting currency on top of the
+// block rewards it claims to issue.
+type counterfeitEngine struct {
+	*ethash.Ethash
+}
+
+func (e *counterfeitEngine) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	state.AddBalance(common.HexToAddress("0xbad"), big.NewInt(1))
+	return e.Ethash.Finalize(chain, header, state, txs, uncles, receipts)
+}
+
+// newBreakerChain creates a chain checking the given invariants on top of the
+// genesis, and inserts n generated blocks into it.
+func newBreakerChain(t *testing.T, gspec *Genesis, engine consensus.Engine, config *InvariantConfig, n int, gen func(int, *BlockGen)) *BlockChain {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+
+	blockchain, err := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	blockchain.SetInvariants(config)
+
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, n, gen)
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return blockchain
+}
+
+// Tests that a chain honouring the invariants keeps the circuit breaker closed.
+func TestCircuitBreakerHealthy(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{sender: {Balance: big.NewInt(1000000000)}}}
+		signer = types.NewEIP155Signer(gspec.Config.ChainId)
+	)
+	blockchain := newBreakerChain(t, gspec, ethash.NewFaker(), &InvariantConfig{Enabled: true}, 4, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(sender), common.HexToAddress("0xaaaa"), big.NewInt(1000), 21000, big.NewInt(1), nil), signer, key)
+		gen.AddTx(tx)
+	})
+	defer blockchain.Stop()
+
+	if violation := blockchain.Halted(); violation != nil {
+		t.Fatalf("healthy chain halted: %v", violation)
+	}
+}
+
+// Tests that currency minted beyond the engine's issuance trips the breaker.
+func TestCircuitBreakerSupply(t *testing.T) {
+	gspec := &Genesis{Config: params.TestChainConfig}
+	engine := &counterfeitEngine{ethash.NewFaker()}
+
+	// Without invariants the faulty chain is accepted silently
+	blockchain := newBreakerChain(t, gspec, engine, nil, 2, nil)
+	if violation := blockchain.Halted(); violation != nil {
+		t.Errorf("unchecked chain halted: %v", violation)
+	}
+	blockchain.Stop()
+
+	blockchain = newBreakerChain(t, gspec, engine, &InvariantConfig{Enabled: true}, 2, nil)
+	defer blockchain.Stop()
+
+	violation := blockchain.Halted()
+	if violation == nil {
+		t.Fatalf("counterfeit chain not halted")
+	}
+	if violation.Invariant != "supply" || violation.Number != 1 {
+		t.Errorf("violation mismatch: have %s at #%d, want supply at #1", violation.Invariant, violation.Number)
+	}
+}
+
+// Tests the committee size bounds, the halt notifications and resetting the
+// tripped breaker.
+func TestCircuitBreakerCommittee(t *testing.T) {
+	gspec := DevnetGenesisBlock(1337, []common.Address{{0x01}, {0x02}, {0x03}})
+
+	// Within bounds, nothing should happen
+	blockchain := newBreakerChain(t, gspec, ethash.NewFaker(), &InvariantConfig{Enabled: true, MinCommittee: 3, MaxCommittee: 3}, 1, nil)
+	if violation := blockchain.Halted(); violation != nil {
+		t.Errorf("chain within committee bounds halted: %v", violation)
+	}
+	blockchain.Stop()
+
+	// Out of bounds, the breaker must trip and announce it
+	db, _ := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+	blockchain, _ = NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer blockchain.Stop()
+	blockchain.SetInvariants(&InvariantConfig{Enabled: true, MaxCommittee: 2})
+
+	halts := make(chan ChainHaltEvent, 1)
+	sub := blockchain.SubscribeHaltEvent(halts)
+	defer sub.Unsubscribe()
+
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 2, nil)
+	if _, err := blockchain.InsertChain(blocks[:1]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	select {
+	case ev := <-halts:
+		if ev.Violation.Invariant != "committee size" {
+			t.Errorf("violated invariant mismatch: have %s, want committee size", ev.Violation.Invariant)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("halt event not delivered")
+	}
+	// Resetting resumes, but the next violating block trips the breaker again
+	blockchain.ResetHalt()
+	if violation := blockchain.Halted(); violation != nil {
+		t.Fatalf("breaker still tripped after reset: %v", violation)
+	}
+	if _, err := blockchain.InsertChain(blocks[1:]); err != nil {
+		t.Fatalf("failed to insert block: %v", err)
+	}
+	if violation := blockchain.Halted(); violation == nil || violation.Number != 2 {
+		t.Errorf("breaker not tripped again: %v", violation)
+	}
+}
+
+// selectiveCounterfeitEngine is a faulty consensus engine minting currency only
+// in blocks mined by the forger account.
+type selectiveCounterfeitEngine struct {
+	*ethash.Ethash
+}
+
+var forger = common.HexToAddress("0xbad")
+
+func (e *selectiveCounterfeitEngine) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	if header.Coinbase == forger {
+		state.AddBalance(forger, big.NewInt(1))
+	}
+	return e.Ethash.Finalize(chain, header, state, txs, uncles, receipts)
+}
+
+// Tests that violating blocks on a side chain do not trip the breaker, only
+// once they become canonical.
+func TestCircuitBreakerSideChain(t *testing.T) {
+	gspec := &Genesis{Config: params.TestChainConfig}
+	engine := &selectiveCounterfeitEngine{ethash.NewFaker()}
+
+	db, _ := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+
+	blockchain, err := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer blockchain.Stop()
+	blockchain.SetInvariants(&InvariantConfig{Enabled: true})
+
+	canon, _ := GenerateChain(gspec.Config, genesis, engine, db, 3, nil)
+	if _, err := blockchain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	forged, _ := GenerateChain(gspec.Config, genesis, engine, db, 4, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(forger)
+	})
+	// A shorter forged side chain must leave the breaker closed
+	if _, err := blockchain.InsertChain(forged[:2]); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+	if violation := blockchain.Halted(); violation != nil {
+		t.Fatalf("side chain tripped the breaker: %v", violation)
+	}
+	// Overtaking the canonical chain must trip it
+	if _, err := blockchain.InsertChain(forged[2:]); err != nil {
+		t.Fatalf("failed to insert reorg: %v", err)
+	}
+	if violation := blockchain.Halted(); violation == nil {
+		t.Fatalf("canonical forged chain not halted")
+	}
+}
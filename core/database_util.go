@@ -26,6 +26,7 @@ import (
 
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/log"
 	"github.com/usechain/go-usechain/metrics"
@@ -48,6 +49,9 @@ var (
 	headBlockKey  = []byte("LastBlock")
 	headFastKey   = []byte("LastFast")
 	trieSyncKey   = []byte("TrieSync")
+	importWALKey  = []byte("ImportJournal")
+	stateFlushKey = []byte("LastStateFlush")
+	historyKey    = []byte("HistoryTail")
 
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`).
 	headerPrefix        = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
@@ -58,9 +62,14 @@ var (
 	blockReceiptsPrefix = []byte("r") // blockReceiptsPrefix + num (uint64 big endian) + hash -> block receipts
 	lookupPrefix        = []byte("l") // lookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix     = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
+	txCategoryPrefix    = []byte("C") // txCategoryPrefix + hash -> transaction category
+	activityPrefix      = []byte("a") // activityPrefix + num (uint64 big endian) + hash -> account activity bloom
+	balancePrefix       = []byte("v") // balancePrefix + num (uint64 big endian) + hash -> attributed balance changes
+	privateRootPrefix   = []byte("P") // privateRootPrefix + hash -> private state root (private state database only)
 
-	preimagePrefix = "secure-key-"              // preimagePrefix + hash -> preimage
-	configPrefix   = []byte("ethereum-config-") // config prefix for the db
+	preimagePrefix       = "secure-key-"              // preimagePrefix + hash -> preimage
+	configPrefix         = []byte("ethereum-config-") // config prefix for the db
+	privatePayloadPrefix = []byte("private-payload-") // privatePayloadPrefix + hash -> salted private payload (private state database only)
 
 	// Chain index prefixes (use `i` + single byte to avoid mixing data types).
 	BloomBitsIndexPrefix = []byte("iB") // BloomBitsIndexPrefix is the data table of a chain indexer to track its progress
@@ -157,6 +166,16 @@ func GetTrieSyncProgress(db DatabaseReader) uint64 {
 	return new(big.Int).SetBytes(data).Uint64()
 }
 
+// GetHistoryTail retrieves the number of the oldest block whose body and
+// receipts are still stored, older ones having been pruned.
+func GetHistoryTail(db DatabaseReader) uint64 {
+	data, _ := db.Get(historyKey)
+	if len(data) == 0 {
+		return 0
+	}
+	return new(big.Int).SetBytes(data).Uint64()
+}
+
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
 // if the header's not found.
 func GetHeaderRLP(db DatabaseReader, hash common.Hash, number uint64) rlp.RawValue {
@@ -341,6 +360,68 @@ func GetReceipt(db DatabaseReader, hash common.Hash) (*types.Receipt, common.Has
 	return (*types.Receipt)(&receipt), common.Hash{}, 0, 0
 }
 
+// GetTxCategory retrieves the category assigned to a transaction during its
+// execution, along with whether the transaction was categorised at all.
+func GetTxCategory(db DatabaseReader, hash common.Hash) (types.TxCategory, bool) {
+	data, _ := db.Get(append(txCategoryPrefix, hash.Bytes()...))
+	if len(data) != 1 {
+		return types.TxCategoryUnknown, false
+	}
+	return types.TxCategory(data[0]), true
+}
+
+// GetActivityBloom retrieves the bloom over the accounts touched by a block, and
+// whether it was indexed at all.
+func GetActivityBloom(db DatabaseReader, hash common.Hash, number uint64) (types.Bloom, bool) {
+	data, _ := db.Get(append(append(activityPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+	if len(data) != types.BloomByteLength {
+		return types.Bloom{}, false
+	}
+	return types.BytesToBloom(data), true
+}
+
+// GetBalanceChanges retrieves the attributed balance changes of a block, and
+// whether they were indexed at all.
+func GetBalanceChanges(db DatabaseReader, hash common.Hash, number uint64) ([]*BalanceChange, bool) {
+	data, _ := db.Get(append(append(balancePrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+	if len(data) == 0 {
+		return nil, false
+	}
+	var stored []balanceChangeRLP
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		log.Error("Invalid balance changes RLP", "hash", hash, "err", err)
+		return nil, false
+	}
+	changes := make([]*BalanceChange, len(stored))
+	for i, change := range stored {
+		changes[i] = &BalanceChange{Address: change.Address, TxHash: change.TxHash, Reason: change.Reason, Amount: change.Amount}
+		if change.Debit {
+			changes[i].Amount.Neg(changes[i].Amount)
+		}
+	}
+	return changes, true
+}
+
+// GetPrivateStateRoot retrieves the root of the private state after the given
+// block, or the empty root if the block wasn't executed privately.
+func GetPrivateStateRoot(db DatabaseReader, hash common.Hash) common.Hash {
+	data, _ := db.Get(append(privateRootPrefix, hash.Bytes()...))
+	if len(data) == 0 {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// GetPrivatePayload retrieves the payload of a private transaction by its
+// commitment, or nil if the node isn't a party to it.
+func GetPrivatePayload(db DatabaseReader, commitment common.Hash) []byte {
+	data, _ := db.Get(append(privatePayloadPrefix, commitment.Bytes()...))
+	if len(data) < PrivatePayloadSaltLength {
+		return nil
+	}
+	return data[PrivatePayloadSaltLength:]
+}
+
 // GetBloomBits retrieves the compressed bloom bit vector belonging to the given
 // section and bit index from the.
 func GetBloomBits(db DatabaseReader, bit uint, section uint64, head common.Hash) ([]byte, error) {
@@ -394,6 +475,15 @@ func WriteTrieSyncProgress(db ethdb.Putter, count uint64) error {
 	return nil
 }
 
+// WriteHistoryTail stores the number of the oldest block whose body and
+// receipts are still stored.
+func WriteHistoryTail(db ethdb.Putter, number uint64) error {
+	if err := db.Put(historyKey, new(big.Int).SetUint64(number).Bytes()); err != nil {
+		log.Crit("Failed to store history tail", "err", err)
+	}
+	return nil
+}
+
 // WriteHeader serializes a block header into the database.
 func WriteHeader(db ethdb.Putter, header *types.Header) error {
 	data, err := rlp.EncodeToBytes(header)
@@ -500,6 +590,20 @@ func WriteTxLookupEntries(db ethdb.Putter, block *types.Block) error {
 	return nil
 }
 
+// WriteTxCategories stores the execution time category of every transaction
+// of a block, skipping receipts that were not produced by local execution.
+func WriteTxCategories(db ethdb.Putter, receipts types.Receipts) error {
+	for _, receipt := range receipts {
+		if receipt.Category == types.TxCategoryUnknown {
+			continue
+		}
+		if err := db.Put(append(txCategoryPrefix, receipt.TxHash.Bytes()...), []byte{byte(receipt.Category)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // WriteBloomBits writes the compressed bloom bits vector belonging to the given
 // section and bit index.
 func WriteBloomBits(db ethdb.Putter, bit uint, section uint64, head common.Hash, bits []byte) {
@@ -540,6 +644,78 @@ func DeleteBlock(db DatabaseDeleter, hash common.Hash, number uint64) {
 	DeleteHeader(db, hash, number)
 	DeleteBody(db, hash, number)
 	DeleteTd(db, hash, number)
+	DeleteActivityBloom(db, hash, number)
+	DeleteBalanceChanges(db, hash, number)
+}
+
+// WriteActivityBloom stores the bloom over the accounts touched by a block.
+func WriteActivityBloom(db ethdb.Putter, hash common.Hash, number uint64, bloom types.Bloom) error {
+	key := append(append(activityPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+	if err := db.Put(key, bloom.Bytes()); err != nil {
+		log.Crit("Failed to store activity bloom", "err", err)
+	}
+	return nil
+}
+
+// DeleteActivityBloom removes the account activity bloom of a block.
+func DeleteActivityBloom(db DatabaseDeleter, hash common.Hash, number uint64) {
+	db.Delete(append(append(activityPrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+}
+
+// WriteBalanceChanges stores the attributed balance changes of a block.
+func WriteBalanceChanges(db ethdb.Putter, hash common.Hash, number uint64, changes []*BalanceChange) error {
+	stored := make([]balanceChangeRLP, len(changes))
+	for i, change := range changes {
+		stored[i] = balanceChangeRLP{
+			Address: change.Address,
+			TxHash:  change.TxHash,
+			Reason:  change.Reason,
+			Debit:   change.Amount.Sign() < 0,
+			Amount:  new(big.Int).Abs(change.Amount),
+		}
+	}
+	data, err := rlp.EncodeToBytes(stored)
+	if err != nil {
+		return err
+	}
+	key := append(append(balancePrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+	if err := db.Put(key, data); err != nil {
+		log.Crit("Failed to store balance changes", "err", err)
+	}
+	return nil
+}
+
+// DeleteBalanceChanges removes the attributed balance changes of a block.
+func DeleteBalanceChanges(db DatabaseDeleter, hash common.Hash, number uint64) {
+	db.Delete(append(append(balancePrefix, encodeBlockNumber(number)...), hash.Bytes()...))
+}
+
+// WritePrivateStateRoot stores the root of the private state after a block.
+func WritePrivateStateRoot(db ethdb.Putter, hash common.Hash, root common.Hash) error {
+	if err := db.Put(append(privateRootPrefix, hash.Bytes()...), root.Bytes()); err != nil {
+		log.Crit("Failed to store private state root", "err", err)
+	}
+	return nil
+}
+
+// PrivatePayloadCommitment returns the commitment a salted private payload is
+// referenced by on chain. The random salt keeps short or guessable payloads
+// from being recovered from the commitment.
+func PrivatePayloadCommitment(salt, payload []byte) common.Hash {
+	return crypto.Keccak256Hash(salt, payload)
+}
+
+// WritePrivatePayload stores the payload of a private transaction along with
+// its salt, returning the commitment it is referenced by on chain.
+func WritePrivatePayload(db ethdb.Putter, salt, payload []byte) (common.Hash, error) {
+	if len(salt) != PrivatePayloadSaltLength {
+		return common.Hash{}, errInvalidPrivateSalt
+	}
+	commitment := PrivatePayloadCommitment(salt, payload)
+	if err := db.Put(append(privatePayloadPrefix, commitment.Bytes()...), append(common.CopyBytes(salt), payload...)); err != nil {
+		return common.Hash{}, err
+	}
+	return commitment, nil
 }
 
 // DeleteBlockReceipts removes all receipt data associated with a block hash.
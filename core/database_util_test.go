@@ -386,3 +386,28 @@ func TestBlockReceiptStorage(t *testing.T) {
 		t.Fatalf("deleted receipts returned: %v", rs)
 	}
 }
+
+// Tests activity bloom storage and retrieval operations.
+func TestActivityBloomStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	hash, addr := common.Hash{0x01}, common.Address{0x02}
+	if _, ok := GetActivityBloom(db, hash, 0); ok {
+		t.Fatalf("non existent activity bloom returned")
+	}
+	var bloom types.Bloom
+	bloom.Add(new(big.Int).SetBytes(addr.Bytes()))
+
+	if err := WriteActivityBloom(db, hash, 0, bloom); err != nil {
+		t.Fatalf("failed to write activity bloom into database: %v", err)
+	}
+	if entry, ok := GetActivityBloom(db, hash, 0); !ok {
+		t.Fatalf("stored activity bloom not found")
+	} else if entry != bloom {
+		t.Fatalf("retrieved activity bloom mismatch")
+	}
+	DeleteActivityBloom(db, hash, 0)
+	if _, ok := GetActivityBloom(db, hash, 0); ok {
+		t.Fatalf("deleted activity bloom returned")
+	}
+}
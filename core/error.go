@@ -32,4 +32,8 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// ErrMaxInitCodeSizeExceeded is returned if a contract creation transaction
+	// carries more initcode than the chain configuration allows.
+	ErrMaxInitCodeSizeExceeded = errors.New("max initcode size exceeded")
 )
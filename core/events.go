@@ -24,6 +24,15 @@ import (
 // TxPreEvent is posted when a transaction enters the transaction pool.
 type TxPreEvent struct{ Tx *types.Transaction }
 
+// TxDropEvent is posted when a transaction leaves the transaction pool without
+// being included in the chain.
+type TxDropEvent struct {
+	Tx          *types.Transaction
+	From        common.Address
+	Reason      TxDropReason
+	Replacement common.Hash // Transaction superseding the dropped one (DropReplaced only)
+}
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
@@ -52,3 +61,6 @@ type ChainSideEvent struct {
 }
 
 type ChainHeadEvent struct{ Block *types.Block }
+
+// FinalizedHeadEvent is posted when the finalized block advances.
+type FinalizedHeadEvent struct{ Block *types.Block }
@@ -23,17 +23,20 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 	"strings"
 
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/common/hexutil"
 	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/contracts/minerlist"
 	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/log"
 	"github.com/usechain/go-usechain/params"
 	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/trie"
 
 	"github.com/usechain/go-usechain/crypto"
 
@@ -139,6 +142,46 @@ func (e *GenesisMismatchError) Error() string {
 	return fmt.Sprintf("database already contains an incompatible genesis block (have %x, new %x)", e.Stored[:8], e.New[:8])
 }
 
+// GenesisSignerError is raised when a genesis spec was not signed by the
+// expected authority.
+type GenesisSignerError struct {
+	Signer, Authority common.Address
+}
+
+func (e *GenesisSignerError) Error() string {
+	return fmt.Sprintf("genesis spec signed by %s, expected %s", e.Signer.Hex(), e.Authority.Hex())
+}
+
+// GenesisSpecSigner recovers the address which signed the raw bytes of a genesis
+// spec. The signature is in the [R || S || V] format produced by 'ethkey
+// signmessage', signing
+//
+//   keccak256("\x19Ethereum Signed Message:\n"${spec length}${spec}).
+func GenesisSpecSigner(spec []byte, signature []byte) (common.Address, error) {
+	if len(signature) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(signature))
+	}
+	msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(spec), spec)
+	pubkey, err := crypto.SigToPub(crypto.Keccak256([]byte(msg)), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return crypto.PubkeyToAddress(*pubkey), nil
+}
+
+// VerifyGenesisSpec checks that the raw bytes of a genesis spec were signed by
+// the given authority, returning a *GenesisSignerError if signed by anyone else.
+func VerifyGenesisSpec(spec []byte, signature []byte, authority common.Address) error {
+	signer, err := GenesisSpecSigner(spec, signature)
+	if err != nil {
+		return err
+	}
+	if signer != authority {
+		return &GenesisSignerError{Signer: signer, Authority: authority}
+	}
+	return nil
+}
+
 // SetupGenesisBlock writes or updates the genesis block in db.
 // The block that will be used is:
 //
@@ -167,7 +210,10 @@ func SetupGenesisBlock(db ethdb.Database, genesis *Genesis) (*params.ChainConfig
 			log.Info("Writing custom genesis block")
 		}
 		block, err := genesis.Commit(db)
-		return genesis.Config, block.Hash(), err
+		if err != nil {
+			return genesis.Config, common.Hash{}, err
+		}
+		return genesis.Config, block.Hash(), nil
 	}
 
 	// Check whether the genesis block is already written.
@@ -225,19 +271,20 @@ func (g *Genesis) configOrDefault(ghash common.Hash) *params.ChainConfig {
 // ToBlock creates the genesis block and writes state of a genesis specification
 // to the given database (or discards it if nil).
 func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
-	if db == nil {
-		db, _ = ethdb.NewMemDatabase()
-	}
-	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
-	for addr, account := range g.Alloc {
-		statedb.AddBalance(addr, account.Balance)
-		statedb.SetCode(addr, account.Code)
-		statedb.SetNonce(addr, account.Nonce)
-		for key, value := range account.Storage {
-			statedb.SetState(addr, key, value)
-		}
+	block, err := g.toBlock(db)
+	if err != nil {
+		log.Error("Failed to write genesis state", "err", err)
+	}
+	return block
+}
+
+// toBlock creates the genesis block and writes state of a genesis specification
+// to the given database (or discards it if nil), returning any write failure.
+func (g *Genesis) toBlock(db ethdb.Database) (*types.Block, error) {
+	root, err := g.Alloc.commit(db)
+	if err != nil {
+		return nil, err
 	}
-	root := statedb.IntermediateRoot(false)
 	head := &types.Header{
 		Number:     new(big.Int).SetUint64(g.Number),
 		Nonce:      types.EncodeNonce(g.Nonce),
@@ -257,19 +304,125 @@ func (g *Genesis) ToBlock(db ethdb.Database) *types.Block {
 	if g.Difficulty == nil {
 		head.Difficulty = params.GenesisDifficulty
 	}
-	statedb.Commit(false)
-	statedb.Database().TrieDB().Commit(root, true)
+	return types.NewBlock(head, nil, nil, nil), nil
+}
+
+// commit computes the state root of the genesis allocation. If db is non-nil,
+// the account and storage tries, the contract codes and the key preimages are
+// written into it too. Accounts and slots are fed into stack tries in hashed
+// key order, which is much faster than going through a StateDB for allocation
+// heavy genesis specs.
+func (ga GenesisAlloc) commit(db ethdb.Database) (common.Hash, error) {
+	var batch ethdb.Batch
+	if db != nil {
+		batch = db.NewBatch()
+	}
+	// Order the accounts the way they are laid out in the state trie
+	type hashedAccount struct {
+		hash    common.Hash
+		address common.Address
+		account GenesisAccount
+	}
+	accounts := make([]hashedAccount, 0, len(ga))
+	for addr, account := range ga {
+		accounts = append(accounts, hashedAccount{crypto.Keccak256Hash(addr[:]), addr, account})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return bytes.Compare(accounts[i].hash[:], accounts[j].hash[:]) < 0
+	})
+	tr := trie.NewStackTrie(batch)
+	for _, acc := range accounts {
+		root, err := acc.account.commitStorage(batch)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		data := state.Account{
+			Nonce:    acc.account.Nonce,
+			Balance:  new(big.Int),
+			Root:     root,
+			CodeHash: crypto.Keccak256(acc.account.Code),
+		}
+		if acc.account.Balance != nil {
+			data.Balance.Set(acc.account.Balance)
+		}
+		blob, _ := rlp.EncodeToBytes(&data)
+		if err := tr.TryUpdate(acc.hash[:], blob); err != nil {
+			return common.Hash{}, err
+		}
+		if batch == nil {
+			continue
+		}
+		if len(acc.account.Code) > 0 {
+			if err := batch.Put(data.CodeHash, acc.account.Code); err != nil {
+				return common.Hash{}, err
+			}
+		}
+		if err := trie.WritePreimage(batch, acc.hash, acc.address[:]); err != nil {
+			return common.Hash{}, err
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return common.Hash{}, err
+			}
+			batch.Reset()
+		}
+	}
+	root, err := tr.Commit()
+	if err != nil || batch == nil {
+		return root, err
+	}
+	return root, batch.Write()
+}
 
-	return types.NewBlock(head, nil, nil, nil)
+// commitStorage computes the storage root of a genesis account, writing the
+// storage trie and the slot preimages into batch if it's non-nil.
+func (ga GenesisAccount) commitStorage(batch ethdb.Batch) (common.Hash, error) {
+	type hashedSlot struct {
+		hash  common.Hash
+		key   common.Hash
+		value common.Hash
+	}
+	slots := make([]hashedSlot, 0, len(ga.Storage))
+	for key, value := range ga.Storage {
+		if value != (common.Hash{}) {
+			slots = append(slots, hashedSlot{crypto.Keccak256Hash(key[:]), key, value})
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return bytes.Compare(slots[i].hash[:], slots[j].hash[:]) < 0
+	})
+	tr := trie.NewStackTrie(batch)
+	for _, slot := range slots {
+		blob, _ := rlp.EncodeToBytes(bytes.TrimLeft(slot.value[:], "\x00"))
+		if err := tr.TryUpdate(slot.hash[:], blob); err != nil {
+			return common.Hash{}, err
+		}
+		if batch != nil {
+			if err := trie.WritePreimage(batch, slot.hash, slot.key[:]); err != nil {
+				return common.Hash{}, err
+			}
+		}
+	}
+	return tr.Commit()
 }
 
 // Commit writes the block and state of a genesis specification to the database.
 // The block is committed as the canonical head block.
 func (g *Genesis) Commit(db ethdb.Database) (*types.Block, error) {
-	block := g.ToBlock(db)
+	block, err := g.toBlock(db)
+	if err != nil {
+		return nil, err
+	}
 	if block.Number().Sign() != 0 {
 		return nil, fmt.Errorf("can't commit genesis block with number > 0")
 	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateSystemContracts(g.Config, statedb); err != nil {
+		return nil, fmt.Errorf("invalid genesis state: %v", err)
+	}
 	if err := WriteTd(db, block.Hash(), block.NumberU64(), g.Difficulty); err != nil {
 		return nil, err
 	}
@@ -367,6 +520,48 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	}
 }
 
+// DevnetGenesisBlock returns the genesis block of a local multi-node development
+// network. The Usechain system contracts are deployed as on Moonet, with the
+// miner list pre-populated with the given miners so that each of them may seal
+// blocks right away. All miners are pre-funded.
+func DevnetGenesisBlock(networkID uint64, miners []common.Address) *Genesis {
+	config := *params.AllEthashProtocolChanges
+	config.ChainId = new(big.Int).SetUint64(networkID)
+	config.SystemContracts = params.MoonetChainConfig.SystemContracts
+
+	// Carry over the system contracts and register the committee of miners
+	moonet := DefaultMoonetGenesisBlock()
+	alloc := GenesisAlloc{
+		identityContract: moonet.Alloc[identityContract],
+	}
+	minerList := common.HexToAddress(minerlist.MinerListContract)
+
+	list := moonet.Alloc[minerList]
+	list.Storage = map[common.Hash]common.Hash{
+		common.Hash{}: common.BigToHash(big.NewInt(int64(len(miners)))),
+	}
+	for _, miner := range miners {
+		list.Storage[minerlist.MinerKey(miner)] = common.BigToHash(common.Big1)
+	}
+	alloc[minerList] = list
+
+	// Pre-fund the precompiles and the miners
+	for i := byte(1); i <= 8; i++ {
+		alloc[common.BytesToAddress([]byte{i})] = GenesisAccount{Balance: big.NewInt(1)}
+	}
+	funds := new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Use))
+	for _, miner := range miners {
+		alloc[miner] = GenesisAccount{Balance: funds}
+	}
+	return &Genesis{
+		Config:     &config,
+		Nonce:      66,
+		GasLimit:   6283185,
+		Difficulty: new(big.Int).Set(params.MinimumDifficulty),
+		Alloc:      alloc,
+	}
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	var p []struct{ Addr, Balance *big.Int }
 	if err := rlp.NewStream(strings.NewReader(data), 0).Decode(&p); err != nil {
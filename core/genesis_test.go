@@ -17,6 +17,9 @@
 package core
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"fmt"
 	"math/big"
 	"reflect"
 	"testing"
@@ -24,7 +27,10 @@ import (
 	"github.com/davecgh/go-spew/spew"
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/params"
 )
@@ -161,3 +167,159 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+// Tests that the genesis state committed through stack tries matches the state
+// a StateDB would build from the same allocation, and that it's fully readable.
+func TestGenesisAllocCommit(t *testing.T) {
+	alloc := make(GenesisAlloc)
+	for i := 0; i < 200; i++ {
+		account := GenesisAccount{Balance: big.NewInt(int64(i)), Nonce: uint64(i % 3)}
+		if i%10 == 0 {
+			account.Code = []byte{byte(i), 0x60, 0x00}
+			account.Storage = map[common.Hash]common.Hash{
+				common.BigToHash(big.NewInt(1)):        common.BigToHash(big.NewInt(int64(i + 1))),
+				common.BigToHash(big.NewInt(int64(i))): common.HexToHash("0xdeadbeef"),
+				common.BigToHash(big.NewInt(1000)):     {}, // zero slots are not stored
+			}
+		}
+		alloc[common.BigToAddress(big.NewInt(int64(i+1)))] = account
+	}
+	// Build the reference state the slow way
+	refdb, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(refdb))
+	for addr, account := range alloc {
+		statedb.AddBalance(addr, account.Balance)
+		statedb.SetCode(addr, account.Code)
+		statedb.SetNonce(addr, account.Nonce)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	want, _ := statedb.Commit(false)
+
+	// Commit the allocation and ensure it's identical and accessible
+	db, _ := ethdb.NewMemDatabase()
+	root, err := alloc.commit(db)
+	if err != nil {
+		t.Fatalf("failed to commit genesis alloc: %v", err)
+	}
+	if root != want {
+		t.Fatalf("state root mismatch: have %x, want %x", root, want)
+	}
+	if hashed, _ := alloc.commit(nil); hashed != want {
+		t.Fatalf("hash-only state root mismatch: have %x, want %x", hashed, want)
+	}
+	sdb := state.NewDatabase(db)
+	committed, err := state.New(root, sdb)
+	if err != nil {
+		t.Fatalf("failed to open committed state: %v", err)
+	}
+	for addr, account := range alloc {
+		if balance := committed.GetBalance(addr); balance.Cmp(account.Balance) != 0 {
+			t.Errorf("account %x: balance mismatch: have %v, want %v", addr, balance, account.Balance)
+		}
+		if code := committed.GetCode(addr); !bytes.Equal(code, account.Code) {
+			t.Errorf("account %x: code mismatch: have %x, want %x", addr, code, account.Code)
+		}
+		for key, value := range account.Storage {
+			if stored := committed.GetState(addr, key); stored != value {
+				t.Errorf("account %x: slot %x mismatch: have %x, want %x", addr, key, stored, value)
+			}
+		}
+	}
+	// Preimages must be available for state dumps
+	tr, _ := sdb.OpenTrie(root)
+	for addr := range alloc {
+		if preimage := tr.GetKey(crypto.Keccak256(addr[:])); !bytes.Equal(preimage, addr[:]) {
+			t.Fatalf("account %x: preimage mismatch: have %x", addr, preimage)
+		}
+	}
+}
+
+// Tests that the devnet genesis deploys the system contracts and registers the
+// requested miners in the miner list.
+func TestDevnetGenesisBlock(t *testing.T) {
+	miners := []common.Address{{0x01}, {0x02}, {0x03}}
+	genesis := DevnetGenesisBlock(4242, miners)
+
+	db, _ := ethdb.NewMemDatabase()
+	block, err := genesis.Commit(db)
+	if err != nil {
+		t.Fatalf("failed to commit devnet genesis: %v", err)
+	}
+	if id := genesis.Config.ChainId.Uint64(); id != 4242 {
+		t.Errorf("chain id mismatch: have %d, want %d", id, 4242)
+	}
+	statedb, _ := state.New(block.Root(), state.NewDatabase(db))
+	if err := ValidateSystemContracts(genesis.Config, statedb); err != nil {
+		t.Fatalf("system contracts invalid: %v", err)
+	}
+	if n := minerlist.ReadMinerNum(statedb); n.Int64() != int64(len(miners)) {
+		t.Errorf("miner count mismatch: have %d, want %d", n, len(miners))
+	}
+	for _, miner := range miners {
+		if !minerlist.IsMiner(statedb, miner) {
+			t.Errorf("miner %x not registered", miner)
+		}
+		if statedb.GetBalance(miner).Sign() == 0 {
+			t.Errorf("miner %x not funded", miner)
+		}
+	}
+	if minerlist.IsMiner(statedb, common.Address{0x04}) {
+		t.Errorf("unexpected miner registered")
+	}
+}
+
+// Tests that genesis specs are only accepted if signed by the expected authority.
+func TestVerifyGenesisSpec(t *testing.T) {
+	spec := []byte(`{
+	"alloc"      : {},
+	"difficulty" : "0x20000",
+	"gasLimit"   : "0x2fefd8",
+	"nonce"      : "0x0000000000000042",
+	"timestamp"  : "0x00"
+}`)
+	authority, _ := crypto.GenerateKey()
+	stranger, _ := crypto.GenerateKey()
+
+	// Sign the spec the same way 'ethkey signmessage' does
+	sign := func(key *ecdsa.PrivateKey) []byte {
+		msg := fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(spec), spec)
+		signature, err := crypto.Sign(crypto.Keccak256([]byte(msg)), key)
+		if err != nil {
+			t.Fatalf("failed to sign genesis spec: %v", err)
+		}
+		return signature
+	}
+	want := crypto.PubkeyToAddress(authority.PublicKey)
+
+	tests := []struct {
+		name      string
+		spec      []byte
+		signature []byte
+		signer    common.Address // Signer expected to be recovered, zero if erroring
+		ok        bool           // Whether the spec must be attributed to the authority
+	}{
+		{"accepted", spec, sign(authority), want, true},
+		{"tampered", bytes.Replace(spec, []byte("0x2fefd8"), []byte("0x2fefd9"), 1), sign(authority), common.Address{}, false},
+		{"unknown signer", spec, sign(stranger), crypto.PubkeyToAddress(stranger.PublicKey), false},
+		{"truncated", spec, sign(authority)[:64], common.Address{}, false},
+	}
+	for _, tt := range tests {
+		err := VerifyGenesisSpec(tt.spec, tt.signature, want)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: verification mismatch: have %v, want accepted %v", tt.name, err, tt.ok)
+			continue
+		}
+		if tt.signer != (common.Address{}) && !tt.ok {
+			serr, ok := err.(*GenesisSignerError)
+			if !ok {
+				t.Errorf("%s: error type mismatch: have %T, want *GenesisSignerError", tt.name, err)
+				continue
+			}
+			if serr.Signer != tt.signer || serr.Authority != want {
+				t.Errorf("%s: signer mismatch: have %x (authority %x), want %x (authority %x)", tt.name, serr.Signer, serr.Authority, tt.signer, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/log"
+)
+
+const (
+	historyPruneInterval = time.Minute // Interval between two rounds of history pruning
+	historyPruneBatch    = 128         // Maximum number of blocks pruned in a single round
+	historyPruneRounds   = 64          // Maximum number of rounds run back to back
+)
+
+// SetHistoryRetention sets the number of recent blocks whose bodies and
+// receipts are kept, older ones being pruned in the background. Headers are
+// always retained. Zero keeps the entire history.
+func (bc *BlockChain) SetHistoryRetention(blocks uint64) {
+	atomic.StoreUint64(&bc.historyRetain, blocks)
+}
+
+// HistoryTail returns the number of the oldest block whose body and receipts
+// are still available. The genesis block is never pruned and is not accounted
+// for by the tail.
+func (bc *BlockChain) HistoryTail() uint64 {
+	return atomic.LoadUint64(&bc.historyTail)
+}
+
+// pruneHistory deletes the bodies, receipts and transaction lookups of the
+// blocks which fell out of the retention window, side chain ones included. The
+// work is done in rounds of at most historyPruneBatch blocks, to avoid holding
+// up block import and head readers.
+func (bc *BlockChain) pruneHistory() {
+	for i := 0; i < historyPruneRounds; i++ {
+		if !bc.pruneHistoryRound() {
+			return
+		}
+		select {
+		case <-bc.quit:
+			return
+		default:
+		}
+	}
+}
+
+// pruneHistoryRound advances the history tail by at most historyPruneBatch
+// blocks, returning whether there may be more to prune. The deletions are
+// gathered without holding any lock, which is only taken to commit them along
+// with the new tail, after ensuring the retention window didn't move back.
+func (bc *BlockChain) pruneHistoryRound() bool {
+	retain := atomic.LoadUint64(&bc.historyRetain)
+	head := bc.CurrentBlock().NumberU64()
+	if retain == 0 || head <= retain {
+		return false
+	}
+	tail, limit := bc.HistoryTail(), head-retain+1
+	if tail >= limit {
+		return false
+	}
+	if limit > tail+historyPruneBatch {
+		limit = tail + historyPruneBatch
+	}
+	batch := bc.db.NewBatch()
+	hashes := blockHashes(bc.db, tail, limit)
+	for number := tail; number < limit; number++ {
+		if number == 0 {
+			continue
+		}
+		canon := GetCanonicalHash(bc.db, number)
+		if body := GetBody(bc.db, canon, number); body != nil {
+			for _, tx := range body.Transactions {
+				DeleteTxLookupEntry(batch, tx.Hash())
+			}
+		}
+		DeleteBody(batch, canon, number)
+		DeleteBlockReceipts(batch, canon, number)
+
+		for _, hash := range hashes[number] {
+			if hash != canon {
+				DeleteBody(batch, hash, number)
+				DeleteBlockReceipts(batch, hash, number)
+			}
+		}
+	}
+	WriteHistoryTail(batch, limit)
+
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+
+	// The chain may have been rewound or pruned meanwhile, check again
+	if head := bc.CurrentBlock().NumberU64(); head < retain || limit > head-retain+1 || bc.HistoryTail() != tail {
+		return false
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("Failed to prune chain history", "err", err)
+		return false
+	}
+	atomic.StoreUint64(&bc.historyTail, limit)
+
+	bc.bodyCache.Purge()
+	bc.bodyRLPCache.Purge()
+	bc.blockCache.Purge()
+
+	log.Debug("Pruned chain history", "from", tail, "to", limit-1, "head", head)
+	return true
+}
+
+// blockHashes returns the hashes of all headers stored in the [from, to) height
+// range, canonical and side chain alike, grouped by number. Databases unable to
+// iterate their contents report nothing.
+func blockHashes(db ethdb.Database, from, to uint64) map[uint64][]common.Hash {
+	snapshotter, ok := db.(ethdb.Snapshotter)
+	if !ok {
+		return nil
+	}
+	snap, err := snapshotter.NewSnapshot()
+	if err != nil {
+		log.Warn("Failed to snapshot database for history pruning", "err", err)
+		return nil
+	}
+	defer snap.Release()
+
+	it := snap.NewIterator(append(append([]byte{}, headerPrefix...), encodeBlockNumber(from)...))
+	defer it.Release()
+
+	hashes := make(map[uint64][]common.Hash)
+	for it.Next() {
+		key := it.Key()
+		if !bytes.HasPrefix(key, headerPrefix) {
+			break
+		}
+		if len(key) != len(headerPrefix)+8+common.HashLength {
+			continue
+		}
+		number := binary.BigEndian.Uint64(key[len(headerPrefix):])
+		if number >= to {
+			break
+		}
+		hashes[number] = append(hashes[number], common.BytesToHash(key[len(headerPrefix)+8:]))
+	}
+	return hashes
+}
@@ -0,0 +1,121 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/params"
+)
+
+// Tests that history pruning drops the bodies, receipts and lookups of the
+// blocks below the retention window, keeping their headers and persisting the
+// new tail.
+func TestHistoryPruning(t *testing.T) {
+	var (
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender = crypto.PubkeyToAddress(key.PublicKey)
+		gspec  = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{sender: {Balance: big.NewInt(1000000000)}}}
+		signer = types.NewEIP155Signer(gspec.Config.ChainId)
+		db, _  = ethdb.NewMemDatabase()
+	)
+	genesis := gspec.MustCommit(db)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 20, func(i int, gen *BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(sender), common.HexToAddress("0xaaaa"), big.NewInt(1000), 21000, big.NewInt(1), nil), signer, key)
+		gen.AddTx(tx)
+	})
+	blockchain, err := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	side, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 3, func(i int, gen *BlockGen) {
+		gen.SetExtra([]byte("side"))
+	})
+	if _, err := blockchain.InsertChain(side); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+	// Without a retention window nothing may be pruned
+	blockchain.pruneHistory()
+	if tail := blockchain.HistoryTail(); tail != 0 {
+		t.Fatalf("history tail mismatch without retention: have %d, want 0", tail)
+	}
+	// Retain the last 5 blocks and check that only those keep their data
+	blockchain.SetHistoryRetention(5)
+	blockchain.pruneHistory()
+
+	if tail := blockchain.HistoryTail(); tail != 16 {
+		t.Fatalf("history tail mismatch: have %d, want 16", tail)
+	}
+	if tail := GetHistoryTail(db); tail != 16 {
+		t.Fatalf("stored history tail mismatch: have %d, want 16", tail)
+	}
+	if blockchain.GetBlockByNumber(0) == nil {
+		t.Fatalf("genesis block pruned")
+	}
+	for _, block := range side {
+		if GetBody(db, block.Hash(), block.NumberU64()) != nil {
+			t.Errorf("side block #%d: body retained below the tail", block.NumberU64())
+		}
+	}
+	for _, block := range blocks {
+		number := block.NumberU64()
+		if blockchain.GetHeaderByNumber(number) == nil {
+			t.Errorf("block #%d: header pruned", number)
+		}
+		tx, _, _, _ := GetTransaction(db, block.Transactions()[0].Hash())
+		if number < 16 {
+			if GetBody(db, block.Hash(), number) != nil {
+				t.Errorf("block #%d: body retained below the tail", number)
+			}
+			if GetBlockReceipts(db, block.Hash(), number) != nil {
+				t.Errorf("block #%d: receipts retained below the tail", number)
+			}
+			if tx != nil {
+				t.Errorf("block #%d: transaction lookup retained below the tail", number)
+			}
+		} else {
+			if GetBody(db, block.Hash(), number) == nil {
+				t.Errorf("block #%d: body pruned above the tail", number)
+			}
+			if tx == nil {
+				t.Errorf("block #%d: transaction lookup pruned above the tail", number)
+			}
+		}
+	}
+	// Rewinding into the pruned range is refused, rewinding above it works
+	if err := blockchain.SetHead(10); err == nil {
+		t.Errorf("rewind below the history tail succeeded")
+	}
+	if err := blockchain.SetHead(18); err != nil {
+		t.Errorf("failed to rewind above the history tail: %v", err)
+	}
+	if head := blockchain.CurrentBlock().NumberU64(); head != 18 {
+		t.Errorf("head mismatch after rewind: have #%d, want #18", head)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+)
+
+// Storage slots of the identity contract state walked by the exporter, see
+// contracts/authentication/authentication.sol for the layout.
+const (
+	certIDCountSlot     = 2  // Next certificate id to be assigned
+	committeeSlot       = 8  // Array of committee member addresses
+	certToAddressSlot   = 10 // Mapping from certificate id to {confirmed, address}
+	oneTimeAddrListSlot = 13 // Array of confirmed one time addresses
+	certificateSlot     = 14 // Mapping from address to {added, confirmed, type, ...}
+
+	certTypeSub = 1 // Value of addrType.sub in the certificate records
+)
+
+// Kinds of identity records in an export.
+const (
+	IdentityKindMain      = "main"
+	IdentityKindSub       = "sub"
+	IdentityKindOneTime   = "onetime"
+	IdentityKindCommittee = "committee"
+)
+
+// identityKindOrder is the order of the record kinds of the same address.
+var identityKindOrder = map[string]int{
+	IdentityKindCommittee: 0,
+	IdentityKindMain:      1,
+	IdentityKindSub:       2,
+	IdentityKindOneTime:   3,
+}
+
+// IdentityRecord is a single entry of the identity registry.
+type IdentityRecord struct {
+	Address   common.Address `json:"address"`
+	Kind      string         `json:"kind"`
+	CertID    uint64         `json:"certId,omitempty"` // Certificate id of main and sub addresses
+	Confirmed bool           `json:"confirmed"`        // Whether the committee approved the record
+	Level     int            `json:"level"`            // Verification level of the address
+}
+
+// IdentityExport is a normalized snapshot of the identity registry at a block.
+// The contract keeps no per record timestamps, the time of the exported block is
+// the point in time the whole dataset is valid for.
+type IdentityExport struct {
+	Number  uint64            `json:"number"`
+	Hash    common.Hash       `json:"hash"`
+	Time    uint64            `json:"timestamp"`
+	Records []*IdentityRecord `json:"records"`
+}
+
+// ExportIdentities walks the storage of the identity contract in the state of
+// the given block and gathers the committee, certified and one time addresses,
+// ordered by address.
+func ExportIdentities(statedb *state.StateDB, header *types.Header) *IdentityExport {
+	records, _ := ReadIdentities(statedb, IdentityKey{}, math.MaxInt32)
+	if records == nil {
+		records = []*IdentityRecord{}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i], records[j]
+		if cmp := bytes.Compare(a.Address[:], b.Address[:]); cmp != 0 {
+			return cmp < 0
+		}
+		return identityKindOrder[a.Kind] < identityKindOrder[b.Kind]
+	})
+	return &IdentityExport{
+		Number:  header.Number.Uint64(),
+		Hash:    header.Hash(),
+		Time:    header.Time.Uint64(),
+		Records: records,
+	}
+}
+
+// Sections of the identity contract storage, in the order they are read.
+const (
+	identitySectionCommittee = iota
+	identitySectionOneTime
+	identitySectionCerts
+	identitySections
+)
+
+// IdentityKey is a position in the identity contract storage, which is read as
+// the committee, the one time addresses and the certificates in storage order.
+type IdentityKey struct {
+	Section int    // Storage section of the record
+	Index   uint64 // Array index, or certificate id within the certificates
+}
+
+// ReadIdentities reads at most limit records of the identity contract starting
+// at the given position, only touching the storage slots of the records read.
+// The position of the first record left out is returned, nil if the records
+// reach the end of the registry.
+func ReadIdentities(statedb *state.StateDB, start IdentityKey, limit int) ([]*IdentityRecord, *IdentityKey) {
+	var (
+		records []*IdentityRecord
+		pos     = start
+	)
+	record := func(addr common.Address, kind string, certID uint64, confirmed bool) *IdentityRecord {
+		return &IdentityRecord{
+			Address:   addr,
+			Kind:      kind,
+			CertID:    certID,
+			Confirmed: confirmed,
+			Level:     statedb.CheckAddrAuthenticateStat(addr),
+		}
+	}
+	for pos.Section < identitySections {
+		switch pos.Section {
+		case identitySectionCommittee, identitySectionOneTime:
+			slot, kind := int64(committeeSlot), IdentityKindCommittee
+			if pos.Section == identitySectionOneTime {
+				slot, kind = oneTimeAddrListSlot, IdentityKindOneTime
+			}
+			if pos.Index >= identityArrayLen(statedb, slot) {
+				pos = IdentityKey{Section: pos.Section + 1}
+				continue
+			}
+			if len(records) == limit {
+				return records, &pos
+			}
+			records = append(records, record(identityArrayAt(statedb, slot, pos.Index), kind, 0, true))
+
+		case identitySectionCerts:
+			// Certificates are never removed from the id space, rejected ones are
+			// only zeroed out, so iterate all the ids handed out so far
+			if pos.Index == 0 {
+				pos.Index = 1
+			}
+			count := identityState(statedb, common.BigToHash(big.NewInt(certIDCountSlot))).Big()
+			if !count.IsUint64() || pos.Index >= count.Uint64() {
+				pos = IdentityKey{Section: pos.Section + 1}
+				continue
+			}
+			cert := identityState(statedb, mappingSlot(common.BigToHash(new(big.Int).SetUint64(pos.Index)), certToAddressSlot))
+			addr := common.BytesToAddress(cert[common.HashLength-1-common.AddressLength : common.HashLength-1])
+			if addr != (common.Address{}) {
+				if len(records) == limit {
+					return records, &pos
+				}
+				info := identityState(statedb, mappingSlot(addr.Hash(), certificateSlot))
+
+				kind := IdentityKindMain
+				if info[common.HashLength-3] == certTypeSub {
+					kind = IdentityKindSub
+				}
+				records = append(records, record(addr, kind, pos.Index, cert[common.HashLength-1] != 0))
+			}
+		}
+		pos.Index++
+	}
+	return records, nil
+}
+
+// identityContext is the JSON-LD context of the export, mapping its fields onto
+// a registry vocabulary and typing the numeric ones.
+var identityContext = map[string]interface{}{
+	"@vocab":    "urn:usechain:identity:",
+	"xsd":       "http://www.w3.org/2001/XMLSchema#",
+	"number":    map[string]string{"@type": "xsd:unsignedLong"},
+	"timestamp": map[string]string{"@type": "xsd:unsignedLong"},
+	"certId":    map[string]string{"@type": "xsd:unsignedLong"},
+}
+
+// identityLDRecord is a record of the export in its JSON-LD form.
+type identityLDRecord struct {
+	Type string `json:"@type"`
+	*IdentityRecord
+}
+
+// identityLDExport is the export in its JSON-LD form.
+type identityLDExport struct {
+	Context interface{} `json:"@context"`
+	Type    string      `json:"@type"`
+	*IdentityExport
+	Records []identityLDRecord `json:"records"`
+}
+
+// WriteJSONLD writes the export as a JSON-LD document, with the snapshot typed
+// as a RegistrySnapshot and every record as an IdentityRecord.
+func (export *IdentityExport) WriteJSONLD(w io.Writer) error {
+	doc := &identityLDExport{
+		Context:        identityContext,
+		Type:           "RegistrySnapshot",
+		IdentityExport: export,
+		Records:        make([]identityLDRecord, len(export.Records)),
+	}
+	for i, record := range export.Records {
+		doc.Records[i] = identityLDRecord{Type: "IdentityRecord", IdentityRecord: record}
+	}
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(out, '\n'))
+	return err
+}
+
+// WriteCSV writes the records of the export as CSV with a header line.
+func (export *IdentityExport) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"address", "kind", "certId", "confirmed", "level"}); err != nil {
+		return err
+	}
+	for _, record := range export.Records {
+		certID := ""
+		if record.CertID != 0 {
+			certID = strconv.FormatUint(record.CertID, 10)
+		}
+		err := out.Write([]string{
+			record.Address.Hex(),
+			record.Kind,
+			certID,
+			strconv.FormatBool(record.Confirmed),
+			strconv.Itoa(record.Level),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// identityState retrieves a storage slot of the identity contract.
+func identityState(statedb *state.StateDB, slot common.Hash) common.Hash {
+	return statedb.GetState(identityContract, slot)
+}
+
+// identityArrayLen retrieves the length of a dynamic array of the identity
+// contract, zero if it's out of range.
+func identityArrayLen(statedb *state.StateDB, slot int64) uint64 {
+	length := identityState(statedb, common.BigToHash(big.NewInt(slot))).Big()
+	if !length.IsUint64() {
+		return 0
+	}
+	return length.Uint64()
+}
+
+// identityArrayAt retrieves the address stored at the given index of a dynamic
+// array of the identity contract.
+func identityArrayAt(statedb *state.StateDB, slot int64, index uint64) common.Address {
+	base := crypto.Keccak256Hash(common.BigToHash(big.NewInt(slot)).Bytes()).Big()
+	elem := common.BigToHash(base.Add(base, new(big.Int).SetUint64(index)))
+	return common.BytesToAddress(identityState(statedb, elem).Bytes())
+}
+
+// mappingSlot calculates the storage slot of a mapping entry.
+func mappingSlot(key common.Hash, slot int64) common.Hash {
+	return crypto.Keccak256Hash(key.Bytes(), common.BigToHash(big.NewInt(slot)).Bytes())
+}
@@ -0,0 +1,174 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// Addresses of the identity records in the test registry.
+var (
+	testCommittee = common.HexToAddress("0x0000000000000000000000000000000000000001")
+	testMain      = common.HexToAddress("0x0000000000000000000000000000000000000002")
+	testSub       = common.HexToAddress("0x0000000000000000000000000000000000000003")
+	testOneTime   = common.HexToAddress("0x0000000000000000000000000000000000000004")
+)
+
+// newIdentityTestState creates a state with a committee member, a one time
+// address, a pending sub certificate, a rejected certificate and a confirmed
+// main certificate in the identity contract storage.
+func newIdentityTestState() *state.StateDB {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+
+	slot := func(n int64) common.Hash { return common.BigToHash(big.NewInt(n)) }
+	setArray := func(n int64, addrs ...common.Address) {
+		statedb.SetState(identityContract, slot(n), slot(int64(len(addrs))))
+		base := crypto.Keccak256Hash(slot(n).Bytes()).Big()
+		for i, addr := range addrs {
+			statedb.SetState(identityContract, common.BigToHash(new(big.Int).Add(base, big.NewInt(int64(i)))), addr.Hash())
+		}
+	}
+	setCert := func(id int64, addr common.Address, confirmed bool, kind byte) {
+		var cert, info common.Hash
+		copy(cert[common.HashLength-1-common.AddressLength:], addr[:])
+		info[common.HashLength-1] = 1
+		if confirmed {
+			cert[common.HashLength-1] = 1
+			info[common.HashLength-2] = 1
+		}
+		info[common.HashLength-3] = kind
+		statedb.SetState(identityContract, mappingSlot(slot(id), certToAddressSlot), cert)
+		statedb.SetState(identityContract, mappingSlot(addr.Hash(), certificateSlot), info)
+	}
+	setArray(committeeSlot, testCommittee)
+	setArray(oneTimeAddrListSlot, testOneTime)
+	setCert(1, testSub, false, certTypeSub)
+	setCert(3, testMain, true, 0) // Certificate 2 was rejected and zeroed out
+	statedb.SetState(identityContract, slot(certIDCountSlot), slot(4))
+
+	return statedb
+}
+
+// Tests that the identity registry is exported from the raw contract storage.
+func TestExportIdentities(t *testing.T) {
+	statedb := newIdentityTestState()
+
+	header := &types.Header{Number: big.NewInt(7), Time: big.NewInt(1500000000)}
+	export := ExportIdentities(statedb, header)
+	if export.Number != 7 || export.Time != 1500000000 || export.Hash != header.Hash() {
+		t.Errorf("export block mismatch: have #%d [%x] @%d", export.Number, export.Hash, export.Time)
+	}
+	want := []IdentityRecord{
+		{Address: testCommittee, Kind: IdentityKindCommittee, Confirmed: true, Level: 0},
+		{Address: testMain, Kind: IdentityKindMain, CertID: 3, Confirmed: true, Level: 1},
+		{Address: testSub, Kind: IdentityKindSub, CertID: 1, Confirmed: false, Level: 1},
+		{Address: testOneTime, Kind: IdentityKindOneTime, Confirmed: true, Level: 0},
+	}
+	if len(export.Records) != len(want) {
+		t.Fatalf("record count mismatch: have %d, want %d", len(export.Records), len(want))
+	}
+	for i, record := range export.Records {
+		if *record != want[i] {
+			t.Errorf("record %d mismatch: have %+v, want %+v", i, *record, want[i])
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := export.WriteCSV(buf); err != nil {
+		t.Fatalf("failed to write csv: %v", err)
+	}
+	csv := "address,kind,certId,confirmed,level\n" +
+		"0x0000000000000000000000000000000000000001,committee,,true,0\n" +
+		"0x0000000000000000000000000000000000000002,main,3,true,1\n" +
+		"0x0000000000000000000000000000000000000003,sub,1,false,1\n" +
+		"0x0000000000000000000000000000000000000004,onetime,,true,0\n"
+	if buf.String() != csv {
+		t.Errorf("csv mismatch: have\n%s\nwant\n%s", buf.String(), csv)
+	}
+	// The JSON-LD form types the snapshot and its records
+	buf.Reset()
+	if err := export.WriteJSONLD(buf); err != nil {
+		t.Fatalf("failed to write json-ld: %v", err)
+	}
+	var doc struct {
+		Context map[string]interface{} `json:"@context"`
+		Type    string                 `json:"@type"`
+		Number  uint64                 `json:"number"`
+		Records []struct {
+			Type    string         `json:"@type"`
+			Address common.Address `json:"address"`
+			Kind    string         `json:"kind"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("failed to parse json-ld: %v", err)
+	}
+	if doc.Context["@vocab"] == nil || doc.Type != "RegistrySnapshot" || doc.Number != 7 {
+		t.Errorf("json-ld document mismatch: context %v, type %q, number %d", doc.Context, doc.Type, doc.Number)
+	}
+	if len(doc.Records) != len(want) {
+		t.Fatalf("json-ld record count mismatch: have %d, want %d", len(doc.Records), len(want))
+	}
+	for i, record := range doc.Records {
+		if record.Type != "IdentityRecord" || record.Address != want[i].Address || record.Kind != want[i].Kind {
+			t.Errorf("json-ld record %d mismatch: have %+v", i, record)
+		}
+	}
+}
+
+// Tests that the identity registry can be read window by window in storage
+// order, resuming at the returned positions.
+func TestReadIdentities(t *testing.T) {
+	statedb := newIdentityTestState()
+
+	want := []common.Address{testCommittee, testOneTime, testSub, testMain}
+	for limit := 1; limit <= len(want)+1; limit++ {
+		var (
+			have  []common.Address
+			start IdentityKey
+		)
+		for windows := 0; ; windows++ {
+			if windows > len(want) {
+				t.Fatalf("limit %d: read not terminating", limit)
+			}
+			records, next := ReadIdentities(statedb, start, limit)
+			if len(records) > limit {
+				t.Fatalf("limit %d: window size %d exceeded", limit, len(records))
+			}
+			for _, record := range records {
+				have = append(have, record.Address)
+			}
+			if next == nil {
+				break
+			}
+			start = *next
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("limit %d: records mismatch: have %x, want %x", limit, have, want)
+		}
+	}
+}
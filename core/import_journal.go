@@ -0,0 +1,244 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+// Stages of a journalled block import.
+const (
+	importWriting   uint8 = iota // Block data being written, head markers untouched
+	importPromoting              // Block data committed, head markers being moved onto it
+)
+
+// importEntry is the write-ahead record of a block import in flight. It is
+// stored before the import touches the database and removed once the import
+// completes, so finding one at startup means the node died mid-commit.
+type importEntry struct {
+	Hash       common.Hash // Hash of the block being imported
+	Number     uint64      // Number of the block being imported
+	ParentHash common.Hash // Parent of the block being imported
+	PrevHead   common.Hash // Head block at the time the import started
+	Stage      uint8       // Last stage the import reached
+}
+
+// stateFlush records the newest canonical block whose state trie was committed
+// to disk in full. Blocks imported after it may reference trie nodes that only
+// ever lived in memory.
+type stateFlush struct {
+	Hash   common.Hash
+	Number uint64
+}
+
+// readImportJournal retrieves the pending block import record, if any.
+func readImportJournal(db DatabaseReader) *importEntry {
+	data, _ := db.Get(importWALKey)
+	if len(data) == 0 {
+		return nil
+	}
+	entry := new(importEntry)
+	if err := rlp.DecodeBytes(data, entry); err != nil {
+		log.Error("Invalid block import journal RLP", "err", err)
+		return nil
+	}
+	return entry
+}
+
+// writeImportJournal stores the pending block import record.
+func writeImportJournal(db ethdb.Putter, entry *importEntry) error {
+	data, err := rlp.EncodeToBytes(entry)
+	if err != nil {
+		return err
+	}
+	return db.Put(importWALKey, data)
+}
+
+// deleteImportJournal removes the pending block import record.
+func deleteImportJournal(db DatabaseDeleter) error {
+	return db.Delete(importWALKey)
+}
+
+// readStateFlush retrieves the last complete state flush record, if any.
+func readStateFlush(db DatabaseReader) *stateFlush {
+	data, _ := db.Get(stateFlushKey)
+	if len(data) == 0 {
+		return nil
+	}
+	flush := new(stateFlush)
+	if err := rlp.DecodeBytes(data, flush); err != nil {
+		log.Error("Invalid state flush RLP", "err", err)
+		return nil
+	}
+	return flush
+}
+
+// writeStateFlush stores the block whose state trie was just committed to disk.
+func writeStateFlush(db ethdb.Putter, hash common.Hash, number uint64) error {
+	data, err := rlp.EncodeToBytes(&stateFlush{Hash: hash, Number: number})
+	if err != nil {
+		return err
+	}
+	return db.Put(stateFlushKey, data)
+}
+
+// recoverImportJournal finishes or undoes a block import interrupted by a crash,
+// leaving the head markers and canonical hashes consistent with each other. An
+// import that already committed its block data is rolled forward onto the new
+// block, anything earlier is rolled back onto the head it started from.
+func recoverImportJournal(db ethdb.Database) error {
+	entry := readImportJournal(db)
+	if entry == nil {
+		return nil
+	}
+	if entry.Stage == importPromoting && GetBlock(db, entry.Hash, entry.Number) != nil {
+		// Block data is in the database, redo the head update
+		updateHeads := GetCanonicalHash(db, entry.Number) != entry.Hash
+
+		if err := WriteCanonicalHash(db, entry.Hash, entry.Number); err != nil {
+			return err
+		}
+		if err := WriteHeadBlockHash(db, entry.Hash); err != nil {
+			return err
+		}
+		if updateHeads {
+			if err := WriteHeadHeaderHash(db, entry.Hash); err != nil {
+				return err
+			}
+			if err := WriteHeadFastBlockHash(db, entry.Hash); err != nil {
+				return err
+			}
+		}
+		log.Warn("Rolled forward interrupted block import", "number", entry.Number, "hash", entry.Hash)
+		return deleteImportJournal(db)
+	}
+	// Block data may be partial but is unreferenced unless a reorg already started
+	// moving the head, in which case roll everything back onto the previous head
+	if GetHeadBlockHash(db) == entry.PrevHead {
+		log.Warn("Discarded interrupted block import", "number", entry.Number, "hash", entry.Hash)
+		return deleteImportJournal(db)
+	}
+	prev := GetBlockNumber(db, entry.PrevHead)
+	if prev == missingNumber {
+		log.Error("Previous head of interrupted block import missing", "hash", entry.PrevHead)
+		return deleteImportJournal(db)
+	}
+	// Drop anything a partial reorg placed above the old head
+	for n := entry.Number; n > prev; n-- {
+		if GetCanonicalHash(db, n) == (common.Hash{}) {
+			continue
+		}
+		DeleteCanonicalHash(db, n)
+	}
+	// Reorgs rewrite the canonical hashes ancestor first, so the heights already
+	// moved onto the new chain are the lowest ones. Walk both chains down to their
+	// common ancestor and restore every height of the old chain above it.
+	var (
+		oldHash, oldNumber = entry.PrevHead, prev
+		newHash, newNumber = entry.ParentHash, entry.Number - 1
+	)
+	for newNumber > oldNumber {
+		header := GetHeader(db, newHash, newNumber)
+		if header == nil {
+			break
+		}
+		newHash, newNumber = header.ParentHash, newNumber-1
+	}
+	for oldNumber > 0 && (oldNumber != newNumber || oldHash != newHash) {
+		header := GetHeader(db, oldHash, oldNumber)
+		if header == nil {
+			log.Error("Old chain of interrupted block import missing", "number", oldNumber, "hash", oldHash)
+			break
+		}
+		if canon := GetCanonicalHash(db, oldNumber); canon != oldHash {
+			if block := GetBlock(db, canon, oldNumber); block != nil {
+				for _, tx := range block.Transactions() {
+					DeleteTxLookupEntry(db, tx.Hash())
+				}
+			}
+			if err := WriteCanonicalHash(db, oldHash, oldNumber); err != nil {
+				return err
+			}
+			if block := GetBlock(db, oldHash, oldNumber); block != nil {
+				if err := WriteTxLookupEntries(db, block); err != nil {
+					return err
+				}
+			}
+		}
+		if oldNumber == newNumber {
+			header := GetHeader(db, newHash, newNumber)
+			if header == nil {
+				// Unknown new chain, fall back to restoring the old chain down to genesis
+				newNumber = 0
+			} else {
+				newHash, newNumber = header.ParentHash, newNumber-1
+			}
+		}
+		oldHash, oldNumber = header.ParentHash, oldNumber-1
+	}
+	if err := WriteHeadBlockHash(db, entry.PrevHead); err != nil {
+		return err
+	}
+	// Header and fast heads may legitimately run ahead, only reset those left
+	// pointing off the restored chain.
+	if head := GetHeadHeaderHash(db); !isCanonical(db, head) {
+		if err := WriteHeadHeaderHash(db, entry.PrevHead); err != nil {
+			return err
+		}
+	}
+	if head := GetHeadFastBlockHash(db); !isCanonical(db, head) {
+		if err := WriteHeadFastBlockHash(db, entry.PrevHead); err != nil {
+			return err
+		}
+	}
+	log.Warn("Rolled back interrupted block import", "number", entry.Number, "hash", entry.Hash, "head", entry.PrevHead)
+	return deleteImportJournal(db)
+}
+
+// recoverStateFlush moves the head block back onto the last block whose state was
+// flushed to disk in full. Blocks imported after it may reference trie nodes lost
+// in an unclean shutdown even if their state root made it to disk, so they are
+// reprocessed on top of the flushed state instead.
+func recoverStateFlush(db ethdb.Database, cache state.Database) error {
+	flush := readStateFlush(db)
+	if flush == nil || GetCanonicalHash(db, flush.Number) != flush.Hash {
+		return nil
+	}
+	head := GetHeadBlockHash(db)
+	if number := GetBlockNumber(db, head); number == missingNumber || number <= flush.Number {
+		return nil
+	}
+	block := GetBlock(db, flush.Hash, flush.Number)
+	if block == nil {
+		return nil
+	}
+	if _, err := state.New(block.Root(), cache); err != nil {
+		return nil
+	}
+	log.Warn("Rewinding past unflushed state", "head", head, "number", flush.Number, "hash", flush.Hash)
+	return WriteHeadBlockHash(db, flush.Hash)
+}
+
+// isCanonical reports whether the given block hash is part of the canonical chain.
+func isCanonical(db DatabaseReader, hash common.Hash) bool {
+	number := GetBlockNumber(db, hash)
+	return number != missingNumber && GetCanonicalHash(db, number) == hash
+}
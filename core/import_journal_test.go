@@ -0,0 +1,218 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// newJournalTestChain creates an archive blockchain, inserts a canonical chain
+// of three blocks and a heavier four block fork into it, returning both.
+func newJournalTestChain(t *testing.T) (ethdb.Database, *Genesis, types.Blocks, types.Blocks) {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := DefaultRPOWTestingGenesisBlock()
+	genesis := gspec.MustCommit(db)
+	engine := ethash.NewFakerUsechain(db)
+
+	blockchain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	canon, _ := GenerateChain(gspec.Config, genesis, engine, db, 3, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	fork, _ := GenerateChain(gspec.Config, genesis, engine, db, 4, func(i int, gen *BlockGen) {
+		gen.SetExtra([]byte("fork"))
+	})
+	if _, err := blockchain.InsertChain(fork); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	return db, gspec, canon, fork
+}
+
+// reopenJournalTestChain reloads the blockchain from the database, running the
+// import journal recovery.
+func reopenJournalTestChain(t *testing.T, db ethdb.Database, gspec *Genesis) *BlockChain {
+	blockchain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, gspec.Config, ethash.NewFakerUsechain(db), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen blockchain: %v", err)
+	}
+	if readImportJournal(db) != nil {
+		t.Errorf("import journal not cleared after recovery")
+	}
+	return blockchain
+}
+
+// Tests that an import which committed its block data but crashed before the
+// head was moved gets rolled forward onto the new block.
+func TestImportJournalRollForward(t *testing.T) {
+	db, gspec, _, fork := newJournalTestChain(t)
+
+	// Simulate a crash between the block data and the head update
+	WriteHeadBlockHash(db, fork[2].Hash())
+	writeImportJournal(db, &importEntry{Hash: fork[3].Hash(), Number: 4, PrevHead: fork[2].Hash(), Stage: importPromoting})
+
+	blockchain := reopenJournalTestChain(t, db, gspec)
+	defer blockchain.Stop()
+
+	if head := blockchain.CurrentBlock(); head.Hash() != fork[3].Hash() {
+		t.Errorf("head mismatch: have #%d [%x], want #4 [%x]", head.NumberU64(), head.Hash(), fork[3].Hash())
+	}
+}
+
+// Tests that an import which crashed halfway through a reorg gets rolled back
+// onto the head it started from, restoring the old canonical chain.
+func TestImportJournalRollBack(t *testing.T) {
+	db, gspec, canon, fork := newJournalTestChain(t)
+
+	// Simulate a crash after the reorg moved the head onto the second fork block
+	WriteHeadBlockHash(db, fork[1].Hash())
+	WriteHeadHeaderHash(db, fork[3].Hash())
+	WriteHeadFastBlockHash(db, fork[3].Hash())
+	writeImportJournal(db, &importEntry{Hash: fork[3].Hash(), Number: 4, PrevHead: canon[2].Hash(), Stage: importWriting})
+
+	blockchain := reopenJournalTestChain(t, db, gspec)
+	defer blockchain.Stop()
+
+	if head := blockchain.CurrentBlock(); head.Hash() != canon[2].Hash() {
+		t.Errorf("head mismatch: have #%d [%x], want #3 [%x]", head.NumberU64(), head.Hash(), canon[2].Hash())
+	}
+	if head := blockchain.CurrentHeader(); head.Hash() != canon[2].Hash() {
+		t.Errorf("head header mismatch: have #%d [%x], want #3 [%x]", head.Number, head.Hash(), canon[2].Hash())
+	}
+	for i, block := range canon {
+		if hash := GetCanonicalHash(db, block.NumberU64()); hash != block.Hash() {
+			t.Errorf("canonical hash #%d mismatch: have %x, want %x", i+1, hash, block.Hash())
+		}
+	}
+	if hash := GetCanonicalHash(db, 4); hash != (common.Hash{}) {
+		t.Errorf("stale canonical hash #4 left: %x", hash)
+	}
+}
+
+// Tests that an import which crashed before touching the head is discarded
+// without moving it.
+func TestImportJournalDiscard(t *testing.T) {
+	db, gspec, _, fork := newJournalTestChain(t)
+
+	writeImportJournal(db, &importEntry{Hash: common.Hash{0x01}, Number: 5, PrevHead: fork[3].Hash(), Stage: importWriting})
+
+	blockchain := reopenJournalTestChain(t, db, gspec)
+	defer blockchain.Stop()
+
+	if head := blockchain.CurrentBlock(); head.Hash() != fork[3].Hash() {
+		t.Errorf("head mismatch: have #%d [%x], want #4 [%x]", head.NumberU64(), head.Hash(), fork[3].Hash())
+	}
+}
+
+// Tests that an import which crashed after a reorg moved only the lowest fork
+// heights onto the canonical chain gets every height restored.
+func TestImportJournalRollBackPartialReorg(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := DefaultRPOWTestingGenesisBlock()
+	genesis := gspec.MustCommit(db)
+	engine := ethash.NewFakerUsechain(db)
+
+	blockchain, err := NewBlockChain(db, &CacheConfig{Disabled: true}, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	canon, _ := GenerateChain(gspec.Config, genesis, engine, db, 10, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	fork, _ := GenerateChain(gspec.Config, canon[4], engine, db, 6, func(i int, gen *BlockGen) {
+		gen.SetExtra([]byte("fork"))
+	})
+	if _, err := blockchain.InsertChain(fork[:4]); err != nil {
+		t.Fatalf("failed to insert fork: %v", err)
+	}
+	blockchain.Stop()
+
+	// Simulate a crash after the reorg onto fork #10 rewrote heights #6 and #7
+	WriteCanonicalHash(db, fork[0].Hash(), 6)
+	WriteCanonicalHash(db, fork[1].Hash(), 7)
+	WriteHeadBlockHash(db, fork[1].Hash())
+	writeImportJournal(db, &importEntry{Hash: fork[4].Hash(), Number: 10, ParentHash: fork[3].Hash(), PrevHead: canon[9].Hash(), Stage: importWriting})
+
+	blockchain = reopenJournalTestChain(t, db, gspec)
+	defer blockchain.Stop()
+
+	if head := blockchain.CurrentBlock(); head.Hash() != canon[9].Hash() {
+		t.Errorf("head mismatch: have #%d [%x], want #10 [%x]", head.NumberU64(), head.Hash(), canon[9].Hash())
+	}
+	for i, block := range canon {
+		if hash := GetCanonicalHash(db, block.NumberU64()); hash != block.Hash() {
+			t.Errorf("canonical hash #%d mismatch: have %x, want %x", i+1, hash, block.Hash())
+		}
+	}
+}
+
+// Tests that after an unclean shutdown the head is moved back onto the last block
+// whose state was flushed in full, even if later states look present.
+func TestStateFlushRecovery(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := DefaultRPOWTestingGenesisBlock()
+	genesis := gspec.MustCommit(db)
+	engine := ethash.NewFakerUsechain(db)
+
+	blockchain, err := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	blocks, _ := GenerateChain(gspec.Config, genesis, engine, db, 5, func(i int, gen *BlockGen) {})
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Flush two states, but only mark the older one complete, then crash
+	triedb := blockchain.stateCache.TrieDB()
+	triedb.Commit(blocks[1].Root(), false)
+	triedb.Commit(blocks[3].Root(), false)
+	writeStateFlush(db, blocks[1].Hash(), 2)
+
+	blockchain, err = NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen blockchain: %v", err)
+	}
+	if head := blockchain.CurrentBlock(); head.Hash() != blocks[1].Hash() {
+		t.Errorf("head mismatch: have #%d [%x], want #2 [%x]", head.NumberU64(), head.Hash(), blocks[1].Hash())
+	}
+	// A clean shutdown marks the head state complete
+	if _, err := blockchain.InsertChain(blocks[2:]); err != nil {
+		t.Fatalf("failed to reinsert chain: %v", err)
+	}
+	blockchain.Stop()
+
+	blockchain, err = NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to reopen blockchain: %v", err)
+	}
+	defer blockchain.Stop()
+
+	if head := blockchain.CurrentBlock(); head.Hash() != blocks[4].Hash() {
+		t.Errorf("head mismatch: have #%d [%x], want #5 [%x]", head.NumberU64(), head.Hash(), blocks[4].Hash())
+	}
+}
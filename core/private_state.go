@@ -0,0 +1,131 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/params"
+)
+
+// PrivateTxPrefix marks the data of private transactions, which only carry the
+// commitment to their payload on chain. The payload itself is distributed off
+// chain to the nodes party to the transaction. The leading STOP opcode makes the
+// public execution of a private contract creation deploy an empty contract at
+// the address the private state creates the real one.
+var PrivateTxPrefix = []byte{0x00, 'p', 'r', 'v'}
+
+// PrivatePayloadSaltLength is the length of the random salt blinding the on
+// chain commitment to a private payload.
+const PrivatePayloadSaltLength = 32
+
+var (
+	errInvalidPrivateSalt   = errors.New("invalid private payload salt length")
+	errPrivateStateDisabled = errors.New("private state disabled")
+)
+
+// PrivateTxData assembles the transaction data referencing a private payload.
+func PrivateTxData(commitment common.Hash) []byte {
+	return append(common.CopyBytes(PrivateTxPrefix), commitment.Bytes()...)
+}
+
+// PrivateTxCommitment returns the payload commitment of a private transaction,
+// and whether the transaction is private at all.
+func PrivateTxCommitment(tx *types.Transaction) (common.Hash, bool) {
+	data := tx.Data()
+	if len(data) != len(PrivateTxPrefix)+common.HashLength || !bytes.HasPrefix(data, PrivateTxPrefix) {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data[len(PrivateTxPrefix):]), true
+}
+
+// ApplyPrivateTransaction executes the payload of a private transaction on the
+// private state. The execution doesn't pay for gas nor transfer value, those
+// are settled by the public part of the transaction, but is bounded by the gas
+// limit of the transaction. Private contracts only see the private state.
+func ApplyPrivateTransaction(config *params.ChainConfig, bc *BlockChain, header *types.Header, tx *types.Transaction, payload []byte, statedb *state.StateDB, cfg vm.Config) error {
+	msg, err := tx.AsMessage(types.MakeSigner(config, header.Number))
+	if err != nil {
+		return err
+	}
+	evm := vm.NewEVM(NewEVMContext(msg, header, bc, nil), statedb, config, cfg)
+	sender := vm.AccountRef(msg.From())
+
+	if msg.To() == nil {
+		// Create the contract at the address of its public placeholder
+		statedb.SetNonce(msg.From(), tx.Nonce())
+		_, _, _, err = evm.Create(sender, payload, msg.Gas(), new(big.Int))
+	} else {
+		_, _, err = evm.Call(sender, *msg.To(), payload, msg.Gas(), new(big.Int))
+	}
+	statedb.Finalise(true)
+	return err
+}
+
+// writePrivateState executes the private transactions of a block whose payload
+// is known locally on top of the private state of its parent, and records the
+// resulting private state root. Blocks written without the private state
+// enabled, or imported by fast sync, start from an empty private state.
+func (bc *BlockChain) writePrivateState(block *types.Block, receipts []*types.Receipt) error {
+	parent := GetPrivateStateRoot(bc.privateDb, block.ParentHash())
+	statedb, err := state.New(parent, bc.privateCache)
+	if err != nil {
+		return err
+	}
+	header := block.Header()
+	for i, tx := range block.Transactions() {
+		commitment, ok := PrivateTxCommitment(tx)
+		if !ok || (i < len(receipts) && receipts[i].Status == types.ReceiptStatusFailed) {
+			continue
+		}
+		payload := GetPrivatePayload(bc.privateDb, commitment)
+		if payload == nil {
+			continue // Not a party to the transaction
+		}
+		statedb.Prepare(tx.Hash(), block.Hash(), i)
+		if err := ApplyPrivateTransaction(bc.chainConfig, bc, header, tx, payload, statedb, bc.vmConfig); err != nil {
+			log.Debug("Private transaction failed", "hash", tx.Hash(), "err", err)
+		}
+	}
+	root, err := statedb.Commit(true)
+	if err != nil {
+		return err
+	}
+	// Private state is small and node specific, keep all of it on disk
+	if root != parent {
+		if err := bc.privateCache.TrieDB().Commit(root, false); err != nil {
+			return err
+		}
+	}
+	return WritePrivateStateRoot(bc.privateDb, block.Hash(), root)
+}
+
+// PrivateStateAt returns the private state after the given block.
+func (bc *BlockChain) PrivateStateAt(hash common.Hash) (*state.StateDB, error) {
+	if atomic.LoadUint32(&bc.privateState) == 0 {
+		return nil, errPrivateStateDisabled
+	}
+	return state.New(GetPrivateStateRoot(bc.privateDb, hash), bc.privateCache)
+}
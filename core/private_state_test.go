@@ -0,0 +1,130 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// Tests that private transactions execute their off-chain payload on the private
+// state of the nodes party to them, leaving only a placeholder on the public
+// state and nothing on the private state of other nodes.
+func TestPrivateState(t *testing.T) {
+	var (
+		db, _      = ethdb.NewMemDatabase()
+		private, _ = ethdb.NewMemDatabase()
+		key, _     = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender     = crypto.PubkeyToAddress(key.PublicKey)
+		price      = big.NewInt(1)
+	)
+	// The private contract stores 42 in slot 0 on creation, and 7 in slot 1 when
+	// called: SSTORE(0, 42) CODECOPY(0, 17, 6) RETURN(0, 6) | SSTORE(1, 7) STOP
+	create := []byte{0x60, 0x2a, 0x60, 0x00, 0x55, 0x60, 0x06, 0x60, 0x11, 0x60, 0x00, 0x39, 0x60, 0x06, 0x60, 0x00, 0xf3}
+	create = append(create, 0x60, 0x07, 0x60, 0x01, 0x55, 0x00)
+	call := []byte{0x01}
+
+	gspec := DefaultRPOWTestingGenesisBlock()
+	gspec.Alloc = GenesisAlloc{sender: {Balance: big.NewInt(1000000000)}}
+	genesis := gspec.MustCommit(db)
+	signer := types.NewEIP155Signer(gspec.Config.ChainId)
+	engine := ethash.NewFakerUsechain(db)
+
+	salt := make([]byte, PrivatePayloadSaltLength)
+	salt[0] = 0x01
+	if _, err := WritePrivatePayload(private, salt[:1], create); err != errInvalidPrivateSalt {
+		t.Fatalf("short salt error mismatch: have %v, want %v", err, errInvalidPrivateSalt)
+	}
+	createCommit, _ := WritePrivatePayload(private, salt, create)
+	callCommit, _ := WritePrivatePayload(private, salt, call)
+	if createCommit == crypto.Keccak256Hash(create) {
+		t.Fatalf("commitment not salted")
+	}
+	contract := crypto.CreateAddress(sender, 0)
+
+	chain, receipts := GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, gen *BlockGen) {
+		tx1, _ := types.SignTx(types.NewContractCreation(0, new(big.Int), 200000, price, PrivateTxData(createCommit)), signer, key)
+		tx2, _ := types.SignTx(types.NewTransaction(1, contract, new(big.Int), 100000, price, PrivateTxData(callCommit)), signer, key)
+		gen.AddTx(tx1)
+		gen.AddTx(tx2)
+	})
+	for i, receipt := range receipts[0] {
+		if receipt.Status != types.ReceiptStatusSuccessful {
+			t.Fatalf("public part of private transaction %d failed", i)
+		}
+	}
+	blockchain, _ := NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	defer blockchain.Stop()
+	blockchain.SetPrivateState(private)
+
+	if _, err := blockchain.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	statedb, err := blockchain.PrivateStateAt(chain[0].Hash())
+	if err != nil {
+		t.Fatalf("failed to open private state: %v", err)
+	}
+	if code := statedb.GetCode(contract); len(code) != 6 {
+		t.Errorf("private code mismatch: have %x, want 6 bytes", code)
+	}
+	if have := statedb.GetState(contract, common.Hash{}); have != common.BigToHash(big.NewInt(42)) {
+		t.Errorf("private slot 0 mismatch: have %x, want 42", have)
+	}
+	if have := statedb.GetState(contract, common.BigToHash(big.NewInt(1))); have != common.BigToHash(big.NewInt(7)) {
+		t.Errorf("private slot 1 mismatch: have %x, want 7", have)
+	}
+	// Nothing private may end up in the chain database, which is shared
+	for _, key := range db.Keys() {
+		if bytes.HasPrefix(key, privatePayloadPrefix) || bytes.HasPrefix(key, privateRootPrefix) {
+			t.Errorf("private data in chain database: %x", key)
+		}
+	}
+	if have := GetPrivatePayload(private, callCommit); !bytes.Equal(have, call) {
+		t.Errorf("private payload mismatch: have %x, want %x", have, call)
+	}
+	public, _ := blockchain.State()
+	if code := public.GetCode(contract); len(code) != 0 {
+		t.Errorf("public code mismatch: have %x, want none", code)
+	}
+	// A node without the payloads only sees the public placeholder
+	otherdb, _ := ethdb.NewMemDatabase()
+	otherPrivate, _ := ethdb.NewMemDatabase()
+	gspec.MustCommit(otherdb)
+
+	other, _ := NewBlockChain(otherdb, nil, gspec.Config, ethash.NewFakerUsechain(otherdb), vm.Config{})
+	defer other.Stop()
+	other.SetPrivateState(otherPrivate)
+
+	if _, err := other.InsertChain(chain); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	if root := GetPrivateStateRoot(otherPrivate, chain[0].Hash()); root != types.EmptyRootHash {
+		t.Errorf("private state root mismatch: have %x, want empty", root)
+	}
+	statedb, _ = other.PrivateStateAt(chain[0].Hash())
+	if statedb.Exist(contract) {
+		t.Errorf("private contract exists without its payload")
+	}
+}
@@ -69,6 +69,7 @@ type Trie interface {
 	NodeIterator(startKey []byte) trie.NodeIterator
 	GetKey([]byte) []byte // TODO(fjl): remove this when SecureTrie is removed
 	Prove(key []byte, fromLevel uint, proofDb ethdb.Putter) error
+	ProveBatch(keys [][]byte, fromLevel uint, proofDb ethdb.Putter) error
 }
 
 // NewDatabase creates a backing store for state. The returned database is safe for
@@ -85,21 +86,34 @@ func NewDatabase(db ethdb.Database) Database {
 
 type cachingDB struct {
 	db            *trie.Database
-	mu            sync.Mutex
-	pastTries     []*trie.SecureTrie
+	mu            sync.RWMutex
+	pastTries     []pastTrie
 	codeSizeCache *lru.Cache
 }
 
+// pastTrie is a committed account trie retained for reuse, along with its root
+// hash so lookups don't need to touch (and rehash) the trie itself.
+type pastTrie struct {
+	root common.Hash
+	trie *trie.SecureTrie
+}
+
 // OpenTrie opens the main account trie.
+//
+// Opening a recent state only takes a shared lock, so any number of readers (e.g.
+// RPC calls) may do so concurrently without blocking on each other, and only wait
+// for an importer briefly while it retains a newly committed trie.
 func (db *cachingDB) OpenTrie(root common.Hash) (Trie, error) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-
+	db.mu.RLock()
 	for i := len(db.pastTries) - 1; i >= 0; i-- {
-		if db.pastTries[i].Hash() == root {
-			return cachedTrie{db.pastTries[i].Copy(), db}, nil
+		if db.pastTries[i].root == root {
+			tr := db.pastTries[i].trie.Copy()
+			db.mu.RUnlock()
+			return cachedTrie{tr, db}, nil
 		}
 	}
+	db.mu.RUnlock()
+
 	tr, err := trie.NewSecure(root, db.db, MaxTrieCacheGen)
 	if err != nil {
 		return nil, err
@@ -107,15 +121,15 @@ func (db *cachingDB) OpenTrie(root common.Hash) (Trie, error) {
 	return cachedTrie{tr, db}, nil
 }
 
-func (db *cachingDB) pushTrie(t *trie.SecureTrie) {
+func (db *cachingDB) pushTrie(root common.Hash, t *trie.SecureTrie) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
 	if len(db.pastTries) >= maxPastTries {
 		copy(db.pastTries, db.pastTries[1:])
-		db.pastTries[len(db.pastTries)-1] = t
+		db.pastTries[len(db.pastTries)-1] = pastTrie{root, t}
 	} else {
-		db.pastTries = append(db.pastTries, t)
+		db.pastTries = append(db.pastTries, pastTrie{root, t})
 	}
 }
 
@@ -171,7 +185,8 @@ type cachedTrie struct {
 func (m cachedTrie) Commit(onleaf trie.LeafCallback) (common.Hash, error) {
 	root, err := m.SecureTrie.Commit(onleaf)
 	if err == nil {
-		m.db.pushTrie(m.SecureTrie)
+		// Retain an independent copy, the committer may keep modifying its own
+		m.db.pushTrie(root, m.SecureTrie.Copy())
 	}
 	return root, err
 }
@@ -75,6 +75,10 @@ type (
 		prev      bool
 		prevDirty bool
 	}
+	transientStorageChange struct {
+		account       *common.Address
+		key, prevalue common.Hash
+	}
 )
 
 func (ch createObjectChange) undo(s *StateDB) {
@@ -138,3 +142,7 @@ func (ch addLogChange) undo(s *StateDB) {
 func (ch addPreimageChange) undo(s *StateDB) {
 	delete(s.preimages, ch.hash)
 }
+
+func (ch transientStorageChange) undo(s *StateDB) {
+	s.setTransientState(*ch.account, ch.key, ch.prevalue)
+}
@@ -0,0 +1,109 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/trie"
+)
+
+// ProveAccounts writes a single merkle proof of the given accounts, and of the
+// listed storage slots of each, into proofDb. Nodes shared by several paths are
+// only written once. The state must not hold uncommitted modifications, as the
+// proof is constructed against the tries.
+func (self *StateDB) ProveAccounts(slots map[common.Address][]common.Hash, proofDb ethdb.Putter) error {
+	addrs := make([]common.Address, 0, len(slots))
+	for addr := range slots {
+		addrs = append(addrs, addr)
+	}
+	sort.Slice(addrs, func(i, j int) bool { return bytes.Compare(addrs[i][:], addrs[j][:]) < 0 })
+
+	keys := make([][]byte, len(addrs))
+	for i, addr := range addrs {
+		keys[i] = crypto.Keccak256(addr[:])
+	}
+	if err := self.trie.ProveBatch(keys, 0, proofDb); err != nil {
+		return err
+	}
+	for _, addr := range addrs {
+		if len(slots[addr]) == 0 {
+			continue
+		}
+		// Missing accounts have no storage, the account proof covers them
+		tr := self.StorageTrie(addr)
+		if tr == nil {
+			continue
+		}
+		keys := make([][]byte, len(slots[addr]))
+		for i, slot := range slots[addr] {
+			keys[i] = crypto.Keccak256(slot[:])
+		}
+		if err := tr.ProveBatch(keys, 0, proofDb); err != nil {
+			return err
+		}
+	}
+	return self.Error()
+}
+
+// VerifyAccountProof checks the proof of an account and of some of its storage
+// slots against a state root, as constructed by ProveAccounts. The returned
+// account is nil if the proof shows it doesn't exist, the storage values are in
+// the order of the slots.
+func VerifyAccountProof(root common.Hash, addr common.Address, slots []common.Hash, proofDb trie.DatabaseReader) (*Account, []common.Hash, error) {
+	enc, err, _ := trie.VerifyProof(root, crypto.Keccak256(addr[:]), proofDb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("account %x: %v", addr, err)
+	}
+	values := make([]common.Hash, len(slots))
+	if enc == nil {
+		return nil, values, nil
+	}
+	account := new(Account)
+	if err := rlp.DecodeBytes(enc, account); err != nil {
+		return nil, nil, fmt.Errorf("account %x: %v", addr, err)
+	}
+	// Empty storage has no nodes to prove, every slot is zero
+	if len(slots) == 0 || account.Root == emptyRoot {
+		return account, values, nil
+	}
+	keys := make([][]byte, len(slots))
+	for i, slot := range slots {
+		keys[i] = crypto.Keccak256(slot[:])
+	}
+	blobs, err := trie.VerifyProofBatch(account.Root, keys, proofDb)
+	if err != nil {
+		return nil, nil, fmt.Errorf("account %x storage: %v", addr, err)
+	}
+	for i, blob := range blobs {
+		if blob == nil {
+			continue
+		}
+		_, content, _, err := rlp.Split(blob)
+		if err != nil {
+			return nil, nil, fmt.Errorf("account %x slot %x: %v", addr, slots[i], err)
+		}
+		values[i] = common.BytesToHash(content)
+	}
+	return account, values, nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// Tests that a batched proof of several accounts and their storage verifies,
+// including accounts and slots proven absent.
+func TestProveAccounts(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	for i := byte(1); i < 100; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)))
+		state.SetNonce(addr, uint64(i))
+		state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i, i}))
+	}
+	eoa := common.HexToAddress("0xbeef")
+	state.AddBalance(eoa, big.NewInt(1))
+	root, _ := state.Commit(false)
+	state.Database().TrieDB().Commit(root, false)
+	state, _ = New(root, NewDatabase(db))
+
+	var (
+		present = common.BytesToAddress([]byte{7})
+		other   = common.BytesToAddress([]byte{42})
+		missing = common.HexToAddress("0xdead")
+	)
+	slots := map[common.Address][]common.Hash{
+		present: {common.BytesToHash([]byte{7}), common.BytesToHash([]byte{8})},
+		other:   {common.BytesToHash([]byte{42})},
+		missing: {common.BytesToHash([]byte{1})},
+		eoa:     {common.BytesToHash([]byte{1})},
+	}
+	proof, _ := ethdb.NewMemDatabase()
+	if err := state.ProveAccounts(slots, proof); err != nil {
+		t.Fatalf("failed to prove accounts: %v", err)
+	}
+	account, values, err := VerifyAccountProof(root, present, slots[present], proof)
+	if err != nil {
+		t.Fatalf("failed to verify proof: %v", err)
+	}
+	if account == nil || account.Nonce != 7 || account.Balance.Cmp(big.NewInt(7)) != 0 {
+		t.Fatalf("account mismatch: have %+v", account)
+	}
+	if values[0] != common.BytesToHash([]byte{7, 7}) || values[1] != (common.Hash{}) {
+		t.Errorf("storage mismatch: have %x", values)
+	}
+	if _, values, err = VerifyAccountProof(root, other, slots[other], proof); err != nil || values[0] != common.BytesToHash([]byte{42, 42}) {
+		t.Errorf("other account mismatch: have %x, %v", values, err)
+	}
+	if account, _, err = VerifyAccountProof(root, missing, slots[missing], proof); err != nil || account != nil {
+		t.Errorf("missing account mismatch: have %+v, %v", account, err)
+	}
+	// Accounts without storage have no storage nodes in the proof
+	if account, values, err = VerifyAccountProof(root, eoa, slots[eoa], proof); err != nil || account == nil || values[0] != (common.Hash{}) {
+		t.Errorf("storageless account mismatch: have %+v, %x, %v", account, values, err)
+	}
+	// Accounts outside of the proof can't be verified
+	if _, _, err = VerifyAccountProof(root, common.BytesToAddress([]byte{99}), nil, proof); err == nil {
+		t.Errorf("unproven account verified")
+	}
+}
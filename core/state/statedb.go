@@ -45,12 +45,24 @@ type revision struct {
 	journalIndex int
 }
 
+// BalanceDiff is the net balance change of an account over a transaction, or
+// over the block finalisation if no transaction was being processed.
+type BalanceDiff struct {
+	TxHash  common.Hash
+	Address common.Address
+	Prev    *big.Int
+	New     *big.Int
+}
+
 var (
 	// emptyState is the known hash of an empty state trie entry.
 	emptyState = crypto.Keccak256Hash(nil)
 
 	// emptyCode is the known hash of the empty EVM bytecode.
 	emptyCode = crypto.Keccak256Hash(nil)
+
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
 )
 
 const (
@@ -87,6 +99,14 @@ type    StateDB struct {
 
 	preimages map[common.Hash][]byte
 
+	// Net balance changes of every finalised transaction, in execution order,
+	// only recorded if requested by the block importer.
+	balanceDiffs     []BalanceDiff
+	balanceRecording bool
+
+	// Transient storage, discarded at the end of every transaction.
+	transientStorage map[common.Address]Storage
+
 	// Journal of state modifications. This is the backbone of
 	// Snapshot and RevertToSnapshot.
 	journal        journal
@@ -109,6 +129,7 @@ func New(root common.Hash, db Database) (*StateDB, error) {
 		stateObjectsDirty: make(map[common.Address]struct{}),
 		logs:              make(map[common.Hash][]*types.Log),
 		preimages:         make(map[common.Hash][]byte),
+		transientStorage:  make(map[common.Address]Storage),
 	}, nil
 }
 
@@ -139,6 +160,8 @@ func (self *StateDB) Reset(root common.Hash) error {
 	self.logs = make(map[common.Hash][]*types.Log)
 	self.logSize = 0
 	self.preimages = make(map[common.Hash][]byte)
+	self.transientStorage = make(map[common.Address]Storage)
+	self.balanceDiffs = nil
 	self.clearJournalAndRefund()
 	return nil
 }
@@ -330,6 +353,42 @@ func (self *StateDB) SetState(addr common.Address, key common.Hash, value common
 	}
 }
 
+// GetTransientState retrieves a value from the transient storage of an account.
+func (self *StateDB) GetTransientState(addr common.Address, key common.Hash) common.Hash {
+	return self.transientStorage[addr][key]
+}
+
+// SetTransientState sets a value in the transient storage of an account. The
+// change is journalled, so it is rolled back with the call that made it.
+func (self *StateDB) SetTransientState(addr common.Address, key, value common.Hash) {
+	prev := self.GetTransientState(addr, key)
+	if prev == value {
+		return
+	}
+	self.journal = append(self.journal, transientStorageChange{
+		account:  &addr,
+		key:      key,
+		prevalue: prev,
+	})
+	self.setTransientState(addr, key, value)
+}
+
+// ClearTransientStorage discards the transient storage of all accounts. It is
+// called at the end of every message, the values never outliving the
+// transaction which set them.
+func (self *StateDB) ClearTransientStorage() {
+	self.transientStorage = make(map[common.Address]Storage)
+}
+
+func (self *StateDB) setTransientState(addr common.Address, key, value common.Hash) {
+	storage, ok := self.transientStorage[addr]
+	if !ok {
+		storage = make(Storage)
+		self.transientStorage[addr] = storage
+	}
+	storage[key] = value
+}
+
 // Suicide marks the given account as suicided.
 // This clears the account balance.
 //
@@ -487,8 +546,11 @@ func (self *StateDB) Copy() *StateDB {
 		logs:              make(map[common.Hash][]*types.Log, len(self.logs)),
 		logSize:           self.logSize,
 		preimages:         make(map[common.Hash][]byte),
+		transientStorage:  make(map[common.Address]Storage, len(self.transientStorage)),
+		balanceDiffs:      append([]BalanceDiff(nil), self.balanceDiffs...),
+		balanceRecording:  self.balanceRecording,
 	}
-	// Copy the dirty states, logs, and preimages
+	// Copy the dirty states, logs, preimages and transient storage
 	for addr := range self.stateObjectsDirty {
 		state.stateObjects[addr] = self.stateObjects[addr].deepCopy(state, state.MarkStateObjectDirty)
 		state.stateObjectsDirty[addr] = struct{}{}
@@ -500,6 +562,9 @@ func (self *StateDB) Copy() *StateDB {
 	for hash, preimage := range self.preimages {
 		state.preimages[hash] = preimage
 	}
+	for addr, storage := range self.transientStorage {
+		state.transientStorage[addr] = storage.Copy()
+	}
 	return state
 }
 
@@ -670,6 +735,10 @@ func (self *StateDB) GetRefund() uint64 {
 // Finalise finalises the state by removing the self destructed objects
 // and clears the journal as well as the refunds.
 func (s *StateDB) Finalise(deleteEmptyObjects bool) {
+	if s.balanceRecording {
+		s.recordBalanceDiffs()
+	}
+
 	for addr := range s.stateObjectsDirty {
 		stateObject := s.stateObjects[addr]
 		if stateObject.suicided || (deleteEmptyObjects && stateObject.empty()) {
@@ -683,6 +752,66 @@ func (s *StateDB) Finalise(deleteEmptyObjects bool) {
 	s.clearJournalAndRefund()
 }
 
+// recordBalanceDiffs derives the net balance change of every account touched
+// since the journal was last cleared, attributing them to the current tx.
+func (s *StateDB) recordBalanceDiffs() {
+	var (
+		order []common.Address
+		prevs = make(map[common.Address]*big.Int)
+	)
+	for _, entry := range s.journal {
+		var (
+			addr common.Address
+			prev *big.Int
+		)
+		switch change := entry.(type) {
+		case balanceChange:
+			addr, prev = *change.account, change.prev
+		case suicideChange:
+			addr, prev = *change.account, change.prevbalance
+		default:
+			continue
+		}
+		if _, ok := prevs[addr]; !ok {
+			prevs[addr] = prev
+			order = append(order, addr)
+		}
+	}
+	for _, addr := range order {
+		if balance := s.GetBalance(addr); balance.Cmp(prevs[addr]) != 0 {
+			s.balanceDiffs = append(s.balanceDiffs, BalanceDiff{
+				TxHash:  s.thash,
+				Address: addr,
+				Prev:    new(big.Int).Set(prevs[addr]),
+				New:     new(big.Int).Set(balance),
+			})
+		}
+	}
+}
+
+// RecordBalanceDiffs enables recording the net balance changes of the
+// transactions finalised from now on. It is off by default, the diffs only being
+// needed by the importer of a block, not by the many short lived states.
+func (s *StateDB) RecordBalanceDiffs() {
+	s.balanceRecording = true
+}
+
+// BalanceDiffs returns the net balance changes of the transactions finalised on
+// top of the state since recording was enabled, in execution order.
+func (s *StateDB) BalanceDiffs() []BalanceDiff {
+	return s.balanceDiffs
+}
+
+// DirtyAddresses returns the accounts modified since the state was last
+// committed, i.e. every account touched by the transactions processed on top.
+func (s *StateDB) DirtyAddresses() []common.Address {
+	addrs := make([]common.Address, 0, len(s.stateObjectsDirty))
+	for addr := range s.stateObjectsDirty {
+		addrs = append(addrs, addr)
+	}
+	return addrs
+}
+
 // IntermediateRoot computes the current root hash of the state trie.
 // It is called in between transactions to get the root hash that
 // goes into transaction receipts.
@@ -168,6 +168,75 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+// Tests that recently committed states can be opened and read concurrently
+// while an importer keeps committing new states on top of them.
+func TestConcurrentReads(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	sdb := NewDatabase(db)
+
+	var (
+		addr  = common.BytesToAddress([]byte{0x01})
+		slot  = common.BytesToHash([]byte{0x02})
+		roots = make(chan common.Hash, 64)
+	)
+	// Commit a chain of states, each holding its own index
+	go func() {
+		defer close(roots)
+
+		var parent common.Hash
+		for i := int64(1); i <= 64; i++ {
+			state, err := New(parent, sdb)
+			if err != nil {
+				t.Errorf("state %d: failed to open parent: %v", i, err)
+				return
+			}
+			state.SetBalance(addr, big.NewInt(i))
+			state.SetState(addr, slot, common.BigToHash(big.NewInt(i)))
+
+			if parent, err = state.Commit(false); err != nil {
+				t.Errorf("state %d: failed to commit: %v", i, err)
+				return
+			}
+			sdb.TrieDB().Reference(parent, common.Hash{})
+			roots <- parent
+		}
+	}()
+	// Read every committed state from multiple goroutines concurrently
+	var (
+		errs  = make(chan error)
+		index int64
+	)
+	for root := range roots {
+		index++
+		for j := 0; j < 4; j++ {
+			go func(root common.Hash, want int64) {
+				state, err := New(root, sdb)
+				if err != nil {
+					errs <- fmt.Errorf("state %d: failed to open: %v", want, err)
+					return
+				}
+				if balance := state.GetBalance(addr); balance.Int64() != want {
+					errs <- fmt.Errorf("state %d: balance mismatch: have %v, want %d", want, balance, want)
+					return
+				}
+				if value := state.GetState(addr, slot); value.Big().Int64() != want {
+					errs <- fmt.Errorf("state %d: storage mismatch: have %x, want %d", want, value, want)
+					return
+				}
+				errs <- nil
+			}(root, index)
+		}
+	}
+	for i := int64(0); i < 4*index; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	if index != 64 {
+		t.Fatalf("committed state count mismatch: have %d, want %d", index, 64)
+	}
+}
+
 func TestSnapshotRandom(t *testing.T) {
 	config := &quick.Config{MaxCount: 1000}
 	err := quick.Check((*snapshotTest).run, config)
@@ -421,3 +490,65 @@ func (s *StateSuite) TestTouchDelete(c *check.C) {
 		c.Fatal("expected no dirty state object")
 	}
 }
+
+// Tests that transient storage is reverted along with the snapshots, copied
+// with the state and discarded at the end of the transaction.
+func TestTransientStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	addr := toAddr([]byte("transient"))
+	key, one, two := common.Hash{1}, common.Hash{1}, common.Hash{2}
+
+	state.SetTransientState(addr, key, one)
+	id := state.Snapshot()
+	state.SetTransientState(addr, key, two)
+	if have := state.GetTransientState(addr, key); have != two {
+		t.Fatalf("value mismatch: have %x, want %x", have, two)
+	}
+	state.RevertToSnapshot(id)
+	if have := state.GetTransientState(addr, key); have != one {
+		t.Fatalf("value not reverted: have %x, want %x", have, one)
+	}
+	cpy := state.Copy()
+	cpy.SetTransientState(addr, key, two)
+	if have := state.GetTransientState(addr, key); have != one {
+		t.Fatalf("copy modified original: have %x, want %x", have, one)
+	}
+	state.ClearTransientStorage()
+	if have := state.GetTransientState(addr, key); have != (common.Hash{}) {
+		t.Fatalf("value kept across transactions: %x", have)
+	}
+	if state.Exist(addr) {
+		t.Fatalf("transient storage created an account")
+	}
+}
+
+// Tests that balance changes are only recorded once requested, and that copies
+// keep recording on their own.
+func TestBalanceDiffsRecording(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	addr := toAddr([]byte("balance"))
+	state.AddBalance(addr, big.NewInt(1))
+	state.Finalise(true)
+	if diffs := state.BalanceDiffs(); len(diffs) != 0 {
+		t.Fatalf("diffs recorded without request: %v", diffs)
+	}
+	state.RecordBalanceDiffs()
+	state.AddBalance(addr, big.NewInt(2))
+	state.Finalise(true)
+	if diffs := state.BalanceDiffs(); len(diffs) != 1 || diffs[0].Prev.Int64() != 1 || diffs[0].New.Int64() != 3 {
+		t.Fatalf("diff mismatch: have %v, want 1 -> 3", diffs)
+	}
+	cpy := state.Copy()
+	cpy.AddBalance(addr, big.NewInt(4))
+	cpy.Finalise(true)
+	if len(cpy.BalanceDiffs()) != 2 {
+		t.Errorf("copy diff count mismatch: have %d, want 2", len(cpy.BalanceDiffs()))
+	}
+	if len(state.BalanceDiffs()) != 1 {
+		t.Errorf("copy modified original: have %d diffs, want 1", len(state.BalanceDiffs()))
+	}
+}
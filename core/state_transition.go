@@ -109,6 +109,12 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 	return gas, nil
 }
 
+// InitCodeGas computes the per-word gas charged for contract creation initcode
+// once the code size fork is active.
+func InitCodeGas(code []byte) uint64 {
+	return (uint64(len(code)) + 31) / 32 * params.InitCodeWordGas
+}
+
 // NewStateTransition initialises and returns a new state transition object.
 func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition {
 	return &StateTransition{
@@ -213,6 +219,10 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	if err = st.preCheck(); err != nil {
 		return
 	}
+	// Transient storage only lives for the duration of the message, whichever
+	// way it ends and whoever executes it
+	defer st.state.ClearTransientStorage()
+
 	msg := st.msg
 	sender := st.from() // err checked in preCheck
 
@@ -262,6 +272,17 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	if err = st.useGas(gas); err != nil {
 		return nil, 0, false, err
 	}
+	// From the code size fork creation initcode is limited and charged per word
+	if contractCreation {
+		if limit := st.evm.ChainConfig().MaxInitCodeSizeAt(st.evm.BlockNumber); limit > 0 {
+			if len(st.data) > limit {
+				return nil, 0, false, ErrMaxInitCodeSizeExceeded
+			}
+			if err = st.useGas(InitCodeGas(st.data)); err != nil {
+				return nil, 0, false, err
+			}
+		}
+	}
 
 	var (
 		evm = st.evm
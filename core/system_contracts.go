@@ -0,0 +1,70 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/params"
+)
+
+// SystemContractError is returned when a system contract referenced by the
+// chain configuration is missing from state or carries unexpected code.
+type SystemContractError struct {
+	Name     string
+	Address  common.Address
+	Expected common.Hash
+	Have     common.Hash // zero if the contract has no code at all
+}
+
+func (e *SystemContractError) Error() string {
+	if e.Have == (common.Hash{}) {
+		return fmt.Sprintf("system contract %s missing at %x", e.Name, e.Address)
+	}
+	return fmt.Sprintf("system contract %s at %x has code hash %x, want %x", e.Name, e.Address, e.Have, e.Expected)
+}
+
+// ValidateSystemContracts checks that the system contracts pinned by the chain
+// configuration are deployed in the given state with the expected code. Chains
+// whose configuration doesn't reference any system contracts always pass.
+func ValidateSystemContracts(config *params.ChainConfig, statedb *state.StateDB) error {
+	if config == nil || config.SystemContracts == nil {
+		return nil
+	}
+	contracts := []struct {
+		name    string
+		address common.Address
+		hash    common.Hash
+	}{
+		{"identity registry", identityContract, config.SystemContracts.IdentityCodeHash},
+		{"miner list", common.HexToAddress(minerlist.MinerListContract), config.SystemContracts.MinerListCodeHash},
+	}
+	for _, c := range contracts {
+		code := statedb.GetCode(c.address)
+		if len(code) == 0 {
+			return &SystemContractError{Name: c.name, Address: c.address, Expected: c.hash}
+		}
+		if have := crypto.Keccak256Hash(code); c.hash != (common.Hash{}) && have != c.hash {
+			return &SystemContractError{Name: c.name, Address: c.address, Expected: c.hash, Have: have}
+		}
+	}
+	return nil
+}
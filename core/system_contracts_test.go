@@ -0,0 +1,108 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/params"
+)
+
+func TestMoonetSystemContracts(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	if _, err := DefaultMoonetGenesisBlock().Commit(db); err != nil {
+		t.Fatalf("failed to commit moonet genesis: %v", err)
+	}
+}
+
+func TestValidateSystemContracts(t *testing.T) {
+	var (
+		identityCode = []byte{0x60, 0x01}
+		minerCode    = []byte{0x60, 0x02}
+		minerAddr    = common.HexToAddress(minerlist.MinerListContract)
+	)
+	tests := []struct {
+		name      string
+		contracts *params.SystemContractsConfig
+		alloc     GenesisAlloc
+		wantErr   bool
+	}{
+		{
+			name:  "not configured",
+			alloc: GenesisAlloc{},
+		},
+		{
+			name:      "any code",
+			contracts: &params.SystemContractsConfig{},
+			alloc: GenesisAlloc{
+				identityContract: {Code: identityCode, Balance: common.Big0},
+				minerAddr:        {Code: minerCode, Balance: common.Big0},
+			},
+		},
+		{
+			name: "pinned code",
+			contracts: &params.SystemContractsConfig{
+				IdentityCodeHash:  crypto.Keccak256Hash(identityCode),
+				MinerListCodeHash: crypto.Keccak256Hash(minerCode),
+			},
+			alloc: GenesisAlloc{
+				identityContract: {Code: identityCode, Balance: common.Big0},
+				minerAddr:        {Code: minerCode, Balance: common.Big0},
+			},
+		},
+		{
+			name:      "missing miner list",
+			contracts: &params.SystemContractsConfig{},
+			alloc: GenesisAlloc{
+				identityContract: {Code: identityCode, Balance: common.Big0},
+			},
+			wantErr: true,
+		},
+		{
+			name: "mismatching identity registry",
+			contracts: &params.SystemContractsConfig{
+				IdentityCodeHash: crypto.Keccak256Hash(minerCode),
+			},
+			alloc: GenesisAlloc{
+				identityContract: {Code: identityCode, Balance: common.Big0},
+				minerAddr:        {Code: minerCode, Balance: common.Big0},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		genesis := &Genesis{
+			Config: &params.ChainConfig{ChainId: common.Big1, SystemContracts: tt.contracts},
+			Alloc:  tt.alloc,
+		}
+		_, err := genesis.Commit(db)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got none", tt.name)
+			} else if GetCanonicalHash(db, 0) != (common.Hash{}) {
+				t.Errorf("%s: genesis written despite invalid system contracts", tt.name)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+	}
+}
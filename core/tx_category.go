@@ -0,0 +1,89 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/contracts/delegation"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+)
+
+var (
+	// identityContract is the address of the identity (authentication) system contract.
+	identityContract = common.HexToAddress(common.AuthenticationContractAddressString)
+
+	// governanceContracts are the system contracts whose every call is a
+	// governance operation.
+	governanceContracts = map[common.Address]bool{
+		common.HexToAddress(minerlist.MinerListContract): true,
+	}
+
+	// governanceMethods are the identity contract methods managing the committee
+	// rather than user identities.
+	governanceMethods = map[[4]byte]bool{
+		methodSelector("addCommittee(address,string)"): true,
+		methodSelector("removeCommittee(address)"):     true,
+	}
+)
+
+// methodSelector returns the 4 byte ABI selector of a method signature.
+func methodSelector(signature string) (selector [4]byte) {
+	copy(selector[:], crypto.Keccak256([]byte(signature))[:4])
+	return selector
+}
+
+// ClassifyTransaction labels a transaction with the category of activity it
+// represents. Whether the recipient has code is needed to tell plain transfers
+// apart from contract calls, so it is best evaluated before execution.
+func ClassifyTransaction(tx *types.Transaction, recipientHasCode bool) types.TxCategory {
+	to := tx.To()
+	switch {
+	case to == nil:
+		return types.TxCategoryContractDeploy
+
+	case governanceContracts[*to]:
+		return types.TxCategoryGovernance
+
+	case *to == identityContract:
+		var selector [4]byte
+		if data := tx.Data(); len(data) >= 4 {
+			copy(selector[:], data[:4])
+		}
+		if governanceMethods[selector] {
+			return types.TxCategoryGovernance
+		}
+		return types.TxCategoryIdentity
+
+	case recipientHasCode, *to == delegation.Address:
+		return types.TxCategoryContractCall
+
+	default:
+		return types.TxCategoryTransfer
+	}
+}
+
+// IsSystemTransaction reports whether a transaction targets one of the Usechain
+// system contracts, i.e. whether it is an identity or governance transaction.
+func IsSystemTransaction(tx *types.Transaction) bool {
+	switch ClassifyTransaction(tx, false) {
+	case types.TxCategoryIdentity, types.TxCategoryGovernance:
+		return true
+	}
+	return false
+}
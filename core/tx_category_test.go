@@ -0,0 +1,91 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/contracts/minerlist"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// Tests that transactions are assigned the expected categories.
+func TestClassifyTransaction(t *testing.T) {
+	var (
+		user      = common.HexToAddress("0x0000000000000000000000000000000000001234")
+		miners    = common.HexToAddress(minerlist.MinerListContract)
+		addCommit = methodSelector("addCommittee(address,string)")
+		storeCert = methodSelector("storeMainUserCert(string,string,string)")
+	)
+	tests := []struct {
+		tx      *types.Transaction
+		hasCode bool
+		want    types.TxCategory
+	}{
+		{types.NewTransaction(0, user, big.NewInt(1), 21000, big.NewInt(1), nil), false, types.TxCategoryTransfer},
+		{types.NewTransaction(0, user, big.NewInt(0), 50000, big.NewInt(1), []byte{0x01}), true, types.TxCategoryContractCall},
+		{types.NewContractCreation(0, big.NewInt(0), 50000, big.NewInt(1), []byte{0x60}), false, types.TxCategoryContractDeploy},
+		{types.NewTransaction(0, identityContract, big.NewInt(0), 50000, big.NewInt(1), storeCert[:]), true, types.TxCategoryIdentity},
+		{types.NewTransaction(0, identityContract, big.NewInt(0), 50000, big.NewInt(1), addCommit[:]), true, types.TxCategoryGovernance},
+		{types.NewTransaction(0, miners, big.NewInt(0), 50000, big.NewInt(1), nil), true, types.TxCategoryGovernance},
+	}
+	for i, tt := range tests {
+		if have := ClassifyTransaction(tt.tx, tt.hasCode); have != tt.want {
+			t.Errorf("test %d: category mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that only identity and governance transactions are treated as system
+// transactions.
+func TestIsSystemTransaction(t *testing.T) {
+	var (
+		user      = common.HexToAddress("0x0000000000000000000000000000000000001234")
+		storeCert = methodSelector("storeMainUserCert(string,string,string)")
+	)
+	if IsSystemTransaction(types.NewTransaction(0, user, big.NewInt(1), 21000, big.NewInt(1), nil)) {
+		t.Errorf("plain transfer classified as system transaction")
+	}
+	if !IsSystemTransaction(types.NewTransaction(0, identityContract, big.NewInt(0), 50000, big.NewInt(1), storeCert[:])) {
+		t.Errorf("identity registration not classified as system transaction")
+	}
+	if !IsSystemTransaction(types.NewTransaction(0, common.HexToAddress(minerlist.MinerListContract), big.NewInt(0), 50000, big.NewInt(1), nil)) {
+		t.Errorf("miner list call not classified as system transaction")
+	}
+}
+
+// Tests transaction category storage and retrieval operations.
+func TestTxCategoryStorage(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	receipts := types.Receipts{
+		{TxHash: common.BytesToHash([]byte{0x01}), Category: types.TxCategoryIdentity},
+		{TxHash: common.BytesToHash([]byte{0x02}), Category: types.TxCategoryUnknown},
+	}
+	if err := WriteTxCategories(db, receipts); err != nil {
+		t.Fatalf("Failed to write categories into database: %v", err)
+	}
+	if category, ok := GetTxCategory(db, receipts[0].TxHash); !ok || category != types.TxCategoryIdentity {
+		t.Fatalf("Retrieved category mismatch: have %v (%v), want %v", category, ok, types.TxCategoryIdentity)
+	}
+	if category, ok := GetTxCategory(db, receipts[1].TxHash); ok {
+		t.Fatalf("Unknown category stored: %v", category)
+	}
+}
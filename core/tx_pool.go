@@ -17,6 +17,7 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math"
@@ -104,6 +105,33 @@ var (
 	// than some meaningful limit a user might use. This is not a consensus error
 	// making the transaction invalid, rather a DOS protection.
 	ErrOversizedData = errors.New("oversized data")
+
+	// ErrAccountQueueFull is returned if a remote transaction would be queued
+	// beyond the non-executable transaction slots permitted for its sender.
+	ErrAccountQueueFull = errors.New("account queue full")
+)
+
+// TxDropReason describes why a transaction was dropped from the pool without
+// being included in the chain.
+type TxDropReason string
+
+const (
+	DropUnderpriced TxDropReason = "underpriced" // Outpriced by others in a full pool, or below the price floor
+	DropReplaced    TxDropReason = "replaced"    // Superseded by a transaction with the same nonce
+	DropInvalidated TxDropReason = "invalidated" // Nonce consumed on chain by a different transaction
+	DropReorged     TxDropReason = "reorged"     // Included in a block reorged out, not valid anymore
+	DropUnpayable   TxDropReason = "unpayable"   // Balance or gas limit insufficient to ever execute
+	DropExpired     TxDropReason = "expired"     // Queued longer than the allowed lifetime
+	DropEvicted     TxDropReason = "evicted"     // Evicted to keep the pool within its limits
+)
+
+// QueueEvictionPolicy selects the accounts losing their queued transactions
+// first when the global queue limit is exceeded.
+type QueueEvictionPolicy string
+
+const (
+	QueueEvictHeartbeat QueueEvictionPolicy = "heartbeat" // Accounts ordered by their last heartbeat
+	QueueEvictLargest   QueueEvictionPolicy = "largest"   // Accounts with the most queued transactions first
 )
 
 var (
@@ -123,10 +151,19 @@ var (
 	queuedReplaceCounter   = metrics.NewRegisteredCounter("txpool/queued/replace", nil)
 	queuedRateLimitCounter = metrics.NewRegisteredCounter("txpool/queued/ratelimit", nil) // Dropped due to rate limiting
 	queuedNofundsCounter   = metrics.NewRegisteredCounter("txpool/queued/nofunds", nil)   // Dropped due to out-of-funds
+	queuedExpiredCounter   = metrics.NewRegisteredCounter("txpool/queued/expired", nil)   // Dropped due to the queue lifetimes
+	queuedCapCounter       = metrics.NewRegisteredCounter("txpool/queued/capped", nil)    // Rejected or dropped due to the per-account cap
+	queuedEvictCounter     = metrics.NewRegisteredCounter("txpool/queued/evicted", nil)   // Dropped due to the global queue limit
 
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+
+	// Metrics for the spam protection quotas
+	quotaSenderCounter   = metrics.NewRegisteredCounter("txpool/quota/sender", nil)   // Held back due to the per-sender pending limit
+	quotaReservedCounter = metrics.NewRegisteredCounter("txpool/quota/reserved", nil) // Dropped to free the slots reserved for locals
+	quotaNonceCounter    = metrics.NewRegisteredCounter("txpool/quota/nonce", nil)    // Rejected due to a too distant nonce
+	quotaSystemCounter   = metrics.NewRegisteredCounter("txpool/quota/system", nil)   // Denied the price exemption due to the system slot limits
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -178,7 +215,17 @@ type TxPoolConfig struct {
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
-	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
+	AccountPending uint64 // Maximum number of executable transaction slots per remote account (0 = unlimited)
+	LocalSlots     uint64 // Number of executable transaction slots reserved for local transactions
+	NonceDepth     uint64 // Maximum nonce distance of a remote transaction ahead of its account (0 = unlimited)
+
+	Lifetime      time.Duration       // Maximum amount of time non-executable transaction are queued
+	QueueLifetime time.Duration       // Maximum amount of time a single transaction is queued, even for active accounts (0 = unlimited)
+	QueueEviction QueueEvictionPolicy // Order of evicting accounts when the global queue limit is exceeded
+
+	SystemPriceExempt  bool   // Whether identity and governance transactions are exempt from the gas price limits
+	SystemSlots        uint64 // Maximum number of price exempted system transactions in the pool
+	AccountSystemSlots uint64 // Maximum number of price exempted system transactions per account
 }
 
 // DefaultTxPoolConfig contains the default configurations for the transaction
@@ -195,7 +242,11 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 
-	Lifetime: 3 * time.Hour,
+	Lifetime:      3 * time.Hour,
+	QueueEviction: QueueEvictHeartbeat,
+
+	SystemSlots:        256,
+	AccountSystemSlots: 16,
 }
 
 // sanitize checks the provided user configurations and changes anything that's
@@ -214,6 +265,32 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 		log.Warn("Sanitizing invalid txpool price bump", "provided", conf.PriceBump, "updated", DefaultTxPoolConfig.PriceBump)
 		conf.PriceBump = DefaultTxPoolConfig.PriceBump
 	}
+	if conf.AccountPending != 0 && conf.AccountPending < conf.AccountSlots {
+		log.Warn("Sanitizing invalid txpool account pending limit", "provided", conf.AccountPending, "updated", conf.AccountSlots)
+		conf.AccountPending = conf.AccountSlots
+	}
+	if conf.LocalSlots > conf.GlobalSlots {
+		log.Warn("Sanitizing invalid txpool local slots", "provided", conf.LocalSlots, "updated", conf.GlobalSlots)
+		conf.LocalSlots = conf.GlobalSlots
+	}
+	if conf.QueueEviction != QueueEvictHeartbeat && conf.QueueEviction != QueueEvictLargest {
+		if conf.QueueEviction != "" {
+			log.Warn("Sanitizing invalid txpool queue eviction policy", "provided", conf.QueueEviction, "updated", QueueEvictHeartbeat)
+		}
+		conf.QueueEviction = QueueEvictHeartbeat
+	}
+	if conf.SystemSlots < 1 {
+		log.Warn("Sanitizing invalid txpool system slots", "provided", conf.SystemSlots, "updated", DefaultTxPoolConfig.SystemSlots)
+		conf.SystemSlots = DefaultTxPoolConfig.SystemSlots
+	}
+	if conf.AccountSystemSlots < 1 {
+		log.Warn("Sanitizing invalid txpool account system slots", "provided", conf.AccountSystemSlots, "updated", DefaultTxPoolConfig.AccountSystemSlots)
+		conf.AccountSystemSlots = DefaultTxPoolConfig.AccountSystemSlots
+	}
+	if conf.AccountSystemSlots > conf.SystemSlots {
+		log.Warn("Sanitizing invalid txpool account system slots", "provided", conf.AccountSystemSlots, "updated", conf.SystemSlots)
+		conf.AccountSystemSlots = conf.SystemSlots
+	}
 	return conf
 }
 
@@ -230,6 +307,7 @@ type TxPool struct {
 	chain        blockChain
 	gasPrice     *big.Int
 	txFeed       event.Feed
+	dropFeed     event.Feed
 	scope        event.SubscriptionScope
 	chainHeadCh  chan ChainHeadEvent
 	chainHeadSub event.Subscription
@@ -246,8 +324,16 @@ type TxPool struct {
 	pending map[common.Address]*txList         // All currently processable transactions
 	queue   map[common.Address]*txList         // Queued but non-processable transactions
 	beats   map[common.Address]time.Time       // Last heartbeat from each known account
+	entered map[common.Hash]time.Time          // Time each queued transaction entered the queue
 	all     map[common.Hash]*types.Transaction // All transactions to allow lookups
 	priced  *txPricedList                      // All transactions sorted by price
+	mined   map[common.Hash]struct{}           // Transactions included by the head being reset to (nil = unknown)
+	exempt  map[common.Hash]common.Address     // System transactions admitted through the price exemption
+
+	drops    []TxDropEvent // Drop events not yet delivered to the subscribers
+	dropsMu  sync.Mutex    // Lock protecting the undelivered drop events
+	dropWake chan struct{} // Notification channel for new drop events
+	quit     chan struct{} // Channel stopping the drop event delivery
 
 	wg sync.WaitGroup // for shutdown sync
 
@@ -270,9 +356,13 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pending:        make(map[common.Address]*txList),
 		queue:          make(map[common.Address]*txList),
 		beats:          make(map[common.Address]time.Time),
+		entered:        make(map[common.Hash]time.Time),
 		all:            make(map[common.Hash]*types.Transaction),
+		exempt:         make(map[common.Hash]common.Address),
 		chainHeadCh:    make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:       new(big.Int).SetUint64(config.PriceLimit),
+		dropWake:       make(chan struct{}, 1),
+		quit:           make(chan struct{}),
 		accountManager: manager,
 	}
 	pool.locals = newAccountSet(pool.signer)
@@ -294,8 +384,9 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 	pool.chainHeadSub = pool.chain.SubscribeChainHeadEvent(pool.chainHeadCh)
 
 	// Start the event loop and return
-	pool.wg.Add(1)
+	pool.wg.Add(2)
 	go pool.loop()
+	go pool.dropLoop()
 
 	return pool
 }
@@ -364,6 +455,20 @@ func (pool *TxPool) loop() {
 				if time.Since(pool.beats[addr]) > pool.config.Lifetime {
 					for _, tx := range pool.queue[addr].Flatten() {
 						pool.removeTx(tx.Hash())
+						queuedExpiredCounter.Inc(1)
+						pool.dropped(tx, DropExpired, common.Hash{})
+					}
+					continue
+				}
+				// Active accounts may still hold transactions stuck behind a nonce
+				// gap, drop the ones queued for too long
+				if pool.config.QueueLifetime > 0 {
+					for _, tx := range pool.queue[addr].Flatten() {
+						if time.Since(pool.entered[tx.Hash()]) > pool.config.QueueLifetime {
+							pool.removeTx(tx.Hash())
+							queuedExpiredCounter.Inc(1)
+							pool.dropped(tx, DropExpired, common.Hash{})
+						}
 					}
 				}
 			}
@@ -439,8 +544,23 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 				}
 			}
 			reinject = types.TxDifference(discarded, included)
+
+			pool.mined = make(map[common.Hash]struct{}, len(included))
+			for _, tx := range included {
+				pool.mined[tx.Hash()] = struct{}{}
+			}
+		}
+	} else if oldHead != nil {
+		// Plain chain extension, only the new head's transactions got included
+		if block := pool.chain.GetBlock(newHead.Hash(), newHead.Number.Uint64()); block != nil {
+			pool.mined = make(map[common.Hash]struct{}, len(block.Transactions()))
+			for _, tx := range block.Transactions() {
+				pool.mined[tx.Hash()] = struct{}{}
+			}
 		}
 	}
+	defer func() { pool.mined = nil }()
+
 	// Initialize the internal state to the current head
 	if newHead == nil {
 		newHead = pool.chain.CurrentBlock().Header() // Special case during testing
@@ -456,7 +576,11 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
-	pool.addTxsLocked(reinject, false)
+	for i, err := range pool.addTxsLocked(reinject, false) {
+		if err != nil && pool.all[reinject[i].Hash()] == nil {
+			pool.dropped(reinject[i], DropReorged, common.Hash{})
+		}
+	}
 
 	// validate the pool of pending transactions, this will remove
 	// any transactions that have been included in the block or
@@ -481,6 +605,7 @@ func (pool *TxPool) Stop() {
 
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
+	close(pool.quit)
 	pool.wg.Wait()
 
 	if pool.journal != nil {
@@ -495,6 +620,12 @@ func (pool *TxPool) SubscribeTxPreEvent(ch chan<- TxPreEvent) event.Subscription
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxDropEvent registers a subscription of TxDropEvent and starts
+// sending event to the given channel.
+func (pool *TxPool) SubscribeTxDropEvent(ch chan<- TxDropEvent) event.Subscription {
+	return pool.scope.Track(pool.dropFeed.Subscribe(ch))
+}
+
 // GasPrice returns the current gas price enforced by the transaction pool.
 func (pool *TxPool) GasPrice() *big.Int {
 	pool.mu.RLock()
@@ -503,6 +634,12 @@ func (pool *TxPool) GasPrice() *big.Int {
 	return new(big.Int).Set(pool.gasPrice)
 }
 
+// PriceBump returns the minimum gas price increase, in percent, required for a
+// transaction to replace another one with the same nonce.
+func (pool *TxPool) PriceBump() uint64 {
+	return pool.config.PriceBump
+}
+
 // SetGasPrice updates the minimum price required by the transaction pool for a
 // new transaction, and drops all transactions below this threshold.
 func (pool *TxPool) SetGasPrice(price *big.Int) {
@@ -512,6 +649,7 @@ func (pool *TxPool) SetGasPrice(price *big.Int) {
 	pool.gasPrice = price
 	for _, tx := range pool.priced.Cap(price, pool.locals) {
 		pool.removeTx(tx.Hash())
+		pool.dropped(tx, DropUnderpriced, common.Hash{})
 	}
 	log.Info("Transaction pool price threshold updated", "price", price)
 }
@@ -564,6 +702,104 @@ func (pool *TxPool) Content() (map[common.Address]types.Transactions, map[common
 	return pending, queued
 }
 
+// TxPoolKey is a position in the content of the transaction pool, which lists
+// the pending transactions before the queued ones, ordered by sender and nonce.
+type TxPoolKey struct {
+	Queued bool
+	From   common.Address
+	Nonce  uint64
+}
+
+// ContentRange retrieves at most limit transactions of the pool starting at the
+// given position, split into pending and queued ones. The position of the first
+// transaction left out is returned, nil if the window reaches the end.
+func (pool *TxPool) ContentRange(start TxPoolKey, limit int) (types.Transactions, types.Transactions, *TxPoolKey) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pending := make([]common.Address, 0, len(pool.pending))
+	for addr := range pool.pending {
+		pending = append(pending, addr)
+	}
+	queued := make([]common.Address, 0, len(pool.queue))
+	for addr := range pool.queue {
+		queued = append(queued, addr)
+	}
+	return contentRange(start, limit, pending, queued, func(queued bool, addr common.Address) types.Transactions {
+		if queued {
+			return pool.queue[addr].Flatten()
+		}
+		return pool.pending[addr].Flatten()
+	})
+}
+
+// ContentRange cuts the window of at most limit transactions starting at the
+// given position out of the pending and queued transactions of a pool, grouped
+// by account and sorted by nonce.
+func ContentRange(pending, queued map[common.Address]types.Transactions, start TxPoolKey, limit int) (types.Transactions, types.Transactions, *TxPoolKey) {
+	var pendingAccounts, queuedAccounts []common.Address
+	for addr := range pending {
+		pendingAccounts = append(pendingAccounts, addr)
+	}
+	for addr := range queued {
+		queuedAccounts = append(queuedAccounts, addr)
+	}
+	return contentRange(start, limit, pendingAccounts, queuedAccounts, func(isQueued bool, addr common.Address) types.Transactions {
+		if isQueued {
+			return queued[addr]
+		}
+		return pending[addr]
+	})
+}
+
+// contentRange collects the window of a content range, only retrieving the
+// transactions of the accounts at or after the starting position.
+func contentRange(start TxPoolKey, limit int, pending, queued []common.Address, list func(bool, common.Address) types.Transactions) (types.Transactions, types.Transactions, *TxPoolKey) {
+	var (
+		window [2]types.Transactions
+		count  int
+	)
+	for i, accounts := range [][]common.Address{pending, queued} {
+		isQueued := i == 1
+		if start.Queued && !isQueued {
+			continue
+		}
+		from, nonce := start.From, start.Nonce
+		if start.Queued != isQueued {
+			from, nonce = common.Address{}, 0
+		}
+		following := make([]common.Address, 0, len(accounts))
+		for _, addr := range accounts {
+			if bytes.Compare(addr[:], from[:]) >= 0 {
+				following = append(following, addr)
+			}
+		}
+		sort.Slice(following, func(i, j int) bool { return bytes.Compare(following[i][:], following[j][:]) < 0 })
+
+		for _, addr := range following {
+			for _, tx := range list(isQueued, addr) {
+				if addr == from && tx.Nonce() < nonce {
+					continue
+				}
+				if count == limit {
+					return window[0], window[1], &TxPoolKey{Queued: isQueued, From: addr, Nonce: tx.Nonce()}
+				}
+				window[i] = append(window[i], tx)
+				count++
+			}
+		}
+	}
+	return window[0], window[1], nil
+}
+
+// Locals retrieves the accounts currently considered local by the pool.
+func (pool *TxPool) Locals() []common.Address {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	return pool.locals.flatten()
+}
+
 // Pending retrieves all currently processable transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
@@ -669,6 +905,37 @@ func checkMultiAccountSig(tx *types.Transaction, _db *state.StateDB, _addType in
 	return nil
 }
 
+// priceExempt reports whether the pool's policy allows a transaction to bypass
+// the gas price limits, which is the case for system transactions if enabled
+// and neither the pool nor the sender exhausted their exempted slots yet.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) priceExempt(from common.Address, tx *types.Transaction) bool {
+	if !pool.config.SystemPriceExempt || !IsSystemTransaction(tx) {
+		return false
+	}
+	// Forget about exempted transactions that left the pool in the meantime
+	var slots uint64
+	for hash, sender := range pool.exempt {
+		if pool.all[hash] == nil {
+			delete(pool.exempt, hash)
+			continue
+		}
+		if sender == from {
+			slots++
+		}
+	}
+	if uint64(len(pool.exempt)) >= pool.config.SystemSlots {
+		quotaSystemCounter.Inc(1)
+		return false
+	}
+	if slots >= pool.config.AccountSystemSlots {
+		quotaSystemCounter.Inc(1)
+		return false
+	}
+	return true
+}
+
 // validateTx checks whether a transaction is valid according to the consensus
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
@@ -727,7 +994,7 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
-	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 && !tx.IsAuthentication() {
+	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 && !tx.IsAuthentication() && !pool.priceExempt(from, tx) {
 		return ErrUnderpriced
 	}
 
@@ -735,6 +1002,16 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if pool.currentState.GetNonce(from) > tx.Nonce() {
 		return ErrNonceTooLow
 	}
+	// Don't let remote senders reserve queue slots arbitrarily far in the future
+	if !local && pool.config.NonceDepth > 0 && tx.Nonce() > pool.pendingState.GetNonce(from)+pool.config.NonceDepth {
+		quotaNonceCounter.Inc(1)
+		return ErrNonceTooHigh
+	}
+	// Don't accept remote transactions dropped right away by the account queue cap
+	if !local && pool.queueFull(from, tx) {
+		queuedCapCounter.Inc(1)
+		return ErrAccountQueueFull
+	}
 	// Transactor should have enough funds to cover the costs
 	// cost == V + GP * GL
 	if pool.currentState.GetBalance(from).Cmp(tx.Cost()) < 0 {
@@ -744,6 +1021,15 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	if err != nil {
 		return err
 	}
+	if tx.To() == nil {
+		next := new(big.Int).Add(pool.chain.CurrentBlock().Number(), common.Big1)
+		if limit := pool.chainconfig.MaxInitCodeSizeAt(next); limit > 0 {
+			if len(tx.Data()) > limit {
+				return ErrMaxInitCodeSizeExceeded
+			}
+			intrGas += InitCodeGas(tx.Data())
+		}
+	}
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
 	}
@@ -771,13 +1057,22 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
+	from, _ := types.Sender(pool.signer, tx) // already validated
+
+	// Remote transactions under the minimum price only got in through the system
+	// exemption, track them to enforce its slot limits
+	exempt := !local && !pool.locals.contains(from) && !tx.IsAuthentication() && pool.gasPrice.Cmp(tx.GasPrice()) > 0
+
 	// If the transaction pool is full, discard underpriced transactions
 	if uint64(len(pool.all)) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
 		if pool.priced.Underpriced(tx, pool.locals) {
-			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
-			underpricedTxCounter.Inc(1)
-			return false, ErrUnderpriced
+			if !pool.priceExempt(from, tx) {
+				log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
+				underpricedTxCounter.Inc(1)
+				return false, ErrUnderpriced
+			}
+			exempt = true
 		}
 		// New transaction is better than our worse ones, make room for it
 		drop := pool.priced.Discard(len(pool.all)-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
@@ -785,10 +1080,10 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
 			pool.removeTx(tx.Hash())
+			pool.dropped(tx, DropUnderpriced, common.Hash{})
 		}
 	}
 	// If the transaction is replacing an already pending one, do directly
-	from, _ := types.Sender(pool.signer, tx) // already validated
 	if list := pool.pending[from]; list != nil && list.Overlaps(tx) {
 		// Nonce already pending, check if required price bump is met
 		inserted, old := list.Add(tx, pool.config.PriceBump)
@@ -801,9 +1096,13 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			delete(pool.all, old.Hash())
 			pool.priced.Removed()
 			pendingReplaceCounter.Inc(1)
+			pool.dropped(old, DropReplaced, hash)
 		}
 		pool.all[tx.Hash()] = tx
 		pool.priced.Put(tx)
+		if exempt {
+			pool.exempt[hash] = from
+		}
 		pool.journalTx(from, tx)
 
 		log.Trace("Pooled new executable transaction", "hash", hash, "from", from, "to", tx.To())
@@ -863,6 +1162,9 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	if err != nil {
 		return false, err
 	}
+	if exempt {
+		pool.exempt[hash] = from
+	}
 	// Mark local addresses and journal local transactions
 	if local {
 		pool.locals.add(from)
@@ -891,14 +1193,43 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx *types.Transaction) (bool, er
 	// Discard any previous transaction and mark this
 	if old != nil {
 		delete(pool.all, old.Hash())
+		delete(pool.entered, old.Hash())
 		pool.priced.Removed()
 		queuedReplaceCounter.Inc(1)
+		pool.dropped(old, DropReplaced, hash)
 	}
 	pool.all[hash] = tx
 	pool.priced.Put(tx)
+	if _, ok := pool.entered[hash]; !ok {
+		pool.entered[hash] = time.Now()
+	}
 	return old != nil, nil
 }
 
+// queueFull reports whether a transaction would be dropped right away for
+// exceeding the queue cap of its sender: the non-executable part of the queue
+// is full and the transaction neither replaces a queued one nor precedes the
+// last of them.
+//
+// Note, this method assumes the pool lock is held!
+func (pool *TxPool) queueFull(from common.Address, tx *types.Transaction) bool {
+	list := pool.queue[from]
+	if list == nil || list.Overlaps(tx) {
+		return false
+	}
+	// Transactions executable right away leave the queue on promotion
+	start := pool.pendingState.GetNonce(from)
+	next := start
+	for list.txs.Get(next) != nil {
+		next++
+	}
+	if tx.Nonce() <= next || uint64(list.Len())-(next-start) < pool.config.AccountQueue {
+		return false
+	}
+	txs := list.Flatten()
+	return tx.Nonce() > txs[len(txs)-1].Nonce()
+}
+
 // journalTx adds the specified transaction to the local disk journal if it is
 // deemed to have been sent from a local account.
 func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
@@ -915,6 +1246,8 @@ func (pool *TxPool) journalTx(from common.Address, tx *types.Transaction) {
 //
 // Note, this method assumes the pool lock is held!
 func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.Transaction) {
+	delete(pool.entered, hash)
+
 	// Try to insert the transaction into the pending queue
 	if pool.pending[addr] == nil {
 		pool.pending[addr] = newTxList(true)
@@ -928,6 +1261,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingDiscardCounter.Inc(1)
+		pool.dropped(tx, DropReplaced, list.txs.Get(tx.Nonce()).Hash())
 		return
 	}
 	// Otherwise discard any previous transaction and mark this
@@ -936,6 +1270,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 		pool.priced.Removed()
 
 		pendingReplaceCounter.Inc(1)
+		pool.dropped(old, DropReplaced, hash)
 	}
 	// Failsafe to work around direct pending inserts (tests)
 	if pool.all[hash] == nil {
@@ -1071,6 +1406,7 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 
 	// Remove it from the list of known transactions
 	delete(pool.all, hash)
+	delete(pool.entered, hash)
 	pool.priced.Removed()
 
 	// Remove the transaction from the pending lists and reset the account nonce
@@ -1102,6 +1438,55 @@ func (pool *TxPool) removeTx(hash common.Hash) {
 	}
 }
 
+// dropped notifies any subsystems of a transaction leaving the pool without
+// being included in the chain.
+func (pool *TxPool) dropped(tx *types.Transaction, reason TxDropReason, replacement common.Hash) {
+	from, _ := types.Sender(pool.signer, tx) // already validated during insertion
+
+	pool.dropsMu.Lock()
+	pool.drops = append(pool.drops, TxDropEvent{Tx: tx, From: from, Reason: reason, Replacement: replacement})
+	pool.dropsMu.Unlock()
+
+	select {
+	case pool.dropWake <- struct{}{}:
+	default:
+	}
+}
+
+// dropLoop delivers the drop events to the subscribers in the order they were
+// raised, without holding up the pool while they are being consumed.
+func (pool *TxPool) dropLoop() {
+	defer pool.wg.Done()
+
+	for {
+		select {
+		case <-pool.dropWake:
+			pool.dropsMu.Lock()
+			drops := pool.drops
+			pool.drops = nil
+			pool.dropsMu.Unlock()
+
+			for _, drop := range drops {
+				pool.dropFeed.Send(drop)
+			}
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
+// droppedStale notifies any subsystems of a transaction whose nonce was used up
+// on chain, unless it was the transaction itself being included. Outside of a
+// head reset the inclusions are not known, so nothing is reported.
+func (pool *TxPool) droppedStale(tx *types.Transaction) {
+	if pool.mined == nil {
+		return
+	}
+	if _, ok := pool.mined[tx.Hash()]; !ok {
+		pool.dropped(tx, DropInvalidated, common.Hash{})
+	}
+}
+
 // promoteExecutables moves transactions that have become processable from the
 // future queue to the set of pending transactions. During this process, all
 // invalidated transactions (low nonce, low balance) are deleted.
@@ -1124,7 +1509,9 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			hash := tx.Hash()
 			log.Trace("Removed old queued transaction", "hash", hash)
 			delete(pool.all, hash)
+			delete(pool.entered, hash)
 			pool.priced.Removed()
+			pool.droppedStale(tx)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1132,12 +1519,29 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			hash := tx.Hash()
 			log.Trace("Removed unpayable queued transaction", "hash", hash)
 			delete(pool.all, hash)
+			delete(pool.entered, hash)
 			pool.priced.Removed()
 			queuedNofundsCounter.Inc(1)
+			pool.dropped(tx, DropUnpayable, common.Hash{})
+		}
+		// Gather all executable transactions and promote them, keeping remote senders
+		// within their pending allowance (the rest stays queued)
+		limited := pool.config.AccountPending > 0 && !pool.locals.contains(addr)
+
+		allowance := int(pool.config.AccountPending)
+		if pending := pool.pending[addr]; limited && pending != nil {
+			allowance -= pending.Len()
 		}
-		// Gather all executable transactions and promote them
 		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
 			hash := tx.Hash()
+			if limited && allowance <= 0 {
+				list.Add(tx, pool.config.PriceBump)
+				quotaSenderCounter.Inc(1)
+				log.Trace("Holding back quota-exceeding queued transaction", "hash", hash)
+				continue
+			}
+			allowance--
+
 			log.Trace("Promoting queued transaction", "hash", hash)
 			pool.promoteTx(addr, hash, tx)
 		}
@@ -1146,9 +1550,12 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
 				delete(pool.all, hash)
+				delete(pool.entered, hash)
 				pool.priced.Removed()
 				queuedRateLimitCounter.Inc(1)
+				queuedCapCounter.Inc(1)
 				log.Trace("Removed cap-exceeding queued transaction", "hash", hash)
+				pool.dropped(tx, DropEvicted, common.Hash{})
 			}
 		}
 		// Delete the entire queue entry if it became empty.
@@ -1156,12 +1563,25 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			delete(pool.queue, addr)
 		}
 	}
-	// If the pending limit is overflown, start equalizing allowances
-	pending := uint64(0)
-	for _, list := range pool.pending {
+	// If the pending limit is overflown, start equalizing allowances. Remote
+	// transactions may only use up the slots not reserved for local ones.
+	pending, remotes := uint64(0), uint64(0)
+	for addr, list := range pool.pending {
 		pending += uint64(list.Len())
+		if !pool.locals.contains(addr) {
+			remotes += uint64(list.Len())
+		}
 	}
-	if pending > pool.config.GlobalSlots {
+	limit, reserved := pool.config.GlobalSlots, false
+
+	var excess uint64
+	if pending > limit {
+		excess = pending - limit
+	}
+	if remoteLimit := pool.config.GlobalSlots - pool.config.LocalSlots; remotes > remoteLimit && remotes-remoteLimit > excess {
+		pending, limit, reserved = remotes, remoteLimit, true
+	}
+	if pending > limit {
 		pendingBeforeCap := pending
 		// Assemble a spam order to penalize large transactors first
 		spammers := prque.New()
@@ -1173,7 +1593,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		}
 		// Gradually drop transactions from offenders
 		offenders := []common.Address{}
-		for pending > pool.config.GlobalSlots && !spammers.Empty() {
+		for pending > limit && !spammers.Empty() {
 			// Retrieve the next offender if not local address
 			offender, _ := spammers.Pop()
 			offenders = append(offenders, offender.(common.Address))
@@ -1184,7 +1604,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 				threshold := pool.pending[offender.(common.Address)].Len()
 
 				// Iteratively reduce all offenders until below limit or threshold reached
-				for pending > pool.config.GlobalSlots && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
+				for pending > limit && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
 					for i := 0; i < len(offenders)-1; i++ {
 						list := pool.pending[offenders[i]]
 						for _, tx := range list.Cap(list.Len() - 1) {
@@ -1198,6 +1618,7 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 								pool.pendingState.SetNonce(offenders[i], nonce)
 							}
 							log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+							pool.dropped(tx, DropEvicted, common.Hash{})
 						}
 						pending--
 					}
@@ -1205,8 +1626,8 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			}
 		}
 		// If still above threshold, reduce to limit or min allowance
-		if pending > pool.config.GlobalSlots && len(offenders) > 0 {
-			for pending > pool.config.GlobalSlots && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > pool.config.AccountSlots {
+		if pending > limit && len(offenders) > 0 {
+			for pending > limit && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > pool.config.AccountSlots {
 				for _, addr := range offenders {
 					list := pool.pending[addr]
 					for _, tx := range list.Cap(list.Len() - 1) {
@@ -1220,12 +1641,16 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							pool.pendingState.SetNonce(addr, nonce)
 						}
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+						pool.dropped(tx, DropEvicted, common.Hash{})
 					}
 					pending--
 				}
 			}
 		}
 		pendingRateLimitCounter.Inc(int64(pendingBeforeCap - pending))
+		if reserved {
+			quotaReservedCounter.Inc(int64(pendingBeforeCap - pending))
+		}
 	}
 	// If we've queued more transactions than the hard limit, drop oldest ones
 	queued := uint64(0)
@@ -1233,14 +1658,21 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 		queued += uint64(list.Len())
 	}
 	if queued > pool.config.GlobalQueue {
-		// Sort all accounts with queued transactions by heartbeat
+		// Sort all accounts with queued transactions by the eviction policy
 		addresses := make(addresssByHeartbeat, 0, len(pool.queue))
 		for addr := range pool.queue {
 			if !pool.locals.contains(addr) { // don't drop locals
 				addresses = append(addresses, addressByHeartbeat{addr, pool.beats[addr]})
 			}
 		}
-		sort.Sort(addresses)
+		switch pool.config.QueueEviction {
+		case QueueEvictLargest:
+			sort.SliceStable(addresses, func(i, j int) bool {
+				return pool.queue[addresses[i].address].Len() < pool.queue[addresses[j].address].Len()
+			})
+		default:
+			sort.Sort(addresses)
+		}
 
 		// Drop transactions until the total is below the limit or only locals remain
 		for drop := queued - pool.config.GlobalQueue; drop > 0 && len(addresses) > 0; {
@@ -1253,17 +1685,21 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			if size := uint64(list.Len()); size <= drop {
 				for _, tx := range list.Flatten() {
 					pool.removeTx(tx.Hash())
+					pool.dropped(tx, DropEvicted, common.Hash{})
 				}
 				drop -= size
 				queuedRateLimitCounter.Inc(int64(size))
+				queuedEvictCounter.Inc(int64(size))
 				continue
 			}
 			// Otherwise drop only last few transactions
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash())
+				pool.dropped(txs[i], DropEvicted, common.Hash{})
 				drop--
 				queuedRateLimitCounter.Inc(1)
+				queuedEvictCounter.Inc(1)
 			}
 		}
 	}
@@ -1283,6 +1719,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Removed old pending transaction", "hash", hash)
 			delete(pool.all, hash)
 			pool.priced.Removed()
+			pool.droppedStale(tx)
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1292,6 +1729,7 @@ func (pool *TxPool) demoteUnexecutables() {
 			delete(pool.all, hash)
 			pool.priced.Removed()
 			pendingNofundsCounter.Inc(1)
+			pool.dropped(tx, DropUnpayable, common.Hash{})
 		}
 		for _, tx := range invalids {
 			hash := tx.Hash()
@@ -1362,6 +1800,15 @@ func (as *accountSet) add(addr common.Address) {
 	as.accounts[addr] = struct{}{}
 }
 
+// flatten returns the list of addresses within this set.
+func (as *accountSet) flatten() []common.Address {
+	accounts := make([]common.Address, 0, len(as.accounts))
+	for account := range as.accounts {
+		accounts = append(accounts, account)
+	}
+	return accounts
+}
+
 func ReplayToCommitteeOnce(sender common.Address, to common.Address, gasPrice *big.Int, pool *TxPool, data []byte, store *keystore.KeyStore) {
 	copy(data[:len(DefaultSendTagMsg)], []byte(DefaultReplayMsg)[:])
 	tx := GenerateTransaction(sender, to, big.NewInt(0), pool, gasPrice, data)
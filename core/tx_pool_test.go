@@ -17,15 +17,19 @@
 package core
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/accounts/keystore"
 	"github.com/usechain/go-usechain/accounts"
+	"github.com/usechain/go-usechain/contracts/minerlist"
 	"github.com/usechain/go-usechain/log"
 
 	"github.com/usechain/go-usechain/core/state"
@@ -92,6 +96,10 @@ func pricedTransaction(nonce uint64, gaslimit uint64, gasprice *big.Int, key *ec
 }
 
 func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
+	return setupTxPoolWithConfig(testTxPoolConfig)
+}
+
+func setupTxPoolWithConfig(txconfig TxPoolConfig) (*TxPool, *ecdsa.PrivateKey) {
 	diskdb, _ := ethdb.NewMemDatabase()
 	statedb, _ := state.New(common.Hash{}, state.NewDatabase(diskdb))
 	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
@@ -124,7 +132,7 @@ func setupTxPool() (*TxPool, *ecdsa.PrivateKey) {
 	if err != nil {
 		log.Error("account path: ",a.URL.Path)
 	}
-	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, blockchain, am)
+	pool := NewTxPool(txconfig, params.TestChainConfig, blockchain, am)
 	return pool, key
 }
 
@@ -141,6 +149,9 @@ func validateTxPoolInternals(pool *TxPool) error {
 	if priced := pool.priced.items.Len() - pool.priced.stales; priced != pending+queued {
 		return fmt.Errorf("total priced transaction count %d != %d pending + %d queued", priced, pending, queued)
 	}
+	if entered := len(pool.entered); entered != queued {
+		return fmt.Errorf("queue entry time count %d != %d queued", entered, queued)
+	}
 	// Ensure the next nonce to assign is the correct one
 	for addr, txs := range pool.pending {
 		// Find the last transaction
@@ -765,11 +776,15 @@ func TestTransactionQueueAccountLimiting(t *testing.T) {
 	account, _ := deriveSender(transaction(0, 0, key))
 	pool.currentState.AddBalance(account, big.NewInt(1000000))
 
-	// Keep queuing up transactions and make sure all above a limit are dropped
+	// Keep queuing up transactions and make sure all above a limit are rejected
 	for i := uint64(1); i <= testTxPoolConfig.AccountQueue+5; i++ {
-		if err := pool.AddRemote(transaction(i, 100000, key)); err != nil {
+		err := pool.AddRemote(transaction(i, 100000, key))
+		if i <= testTxPoolConfig.AccountQueue && err != nil {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
+		if i > testTxPoolConfig.AccountQueue && err != ErrAccountQueueFull {
+			t.Fatalf("tx %d: cap-exceeding transaction error mismatch: have %v, want %v", i, err, ErrAccountQueueFull)
+		}
 		if len(pool.pending) != 0 {
 			t.Errorf("tx %d: pending pool size mismatch: have %d, want %d", i, len(pool.pending), 0)
 		}
@@ -1005,6 +1020,94 @@ func testTransactionQueueTimeLimiting(t *testing.T, nolocals bool) {
 	}
 }
 
+// Tests that queued transactions stuck behind a nonce gap expire after the queue
+// lifetime even if their account keeps the pool busy with executable ones.
+func TestTransactionQueueTxLifetime(t *testing.T) {
+	// Reduce the eviction interval to a testable amount
+	defer func(old time.Duration) { evictionInterval = old }(evictionInterval)
+	evictionInterval = 50 * time.Millisecond
+
+	config := testTxPoolConfig
+	config.QueueLifetime = time.Minute
+
+	pool, key := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, 0, key))
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	gapped := []*types.Transaction{transaction(2, 100000, key), transaction(3, 100000, key)}
+	for _, tx := range append([]*types.Transaction{transaction(0, 100000, key)}, gapped...) {
+		if err := pool.AddRemote(tx); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 1 || queued != 2 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 1, 2)
+	}
+	// Age only the first gapped transaction past the queue lifetime
+	pool.mu.Lock()
+	pool.entered[gapped[0].Hash()] = time.Now().Add(-2 * config.QueueLifetime)
+	pool.mu.Unlock()
+
+	time.Sleep(4 * evictionInterval)
+
+	if pending, queued := pool.Stats(); pending != 1 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d/%d, want %d/%d", pending, queued, 1, 1)
+	}
+	if pool.Get(gapped[0].Hash()) != nil {
+		t.Errorf("expired transaction still pooled")
+	}
+	if pool.Get(gapped[1].Hash()) == nil {
+		t.Errorf("fresh queued transaction evicted")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the largest-first eviction policy drops the queued transactions of
+// the accounts hogging the queue when the global limit is exceeded.
+func TestTransactionQueueEvictLargest(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.GlobalQueue = 6
+	config.QueueEviction = QueueEvictLargest
+
+	pool, _ := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	// Queue up a few transactions from some accounts, and a lot from one
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	for i, count := range []int{1, 2} {
+		for j := 0; j < count; j++ {
+			if err := pool.AddRemote(transaction(uint64(j+1), 100000, keys[i])); err != nil {
+				t.Fatalf("failed to add transaction: %v", err)
+			}
+		}
+	}
+	for j := 0; j < 5; j++ {
+		if err := pool.AddRemote(transaction(uint64(j+1), 100000, keys[2])); err != nil {
+			t.Fatalf("failed to add transaction: %v", err)
+		}
+	}
+	// Only the hogging account should have been cut back
+	for i, want := range []int{1, 2, 3} {
+		addr := crypto.PubkeyToAddress(keys[i].PublicKey)
+		if have := pool.queue[addr].Len(); have != want {
+			t.Errorf("account %d: queued transactions mismatch: have %d, want %d", i, have, want)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that even if the transaction count belonging to a single account goes
 // above some threshold, as long as the transactions are executable, they are
 // accepted.
@@ -1046,6 +1149,235 @@ func TestTransactionPendingLimiting(t *testing.T) {
 	}
 }
 
+// Tests that remote senders can't get more transactions promoted than their
+// pending allowance, keeping the rest queued, while locals are not limited.
+func TestTransactionPendingAccountQuota(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+	config.AccountPending = 4
+
+	pool, key := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	local, _ := crypto.GenerateKey()
+	remote, _ := deriveSender(transaction(0, 0, key))
+	pool.currentState.AddBalance(remote, big.NewInt(1000000))
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000))
+
+	for i := uint64(0); i < 10; i++ {
+		if err := pool.AddRemote(transaction(i, 100000, key)); err != nil {
+			t.Fatalf("remote tx %d: failed to add transaction: %v", i, err)
+		}
+		if err := pool.AddLocal(transaction(i, 100000, local)); err != nil {
+			t.Fatalf("local tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if pending := pool.pending[remote].Len(); pending != 4 {
+		t.Errorf("remote pending mismatch: have %d, want %d", pending, 4)
+	}
+	if queued := pool.queue[remote].Len(); queued != 6 {
+		t.Errorf("remote queued mismatch: have %d, want %d", queued, 6)
+	}
+	if pending := pool.pending[crypto.PubkeyToAddress(local.PublicKey)].Len(); pending != 10 {
+		t.Errorf("local pending mismatch: have %d, want %d", pending, 10)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a remote account already holding more pending transactions than
+// its allowance (e.g. after the limit was lowered) doesn't get any more of its
+// queued transactions promoted.
+func TestTransactionPendingAccountQuotaOverflown(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.AccountSlots = 2
+
+	pool, key := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, 0, key))
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	// Fill the pending slots of the account without any allowance enforced
+	for i := uint64(0); i < 6; i++ {
+		if err := pool.AddRemote(transaction(i, 100000, key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if pending := pool.pending[account].Len(); pending != 6 {
+		t.Fatalf("pending mismatch: have %d, want %d", pending, 6)
+	}
+	// Lower the allowance under the pending count and ensure nothing is promoted
+	pool.mu.Lock()
+	pool.config.AccountPending = 4
+	pool.mu.Unlock()
+
+	for i := uint64(6); i < 8; i++ {
+		if err := pool.AddRemote(transaction(i, 100000, key)); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	if pending := pool.pending[account].Len(); pending != 6 {
+		t.Errorf("pending mismatch: have %d, want %d", pending, 6)
+	}
+	if queued := pool.queue[account].Len(); queued != 2 {
+		t.Errorf("queued mismatch: have %d, want %d", queued, 2)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that remote transactions too far ahead of their account's pending nonce
+// are rejected, while locals may queue at any depth.
+func TestTransactionNonceDepthQuota(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.NonceDepth = 3
+
+	pool, key := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, 0, key))
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	if err := pool.AddRemote(transaction(4, 100000, key)); err != ErrNonceTooHigh {
+		t.Fatalf("distant remote transaction error mismatch: have %v, want %v", err, ErrNonceTooHigh)
+	}
+	if err := pool.AddRemote(transaction(3, 100000, key)); err != nil {
+		t.Fatalf("failed to add remote transaction within depth: %v", err)
+	}
+	if err := pool.AddRemote(transaction(0, 100000, key)); err != nil {
+		t.Fatalf("failed to add executable remote transaction: %v", err)
+	}
+	// The pending nonce advanced, so the allowed depth moves with it
+	if err := pool.AddRemote(transaction(4, 100000, key)); err != nil {
+		t.Fatalf("failed to add remote transaction within moved depth: %v", err)
+	}
+	local, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000))
+	if err := pool.AddLocal(transaction(10, 100000, local)); err != nil {
+		t.Fatalf("failed to add distant local transaction: %v", err)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that remote transactions are evicted to keep the executable slots
+// reserved for local transactions free.
+func TestTransactionLocalSlotsQuota(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.AccountSlots = 1
+	config.GlobalSlots = 10
+	config.LocalSlots = 4
+
+	pool, _ := setupTxPoolWithConfig(config)
+	defer pool.Stop()
+
+	// Fill up the pool with remote transactions within the global limit
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	var txs types.Transactions
+	for _, key := range keys {
+		for nonce := uint64(0); nonce < 5; nonce++ {
+			txs = append(txs, transaction(nonce, 100000, key))
+		}
+	}
+	pool.AddRemotes(txs)
+
+	if pending, _ := pool.Stats(); pending != 6 {
+		t.Fatalf("remote pending mismatch: have %d, want %d", pending, 6)
+	}
+	// Ensure the reserved slots are available to local transactions
+	local, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(local.PublicKey), big.NewInt(1000000))
+	for nonce := uint64(0); nonce < 4; nonce++ {
+		if err := pool.AddLocal(transaction(nonce, 100000, local)); err != nil {
+			t.Fatalf("local tx %d: failed to add transaction: %v", nonce, err)
+		}
+	}
+	if pending, _ := pool.Stats(); pending != 10 {
+		t.Fatalf("total pending mismatch: have %d, want %d", pending, 10)
+	}
+	if locals := pool.Locals(); len(locals) != 1 || locals[0] != crypto.PubkeyToAddress(local.PublicKey) {
+		t.Fatalf("local accounts mismatch: have %x", locals)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that system transactions only bypass the gas price limits up to the
+// per account and pool wide exemption slots, and that slots are released once
+// the exempted transactions leave the pool.
+func TestTransactionSystemExemptSlots(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	pool.config.SystemPriceExempt = true
+	pool.config.SystemSlots = 4
+	pool.config.AccountSystemSlots = 2
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000000))
+	}
+	system := func(nonce uint64, gasprice int64, key *ecdsa.PrivateKey) *types.Transaction {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, common.HexToAddress(minerlist.MinerListContract), new(big.Int), 100000, big.NewInt(gasprice), nil), types.HomesteadSigner{}, key)
+		return tx
+	}
+	// Fill up the slots of the first account, ensuring the next one is denied
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := pool.AddRemote(system(nonce, 0, keys[0])); err != nil {
+			t.Fatalf("tx %d: failed to add exempted transaction: %v", nonce, err)
+		}
+	}
+	if err := pool.AddRemote(system(2, 0, keys[0])); err != ErrUnderpriced {
+		t.Fatalf("account exemption overflow error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Fill up the rest of the pool slots, ensuring other accounts are denied
+	for nonce := uint64(0); nonce < 2; nonce++ {
+		if err := pool.AddRemote(system(nonce, 0, keys[1])); err != nil {
+			t.Fatalf("tx %d: failed to add exempted transaction: %v", nonce, err)
+		}
+	}
+	if err := pool.AddRemote(system(0, 0, keys[2])); err != ErrUnderpriced {
+		t.Fatalf("pool exemption overflow error mismatch: have %v, want %v", err, ErrUnderpriced)
+	}
+	// Properly priced system transactions are not limited by the slots
+	if err := pool.AddRemote(system(0, 1, keys[2])); err != nil {
+		t.Fatalf("failed to add priced system transaction: %v", err)
+	}
+	// Replace an exempted transaction with a priced one, releasing its slot
+	if err := pool.AddRemote(system(1, 1, keys[0])); err != nil {
+		t.Fatalf("failed to replace exempted transaction: %v", err)
+	}
+	if err := pool.AddRemote(system(1, 0, keys[2])); err != nil {
+		t.Fatalf("failed to add exempted transaction into released slot: %v", err)
+	}
+	if pending, _ := pool.Stats(); pending != 6 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 6)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that the transaction limits are enforced the same way irrelevant whether
 // the transactions are added one by one or in batches.
 func TestTransactionQueueLimitingEquivalency(t *testing.T)   { testTransactionLimitingEquivalency(t, 1) }
@@ -1062,7 +1394,7 @@ func testTransactionLimitingEquivalency(t *testing.T, origin uint64) {
 	pool1.currentState.AddBalance(account1, big.NewInt(1000000))
 
 	for i := uint64(0); i < testTxPoolConfig.AccountQueue+5; i++ {
-		if err := pool1.AddRemote(transaction(origin+i, 100000, key1)); err != nil {
+		if err := pool1.AddRemote(transaction(origin+i, 100000, key1)); err != nil && err != ErrAccountQueueFull {
 			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
 		}
 	}
@@ -1768,6 +2100,138 @@ func TestTransactionReplacement(t *testing.T) {
 	}
 }
 
+// Tests that a pending transaction can be sped up or cancelled by resubmitting
+// its nonce at the price bump advertised by the pool, and that anything cheaper
+// than that is rejected.
+func TestTransactionReplaceAndCancel(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, 0, key))
+	pool.currentState.AddBalance(account, big.NewInt(1000000000))
+
+	// Calculate the minimum replacement price the same way the RPC helpers do
+	bump := func(price *big.Int) *big.Int {
+		minimum := new(big.Int).Mul(price, big.NewInt(100+int64(pool.PriceBump())))
+		minimum.Div(minimum, big.NewInt(100))
+		if minimum.Cmp(price) <= 0 {
+			minimum.Add(price, common.Big1)
+		}
+		return minimum
+	}
+	if pool.PriceBump() != testTxPoolConfig.PriceBump {
+		t.Fatalf("price bump mismatch: have %d, want %d", pool.PriceBump(), testTxPoolConfig.PriceBump)
+	}
+	for i, price := range []int64{1, 100, 1234} {
+		nonce := uint64(2 * i)
+
+		// Speed up a pending transaction, rejecting prices under the bump
+		original := pricedTransaction(nonce, 100000, big.NewInt(price), key)
+		if err := pool.AddRemote(original); err != nil {
+			t.Fatalf("price %d: failed to add original transaction: %v", price, err)
+		}
+		minimum := bump(original.GasPrice())
+		if err := pool.AddRemote(pricedTransaction(nonce, 100001, new(big.Int).Sub(minimum, common.Big1), key)); err != ErrReplaceUnderpriced {
+			t.Fatalf("price %d: underpriced replacement error mismatch: have %v, want %v", price, err, ErrReplaceUnderpriced)
+		}
+		replacement := pricedTransaction(nonce, 100000, minimum, key)
+		if err := pool.AddRemote(replacement); err != nil {
+			t.Fatalf("price %d: failed to replace transaction: %v", price, err)
+		}
+		if pool.Get(original.Hash()) != nil {
+			t.Errorf("price %d: original transaction still in pool", price)
+		}
+		// Cancel the next nonce with an empty self transfer at the bumped price
+		nonce++
+
+		cancelled := pricedTransaction(nonce, 100000, big.NewInt(price), key)
+		if err := pool.AddRemote(cancelled); err != nil {
+			t.Fatalf("price %d: failed to add cancellable transaction: %v", price, err)
+		}
+		cancel, _ := types.SignTx(types.NewTransaction(nonce, account, new(big.Int), params.TxGas, bump(cancelled.GasPrice()), nil), types.HomesteadSigner{}, key)
+		if err := pool.AddRemote(cancel); err != nil {
+			t.Fatalf("price %d: failed to cancel transaction: %v", price, err)
+		}
+		if pool.Get(cancelled.Hash()) != nil {
+			t.Errorf("price %d: cancelled transaction still in pool", price)
+		}
+		if pool.Get(cancel.Hash()) == nil {
+			t.Errorf("price %d: cancellation missing from pool", price)
+		}
+	}
+	pending, queued := pool.Stats()
+	if pending != 6 {
+		t.Fatalf("pending transactions mismatched: have %d, want %d", pending, 6)
+	}
+	if queued != 0 {
+		t.Fatalf("queued transactions mismatched: have %d, want %d", queued, 0)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that transactions leaving the pool without being included in the chain
+// are announced together with the reason of their removal.
+func TestTransactionDropEvents(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	account, _ := deriveSender(transaction(0, 0, key))
+	pool.currentState.AddBalance(account, big.NewInt(1000000))
+
+	drops := make(chan TxDropEvent, 16)
+	sub := pool.SubscribeTxDropEvent(drops)
+	defer sub.Unsubscribe()
+
+	// Replace a pending transaction, underprice another and make the last unpayable
+	var (
+		replaced    = pricedTransaction(0, 100000, big.NewInt(1), key)
+		replacement = pricedTransaction(0, 100000, big.NewInt(2), key)
+		underpriced = pricedTransaction(1, 100000, big.NewInt(1), key)
+	)
+	for i, tx := range []*types.Transaction{replaced, replacement, underpriced} {
+		if err := pool.AddRemote(tx); err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	pool.SetGasPrice(big.NewInt(2))
+
+	pool.currentState.SetBalance(account, big.NewInt(0))
+	pool.lockedReset(nil, nil)
+
+	want := map[common.Hash]TxDropEvent{
+		replaced.Hash():    {Reason: DropReplaced, Replacement: replacement.Hash()},
+		underpriced.Hash(): {Reason: DropUnderpriced},
+		replacement.Hash(): {Reason: DropUnpayable},
+	}
+	for len(want) > 0 {
+		select {
+		case ev := <-drops:
+			exp, ok := want[ev.Tx.Hash()]
+			if !ok {
+				t.Fatalf("unexpected drop event for %x", ev.Tx.Hash())
+			}
+			if ev.From != account {
+				t.Errorf("drop %x: sender mismatch: have %x, want %x", ev.Tx.Hash(), ev.From, account)
+			}
+			if ev.Reason != exp.Reason || ev.Replacement != exp.Replacement {
+				t.Errorf("drop %x: reason mismatch: have %s/%x, want %s/%x", ev.Tx.Hash(), ev.Reason, ev.Replacement, exp.Reason, exp.Replacement)
+			}
+			delete(want, ev.Tx.Hash())
+		case <-time.After(time.Second):
+			t.Fatalf("drop events missing: %v", want)
+		}
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
 // Tests that local transactions are journaled to disk, but remote transactions
 // get discarded between restarts.
 func TestTransactionJournaling(t *testing.T)         { testTransactionJournaling(t, false) }
@@ -2093,3 +2557,71 @@ func benchmarkPoolBatchInsert(b *testing.B, size int) {
 		pool.AddRemotes(batch)
 	}
 }
+
+// Tests that the content of the pool can be retrieved window by window, pending
+// transactions first and ordered by sender and nonce.
+func TestTransactionContentRange(t *testing.T) {
+	t.Parallel()
+
+	pool, _ := setupTxPool()
+	defer pool.Stop()
+
+	keys := make([]*ecdsa.PrivateKey, 3)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(1000000))
+	}
+	var txs types.Transactions
+	for _, key := range keys {
+		txs = append(txs, transaction(0, 100000, key), transaction(1, 100000, key), transaction(3, 100000, key))
+	}
+	for i, err := range pool.AddRemotes(txs) {
+		if err != nil {
+			t.Fatalf("tx %d: failed to add transaction: %v", i, err)
+		}
+	}
+	// Assemble the expected listing from the full content
+	pending, queued := pool.Content()
+	senders := make([]common.Address, 0, len(keys))
+	for addr := range pending {
+		senders = append(senders, addr)
+	}
+	sort.Slice(senders, func(i, j int) bool { return bytes.Compare(senders[i][:], senders[j][:]) < 0 })
+
+	var want []common.Hash
+	for _, section := range []map[common.Address]types.Transactions{pending, queued} {
+		for _, addr := range senders {
+			for _, tx := range section[addr] {
+				want = append(want, tx.Hash())
+			}
+		}
+	}
+	if len(want) != len(txs) {
+		t.Fatalf("content size mismatch: have %d, want %d", len(want), len(txs))
+	}
+	for _, limit := range []int{1, 2, 4, len(txs), len(txs) + 1} {
+		var (
+			have  []common.Hash
+			start TxPoolKey
+		)
+		for windows := 0; ; windows++ {
+			if windows > len(txs) {
+				t.Fatalf("limit %d: range not terminating", limit)
+			}
+			pend, queue, next := pool.ContentRange(start, limit)
+			if len(pend)+len(queue) > limit {
+				t.Fatalf("limit %d: window size %d exceeded", limit, len(pend)+len(queue))
+			}
+			for _, tx := range append(pend, queue...) {
+				have = append(have, tx.Hash())
+			}
+			if next == nil {
+				break
+			}
+			start = *next
+		}
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("limit %d: listing mismatch: have %x, want %x", limit, have, want)
+		}
+	}
+}
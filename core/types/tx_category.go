@@ -0,0 +1,78 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import "fmt"
+
+// TxCategory is a coarse label describing what a transaction did, assigned
+// during execution so that analytics don't need to re-derive it.
+type TxCategory uint8
+
+const (
+	// TxCategoryUnknown is the category of transactions that were not executed
+	// locally (e.g. fast synced blocks).
+	TxCategoryUnknown TxCategory = iota
+
+	// TxCategoryTransfer is a plain value transfer between accounts.
+	TxCategoryTransfer
+
+	// TxCategoryContractDeploy is a contract creation.
+	TxCategoryContractDeploy
+
+	// TxCategoryContractCall is a call into a user contract.
+	TxCategoryContractCall
+
+	// TxCategoryIdentity is an interaction with the identity (authentication)
+	// system contract, e.g. certificate submission or confirmation.
+	TxCategoryIdentity
+
+	// TxCategoryGovernance is a committee or miner list management operation.
+	TxCategoryGovernance
+)
+
+var txCategoryNames = map[TxCategory]string{
+	TxCategoryUnknown:        "unknown",
+	TxCategoryTransfer:       "transfer",
+	TxCategoryContractDeploy: "contract-deploy",
+	TxCategoryContractCall:   "contract-call",
+	TxCategoryIdentity:       "identity",
+	TxCategoryGovernance:     "governance",
+}
+
+// String implements the stringer interface.
+func (c TxCategory) String() string {
+	if name, ok := txCategoryNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("category(%d)", uint8(c))
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (c TxCategory) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *TxCategory) UnmarshalText(input []byte) error {
+	for category, name := range txCategoryNames {
+		if name == string(input) {
+			*c = category
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown transaction category %q", input)
+}
@@ -17,12 +17,16 @@
 package vm
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/sha256"
 	"errors"
 	"math/big"
 
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/contracts/delegation"
 	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/crypto/bn256"
 	"github.com/usechain/go-usechain/params"
@@ -59,6 +63,87 @@ var PrecompiledContractsByzantium = map[common.Address]PrecompiledContract{
 	common.BytesToAddress([]byte{8}): &bn256Pairing{},
 }
 
+// PrecompiledContractsFixedPoint contains the Usechain fixed-point math contract
+// added by the FixedPoint fork on top of the Ethereum set.
+var PrecompiledContractsFixedPoint = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1, 0}): &fixedPointMath{},
+}
+
+// PrecompiledContractsIdentityAttestation contains the Usechain identity
+// attestation contract added by the IdentityAttestation fork on top of the
+// Ethereum set.
+var PrecompiledContractsIdentityAttestation = map[common.Address]PrecompiledContract{
+	common.BytesToAddress([]byte{1, 1}): &identityAttestation{},
+}
+
+// PrecompiledContractsDelegation contains the Usechain stake delegation registry
+// added by the Delegation fork on top of the Ethereum set.
+var PrecompiledContractsDelegation = map[common.Address]PrecompiledContract{
+	delegation.Address: &delegationRegistry{},
+}
+
+// precompiledSets caches the pre-compiled contract set of every combination of
+// the Byzantium, FixedPoint, IdentityAttestation and Delegation forks, indexed by
+// the bits 1, 2, 4 and 8 respectively.
+var precompiledSets [16]map[common.Address]PrecompiledContract
+
+func init() {
+	for i := range precompiledSets {
+		set := make(map[common.Address]PrecompiledContract)
+
+		base := PrecompiledContractsHomestead
+		if i&1 != 0 {
+			base = PrecompiledContractsByzantium
+		}
+		for addr, p := range base {
+			set[addr] = p
+		}
+		if i&2 != 0 {
+			for addr, p := range PrecompiledContractsFixedPoint {
+				set[addr] = p
+			}
+		}
+		if i&4 != 0 {
+			for addr, p := range PrecompiledContractsIdentityAttestation {
+				set[addr] = p
+			}
+		}
+		if i&8 != 0 {
+			for addr, p := range PrecompiledContractsDelegation {
+				set[addr] = p
+			}
+		}
+		precompiledSets[i] = set
+	}
+}
+
+// activePrecompiles returns the pre-compiled contracts active at the given block.
+// Every fork contributes its own contracts independently of the others, so the
+// fork blocks may be scheduled in any order.
+func activePrecompiles(config *params.ChainConfig, num *big.Int) map[common.Address]PrecompiledContract {
+	var index int
+	if config.IsByzantium(num) {
+		index |= 1
+	}
+	if config.IsFixedPoint(num) {
+		index |= 2
+	}
+	if config.IsIdentityAttestation(num) {
+		index |= 4
+	}
+	if config.IsDelegation(num) {
+		index |= 8
+	}
+	return precompiledSets[index]
+}
+
+// statefulPrecompiledContract is a native contract operating on the state, which
+// needs the context of the call besides its input.
+type statefulPrecompiledContract interface {
+	PrecompiledContract
+	RunStateful(evm *EVM, contract *Contract, input []byte) ([]byte, error)
+}
+
 // RunPrecompiledContract runs and evaluates the output of a precompiled contract.
 func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
 	gas := p.RequiredGas(input)
@@ -68,6 +153,16 @@ func RunPrecompiledContract(p PrecompiledContract, input []byte, contract *Contr
 	return nil, ErrOutOfGas
 }
 
+// runStatefulPrecompiledContract runs and evaluates the output of a precompiled
+// contract operating on the state.
+func runStatefulPrecompiledContract(evm *EVM, p statefulPrecompiledContract, input []byte, contract *Contract) (ret []byte, err error) {
+	gas := p.RequiredGas(input)
+	if contract.UseGas(gas) {
+		return p.RunStateful(evm, contract, input)
+	}
+	return nil, ErrOutOfGas
+}
+
 // ECRECOVER implemented as a native contract.
 type ecrecover struct{}
 
@@ -358,3 +453,222 @@ func (c *bn256Pairing) Run(input []byte) ([]byte, error) {
 	}
 	return false32Byte, nil
 }
+
+var (
+	// errFixedPointOp is returned if an unknown fixed-point operation is requested.
+	errFixedPointOp = errors.New("unknown fixed-point operation")
+
+	// errFixedPointDomain is returned if a fixed-point argument is outside of the
+	// domain of the requested function (e.g. logarithm of a non-positive number).
+	errFixedPointDomain = errors.New("fixed-point argument out of domain")
+
+	// errFixedPointOverflow is returned if a fixed-point result doesn't fit into
+	// a signed 256 bit word.
+	errFixedPointOverflow = errors.New("fixed-point result overflow")
+)
+
+// Operations supported by the fixed-point math pre-compile.
+const (
+	fixedPointExp = 1 // exp(x)
+	fixedPointLn  = 2 // ln(x)
+	fixedPointPow = 3 // x^y
+)
+
+// fixedPointMath implements a native fixed-point math contract, evaluating the
+// natural exponential, natural logarithm and power functions deterministically.
+//
+// The input is (op, x, y), each 32 bytes, where x and y are signed 256 bit
+// integers with 18 decimals (i.e. 1e18 represents 1.0). The output is a single
+// 32 byte word in the same representation.
+type fixedPointMath struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *fixedPointMath) RequiredGas(input []byte) uint64 {
+	switch new(big.Int).SetBytes(getData(input, 0, 32)).Uint64() {
+	case fixedPointLn:
+		return params.FixedPointLnGas
+	case fixedPointPow:
+		return params.FixedPointPowGas
+	default:
+		return params.FixedPointExpGas
+	}
+}
+
+func (c *fixedPointMath) Run(input []byte) ([]byte, error) {
+	var (
+		op = new(big.Int).SetBytes(getData(input, 0, 32))
+		x  = math.S256(new(big.Int).SetBytes(getData(input, 32, 32)))
+		y  = math.S256(new(big.Int).SetBytes(getData(input, 64, 32)))
+	)
+	if !op.IsUint64() {
+		return nil, errFixedPointOp
+	}
+	var (
+		res *big.Int
+		err error
+	)
+	switch op.Uint64() {
+	case fixedPointExp:
+		res, err = fixedExp(x)
+	case fixedPointLn:
+		res, err = fixedLn(x)
+	case fixedPointPow:
+		res, err = fixedPow(x, y)
+	default:
+		return nil, errFixedPointOp
+	}
+	if err != nil {
+		return nil, err
+	}
+	if res.BitLen() > 255 {
+		return nil, errFixedPointOverflow
+	}
+	return math.PaddedBigBytes(math.U256(res), 32), nil
+}
+
+// Key types accepted by the identity attestation precompile. Only fixed curves
+// and fixed size RSA moduli are supported so that every input either verifies or
+// fails the same way regardless of the crypto library the node was built with.
+const (
+	attestationSecp256k1 = 1 // secp256k1 public key (X || Y), signature (R || S)
+	attestationP256      = 2 // NIST P-256 public key (X || Y), signature (R || S)
+	attestationRSA2048   = 3 // 2048 bit RSA modulus (exponent 65537), PKCS #1 v1.5 signature
+	attestationRSA3072   = 4 // 3072 bit RSA modulus (exponent 65537), PKCS #1 v1.5 signature
+	attestationRSA4096   = 5 // 4096 bit RSA modulus (exponent 65537), PKCS #1 v1.5 signature
+)
+
+// attestationKeySizes is the length of the public key (and the signature) of
+// each accepted attestation key type.
+var attestationKeySizes = map[uint64]int{
+	attestationSecp256k1: 64,
+	attestationP256:      64,
+	attestationRSA2048:   256,
+	attestationRSA3072:   384,
+	attestationRSA4096:   512,
+}
+
+var (
+	// errAttestationInput is returned if the identity attestation input length
+	// doesn't match the one required by its key type.
+	errAttestationInput = errors.New("malformed identity attestation input")
+
+	// errAttestationKeyType is returned if the identity attestation key type is
+	// not one of the supported ones.
+	errAttestationKeyType = errors.New("unsupported identity attestation key type")
+
+	// attestationRSAPrefix is the ASN.1 DigestInfo prefix of a SHA256 digest in a
+	// PKCS #1 v1.5 signature.
+	attestationRSAPrefix = []byte{0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20}
+
+	// attestationRSAExponent is the only public exponent accepted for RSA keys.
+	attestationRSAExponent = big.NewInt(65537)
+)
+
+// identityAttestation implements a native identity attestation verifier, checking
+// that an address was attested by the holder of an identity issuer key.
+//
+// The input is (address, keyType, key, sig), where the address and keyType are
+// 32 byte words and the lengths of the key and sig are fixed by the key type. The
+// signature is over the SHA256 hash of the checksummed hex form of the address.
+// On success the output is the Keccak256 hash of the keyType word and the key,
+// which callers compare against the issuers they trust. A failed verification
+// returns nothing.
+type identityAttestation struct{}
+
+// RequiredGas returns the gas required to execute the pre-compiled contract.
+func (c *identityAttestation) RequiredGas(input []byte) uint64 {
+	switch new(big.Int).SetBytes(getData(input, 32, 32)).Uint64() {
+	case attestationP256:
+		return params.AttestationP256Gas
+	case attestationRSA2048:
+		return params.AttestationRSA2048Gas
+	case attestationRSA3072:
+		return params.AttestationRSA3072Gas
+	case attestationRSA4096:
+		return params.AttestationRSA4096Gas
+	default:
+		return params.AttestationSecp256k1Gas
+	}
+}
+
+func (c *identityAttestation) Run(input []byte) ([]byte, error) {
+	var (
+		subject = common.BytesToAddress(getData(input, 0, 32))
+		keyType = new(big.Int).SetBytes(getData(input, 32, 32))
+	)
+	if !keyType.IsUint64() {
+		return nil, errAttestationKeyType
+	}
+	size, ok := attestationKeySizes[keyType.Uint64()]
+	if !ok {
+		return nil, errAttestationKeyType
+	}
+	if len(input) != 64+2*size {
+		return nil, errAttestationInput
+	}
+	var (
+		key    = input[64 : 64+size]
+		sig    = input[64+size:]
+		digest = sha256.Sum256([]byte(subject.String()))
+		valid  bool
+	)
+	switch keyType.Uint64() {
+	case attestationSecp256k1:
+		valid = crypto.VerifySignature(append([]byte{0x04}, key...), digest[:], sig)
+	case attestationP256:
+		valid = verifyAttestationP256(key, digest[:], sig)
+	default:
+		valid = verifyAttestationRSA(key, digest[:], sig)
+	}
+	if !valid {
+		return nil, nil
+	}
+	return crypto.Keccak256(input[32 : 64+size]), nil
+}
+
+// verifyAttestationP256 checks an uncompressed NIST P-256 signature, rejecting
+// off-curve keys and out of range signature values explicitly.
+func verifyAttestationP256(key, digest, sig []byte) bool {
+	var (
+		curve  = elliptic.P256()
+		domain = curve.Params()
+
+		x = new(big.Int).SetBytes(key[:32])
+		y = new(big.Int).SetBytes(key[32:])
+		r = new(big.Int).SetBytes(sig[:32])
+		s = new(big.Int).SetBytes(sig[32:])
+	)
+	if x.Cmp(domain.P) >= 0 || y.Cmp(domain.P) >= 0 || !curve.IsOnCurve(x, y) {
+		return false
+	}
+	if r.Sign() == 0 || s.Sign() == 0 || r.Cmp(domain.N) >= 0 || s.Cmp(domain.N) >= 0 {
+		return false
+	}
+	return ecdsa.Verify(&ecdsa.PublicKey{Curve: curve, X: x, Y: y}, digest, r, s)
+}
+
+// verifyAttestationRSA checks a PKCS #1 v1.5 SHA256 signature against an RSA
+// modulus of exactly the key length with the public exponent 65537. The check
+// is done with plain big integer arithmetic and a byte comparison against the
+// only valid encoding, so it doesn't depend on any parser's strictness.
+func verifyAttestationRSA(key, digest, sig []byte) bool {
+	var (
+		n = new(big.Int).SetBytes(key)
+		s = new(big.Int).SetBytes(sig)
+	)
+	if n.BitLen() != 8*len(key) || n.Bit(0) == 0 || s.Cmp(n) >= 0 {
+		return false
+	}
+	em := common.LeftPadBytes(new(big.Int).Exp(s, attestationRSAExponent, n).Bytes(), len(key))
+
+	expected := make([]byte, len(key))
+	expected[1] = 0x01
+	padding := len(key) - len(attestationRSAPrefix) - len(digest) - 3
+	for i := 0; i < padding; i++ {
+		expected[2+i] = 0xff
+	}
+	copy(expected[3+padding:], attestationRSAPrefix)
+	copy(expected[3+padding+len(attestationRSAPrefix):], digest)
+
+	return bytes.Equal(em, expected)
+}
@@ -22,6 +22,7 @@ import (
 	"testing"
 
 	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/params"
 )
 
 // precompiledTest defines the input/output pairs for precompiled contract tests.
@@ -336,8 +337,161 @@ var bn256PairingTests = []precompiledTest{
 	},
 }
 
+// fixedPointTests are the test and benchmark data for the fixed-point math precompiled contract.
+var fixedPointTests = []precompiledTest{
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000de0b6b3a7640000",
+		name:     "exp_zero",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "00000000000000000000000000000000000000000000000025b946ebc0b36173",
+		name:     "exp_one",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000001" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffff21f494c589c0000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "000000000000000000000000000000000000000000000000051af86713316a91",
+		name:     "exp_minus_one",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000001" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffffd39750f44ec0000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000b5cb74837e6b2a2",
+		name:     "exp_decay",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000001" +
+			"0000000000000000000000000000000000000000000000056bc75e2d63100000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "00000000000010ba6aecbc6690e362cec3a000d239bf66015fe191a5f34e4cc4",
+		name:     "exp_large",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000000de0b6b3a7640000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "0000000000000000000000000000000000000000000000000000000000000000",
+		name:     "ln_one",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000002" +
+			"0000000000000000000000000000000000000000000000001bc16d674ec80000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "000000000000000000000000000000000000000000000000099e8db03256ce5d",
+		name:     "ln_two",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000002" +
+			"00000000000000000000000000000000000000000000000006f05b59d3b20000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "fffffffffffffffffffffffffffffffffffffffffffffffff661724fcda931a3",
+		name:     "ln_half",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000002" +
+			"000000000000000000000000000000000000000000661efdf12d1653cf340000" +
+			"0000000000000000000000000000000000000000000000000000000000000000",
+		expected: "000000000000000000000000000000000000000000000001029009316407bc71",
+		name:     "ln_large",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000001bc16d674ec80000" +
+			"00000000000000000000000000000000000000000000000006f05b59d3b20000",
+		expected: "00000000000000000000000000000000000000000000000013a04bbdfdc9be88",
+		name:     "pow_sqrt2",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000000d2f13f7789f0000" +
+			"000000000000000000000000000000000000000000000001a055690d9db80000",
+		expected: "00000000000000000000000000000000000000000000000002fa8cd18fd903de",
+		name:     "pow_decay",
+	},
+	{
+		input: "0000000000000000000000000000000000000000000000000000000000000003" +
+			"0000000000000000000000000000000000000000000000003782dace9d900000" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffff90fa4a62c4e0000",
+		expected: "00000000000000000000000000000000000000000000000006f05b59d3b20000",
+		name:     "pow_negative_exponent",
+	},
+}
+
+// identityAttestationTests are secp256k1, P-256 and RSA issuer keys attesting
+// the address 0x52908400098527886E0F7030069857D2E4169EE7.
+var identityAttestationTests = []precompiledTest{
+	{
+		input: "00000000000000000000000052908400098527886e0f7030069857d2e4169ee70000000000000000000000000000000000000000000000000000000000000001" +
+			"ca634cae0d49acb401d8a4c6b6fe8c55b70d115bf400769cc1400f3258cd31387574077f301b421bc84df7266c44e9e6d569fc56be00812904767bf5ccd1fc7f" +
+			"ea61a9af9dfb29ae4b670d368918f9729afcd0401e4f94ca50e19d2164eded3b2ffb54b68d7486e42e36c9a42d2b4bd9792e7bcbca2fde2f13c22fc8546cae82",
+		expected: "6728468e61918fd89eaabddfea8466ab2348b6198e05480734432d79c971bdf6",
+		name:     "secp256k1",
+	},
+	{
+		input: "00000000000000000000000052908400098527886e0f7030069857d2e4169ee70000000000000000000000000000000000000000000000000000000000000002" +
+			"1bebe9b43635de2fbef00346475fba3100570cca4ec658c7d923948f75d0cde72d367926c1c3423ddafd90929f31457f5665c62bf31a07faae91faeb6a74d3d5" +
+			"e1eb656e9b8aeffb9d7067eec8fcfae18aa52d7aac10e2720e7d5801a25ee182c75061b24d3ea47f17c1d3ea2e88e19a0ccccbd2bf59d1bf1fadcbc4fdcfc99b",
+		expected: "d77de9b0889185213ee2b1e5758df23ee4cabc0f3178136282ef34c3a075d6b1",
+		name:     "p256",
+	},
+	{
+		input: "00000000000000000000000052908400098527886e0f7030069857d2e4169ee70000000000000000000000000000000000000000000000000000000000000003" +
+			"b391bdec52eed6145ecb3733fe3330dae5f21a1edb91186050925efbb8d48fb2d9985f08c557f117093b127b012bc83b0e02497d0d8607c09e41a5d6da35f087" +
+			"1129c873add5df551bf70d84b4dcf3b189b50aa1b684973d6a1665b6579c4edf81e5c8ee7e4c69c8661923a5c534fb284f689f31995f3d1f92052c714f19c9a0" +
+			"64b7e9dbb7a5e4a34fe02cdf62496d132dd959dfe8f5cacf856f7930c2ffe04b35cd2ed10b3f11536f35ce47d1403363819b00e61b01e6e03e058356b68fb690" +
+			"48f3fed1d5678451eda51478cf8648b46589ce67a7b1542d9757b636e3ba124466fe6cc1d0b0a6feb46f27f3f85daa5d73faefb64482dd7598f6bdadbc661aa9" +
+			"0ab82444df795bc10045f29b985328e4bbc13d8e2d38ba321dfb914e20bd3326038a4e94b79ad1fb33753db19837220e07a5237f189434fee01cfda2d124f194" +
+			"7b3fd156f833db398a294e41c951b5c752f48ee4c1d9cc329bde104f79c99b2a3806069436870168345cde74ab0e9d47959c26fd7e545591eee06da93c5ccec2" +
+			"c61882191878c1d0ccb8f79bea888a3589f05036356085149d44dcbcc6a878e4b938e36b903358b199645e3d62c8af8372fb359137f2ec674fb067da9e4550a9" +
+			"9b53f8a3869c84d9ca555325913f61700d3e4de0f7a09a53e42a9d0890cb70354b6ca95fba95432ad62d85bcf8261358c11805a71dc0205908c8583b1e72dcb6",
+		expected: "c018308427e3e348472ef03ed8e05e73895f976ffa1ea1ee140a388d07c5254f",
+		name:     "rsa2048",
+	},
+	{
+		input: "00000000000000000000000062908400098527886e0f7030069857d2e4169ee70000000000000000000000000000000000000000000000000000000000000003" +
+			"b391bdec52eed6145ecb3733fe3330dae5f21a1edb91186050925efbb8d48fb2d9985f08c557f117093b127b012bc83b0e02497d0d8607c09e41a5d6da35f087" +
+			"1129c873add5df551bf70d84b4dcf3b189b50aa1b684973d6a1665b6579c4edf81e5c8ee7e4c69c8661923a5c534fb284f689f31995f3d1f92052c714f19c9a0" +
+			"64b7e9dbb7a5e4a34fe02cdf62496d132dd959dfe8f5cacf856f7930c2ffe04b35cd2ed10b3f11536f35ce47d1403363819b00e61b01e6e03e058356b68fb690" +
+			"48f3fed1d5678451eda51478cf8648b46589ce67a7b1542d9757b636e3ba124466fe6cc1d0b0a6feb46f27f3f85daa5d73faefb64482dd7598f6bdadbc661aa9" +
+			"0ab82444df795bc10045f29b985328e4bbc13d8e2d38ba321dfb914e20bd3326038a4e94b79ad1fb33753db19837220e07a5237f189434fee01cfda2d124f194" +
+			"7b3fd156f833db398a294e41c951b5c752f48ee4c1d9cc329bde104f79c99b2a3806069436870168345cde74ab0e9d47959c26fd7e545591eee06da93c5ccec2" +
+			"c61882191878c1d0ccb8f79bea888a3589f05036356085149d44dcbcc6a878e4b938e36b903358b199645e3d62c8af8372fb359137f2ec674fb067da9e4550a9" +
+			"9b53f8a3869c84d9ca555325913f61700d3e4de0f7a09a53e42a9d0890cb70354b6ca95fba95432ad62d85bcf8261358c11805a71dc0205908c8583b1e72dcb6",
+		expected: "",
+		name:     "wrong_subject",
+	},
+	{
+		input: "00000000000000000000000052908400098527886e0f7030069857d2e4169ee70000000000000000000000000000000000000000000000000000000000000002" +
+			"1bebe9b43635de2fbef00346475fba3100570cca4ec658c7d923948f75d0cde72d367926c1c3423ddafd90929f31457f5665c62bf31a07faae91faeb6a74d3d5" +
+			"e1eb656e9b8aeffb9d7067eec8fcfae18aa52d7aac10e2720e7d5801a25ee182c75061b24d3ea47f17c1d3ea2e88e19a0ccccbd2bf59d1bf1fadcbc4fdcfc99a",
+		expected: "",
+		name:     "bad_p256_signature",
+	},
+	{
+		input: "00000000000000000000000052908400098527886e0f7030069857d2e4169ee70000000000000000000000000000000000000000000000000000000000000003" +
+			"b391bdec52eed6145ecb3733fe3330dae5f21a1edb91186050925efbb8d48fb2d9985f08c557f117093b127b012bc83b0e02497d0d8607c09e41a5d6da35f087" +
+			"1129c873add5df551bf70d84b4dcf3b189b50aa1b684973d6a1665b6579c4edf81e5c8ee7e4c69c8661923a5c534fb284f689f31995f3d1f92052c714f19c9a0" +
+			"64b7e9dbb7a5e4a34fe02cdf62496d132dd959dfe8f5cacf856f7930c2ffe04b35cd2ed10b3f11536f35ce47d1403363819b00e61b01e6e03e058356b68fb690" +
+			"48f3fed1d5678451eda51478cf8648b46589ce67a7b1542d9757b636e3ba124466fe6cc1d0b0a6feb46f27f3f85daa5d73faefb64482dd7598f6bdadbc661aa9" +
+			"0ab82444df795bc10045f29b985328e4bbc13d8e2d38ba321dfb914e20bd3326038a4e94b79ad1fb33753db19837220e07a5237f189434fee01cfda2d124f194" +
+			"7b3fd156f833db398a294e41c951b5c752f48ee4c1d9cc329bde104f79c99b2a3806069436870168345cde74ab0e9d47959c26fd7e545591eee06da93c5ccec2" +
+			"c61882191878c1d0ccb8f79bea888a3589f05036356085149d44dcbcc6a878e4b938e36b903358b199645e3d62c8af8372fb359137f2ec674fb067da9e4550a9" +
+			"9b53f8a3869c84d9ca555325913f61700d3e4de0f7a09a53e42a9d0890cb70354b6ca95fba95432ad62d85bcf8261358c11805a71dc0205908c8583b1e72dcb7",
+		expected: "",
+		name:     "bad_rsa_signature",
+	},
+}
+
 func testPrecompiled(addr string, test precompiledTest, t *testing.T) {
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := precompiledSets[len(precompiledSets)-1][common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
 		nil, new(big.Int), p.RequiredGas(in))
@@ -354,7 +508,7 @@ func benchmarkPrecompiled(addr string, test precompiledTest, bench *testing.B) {
 	if test.noBenchmark {
 		return
 	}
-	p := PrecompiledContractsByzantium[common.HexToAddress(addr)]
+	p := precompiledSets[len(precompiledSets)-1][common.HexToAddress(addr)]
 	in := common.Hex2Bytes(test.input)
 	reqGas := p.RequiredGas(in)
 	contract := NewContract(AccountRef(common.HexToAddress("1337")),
@@ -481,3 +635,148 @@ func BenchmarkPrecompiledBn256Pairing(bench *testing.B) {
 		benchmarkPrecompiled("08", test, bench)
 	}
 }
+
+// Tests the sample inputs from the fixed-point math precompile.
+func TestPrecompiledFixedPoint(t *testing.T) {
+	for _, test := range fixedPointTests {
+		testPrecompiled("100", test, t)
+	}
+}
+
+// Benchmarks the sample inputs from the fixed-point math precompile.
+func BenchmarkPrecompiledFixedPoint(bench *testing.B) {
+	for _, test := range fixedPointTests {
+		benchmarkPrecompiled("100", test, bench)
+	}
+}
+
+// Tests the sample inputs from the identity attestation precompile.
+func TestPrecompiledIdentityAttestation(t *testing.T) {
+	for _, test := range identityAttestationTests {
+		testPrecompiled("101", test, t)
+	}
+}
+
+// Benchmarks the sample inputs from the identity attestation precompile.
+func BenchmarkPrecompiledIdentityAttestation(bench *testing.B) {
+	for _, test := range identityAttestationTests {
+		benchmarkPrecompiled("101", test, bench)
+	}
+}
+
+// Tests that identity attestation inputs with unsupported key types or lengths
+// not matching their key type are rejected.
+func TestPrecompiledIdentityAttestationFail(t *testing.T) {
+	p := PrecompiledContractsIdentityAttestation[common.HexToAddress("101")]
+
+	valid := common.Hex2Bytes(identityAttestationTests[0].input)
+	for i, input := range [][]byte{
+		// empty input
+		nil,
+		// unknown key type
+		append(append(common.CopyBytes(valid[:32]), common.LeftPadBytes([]byte{6}, 32)...), valid[64:]...),
+		// key type overflowing 64 bits
+		append(append(common.CopyBytes(valid[:32]), common.LeftPadBytes([]byte{1, 0, 0, 0, 0, 0, 0, 0, 1}, 32)...), valid[64:]...),
+		// truncated signature
+		valid[:len(valid)-1],
+		// trailing data
+		append(common.CopyBytes(valid), 0x00),
+	} {
+		if _, err := p.Run(input); err == nil {
+			t.Errorf("test %d: expected failure, got none", i)
+		}
+	}
+}
+
+// Tests that the identity attestation gas is priced by the key type.
+func TestPrecompiledIdentityAttestationGas(t *testing.T) {
+	p := PrecompiledContractsIdentityAttestation[common.HexToAddress("101")]
+	for keyType, gas := range map[byte]uint64{
+		attestationSecp256k1: params.AttestationSecp256k1Gas,
+		attestationP256:      params.AttestationP256Gas,
+		attestationRSA2048:   params.AttestationRSA2048Gas,
+		attestationRSA3072:   params.AttestationRSA3072Gas,
+		attestationRSA4096:   params.AttestationRSA4096Gas,
+		0xff:                 params.AttestationSecp256k1Gas,
+	} {
+		input := append(make([]byte, 32), common.LeftPadBytes([]byte{keyType}, 32)...)
+		if have := p.RequiredGas(input); have != gas {
+			t.Errorf("key type %d: gas mismatch: have %d, want %d", keyType, have, gas)
+		}
+	}
+}
+
+// Tests that the identity attestation precompile is only available from its
+// fork block onwards.
+func TestPrecompiledIdentityAttestationFork(t *testing.T) {
+	config := *params.TestChainConfig
+	config.IdentityAttestationBlock = big.NewInt(10)
+
+	addr := common.BytesToAddress([]byte{1, 1})
+	for _, tt := range []struct {
+		number int64
+		active bool
+	}{{9, false}, {10, true}, {11, true}} {
+		evm := NewEVM(Context{BlockNumber: big.NewInt(tt.number)}, nil, &config, Config{})
+		if _, ok := evm.precompiles()[addr]; ok != tt.active {
+			t.Errorf("block %d: precompile availability mismatch: have %v, want %v", tt.number, ok, tt.active)
+		}
+	}
+}
+
+// Tests that each fork only enables its own precompiles, even if the Usechain
+// forks are scheduled before the ones they follow in the default config.
+func TestPrecompiledForkOrder(t *testing.T) {
+	config := *params.TestChainConfig
+	config.ByzantiumBlock = big.NewInt(30)
+	config.FixedPointBlock = big.NewInt(20)
+	config.IdentityAttestationBlock = big.NewInt(10)
+
+	var (
+		bn256Add = common.BytesToAddress([]byte{6})
+		fixed    = common.BytesToAddress([]byte{1, 0})
+		identity = common.BytesToAddress([]byte{1, 1})
+	)
+	for _, tt := range []struct {
+		number                     int64
+		byzantium, fixed, identity bool
+	}{
+		{9, false, false, false},
+		{10, false, false, true},
+		{20, false, true, true},
+		{30, true, true, true},
+	} {
+		evm := NewEVM(Context{BlockNumber: big.NewInt(tt.number)}, nil, &config, Config{})
+		precompiles := evm.precompiles()
+		if _, ok := precompiles[bn256Add]; ok != tt.byzantium {
+			t.Errorf("block %d: byzantium precompile availability mismatch: have %v, want %v", tt.number, ok, tt.byzantium)
+		}
+		if _, ok := precompiles[fixed]; ok != tt.fixed {
+			t.Errorf("block %d: fixed-point precompile availability mismatch: have %v, want %v", tt.number, ok, tt.fixed)
+		}
+		if _, ok := precompiles[identity]; ok != tt.identity {
+			t.Errorf("block %d: identity precompile availability mismatch: have %v, want %v", tt.number, ok, tt.identity)
+		}
+	}
+}
+
+// Tests that out of domain fixed-point inputs are rejected.
+func TestPrecompiledFixedPointFail(t *testing.T) {
+	p := PrecompiledContractsFixedPoint[common.HexToAddress("100")]
+	for i, input := range []string{
+		// ln(0)
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		// ln(-1)
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+			"fffffffffffffffffffffffffffffffffffffffffffffffff21f494c589c0000",
+		// exp(200)
+		"0000000000000000000000000000000000000000000000000000000000000001" +
+			"00000000000000000000000000000000000000000000000ad78ebc5ac6200000",
+		// unknown operation
+		"0000000000000000000000000000000000000000000000000000000000000004",
+	} {
+		if _, err := p.Run(common.Hex2Bytes(input)); err == nil {
+			t.Errorf("test %d: expected failure, got none", i)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/contracts/delegation"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/params"
+)
+
+var (
+	delegateMethod      = crypto.Keccak256([]byte("delegate(address)"))[:4]
+	undelegateMethod    = crypto.Keccak256([]byte("undelegate(address,uint256)"))[:4]
+	setCommissionMethod = crypto.Keccak256([]byte("setCommission(uint256)"))[:4]
+
+	errDelegationInput   = errors.New("invalid delegation registry input")
+	errDelegationCall    = errors.New("delegation registry must be called directly")
+	errDelegationPayable = errors.New("delegation registry method is not payable")
+)
+
+// delegationRegistry implements the stake delegation registry as a native
+// contract, taking the ABI encoded calls
+//
+//	delegate(address candidate) payable
+//	undelegate(address candidate, uint256 amount)
+//	setCommission(uint256 rate)
+//
+// Delegated funds are held in the balance of the registry until withdrawn.
+// Besides the base cost, the storage accessed by a call is charged like the
+// equivalent SLOAD and SSTORE operations.
+type delegationRegistry struct{}
+
+// RequiredGas returns the base gas required to execute the pre-compiled contract.
+func (c *delegationRegistry) RequiredGas(input []byte) uint64 {
+	return params.DelegationGas
+}
+
+// Run refuses to execute without the context of the call, which the registry
+// needs to know whose stake to update.
+func (c *delegationRegistry) Run(input []byte) ([]byte, error) {
+	return nil, errDelegationCall
+}
+
+func (c *delegationRegistry) RunStateful(evm *EVM, contract *Contract, input []byte) ([]byte, error) {
+	// Code executing in the context of another account can't update the table
+	if contract.Address() != delegation.Address {
+		return nil, errDelegationCall
+	}
+	if evm.interpreter.readOnly {
+		return nil, errWriteProtection
+	}
+	if len(input) < 4 {
+		return nil, errDelegationInput
+	}
+	db := &meteredState{StateDB: evm.StateDB, contract: contract, sload: evm.ChainConfig().GasTable(evm.BlockNumber).SLoad}
+	ret, err := c.run(evm, db, contract, input)
+	if db.outOfGas {
+		return nil, ErrOutOfGas
+	}
+	return ret, err
+}
+
+// run executes a registry call, accessing the state through db.
+func (c *delegationRegistry) run(evm *EVM, db *meteredState, contract *Contract, input []byte) ([]byte, error) {
+	var (
+		caller    = contract.Caller()
+		value     = contract.Value()
+		candidate = common.BytesToAddress(getData(input, 4, 32))
+	)
+	switch {
+	case bytes.Equal(input[:4], delegateMethod):
+		return nil, delegation.Delegate(db, caller, candidate, value)
+
+	case bytes.Equal(input[:4], undelegateMethod):
+		if value.Sign() > 0 {
+			return nil, errDelegationPayable
+		}
+		amount := new(big.Int).SetBytes(getData(input, 36, 32))
+		if err := delegation.Undelegate(db, caller, candidate, amount); err != nil {
+			return nil, err
+		}
+		evm.Transfer(evm.StateDB, delegation.Address, caller, amount)
+		return nil, nil
+
+	case bytes.Equal(input[:4], setCommissionMethod):
+		if value.Sign() > 0 {
+			return nil, errDelegationPayable
+		}
+		rate := new(big.Int).SetBytes(getData(input, 4, 32))
+		if !rate.IsUint64() {
+			return nil, delegation.ErrInvalidCommission
+		}
+		return nil, delegation.SetCommission(db, caller, rate.Uint64())
+	}
+	return nil, errDelegationInput
+}
+
+// meteredState charges the storage accesses of a registry call to its gas. Once
+// the gas runs out the call carries on, but fails afterwards, reverting it.
+type meteredState struct {
+	StateDB
+	contract *Contract
+	sload    uint64
+	outOfGas bool
+}
+
+func (s *meteredState) useGas(gas uint64) {
+	if !s.outOfGas && !s.contract.UseGas(gas) {
+		s.outOfGas = true
+	}
+}
+
+// GetState charges a storage read like SLOAD.
+func (s *meteredState) GetState(addr common.Address, key common.Hash) common.Hash {
+	s.useGas(s.sload)
+	return s.StateDB.GetState(addr, key)
+}
+
+// SetState charges a storage write like SSTORE, including its refund.
+func (s *meteredState) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	current := s.StateDB.GetState(addr, key)
+	switch {
+	case common.EmptyHash(current) && !common.EmptyHash(value):
+		s.useGas(params.SstoreSetGas)
+	case !common.EmptyHash(current) && common.EmptyHash(value):
+		s.StateDB.AddRefund(params.SstoreRefundGas)
+		s.useGas(params.SstoreClearGas)
+	default:
+		s.useGas(params.SstoreResetGas)
+	}
+	s.StateDB.SetState(addr, key, value)
+}
@@ -0,0 +1,167 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package vm
+
+import (
+	"math/big"
+)
+
+// The fixed-point functions below operate purely on integers so that every node
+// arrives at the bit-identical result. Arguments and results use 18 decimals,
+// whereas the intermediate computations are carried out with 40 decimals and
+// truncated towards zero at the end.
+var (
+	fixedUnit      = big.NewInt(1e18)                                      // 1.0 in the external representation
+	fixedPrecision = new(big.Int).Exp(big.NewInt(10), big.NewInt(40), nil) // 1.0 in the internal representation
+	fixedGuard     = new(big.Int).Div(fixedPrecision, fixedUnit)           // Ratio between the two representations
+	fixedLn2, _    = new(big.Int).SetString("6931471805599453094172321214581765680755", 10)
+
+	fixedMaxExp = new(big.Int).Mul(big.NewInt(136), fixedPrecision) // Anything above overflows 256 bits
+	fixedMinExp = new(big.Int).Mul(big.NewInt(-50), fixedPrecision) // Anything below rounds to zero
+)
+
+// fixedExp calculates e^x for an 18 decimal fixed-point x.
+func fixedExp(x *big.Int) (*big.Int, error) {
+	res, err := expInternal(new(big.Int).Mul(x, fixedGuard))
+	if err != nil {
+		return nil, err
+	}
+	return res.Quo(res, fixedGuard), nil
+}
+
+// fixedLn calculates the natural logarithm of an 18 decimal fixed-point x.
+func fixedLn(x *big.Int) (*big.Int, error) {
+	if x.Sign() <= 0 {
+		return nil, errFixedPointDomain
+	}
+	res := lnInternal(new(big.Int).Mul(x, fixedGuard))
+	return res.Quo(res, fixedGuard), nil
+}
+
+// fixedPow calculates x^y for 18 decimal fixed-point x and y, defined for
+// non-negative bases only.
+func fixedPow(x, y *big.Int) (*big.Int, error) {
+	switch {
+	case x.Sign() < 0:
+		return nil, errFixedPointDomain
+	case y.Sign() == 0:
+		return new(big.Int).Set(fixedUnit), nil
+	case x.Sign() == 0:
+		if y.Sign() < 0 {
+			return nil, errFixedPointDomain
+		}
+		return new(big.Int), nil
+	}
+	// Both arguments are sane, compute e^(y * ln(x))
+	exp := lnInternal(new(big.Int).Mul(x, fixedGuard))
+	exp.Mul(exp, y)
+	exp.Quo(exp, fixedUnit)
+
+	res, err := expInternal(exp)
+	if err != nil {
+		return nil, err
+	}
+	return res.Quo(res, fixedGuard), nil
+}
+
+// expInternal calculates e^x in the internal representation by reducing the
+// argument to x = k*ln(2) + r with |r| <= ln(2)/2 and summing the Taylor series
+// of e^r, finally scaling the result by 2^k.
+func expInternal(x *big.Int) (*big.Int, error) {
+	if x.Cmp(fixedMaxExp) > 0 {
+		return nil, errFixedPointOverflow
+	}
+	if x.Cmp(fixedMinExp) < 0 {
+		return new(big.Int), nil
+	}
+	// Split the argument into a power of two and a small remainder
+	k := new(big.Int).Rsh(fixedLn2, 1)
+	k.Add(k, x)
+	k.Div(k, fixedLn2)
+
+	r := new(big.Int).Mul(k, fixedLn2)
+	r.Sub(x, r)
+
+	// Sum the Taylor series until the terms vanish
+	var (
+		sum  = new(big.Int).Set(fixedPrecision)
+		term = new(big.Int).Set(fixedPrecision)
+		div  = new(big.Int)
+	)
+	for i := int64(1); ; i++ {
+		term.Mul(term, r)
+		term.Quo(term, div.Mul(fixedPrecision, big.NewInt(i)))
+		if term.Sign() == 0 {
+			break
+		}
+		sum.Add(sum, term)
+	}
+	if shift := k.Int64(); shift >= 0 {
+		sum.Lsh(sum, uint(shift))
+	} else {
+		sum.Rsh(sum, uint(-shift))
+	}
+	return sum, nil
+}
+
+// lnInternal calculates the natural logarithm of a positive x in the internal
+// representation by reducing the argument to x = 2^k * y with 1 <= y < 2 and
+// summing the series ln(y) = 2 * atanh((y-1)/(y+1)).
+func lnInternal(x *big.Int) *big.Int {
+	// Split the argument into a power of two and a mantissa in [1, 2)
+	k := x.BitLen() - fixedPrecision.BitLen()
+
+	y := new(big.Int)
+	if k >= 0 {
+		y.Rsh(x, uint(k))
+	} else {
+		y.Lsh(x, uint(-k))
+	}
+	for y.Cmp(fixedPrecision) < 0 {
+		y.Lsh(y, 1)
+		k--
+	}
+	for limit := new(big.Int).Lsh(fixedPrecision, 1); y.Cmp(limit) >= 0; {
+		y.Rsh(y, 1)
+		k++
+	}
+	// Sum the atanh series until the terms vanish
+	z := new(big.Int).Sub(y, fixedPrecision)
+	z.Mul(z, fixedPrecision)
+	z.Quo(z, new(big.Int).Add(y, fixedPrecision))
+
+	z2 := new(big.Int).Mul(z, z)
+	z2.Quo(z2, fixedPrecision)
+
+	var (
+		sum  = new(big.Int)
+		term = new(big.Int).Set(z)
+		part = new(big.Int)
+	)
+	for n := int64(1); ; n += 2 {
+		part.Quo(term, big.NewInt(n))
+		if part.Sign() == 0 {
+			break
+		}
+		sum.Add(sum, part)
+
+		term.Mul(term, z2)
+		term.Quo(term, fixedPrecision)
+	}
+	sum.Lsh(sum, 1)
+	return sum.Add(sum, new(big.Int).Mul(big.NewInt(int64(k)), fixedLn2))
+}
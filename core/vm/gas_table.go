@@ -286,6 +286,16 @@ func gasCreate(gt params.GasTable, evm *EVM, contract *Contract, stack *Stack, m
 	if gas, overflow = math.SafeAdd(gas, params.CreateGas); overflow {
 		return 0, errGasUintOverflow
 	}
+	// From the code size fork initcode is limited and charged per word
+	if limit := evm.ChainConfig().MaxInitCodeSizeAt(evm.BlockNumber); limit > 0 {
+		size, overflow := bigUint64(stack.Back(2))
+		if overflow || size > uint64(limit) {
+			return 0, errMaxInitCodeSizeExceeded
+		}
+		if gas, overflow = math.SafeAdd(gas, toWordSize(size)*params.InitCodeWordGas); overflow {
+			return 0, errGasUintOverflow
+		}
+	}
 	return gas, nil
 }
 
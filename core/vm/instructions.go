@@ -29,11 +29,12 @@ import (
 )
 
 var (
-	bigZero                  = new(big.Int)
-	errWriteProtection       = errors.New("evm: write protection")
-	errReturnDataOutOfBounds = errors.New("evm: return data out of bounds")
-	errExecutionReverted     = errors.New("evm: execution reverted")
-	errMaxCodeSizeExceeded   = errors.New("evm: max code size exceeded")
+	bigZero                    = new(big.Int)
+	errWriteProtection         = errors.New("evm: write protection")
+	errReturnDataOutOfBounds   = errors.New("evm: return data out of bounds")
+	errExecutionReverted       = errors.New("evm: execution reverted")
+	errMaxCodeSizeExceeded     = errors.New("evm: max code size exceeded")
+	errMaxInitCodeSizeExceeded = errors.New("evm: max initcode size exceeded")
 )
 
 func opAdd(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
@@ -582,6 +583,22 @@ func opSstore(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *S
 	return nil, nil
 }
 
+func opTload(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	loc := common.BigToHash(stack.pop())
+	val := evm.StateDB.GetTransientState(contract.Address(), loc).Big()
+	stack.push(val)
+	return nil, nil
+}
+
+func opTstore(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
+	loc := common.BigToHash(stack.pop())
+	val := stack.pop()
+	evm.StateDB.SetTransientState(contract.Address(), loc, common.BigToHash(val))
+
+	evm.interpreter.intPool.put(val)
+	return nil, nil
+}
+
 func opJump(pc *uint64, evm *EVM, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
 	pos := stack.pop()
 	if !contract.jumpdests.has(contract.CodeHash, contract.Code, pos) {
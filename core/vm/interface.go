@@ -45,6 +45,10 @@ type StateDB interface {
 	GetState(common.Address, common.Hash) common.Hash
 	SetState(common.Address, common.Hash, common.Hash)
 
+	GetTransientState(common.Address, common.Hash) common.Hash
+	SetTransientState(common.Address, common.Hash, common.Hash)
+	ClearTransientStorage()
+
 	Suicide(common.Address) bool
 	HasSuicided(common.Address) bool
 
@@ -75,6 +75,12 @@ func NewInterpreter(evm *EVM, cfg Config) *Interpreter {
 		default:
 			cfg.JumpTable = frontierInstructionSet
 		}
+		// Transient storage is an independent fork, layer it on whichever
+		// table was picked (the table is copied by value, leaving the
+		// shared defaults untouched)
+		if evm.ChainConfig().IsTransientStorage(evm.BlockNumber) {
+			enableTransientStorage(&cfg.JumpTable)
+		}
 	}
 
 	return &Interpreter{
@@ -57,6 +57,24 @@ var (
 	constantinopleInstructionSet = NewConstantinopleInstructionSet()
 )
 
+// enableTransientStorage adds the TLOAD and TSTORE transient storage opcodes
+// to an instruction set.
+func enableTransientStorage(instructionSet *[256]operation) {
+	instructionSet[TLOAD] = operation{
+		execute:       opTload,
+		gasCost:       constGasFunc(params.TloadGas),
+		validateStack: makeStackFunc(1, 1),
+		valid:         true,
+	}
+	instructionSet[TSTORE] = operation{
+		execute:       opTstore,
+		gasCost:       constGasFunc(params.TstoreGas),
+		validateStack: makeStackFunc(2, 0),
+		valid:         true,
+		writes:        true,
+	}
+}
+
 // NewConstantinopleInstructionSet returns the frontier, homestead
 // byzantium and contantinople instructions.
 func NewConstantinopleInstructionSet() [256]operation {
@@ -59,6 +59,9 @@ func (NoopStateDB) AddRefund(uint64)
 func (NoopStateDB) GetRefund() uint64                                                  { return 0 }
 func (NoopStateDB) GetState(common.Address, common.Hash) common.Hash                   { return common.Hash{} }
 func (NoopStateDB) SetState(common.Address, common.Hash, common.Hash)                  {}
+func (NoopStateDB) GetTransientState(common.Address, common.Hash) common.Hash          { return common.Hash{} }
+func (NoopStateDB) SetTransientState(common.Address, common.Hash, common.Hash)         {}
+func (NoopStateDB) ClearTransientStorage()                                             {}
 func (NoopStateDB) Suicide(common.Address) bool                                        { return false }
 func (NoopStateDB) HasSuicided(common.Address) bool                                    { return false }
 func (NoopStateDB) Exist(common.Address) bool                                          { return false }
@@ -113,6 +113,8 @@ const (
 	MSIZE
 	GAS
 	JUMPDEST
+	TLOAD
+	TSTORE
 )
 
 const (
@@ -286,6 +288,8 @@ var opCodeToString = map[OpCode]string{
 	MSIZE:    "MSIZE",
 	GAS:      "GAS",
 	JUMPDEST: "JUMPDEST",
+	TLOAD:    "TLOAD",
+	TSTORE:   "TSTORE",
 
 	// 0x60 range - push
 	PUSH1:  "PUSH1",
@@ -447,6 +451,8 @@ var stringToOp = map[string]OpCode{
 	"MSIZE":          MSIZE,
 	"GAS":            GAS,
 	"JUMPDEST":       JUMPDEST,
+	"TLOAD":          TLOAD,
+	"TSTORE":         TSTORE,
 	"PUSH1":          PUSH1,
 	"PUSH2":          PUSH2,
 	"PUSH3":          PUSH3,
@@ -23,9 +23,12 @@ import (
 
 	"github.com/usechain/go-usechain/accounts/abi"
 	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/contracts/delegation"
+	"github.com/usechain/go-usechain/contracts/minerlist"
 	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/core/vm"
 	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/params"
 )
 
 func TestDefaults(t *testing.T) {
@@ -149,3 +152,176 @@ func BenchmarkCall(b *testing.B) {
 		}
 	}
 }
+
+func TestTransientStorage(t *testing.T) {
+	code := []byte{
+		byte(vm.PUSH1), 42,
+		byte(vm.PUSH1), 1,
+		byte(vm.TSTORE),
+		byte(vm.PUSH1), 1,
+		byte(vm.TLOAD),
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	config := *params.TestChainConfig
+	config.TransientStorageBlock = big.NewInt(10)
+
+	// Before the fork the opcodes are invalid
+	if _, _, err := Execute(code, nil, &Config{ChainConfig: &config, BlockNumber: big.NewInt(9)}); err == nil {
+		t.Fatal("expected transient storage opcodes to be invalid before the fork")
+	}
+	// After the fork the stored value must be readable with the exact gas
+	ret, _, err := Execute(code, nil, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), GasLimit: 224})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(42)) != 0 {
+		t.Error("Expected 42, got", num)
+	}
+	if _, _, err := Execute(code, nil, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), GasLimit: 223}); err != vm.ErrOutOfGas {
+		t.Errorf("gas underpriced: have %v, want %v", err, vm.ErrOutOfGas)
+	}
+}
+
+func TestCodeSizeLimits(t *testing.T) {
+	// Deploys 30000 bytes of code, above the inherited limit
+	deploy := []byte{
+		byte(vm.PUSH2), 0x75, 0x30,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	config := *params.TestChainConfig
+	config.CodeSizeBlock = big.NewInt(10)
+	config.MaxCodeSize = 32768
+
+	if _, _, _, err := Create(deploy, &Config{ChainConfig: &config, BlockNumber: big.NewInt(9)}); err == nil {
+		t.Fatal("expected code size limit to be exceeded before the fork")
+	}
+	code, _, _, err := Create(deploy, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10)})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if len(code) != 30000 {
+		t.Errorf("deployed code size mismatch: have %d, want %d", len(code), 30000)
+	}
+	// Creates a contract from initcode of the given size
+	create := func(size uint32) []byte {
+		return []byte{
+			byte(vm.PUSH4), byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size),
+			byte(vm.PUSH1), 0,
+			byte(vm.PUSH1), 0,
+			byte(vm.CREATE),
+		}
+	}
+	if _, _, err := Execute(create(65537), nil, &Config{ChainConfig: &config, BlockNumber: big.NewInt(9)}); err != nil {
+		t.Fatal("didn't expect error before the fork", err)
+	}
+	if _, _, err := Execute(create(65537), nil, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10)}); err != vm.ErrOutOfGas {
+		t.Errorf("initcode limit mismatch: have %v, want %v", err, vm.ErrOutOfGas)
+	}
+	if _, _, err := Execute(create(65536), nil, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10)}); err != nil {
+		t.Fatal("didn't expect error at the initcode limit", err)
+	}
+	// Initcode is charged per word after the fork
+	_, _, before, _ := Create(create(64), &Config{ChainConfig: &config, BlockNumber: big.NewInt(9)})
+	_, _, after, _ := Create(create(64), &Config{ChainConfig: &config, BlockNumber: big.NewInt(10)})
+	if before-after != 2*params.InitCodeWordGas {
+		t.Errorf("initcode gas mismatch: have %d, want %d", before-after, 2*params.InitCodeWordGas)
+	}
+}
+
+const delegationABI = `[
+	{"type":"function","name":"delegate","inputs":[{"name":"candidate","type":"address"}]},
+	{"type":"function","name":"undelegate","inputs":[{"name":"candidate","type":"address"},{"name":"amount","type":"uint256"}]},
+	{"type":"function","name":"setCommission","inputs":[{"name":"rate","type":"uint256"}]}
+]`
+
+func TestDelegationRegistry(t *testing.T) {
+	registry, err := abi.JSON(strings.NewReader(delegationABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		miner   = common.Address{0x01}
+		holder  = common.Address{0x10}
+		stake   = new(big.Int).Mul(big.NewInt(2), delegation.MinDelegation)
+		funds   = new(big.Int).Mul(big.NewInt(10), delegation.MinDelegation)
+		db, _   = ethdb.NewMemDatabase()
+		statedb *state.StateDB
+	)
+	statedb, _ = state.New(common.Hash{}, state.NewDatabase(db))
+	statedb.SetState(common.HexToAddress(minerlist.MinerListContract), minerlist.MinerKey(miner), common.BigToHash(common.Big1))
+	statedb.AddBalance(holder, funds)
+
+	config := *params.TestChainConfig
+	config.DelegationBlock = big.NewInt(10)
+
+	input, _ := registry.Pack("delegate", miner)
+
+	// Before the fork the registry is a plain account
+	Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(9), State: statedb, Origin: holder, Value: stake})
+	if have := delegation.Stake(statedb, miner, holder); have.Sign() != 0 {
+		t.Fatalf("stake registered before the fork: %v", have)
+	}
+	statedb.SubBalance(delegation.Address, stake)
+	statedb.AddBalance(holder, stake)
+
+	// After the fork the stake is held by the registry
+	if _, _, err := Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), State: statedb, Origin: holder, Value: stake}); err != nil {
+		t.Fatalf("failed to delegate: %v", err)
+	}
+	if have := delegation.Stake(statedb, miner, holder); have.Cmp(stake) != 0 {
+		t.Errorf("stake mismatch: have %v, want %v", have, stake)
+	}
+	if have := statedb.GetBalance(delegation.Address); have.Cmp(stake) != 0 {
+		t.Errorf("registry balance mismatch: have %v, want %v", have, stake)
+	}
+	// Failed operations revert the value transfer
+	input, _ = registry.Pack("delegate", common.Address{0x02})
+	if _, _, err := Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), State: statedb, Origin: holder, Value: stake}); err != delegation.ErrNotMiner {
+		t.Errorf("delegation to non-miner: have %v, want %v", err, delegation.ErrNotMiner)
+	}
+	if have := statedb.GetBalance(holder); have.Cmp(new(big.Int).Sub(funds, stake)) != 0 {
+		t.Errorf("holder balance after failed delegation: have %v, want %v", have, new(big.Int).Sub(funds, stake))
+	}
+	// Withdrawals are paid out of the registry
+	input, _ = registry.Pack("undelegate", miner, delegation.MinDelegation)
+	if _, _, err := Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), State: statedb, Origin: holder}); err != nil {
+		t.Fatalf("failed to undelegate: %v", err)
+	}
+	if have := statedb.GetBalance(holder); have.Cmp(new(big.Int).Sub(funds, delegation.MinDelegation)) != 0 {
+		t.Errorf("holder balance after withdrawal: have %v, want %v", have, new(big.Int).Sub(funds, delegation.MinDelegation))
+	}
+	// Only miners may set a commission
+	input, _ = registry.Pack("setCommission", big.NewInt(500))
+	if _, _, err := Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), State: statedb, Origin: holder}); err != delegation.ErrNotMiner {
+		t.Errorf("commission of non-miner: have %v, want %v", err, delegation.ErrNotMiner)
+	}
+	if _, _, err := Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), State: statedb, Origin: miner}); err != nil {
+		t.Errorf("failed to set commission: %v", err)
+	}
+	// Storage writes are charged on top of the base cost, running short reverts
+	other := common.Address{0x20}
+	statedb.AddBalance(other, funds)
+
+	input, _ = registry.Pack("delegate", miner)
+	if _, _, err := Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), State: statedb, Origin: other, Value: stake, GasLimit: params.DelegationGas + params.SstoreSetGas}); err != vm.ErrOutOfGas {
+		t.Errorf("underpaid delegation: have %v, want %v", err, vm.ErrOutOfGas)
+	}
+	if have := delegation.Stake(statedb, miner, other); have.Sign() != 0 {
+		t.Errorf("stake registered by underpaid delegation: %v", have)
+	}
+	if have := statedb.GetBalance(other); have.Cmp(funds) != 0 {
+		t.Errorf("balance after underpaid delegation: have %v, want %v", have, funds)
+	}
+	_, left, err := Call(delegation.Address, input, &Config{ChainConfig: &config, BlockNumber: big.NewInt(10), State: statedb, Origin: other, Value: stake, GasLimit: 1000000})
+	if err != nil {
+		t.Fatalf("failed to delegate: %v", err)
+	}
+	if used := 1000000 - left; used < params.DelegationGas+3*params.SstoreSetGas {
+		t.Errorf("delegation gas too low: have %d, want at least %d", used, params.DelegationGas+3*params.SstoreSetGas)
+	}
+}
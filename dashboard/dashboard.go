@@ -64,6 +64,7 @@ type Dashboard struct {
 	conns    map[uint32]*client // Currently live websocket connections
 	charts   *HomeMessage
 	commit   string
+	server   *p2p.Server  // Running p2p server to report the peer traffic of
 	lock     sync.RWMutex // Lock protecting the dashboard's internals
 
 	quit chan chan error // Channel used for graceful exit
@@ -120,6 +121,8 @@ func (db *Dashboard) APIs() []rpc.API { return nil }
 func (db *Dashboard) Start(server *p2p.Server) error {
 	log.Info("Starting dashboard")
 
+	db.server = server
+
 	db.wg.Add(2)
 	go db.collectData()
 	go db.collectLogs() // In case of removing this line change 2 back to 1 in wg.Add.
@@ -251,6 +254,7 @@ func (db *Dashboard) apiHandler(conn *websocket.Conn) {
 			DiskRead:       db.charts.DiskRead,
 			DiskWrite:      db.charts.DiskWrite,
 		},
+		Network: db.networkMessage(),
 	}
 	// Start tracking the connection and drop at connection loss.
 	db.lock.Lock()
@@ -373,11 +377,23 @@ func (db *Dashboard) collectData() {
 					DiskRead:       ChartEntries{diskRead},
 					DiskWrite:      ChartEntries{diskWrite},
 				},
+				Network: db.networkMessage(),
 			})
 		}
 	}
 }
 
+// networkMessage gathers the traffic accounting of the connected peers, or nil
+// if the dashboard runs without a p2p server.
+func (db *Dashboard) networkMessage() *NetworkMessage {
+	if db.server == nil {
+		return nil
+	}
+	return &NetworkMessage{
+		Peers: db.server.PeerStats(),
+	}
+}
+
 // collectLogs collects and sends the logs to the active dashboards.
 func (db *Dashboard) collectLogs() {
 	defer db.wg.Done()
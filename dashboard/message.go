@@ -16,7 +16,11 @@
 
 package dashboard
 
-import "time"
+import (
+	"time"
+
+	"github.com/usechain/go-usechain/p2p"
+)
 
 type Message struct {
 	General *GeneralMessage `json:"general,omitempty"`
@@ -60,7 +64,7 @@ type TxPoolMessage struct {
 }
 
 type NetworkMessage struct {
-	/* TODO (kurkomisi) */
+	Peers []*p2p.PeerStats `json:"peers,omitempty"` // Traffic of the connected peers, heaviest first
 }
 
 type SystemMessage struct {
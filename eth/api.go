@@ -30,6 +30,7 @@ import (
 	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/log"
 	"github.com/usechain/go-usechain/miner"
 	"github.com/usechain/go-usechain/params"
@@ -242,6 +243,12 @@ func hasAllBlocks(chain *core.BlockChain, bs []*types.Block) bool {
 	return true
 }
 
+// SyncStatus retrieves the progress of the chain synchronisation along with the
+// measured quality and current assignments of every download peer.
+func (api *PrivateAdminAPI) SyncStatus() *downloader.SyncStatus {
+	return api.eth.Downloader().SyncStatus()
+}
+
 // ImportChain imports a blockchain from a local file.
 func (api *PrivateAdminAPI) ImportChain(file string) (bool, error) {
 	// Make sure the can access the file to import
@@ -313,9 +320,14 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 		return stateDb.RawDump(), nil
 	}
 	var block *types.Block
-	if blockNr == rpc.LatestBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber:
 		block = api.eth.blockchain.CurrentBlock()
-	} else {
+	case rpc.SafeBlockNumber:
+		block = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.eth.blockchain.CurrentFinalizedBlock()
+	default:
 		block = api.eth.blockchain.GetBlockByNumber(uint64(blockNr))
 	}
 	if block == nil {
@@ -328,6 +340,88 @@ func (api *PublicDebugAPI) DumpBlock(blockNr rpc.BlockNumber) (state.Dump, error
 	return stateDb.RawDump(), nil
 }
 
+// ExportIdentities retrieves a normalized snapshot of the identity registry at a
+// given block for compliance reporting.
+func (api *PublicDebugAPI) ExportIdentities(blockNr rpc.BlockNumber) (*core.IdentityExport, error) {
+	header, stateAt, err := api.identityRegistry(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	stateDb, err := stateAt()
+	if err != nil {
+		return nil, err
+	}
+	return core.ExportIdentities(stateDb, header), nil
+}
+
+// ExportIdentitiesPaged retrieves the records of the identity registry at a given
+// block page by page, with at most pageSize records each (0 = default page size).
+// Unlike the full export, the records are listed in storage order: committee,
+// one time addresses, then certificates by id. Every page only reads the storage
+// of its own records.
+func (api *PublicDebugAPI) ExportIdentitiesPaged(ctx context.Context, blockNr rpc.BlockNumber, pageSize int) (*rpc.Page, error) {
+	_, stateAt, err := api.identityRegistry(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.Paginate(ctx, "debug_exportIdentitiesPaged", &identityIterator{stateAt: stateAt}, pageSize)
+}
+
+// identityRegistry resolves the block to read the identity registry at, returning
+// its header along with a function opening its state.
+func (api *PublicDebugAPI) identityRegistry(blockNr rpc.BlockNumber) (*types.Header, func() (*state.StateDB, error), error) {
+	if blockNr == rpc.PendingBlockNumber {
+		block, stateDb := api.eth.miner.Pending()
+		return block.Header(), func() (*state.StateDB, error) { return stateDb, nil }, nil
+	}
+	var block *types.Block
+	switch blockNr {
+	case rpc.LatestBlockNumber:
+		block = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		block = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.eth.blockchain.CurrentFinalizedBlock()
+	default:
+		block = api.eth.blockchain.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	root := block.Root()
+	if _, err := api.eth.BlockChain().StateAt(root); err != nil {
+		return nil, nil, err
+	}
+	return block.Header(), func() (*state.StateDB, error) { return api.eth.BlockChain().StateAt(root) }, nil
+}
+
+// identityIterator pages through the identity registry, reopening the state for
+// every page so only the storage of the requested records is held.
+type identityIterator struct {
+	stateAt func() (*state.StateDB, error)
+	next    core.IdentityKey // Position of the next record to read
+}
+
+func (it *identityIterator) Next(ctx context.Context, limit int) ([]interface{}, bool, error) {
+	stateDb, err := it.stateAt()
+	if err != nil {
+		return nil, false, err
+	}
+	records, next := core.ReadIdentities(stateDb, it.next, limit)
+
+	items := make([]interface{}, len(records))
+	for i, record := range records {
+		items[i] = record
+	}
+	if next == nil {
+		return items, true, nil
+	}
+	it.next = *next
+	return items, false, nil
+}
+
+func (it *identityIterator) Close() {}
+
 // PrivateDebugAPI is the collection of Ethereum full node APIs exposed over
 // the private debugging endpoint.
 type PrivateDebugAPI struct {
@@ -0,0 +1,85 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/internal/ethapi"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// maxActivityRange is the maximum number of blocks scanned by a single account
+// activity query.
+const maxActivityRange = 10000
+
+// ActivityResult lists the blocks of a range which may have touched an account.
+type ActivityResult struct {
+	Blocks    []hexutil.Uint64 `json:"blocks"`              // Blocks whose activity bloom matches the account
+	Unindexed []hexutil.Uint64 `json:"unindexed,omitempty"` // Blocks without activity bloom, to be checked by other means
+}
+
+// PublicActivityAPI provides an API to query the account activity blooms,
+// allowing wallets to cheaply find the blocks which may affect their accounts.
+type PublicActivityAPI struct {
+	e *Ethereum
+}
+
+// NewPublicActivityAPI creates a new account activity API.
+func NewPublicActivityAPI(e *Ethereum) *PublicActivityAPI {
+	return &PublicActivityAPI{e: e}
+}
+
+// GetActivityBlocks returns the canonical blocks in the inclusive range whose
+// activity bloom indicates the account may have been touched. Being a bloom,
+// false positives are possible, but no touching block is missed. Blocks which
+// were imported without the activity index are reported separately.
+func (api *PublicActivityAPI) GetActivityBlocks(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber) (*ActivityResult, error) {
+	from, err := ethapi.ResolveBlockNumber(ctx, api.e.ApiBackend, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ethapi.ResolveBlockNumber(ctx, api.e.ApiBackend, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d > %d", from, to)
+	}
+	if to-from >= maxActivityRange {
+		return nil, fmt.Errorf("block range %d-%d too large, max %d blocks", from, to, maxActivityRange)
+	}
+	result := &ActivityResult{Blocks: []hexutil.Uint64{}}
+	for number := from; number <= to; number++ {
+		hash := core.GetCanonicalHash(api.e.ChainDb(), number)
+		if hash == (common.Hash{}) {
+			break
+		}
+		bloom, ok := core.GetActivityBloom(api.e.ChainDb(), hash, number)
+		switch {
+		case !ok:
+			result.Unindexed = append(result.Unindexed, hexutil.Uint64(number))
+		case core.ActivityBloomMatch(bloom, address):
+			result.Blocks = append(result.Blocks, hexutil.Uint64(number))
+		}
+	}
+	return result, nil
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/usechain/go-usechain/core/vm"
 	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/eth/gasprice"
+	"github.com/usechain/go-usechain/eth/txtracker"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
 	"github.com/usechain/go-usechain/params"
@@ -62,8 +63,13 @@ func (b *EthApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNum
 		return block.Header(), nil
 	}
 	// Otherwise resolve and return the block
-	if blockNr == rpc.LatestBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber:
 		return b.eth.blockchain.CurrentBlock().Header(), nil
+	case rpc.SafeBlockNumber:
+		return b.eth.blockchain.CurrentSafeBlock().Header(), nil
+	case rpc.FinalizedBlockNumber:
+		return b.eth.blockchain.CurrentFinalizedBlock().Header(), nil
 	}
 	return b.eth.blockchain.GetHeaderByNumber(uint64(blockNr)), nil
 }
@@ -75,8 +81,13 @@ func (b *EthApiBackend) BlockByNumber(ctx context.Context, blockNr rpc.BlockNumb
 		return block, nil
 	}
 	// Otherwise resolve and return the block
-	if blockNr == rpc.LatestBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber:
 		return b.eth.blockchain.CurrentBlock(), nil
+	case rpc.SafeBlockNumber:
+		return b.eth.blockchain.CurrentSafeBlock(), nil
+	case rpc.FinalizedBlockNumber:
+		return b.eth.blockchain.CurrentFinalizedBlock(), nil
 	}
 	return b.eth.blockchain.GetBlockByNumber(uint64(blockNr)), nil
 }
@@ -140,6 +151,10 @@ func (b *EthApiBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) e
 	return b.eth.BlockChain().SubscribeChainHeadEvent(ch)
 }
 
+func (b *EthApiBackend) SubscribeFinalizedHeadEvent(ch chan<- core.FinalizedHeadEvent) event.Subscription {
+	return b.eth.BlockChain().SubscribeFinalizedHeadEvent(ch)
+}
+
 func (b *EthApiBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
 	return b.eth.BlockChain().SubscribeChainSideEvent(ch)
 }
@@ -149,7 +164,30 @@ func (b *EthApiBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthApiBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	return b.eth.txPool.AddLocal(signedTx)
+	if err := b.eth.txPool.AddLocal(signedTx); err != nil {
+		return err
+	}
+	if b.eth.txTracker != nil {
+		b.eth.txTracker.Track(signedTx)
+	}
+	return nil
+}
+
+// TrackTransaction returns the tracking status of a local transaction, starting
+// to track it if still in the pool but not yet followed.
+func (b *EthApiBackend) TrackTransaction(hash common.Hash) (*txtracker.Status, error) {
+	if b.eth.txTracker == nil {
+		return nil, txtracker.ErrDisabled
+	}
+	if status := b.eth.txTracker.Status(hash); status != nil {
+		return status, nil
+	}
+	tx := b.eth.txPool.Get(hash)
+	if tx == nil {
+		return nil, nil
+	}
+	b.eth.txTracker.Track(tx)
+	return b.eth.txTracker.Status(hash), nil
 }
 
 func (b *EthApiBackend) GetPoolTransactions() (types.Transactions, error) {
@@ -172,18 +210,34 @@ func (b *EthApiBackend) GetPoolNonce(ctx context.Context, addr common.Address) (
 	return b.eth.txPool.State().GetNonce(addr), nil
 }
 
+func (b *EthApiBackend) PriceBump() uint64 {
+	return b.eth.txPool.PriceBump()
+}
+
 func (b *EthApiBackend) Stats() (pending int, queued int) {
 	return b.eth.txPool.Stats()
 }
 
+func (b *EthApiBackend) TxPoolLocals() []common.Address {
+	return b.eth.TxPool().Locals()
+}
+
 func (b *EthApiBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
 	return b.eth.TxPool().Content()
 }
 
+func (b *EthApiBackend) TxPoolContentRange(start core.TxPoolKey, limit int) (types.Transactions, types.Transactions, *core.TxPoolKey) {
+	return b.eth.TxPool().ContentRange(start, limit)
+}
+
 func (b *EthApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
 	return b.eth.TxPool().SubscribeTxPreEvent(ch)
 }
 
+func (b *EthApiBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return b.eth.TxPool().SubscribeTxDropEvent(ch)
+}
+
 func (b *EthApiBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
@@ -196,6 +250,10 @@ func (b *EthApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *EthApiBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blocks, lastBlock, percentiles)
+}
+
 func (b *EthApiBackend) ChainDb() ethdb.Database {
 	return b.eth.ChainDb()
 }
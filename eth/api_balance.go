@@ -0,0 +1,104 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/internal/ethapi"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// BalanceChangeResult is a single attributed change to the balance of an account.
+type BalanceChangeResult struct {
+	BlockNumber hexutil.Uint64           `json:"blockNumber"`
+	BlockHash   common.Hash              `json:"blockHash"`
+	TxHash      *common.Hash             `json:"transactionHash"` // nil for block rewards
+	Reason      core.BalanceChangeReason `json:"reason"`
+	Amount      *hexutil.Big             `json:"amount"` // Negative for debits
+}
+
+// BalanceChangesResult lists the balance changes of an account over a range.
+type BalanceChangesResult struct {
+	Changes   []*BalanceChangeResult `json:"changes"`
+	Unindexed []hexutil.Uint64       `json:"unindexed,omitempty"` // Blocks without balance index, to be checked by other means
+}
+
+// PublicBalanceAPI provides an API to query the balance change index, allowing
+// accounting systems to follow accounts without re-tracing blocks.
+type PublicBalanceAPI struct {
+	e *Ethereum
+}
+
+// NewPublicBalanceAPI creates a new balance change API.
+func NewPublicBalanceAPI(e *Ethereum) *PublicBalanceAPI {
+	return &PublicBalanceAPI{e: e}
+}
+
+// GetBalanceChanges returns every change to the balance of an account over the
+// inclusive range of canonical blocks, attributed to value transfers, fees,
+// block rewards or internal transfers. Blocks which were imported without the
+// balance index are reported separately.
+func (api *PublicBalanceAPI) GetBalanceChanges(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber) (*BalanceChangesResult, error) {
+	from, err := ethapi.ResolveBlockNumber(ctx, api.e.ApiBackend, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ethapi.ResolveBlockNumber(ctx, api.e.ApiBackend, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d > %d", from, to)
+	}
+	if to-from >= maxActivityRange {
+		return nil, fmt.Errorf("block range %d-%d too large, max %d blocks", from, to, maxActivityRange)
+	}
+	result := &BalanceChangesResult{Changes: []*BalanceChangeResult{}}
+	for number := from; number <= to; number++ {
+		hash := core.GetCanonicalHash(api.e.ChainDb(), number)
+		if hash == (common.Hash{}) {
+			break
+		}
+		changes, ok := core.GetBalanceChanges(api.e.ChainDb(), hash, number)
+		if !ok {
+			result.Unindexed = append(result.Unindexed, hexutil.Uint64(number))
+			continue
+		}
+		for _, change := range changes {
+			if change.Address != address {
+				continue
+			}
+			res := &BalanceChangeResult{
+				BlockNumber: hexutil.Uint64(number),
+				BlockHash:   hash,
+				Reason:      change.Reason,
+				Amount:      (*hexutil.Big)(change.Amount),
+			}
+			if change.TxHash != (common.Hash{}) {
+				txHash := change.TxHash
+				res.TxHash = &txHash
+			}
+			result.Changes = append(result.Changes, res)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// CircuitBreaker returns the invariant violation which halted local block
+// production and relay, or nil if the chain is healthy.
+func (api *PrivateDebugAPI) CircuitBreaker() *core.InvariantViolation {
+	return api.eth.BlockChain().Halted()
+}
+
+// ResetCircuitBreaker resumes block production and relay after a halt. It is
+// meant to be called once the operator investigated the violation, and reports
+// whether the breaker was tripped.
+func (api *PrivateDebugAPI) ResetCircuitBreaker() bool {
+	halted := api.eth.BlockChain().Halted() != nil
+	api.eth.BlockChain().ResetHalt()
+	return halted
+}
+
+// ChainHalt creates a subscription notified with the violated invariant each
+// time the circuit breaker trips.
+func (api *PrivateDebugAPI) ChainHalt(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		halts := make(chan core.ChainHaltEvent)
+		haltSub := api.eth.BlockChain().SubscribeHaltEvent(halts)
+		defer haltSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-halts:
+				notifier.Notify(rpcSub.ID, ev.Violation)
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
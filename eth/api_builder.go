@@ -0,0 +1,134 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+// TemplateTransaction is a transaction included in a block template, along with
+// the gas it used and the fee it pays on top of the template's parent.
+type TemplateTransaction struct {
+	Hash    common.Hash    `json:"hash"`
+	Raw     hexutil.Bytes  `json:"raw"`
+	GasUsed hexutil.Uint64 `json:"gasUsed"`
+	Fee     *hexutil.Big   `json:"fee"`
+}
+
+// BlockTemplate is the assembly context handed out to external block builders:
+// the header the local miner would seal next, the pending transactions it
+// selected and the state they produce.
+type BlockTemplate struct {
+	ParentHash   common.Hash            `json:"parentHash"`
+	Number       *hexutil.Big           `json:"number"`
+	Timestamp    *hexutil.Big           `json:"timestamp"`
+	Coinbase     common.Address         `json:"miner"`
+	Difficulty   *hexutil.Big           `json:"difficulty"`
+	GasLimit     hexutil.Uint64         `json:"gasLimit"`
+	GasUsed      hexutil.Uint64         `json:"gasUsed"`
+	Extra        hexutil.Bytes          `json:"extraData"`
+	StateRoot    common.Hash            `json:"stateRoot"`
+	ReceiptRoot  common.Hash            `json:"receiptsRoot"`
+	Fees         *hexutil.Big           `json:"fees"`
+	Transactions []*TemplateTransaction `json:"transactions"`
+}
+
+// PrivateBuilderAPI lets external block builders assemble blocks from the local
+// pending context and hand them back for validation and import.
+type PrivateBuilderAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateBuilderAPI creates a new block builder API.
+func NewPrivateBuilderAPI(e *Ethereum) *PrivateBuilderAPI {
+	return &PrivateBuilderAPI{e: e}
+}
+
+// GetBlockTemplate returns the block the local miner is currently assembling.
+func (api *PrivateBuilderAPI) GetBlockTemplate() (*BlockTemplate, error) {
+	block, receipts, fees := api.e.Miner().Template()
+	if block == nil {
+		return nil, errors.New("no pending block")
+	}
+	header := block.Header()
+	template := &BlockTemplate{
+		ParentHash:   header.ParentHash,
+		Number:       (*hexutil.Big)(header.Number),
+		Timestamp:    (*hexutil.Big)(header.Time),
+		Coinbase:     header.Coinbase,
+		Difficulty:   (*hexutil.Big)(header.Difficulty),
+		GasLimit:     hexutil.Uint64(header.GasLimit),
+		GasUsed:      hexutil.Uint64(header.GasUsed),
+		Extra:        hexutil.Bytes(header.Extra),
+		StateRoot:    header.Root,
+		ReceiptRoot:  header.ReceiptHash,
+		Fees:         (*hexutil.Big)(fees),
+		Transactions: make([]*TemplateTransaction, 0, len(block.Transactions())),
+	}
+	for i, tx := range block.Transactions() {
+		raw, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			return nil, err
+		}
+		entry := &TemplateTransaction{Hash: tx.Hash(), Raw: raw}
+		if i < len(receipts) {
+			entry.GasUsed = hexutil.Uint64(receipts[i].GasUsed)
+			entry.Fee = (*hexutil.Big)(new(big.Int).Mul(new(big.Int).SetUint64(receipts[i].GasUsed), tx.GasPrice()))
+		}
+		template.Transactions = append(template.Transactions, entry)
+	}
+	return template, nil
+}
+
+// SubmitBlock accepts an RLP encoded block built and sealed externally. The
+// block is fully validated and imported, then announced to the network like a
+// locally mined one if it became the canonical head.
+func (api *PrivateBuilderAPI) SubmitBlock(raw hexutil.Bytes) (common.Hash, error) {
+	block := new(types.Block)
+	if err := rlp.DecodeBytes(raw, block); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid block encoding: %v", err)
+	}
+	chain := api.e.BlockChain()
+	if chain.HasBlock(block.Hash(), block.NumberU64()) {
+		return common.Hash{}, core.ErrKnownBlock
+	}
+	if chain.GetBlock(block.ParentHash(), block.NumberU64()-1) == nil {
+		return common.Hash{}, fmt.Errorf("unknown parent %x", block.ParentHash())
+	}
+	if _, err := chain.InsertChain(types.Blocks{block}); err != nil {
+		return common.Hash{}, err
+	}
+	// Only announce the block if it became the new head, side blocks would be
+	// dropped by the peers anyway
+	if chain.CurrentBlock().Hash() != block.Hash() {
+		log.Info("Imported externally built side block", "number", block.Number(), "hash", block.Hash(), "txs", len(block.Transactions()))
+		return block.Hash(), nil
+	}
+	log.Info("Imported externally built block", "number", block.Number(), "hash", block.Hash(), "txs", len(block.Transactions()))
+	api.e.EventMux().Post(core.NewMinedBlockEvent{Block: block})
+
+	return block.Hash(), nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/event"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+// Tests that submitted blocks are only announced if they become the new head.
+func TestSubmitBlockAnnounce(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	gspec := core.DefaultRPOWTestingGenesisBlock()
+	genesis := gspec.MustCommit(db)
+	engine := ethash.NewFakerUsechain(db)
+
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	defer blockchain.Stop()
+
+	canon, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 2, nil)
+	if _, err := blockchain.InsertChain(canon); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	side, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, gen *core.BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	head, _ := core.GenerateChain(gspec.Config, canon[1], engine, db, 1, nil)
+
+	mux := new(event.TypeMux)
+	defer mux.Stop()
+
+	sub := mux.Subscribe(core.NewMinedBlockEvent{})
+	announced := make(chan common.Hash, 2)
+	go func() {
+		for ev := range sub.Chan() {
+			announced <- ev.Data.(core.NewMinedBlockEvent).Block.Hash()
+		}
+	}()
+	api := NewPrivateBuilderAPI(&Ethereum{blockchain: blockchain, eventMux: mux})
+
+	for _, block := range []*types.Block{side[0], head[0]} {
+		raw, _ := rlp.EncodeToBytes(block)
+		if hash, err := api.SubmitBlock(raw); err != nil || hash != block.Hash() {
+			t.Fatalf("block #%d: submission failed: hash %x, err %v", block.NumberU64(), hash, err)
+		}
+	}
+	select {
+	case hash := <-announced:
+		if hash != head[0].Hash() {
+			t.Errorf("announced block mismatch: have %x, want %x", hash, head[0].Hash())
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("new head not announced")
+	}
+	select {
+	case hash := <-announced:
+		t.Errorf("unexpected announcement of %x", hash)
+	default:
+	}
+}
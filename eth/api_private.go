@@ -0,0 +1,201 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/internal/ethapi"
+	"github.com/usechain/go-usechain/p2p/discover"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// privateDistributeTimeout is the time allowed for each recipient to accept a
+// distributed private payload.
+const privateDistributeTimeout = 10 * time.Second
+
+var (
+	errPrivateStateDisabled = errors.New("private state disabled")
+	errPrivateUnauthorized  = errors.New("private payload not signed by an authorized node")
+)
+
+// PrivateStateAPI manages the off-chain payloads of private transactions and
+// exposes the private state they produce.
+type PrivateStateAPI struct {
+	e *Ethereum
+}
+
+// NewPrivateStateAPI creates a new private transaction API.
+func NewPrivateStateAPI(e *Ethereum) *PrivateStateAPI {
+	return &PrivateStateAPI{e: e}
+}
+
+// StorePayload stores a salted private payload distributed by another node,
+// making this node a party to the private transactions referencing it, and
+// returns its commitment. The commitment must be signed by the key of a node
+// authorized to distribute private payloads to this one.
+func (api *PrivateStateAPI) StorePayload(payload, salt, signature hexutil.Bytes) (common.Hash, error) {
+	if api.e.privateDb == nil {
+		return common.Hash{}, errPrivateStateDisabled
+	}
+	if len(payload) == 0 {
+		return common.Hash{}, fmt.Errorf("empty private payload")
+	}
+	commitment := core.PrivatePayloadCommitment(salt, payload)
+	if !api.authorized(commitment, signature) {
+		return common.Hash{}, errPrivateUnauthorized
+	}
+	return core.WritePrivatePayload(api.e.privateDb, salt, payload)
+}
+
+// authorized reports whether a payload commitment was signed by the local node
+// or by one of the nodes authorized to distribute private payloads.
+func (api *PrivateStateAPI) authorized(commitment common.Hash, signature []byte) bool {
+	if len(signature) != 65 {
+		return false
+	}
+	pubkey, err := crypto.SigToPub(commitment.Bytes(), signature)
+	if err != nil {
+		return false
+	}
+	id := discover.PubkeyID(pubkey)
+	if key := api.e.nodeKey; key != nil && id == discover.PubkeyID(&key.PublicKey) {
+		return true
+	}
+	for _, node := range api.e.config.PrivateNodes {
+		if id == node {
+			return true
+		}
+	}
+	return false
+}
+
+// Distribute stores a private payload locally and on each of the recipient
+// nodes, given by their RPC endpoints. The payload is blinded with a random
+// salt and signed by the local node key, so the recipients can check that it
+// comes from an authorized node. It must reach every recipient before the
+// transaction referencing it is sent.
+func (api *PrivateStateAPI) Distribute(ctx context.Context, payload hexutil.Bytes, recipients []string) (common.Hash, error) {
+	if api.e.privateDb == nil {
+		return common.Hash{}, errPrivateStateDisabled
+	}
+	if len(payload) == 0 {
+		return common.Hash{}, fmt.Errorf("empty private payload")
+	}
+	if api.e.nodeKey == nil {
+		return common.Hash{}, fmt.Errorf("node key unavailable")
+	}
+	salt := make([]byte, core.PrivatePayloadSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return common.Hash{}, err
+	}
+	commitment, err := core.WritePrivatePayload(api.e.privateDb, salt, payload)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signature, err := crypto.Sign(commitment.Bytes(), api.e.nodeKey)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	for _, url := range recipients {
+		if err := distributePayload(ctx, url, payload, salt, signature, commitment); err != nil {
+			return common.Hash{}, fmt.Errorf("recipient %s: %v", url, err)
+		}
+	}
+	return commitment, nil
+}
+
+// distributePayload hands a signed private payload over to a single recipient.
+func distributePayload(ctx context.Context, url string, payload, salt, signature hexutil.Bytes, commitment common.Hash) error {
+	ctx, cancel := context.WithTimeout(ctx, privateDistributeTimeout)
+	defer cancel()
+
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var stored common.Hash
+	if err := client.CallContext(ctx, &stored, "private_storePayload", payload, salt, signature); err != nil {
+		return err
+	}
+	if stored != commitment {
+		return fmt.Errorf("commitment mismatch: have %x, want %x", stored, commitment)
+	}
+	return nil
+}
+
+// GetPayload returns the locally known private payload of a commitment.
+func (api *PrivateStateAPI) GetPayload(commitment common.Hash) (hexutil.Bytes, error) {
+	if api.e.privateDb == nil {
+		return nil, errPrivateStateDisabled
+	}
+	payload := core.GetPrivatePayload(api.e.privateDb, commitment)
+	if payload == nil {
+		return nil, fmt.Errorf("private payload %x not found", commitment)
+	}
+	return payload, nil
+}
+
+// TxData returns the transaction data referencing a private payload, to be
+// sent in place of the payload itself.
+func (api *PrivateStateAPI) TxData(commitment common.Hash) hexutil.Bytes {
+	return core.PrivateTxData(commitment)
+}
+
+// GetCode returns the code of a private contract at the given block.
+func (api *PrivateStateAPI) GetCode(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
+	statedb, err := api.stateAt(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return statedb.GetCode(address), nil
+}
+
+// GetStorageAt returns a storage slot of a private contract at the given block.
+func (api *PrivateStateAPI) GetStorageAt(ctx context.Context, address common.Address, key common.Hash, blockNr rpc.BlockNumber) (common.Hash, error) {
+	statedb, err := api.stateAt(ctx, blockNr)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.GetState(address, key), nil
+}
+
+// stateAt opens the private state after a canonical block.
+func (api *PrivateStateAPI) stateAt(ctx context.Context, blockNr rpc.BlockNumber) (*state.StateDB, error) {
+	chain := api.e.BlockChain()
+
+	number, err := ethapi.ResolveBlockNumber(ctx, api.e.ApiBackend, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	block := chain.GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	return chain.PrivateStateAt(block.Hash())
+}
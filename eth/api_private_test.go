@@ -0,0 +1,85 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/p2p/discover"
+)
+
+// Tests that private payloads are salted and only accepted from the nodes
+// authorized to distribute them.
+func TestPrivatePayloadAuthorization(t *testing.T) {
+	var (
+		local, _    = crypto.GenerateKey()
+		partner, _  = crypto.GenerateKey()
+		stranger, _ = crypto.GenerateKey()
+		db, _       = ethdb.NewMemDatabase()
+	)
+	api := NewPrivateStateAPI(&Ethereum{
+		config:    &Config{PrivateNodes: []discover.NodeID{discover.PubkeyID(&partner.PublicKey)}},
+		privateDb: db,
+		nodeKey:   local,
+	})
+	payload := []byte{0x01, 0x02}
+	salt := bytes.Repeat([]byte{0x42}, core.PrivatePayloadSaltLength)
+	commitment := core.PrivatePayloadCommitment(salt, payload)
+
+	// Payloads signed by unknown nodes must be refused
+	signature, _ := crypto.Sign(commitment.Bytes(), stranger)
+	if _, err := api.StorePayload(payload, salt, signature); err != errPrivateUnauthorized {
+		t.Fatalf("unknown signer error mismatch: have %v, want %v", err, errPrivateUnauthorized)
+	}
+	if _, err := api.GetPayload(commitment); err == nil {
+		t.Fatalf("unauthorized payload stored")
+	}
+	// Payloads signed by authorized nodes must be stored
+	signature, _ = crypto.Sign(commitment.Bytes(), partner)
+	stored, err := api.StorePayload(payload, salt, signature)
+	if err != nil {
+		t.Fatalf("failed to store authorized payload: %v", err)
+	}
+	if stored != commitment {
+		t.Fatalf("commitment mismatch: have %x, want %x", stored, commitment)
+	}
+	if have, _ := api.GetPayload(commitment); !bytes.Equal(have, payload) {
+		t.Fatalf("payload mismatch: have %x, want %x", have, payload)
+	}
+	// Distributed payloads must be blinded by a fresh salt every time
+	first, err := api.Distribute(context.Background(), payload, nil)
+	if err != nil {
+		t.Fatalf("failed to distribute payload: %v", err)
+	}
+	second, _ := api.Distribute(context.Background(), payload, nil)
+	if first == second || first == crypto.Keccak256Hash(payload) {
+		t.Errorf("distributed commitments not salted: %x, %x", first, second)
+	}
+	if have, _ := api.GetPayload(first); !bytes.Equal(have, payload) {
+		t.Errorf("distributed payload mismatch: have %x, want %x", have, payload)
+	}
+	// Without a private state, nothing can be stored
+	api = NewPrivateStateAPI(&Ethereum{config: &Config{}, nodeKey: local})
+	if _, err := api.StorePayload(payload, salt, signature); err != errPrivateStateDisabled {
+		t.Errorf("disabled store error mismatch: have %v, want %v", err, errPrivateStateDisabled)
+	}
+}
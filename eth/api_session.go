@@ -0,0 +1,393 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/internal/ethapi"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// debugSessionTimeout is the idle time after which a debug session is closed.
+	debugSessionTimeout = 10 * time.Minute
+
+	// maxDebugSessions is the number of sessions allowed to be open at once, each
+	// of them pinning a copy of a historical state in memory.
+	maxDebugSessions = 16
+
+	// debugCallTimeout is the maximum time a single call of a session may run.
+	debugCallTimeout = 5 * time.Second
+
+	// debugTraceLimit is the default maximum number of steps a trace records.
+	debugTraceLimit = 100000
+)
+
+var errDebugSessionUnknown = errors.New("debug session not found")
+
+// DebugSessionInfo describes an open debug session.
+type DebugSessionInfo struct {
+	ID      string         `json:"id"`
+	Number  hexutil.Uint64 `json:"number"`  // Block the session state is pinned to
+	Hash    common.Hash    `json:"hash"`    // Hash of the pinned block
+	Steps   int            `json:"steps"`   // Number of steps of the recorded trace
+	Cursor  int            `json:"cursor"`  // Next step of the recorded trace to return
+	Expires time.Time      `json:"expires"` // Time the session is closed unless used
+}
+
+// DebugCallResult is the outcome of a call executed in a debug session.
+type DebugCallResult struct {
+	ReturnValue hexutil.Bytes  `json:"returnValue"`
+	GasUsed     hexutil.Uint64 `json:"gasUsed"`
+	Failed      bool           `json:"failed"`
+	Steps       int            `json:"steps,omitempty"` // Number of steps recorded by a trace
+}
+
+// DebugAccountResult is the state of an account in the overlay of a session.
+type DebugAccountResult struct {
+	Balance *hexutil.Big                `json:"balance"`
+	Nonce   hexutil.Uint64              `json:"nonce"`
+	Code    hexutil.Bytes               `json:"code"`
+	Storage map[common.Hash]common.Hash `json:"storage,omitempty"`
+}
+
+// debugSession is a historical state pinned for interactive debugging. All the
+// modifications done through the session go into an in-memory overlay, the
+// database is never written.
+type debugSession struct {
+	header  *types.Header  // Header the calls of the session are executed in
+	statedb *state.StateDB // Overlay on top of the pinned state
+	trace   []vm.StructLog // Steps of the last recorded execution
+	cursor  int            // Next step of the trace to return
+	used    time.Time      // Last time the session was accessed
+
+	lock sync.Mutex
+}
+
+// PrivateDebugSessionAPI lets debuggers pin a historical state, run calls and
+// modify an overlay on top of it, and step through executions opcode by opcode.
+type PrivateDebugSessionAPI struct {
+	eth *Ethereum
+
+	sessions map[string]*debugSession
+	lock     sync.Mutex
+}
+
+// NewPrivateDebugSessionAPI creates a new debug session API.
+func NewPrivateDebugSessionAPI(eth *Ethereum) *PrivateDebugSessionAPI {
+	return &PrivateDebugSessionAPI{
+		eth:      eth,
+		sessions: make(map[string]*debugSession),
+	}
+}
+
+// OpenSession pins the state after the given block and returns the identifier
+// of the new session. Missing historical state is regenerated.
+func (api *PrivateDebugSessionAPI) OpenSession(ctx context.Context, number rpc.BlockNumber) (string, error) {
+	var block *types.Block
+	switch number {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
+		block = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		block = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.eth.blockchain.CurrentFinalizedBlock()
+	default:
+		if number < 0 {
+			return "", fmt.Errorf("unsupported block number %d", number)
+		}
+		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+	if block == nil {
+		return "", fmt.Errorf("block #%d not found", number)
+	}
+	statedb, err := NewPrivateDebugAPI(api.eth.chainConfig, api.eth).computeStateDB(block, defaultTraceReexec)
+	if err != nil {
+		return "", err
+	}
+	return api.open(&debugSession{header: block.Header(), statedb: statedb})
+}
+
+// OpenTransactionSession pins the state right before the given transaction and
+// records the trace of its execution, ready to be stepped through.
+func (api *PrivateDebugSessionAPI) OpenTransactionSession(ctx context.Context, hash common.Hash, config *vm.LogConfig) (string, error) {
+	tx, blockHash, _, index := core.GetTransaction(api.eth.ChainDb(), hash)
+	if tx == nil {
+		return "", fmt.Errorf("transaction %x not found", hash)
+	}
+	msg, _, statedb, err := NewPrivateDebugAPI(api.eth.chainConfig, api.eth).computeTxEnv(blockHash, int(index), defaultTraceReexec)
+	if err != nil {
+		return "", err
+	}
+	session := &debugSession{header: api.eth.blockchain.GetHeaderByHash(blockHash), statedb: statedb}
+	if _, err := api.trace(ctx, session, msg, config); err != nil {
+		return "", err
+	}
+	return api.open(session)
+}
+
+// open registers a new session, dropping the expired ones.
+func (api *PrivateDebugSessionAPI) open(session *debugSession) (string, error) {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	api.expire()
+	if len(api.sessions) >= maxDebugSessions {
+		return "", fmt.Errorf("too many debug sessions, max %d", maxDebugSessions)
+	}
+	id := string(rpc.NewID())
+	session.used = time.Now()
+	api.sessions[id] = session
+	return id, nil
+}
+
+// expire closes the sessions idle for too long. The lock must be held.
+func (api *PrivateDebugSessionAPI) expire() {
+	for id, session := range api.sessions {
+		session.lock.Lock()
+		idle := time.Since(session.used)
+		session.lock.Unlock()
+
+		if idle > debugSessionTimeout {
+			delete(api.sessions, id)
+		}
+	}
+}
+
+// session retrieves and locks an open session. The caller must unlock it.
+func (api *PrivateDebugSessionAPI) session(id string) (*debugSession, error) {
+	api.lock.Lock()
+	api.expire()
+	session := api.sessions[id]
+	api.lock.Unlock()
+
+	if session == nil {
+		return nil, errDebugSessionUnknown
+	}
+	session.lock.Lock()
+	session.used = time.Now()
+	return session, nil
+}
+
+// CloseSession drops a session along with its overlay state.
+func (api *PrivateDebugSessionAPI) CloseSession(id string) bool {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	_, ok := api.sessions[id]
+	delete(api.sessions, id)
+	return ok
+}
+
+// Sessions lists the open sessions.
+func (api *PrivateDebugSessionAPI) Sessions() []*DebugSessionInfo {
+	api.lock.Lock()
+	defer api.lock.Unlock()
+
+	api.expire()
+	infos := make([]*DebugSessionInfo, 0, len(api.sessions))
+	for id, session := range api.sessions {
+		session.lock.Lock()
+		infos = append(infos, &DebugSessionInfo{
+			ID:      id,
+			Number:  hexutil.Uint64(session.header.Number.Uint64()),
+			Hash:    session.header.Hash(),
+			Steps:   len(session.trace),
+			Cursor:  session.cursor,
+			Expires: session.used.Add(debugSessionTimeout),
+		})
+		session.lock.Unlock()
+	}
+	return infos
+}
+
+// SessionOverride modifies accounts in the overlay state of a session.
+func (api *PrivateDebugSessionAPI) SessionOverride(id string, overrides map[common.Address]ethapi.SimulateAccountOverride) error {
+	session, err := api.session(id)
+	if err != nil {
+		return err
+	}
+	defer session.lock.Unlock()
+
+	for addr, account := range overrides {
+		if account.Nonce != nil {
+			session.statedb.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Balance != nil {
+			session.statedb.SetBalance(addr, account.Balance.ToInt())
+		}
+		if account.Code != nil {
+			session.statedb.SetCode(addr, *account.Code)
+		}
+		for key, value := range account.StateDiff {
+			session.statedb.SetState(addr, key, value)
+		}
+	}
+	return session.statedb.Error()
+}
+
+// SessionAccount returns an account, and the requested storage slots of it,
+// from the overlay state of a session.
+func (api *PrivateDebugSessionAPI) SessionAccount(id string, address common.Address, keys []common.Hash) (*DebugAccountResult, error) {
+	session, err := api.session(id)
+	if err != nil {
+		return nil, err
+	}
+	defer session.lock.Unlock()
+
+	result := &DebugAccountResult{
+		Balance: (*hexutil.Big)(session.statedb.GetBalance(address)),
+		Nonce:   hexutil.Uint64(session.statedb.GetNonce(address)),
+		Code:    session.statedb.GetCode(address),
+	}
+	if len(keys) > 0 {
+		result.Storage = make(map[common.Hash]common.Hash, len(keys))
+		for _, key := range keys {
+			result.Storage[key] = session.statedb.GetState(address, key)
+		}
+	}
+	return result, session.statedb.Error()
+}
+
+// SessionCall executes a call on the overlay state of a session. Its changes
+// are only kept if commit is set, otherwise the call runs on a throwaway copy.
+func (api *PrivateDebugSessionAPI) SessionCall(ctx context.Context, id string, args ethapi.CallArgs, commit bool) (*DebugCallResult, error) {
+	session, err := api.session(id)
+	if err != nil {
+		return nil, err
+	}
+	defer session.lock.Unlock()
+
+	statedb := session.statedb
+	if !commit {
+		statedb = statedb.Copy()
+	}
+	ret, gas, failed, err := api.execute(ctx, session.header, debugMessage(args), statedb, vm.Config{})
+	if err != nil {
+		return nil, err
+	}
+	statedb.Finalise(true)
+	return &DebugCallResult{ReturnValue: ret, GasUsed: hexutil.Uint64(gas), Failed: failed}, nil
+}
+
+// SessionTrace executes a call on a copy of the overlay state of a session,
+// recording every step of the execution. The trace replaces any previously
+// recorded one and is stepped through from its start.
+func (api *PrivateDebugSessionAPI) SessionTrace(ctx context.Context, id string, args ethapi.CallArgs, config *vm.LogConfig) (*DebugCallResult, error) {
+	session, err := api.session(id)
+	if err != nil {
+		return nil, err
+	}
+	defer session.lock.Unlock()
+
+	return api.trace(ctx, session, debugMessage(args), config)
+}
+
+// trace records the execution of a message on a copy of the session state.
+func (api *PrivateDebugSessionAPI) trace(ctx context.Context, session *debugSession, msg core.Message, config *vm.LogConfig) (*DebugCallResult, error) {
+	cfg := vm.LogConfig{Limit: debugTraceLimit}
+	if config != nil {
+		cfg = *config
+		if cfg.Limit == 0 || cfg.Limit > debugTraceLimit {
+			cfg.Limit = debugTraceLimit
+		}
+	}
+	tracer := vm.NewStructLogger(&cfg)
+
+	ret, gas, failed, err := api.execute(ctx, session.header, msg, session.statedb.Copy(), vm.Config{Debug: true, Tracer: tracer})
+	if err != nil {
+		return nil, err
+	}
+	session.trace, session.cursor = tracer.StructLogs(), 0
+	return &DebugCallResult{ReturnValue: ret, GasUsed: hexutil.Uint64(gas), Failed: failed, Steps: len(session.trace)}, nil
+}
+
+// SessionStep returns the next steps of the recorded trace of a session,
+// advancing its cursor. A count of zero returns a single step.
+func (api *PrivateDebugSessionAPI) SessionStep(id string, count int) ([]ethapi.StructLogRes, error) {
+	session, err := api.session(id)
+	if err != nil {
+		return nil, err
+	}
+	defer session.lock.Unlock()
+
+	if count <= 0 {
+		count = 1
+	}
+	end := session.cursor + count
+	if end > len(session.trace) {
+		end = len(session.trace)
+	}
+	steps := ethapi.FormatLogs(session.trace[session.cursor:end])
+	session.cursor = end
+	return steps, nil
+}
+
+// SessionSeek moves the cursor of the recorded trace of a session to the given
+// step, allowing to travel back to any earlier point of the execution.
+func (api *PrivateDebugSessionAPI) SessionSeek(id string, step int) error {
+	session, err := api.session(id)
+	if err != nil {
+		return err
+	}
+	defer session.lock.Unlock()
+
+	if step < 0 || step > len(session.trace) {
+		return fmt.Errorf("step %d out of range [0, %d]", step, len(session.trace))
+	}
+	session.cursor = step
+	return nil
+}
+
+// execute runs a message on the given state in the block context of a session.
+func (api *PrivateDebugSessionAPI) execute(ctx context.Context, header *types.Header, msg core.Message, statedb *state.StateDB, cfg vm.Config) ([]byte, uint64, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, debugCallTimeout)
+	defer cancel()
+
+	evm := vm.NewEVM(core.NewEVMContext(msg, header, api.eth.blockchain, nil), statedb, api.eth.chainConfig, cfg)
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	ret, gas, failed, err := core.ApplyMessage(evm, msg, new(core.GasPool).AddGas(math.MaxUint64))
+	if ctx.Err() == context.DeadlineExceeded {
+		return nil, 0, false, fmt.Errorf("execution aborted (timeout = %v)", debugCallTimeout)
+	}
+	return ret, gas, failed, err
+}
+
+// debugMessage converts the arguments of a session call into a message. Unset
+// gas is unbounded, as far as the call timeout allows.
+func debugMessage(args ethapi.CallArgs) types.Message {
+	gas := uint64(args.Gas)
+	if gas == 0 {
+		gas = math.MaxUint64 / 2
+	}
+	return types.NewMessage(args.From, args.To, 0, args.Value.ToInt(), gas, args.GasPrice.ToInt(), args.Data, false)
+}
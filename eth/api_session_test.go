@@ -0,0 +1,144 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/internal/ethapi"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// Tests that debug sessions pin historical states, keep their modifications in
+// an overlay and step back and forth through recorded executions.
+func TestDebugSessions(t *testing.T) {
+	var (
+		db, _    = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+		slot     = common.Hash{}
+	)
+	// The contract increments its first storage slot:
+	// SSTORE(0, ADD(SLOAD(0), 1)) STOP
+	code := []byte{0x60, 0x00, 0x54, 0x60, 0x01, 0x01, 0x60, 0x00, 0x55, 0x00}
+
+	gspec := core.DefaultRPOWTestingGenesisBlock()
+	gspec.Alloc = core.GenesisAlloc{
+		sender:   {Balance: big.NewInt(1000000000)},
+		contract: {Balance: new(big.Int), Code: code},
+	}
+	genesis := gspec.MustCommit(db)
+	engine := ethash.NewFakerUsechain(db)
+
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	defer blockchain.Stop()
+
+	tx, _ := types.SignTx(types.NewTransaction(0, contract, new(big.Int), 100000, big.NewInt(1), nil), types.NewEIP155Signer(gspec.Config.ChainId), key)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, gen *core.BlockGen) {
+		gen.AddTx(tx)
+	})
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	api := NewPrivateDebugSessionAPI(&Ethereum{chainConfig: gspec.Config, blockchain: blockchain, chainDb: db})
+	ctx := context.Background()
+
+	// Step through the historical transaction, travelling back once
+	txSession, err := api.OpenTransactionSession(ctx, tx.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to open transaction session: %v", err)
+	}
+	steps, err := api.SessionStep(txSession, 2)
+	if err != nil || len(steps) != 2 || steps[0].Op != "PUSH1" || steps[1].Op != "SLOAD" {
+		t.Fatalf("first steps mismatch: have %v, %v", steps, err)
+	}
+	if err := api.SessionSeek(txSession, 1); err != nil {
+		t.Fatalf("failed to seek: %v", err)
+	}
+	if steps, _ = api.SessionStep(txSession, 0); len(steps) != 1 || steps[0].Op != "SLOAD" {
+		t.Fatalf("step after seek mismatch: have %v", steps)
+	}
+	if steps, _ = api.SessionStep(txSession, 100); len(steps) != 5 || steps[4].Op != "STOP" {
+		t.Fatalf("remaining steps mismatch: have %v", steps)
+	}
+	account, _ := api.SessionAccount(txSession, contract, []common.Hash{slot})
+	if account.Storage[slot] != (common.Hash{}) {
+		t.Errorf("state before transaction mismatch: have %x", account.Storage[slot])
+	}
+	// Run calls on the state after the block, committed or not
+	session, err := api.OpenSession(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	check := func(want int64) {
+		t.Helper()
+		account, err := api.SessionAccount(session, contract, []common.Hash{slot})
+		if err != nil {
+			t.Fatalf("failed to retrieve account: %v", err)
+		}
+		if have := account.Storage[slot].Big().Int64(); have != want {
+			t.Errorf("slot mismatch: have %d, want %d", have, want)
+		}
+	}
+	check(1)
+	args := ethapi.CallArgs{From: sender, To: &contract}
+	if _, err := api.SessionCall(ctx, session, args, false); err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	check(1)
+	if _, err := api.SessionCall(ctx, session, args, true); err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	check(2)
+
+	overrides := map[common.Address]ethapi.SimulateAccountOverride{
+		contract: {StateDiff: map[common.Hash]common.Hash{slot: common.BigToHash(big.NewInt(10))}},
+	}
+	if err := api.SessionOverride(session, overrides); err != nil {
+		t.Fatalf("failed to override state: %v", err)
+	}
+	check(10)
+	if res, err := api.SessionTrace(ctx, session, args, nil); err != nil || res.Steps != 7 {
+		t.Fatalf("trace mismatch: have %+v, %v", res, err)
+	}
+	check(10)
+
+	// The chain state itself must be untouched
+	statedb, _ := blockchain.State()
+	if have := statedb.GetState(contract, slot).Big().Int64(); have != 1 {
+		t.Errorf("chain state modified: have %d, want 1", have)
+	}
+	if infos := api.Sessions(); len(infos) != 2 {
+		t.Errorf("session count mismatch: have %d, want 2", len(infos))
+	}
+	if !api.CloseSession(session) {
+		t.Errorf("failed to close session")
+	}
+	if _, err := api.SessionAccount(session, contract, nil); err != errDebugSessionUnknown {
+		t.Errorf("closed session error mismatch: have %v, want %v", err, errDebugSessionUnknown)
+	}
+}
@@ -100,6 +100,10 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		from = api.eth.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		from = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		from = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		from = api.eth.blockchain.CurrentFinalizedBlock()
 	default:
 		from = api.eth.blockchain.GetBlockByNumber(uint64(start))
 	}
@@ -108,6 +112,10 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 		to = api.eth.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		to = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		to = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		to = api.eth.blockchain.CurrentFinalizedBlock()
 	default:
 		to = api.eth.blockchain.GetBlockByNumber(uint64(end))
 	}
@@ -121,6 +129,76 @@ func (api *PrivateDebugAPI) TraceChain(ctx context.Context, start, end rpc.Block
 	return api.traceChain(ctx, from, to, config)
 }
 
+// TraceChainPaged returns the structured logs created during the execution of EVM
+// between two blocks (including both) page by page, with the traces of at most
+// pageSize blocks each (0 = default page size). The blocks are traced as the
+// following pages are retrieved through rpc_nextPage.
+func (api *PrivateDebugAPI) TraceChainPaged(ctx context.Context, start, end rpc.BlockNumber, config *TraceConfig, pageSize int) (*rpc.Page, error) {
+	from, to := api.blockByNumber(start), api.blockByNumber(end)
+	if from == nil {
+		return nil, fmt.Errorf("starting block #%d not found", start)
+	}
+	if to == nil {
+		return nil, fmt.Errorf("end block #%d not found", end)
+	}
+	if from.NumberU64() > to.NumberU64() {
+		return nil, fmt.Errorf("end block (#%d) needs to come after start block (#%d)", to.NumberU64(), from.NumberU64())
+	}
+	it := &blockTraceIterator{api: api, config: config, next: from.NumberU64(), end: to.NumberU64()}
+	if it.next == 0 {
+		it.next = 1 // The genesis block has no transactions to trace
+	}
+	return rpc.Paginate(ctx, "debug_traceChainPaged", it, pageSize)
+}
+
+// blockByNumber retrieves a block of the canonical chain, resolving the special
+// block numbers.
+func (api *PrivateDebugAPI) blockByNumber(number rpc.BlockNumber) *types.Block {
+	switch number {
+	case rpc.PendingBlockNumber:
+		return api.eth.miner.PendingBlock()
+	case rpc.LatestBlockNumber:
+		return api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		return api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		return api.eth.blockchain.CurrentFinalizedBlock()
+	default:
+		return api.eth.blockchain.GetBlockByNumber(uint64(number))
+	}
+}
+
+// blockTraceIterator pages through the traces of a range of blocks, tracing the
+// blocks one by one as the pages are requested.
+type blockTraceIterator struct {
+	api    *PrivateDebugAPI
+	config *TraceConfig
+	next   uint64 // Next block to trace
+	end    uint64 // Last block to trace
+}
+
+func (it *blockTraceIterator) Next(ctx context.Context, limit int) ([]interface{}, bool, error) {
+	var items []interface{}
+	for ; len(items) < limit && it.next <= it.end; it.next++ {
+		block := it.api.eth.blockchain.GetBlockByNumber(it.next)
+		if block == nil {
+			return nil, false, fmt.Errorf("block #%d not found", it.next)
+		}
+		traces, err := it.api.traceBlock(ctx, block, it.config)
+		if err != nil {
+			return nil, false, err
+		}
+		items = append(items, &blockTraceResult{
+			Block:  hexutil.Uint64(block.NumberU64()),
+			Hash:   block.Hash(),
+			Traces: traces,
+		})
+	}
+	return items, it.next > it.end, nil
+}
+
+func (it *blockTraceIterator) Close() {}
+
 // traceChain configures a new tracer according to the provided configuration, and
 // executes all the transactions contained within. The return value will be one item
 // per transaction, dependent on the requestd tracer.
@@ -342,6 +420,10 @@ func (api *PrivateDebugAPI) TraceBlockByNumber(ctx context.Context, number rpc.B
 		block = api.eth.miner.PendingBlock()
 	case rpc.LatestBlockNumber:
 		block = api.eth.blockchain.CurrentBlock()
+	case rpc.SafeBlockNumber:
+		block = api.eth.blockchain.CurrentSafeBlock()
+	case rpc.FinalizedBlockNumber:
+		block = api.eth.blockchain.CurrentFinalizedBlock()
 	default:
 		block = api.eth.blockchain.GetBlockByNumber(uint64(number))
 	}
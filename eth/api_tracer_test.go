@@ -0,0 +1,122 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/internal/ethapi"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// Tests that tracing transactions of a block does not leak the transient
+// storage of one transaction into the next, matching consensus execution.
+func TestTraceTransientStorage(t *testing.T) {
+	var (
+		db, _    = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		sender   = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.HexToAddress("0xc0de")
+	)
+	// The contract persists its first transient slot, then sets it:
+	// SSTORE(1, TLOAD(0)) TSTORE(0, 1) STOP
+	code := []byte{
+		byte(vm.PUSH1), 0, byte(vm.TLOAD), byte(vm.PUSH1), 1, byte(vm.SSTORE),
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.TSTORE),
+		byte(vm.STOP),
+	}
+	gspec := core.DefaultRPOWTestingGenesisBlock()
+	config := *gspec.Config
+	config.TransientStorageBlock = big.NewInt(0)
+	gspec.Config = &config
+	gspec.Alloc = core.GenesisAlloc{
+		sender:   {Balance: big.NewInt(1000000000)},
+		contract: {Balance: new(big.Int), Code: code},
+	}
+	genesis := gspec.MustCommit(db)
+	engine := ethash.NewFakerUsechain(db)
+
+	blockchain, _ := core.NewBlockChain(db, nil, gspec.Config, engine, vm.Config{})
+	defer blockchain.Stop()
+
+	signer := types.NewEIP155Signer(gspec.Config.ChainId)
+	txs := make([]*types.Transaction, 2)
+	for i := range txs {
+		txs[i], _ = types.SignTx(types.NewTransaction(uint64(i), contract, new(big.Int), 100000, big.NewInt(1), nil), signer, key)
+	}
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, engine, db, 1, func(i int, gen *core.BlockGen) {
+		for _, tx := range txs {
+			gen.AddTx(tx)
+		}
+	})
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// Consensus execution never sees the transient value of the first transaction
+	statedb, _ := blockchain.State()
+	if have := statedb.GetState(contract, common.BigToHash(big.NewInt(1))); have != (common.Hash{}) {
+		t.Fatalf("transient storage leaked on import: have %x", have)
+	}
+	api := NewPrivateDebugAPI(gspec.Config, &Ethereum{chainConfig: gspec.Config, blockchain: blockchain, chainDb: db, engine: engine})
+
+	// The second transaction must read back zero, both from block and single traces
+	check := func(name string, res interface{}) {
+		t.Helper()
+		result, ok := res.(*ethapi.ExecutionResult)
+		if !ok {
+			t.Fatalf("%s: result type mismatch: have %T", name, res)
+		}
+		for _, log := range result.StructLogs {
+			if log.Op != "SSTORE" {
+				continue
+			}
+			if value := (*log.Stack)[0]; value != strings.Repeat("0", 64) {
+				t.Errorf("%s: transient storage leaked: read %s", name, value)
+			}
+			return
+		}
+		t.Fatalf("%s: SSTORE not traced", name)
+	}
+	results, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(1), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if len(results) != len(txs) {
+		t.Fatalf("trace count mismatch: have %d, want %d", len(results), len(txs))
+	}
+	for i, res := range results {
+		if res.Error != "" {
+			t.Fatalf("block trace %d failed: %s", i, res.Error)
+		}
+		check("block trace", res.Result)
+	}
+	res, err := api.TraceTransaction(context.Background(), txs[1].Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	check("transaction trace", res)
+}
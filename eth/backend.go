@@ -18,12 +18,14 @@
 package eth
 
 import (
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
 	"math/big"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/usechain/go-usechain/accounts"
 	"github.com/usechain/go-usechain/common"
@@ -38,6 +40,9 @@ import (
 	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/eth/filters"
 	"github.com/usechain/go-usechain/eth/gasprice"
+	"github.com/usechain/go-usechain/eth/heartbeat"
+	"github.com/usechain/go-usechain/eth/txtracker"
+	"github.com/usechain/go-usechain/eth/replica"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
 	"github.com/usechain/go-usechain/internal/ethapi"
@@ -71,9 +76,15 @@ type Ethereum struct {
 	blockchain      *core.BlockChain
 	protocolManager *ProtocolManager
 	lesServer       LesServer
+	replica         *replica.Follower
+	heartbeats      *heartbeat.Tracker // Liveness of the committee members
+	beacon          *heartbeat.Beacon  // Heartbeat publisher if the node is a committee member
+	txTracker       *txtracker.Tracker // Rebroadcaster of stuck local transactions (nil = disabled)
+	webhooks        *filters.Webhooks  // Filter match deliveries to registered callbacks
 
 	// DB interfaces
-	chainDb ethdb.Database // Block chain database
+	chainDb   ethdb.Database // Block chain database
+	privateDb ethdb.Database // Private state and payload database (nil = private state disabled)
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -90,6 +101,7 @@ type Ethereum struct {
 
 	networkId     uint64
 	netRPCService *ethapi.PublicNetAPI
+	nodeKey       *ecdsa.PrivateKey // Key of the p2p node, authenticating distributed private payloads
 
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and usebase)
 }
@@ -152,12 +164,31 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	eth.blockchain.SetFinalityDepth(config.SafeDepth, config.FinalityDepth)
+	eth.blockchain.SetActivityBloom(config.ActivityBloom)
+	eth.blockchain.SetBalanceIndex(config.BalanceIndex)
+	eth.blockchain.SetHistoryRetention(config.HistoryRetain)
+	if config.PrivateState {
+		// Keep the private state out of the chain database, which is shared
+		if eth.privateDb, err = CreateDB(ctx, config, "privatestate"); err != nil {
+			return nil, err
+		}
+		eth.blockchain.SetPrivateState(eth.privateDb)
+	}
+	eth.blockchain.SetInvariants(&config.Invariants)
+	if config.ReplicaLeader != "" {
+		eth.replica = replica.NewFollower(eth.blockchain, config.ReplicaLeader, config.ReplicaSecret, config.ReplicaVerify)
+	}
+
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
 		eth.blockchain.SetHead(compat.RewindTo)
 		core.WriteChainConfig(chainDb, genesisHash, chainConfig)
 	}
+	if err := eth.checkSystemContracts(); err != nil {
+		log.Error("System contract validation failed, mining disabled", "err", err)
+	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
 	if config.TxPool.Journal != "" {
@@ -168,7 +199,15 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, config.NetworkId, eth.eventMux, eth.txPool, eth.engine, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
-	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine)
+	eth.heartbeats = heartbeat.NewTracker(eth.isCommittee, config.CommitteeMembers, heartbeat.DefaultTimeout)
+	eth.protocolManager.heartbeats = eth.heartbeats
+	if config.CommitteeHeartbeat > 0 {
+		eth.beacon = heartbeat.NewBeacon(eth.Usebase, eth.currentNumber, eth.signHeartbeat, eth.publishHeartbeat, config.CommitteeHeartbeat)
+	}
+	if config.TxRebroadcast > 0 {
+		eth.txTracker = txtracker.New(eth.txPool, eth.blockchain, chainDb, eth.protocolManager.rebroadcastTx, config.TxRebroadcast)
+	}
+	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.engine, config.Miner)
 	eth.miner.SetExtra(makeExtraData(config.ExtraData))
 
 	eth.ApiBackend = &EthApiBackend{eth, nil}
@@ -177,6 +216,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		gpoParams.Default = config.GasPrice
 	}
 	eth.ApiBackend.gpo = gasprice.NewOracle(eth.ApiBackend, gpoParams)
+	eth.webhooks = filters.NewWebhooks(eth.ApiBackend, false)
 
 	return eth, nil
 }
@@ -241,6 +281,13 @@ func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chai
 	}
 }
 
+// newFilterAPI creates a filter API bounding log queries by the configured limits.
+func (s *Ethereum) newFilterAPI() *filters.PublicFilterAPI {
+	api := filters.NewPublicFilterAPI(s.ApiBackend, false)
+	api.SetLogLimits(s.config.LogChunkSize, s.config.LogQueryLimit)
+	return api
+}
+
 // APIs returns the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
@@ -249,8 +296,8 @@ func (s *Ethereum) APIs() []rpc.API {
 	// Append any APIs exposed explicitly by the consensus engine
 	apis = append(apis, s.engine.APIs(s.BlockChain())...)
 
-	// Append all the local APIs and return
-	return append(apis, []rpc.API{
+	// Append all the local APIs
+	apis = append(apis, []rpc.API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
@@ -269,7 +316,7 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "eth",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false),
+			Service:   s.newFilterAPI(),
 			Public:    true,
 		},{
 			Namespace: "miner",
@@ -294,7 +341,7 @@ func (s *Ethereum) APIs() []rpc.API {
 		},{
 			Namespace: "use",
 			Version:   "1.0",
-			Service:   filters.NewPublicFilterAPI(s.ApiBackend, false),
+			Service:   s.newFilterAPI(),
 			Public:    true,
 		}, {
 			Namespace: "admin",
@@ -316,6 +363,101 @@ func (s *Ethereum) APIs() []rpc.API {
 			Public:    true,
 		},
 	}...)
+	// Serve the account activity index
+	apis = append(apis, rpc.API{
+		Namespace: "usx",
+		Version:   "1.0",
+		Service:   NewPublicActivityAPI(s),
+		Public:    true,
+	})
+	// Serve the balance change index
+	apis = append(apis, rpc.API{
+		Namespace: "eth",
+		Version:   "1.0",
+		Service:   NewPublicBalanceAPI(s),
+		Public:    true,
+	}, rpc.API{
+		Namespace: "use",
+		Version:   "1.0",
+		Service:   NewPublicBalanceAPI(s),
+		Public:    true,
+	})
+	// Debug contracts interactively on top of historical states
+	apis = append(apis, rpc.API{
+		Namespace: "debug",
+		Version:   "1.0",
+		Service:   NewPrivateDebugSessionAPI(s),
+	})
+	// Let external block builders work on top of the local chain
+	apis = append(apis, rpc.API{
+		Namespace: "builder",
+		Version:   "1.0",
+		Service:   NewPrivateBuilderAPI(s),
+	})
+	// Manage private payloads and query the private state
+	apis = append(apis, rpc.API{
+		Namespace: "private",
+		Version:   "1.0",
+		Service:   NewPrivateStateAPI(s),
+	})
+	// Deliver filter matches to registered callbacks
+	apis = append(apis, rpc.API{
+		Namespace: "webhook",
+		Version:   "1.0",
+		Service:   filters.NewPrivateWebhookAPI(s.webhooks),
+	})
+	// Report the liveness of the committee members
+	apis = append(apis, rpc.API{
+		Namespace: "committee",
+		Version:   "1.0",
+		Service:   heartbeat.NewPublicCommitteeAPI(s.heartbeats),
+		Public:    true,
+	})
+	// Serve replica followers if a shared secret was configured
+	if s.config.ReplicaSecret != "" {
+		apis = append(apis, rpc.API{
+			Namespace: "replica",
+			Version:   "1.0",
+			Service:   replica.NewLeaderAPI(s.blockchain, s.config.ReplicaSecret),
+		})
+	}
+	return apis
+}
+
+// isCommittee checks whether the address is registered as a committee member in
+// the current chain state.
+func (s *Ethereum) isCommittee(addr common.Address) bool {
+	statedb, err := s.blockchain.State()
+	if err != nil {
+		return false
+	}
+	return statedb.IsCommittee(addr)
+}
+
+// currentNumber retrieves the number of the current head block.
+func (s *Ethereum) currentNumber() uint64 {
+	return s.blockchain.CurrentBlock().NumberU64()
+}
+
+// signHeartbeat signs a committee heartbeat hash with the member's account,
+// which needs to be unlocked.
+func (s *Ethereum) signHeartbeat(member common.Address, hash []byte) ([]byte, error) {
+	account := accounts.Account{Address: member}
+
+	wallet, err := s.accountManager.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.SignHash(account, hash)
+}
+
+// publishHeartbeat records a heartbeat of the local committee member and
+// propagates it to the network.
+func (s *Ethereum) publishHeartbeat(hb *heartbeat.Heartbeat) {
+	if err := s.heartbeats.Add(hb, time.Now()); err != nil {
+		log.Warn("Local committee heartbeat rejected", "member", hb.Member, "err", err)
+	}
+	s.protocolManager.BroadcastHeartbeat(hb)
 }
 
 func (s *Ethereum) ResetWithGenesisBlock(gb *types.Block) {
@@ -360,6 +502,10 @@ func (s *Ethereum) StartMining(local bool) error {
 		log.Error("Cannot start mining without usebase", "err", err)
 		return fmt.Errorf("usebase missing: %v", err)
 	}
+	if err := s.checkSystemContracts(); err != nil {
+		log.Error("Cannot start mining with invalid system contracts", "err", err)
+		return err
+	}
 	if clique, ok := s.engine.(*clique.Clique); ok {
 		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
 		if wallet == nil || err != nil {
@@ -379,6 +525,16 @@ func (s *Ethereum) StartMining(local bool) error {
 	return nil
 }
 
+// checkSystemContracts validates the system contracts pinned by the chain
+// configuration against the state of the current head block.
+func (s *Ethereum) checkSystemContracts() error {
+	statedb, err := s.blockchain.State()
+	if err != nil {
+		return err
+	}
+	return core.ValidateSystemContracts(s.chainConfig, statedb)
+}
+
 func (s *Ethereum) StopMining()         { s.miner.Stop() }
 func (s *Ethereum) IsMining() bool      { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
@@ -394,6 +550,23 @@ func (s *Ethereum) IsListening() bool                  { return true } // Always
 func (s *Ethereum) EthVersion() int                    { return int(s.protocolManager.SubProtocols[0].Version) }
 func (s *Ethereum) NetVersion() uint64                 { return s.networkId }
 func (s *Ethereum) Downloader() *downloader.Downloader { return s.protocolManager.downloader }
+func (s *Ethereum) Heartbeats() *heartbeat.Tracker     { return s.heartbeats }
+
+// CrashInfo implements node.CrashReporter, describing the chain and sync state
+// for crash reports.
+func (s *Ethereum) CrashInfo() interface{} {
+	head := s.blockchain.CurrentBlock()
+	pending, queued := s.txPool.Stats()
+
+	return map[string]interface{}{
+		"head":     map[string]interface{}{"number": head.NumberU64(), "hash": head.Hash(), "time": head.Time()},
+		"fastHead": s.blockchain.CurrentFastBlock().NumberU64(),
+		"syncing":  s.protocolManager.downloader.Synchronising(),
+		"peers":    s.protocolManager.peers.Len(),
+		"pending":  pending,
+		"queued":   queued,
+	}
+}
 
 // Protocols implements node.Service, returning all the currently configured
 // network protocols to start.
@@ -412,6 +585,7 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 
 	// Start the RPC service
 	s.netRPCService = ethapi.NewPublicNetAPI(srvr, s.NetVersion())
+	s.nodeKey = srvr.PrivateKey
 
 	// Figure out a max peers count based on the server limits
 	maxPeers := srvr.MaxPeers
@@ -426,6 +600,18 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 	if s.lesServer != nil {
 		s.lesServer.Start(srvr)
 	}
+	// Start following the replica leader if requested
+	if s.replica != nil {
+		s.replica.Start()
+	}
+	// Attest the liveness of the local committee member
+	if s.beacon != nil {
+		s.beacon.Start()
+	}
+	// Keep an eye on the local transactions until mined
+	if s.txTracker != nil {
+		s.txTracker.Start()
+	}
 	return nil
 }
 
@@ -435,6 +621,16 @@ func (s *Ethereum) Stop() error {
 	if s.stopDbUpgrade != nil {
 		s.stopDbUpgrade()
 	}
+	if s.replica != nil {
+		s.replica.Stop()
+	}
+	if s.beacon != nil {
+		s.beacon.Stop()
+	}
+	if s.txTracker != nil {
+		s.txTracker.Stop()
+	}
+	s.webhooks.Stop()
 	s.bloomIndexer.Close()
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
@@ -446,6 +642,9 @@ func (s *Ethereum) Stop() error {
 	s.eventMux.Stop()
 
 	s.chainDb.Close()
+	if s.privateDb != nil {
+		s.privateDb.Close()
+	}
 	close(s.shutdownChan)
 
 	return nil
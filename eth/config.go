@@ -29,7 +29,11 @@ import (
 	"github.com/usechain/go-usechain/consensus/ethash"
 	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/eth/downloader"
+	"github.com/usechain/go-usechain/eth/filters"
 	"github.com/usechain/go-usechain/eth/gasprice"
+	"github.com/usechain/go-usechain/eth/replica"
+	"github.com/usechain/go-usechain/miner"
+	"github.com/usechain/go-usechain/p2p/discover"
 	"github.com/usechain/go-usechain/params"
 )
 
@@ -49,11 +53,17 @@ var DefaultConfig = Config{
 	TrieCache:     256,
 	TrieTimeout:   5 * time.Minute,
 	GasPrice:      big.NewInt(18 * params.Shannon),
+	Miner:         miner.DefaultConfig,
+	SafeDepth:     core.DefaultSafeDepth,
+	FinalityDepth: core.DefaultFinalityDepth,
+	LogChunkSize:  filters.DefaultLogChunkSize,
+	LogQueryLimit: filters.DefaultLogLimit,
 
 	TxPool: core.DefaultTxPoolConfig,
 	GPO: gasprice.Config{
 		Blocks:     20,
 		Percentile: 60,
+		MaxPrice:   gasprice.DefaultMaxPrice,
 	},
 }
 
@@ -94,17 +104,50 @@ type Config struct {
 	TrieCache          int
 	TrieTimeout        time.Duration
 
+	// Finality options
+	SafeDepth     uint64 // Confirmations after which a block is reported as "safe"
+	FinalityDepth uint64 // Confirmations after which a block is reported as "finalized"
+
+	// Indexing options
+	ActivityBloom bool `toml:",omitempty"` // Whether to index the accounts touched by each imported block
+	BalanceIndex  bool `toml:",omitempty"` // Whether to index the balance changes of each imported block
+
+	// History options
+	HistoryRetain uint64 `toml:",omitempty"` // Number of recent blocks whose bodies and receipts are kept (0 = all)
+
+	// Private transaction options
+	PrivateState bool              `toml:",omitempty"` // Whether to execute private transactions with a known payload on the private state
+	PrivateNodes []discover.NodeID `toml:",omitempty"` // Nodes authorized to distribute private payloads to this one
+
+	// Circuit breaker options
+	Invariants core.InvariantConfig `toml:",omitempty"` // Chain invariants halting block production when violated
+
+	// Log query options
+	LogChunkSize  uint64 // Number of blocks searched at once by eth_getLogs (0 = whole range)
+	LogQueryLimit int    // Maximum number of logs returned by eth_getLogs (0 = unlimited)
+
+	// Replica options
+	ReplicaLeader string             `toml:",omitempty"` // RPC endpoint of the leader to follow (empty = not a follower)
+	ReplicaSecret string             `toml:",omitempty"` // Shared secret authenticating followers (empty = not a leader)
+	ReplicaVerify replica.VerifyMode `toml:",omitempty"` // Verification applied to blocks received from the leader
+
+	// Committee options
+	CommitteeHeartbeat time.Duration    `toml:",omitempty"` // Interval between liveness heartbeats of the local member (0 = not a member)
+	CommitteeMembers   []common.Address `toml:",omitempty"` // Members reported by the liveness API even if never seen
+
 	// Mining-related options
 	Usebase    common.Address `toml:",omitempty"`
 	MinerThreads int            `toml:",omitempty"`
 	ExtraData    []byte         `toml:",omitempty"`
 	GasPrice     *big.Int
+	Miner        miner.Config // Block production policy
 
 	// Ethash options
 	Ethash ethash.Config
 
 	// Transaction pool options
-	TxPool core.TxPoolConfig
+	TxPool        core.TxPoolConfig
+	TxRebroadcast uint64 `toml:",omitempty"` // Blocks after which unmined local transactions are rebroadcast (0 = disabled)
 
 	// Gas Price Oracle options
 	GPO gasprice.Config
@@ -1014,6 +1014,14 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 				if err == errInvalidChain {
 					return err
 				}
+				// Score the peer on the usefulness of its response, unless it's
+				// a late answer to an already expired (and penalised) request.
+				// Unaccepted skeleton fills may come from honest peers on another
+				// fork, so only contents not matching their headers are garbage.
+				if err != errNoFetchesPending {
+					peer.MarkResponse(err != nil && kind != "headers")
+					d.scorePeer(peer)
+				}
 				// Unless a peer delivered something completely else than requested (usually
 				// caused by a timed out request which came through in the end), set it to
 				// idle. If the delivery's stale, the peer should have already been idled.
@@ -1062,6 +1070,8 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 			// Check for fetch request timeouts and demote the responsible peers
 			for pid, fails := range expire() {
 				if peer := d.peers.Peer(pid); peer != nil {
+					peer.MarkTimeout()
+
 					// If a lot of retrieval elements expired, we might have overestimated the remote peer or perhaps
 					// ourselves. Only reset to minimal throughput but don't drop just yet. If even the minimal times
 					// out that sync wise we need to get rid of the peer.
@@ -1072,6 +1082,7 @@ func (d *Downloader) fetchParts(errCancel error, deliveryCh chan dataPack, deliv
 					if fails > 2 {
 						peer.log.Trace("Data delivery timed out", "type", kind)
 						setIdle(peer, 0)
+						d.scorePeer(peer)
 					} else {
 						peer.log.Debug("Stalling delivery, dropping", "type", kind)
 						if d.dropPeer == nil {
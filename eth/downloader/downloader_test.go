@@ -32,6 +32,7 @@ import (
 	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
+	"github.com/usechain/go-usechain/log"
 	"github.com/usechain/go-usechain/params"
 	"github.com/usechain/go-usechain/trie"
 )
@@ -665,6 +666,10 @@ func TestCanonicalSynchronisation63Fast(t *testing.T)  { testCanonicalSynchronis
 func TestCanonicalSynchronisation64Full(t *testing.T)  { testCanonicalSynchronisation(t, 64, FullSync) }
 func TestCanonicalSynchronisation64Fast(t *testing.T)  { testCanonicalSynchronisation(t, 64, FastSync) }
 func TestCanonicalSynchronisation64Light(t *testing.T) { testCanonicalSynchronisation(t, 64, LightSync) }
+func TestCanonicalSynchronisation65Full(t *testing.T)  { testCanonicalSynchronisation(t, 65, FullSync) }
+func TestCanonicalSynchronisation65Fast(t *testing.T)  { testCanonicalSynchronisation(t, 65, FastSync) }
+func TestCanonicalSynchronisation66Full(t *testing.T)  { testCanonicalSynchronisation(t, 66, FullSync) }
+func TestCanonicalSynchronisation66Fast(t *testing.T)  { testCanonicalSynchronisation(t, 66, FastSync) }
 
 func testCanonicalSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	t.Parallel()
@@ -1018,6 +1023,79 @@ func testMultiSynchronisation(t *testing.T, protocol int, mode SyncMode) {
 	assertOwnChain(t, tester, targetBlocks+1)
 }
 
+// rangeTesterPeer is a download tester peer advertising that it pruned the
+// bodies and receipts of the blocks below a given number.
+type rangeTesterPeer struct {
+	*downloadTesterPeer
+	earliest uint64
+	stale    int32 // Number of pruned blocks requested from the peer (atomic access)
+}
+
+func (dlp *rangeTesterPeer) Serves(number uint64) bool {
+	return number >= dlp.earliest
+}
+
+func (dlp *rangeTesterPeer) countStale(hashes []common.Hash) {
+	dlp.dl.lock.RLock()
+	defer dlp.dl.lock.RUnlock()
+
+	for _, hash := range hashes {
+		if header := dlp.dl.peerHeaders[dlp.id][hash]; header != nil && header.Number.Uint64() < dlp.earliest {
+			atomic.AddInt32(&dlp.stale, 1)
+		}
+	}
+}
+
+func (dlp *rangeTesterPeer) RequestBodies(hashes []common.Hash) error {
+	dlp.countStale(hashes)
+	return dlp.downloadTesterPeer.RequestBodies(hashes)
+}
+
+func (dlp *rangeTesterPeer) RequestReceipts(hashes []common.Hash) error {
+	dlp.countStale(hashes)
+	return dlp.downloadTesterPeer.RequestReceipts(hashes)
+}
+
+// Tests that bodies and receipts are not requested from peers which advertised
+// to have pruned them, retrieving them from the peers still serving them.
+func TestPrunedRangeSynchronisation63Full(t *testing.T) { testPrunedRangeSync(t, 63, FullSync) }
+func TestPrunedRangeSynchronisation63Fast(t *testing.T) { testPrunedRangeSync(t, 63, FastSync) }
+func TestPrunedRangeSynchronisation64Full(t *testing.T) { testPrunedRangeSync(t, 64, FullSync) }
+func TestPrunedRangeSynchronisation64Fast(t *testing.T) { testPrunedRangeSync(t, 64, FastSync) }
+func TestPrunedRangeSynchronisation66Full(t *testing.T) { testPrunedRangeSync(t, 66, FullSync) }
+func TestPrunedRangeSynchronisation66Fast(t *testing.T) { testPrunedRangeSync(t, 66, FastSync) }
+
+func testPrunedRangeSync(t *testing.T, protocol int, mode SyncMode) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+
+	targetBlocks := 4*blockCacheItems - 15
+	hashes, headers, blocks, receipts := tester.makeChain(targetBlocks, 0, tester.genesis, nil, false)
+
+	tester.newPeer("archive", protocol, hashes, headers, blocks, receipts)
+	tester.newPeer("pruned", protocol, hashes, headers, blocks, receipts)
+
+	// Re-register the second peer advertising a pruned history
+	tester.downloader.UnregisterPeer("pruned")
+	pruned := &rangeTesterPeer{
+		downloadTesterPeer: &downloadTesterPeer{dl: tester, id: "pruned"},
+		earliest:           uint64(targetBlocks / 2),
+	}
+	if err := tester.downloader.RegisterPeer("pruned", protocol, pruned); err != nil {
+		t.Fatalf("failed to register pruned peer: %v", err)
+	}
+	if err := tester.sync("pruned", nil, mode); err != nil {
+		t.Fatalf("failed to synchronise blocks: %v", err)
+	}
+	assertOwnChain(t, tester, targetBlocks+1)
+
+	if stale := atomic.LoadInt32(&pruned.stale); stale != 0 {
+		t.Errorf("pruned blocks requested from peer: %d", stale)
+	}
+}
+
 // Tests that synchronisations behave well in multi-version protocol environments
 // and not wreak havoc on other nodes in the network.
 func TestMultiProtoSynchronisation62(t *testing.T)      { testMultiProtoSync(t, 62, FullSync) }
@@ -1753,3 +1831,53 @@ func testDeliverHeadersHang(t *testing.T, protocol int, mode SyncMode) {
 		tester.downloader.peers.peers["peer"].peer.(*floodingTestPeer).pend.Wait()
 	}
 }
+
+// Tests that peers timing out or delivering garbage are deprioritised, and that
+// persistently misbehaving ones get banned from registering.
+func TestPeerScoring(t *testing.T) {
+	peers := newPeerSet()
+
+	good := newPeerConnection("good", 63, nil, log.New("peer", "good"))
+	bad := newPeerConnection("bad", 63, nil, log.New("peer", "bad"))
+	if err := peers.Register(good); err != nil {
+		t.Fatalf("failed to register good peer: %v", err)
+	}
+	if err := peers.Register(bad); err != nil {
+		t.Fatalf("failed to register bad peer: %v", err)
+	}
+	// Make the bad peer faster, but unreliable
+	good.headerThroughput, bad.headerThroughput = 100, 150
+
+	bad.MarkResponse(true)
+	bad.MarkTimeout()
+
+	idle, _ := peers.HeaderIdlePeers()
+	if len(idle) != 2 || idle[0] != good {
+		t.Fatalf("unreliable peer preferred over reliable one")
+	}
+	// Keep misbehaving, which should only be judged after enough samples
+	for bad.samples < scoreMinSamples-1 {
+		bad.MarkResponse(true)
+	}
+	if bad.Misbehaving() {
+		t.Fatalf("peer considered misbehaving after only %d samples", bad.samples)
+	}
+	bad.MarkResponse(true)
+	if !bad.Misbehaving() {
+		t.Fatalf("garbage delivering peer not considered misbehaving")
+	}
+	peers.Ban(bad.id)
+	peers.Unregister(bad.id)
+
+	if err := peers.Register(newPeerConnection("bad", 63, nil, log.New("peer", "bad"))); err != errBannedPeer {
+		t.Fatalf("banned peer registration error mismatch: have %v, want %v", err, errBannedPeer)
+	}
+	if _, ok := peers.Bans()["bad"]; !ok {
+		t.Fatalf("banned peer not reported")
+	}
+	// Expired bans should no longer be enforced
+	peers.banned["bad"] = time.Now().Add(-time.Second)
+	if err := peers.Register(newPeerConnection("bad", 63, nil, log.New("peer", "bad"))); err != nil {
+		t.Fatalf("failed to register peer with expired ban: %v", err)
+	}
+}
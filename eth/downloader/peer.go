@@ -61,6 +61,10 @@ type peerConnection struct {
 
 	rtt time.Duration // Request round trip time to track responsiveness (QoS)
 
+	samples     int     // Number of responses and timeouts the rates below are measured over
+	timeoutRate float64 // Decaying rate of requests timed out by the peer
+	garbageRate float64 // Decaying rate of useless (invalid or unrequested) deliveries
+
 	headerStarted  time.Time // Time instance when the last header fetch was started
 	blockStarted   time.Time // Time instance when the last block (body) fetch was started
 	receiptStarted time.Time // Time instance when the last receipt fetch was started
@@ -90,6 +94,12 @@ type Peer interface {
 	RequestNodeData([]common.Hash) error
 }
 
+// RangePeer is implemented by the peers advertising the range of blocks whose
+// bodies and receipts they are able to serve.
+type RangePeer interface {
+	Serves(number uint64) bool
+}
+
 // lightPeerWrapper wraps a LightPeer struct, stubbing out the Peer-only methods.
 type lightPeerWrapper struct {
 	peer LightPeer
@@ -346,10 +356,21 @@ func (p *peerConnection) Lacks(hash common.Hash) bool {
 	return ok
 }
 
+// Serves retrieves whether the peer is expected to have the body and receipts of
+// the given block, judging by the block range it advertised. Peers not
+// advertising a range are assumed to serve the entire chain.
+func (p *peerConnection) Serves(number uint64) bool {
+	if served, ok := p.peer.(RangePeer); ok {
+		return served.Serves(number)
+	}
+	return true
+}
+
 // peerSet represents the collection of active peer participating in the chain
 // download procedure.
 type peerSet struct {
 	peers        map[string]*peerConnection
+	banned       map[string]time.Time // Peers refused for misbehaving, until the given time
 	newPeerFeed  event.Feed
 	peerDropFeed event.Feed
 	lock         sync.RWMutex
@@ -358,7 +379,8 @@ type peerSet struct {
 // newPeerSet creates a new peer set top track the active download sources.
 func newPeerSet() *peerSet {
 	return &peerSet{
-		peers: make(map[string]*peerConnection),
+		peers:  make(map[string]*peerConnection),
+		banned: make(map[string]time.Time),
 	}
 }
 
@@ -384,7 +406,7 @@ func (ps *peerSet) Reset() {
 }
 
 // Register injects a new peer into the working set, or returns an error if the
-// peer is already known.
+// peer is already known or banned.
 //
 // The method also sets the starting throughput values of the new peer to the
 // average of all existing peers, to give it a realistic chance of being used
@@ -399,6 +421,10 @@ func (ps *peerSet) Register(p *peerConnection) error {
 		ps.lock.Unlock()
 		return errAlreadyRegistered
 	}
+	if ps.isBanned(p.id) {
+		ps.lock.Unlock()
+		return errBannedPeer
+	}
 	if len(ps.peers) > 0 {
 		p.headerThroughput, p.blockThroughput, p.receiptThroughput, p.stateThroughput = 0, 0, 0, 0
 
@@ -477,7 +503,7 @@ func (ps *peerSet) HeaderIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.headerThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 66, idle, throughput)
 }
 
 // BodyIdlePeers retrieves a flat list of all the currently body-idle peers within
@@ -491,7 +517,7 @@ func (ps *peerSet) BodyIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.blockThroughput
 	}
-	return ps.idlePeers(62, 64, idle, throughput)
+	return ps.idlePeers(62, 66, idle, throughput)
 }
 
 // ReceiptIdlePeers retrieves a flat list of all the currently receipt-idle peers
@@ -505,7 +531,7 @@ func (ps *peerSet) ReceiptIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.receiptThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 66, idle, throughput)
 }
 
 // NodeDataIdlePeers retrieves a flat list of all the currently node-data-idle
@@ -519,12 +545,13 @@ func (ps *peerSet) NodeDataIdlePeers() ([]*peerConnection, int) {
 		defer p.lock.RUnlock()
 		return p.stateThroughput
 	}
-	return ps.idlePeers(63, 64, idle, throughput)
+	return ps.idlePeers(63, 66, idle, throughput)
 }
 
 // idlePeers retrieves a flat list of all currently idle peers satisfying the
 // protocol version constraints, using the provided function to check idleness.
-// The resulting set of peers are sorted by their measure throughput.
+// The resulting set of peers are sorted by their measured throughput, discounted
+// by their rate of timed out requests and useless deliveries.
 func (ps *peerSet) idlePeers(minProtocol, maxProtocol int, idleCheck func(*peerConnection) bool, throughput func(*peerConnection) float64) ([]*peerConnection, int) {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
@@ -538,10 +565,15 @@ func (ps *peerSet) idlePeers(minProtocol, maxProtocol int, idleCheck func(*peerC
 			total++
 		}
 	}
+	scores := make([]float64, len(idle))
+	for i, p := range idle {
+		scores[i] = throughput(p) * p.reliability()
+	}
 	for i := 0; i < len(idle); i++ {
 		for j := i + 1; j < len(idle); j++ {
-			if throughput(idle[i]) < throughput(idle[j]) {
+			if scores[i] < scores[j] {
 				idle[i], idle[j] = idle[j], idle[i]
+				scores[i], scores[j] = scores[j], scores[i]
 			}
 		}
 	}
@@ -176,6 +176,37 @@ func (q *queue) PendingReceipts() int {
 	return q.receiptTaskQueue.Size()
 }
 
+// Assignments retrieves the fetch requests currently in flight, grouped by the
+// peers they are assigned to.
+func (q *queue) Assignments() map[string][]PeerAssignment {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
+	assignments := make(map[string][]PeerAssignment)
+	for id, request := range q.headerPendPool {
+		assignments[id] = append(assignments[id], PeerAssignment{
+			Kind:    "headers",
+			From:    request.From,
+			Count:   MaxHeaderFetch,
+			Elapsed: common.PrettyDuration(time.Since(request.Time)).String(),
+		})
+	}
+	for kind, pool := range map[string]map[string]*fetchRequest{"bodies": q.blockPendPool, "receipts": q.receiptPendPool} {
+		for id, request := range pool {
+			if len(request.Headers) == 0 {
+				continue
+			}
+			assignments[id] = append(assignments[id], PeerAssignment{
+				Kind:    kind,
+				From:    request.Headers[0].Number.Uint64(),
+				Count:   len(request.Headers),
+				Elapsed: common.PrettyDuration(time.Since(request.Time)).String(),
+			})
+		}
+	}
+	return assignments
+}
+
 // InFlightHeaders retrieves whether there are header fetch requests currently
 // in flight.
 func (q *queue) InFlightHeaders() bool {
@@ -534,7 +565,7 @@ func (q *queue) reserveHeaders(p *peerConnection, count int, taskPool map[common
 			continue
 		}
 		// Otherwise unless the peer is known not to have the data, add to the retrieve list
-		if p.Lacks(hash) {
+		if p.Lacks(hash) || !p.Serves(header.Number.Uint64()) {
 			skip = append(skip, header)
 		} else {
 			send = append(send, header)
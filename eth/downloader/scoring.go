@@ -0,0 +1,203 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+package downloader
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	ethereum "github.com/usechain/go-usechain"
+	"github.com/usechain/go-usechain/common"
+)
+
+const (
+	scoreImpact     = 0.2              // The impact a single response has on a peer's timeout and garbage rates
+	scoreMinSamples = 8                // Number of responses to observe before a peer may be banned
+	banGarbageRate  = 0.5              // Rate of useless deliveries above which a peer is banned
+	banTimeoutRate  = 0.75             // Rate of timed out requests above which a peer is banned
+	peerBanDuration = 30 * time.Minute // Time a banned peer is refused as a download source
+)
+
+var errBannedPeer = errors.New("peer is banned for poor sync performance")
+
+// MarkResponse updates the reliability of the peer with the outcome of a data
+// retrieval request, useless (invalid or unrequested) data counting as garbage.
+func (p *peerConnection) MarkResponse(garbage bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.samples++
+	p.timeoutRate = (1 - scoreImpact) * p.timeoutRate
+	p.garbageRate = (1 - scoreImpact) * p.garbageRate
+	if garbage {
+		p.garbageRate += scoreImpact
+	}
+}
+
+// MarkTimeout updates the reliability of the peer with a timed out request.
+func (p *peerConnection) MarkTimeout() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.samples++
+	p.timeoutRate = (1-scoreImpact)*p.timeoutRate + scoreImpact
+}
+
+// reliability returns the estimated probability of a request to the peer being
+// answered in time with useful data.
+func (p *peerConnection) reliability() float64 {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return (1 - p.timeoutRate) * (1 - p.garbageRate)
+}
+
+// Misbehaving checks whether the peer persistently times out or delivers useless
+// data, sabotaging the sync more than contributing to it.
+func (p *peerConnection) Misbehaving() bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	if p.samples < scoreMinSamples {
+		return false
+	}
+	return p.garbageRate > banGarbageRate || p.timeoutRate > banTimeoutRate
+}
+
+// Ban marks a peer as unfit for downloading, refusing its registration until
+// the ban expires.
+func (ps *peerSet) Ban(id string) {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	ps.banned[id] = time.Now().Add(peerBanDuration)
+}
+
+// isBanned checks whether a peer is currently banned, dropping expired bans.
+//
+// Note, this method assumes that the set's lock is held!
+func (ps *peerSet) isBanned(id string) bool {
+	until, ok := ps.banned[id]
+	if ok && time.Now().After(until) {
+		delete(ps.banned, id)
+		return false
+	}
+	return ok
+}
+
+// scorePeer bans and drops a peer if it's misbehaving, so its pending requests
+// are rescheduled to better peers and it's not assigned new ones.
+func (d *Downloader) scorePeer(p *peerConnection) {
+	if !p.Misbehaving() {
+		return
+	}
+	p.log.Warn("Banning misbehaving sync peer", "timeouts", p.timeoutRate, "garbage", p.garbageRate, "duration", peerBanDuration)
+	peerBanMeter.Mark(1)
+
+	d.peers.Ban(p.id)
+	if d.dropPeer == nil {
+		// The dropPeer method is nil when `--copydb` is used for a local copy.
+		p.log.Warn("Downloader wants to drop peer, but peerdrop-function is not set", "peer", p.id)
+		return
+	}
+	d.dropPeer(p.id)
+}
+
+// PeerAssignment is a data retrieval request currently in flight to a peer.
+type PeerAssignment struct {
+	Kind    string `json:"kind"`    // Type of the retrieved data (headers, bodies, receipts)
+	From    uint64 `json:"from"`    // Number of the first requested block
+	Count   int    `json:"count"`   // Number of requested items
+	Elapsed string `json:"elapsed"` // Time since the request was made
+}
+
+// PeerSyncStatus is the scheduling state of a single download peer.
+type PeerSyncStatus struct {
+	ID                string           `json:"id"`
+	Version           int              `json:"version"`
+	RTT               string           `json:"rtt"`
+	HeaderThroughput  float64          `json:"headerThroughput"`
+	BlockThroughput   float64          `json:"blockThroughput"`
+	ReceiptThroughput float64          `json:"receiptThroughput"`
+	StateThroughput   float64          `json:"stateThroughput"`
+	TimeoutRate       float64          `json:"timeoutRate"`
+	GarbageRate       float64          `json:"garbageRate"`
+	Samples           int              `json:"samples"`
+	Assignments       []PeerAssignment `json:"assignments"`
+}
+
+// SyncStatus is the scheduling state of the downloader across all its peers.
+type SyncStatus struct {
+	Syncing  bool                  `json:"syncing"`
+	Progress ethereum.SyncProgress `json:"progress"`
+	Peers    []PeerSyncStatus      `json:"peers"`
+	Banned   map[string]time.Time  `json:"banned"`
+}
+
+// SyncStatus retrieves the current sync progress along with the measured quality
+// and in-flight assignments of every download peer, best ones first.
+func (d *Downloader) SyncStatus() *SyncStatus {
+	status := &SyncStatus{
+		Syncing:  d.Synchronising(),
+		Progress: d.Progress(),
+		Peers:    []PeerSyncStatus{},
+		Banned:   d.peers.Bans(),
+	}
+	assignments := d.queue.Assignments()
+	for _, p := range d.peers.AllPeers() {
+		p.lock.RLock()
+		peer := PeerSyncStatus{
+			ID:                p.id,
+			Version:           p.version,
+			RTT:               common.PrettyDuration(p.rtt).String(),
+			HeaderThroughput:  p.headerThroughput,
+			BlockThroughput:   p.blockThroughput,
+			ReceiptThroughput: p.receiptThroughput,
+			StateThroughput:   p.stateThroughput,
+			TimeoutRate:       p.timeoutRate,
+			GarbageRate:       p.garbageRate,
+			Samples:           p.samples,
+			Assignments:       assignments[p.id],
+		}
+		p.lock.RUnlock()
+
+		if peer.Assignments == nil {
+			peer.Assignments = []PeerAssignment{}
+		}
+		status.Peers = append(status.Peers, peer)
+	}
+	sort.Slice(status.Peers, func(i, j int) bool {
+		a, b := status.Peers[i], status.Peers[j]
+		return a.BlockThroughput*(1-a.TimeoutRate)*(1-a.GarbageRate) > b.BlockThroughput*(1-b.TimeoutRate)*(1-b.GarbageRate)
+	})
+	return status
+}
+
+// Bans retrieves the currently banned peers along with the expiry of their bans.
+func (ps *peerSet) Bans() map[string]time.Time {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	bans := make(map[string]time.Time)
+	for id, until := range ps.banned {
+		if !ps.isBanned(id) {
+			continue
+		}
+		bans[id] = until
+	}
+	return bans
+}
@@ -28,6 +28,7 @@ import (
 	ethereum "github.com/usechain/go-usechain"
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
@@ -59,6 +60,9 @@ type PublicFilterAPI struct {
 	events    *EventSystem
 	filtersMu sync.Mutex
 	filters   map[rpc.ID]*filter
+
+	logChunk uint64 // Number of blocks searched at once by log queries
+	logLimit int    // Maximum number of logs returned by a log query
 }
 
 // NewPublicFilterAPI returns a new PublicFilterAPI instance.
@@ -69,12 +73,21 @@ func NewPublicFilterAPI(backend Backend, lightMode bool) *PublicFilterAPI {
 		chainDb: backend.ChainDb(),
 		events:  NewEventSystem(backend.EventMux(), backend, lightMode),
 		filters: make(map[rpc.ID]*filter),
+
+		logChunk: DefaultLogChunkSize,
+		logLimit: DefaultLogLimit,
 	}
 	go api.timeoutLoop()
 
 	return api
 }
 
+// SetLogLimits sets the number of blocks searched at once by log queries and
+// the maximum number of logs they may return. Zero values disable the bound.
+func (api *PublicFilterAPI) SetLogLimits(chunk uint64, limit int) {
+	api.logChunk, api.logLimit = chunk, limit
+}
+
 // timeoutLoop runs every 5 minutes and deletes filters that have not been recently used.
 // Tt is started when the api is created.
 func (api *PublicFilterAPI) timeoutLoop() {
@@ -229,6 +242,103 @@ func (api *PublicFilterAPI) NewHeads(ctx context.Context) (*rpc.Subscription, er
 	return rpcSub, nil
 }
 
+// NewFinalizedHeads send a notification each time a block becomes finalized,
+// i.e. is buried under the configured number of confirmations.
+func (api *PublicFilterAPI) NewFinalizedHeads(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		headers := make(chan *types.Header)
+		headersSub := api.events.SubscribeFinalizedHeads(headers)
+
+		for {
+			select {
+			case h := <-headers:
+				notifier.Notify(rpcSub.ID, h)
+			case <-rpcSub.Err():
+				headersSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				headersSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// DroppedTransaction is the notification sent for a transaction which left the
+// pool without being included in the chain.
+type DroppedTransaction struct {
+	Hash       common.Hash       `json:"hash"`
+	From       common.Address    `json:"from"`
+	Nonce      hexutil.Uint64    `json:"nonce"`
+	Reason     core.TxDropReason `json:"reason"`
+	ReplacedBy *common.Hash      `json:"replacedBy,omitempty"`
+}
+
+// DroppedTxCriteria restricts the dropped transaction notifications to a set
+// of senders.
+type DroppedTxCriteria struct {
+	From []common.Address `json:"from"`
+}
+
+// DroppedTransactions creates a subscription that is triggered each time a
+// transaction is dropped from the pool without being included in the chain,
+// e.g. because it was replaced, outpriced or invalidated by a reorg. Without
+// criteria the drops of all senders are reported.
+func (api *PublicFilterAPI) DroppedTransactions(ctx context.Context, crit *DroppedTxCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	senders := make(map[common.Address]bool)
+	if crit != nil {
+		for _, addr := range crit.From {
+			senders[addr] = true
+		}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		drops := make(chan core.TxDropEvent)
+		dropsSub := api.events.SubscribeDroppedTxEvents(drops)
+
+		for {
+			select {
+			case ev := <-drops:
+				if len(senders) > 0 && !senders[ev.From] {
+					continue
+				}
+				dropped := &DroppedTransaction{
+					Hash:   ev.Tx.Hash(),
+					From:   ev.From,
+					Nonce:  hexutil.Uint64(ev.Tx.Nonce()),
+					Reason: ev.Reason,
+				}
+				if ev.Replacement != (common.Hash{}) {
+					dropped.ReplacedBy = &ev.Replacement
+				}
+				notifier.Notify(rpcSub.ID, dropped)
+			case <-rpcSub.Err():
+				dropsSub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				dropsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Logs creates a subscription that fires for all new log that match the given filter criteria.
 func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
 	notifier, supported := rpc.NotifierFromContext(ctx)
@@ -267,6 +377,85 @@ func (api *PublicFilterAPI) Logs(ctx context.Context, crit FilterCriteria) (*rpc
 	return rpcSub, nil
 }
 
+// LogsChunk is a batch of logs streamed by a pastLogs subscription, holding all
+// the matches within a range of blocks.
+type LogsChunk struct {
+	From  hexutil.Uint64 `json:"fromBlock"`
+	To    hexutil.Uint64 `json:"toBlock"`
+	Logs  []*types.Log   `json:"logs"`
+	Done  bool           `json:"done"`            // Whether the search completed
+	Error string         `json:"error,omitempty"` // Failure terminating the search
+}
+
+// PastLogs creates a subscription streaming the logs stored in the chain which
+// match the given filter criteria, as the range is searched chunk by chunk. The
+// last notification either has done set or reports the error ending the search,
+// along with the unavailable range if blocks were found pruned.
+func (api *PublicFilterAPI) PastLogs(ctx context.Context, crit FilterCriteria) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var (
+		rpcSub = notifier.CreateSubscription()
+		filter = api.newFilter(crit)
+	)
+	// The search outlives the request, abort it if the client goes away
+	search, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-rpcSub.Err(): // client send an unsubscribe request
+		case <-notifier.Closed(): // connection dropped
+		case <-search.Done():
+		}
+		cancel()
+	}()
+	go func() {
+		defer cancel()
+
+		// Hold the search back until the client is told the subscription ID, as
+		// the notifications would pile up in memory meanwhile
+		select {
+		case <-rpcSub.Activated():
+		case <-search.Done():
+			return
+		}
+		err := filter.Stream(search, func(from, to uint64, logs []*types.Log) error {
+			return notifier.Notify(rpcSub.ID, &LogsChunk{From: hexutil.Uint64(from), To: hexutil.Uint64(to), Logs: returnLogs(logs)})
+		})
+		if search.Err() != nil {
+			return
+		}
+		last := &LogsChunk{Logs: []*types.Log{}, Done: err == nil}
+		if err != nil {
+			last.Error = err.Error()
+			if pruned, ok := err.(*PrunedRangeError); ok {
+				last.From, last.To = hexutil.Uint64(pruned.From), hexutil.Uint64(pruned.To)
+			}
+		}
+		notifier.Notify(rpcSub.ID, last)
+	}()
+
+	return rpcSub, nil
+}
+
+// newFilter creates a filter over the stored logs matching the given criteria,
+// bounded by the configured log query limits.
+func (api *PublicFilterAPI) newFilter(crit FilterCriteria) *Filter {
+	begin := rpc.LatestBlockNumber.Int64()
+	if crit.FromBlock != nil {
+		begin = crit.FromBlock.Int64()
+	}
+	end := rpc.LatestBlockNumber.Int64()
+	if crit.ToBlock != nil {
+		end = crit.ToBlock.Int64()
+	}
+	filter := New(api.backend, begin, end, crit.Addresses, crit.Topics)
+	filter.SetLimits(api.logChunk, api.logLimit)
+
+	return filter
+}
+
 // FilterCriteria represents a request to create a new filter.
 //
 // TODO(karalabe): Kill this in favor of ethereum.FilterQuery.
@@ -324,17 +513,15 @@ func (api *PublicFilterAPI) NewFilter(crit FilterCriteria) (rpc.ID, error) {
 
 // GetLogs returns logs matching the given argument that are stored within the state.
 //
+// Ranges are searched in chunks of bounded size. Queries matching more logs than
+// allowed fail with a LimitExceededError, and queries covering blocks whose data
+// is unavailable with a PrunedRangeError, both carrying the logs found before in
+// their error data.
+//
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_getlogs
 func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([]*types.Log, error) {
-	// Convert the RPC block numbers into internal representations
-	if crit.FromBlock == nil {
-		crit.FromBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
-	}
-	if crit.ToBlock == nil {
-		crit.ToBlock = big.NewInt(rpc.LatestBlockNumber.Int64())
-	}
 	// Create and run the filter to get all the logs
-	filter := New(api.backend, crit.FromBlock.Int64(), crit.ToBlock.Int64(), crit.Addresses, crit.Topics)
+	filter := api.newFilter(crit)
 
 	logs, err := filter.Logs(ctx)
 	if err != nil {
@@ -343,6 +530,18 @@ func (api *PublicFilterAPI) GetLogs(ctx context.Context, crit FilterCriteria) ([
 	return returnLogs(logs), err
 }
 
+// GetLogsPaged returns the logs matching the given argument page by page, with at
+// most pageSize logs each (0 = default page size). The range is searched as the
+// following pages are retrieved through rpc_nextPage, and the number of logs is
+// not limited as with GetLogs.
+func (api *PublicFilterAPI) GetLogsPaged(ctx context.Context, crit FilterCriteria, pageSize int) (*rpc.Page, error) {
+	it, err := api.newFilter(crit).Iterator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.Paginate(ctx, "eth_getLogsPaged", it, pageSize)
+}
+
 // UninstallFilter removes the filter with the given filter id.
 //
 // https://github.com/ethereum/wiki/wiki/JSON-RPC#eth_uninstallfilter
@@ -373,16 +572,8 @@ func (api *PublicFilterAPI) GetFilterLogs(ctx context.Context, id rpc.ID) ([]*ty
 		return nil, fmt.Errorf("filter not found")
 	}
 
-	begin := rpc.LatestBlockNumber.Int64()
-	if f.crit.FromBlock != nil {
-		begin = f.crit.FromBlock.Int64()
-	}
-	end := rpc.LatestBlockNumber.Int64()
-	if f.crit.ToBlock != nil {
-		end = f.crit.ToBlock.Int64()
-	}
 	// Create and run the filter to get all the logs
-	filter := New(api.backend, begin, end, f.crit.Addresses, f.crit.Topics)
+	filter := api.newFilter(f.crit)
 
 	logs, err := filter.Logs(ctx)
 	if err != nil {
@@ -18,9 +18,13 @@ package filters
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
+	"sort"
 
 	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
 	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/core/bloombits"
 	"github.com/usechain/go-usechain/core/types"
@@ -37,7 +41,9 @@ type Backend interface {
 	GetLogs(ctx context.Context, blockHash common.Hash) ([][]*types.Log, error)
 
 	SubscribeTxPreEvent(chan<- core.TxPreEvent) event.Subscription
+	SubscribeTxDropEvent(chan<- core.TxDropEvent) event.Subscription
 	SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription
+	SubscribeFinalizedHeadEvent(ch chan<- core.FinalizedHeadEvent) event.Subscription
 	SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription
 	SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscription
 
@@ -45,6 +51,64 @@ type Backend interface {
 	ServiceFilter(ctx context.Context, session *bloombits.MatcherSession)
 }
 
+const (
+	// DefaultLogChunkSize is the number of blocks searched at once by a log query,
+	// between which the limits of the query are checked.
+	DefaultLogChunkSize = 2048
+
+	// DefaultLogLimit is the maximum number of logs returned by a log query of
+	// the RPC API.
+	DefaultLogLimit = 10000
+)
+
+// PrunedRangeError is returned by log queries covering blocks whose headers or
+// receipts are not available locally (anymore), instead of silently skipping
+// the logs of those blocks.
+type PrunedRangeError struct {
+	From, To uint64       // Range of blocks found unavailable
+	Logs     []*types.Log // Matching logs of the blocks preceding the range
+}
+
+func (e *PrunedRangeError) Error() string {
+	return fmt.Sprintf("logs of blocks %d-%d are unavailable (pruned)", e.From, e.To)
+}
+
+// ErrorCode returns the EIP-1474 "resource unavailable" code.
+func (e *PrunedRangeError) ErrorCode() int { return -32002 }
+
+// ErrorData returns the unavailable range and the logs found before it.
+func (e *PrunedRangeError) ErrorData() interface{} {
+	return &rangeErrorData{From: hexutil.Uint64(e.From), To: hexutil.Uint64(e.To), Logs: returnLogs(e.Logs)}
+}
+
+// LimitExceededError is returned by log queries matching more logs than allowed.
+// The logs of the blocks up to To, fitting within the limit, are returned along
+// with the error so the query can be resumed from the following block.
+type LimitExceededError struct {
+	Limit    int          // Maximum number of logs allowed
+	From, To uint64       // Range of blocks whose logs are returned
+	Logs     []*types.Log // Matching logs of the returned range
+}
+
+func (e *LimitExceededError) Error() string {
+	return fmt.Sprintf("query returned more than %d results, blocks %d-%d returned", e.Limit, e.From, e.To)
+}
+
+// ErrorCode returns the EIP-1474 "limit exceeded" code.
+func (e *LimitExceededError) ErrorCode() int { return -32005 }
+
+// ErrorData returns the range covered by the returned logs and the logs.
+func (e *LimitExceededError) ErrorData() interface{} {
+	return &rangeErrorData{From: hexutil.Uint64(e.From), To: hexutil.Uint64(e.To), Logs: returnLogs(e.Logs)}
+}
+
+// rangeErrorData is the structured data attached to log query errors.
+type rangeErrorData struct {
+	From hexutil.Uint64 `json:"fromBlock"`
+	To   hexutil.Uint64 `json:"toBlock"`
+	Logs []*types.Log   `json:"logs"`
+}
+
 // Filter can be used to retrieve and filter logs.
 type Filter struct {
 	backend Backend
@@ -54,6 +118,9 @@ type Filter struct {
 	addresses  []common.Address
 	topics     [][]common.Hash
 
+	chunk uint64 // Number of blocks searched at once (0 = whole range)
+	limit int    // Maximum number of logs to return (0 = unlimited)
+
 	matcher *bloombits.Matcher
 }
 
@@ -88,36 +155,208 @@ func New(backend Backend, begin, end int64, addresses []common.Address, topics [
 		addresses: addresses,
 		topics:    topics,
 		db:        backend.ChainDb(),
+		chunk:     DefaultLogChunkSize,
 		matcher:   bloombits.NewMatcher(size, filters),
 	}
 }
 
+// SetLimits sets the number of blocks searched at once and the maximum number
+// of logs returned by Logs. Zero values disable the respective bound.
+func (f *Filter) SetLimits(chunk uint64, limit int) {
+	f.chunk, f.limit = chunk, limit
+}
+
 // Logs searches the blockchain for matching log entries, returning all from the
 // first block that contains matches, updating the start of the filter accordingly.
+//
+// If the limit of the filter is exceeded, or the data of some blocks in the range
+// is unavailable, the logs found before are returned along with the error.
 func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
+	begin, end, err := f.span(ctx)
+	if err != nil || begin > end {
+		return nil, err
+	}
+	var logs []*types.Log
+	err = f.stream(ctx, uint64(begin), uint64(end), func(from, to uint64, found []*types.Log) error {
+		if f.limit > 0 && len(logs)+len(found) > f.limit {
+			// Cut the results before the first block not fitting in entirely
+			last := found[f.limit-len(logs)].BlockNumber
+			cut := sort.Search(len(found), func(i int) bool { return found[i].BlockNumber >= last })
+			if len(logs)+cut == 0 {
+				// A single block exceeds the limit, return it whole to make progress
+				cut = sort.Search(len(found), func(i int) bool { return found[i].BlockNumber > last })
+				last++
+			}
+			logs = append(logs, found[:cut]...)
+
+			return &LimitExceededError{Limit: f.limit, From: uint64(begin), To: last - 1}
+		}
+		logs = append(logs, found...)
+		return nil
+	})
+	switch err := err.(type) {
+	case *LimitExceededError:
+		err.Logs = logs
+	case *PrunedRangeError:
+		err.Logs = logs
+	}
+	return logs, err
+}
+
+// Stream searches the blockchain for matching log entries chunk by chunk, passing
+// the logs found in each range of blocks to the callback as soon as the range is
+// fully searched. Any error returned by the callback aborts the search.
+func (f *Filter) Stream(ctx context.Context, fn func(from, to uint64, logs []*types.Log) error) error {
+	begin, end, err := f.span(ctx)
+	if err != nil || begin > end {
+		return err
+	}
+	return f.stream(ctx, uint64(begin), uint64(end), fn)
+}
+
+// Iterator returns an iterator paging through the matching log entries, the
+// range of blocks being searched chunk by chunk as the pages are requested.
+// The limit on the number of logs doesn't apply.
+func (f *Filter) Iterator(ctx context.Context) (rpc.Iterator, error) {
+	begin, end, err := f.span(ctx)
+	if err != nil {
+		return nil, err
+	}
+	it := &logIterator{filter: f, searched: begin > end}
+	if !it.searched {
+		it.next, it.end = uint64(begin), uint64(end)
+	}
+	return it, nil
+}
+
+// logIterator pages through the logs matching a filter.
+type logIterator struct {
+	filter   *Filter
+	next     uint64       // Next block to search
+	end      uint64       // Last block to search
+	searched bool         // Whether the entire range was searched
+	pending  []*types.Log // Logs found but not returned yet
+}
+
+// Next searches the following chunks of the range until enough logs are found
+// to fill the page, or the entire range was searched. If the data of some
+// blocks is unavailable, the logs found before are returned with the error.
+func (it *logIterator) Next(ctx context.Context, limit int) ([]interface{}, bool, error) {
+	chunk := it.filter.chunk
+	if chunk == 0 {
+		chunk = DefaultLogChunkSize
+	}
+	for len(it.pending) < limit && !it.searched {
+		to := it.end
+		if it.end-it.next >= chunk {
+			to = it.next + chunk - 1
+		}
+		logs, err := it.filter.rangeLogs(ctx, it.next, to)
+		if err != nil {
+			if pruned, ok := err.(*PrunedRangeError); ok {
+				it.filter.extendPruned(ctx, pruned, to)
+				pruned.Logs = append(it.pending, logs...)
+			}
+			return nil, false, err
+		}
+		it.pending = append(it.pending, logs...)
+		if to == it.end {
+			it.searched = true
+		} else {
+			it.next = to + 1
+		}
+	}
+	if limit > len(it.pending) {
+		limit = len(it.pending)
+	}
+	items := make([]interface{}, limit)
+	for i, log := range it.pending[:limit] {
+		items[i] = log
+	}
+	it.pending = it.pending[limit:]
+
+	return items, it.searched && len(it.pending) == 0, nil
+}
+
+// Close drops the logs found but not returned.
+func (it *logIterator) Close() {
+	it.pending = nil
+}
+
+// span resolves the block range of the filter against the current chain. An
+// empty range is reported with begin exceeding end.
+func (f *Filter) span(ctx context.Context) (int64, int64, error) {
 	// Figure out the limits of the filter range
 	header, _ := f.backend.HeaderByNumber(ctx, rpc.LatestBlockNumber)
 	if header == nil {
-		return nil, nil
+		return 0, -1, nil
 	}
-	head := header.Number.Uint64()
+	head := header.Number.Int64()
 
-	if f.begin == -1 {
-		f.begin = int64(head)
+	// Resolve the safe and finalized tags against the current finality markers
+	begin, end := f.begin, f.end
+	if err := f.resolveTag(ctx, &begin); err != nil {
+		return 0, -1, err
 	}
-	end := uint64(f.end)
-	if f.end == -1 {
+	if err := f.resolveTag(ctx, &end); err != nil {
+		return 0, -1, err
+	}
+	if begin < 0 {
+		begin = head
+	}
+	if end < 0 || end > head {
 		end = head
 	}
-	// Gather all indexed logs, and finish with non indexed ones
+	return begin, end, nil
+}
+
+// stream searches the given range of blocks chunk by chunk, handing the logs of
+// each to the callback. Logs preceding a pruned gap are handed out before the
+// search fails.
+func (f *Filter) stream(ctx context.Context, begin, end uint64, fn func(from, to uint64, logs []*types.Log) error) error {
+	for from := begin; ; {
+		to := end
+		if f.chunk > 0 && end-from >= f.chunk {
+			to = from + f.chunk - 1
+		}
+		logs, err := f.rangeLogs(ctx, from, to)
+		if err != nil {
+			if pruned, ok := err.(*PrunedRangeError); ok {
+				f.extendPruned(ctx, pruned, to)
+				if pruned.From > from {
+					if err := fn(from, pruned.From-1, logs); err != nil {
+						return err
+					}
+				}
+			}
+			return err
+		}
+		if err := fn(from, to, logs); err != nil {
+			return err
+		}
+		if to == end {
+			return nil
+		}
+		from = to + 1
+	}
+}
+
+// rangeLogs gathers the logs of a range of blocks, using the bloom bits index
+// where available and finishing with the non indexed blocks.
+func (f *Filter) rangeLogs(ctx context.Context, from, to uint64) ([]*types.Log, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.begin = int64(from)
+
 	var (
 		logs []*types.Log
 		err  error
 	)
 	size, sections := f.backend.BloomStatus()
-	if indexed := sections * size; indexed > uint64(f.begin) {
-		if indexed > end {
-			logs, err = f.indexedLogs(ctx, end)
+	if indexed := sections * size; indexed > from {
+		if indexed > to {
+			logs, err = f.indexedLogs(ctx, to)
 		} else {
 			logs, err = f.indexedLogs(ctx, indexed-1)
 		}
@@ -125,11 +364,28 @@ func (f *Filter) Logs(ctx context.Context) ([]*types.Log, error) {
 			return logs, err
 		}
 	}
-	rest, err := f.unindexedLogs(ctx, end)
+	rest, err := f.unindexedLogs(ctx, to)
 	logs = append(logs, rest...)
 	return logs, err
 }
 
+// resolveTag replaces a safe or finalized block tag with the number of the
+// block it currently refers to.
+func (f *Filter) resolveTag(ctx context.Context, number *int64) error {
+	if *number != rpc.SafeBlockNumber.Int64() && *number != rpc.FinalizedBlockNumber.Int64() {
+		return nil
+	}
+	header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(*number))
+	if header == nil {
+		if err == nil {
+			err = errors.New("unknown block")
+		}
+		return err
+	}
+	*number = header.Number.Int64()
+	return nil
+}
+
 // indexedLogs returns the logs matching the filter criteria based on the bloom
 // bits indexed available locally or via the network.
 func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, error) {
@@ -162,9 +418,12 @@ func (f *Filter) indexedLogs(ctx context.Context, end uint64) ([]*types.Log, err
 
 			// Retrieve the suggested block and pull any truly matching logs
 			header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(number))
-			if header == nil || err != nil {
+			if err != nil {
 				return logs, err
 			}
+			if header == nil {
+				return logs, &PrunedRangeError{From: number, To: number}
+			}
 			found, err := f.checkMatches(ctx, header)
 			if err != nil {
 				return logs, err
@@ -184,9 +443,12 @@ func (f *Filter) unindexedLogs(ctx context.Context, end uint64) ([]*types.Log, e
 
 	for ; f.begin <= int64(end); f.begin++ {
 		header, err := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(f.begin))
-		if header == nil || err != nil {
+		if err != nil {
 			return logs, err
 		}
+		if header == nil {
+			return logs, &PrunedRangeError{From: uint64(f.begin), To: uint64(f.begin)}
+		}
 		if bloomFilter(header.Bloom, f.addresses, f.topics) {
 			found, err := f.checkMatches(ctx, header)
 			if err != nil {
@@ -206,6 +468,9 @@ func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs [
 	if err != nil {
 		return nil, err
 	}
+	if len(logsList) == 0 && header.ReceiptHash != types.EmptyRootHash {
+		return nil, &PrunedRangeError{From: header.Number.Uint64(), To: header.Number.Uint64()}
+	}
 	var unfiltered []*types.Log
 	for _, logs := range logsList {
 		unfiltered = append(unfiltered, logs...)
@@ -229,6 +494,23 @@ func (f *Filter) checkMatches(ctx context.Context, header *types.Header) (logs [
 	return nil, nil
 }
 
+// extendPruned extends the range of a pruned error over the directly following
+// blocks whose data is unavailable too, up to end.
+func (f *Filter) extendPruned(ctx context.Context, err *PrunedRangeError, end uint64) {
+	for ; err.To < end; err.To++ {
+		header, _ := f.backend.HeaderByNumber(ctx, rpc.BlockNumber(err.To+1))
+		if header == nil {
+			continue
+		}
+		if header.ReceiptHash == types.EmptyRootHash {
+			return
+		}
+		if receipts, _ := f.backend.GetReceipts(ctx, header.Hash()); len(receipts) > 0 {
+			return
+		}
+	}
+}
+
 func includes(addresses []common.Address, a common.Address) bool {
 	for _, addr := range addresses {
 		if addr == a {
@@ -51,6 +51,11 @@ const (
 	PendingTransactionsSubscription
 	// BlocksSubscription queries hashes for blocks that are imported
 	BlocksSubscription
+	// FinalizedBlocksSubscription queries headers for blocks that become finalized
+	FinalizedBlocksSubscription
+	// DroppedTransactionsSubscription queries transactions dropped from the
+	// pool without being included in the chain
+	DroppedTransactionsSubscription
 	// LastSubscription keeps track of the last index
 	LastIndexSubscription
 )
@@ -60,12 +65,16 @@ const (
 	// txChanSize is the size of channel listening to TxPreEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+	// dropChanSize is the size of channel listening to TxDropEvent.
+	dropChanSize = 1024
 	// rmLogsChanSize is the size of channel listening to RemovedLogsEvent.
 	rmLogsChanSize = 10
 	// logsChanSize is the size of channel listening to LogsEvent.
 	logsChanSize = 10
 	// chainEvChanSize is the size of channel listening to ChainEvent.
 	chainEvChanSize = 10
+	// finalizedEvChanSize is the size of channel listening to FinalizedHeadEvent.
+	finalizedEvChanSize = 10
 )
 
 var (
@@ -80,6 +89,7 @@ type subscription struct {
 	logs      chan []*types.Log
 	hashes    chan common.Hash
 	headers   chan *types.Header
+	drops     chan core.TxDropEvent
 	installed chan struct{} // closed when the filter is installed
 	err       chan error    // closed when the filter is uninstalled
 }
@@ -143,6 +153,7 @@ func (sub *Subscription) Unsubscribe() {
 			case <-sub.f.logs:
 			case <-sub.f.hashes:
 			case <-sub.f.headers:
+			case <-sub.f.drops:
 			}
 		}
 
@@ -210,6 +221,7 @@ func (es *EventSystem) subscribeMinedPendingLogs(crit ethereum.FilterQuery, logs
 		logs:      logs,
 		hashes:    make(chan common.Hash),
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -227,6 +239,7 @@ func (es *EventSystem) subscribeLogs(crit ethereum.FilterQuery, logs chan []*typ
 		logs:      logs,
 		hashes:    make(chan common.Hash),
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -244,6 +257,7 @@ func (es *EventSystem) subscribePendingLogs(crit ethereum.FilterQuery, logs chan
 		logs:      logs,
 		hashes:    make(chan common.Hash),
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -260,6 +274,24 @@ func (es *EventSystem) SubscribeNewHeads(headers chan *types.Header) *Subscripti
 		logs:      make(chan []*types.Log),
 		hashes:    make(chan common.Hash),
 		headers:   headers,
+		drops:     make(chan core.TxDropEvent),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeFinalizedHeads creates a subscription that writes the header of a
+// block once it is buried deep enough in the chain to be considered final.
+func (es *EventSystem) SubscribeFinalizedHeads(headers chan *types.Header) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       FinalizedBlocksSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan common.Hash),
+		headers:   headers,
+		drops:     make(chan core.TxDropEvent),
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -276,6 +308,24 @@ func (es *EventSystem) SubscribePendingTxEvents(hashes chan common.Hash) *Subscr
 		logs:      make(chan []*types.Log),
 		hashes:    hashes,
 		headers:   make(chan *types.Header),
+		drops:     make(chan core.TxDropEvent),
+		installed: make(chan struct{}),
+		err:       make(chan error),
+	}
+	return es.subscribe(sub)
+}
+
+// SubscribeDroppedTxEvents creates a subscription that writes the transactions
+// dropped from the transaction pool without being included in the chain.
+func (es *EventSystem) SubscribeDroppedTxEvents(drops chan core.TxDropEvent) *Subscription {
+	sub := &subscription{
+		id:        rpc.NewID(),
+		typ:       DroppedTransactionsSubscription,
+		created:   time.Now(),
+		logs:      make(chan []*types.Log),
+		hashes:    make(chan common.Hash),
+		headers:   make(chan *types.Header),
+		drops:     drops,
 		installed: make(chan struct{}),
 		err:       make(chan error),
 	}
@@ -320,6 +370,10 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 		for _, f := range filters[PendingTransactionsSubscription] {
 			f.hashes <- e.Tx.Hash()
 		}
+	case core.TxDropEvent:
+		for _, f := range filters[DroppedTransactionsSubscription] {
+			f.drops <- e
+		}
 	case core.ChainEvent:
 		for _, f := range filters[BlocksSubscription] {
 			f.headers <- e.Block.Header()
@@ -333,6 +387,10 @@ func (es *EventSystem) broadcast(filters filterIndex, ev interface{}) {
 				}
 			})
 		}
+	case core.FinalizedHeadEvent:
+		for _, f := range filters[FinalizedBlocksSubscription] {
+			f.headers <- e.Block.Header()
+		}
 	}
 }
 
@@ -417,6 +475,9 @@ func (es *EventSystem) eventLoop() {
 		// Subscribe TxPreEvent form txpool
 		txCh  = make(chan core.TxPreEvent, txChanSize)
 		txSub = es.backend.SubscribeTxPreEvent(txCh)
+		// Subscribe TxDropEvent form txpool
+		dropCh  = make(chan core.TxDropEvent, dropChanSize)
+		dropSub = es.backend.SubscribeTxDropEvent(dropCh)
 		// Subscribe RemovedLogsEvent
 		rmLogsCh  = make(chan core.RemovedLogsEvent, rmLogsChanSize)
 		rmLogsSub = es.backend.SubscribeRemovedLogsEvent(rmLogsCh)
@@ -426,14 +487,19 @@ func (es *EventSystem) eventLoop() {
 		// Subscribe ChainEvent
 		chainEvCh  = make(chan core.ChainEvent, chainEvChanSize)
 		chainEvSub = es.backend.SubscribeChainEvent(chainEvCh)
+		// Subscribe FinalizedHeadEvent
+		finalizedCh  = make(chan core.FinalizedHeadEvent, finalizedEvChanSize)
+		finalizedSub = es.backend.SubscribeFinalizedHeadEvent(finalizedCh)
 	)
 
 	// Unsubscribe all events
 	defer sub.Unsubscribe()
 	defer txSub.Unsubscribe()
+	defer dropSub.Unsubscribe()
 	defer rmLogsSub.Unsubscribe()
 	defer logsSub.Unsubscribe()
 	defer chainEvSub.Unsubscribe()
+	defer finalizedSub.Unsubscribe()
 
 	for i := UnknownSubscription; i < LastIndexSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
@@ -450,12 +516,16 @@ func (es *EventSystem) eventLoop() {
 		// Handle subscribed events
 		case ev := <-txCh:
 			es.broadcast(index, ev)
+		case ev := <-dropCh:
+			es.broadcast(index, ev)
 		case ev := <-rmLogsCh:
 			es.broadcast(index, ev)
 		case ev := <-logsCh:
 			es.broadcast(index, ev)
 		case ev := <-chainEvCh:
 			es.broadcast(index, ev)
+		case ev := <-finalizedCh:
+			es.broadcast(index, ev)
 
 		case f := <-es.install:
 			if f.typ == MinedAndPendingLogsSubscription {
@@ -479,12 +549,16 @@ func (es *EventSystem) eventLoop() {
 		// System stopped
 		case <-txSub.Err():
 			return
+		case <-dropSub.Err():
+			return
 		case <-rmLogsSub.Err():
 			return
 		case <-logsSub.Err():
 			return
 		case <-chainEvSub.Err():
 			return
+		case <-finalizedSub.Err():
+			return
 		}
 	}
 }
@@ -45,6 +45,8 @@ type testBackend struct {
 	rmLogsFeed *event.Feed
 	logsFeed   *event.Feed
 	chainFeed  *event.Feed
+	finalFeed  *event.Feed
+	dropFeed   *event.Feed
 }
 
 func (b *testBackend) ChainDb() ethdb.Database {
@@ -88,6 +90,10 @@ func (b *testBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subsc
 	return b.txFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return b.dropFeed.Subscribe(ch)
+}
+
 func (b *testBackend) SubscribeRemovedLogsEvent(ch chan<- core.RemovedLogsEvent) event.Subscription {
 	return b.rmLogsFeed.Subscribe(ch)
 }
@@ -100,6 +106,10 @@ func (b *testBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subsc
 	return b.chainFeed.Subscribe(ch)
 }
 
+func (b *testBackend) SubscribeFinalizedHeadEvent(ch chan<- core.FinalizedHeadEvent) event.Subscription {
+	return b.finalFeed.Subscribe(ch)
+}
+
 func (b *testBackend) BloomStatus() (uint64, uint64) {
 	return params.BloomBitsBlocks, b.sections
 }
@@ -146,7 +156,7 @@ func TestBlockSubscription(t *testing.T) {
 		rmLogsFeed  = new(event.Feed)
 		logsFeed    = new(event.Feed)
 		chainFeed   = new(event.Feed)
-		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend     = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		api         = NewPublicFilterAPI(backend, false)
 		genesis     = new(core.Genesis).MustCommit(db)
 		chain, _    = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 10, func(i int, gen *core.BlockGen) {})
@@ -192,6 +202,79 @@ func TestBlockSubscription(t *testing.T) {
 	<-sub1.Err()
 }
 
+// TestFinalizedHeadSubscription tests that finalized head subscriptions receive
+// the headers of posted finalized head events.
+func TestFinalizedHeadSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux       = new(event.TypeMux)
+		db, _     = ethdb.NewMemDatabase()
+		finalFeed = new(event.Feed)
+		backend   = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), finalFeed, new(event.Feed)}
+		api       = NewPublicFilterAPI(backend, false)
+		genesis   = new(core.Genesis).MustCommit(db)
+		chain, _  = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 5, func(i int, gen *core.BlockGen) {})
+	)
+	headers := make(chan *types.Header)
+	sub := api.events.SubscribeFinalizedHeads(headers)
+	defer sub.Unsubscribe()
+
+	go func() {
+		for _, block := range chain {
+			finalFeed.Send(core.FinalizedHeadEvent{Block: block})
+		}
+	}()
+	for i, block := range chain {
+		select {
+		case header := <-headers:
+			if header.Hash() != block.Hash() {
+				t.Errorf("header %d: hash mismatch: have %x, want %x", i, header.Hash(), block.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("header %d: timeout waiting for finalized head", i)
+		}
+	}
+}
+
+// TestDroppedTxSubscription tests that dropped transaction subscriptions receive
+// the events posted by the transaction pool.
+func TestDroppedTxSubscription(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mux      = new(event.TypeMux)
+		db, _    = ethdb.NewMemDatabase()
+		dropFeed = new(event.Feed)
+		backend  = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), dropFeed}
+		api      = NewPublicFilterAPI(backend, false)
+
+		drops = []core.TxDropEvent{
+			{Tx: types.NewTransaction(0, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), 0, new(big.Int), nil), Reason: core.DropUnderpriced},
+			{Tx: types.NewTransaction(1, common.HexToAddress("0xb794f5ea0ba39494ce83a213fffba74279579268"), new(big.Int), 0, new(big.Int), nil), Reason: core.DropReplaced},
+		}
+	)
+	events := make(chan core.TxDropEvent)
+	sub := api.events.SubscribeDroppedTxEvents(events)
+	defer sub.Unsubscribe()
+
+	go func() {
+		for _, ev := range drops {
+			dropFeed.Send(ev)
+		}
+	}()
+	for i, want := range drops {
+		select {
+		case ev := <-events:
+			if ev.Tx.Hash() != want.Tx.Hash() || ev.Reason != want.Reason {
+				t.Errorf("drop %d: event mismatch: have %x/%s, want %x/%s", i, ev.Tx.Hash(), ev.Reason, want.Tx.Hash(), want.Reason)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("drop %d: timeout waiting for event", i)
+		}
+	}
+}
+
 // TestPendingTxFilter tests whether pending tx filters retrieve all pending transactions that are posted to the event mux.
 func TestPendingTxFilter(t *testing.T) {
 	t.Parallel()
@@ -203,7 +286,7 @@ func TestPendingTxFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		transactions = []*types.Transaction{
@@ -266,7 +349,7 @@ func TestLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		testCases = []struct {
@@ -315,7 +398,7 @@ func TestInvalidLogFilterCreation(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 	)
 
@@ -345,7 +428,7 @@ func TestLogFilter(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -464,7 +547,7 @@ func TestPendingLogsSubscription(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		api        = NewPublicFilterAPI(backend, false)
 
 		firstAddr      = common.HexToAddress("0x1111111111111111111111111111111111111111")
@@ -56,7 +56,7 @@ func BenchmarkFilters(b *testing.B) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr1      = crypto.PubkeyToAddress(key1.PublicKey)
 		addr2      = common.BytesToAddress([]byte("jeff"))
@@ -121,7 +121,7 @@ func TestFilters(t *testing.T) {
 		rmLogsFeed = new(event.Feed)
 		logsFeed   = new(event.Feed)
 		chainFeed  = new(event.Feed)
-		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed}
+		backend    = &testBackend{mux, db, 0, txFeed, rmLogsFeed, logsFeed, chainFeed, new(event.Feed), new(event.Feed)}
 		key1, _    = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
 		addr       = crypto.PubkeyToAddress(key1.PublicKey)
 
@@ -241,3 +241,171 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+// newLoggingChain creates a chain of 100 blocks, blocks 10-19 and 50-52 of which
+// carry two logs of the returned address each.
+func newLoggingChain() (*testBackend, ethdb.Database, []*types.Block, common.Address) {
+	var (
+		db, _   = ethdb.NewMemDatabase()
+		backend = &testBackend{new(event.TypeMux), db, 0, new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed), new(event.Feed)}
+		addr    = common.BytesToAddress([]byte("logger"))
+	)
+	genesis := core.GenesisBlockForTesting(db, addr, big.NewInt(1000000))
+	chain, receipts := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 100, func(i int, gen *core.BlockGen) {
+		if number := i + 1; (number >= 10 && number < 20) || (number >= 50 && number <= 52) {
+			receipt := types.NewReceipt(nil, false, 0)
+			receipt.Logs = []*types.Log{{Address: addr, BlockNumber: uint64(number)}, {Address: addr, BlockNumber: uint64(number)}}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			gen.AddUncheckedReceipt(receipt)
+		}
+	})
+	for i, block := range chain {
+		core.WriteBlock(db, block)
+		core.WriteCanonicalHash(db, block.Hash(), block.NumberU64())
+		core.WriteHeadBlockHash(db, block.Hash())
+		core.WriteBlockReceipts(db, block.Hash(), block.NumberU64(), receipts[i])
+	}
+	return backend, db, chain, addr
+}
+
+// Tests that log queries are searched in chunks, cut at the result limit on block
+// boundaries, and fail with the unavailable range if receipts are missing.
+func TestFilterLimits(t *testing.T) {
+	backend, db, chain, addr := newLoggingChain()
+
+	// Chunks cover the whole range in order
+	var next uint64
+	filter := New(backend, 0, 99, []common.Address{addr}, nil)
+	filter.SetLimits(8, 0)
+	err := filter.Stream(context.Background(), func(from, to uint64, logs []*types.Log) error {
+		if from != next || to-from >= 8 {
+			t.Errorf("chunk mismatch: have %d-%d, want %d-", from, to, next)
+		}
+		next = to + 1
+		return nil
+	})
+	if err != nil || next != 100 {
+		t.Fatalf("stream failed: err %v, searched up to %d", err, next)
+	}
+	// Queries exceeding the limit return the fitting blocks
+	filter = New(backend, 0, 40, []common.Address{addr}, nil)
+	filter.SetLimits(8, 5)
+
+	logs, err := filter.Logs(context.Background())
+	limitErr, ok := err.(*LimitExceededError)
+	if !ok {
+		t.Fatalf("error mismatch: have %v, want limit exceeded", err)
+	}
+	if limitErr.From != 0 || limitErr.To != 11 || len(logs) != 4 || len(limitErr.Logs) != 4 {
+		t.Errorf("limit cut mismatch: have %d-%d with %d logs, want 0-11 with 4 logs", limitErr.From, limitErr.To, len(logs))
+	}
+	// Single blocks exceeding the limit are returned whole
+	filter = New(backend, 10, 40, []common.Address{addr}, nil)
+	filter.SetLimits(8, 1)
+
+	if logs, err = filter.Logs(context.Background()); err == nil || len(logs) != 2 || err.(*LimitExceededError).To != 10 {
+		t.Errorf("single block cut mismatch: have %d logs, error %v", len(logs), err)
+	}
+	// Queries covering missing receipts report the unavailable range
+	for i := 50; i <= 51; i++ {
+		core.DeleteBlockReceipts(db, chain[i-1].Hash(), uint64(i))
+	}
+	filter = New(backend, 15, 99, []common.Address{addr}, nil)
+
+	logs, err = filter.Logs(context.Background())
+	pruned, ok := err.(*PrunedRangeError)
+	if !ok {
+		t.Fatalf("error mismatch: have %v, want pruned range", err)
+	}
+	if pruned.From != 50 || pruned.To != 51 || len(logs) != 10 {
+		t.Errorf("pruned range mismatch: have %d-%d with %d logs, want 50-51 with 10 logs", pruned.From, pruned.To, len(logs))
+	}
+}
+
+// Tests that log queries are paged through without being limited, and that the
+// pages preceding missing receipts are served.
+func TestFilterIterator(t *testing.T) {
+	backend, db, chain, addr := newLoggingChain()
+
+	filter := New(backend, 0, 99, []common.Address{addr}, nil)
+	filter.SetLimits(8, 5)
+
+	it, err := filter.Iterator(context.Background())
+	if err != nil {
+		t.Fatalf("failed to create iterator: %v", err)
+	}
+	var logs []*types.Log
+	for done := false; !done; {
+		var items []interface{}
+		if items, done, err = it.Next(context.Background(), 7); err != nil {
+			t.Fatalf("failed to retrieve page: %v", err)
+		}
+		if !done && len(items) != 7 {
+			t.Fatalf("page size mismatch: have %d, want 7", len(items))
+		}
+		for _, item := range items {
+			logs = append(logs, item.(*types.Log))
+		}
+	}
+	if len(logs) != 26 {
+		t.Fatalf("log count mismatch: have %d, want 26", len(logs))
+	}
+	for i := 1; i < len(logs); i++ {
+		if logs[i].BlockNumber < logs[i-1].BlockNumber {
+			t.Fatalf("logs out of order: #%d at block %d after block %d", i, logs[i].BlockNumber, logs[i-1].BlockNumber)
+		}
+	}
+	// Missing receipts fail the page covering them
+	core.DeleteBlockReceipts(db, chain[49].Hash(), 50)
+
+	filter = New(backend, 15, 99, []common.Address{addr}, nil)
+	filter.SetLimits(8, 0)
+
+	it, _ = filter.Iterator(context.Background())
+	if items, done, err := it.Next(context.Background(), 5); err != nil || done || len(items) != 5 {
+		t.Fatalf("first page mismatch: have %d items, done %v, err %v", len(items), done, err)
+	}
+	_, _, err = it.Next(context.Background(), 6)
+	if pruned, ok := err.(*PrunedRangeError); !ok || pruned.From != 50 || pruned.To != 50 || len(pruned.Logs) != 5 {
+		t.Fatalf("error mismatch: have %v, want pruned block 50 with 5 logs", err)
+	}
+}
+
+// Tests that ranges reaching past the head are searched up to the head only,
+// rather than failing on the blocks not yet available.
+func TestFilterBeyondHead(t *testing.T) {
+	backend, _, _, addr := newLoggingChain()
+
+	filter := New(backend, 40, 1000, []common.Address{addr}, nil)
+	filter.SetLimits(8, 0)
+
+	logs, err := filter.Logs(context.Background())
+	if err != nil || len(logs) != 6 {
+		t.Fatalf("log mismatch: have %d logs, err %v, want 6 logs", len(logs), err)
+	}
+	filter = New(backend, 40, 1000, []common.Address{addr}, nil)
+	filter.SetLimits(8, 0)
+
+	var last uint64
+	if err := filter.Stream(context.Background(), func(from, to uint64, logs []*types.Log) error {
+		last = to
+		return nil
+	}); err != nil || last != 100 {
+		t.Fatalf("stream mismatch: searched up to %d, err %v, want 100", last, err)
+	}
+	filter = New(backend, 40, 1000, []common.Address{addr}, nil)
+	filter.SetLimits(8, 0)
+
+	it, err := filter.Iterator(context.Background())
+	if err != nil {
+		t.Fatalf("failed to create iterator: %v", err)
+	}
+	if items, done, err := it.Next(context.Background(), 10); err != nil || !done || len(items) != 6 {
+		t.Fatalf("page mismatch: have %d items, done %v, err %v, want 6 items", len(items), done, err)
+	}
+	// Ranges starting past the head are empty
+	filter = New(backend, 200, 300, []common.Address{addr}, nil)
+	if logs, err := filter.Logs(context.Background()); err != nil || len(logs) != 0 {
+		t.Fatalf("future range mismatch: have %d logs, err %v, want none", len(logs), err)
+	}
+}
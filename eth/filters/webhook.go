@@ -0,0 +1,383 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	ethereum "github.com/usechain/go-usechain"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// WebhookSignatureHeader carries the hex encoded HMAC-SHA256 of the payload,
+	// keyed with the secret given at registration.
+	WebhookSignatureHeader = "X-Usechain-Signature"
+
+	webhookQueue   = 256              // Payloads buffered per webhook, newer ones are dropped when full
+	webhookRetries = 8                // Delivery attempts of a payload before it is given up
+	webhookTimeout = 10 * time.Second // Timeout of a single delivery attempt
+)
+
+var (
+	webhookBackoff    = time.Second     // Delay before the first retry, doubled with every attempt
+	webhookMaxBackoff = 5 * time.Minute // Upper bound of the delay between retries
+)
+
+var (
+	errWebhookURL  = errors.New("webhook URL must use https (plain http is only allowed for loopback hosts)")
+	errWebhookKind = errors.New(`webhook kind must be "logs" or "blocks"`)
+)
+
+// WebhookArgs configures a webhook to register.
+type WebhookArgs struct {
+	URL      string          `json:"url"`
+	Secret   string          `json:"secret"`   // Key signing the payloads
+	Kind     string          `json:"kind"`     // "logs" or "blocks"
+	Criteria *FilterCriteria `json:"criteria"` // Log filter, only used by "logs" webhooks
+}
+
+// WebhookInfo describes a registered webhook along with its delivery statistics.
+type WebhookInfo struct {
+	ID        rpc.ID `json:"id"`
+	URL       string `json:"url"`
+	Kind      string `json:"kind"`
+	Delivered uint64 `json:"delivered"`
+	Failed    uint64 `json:"failed"`  // Payloads given up after exhausting the retries
+	Dropped   uint64 `json:"dropped"` // Payloads dropped because the queue was full
+	Pending   int    `json:"pending"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// WebhookPayload is the body posted to a webhook for every filter match.
+type WebhookPayload struct {
+	Webhook  rpc.ID      `json:"webhook"`
+	Sequence uint64      `json:"sequence"` // Increasing per webhook, to detect gaps and duplicates
+	Kind     string      `json:"kind"`
+	Data     interface{} `json:"data"` // Matched logs or the new block header
+}
+
+// webhook is a registered webhook and its delivery state.
+type webhook struct {
+	id     rpc.ID
+	url    string
+	secret []byte
+	kind   string
+
+	sub   *Subscription
+	queue chan *WebhookPayload
+	quit  chan struct{}
+
+	lock      sync.Mutex // Protects the statistics below
+	sequence  uint64
+	delivered uint64
+	failed    uint64
+	dropped   uint64
+	lastErr   error
+}
+
+// Webhooks delivers filter matches to HTTPS callbacks, letting backend systems
+// without a persistent websocket connection follow the chain. Registrations are
+// kept in memory and don't survive a restart.
+type Webhooks struct {
+	events *EventSystem
+	client *http.Client
+
+	lock  sync.Mutex
+	hooks map[rpc.ID]*webhook
+}
+
+// NewWebhooks creates a webhook dispatcher on top of the backend's events.
+func NewWebhooks(backend Backend, lightMode bool) *Webhooks {
+	return &Webhooks{
+		events: NewEventSystem(backend.EventMux(), backend, lightMode),
+		client: &http.Client{Timeout: webhookTimeout},
+		hooks:  make(map[rpc.ID]*webhook),
+	}
+}
+
+// Register installs a webhook, returning its identifier.
+func (w *Webhooks) Register(args WebhookArgs) (rpc.ID, error) {
+	if err := validateWebhookURL(args.URL); err != nil {
+		return "", err
+	}
+	hook := &webhook{
+		id:     rpc.NewID(),
+		url:    args.URL,
+		secret: []byte(args.Secret),
+		kind:   args.Kind,
+		queue:  make(chan *WebhookPayload, webhookQueue),
+		quit:   make(chan struct{}),
+	}
+	switch args.Kind {
+	case "logs":
+		var crit FilterCriteria
+		if args.Criteria != nil {
+			crit = *args.Criteria
+		}
+		matches := make(chan []*types.Log)
+		sub, err := w.events.SubscribeLogs(ethereum.FilterQuery(crit), matches)
+		if err != nil {
+			return "", err
+		}
+		hook.sub = sub
+		go hook.loop(func() (interface{}, bool) {
+			select {
+			case logs := <-matches:
+				return logs, true
+			case <-sub.Err():
+				return nil, false
+			}
+		})
+
+	case "blocks":
+		headers := make(chan *types.Header)
+		sub := w.events.SubscribeNewHeads(headers)
+		hook.sub = sub
+		go hook.loop(func() (interface{}, bool) {
+			select {
+			case header := <-headers:
+				return header, true
+			case <-sub.Err():
+				return nil, false
+			}
+		})
+
+	default:
+		return "", errWebhookKind
+	}
+	go hook.deliver(w.client)
+
+	w.lock.Lock()
+	w.hooks[hook.id] = hook
+	w.lock.Unlock()
+
+	log.Info("Registered filter webhook", "id", hook.id, "kind", hook.kind, "url", hook.url)
+	return hook.id, nil
+}
+
+// Unregister removes a webhook, dropping its undelivered payloads.
+func (w *Webhooks) Unregister(id rpc.ID) bool {
+	w.lock.Lock()
+	hook, ok := w.hooks[id]
+	delete(w.hooks, id)
+	w.lock.Unlock()
+
+	if ok {
+		hook.stop()
+	}
+	return ok
+}
+
+// List returns the registered webhooks.
+func (w *Webhooks) List() []*WebhookInfo {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	infos := make([]*WebhookInfo, 0, len(w.hooks))
+	for _, hook := range w.hooks {
+		infos = append(infos, hook.info())
+	}
+	return infos
+}
+
+// Stop removes all webhooks.
+func (w *Webhooks) Stop() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for id, hook := range w.hooks {
+		hook.stop()
+		delete(w.hooks, id)
+	}
+}
+
+// validateWebhookURL ensures payloads are only posted over TLS, except to the
+// local machine.
+func validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %v", err)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+	}
+	return errWebhookURL
+}
+
+// loop queues the matches returned by next until the subscription ends.
+func (hook *webhook) loop(next func() (interface{}, bool)) {
+	for {
+		data, ok := next()
+		if !ok {
+			return
+		}
+		hook.lock.Lock()
+		hook.sequence++
+		payload := &WebhookPayload{Webhook: hook.id, Sequence: hook.sequence, Kind: hook.kind, Data: data}
+		hook.lock.Unlock()
+
+		select {
+		case hook.queue <- payload:
+		default:
+			hook.lock.Lock()
+			hook.dropped++
+			hook.lock.Unlock()
+			log.Warn("Webhook queue full, dropping payload", "id", hook.id, "sequence", payload.Sequence)
+		}
+	}
+}
+
+// deliver posts the queued payloads in order, retrying failed deliveries with
+// exponential backoff.
+func (hook *webhook) deliver(client *http.Client) {
+	for {
+		select {
+		case payload := <-hook.queue:
+			body, err := json.Marshal(payload)
+			if err != nil {
+				log.Error("Failed to encode webhook payload", "id", hook.id, "err", err)
+				continue
+			}
+			backoff := webhookBackoff
+			for attempt := 1; ; attempt++ {
+				err = hook.post(client, body)
+				if err == nil || attempt == webhookRetries {
+					break
+				}
+				log.Debug("Webhook delivery failed, retrying", "id", hook.id, "sequence", payload.Sequence, "attempt", attempt, "err", err)
+				select {
+				case <-time.After(backoff):
+				case <-hook.quit:
+					return
+				}
+				if backoff *= 2; backoff > webhookMaxBackoff {
+					backoff = webhookMaxBackoff
+				}
+			}
+			hook.lock.Lock()
+			if err == nil {
+				hook.delivered++
+			} else {
+				hook.failed++
+				hook.lastErr = err
+			}
+			hook.lock.Unlock()
+
+			if err != nil {
+				log.Warn("Webhook delivery given up", "id", hook.id, "sequence", payload.Sequence, "err", err)
+			}
+		case <-hook.quit:
+			return
+		}
+	}
+}
+
+// post makes a single signed delivery attempt.
+func (hook *webhook) post(client *http.Client, body []byte) error {
+	mac := hmac.New(sha256.New, hook.secret)
+	mac.Write(body)
+
+	req, err := http.NewRequest("POST", hook.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// info assembles the current statistics of the webhook.
+func (hook *webhook) info() *WebhookInfo {
+	hook.lock.Lock()
+	defer hook.lock.Unlock()
+
+	info := &WebhookInfo{
+		ID:        hook.id,
+		URL:       hook.url,
+		Kind:      hook.kind,
+		Delivered: hook.delivered,
+		Failed:    hook.failed,
+		Dropped:   hook.dropped,
+		Pending:   len(hook.queue),
+	}
+	if hook.lastErr != nil {
+		info.LastError = hook.lastErr.Error()
+	}
+	return info
+}
+
+// stop ends the subscription and the delivery of the webhook.
+func (hook *webhook) stop() {
+	hook.sub.Unsubscribe()
+	close(hook.quit)
+}
+
+// PrivateWebhookAPI offers the management of filter webhooks. As the node posts
+// to the given URLs, it is only exposed over secure RPC channels.
+type PrivateWebhookAPI struct {
+	hooks *Webhooks
+}
+
+// NewPrivateWebhookAPI creates a new webhook management API.
+func NewPrivateWebhookAPI(hooks *Webhooks) *PrivateWebhookAPI {
+	return &PrivateWebhookAPI{hooks: hooks}
+}
+
+// Register installs a webhook receiving the matching logs or new block headers.
+func (api *PrivateWebhookAPI) Register(args WebhookArgs) (rpc.ID, error) {
+	return api.hooks.Register(args)
+}
+
+// Unregister removes a webhook.
+func (api *PrivateWebhookAPI) Unregister(id rpc.ID) bool {
+	return api.hooks.Unregister(id)
+}
+
+// List returns the registered webhooks along with their delivery statistics.
+func (api *PrivateWebhookAPI) List() []*WebhookInfo {
+	return api.hooks.List()
+}
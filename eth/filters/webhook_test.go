@@ -0,0 +1,111 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package filters
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/event"
+	"github.com/usechain/go-usechain/params"
+)
+
+// Tests that new blocks are posted to webhooks with a valid signature, and that
+// failed deliveries are retried.
+func TestWebhookDelivery(t *testing.T) {
+	defer func(backoff time.Duration) { webhookBackoff = backoff }(webhookBackoff)
+	webhookBackoff = 10 * time.Millisecond
+
+	var (
+		mux       = new(event.TypeMux)
+		db, _     = ethdb.NewMemDatabase()
+		chainFeed = new(event.Feed)
+		backend   = &testBackend{mux, db, 0, new(event.Feed), new(event.Feed), new(event.Feed), chainFeed, new(event.Feed), new(event.Feed)}
+		hooks     = NewWebhooks(backend, false)
+		genesis   = new(core.Genesis).MustCommit(db)
+		chain, _  = core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), db, 3, func(i int, gen *core.BlockGen) {})
+	)
+	defer hooks.Stop()
+
+	// Fail every first delivery attempt, accept and report the retries
+	var (
+		attempts = 0
+		received = make(chan *WebhookPayload, len(chain))
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts++; attempts%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if sig := r.Header.Get(WebhookSignatureHeader); sig != hex.EncodeToString(mac.Sum(nil)) {
+			t.Errorf("signature mismatch: have %s, want %x", sig, mac.Sum(nil))
+		}
+		payload := &WebhookPayload{Data: new(struct{ Hash common.Hash })}
+		if err := json.Unmarshal(body, payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		received <- payload
+	}))
+	defer server.Close()
+
+	if _, err := hooks.Register(WebhookArgs{URL: "http://example.com", Kind: "blocks"}); err != errWebhookURL {
+		t.Fatalf("plain http to remote host accepted: %v", err)
+	}
+	id, err := hooks.Register(WebhookArgs{URL: server.URL, Secret: "secret", Kind: "blocks"})
+	if err != nil {
+		t.Fatalf("failed to register webhook: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond) // wait until the subscription is installed
+
+	for _, block := range chain {
+		chainFeed.Send(core.ChainEvent{Hash: block.Hash(), Block: block})
+	}
+	for i, block := range chain {
+		select {
+		case payload := <-received:
+			if payload.Webhook != id || payload.Sequence != uint64(i+1) || payload.Kind != "blocks" {
+				t.Errorf("payload %d: metadata mismatch: %v", i, payload)
+			}
+			if hash := payload.Data.(*struct{ Hash common.Hash }).Hash; hash != block.Hash() {
+				t.Errorf("payload %d: hash mismatch: have %x, want %x", i, hash, block.Hash())
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("payload %d not delivered", i)
+		}
+	}
+	infos := hooks.List()
+	if len(infos) != 1 || infos[0].Failed != 0 || infos[0].Dropped != 0 {
+		t.Errorf("webhook statistics mismatch: %+v", infos)
+	}
+	if !hooks.Unregister(id) || len(hooks.List()) != 0 {
+		t.Errorf("failed to unregister webhook")
+	}
+}
@@ -18,6 +18,8 @@ package gasprice
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"math/big"
 	"sort"
 	"sync"
@@ -29,12 +31,21 @@ import (
 	"github.com/usechain/go-usechain/rpc"
 )
 
-var maxPrice = big.NewInt(500 * params.Shannon)
+var (
+	// DefaultMaxPrice is the upper bound of suggested gas prices if none is configured.
+	DefaultMaxPrice = big.NewInt(500 * params.Shannon)
+
+	errMissingBlock = errors.New("missing block")
+)
+
+// maxFeeHistory is the maximum number of blocks a single fee history query may span.
+const maxFeeHistory = 1024
 
 type Config struct {
 	Blocks     int
 	Percentile int
 	Default    *big.Int `toml:",omitempty"`
+	MaxPrice   *big.Int `toml:",omitempty"`
 }
 
 // Oracle recommends gas prices based on the content of recent
@@ -48,6 +59,7 @@ type Oracle struct {
 
 	checkBlocks, maxEmpty, maxBlocks int
 	percentile                       int
+	maxPrice                         *big.Int
 }
 
 // NewOracle returns a new oracle.
@@ -63,6 +75,10 @@ func NewOracle(backend ethapi.Backend, params Config) *Oracle {
 	if percent > 100 {
 		percent = 100
 	}
+	maxPrice := params.MaxPrice
+	if maxPrice == nil || maxPrice.Sign() <= 0 {
+		maxPrice = DefaultMaxPrice
+	}
 	return &Oracle{
 		backend:     backend,
 		lastPrice:   params.Default,
@@ -70,6 +86,7 @@ func NewOracle(backend ethapi.Backend, params Config) *Oracle {
 		maxEmpty:    blocks / 2,
 		maxBlocks:   blocks * 5,
 		percentile:  percent,
+		maxPrice:    maxPrice,
 	}
 }
 
@@ -136,8 +153,8 @@ func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
 		sort.Sort(bigIntArray(blockPrices))
 		price = blockPrices[(len(blockPrices)-1)*gpo.percentile/100]
 	}
-	if price.Cmp(maxPrice) > 0 {
-		price = new(big.Int).Set(maxPrice)
+	if price.Cmp(gpo.maxPrice) > 0 {
+		price = new(big.Int).Set(gpo.maxPrice)
 	}
 
 	gpo.cacheLock.Lock()
@@ -147,6 +164,106 @@ func (gpo *Oracle) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return price, nil
 }
 
+// FeeHistory returns the fee statistics of up to blocks consecutive blocks
+// ending at lastBlock: the number of the oldest block returned, the effective
+// gas prices paid at each of the requested percentiles (weighted by the gas used
+// by the individual transactions) and the ratio of gas used to the gas limit.
+func (gpo *Oracle) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	if blocks < 1 {
+		return common.Big0, nil, nil, nil
+	}
+	if blocks > maxFeeHistory {
+		blocks = maxFeeHistory
+	}
+	for i, p := range percentiles {
+		if p < 0 || p > 100 {
+			return nil, nil, nil, fmt.Errorf("invalid reward percentile: %f", p)
+		}
+		if i > 0 && p < percentiles[i-1] {
+			return nil, nil, nil, fmt.Errorf("invalid reward percentile: #%d:%f > #%d:%f", i-1, percentiles[i-1], i, p)
+		}
+	}
+	head, err := gpo.backend.HeaderByNumber(ctx, lastBlock)
+	if head == nil {
+		if err == nil {
+			err = errMissingBlock
+		}
+		return nil, nil, nil, err
+	}
+	last := head.Number.Uint64()
+	if uint64(blocks) > last+1 {
+		blocks = int(last + 1)
+	}
+	oldest := last + 1 - uint64(blocks)
+
+	var (
+		rewards [][]*big.Int
+		ratios  = make([]float64, blocks)
+	)
+	if len(percentiles) > 0 {
+		rewards = make([][]*big.Int, blocks)
+	}
+	for i := 0; i < blocks; i++ {
+		block, err := gpo.backend.BlockByNumber(ctx, rpc.BlockNumber(oldest+uint64(i)))
+		if block == nil {
+			if err == nil {
+				err = errMissingBlock
+			}
+			return nil, nil, nil, err
+		}
+		if block.GasLimit() > 0 {
+			ratios[i] = float64(block.GasUsed()) / float64(block.GasLimit())
+		}
+		if len(percentiles) == 0 {
+			continue
+		}
+		receipts, err := gpo.backend.GetReceipts(ctx, block.Hash())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		rewards[i] = blockPercentiles(block, receipts, percentiles)
+	}
+	return new(big.Int).SetUint64(oldest), rewards, ratios, nil
+}
+
+// txGasAndPrice is the gas used and gas price paid by a single transaction.
+type txGasAndPrice struct {
+	gasUsed uint64
+	price   *big.Int
+}
+
+// blockPercentiles calculates the gas prices paid at the given percentiles of
+// the gas used within a block. Empty blocks report zero prices.
+func blockPercentiles(block *types.Block, receipts types.Receipts, percentiles []float64) []*big.Int {
+	result := make([]*big.Int, len(percentiles))
+	txs := block.Transactions()
+	if len(txs) == 0 || len(receipts) != len(txs) {
+		for i := range result {
+			result[i] = new(big.Int)
+		}
+		return result
+	}
+	sorted := make([]txGasAndPrice, len(txs))
+	for i, tx := range txs {
+		sorted[i] = txGasAndPrice{gasUsed: receipts[i].GasUsed, price: tx.GasPrice()}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].price.Cmp(sorted[j].price) < 0 })
+
+	var (
+		txIndex int
+		sumUsed = sorted[0].gasUsed
+	)
+	for i, p := range percentiles {
+		threshold := uint64(float64(block.GasUsed()) * p / 100)
+		for sumUsed < threshold && txIndex < len(sorted)-1 {
+			txIndex++
+			sumUsed += sorted[txIndex].gasUsed
+		}
+		result[i] = new(big.Int).Set(sorted[txIndex].price)
+	}
+	return result
+}
+
 type getBlockPricesResult struct {
 	price *big.Int
 	err   error
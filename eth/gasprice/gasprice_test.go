@@ -0,0 +1,178 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package gasprice
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/internal/ethapi"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// testBackend serves a fixed chain of blocks and receipts to the oracle. Only
+// the methods used by the fee history are implemented.
+type testBackend struct {
+	ethapi.Backend
+
+	blocks   []*types.Block
+	receipts map[common.Hash]types.Receipts
+}
+
+// testTx is a transaction to be included in a test block.
+type testTx struct {
+	price   int64
+	gasUsed uint64
+}
+
+func newTestBackend(chain [][]testTx) *testBackend {
+	backend := &testBackend{receipts: make(map[common.Hash]types.Receipts)}
+	for number, txs := range chain {
+		var (
+			transactions []*types.Transaction
+			receipts     []*types.Receipt
+			gasUsed      uint64
+		)
+		for i, tx := range txs {
+			transactions = append(transactions, types.NewTransaction(uint64(i), common.Address{}, new(big.Int), tx.gasUsed, big.NewInt(tx.price), nil))
+
+			gasUsed += tx.gasUsed
+			receipt := types.NewReceipt(nil, false, gasUsed)
+			receipt.GasUsed = tx.gasUsed
+			receipts = append(receipts, receipt)
+		}
+		header := &types.Header{
+			Number:   big.NewInt(int64(number)),
+			GasLimit: 100000,
+			GasUsed:  gasUsed,
+		}
+		block := types.NewBlock(header, transactions, nil, receipts)
+		backend.blocks = append(backend.blocks, block)
+		backend.receipts[block.Hash()] = receipts
+	}
+	return backend
+}
+
+func (b *testBackend) block(number rpc.BlockNumber) *types.Block {
+	if number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber {
+		return b.blocks[len(b.blocks)-1]
+	}
+	if number < 0 || int(number) >= len(b.blocks) {
+		return nil
+	}
+	return b.blocks[number]
+}
+
+func (b *testBackend) HeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Header, error) {
+	if block := b.block(number); block != nil {
+		return block.Header(), nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) BlockByNumber(ctx context.Context, number rpc.BlockNumber) (*types.Block, error) {
+	if block := b.block(number); block != nil {
+		return block, nil
+	}
+	return nil, nil
+}
+
+func (b *testBackend) GetReceipts(ctx context.Context, hash common.Hash) (types.Receipts, error) {
+	return b.receipts[hash], nil
+}
+
+func bigs(values ...int64) []*big.Int {
+	result := make([]*big.Int, len(values))
+	for i, value := range values {
+		result[i] = big.NewInt(value)
+	}
+	return result
+}
+
+// Tests that the fee history reports the correct range, gas usage ratios and
+// gas weighted percentiles.
+func TestFeeHistory(t *testing.T) {
+	backend := newTestBackend([][]testTx{
+		nil,
+		{{1, 21000}, {3, 21000}, {2, 21000}},
+		nil,
+		{{20, 10000}, {10, 50000}},
+	})
+	tests := []struct {
+		blocks      int
+		last        rpc.BlockNumber
+		percentiles []float64
+
+		oldest  int64
+		rewards [][]*big.Int
+		ratios  []float64
+		fail    bool
+	}{
+		// Empty requests return nothing
+		{blocks: 0, last: rpc.LatestBlockNumber, oldest: 0},
+		// Ratios only, without percentiles
+		{blocks: 2, last: 1, oldest: 0, ratios: []float64{0, 0.63}},
+		// Percentiles weighted by gas used
+		{blocks: 1, last: 1, percentiles: []float64{0, 50, 100}, oldest: 1, rewards: [][]*big.Int{bigs(1, 2, 3)}, ratios: []float64{0.63}},
+		{blocks: 1, last: 3, percentiles: []float64{50, 90}, oldest: 3, rewards: [][]*big.Int{bigs(10, 20)}, ratios: []float64{0.6}},
+		// Empty blocks report zero prices
+		{blocks: 2, last: rpc.LatestBlockNumber, percentiles: []float64{50}, oldest: 2, rewards: [][]*big.Int{bigs(0), bigs(10)}, ratios: []float64{0, 0.6}},
+		// Ranges beyond genesis are truncated
+		{blocks: 10, last: 1, oldest: 0, ratios: []float64{0, 0.63}},
+		// Oversized ranges are capped and truncated at genesis
+		{blocks: maxFeeHistory + 1, last: rpc.LatestBlockNumber, oldest: 0, ratios: []float64{0, 0.63, 0, 0.6}},
+		// Unknown blocks are rejected
+		{blocks: 1, last: 10, fail: true},
+		// Invalid percentiles are rejected
+		{blocks: 1, last: 1, percentiles: []float64{-1}, fail: true},
+		{blocks: 1, last: 1, percentiles: []float64{101}, fail: true},
+		{blocks: 1, last: 1, percentiles: []float64{50, 10}, fail: true},
+	}
+	oracle := NewOracle(backend, Config{Blocks: 20, Percentile: 60})
+	for i, tt := range tests {
+		oldest, rewards, ratios, err := oracle.FeeHistory(context.Background(), tt.blocks, tt.last, tt.percentiles)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected error, got none", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to retrieve fee history: %v", i, err)
+			continue
+		}
+		if oldest.Int64() != tt.oldest {
+			t.Errorf("test %d: oldest block mismatch: have %v, want %d", i, oldest, tt.oldest)
+		}
+		if !reflect.DeepEqual(rewards, tt.rewards) {
+			t.Errorf("test %d: rewards mismatch: have %v, want %v", i, rewards, tt.rewards)
+		}
+		if len(ratios) != len(tt.ratios) {
+			t.Errorf("test %d: ratio count mismatch: have %d, want %d", i, len(ratios), len(tt.ratios))
+			continue
+		}
+		for j := range ratios {
+			if ratios[j] != tt.ratios[j] {
+				t.Errorf("test %d: ratio %d mismatch: have %f, want %f", i, j, ratios[j], tt.ratios[j])
+			}
+		}
+	}
+}
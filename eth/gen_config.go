@@ -4,6 +4,7 @@ package eth
 
 import (
 	"math/big"
+	"time"
 
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/common/hexutil"
@@ -11,6 +12,9 @@ import (
 	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/eth/gasprice"
+	"github.com/usechain/go-usechain/eth/replica"
+	"github.com/usechain/go-usechain/miner"
+	"github.com/usechain/go-usechain/p2p/discover"
 )
 
 var _ = (*configMarshaling)(nil)
@@ -25,12 +29,29 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		SkipBcVersionCheck      bool `toml:"-"`
 		DatabaseHandles         int  `toml:"-"`
 		DatabaseCache           int
+		SafeDepth               uint64
+		FinalityDepth           uint64
+		ActivityBloom           bool `toml:",omitempty"`
+		BalanceIndex            bool `toml:",omitempty"`
+		HistoryRetain           uint64 `toml:",omitempty"`
+		PrivateState            bool `toml:",omitempty"`
+		PrivateNodes            []discover.NodeID `toml:",omitempty"`
+		Invariants              core.InvariantConfig `toml:",omitempty"`
+		LogChunkSize            uint64
+		LogQueryLimit           int
+		ReplicaLeader           string             `toml:",omitempty"`
+		ReplicaSecret           string             `toml:",omitempty"`
+		ReplicaVerify           replica.VerifyMode `toml:",omitempty"`
+		CommitteeHeartbeat      time.Duration      `toml:",omitempty"`
+		CommitteeMembers        []common.Address   `toml:",omitempty"`
 		Usebase               common.Address `toml:",omitempty"`
 		MinerThreads            int            `toml:",omitempty"`
 		ExtraData               hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		Miner                   miner.Config
 		Ethash                  ethash.Config
 		TxPool                  core.TxPoolConfig
+		TxRebroadcast           uint64 `toml:",omitempty"`
 		GPO                     gasprice.Config
 		EnablePreimageRecording bool
 		DocRoot                 string `toml:"-"`
@@ -44,12 +65,29 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.SkipBcVersionCheck = c.SkipBcVersionCheck
 	enc.DatabaseHandles = c.DatabaseHandles
 	enc.DatabaseCache = c.DatabaseCache
+	enc.SafeDepth = c.SafeDepth
+	enc.FinalityDepth = c.FinalityDepth
+	enc.ActivityBloom = c.ActivityBloom
+	enc.BalanceIndex = c.BalanceIndex
+	enc.HistoryRetain = c.HistoryRetain
+	enc.PrivateState = c.PrivateState
+	enc.PrivateNodes = c.PrivateNodes
+	enc.Invariants = c.Invariants
+	enc.LogChunkSize = c.LogChunkSize
+	enc.LogQueryLimit = c.LogQueryLimit
+	enc.ReplicaLeader = c.ReplicaLeader
+	enc.ReplicaSecret = c.ReplicaSecret
+	enc.ReplicaVerify = c.ReplicaVerify
+	enc.CommitteeHeartbeat = c.CommitteeHeartbeat
+	enc.CommitteeMembers = c.CommitteeMembers
 	enc.Usebase = c.Usebase
 	enc.MinerThreads = c.MinerThreads
 	enc.ExtraData = c.ExtraData
 	enc.GasPrice = c.GasPrice
+	enc.Miner = c.Miner
 	enc.Ethash = c.Ethash
 	enc.TxPool = c.TxPool
+	enc.TxRebroadcast = c.TxRebroadcast
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.DocRoot = c.DocRoot
@@ -66,12 +104,29 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		SkipBcVersionCheck      *bool `toml:"-"`
 		DatabaseHandles         *int  `toml:"-"`
 		DatabaseCache           *int
+		SafeDepth               *uint64
+		FinalityDepth           *uint64
+		ActivityBloom           *bool `toml:",omitempty"`
+		BalanceIndex            *bool `toml:",omitempty"`
+		HistoryRetain           *uint64 `toml:",omitempty"`
+		PrivateState            *bool `toml:",omitempty"`
+		PrivateNodes            []discover.NodeID `toml:",omitempty"`
+		Invariants              *core.InvariantConfig `toml:",omitempty"`
+		LogChunkSize            *uint64
+		LogQueryLimit           *int
+		ReplicaLeader           *string             `toml:",omitempty"`
+		ReplicaSecret           *string             `toml:",omitempty"`
+		ReplicaVerify           *replica.VerifyMode `toml:",omitempty"`
+		CommitteeHeartbeat      *time.Duration      `toml:",omitempty"`
+		CommitteeMembers        []common.Address    `toml:",omitempty"`
 		Usebase               *common.Address `toml:",omitempty"`
 		MinerThreads            *int            `toml:",omitempty"`
 		ExtraData               *hexutil.Bytes  `toml:",omitempty"`
 		GasPrice                *big.Int
+		Miner                   *miner.Config
 		Ethash                  *ethash.Config
 		TxPool                  *core.TxPoolConfig
+		TxRebroadcast           *uint64 `toml:",omitempty"`
 		GPO                     *gasprice.Config
 		EnablePreimageRecording *bool
 		DocRoot                 *string `toml:"-"`
@@ -104,6 +159,51 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseCache != nil {
 		c.DatabaseCache = *dec.DatabaseCache
 	}
+	if dec.SafeDepth != nil {
+		c.SafeDepth = *dec.SafeDepth
+	}
+	if dec.FinalityDepth != nil {
+		c.FinalityDepth = *dec.FinalityDepth
+	}
+	if dec.ActivityBloom != nil {
+		c.ActivityBloom = *dec.ActivityBloom
+	}
+	if dec.BalanceIndex != nil {
+		c.BalanceIndex = *dec.BalanceIndex
+	}
+	if dec.HistoryRetain != nil {
+		c.HistoryRetain = *dec.HistoryRetain
+	}
+	if dec.PrivateState != nil {
+		c.PrivateState = *dec.PrivateState
+	}
+	if dec.PrivateNodes != nil {
+		c.PrivateNodes = dec.PrivateNodes
+	}
+	if dec.Invariants != nil {
+		c.Invariants = *dec.Invariants
+	}
+	if dec.LogChunkSize != nil {
+		c.LogChunkSize = *dec.LogChunkSize
+	}
+	if dec.LogQueryLimit != nil {
+		c.LogQueryLimit = *dec.LogQueryLimit
+	}
+	if dec.ReplicaLeader != nil {
+		c.ReplicaLeader = *dec.ReplicaLeader
+	}
+	if dec.ReplicaSecret != nil {
+		c.ReplicaSecret = *dec.ReplicaSecret
+	}
+	if dec.ReplicaVerify != nil {
+		c.ReplicaVerify = *dec.ReplicaVerify
+	}
+	if dec.CommitteeHeartbeat != nil {
+		c.CommitteeHeartbeat = *dec.CommitteeHeartbeat
+	}
+	if dec.CommitteeMembers != nil {
+		c.CommitteeMembers = dec.CommitteeMembers
+	}
 	if dec.Usebase != nil {
 		c.Usebase = *dec.Usebase
 	}
@@ -116,12 +216,18 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.GasPrice != nil {
 		c.GasPrice = dec.GasPrice
 	}
+	if dec.Miner != nil {
+		c.Miner = *dec.Miner
+	}
 	if dec.Ethash != nil {
 		c.Ethash = *dec.Ethash
 	}
 	if dec.TxPool != nil {
 		c.TxPool = *dec.TxPool
 	}
+	if dec.TxRebroadcast != nil {
+		c.TxRebroadcast = *dec.TxRebroadcast
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
@@ -33,6 +33,7 @@ import (
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/eth/fetcher"
+	"github.com/usechain/go-usechain/eth/heartbeat"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
 	"github.com/usechain/go-usechain/log"
@@ -49,6 +50,13 @@ const (
 	// txChanSize is the size of channel listening to TxPreEvent.
 	// The number is referenced from the size of tx pool.
 	txChanSize = 4096
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// blockRangeInterval is the number of blocks after which the served block
+	// range is advertised anew to the peers.
+	blockRangeInterval = 32
 )
 
 var (
@@ -73,6 +81,7 @@ type ProtocolManager struct {
 	blockchain  *core.BlockChain
 	chainconfig *params.ChainConfig
 	maxPeers    int
+	heartbeats  *heartbeat.Tracker // Committee liveness tracker, nil if heartbeats are ignored
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
@@ -84,6 +93,8 @@ type ProtocolManager struct {
 	txCh          chan core.TxPreEvent
 	txSub         event.Subscription
 	minedBlockSub *event.TypeMuxSubscription
+	chainHeadCh   chan core.ChainHeadEvent
+	chainHeadSub  event.Subscription
 
 	// channels for fetcher, syncer, txsyncLoop
 	newPeerCh   chan *peer
@@ -153,6 +164,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 				}
 				return nil
 			},
+			MessageName: messageName,
 		})
 	}
 	if len(manager.SubProtocols) == 0 {
@@ -212,6 +224,11 @@ func (pm *ProtocolManager) Start(maxPeers int) {
 	pm.minedBlockSub = pm.eventMux.Subscribe(core.NewMinedBlockEvent{})
 	go pm.minedBroadcastLoop()
 
+	// advertise the served block range
+	pm.chainHeadCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+	pm.chainHeadSub = pm.blockchain.SubscribeChainHeadEvent(pm.chainHeadCh)
+	go pm.blockRangeLoop()
+
 	// start sync handlers
 	go pm.syncer()
 	go pm.txsyncLoop()
@@ -222,6 +239,7 @@ func (pm *ProtocolManager) Stop() {
 
 	pm.txSub.Unsubscribe()         // quits txBroadcastLoop
 	pm.minedBlockSub.Unsubscribe() // quits blockBroadcastLoop
+	pm.chainHeadSub.Unsubscribe()  // quits blockRangeLoop
 
 	// Quit the sync loop.
 	// After this send has completed, no new peers will be accepted.
@@ -281,6 +299,12 @@ func (pm *ProtocolManager) handle(p *peer) error {
 	if err := pm.downloader.RegisterPeer(p.id, p.version, p); err != nil {
 		return err
 	}
+	// Advertise the blocks we can serve, so the peer doesn't ask for pruned ones
+	if p.version >= eth66 {
+		if err := p.SendBlockRange(pm.blockRange()); err != nil {
+			return err
+		}
+	}
 	// Propagate existing transactions. new transactions appearing
 	// after this will be sent via broadcasts.
 	pm.syncTransactions(p)
@@ -654,6 +678,55 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		}
 		pm.txpool.AddRemotes(txs)
 
+	case p.version >= eth64 && msg.Code == SystemTxMsg:
+		// System transactions arrived, make sure we have a valid and fresh chain to handle them
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		// Transactions can be processed, ensure they're all system ones and deliver to the pool
+		var txs []*types.Transaction
+		if err := msg.Decode(&txs); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for i, tx := range txs {
+			// Validate and mark the remote transaction
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+			if !core.IsSystemTransaction(tx) {
+				return errResp(ErrDecode, "transaction %d is not a system transaction", i)
+			}
+			p.MarkTransaction(tx.Hash())
+		}
+		pm.txpool.AddRemotes(txs)
+
+	case p.version >= eth65 && msg.Code == HeartbeatMsg:
+		// A committee member attested its liveness, record and relay it if new
+		var hb heartbeat.Heartbeat
+		if err := msg.Decode(&hb); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.MarkHeartbeat(hb.Hash())
+		if pm.heartbeats == nil {
+			break
+		}
+		if err := pm.heartbeats.Add(&hb, time.Now()); err != nil {
+			log.Trace("Discarded committee heartbeat", "member", hb.Member, "peer", p.id, "err", err)
+			break
+		}
+		pm.BroadcastHeartbeat(&hb)
+
+	case p.version >= eth66 && msg.Code == BlockRangeMsg:
+		// A peer advertised the blocks it can serve, target our requests accordingly
+		var served blockRangeData
+		if err := msg.Decode(&served); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if served.Earliest > served.Latest {
+			return errResp(ErrDecode, "invalid block range %d > %d", served.Earliest, served.Latest)
+		}
+		p.SetBlockRange(&served)
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -663,6 +736,10 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 // BroadcastBlock will either propagate a block to a subset of it's peers, or
 // will only announce it's availability (depending what's requested).
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
+	// Don't relay blocks while the chain is halted on an invariant violation
+	if pm.blockchain.Halted() != nil {
+		return
+	}
 	hash := block.Hash()
 	peers := pm.peers.PeersWithoutBlock(hash)
 
@@ -705,6 +782,79 @@ func (pm *ProtocolManager) BroadcastTx(hash common.Hash, tx *types.Transaction)
 	log.Trace("Broadcast transaction", "hash", hash, "recipients", len(peers))
 }
 
+// rebroadcastTx propagates a local transaction again to all peers which are not
+// known to have it yet, returning the number of peers it was sent to.
+func (pm *ProtocolManager) rebroadcastTx(tx *types.Transaction) int {
+	peers := pm.peers.PeersWithoutTx(tx.Hash())
+	for _, peer := range peers {
+		peer.SendTransactions(types.Transactions{tx})
+	}
+	return len(peers)
+}
+
+// BroadcastSystemTx will propagate an identity or governance transaction to all
+// peers which are not known to already have it, using the dedicated channel.
+func (pm *ProtocolManager) BroadcastSystemTx(hash common.Hash, tx *types.Transaction) {
+	peers := pm.peers.PeersWithoutTx(hash)
+	for _, peer := range peers {
+		peer.SendSystemTransactions(types.Transactions{tx})
+	}
+	log.Trace("Broadcast system transaction", "hash", hash, "recipients", len(peers))
+}
+
+// BroadcastHeartbeat will propagate a committee heartbeat to all peers which
+// are not known to already have it.
+func (pm *ProtocolManager) BroadcastHeartbeat(hb *heartbeat.Heartbeat) {
+	hash := hb.Hash()
+	peers := pm.peers.PeersWithoutHeartbeat(hash)
+	for _, peer := range peers {
+		peer.SendHeartbeat(hb)
+	}
+	log.Trace("Broadcast committee heartbeat", "member", hb.Member, "hash", hash, "recipients", len(peers))
+}
+
+// blockRange returns the range of blocks whose bodies and receipts are served
+// by the local node.
+func (pm *ProtocolManager) blockRange() *blockRangeData {
+	head := pm.blockchain.CurrentBlock()
+	return &blockRangeData{
+		Earliest:   pm.blockchain.HistoryTail(),
+		Latest:     head.NumberU64(),
+		LatestHash: head.Hash(),
+	}
+}
+
+// broadcastBlockRange advertises the served block range to all peers which
+// support it.
+func (pm *ProtocolManager) broadcastBlockRange(served *blockRangeData) {
+	peers := pm.peers.PeersWithBlockRange()
+	for _, peer := range peers {
+		peer.SendBlockRange(served)
+	}
+	log.Trace("Broadcast served block range", "earliest", served.Earliest, "latest", served.Latest, "recipients", len(peers))
+}
+
+// blockRangeLoop advertises the served block range whenever the chain advanced
+// by blockRangeInterval blocks or history got pruned.
+func (pm *ProtocolManager) blockRangeLoop() {
+	var last *blockRangeData
+	for {
+		select {
+		case <-pm.chainHeadCh:
+			served := pm.blockRange()
+			if last != nil && served.Earliest == last.Earliest && served.Latest < last.Latest+blockRangeInterval {
+				break
+			}
+			pm.broadcastBlockRange(served)
+			last = served
+
+		// Err() channel will be closed when unsubscribing.
+		case <-pm.chainHeadSub.Err():
+			return
+		}
+	}
+}
+
 // Mined broadcast loop
 func (self *ProtocolManager) minedBroadcastLoop() {
 	// automatically stops if unsubscribe
@@ -718,10 +868,24 @@ func (self *ProtocolManager) minedBroadcastLoop() {
 }
 
 func (self *ProtocolManager) txBroadcastLoop() {
+	// Broadcast user transactions on a separate goroutine, so that system ones
+	// never queue up behind them
+	userTxs := make(chan *types.Transaction, txChanSize)
+	defer close(userTxs)
+
+	go func() {
+		for tx := range userTxs {
+			self.BroadcastTx(tx.Hash(), tx)
+		}
+	}()
 	for {
 		select {
 		case event := <-self.txCh:
-			self.BroadcastTx(event.Tx.Hash(), event.Tx)
+			if core.IsSystemTransaction(event.Tx) {
+				self.BroadcastSystemTx(event.Tx.Hash(), event.Tx)
+			} else {
+				userTxs <- event.Tx
+			}
 
 		// Err() channel will be closed when unsubscribing.
 		case <-self.txSub.Err():
@@ -0,0 +1,101 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package heartbeat
+
+import (
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/log"
+)
+
+// Beacon periodically signs and publishes the heartbeat of a local committee
+// member.
+type Beacon struct {
+	member   func() (common.Address, error) // Committee account of the local node
+	head     func() uint64                  // Current chain head of the local node
+	sign     SignFn                         // Signer producing the heartbeat signatures
+	publish  func(*Heartbeat)               // Callback delivering heartbeats to the network
+	interval time.Duration
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewBeacon creates a heartbeat beacon for the local committee member.
+func NewBeacon(member func() (common.Address, error), head func() uint64, sign SignFn, publish func(*Heartbeat), interval time.Duration) *Beacon {
+	return &Beacon{
+		member:   member,
+		head:     head,
+		sign:     sign,
+		publish:  publish,
+		interval: interval,
+		quit:     make(chan struct{}),
+	}
+}
+
+// Start launches the heartbeat loop.
+func (b *Beacon) Start() {
+	b.wg.Add(1)
+	go b.loop()
+
+	log.Info("Committee heartbeat started", "interval", b.interval)
+}
+
+// Stop terminates the heartbeat loop.
+func (b *Beacon) Stop() {
+	close(b.quit)
+	b.wg.Wait()
+
+	log.Info("Committee heartbeat stopped")
+}
+
+// loop publishes a heartbeat every interval until stopped.
+func (b *Beacon) loop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		b.beat()
+
+		select {
+		case <-ticker.C:
+		case <-b.quit:
+			return
+		}
+	}
+}
+
+// beat signs and publishes a single heartbeat. Failures are only logged, as a
+// locked account may well be unlocked before the next round.
+func (b *Beacon) beat() {
+	member, err := b.member()
+	if err != nil {
+		log.Warn("Committee account unavailable for heartbeat", "err", err)
+		return
+	}
+	hb, err := New(member, b.head(), time.Now(), b.sign)
+	if err != nil {
+		log.Warn("Failed to sign committee heartbeat", "member", member, "err", err)
+		return
+	}
+	b.publish(hb)
+	log.Debug("Published committee heartbeat", "member", member, "head", hb.Head)
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package heartbeat implements the liveness attestation of committee members.
+//
+// Every committee node periodically signs a heartbeat with its committee account
+// and gossips it over the eth protocol. All nodes track the latest heartbeat of
+// each member, so operators can detect offline verifiers before verification
+// rounds stall.
+package heartbeat
+
+import (
+	"errors"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+const (
+	// DefaultInterval is the default period between two heartbeats of a member.
+	DefaultInterval = 30 * time.Second
+
+	// DefaultTimeout is the default silence after which a member is reported
+	// offline, allowing a few heartbeats to be lost on the way.
+	DefaultTimeout = 4 * DefaultInterval
+
+	// maxClockDrift is the tolerated clock skew between members.
+	maxClockDrift = 15 * time.Second
+)
+
+var errInvalidSignature = errors.New("invalid heartbeat signature")
+
+// Heartbeat is a liveness attestation signed by a committee member.
+type Heartbeat struct {
+	Member common.Address // Committee account attesting its liveness
+	Time   uint64         // Unix time the heartbeat was signed at
+	Head   uint64         // Chain head of the member when signing
+	Sig    []byte         // Signature of the member over the fields above
+}
+
+// SigHash returns the hash to be signed by the member. It is prefixed so that a
+// heartbeat signature can never be replayed as a transaction or message one.
+func (hb *Heartbeat) SigHash() common.Hash {
+	blob, _ := rlp.EncodeToBytes([]interface{}{hb.Member, hb.Time, hb.Head})
+	return crypto.Keccak256Hash([]byte("\x19Usechain Heartbeat:\n"), blob)
+}
+
+// Hash returns the unique identifier of the heartbeat, signature included.
+func (hb *Heartbeat) Hash() common.Hash {
+	return crypto.Keccak256Hash(hb.SigHash().Bytes(), hb.Sig)
+}
+
+// Verify checks that the heartbeat was signed by the member it claims to be from.
+func (hb *Heartbeat) Verify() error {
+	if len(hb.Sig) != 65 {
+		return errInvalidSignature
+	}
+	pubkey, err := crypto.SigToPub(hb.SigHash().Bytes(), hb.Sig)
+	if err != nil {
+		return errInvalidSignature
+	}
+	if crypto.PubkeyToAddress(*pubkey) != hb.Member {
+		return errInvalidSignature
+	}
+	return nil
+}
+
+// SignFn is a signer callback producing a signature of a hash with the account
+// of the given member.
+type SignFn func(member common.Address, hash []byte) ([]byte, error)
+
+// New creates a heartbeat of the member at the given chain head and signs it.
+func New(member common.Address, head uint64, now time.Time, sign SignFn) (*Heartbeat, error) {
+	hb := &Heartbeat{
+		Member: member,
+		Time:   uint64(now.Unix()),
+		Head:   head,
+	}
+	sig, err := sign(member, hb.SigHash().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	hb.Sig = sig
+	return hb, nil
+}
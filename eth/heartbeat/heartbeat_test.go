@@ -0,0 +1,148 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package heartbeat
+
+import (
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/crypto"
+)
+
+// newTestMember creates a committee account and a heartbeat signer for it.
+func newTestMember(t *testing.T) (common.Address, SignFn) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), keySigner(key)
+}
+
+func keySigner(key *ecdsa.PrivateKey) SignFn {
+	return func(member common.Address, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	}
+}
+
+// Tests that signed heartbeats verify, and tampered ones are rejected.
+func TestHeartbeatSignature(t *testing.T) {
+	member, sign := newTestMember(t)
+
+	hb, err := New(member, 10, time.Now(), sign)
+	if err != nil {
+		t.Fatalf("failed to create heartbeat: %v", err)
+	}
+	if err := hb.Verify(); err != nil {
+		t.Fatalf("valid heartbeat rejected: %v", err)
+	}
+	forged := *hb
+	forged.Head = 11
+	if err := forged.Verify(); err != errInvalidSignature {
+		t.Errorf("tampered heartbeat error mismatch: have %v, want %v", err, errInvalidSignature)
+	}
+	other, _ := newTestMember(t)
+	forged = *hb
+	forged.Member = other
+	if err := forged.Verify(); err != errInvalidSignature {
+		t.Errorf("impersonating heartbeat error mismatch: have %v, want %v", err, errInvalidSignature)
+	}
+	if hb.Hash() == forged.Hash() {
+		t.Errorf("distinct heartbeats share hash %x", hb.Hash())
+	}
+}
+
+// Tests that the tracker only accepts fresh heartbeats from committee members.
+func TestTrackerAdd(t *testing.T) {
+	var (
+		member, sign   = newTestMember(t)
+		outsider, fake = newTestMember(t)
+		now            = time.Now()
+	)
+	tracker := NewTracker(func(addr common.Address) bool { return addr == member }, nil, DefaultTimeout)
+
+	beat := func(addr common.Address, sign SignFn, at time.Time) *Heartbeat {
+		hb, err := New(addr, 1, at, sign)
+		if err != nil {
+			t.Fatalf("failed to create heartbeat: %v", err)
+		}
+		return hb
+	}
+	tests := []struct {
+		hb  *Heartbeat
+		err error
+	}{
+		{beat(outsider, fake, now), errNotMember},
+		{beat(member, sign, now.Add(time.Minute)), errFuture},
+		{beat(member, sign, now.Add(-2*DefaultTimeout)), errExpired},
+		{beat(member, sign, now.Add(-time.Minute)), nil},
+		{beat(member, sign, now.Add(-time.Minute)), errStale},
+		{beat(member, sign, now.Add(-90*time.Second)), errStale},
+		{beat(member, sign, now), nil},
+	}
+	for i, tt := range tests {
+		if err := tracker.Add(tt.hb, now); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that the liveness report covers both seen and expected members.
+func TestTrackerLiveness(t *testing.T) {
+	var (
+		online, sign1  = newTestMember(t)
+		offline, sign2 = newTestMember(t)
+		missing, _     = newTestMember(t)
+		now            = time.Now()
+	)
+	tracker := NewTracker(nil, []common.Address{online, offline, missing}, DefaultTimeout)
+
+	hb, _ := New(online, 5, now.Add(-time.Second), sign1)
+	if err := tracker.Add(hb, now); err != nil {
+		t.Fatalf("failed to add online heartbeat: %v", err)
+	}
+	hb, _ = New(offline, 3, now.Add(-DefaultTimeout+time.Second), sign2)
+	if err := tracker.Add(hb, now); err != nil {
+		t.Fatalf("failed to add offline heartbeat: %v", err)
+	}
+	report := tracker.Liveness(now.Add(time.Minute))
+	if len(report) != 3 {
+		t.Fatalf("report length mismatch: have %d, want 3", len(report))
+	}
+	for i := 1; i < len(report); i++ {
+		if report[i-1].Member.Big().Cmp(report[i].Member.Big()) >= 0 {
+			t.Errorf("report not ordered at %d: %x >= %x", i, report[i-1].Member, report[i].Member)
+		}
+	}
+	for _, status := range report {
+		switch status.Member {
+		case online:
+			if !status.Online || status.LastSeen == nil || uint64(*status.Head) != 5 || uint64(*status.Age) != 61 {
+				t.Errorf("online member status mismatch: %+v", status)
+			}
+		case offline:
+			if status.Online || status.LastSeen == nil || uint64(*status.Head) != 3 {
+				t.Errorf("offline member status mismatch: %+v", status)
+			}
+		case missing:
+			if status.Online || status.LastSeen != nil || status.Age != nil {
+				t.Errorf("missing member status mismatch: %+v", status)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package heartbeat
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+)
+
+var (
+	errNotMember = errors.New("sender is not a committee member")
+	errStale     = errors.New("heartbeat older than last seen")
+	errExpired   = errors.New("heartbeat expired")
+	errFuture    = errors.New("heartbeat from the future")
+)
+
+// Liveness is the attestation status of a single committee member.
+type Liveness struct {
+	Member   common.Address  `json:"member"`
+	Online   bool            `json:"online"`
+	LastSeen *hexutil.Uint64 `json:"lastSeen"` // Unix time of the last heartbeat, nil if never seen
+	Age      *hexutil.Uint64 `json:"age"`      // Seconds elapsed since the last heartbeat
+	Head     *hexutil.Uint64 `json:"head"`     // Chain head reported in the last heartbeat
+}
+
+// Tracker records the last heartbeat received from every committee member.
+type Tracker struct {
+	isMember func(common.Address) bool // Committee membership oracle (e.g. the chain state)
+	expected []common.Address          // Members to report even if never seen
+	timeout  time.Duration             // Silence after which a member is considered offline
+
+	seen map[common.Address]*Heartbeat
+	lock sync.RWMutex
+}
+
+// NewTracker creates a heartbeat tracker accepting attestations from senders
+// deemed committee members by either the oracle or the expected member list.
+func NewTracker(isMember func(common.Address) bool, expected []common.Address, timeout time.Duration) *Tracker {
+	return &Tracker{
+		isMember: isMember,
+		expected: expected,
+		timeout:  timeout,
+		seen:     make(map[common.Address]*Heartbeat),
+	}
+}
+
+// member checks whether the address belongs to the committee.
+func (t *Tracker) member(addr common.Address) bool {
+	for _, expected := range t.expected {
+		if expected == addr {
+			return true
+		}
+	}
+	return t.isMember != nil && t.isMember(addr)
+}
+
+// Add validates a heartbeat and records it as the latest attestation of its
+// member. Only heartbeats newer than the last seen one are accepted, so the
+// caller may use a nil error as the signal to propagate it further.
+func (t *Tracker) Add(hb *Heartbeat, now time.Time) error {
+	// Discard anything outside of the liveness window, cheapest checks first
+	switch ts := time.Unix(int64(hb.Time), 0); {
+	case ts.After(now.Add(maxClockDrift)):
+		return errFuture
+	case ts.Before(now.Add(-t.timeout)):
+		return errExpired
+	}
+	t.lock.RLock()
+	last := t.seen[hb.Member]
+	t.lock.RUnlock()
+
+	if last != nil && last.Time >= hb.Time {
+		return errStale
+	}
+	if err := hb.Verify(); err != nil {
+		return err
+	}
+	if !t.member(hb.Member) {
+		return errNotMember
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if last := t.seen[hb.Member]; last != nil && last.Time >= hb.Time {
+		return errStale
+	}
+	t.seen[hb.Member] = hb
+	return nil
+}
+
+// Liveness reports the attestation status of every known committee member,
+// ordered by address.
+func (t *Tracker) Liveness(now time.Time) []Liveness {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	members := make(map[common.Address]struct{})
+	for _, addr := range t.expected {
+		members[addr] = struct{}{}
+	}
+	for addr := range t.seen {
+		members[addr] = struct{}{}
+	}
+	report := make([]Liveness, 0, len(members))
+	for addr := range members {
+		status := Liveness{Member: addr}
+		if hb := t.seen[addr]; hb != nil {
+			var (
+				seen = hexutil.Uint64(hb.Time)
+				head = hexutil.Uint64(hb.Head)
+				age  hexutil.Uint64
+			)
+			if elapsed := now.Unix() - int64(hb.Time); elapsed > 0 {
+				age = hexutil.Uint64(elapsed)
+			}
+			status.LastSeen, status.Age, status.Head = &seen, &age, &head
+			status.Online = time.Duration(age)*time.Second <= t.timeout
+		}
+		report = append(report, status)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		return bytes.Compare(report[i].Member[:], report[j].Member[:]) < 0
+	})
+	return report
+}
@@ -192,6 +192,17 @@ func (p *testPeer) handshake(t *testing.T, td *big.Int, head common.Hash, genesi
 	if err := p2p.Send(p.app, StatusMsg, msg); err != nil {
 		t.Fatalf("status send: %v", err)
 	}
+	// Newer peers advertise their served block range right after the handshake
+	if p.version >= eth66 {
+		msg, err := p.app.ReadMsg()
+		if err != nil {
+			t.Fatalf("block range recv: %v", err)
+		}
+		if msg.Code != BlockRangeMsg {
+			t.Fatalf("block range recv: message code mismatch: have %d, want %d", msg.Code, BlockRangeMsg)
+		}
+		msg.Discard()
+	}
 }
 
 // close terminates the local side of the peer, notifying the remote protocol
@@ -26,6 +26,14 @@ var (
 	propTxnInTrafficMeter     = metrics.NewRegisteredMeter("eth/prop/txns/in/traffic", nil)
 	propTxnOutPacketsMeter    = metrics.NewRegisteredMeter("eth/prop/txns/out/packets", nil)
 	propTxnOutTrafficMeter    = metrics.NewRegisteredMeter("eth/prop/txns/out/traffic", nil)
+	propSysTxnInPacketsMeter  = metrics.NewRegisteredMeter("eth/prop/systxns/in/packets", nil)
+	propSysTxnInTrafficMeter  = metrics.NewRegisteredMeter("eth/prop/systxns/in/traffic", nil)
+	propSysTxnOutPacketsMeter = metrics.NewRegisteredMeter("eth/prop/systxns/out/packets", nil)
+	propSysTxnOutTrafficMeter = metrics.NewRegisteredMeter("eth/prop/systxns/out/traffic", nil)
+	propBeatInPacketsMeter    = metrics.NewRegisteredMeter("eth/prop/heartbeats/in/packets", nil)
+	propBeatInTrafficMeter    = metrics.NewRegisteredMeter("eth/prop/heartbeats/in/traffic", nil)
+	propBeatOutPacketsMeter   = metrics.NewRegisteredMeter("eth/prop/heartbeats/out/packets", nil)
+	propBeatOutTrafficMeter   = metrics.NewRegisteredMeter("eth/prop/heartbeats/out/traffic", nil)
 	propHashInPacketsMeter    = metrics.NewRegisteredMeter("eth/prop/hashes/in/packets", nil)
 	propHashInTrafficMeter    = metrics.NewRegisteredMeter("eth/prop/hashes/in/traffic", nil)
 	propHashOutPacketsMeter   = metrics.NewRegisteredMeter("eth/prop/hashes/out/packets", nil)
@@ -103,6 +111,10 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		packets, traffic = propBlockInPacketsMeter, propBlockInTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnInPacketsMeter, propTxnInTrafficMeter
+	case rw.version >= eth64 && msg.Code == SystemTxMsg:
+		packets, traffic = propSysTxnInPacketsMeter, propSysTxnInTrafficMeter
+	case rw.version >= eth65 && msg.Code == HeartbeatMsg:
+		packets, traffic = propBeatInPacketsMeter, propBeatInTrafficMeter
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
@@ -130,6 +142,10 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 		packets, traffic = propBlockOutPacketsMeter, propBlockOutTrafficMeter
 	case msg.Code == TxMsg:
 		packets, traffic = propTxnOutPacketsMeter, propTxnOutTrafficMeter
+	case rw.version >= eth64 && msg.Code == SystemTxMsg:
+		packets, traffic = propSysTxnOutPacketsMeter, propSysTxnOutTrafficMeter
+	case rw.version >= eth65 && msg.Code == HeartbeatMsg:
+		packets, traffic = propBeatOutPacketsMeter, propBeatOutTrafficMeter
 	}
 	packets.Mark(1)
 	traffic.Mark(int64(msg.Size))
@@ -25,6 +25,7 @@ import (
 
 	"github.com/usechain/go-usechain/common"
 	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/eth/heartbeat"
 	"github.com/usechain/go-usechain/p2p"
 	"github.com/usechain/go-usechain/rlp"
 	"gopkg.in/fatih/set.v0"
@@ -37,9 +38,10 @@ var (
 )
 
 const (
-	maxKnownTxs      = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
-	maxKnownBlocks   = 1024  // Maximum block hashes to keep in the known list (prevent DOS)
-	handshakeTimeout = 5 * time.Second
+	maxKnownTxs        = 32768 // Maximum transactions hashes to keep in the known list (prevent DOS)
+	maxKnownBlocks     = 1024  // Maximum block hashes to keep in the known list (prevent DOS)
+	maxKnownHeartbeats = 1024  // Maximum heartbeat hashes to keep in the known list (prevent DOS)
+	handshakeTimeout   = 5 * time.Second
 )
 
 // PeerInfo represents a short summary of the Ethereum sub-protocol metadata known
@@ -59,24 +61,27 @@ type peer struct {
 	version  int         // Protocol version negotiated
 	forkDrop *time.Timer // Timed connection dropper if forks aren't validated in time
 
-	head common.Hash
-	td   *big.Int
-	lock sync.RWMutex
+	head   common.Hash
+	td     *big.Int
+	served *blockRangeData // Range of blocks advertised by the peer, nil if unknown
+	lock   sync.RWMutex
 
-	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
-	knownBlocks *set.Set // Set of block hashes known to be known by this peer
+	knownTxs        *set.Set // Set of transaction hashes known to be known by this peer
+	knownBlocks     *set.Set // Set of block hashes known to be known by this peer
+	knownHeartbeats *set.Set // Set of committee heartbeat hashes known to be known by this peer
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 	id := p.ID()
 
 	return &peer{
-		Peer:        p,
-		rw:          rw,
-		version:     version,
-		id:          fmt.Sprintf("%x", id[:8]),
-		knownTxs:    set.New(),
-		knownBlocks: set.New(),
+		Peer:            p,
+		rw:              rw,
+		version:         version,
+		id:              fmt.Sprintf("%x", id[:8]),
+		knownTxs:        set.New(),
+		knownBlocks:     set.New(),
+		knownHeartbeats: set.New(),
 	}
 }
 
@@ -110,6 +115,25 @@ func (p *peer) SetHead(hash common.Hash, td *big.Int) {
 	p.td.Set(td)
 }
 
+// SetBlockRange updates the range of blocks the peer advertised to serve.
+func (p *peer) SetBlockRange(served *blockRangeData) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.served = served
+}
+
+// Serves reports whether the peer is expected to be able to serve the body and
+// receipts of the given block. Peers which did not advertise their range are
+// assumed to retain their entire history. Blocks beyond the advertised latest
+// one are not refused, the range being announced only periodically.
+func (p *peer) Serves(number uint64) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	return p.served == nil || number == 0 || number >= p.served.Earliest
+}
+
 // MarkBlock marks a block as known for the peer, ensuring that the block will
 // never be propagated to this particular peer.
 func (p *peer) MarkBlock(hash common.Hash) {
@@ -130,6 +154,16 @@ func (p *peer) MarkTransaction(hash common.Hash) {
 	p.knownTxs.Add(hash)
 }
 
+// MarkHeartbeat marks a committee heartbeat as known for the peer, ensuring
+// that it will never be propagated to this particular peer.
+func (p *peer) MarkHeartbeat(hash common.Hash) {
+	// If we reached the memory allowance, drop a previously known heartbeat hash
+	for p.knownHeartbeats.Size() >= maxKnownHeartbeats {
+		p.knownHeartbeats.Pop()
+	}
+	p.knownHeartbeats.Add(hash)
+}
+
 // SendTransactions sends transactions to the peer and includes the hashes
 // in its transaction hash set for future reference.
 func (p *peer) SendTransactions(txs types.Transactions) error {
@@ -139,6 +173,32 @@ func (p *peer) SendTransactions(txs types.Transactions) error {
 	return p2p.Send(p.rw, TxMsg, txs)
 }
 
+// SendSystemTransactions sends identity and governance transactions to the peer
+// over the dedicated system transaction channel, falling back to the plain one
+// for peers not supporting it.
+func (p *peer) SendSystemTransactions(txs types.Transactions) error {
+	if p.version < eth64 {
+		return p.SendTransactions(txs)
+	}
+	for _, tx := range txs {
+		p.knownTxs.Add(tx.Hash())
+	}
+	return p2p.Send(p.rw, SystemTxMsg, txs)
+}
+
+// SendHeartbeat propagates a committee heartbeat to the peer and includes its
+// hash in the heartbeat set for future reference.
+func (p *peer) SendHeartbeat(hb *heartbeat.Heartbeat) error {
+	p.MarkHeartbeat(hb.Hash())
+	return p2p.Send(p.rw, HeartbeatMsg, hb)
+}
+
+// SendBlockRange advertises the range of blocks whose bodies and receipts are
+// served by the local node.
+func (p *peer) SendBlockRange(served *blockRangeData) error {
+	return p2p.Send(p.rw, BlockRangeMsg, served)
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -387,6 +447,36 @@ func (ps *peerSet) PeersWithoutTx(hash common.Hash) []*peer {
 	return list
 }
 
+// PeersWithoutHeartbeat retrieves a list of peers supporting committee heartbeats
+// that do not have the given one in their set of known hashes.
+func (ps *peerSet) PeersWithoutHeartbeat(hash common.Hash) []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.version >= eth65 && !p.knownHeartbeats.Has(hash) {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// PeersWithBlockRange retrieves a list of peers supporting the advertisement of
+// served block ranges.
+func (ps *peerSet) PeersWithBlockRange() []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		if p.version >= eth66 {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // BestPeer retrieves the known peer with the currently highest total difficulty.
 func (ps *peerSet) BestPeer() *peer {
 	ps.lock.RLock()
@@ -32,16 +32,19 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
+	eth65 = 65
+	eth66 = 66
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "eth"
 
 // Supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+var ProtocolVersions = []uint{eth66, eth65, eth64, eth63, eth62}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{20, 19, 18, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -62,8 +65,42 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to eth/64
+	SystemTxMsg = 0x11
+
+	// Protocol messages belonging to eth/65
+	HeartbeatMsg = 0x12
+
+	// Protocol messages belonging to eth/66
+	BlockRangeMsg = 0x13
 )
 
+// messageNames maps the eth message codes to their names in the traffic metrics.
+var messageNames = map[uint64]string{
+	StatusMsg:          "Status",
+	NewBlockHashesMsg:  "NewBlockHashes",
+	TxMsg:              "Transactions",
+	GetBlockHeadersMsg: "GetBlockHeaders",
+	BlockHeadersMsg:    "BlockHeaders",
+	GetBlockBodiesMsg:  "GetBlockBodies",
+	BlockBodiesMsg:     "BlockBodies",
+	NewBlockMsg:        "NewBlock",
+	GetNodeDataMsg:     "GetNodeData",
+	NodeDataMsg:        "NodeData",
+	GetReceiptsMsg:     "GetReceipts",
+	ReceiptsMsg:        "Receipts",
+	SystemTxMsg:        "SystemTransactions",
+	HeartbeatMsg:       "Heartbeat",
+	BlockRangeMsg:      "BlockRange",
+}
+
+// messageName returns the name of an eth message code, or an empty string for
+// the unused codes.
+func messageName(code uint64) string {
+	return messageNames[code]
+}
+
 type errCode int
 
 const (
@@ -117,6 +154,14 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// blockRangeData is the network packet advertising the range of blocks whose
+// bodies and receipts a peer is able to serve.
+type blockRangeData struct {
+	Earliest   uint64      // Oldest block with its body and receipts available
+	Latest     uint64      // Most recent block available
+	LatestHash common.Hash // Hash of the most recent block available
+}
+
 // newBlockHashesData is the network packet for the block announcements.
 type newBlockHashesData []struct {
 	Hash   common.Hash // Hash of one particular block being announced
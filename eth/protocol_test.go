@@ -18,6 +18,7 @@ package eth
 
 import (
 	"fmt"
+	"math/big"
 	"sync"
 	"testing"
 	"time"
@@ -26,6 +27,7 @@ import (
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/eth/downloader"
+	"github.com/usechain/go-usechain/eth/heartbeat"
 	"github.com/usechain/go-usechain/p2p"
 	"github.com/usechain/go-usechain/rlp"
 )
@@ -95,6 +97,7 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 // This test checks that received transactions are added to the local pool.
 func TestRecvTransactions62(t *testing.T) { testRecvTransactions(t, 62) }
 func TestRecvTransactions63(t *testing.T) { testRecvTransactions(t, 63) }
+func TestRecvTransactions64(t *testing.T) { testRecvTransactions(t, 64) }
 
 func testRecvTransactions(t *testing.T, protocol int) {
 	txAdded := make(chan []*types.Transaction)
@@ -120,6 +123,122 @@ func testRecvTransactions(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that system transactions are accepted over the dedicated channel.
+func TestRecvSystemTransactions64(t *testing.T) {
+	txAdded := make(chan []*types.Transaction)
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, txAdded)
+	pm.acceptTxs = 1 // mark synced to accept transactions
+	p, _ := newTestPeer("peer", eth64, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	tx := types.NewTransaction(0, common.HexToAddress(common.AuthenticationContractAddressString), big.NewInt(0), 100000, big.NewInt(0), nil)
+	tx, _ = types.SignTx(tx, types.HomesteadSigner{}, testAccount)
+	if err := p2p.Send(p.app, SystemTxMsg, []interface{}{tx}); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case added := <-txAdded:
+		if len(added) != 1 {
+			t.Errorf("wrong number of added transactions: got %d, want 1", len(added))
+		} else if added[0].Hash() != tx.Hash() {
+			t.Errorf("added wrong tx hash: got %v, want %v", added[0].Hash(), tx.Hash())
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("no TxPreEvent received within 2 seconds")
+	}
+}
+
+// Tests that committee heartbeats are recorded and relayed to the other peers.
+func TestRecvHeartbeat65(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	member := crypto.PubkeyToAddress(testAccount.PublicKey)
+	pm.heartbeats = heartbeat.NewTracker(nil, []common.Address{member}, heartbeat.DefaultTimeout)
+
+	src, _ := newTestPeer("src", eth65, pm, true)
+	dst, _ := newTestPeer("dst", eth65, pm, true)
+	defer pm.Stop()
+	defer src.close()
+	defer dst.close()
+
+	hb, err := heartbeat.New(member, 0, time.Now(), func(_ common.Address, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, testAccount)
+	})
+	if err != nil {
+		t.Fatalf("failed to sign heartbeat: %v", err)
+	}
+	if err := p2p.Send(src.app, HeartbeatMsg, hb); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	if err := p2p.ExpectMsg(dst.app, HeartbeatMsg, hb); err != nil {
+		t.Fatalf("heartbeat not relayed: %v", err)
+	}
+	report := pm.heartbeats.Liveness(time.Now())
+	if len(report) != 1 || !report[0].Online {
+		t.Errorf("heartbeat not recorded: %+v", report)
+	}
+}
+
+// Tests that the served block range is advertised after the handshake, and that
+// the ranges advertised by remote peers are tracked.
+func TestBlockRange66(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 16, nil, nil)
+	defer pm.Stop()
+
+	p, errc := newTestPeer("peer", eth66, pm, false)
+	defer p.close()
+
+	var (
+		genesis = pm.blockchain.Genesis()
+		head    = pm.blockchain.CurrentBlock()
+		td      = pm.blockchain.GetTd(head.Hash(), head.NumberU64())
+		status  = &statusData{
+			ProtocolVersion: eth66,
+			NetworkId:       DefaultConfig.NetworkId,
+			TD:              td,
+			CurrentBlock:    head.Hash(),
+			GenesisBlock:    genesis.Hash(),
+		}
+	)
+	if err := p2p.ExpectMsg(p.app, StatusMsg, status); err != nil {
+		t.Fatalf("status recv: %v", err)
+	}
+	if err := p2p.Send(p.app, StatusMsg, status); err != nil {
+		t.Fatalf("status send: %v", err)
+	}
+	if err := p2p.ExpectMsg(p.app, BlockRangeMsg, &blockRangeData{Earliest: 0, Latest: 16, LatestHash: head.Hash()}); err != nil {
+		t.Fatalf("block range recv: %v", err)
+	}
+	// Advertise a pruned history and check that older blocks are not requested
+	if err := p2p.Send(p.app, BlockRangeMsg, &blockRangeData{Earliest: 100, Latest: 200}); err != nil {
+		t.Fatalf("block range send: %v", err)
+	}
+	for i := 0; ; i++ {
+		if peer := pm.peers.Peer(p.peer.id); peer != nil && !peer.Serves(99) {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("advertised block range not tracked")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if peer := pm.peers.Peer(p.peer.id); !peer.Serves(0) || !peer.Serves(100) || !peer.Serves(300) {
+		t.Errorf("served blocks refused")
+	}
+	// An inverted range is invalid and must drop the peer
+	if err := p2p.Send(p.app, BlockRangeMsg, &blockRangeData{Earliest: 200, Latest: 100}); err != nil {
+		t.Fatalf("block range send: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Errorf("peer not dropped on invalid block range")
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("peer not dropped on invalid block range")
+	}
+}
+
 // This test checks that pending transactions are sent.
 func TestSendTransactions62(t *testing.T) { testSendTransactions(t, 62) }
 func TestSendTransactions63(t *testing.T) { testSendTransactions(t, 63) }
@@ -0,0 +1,189 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package replica
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// retryInterval is the time to wait before reconnecting to a failed leader.
+	retryInterval = 5 * time.Second
+
+	// requestTimeout is the maximum time allowed for a single leader request.
+	requestTimeout = 30 * time.Second
+
+	// headChanSize is the size of channel listening to leader head notifications.
+	headChanSize = 16
+
+	// maxBlocksFetch is the number of blocks requested from the leader at once.
+	maxBlocksFetch = 128
+)
+
+// Follower imports the blocks served by a trusted leader into the local chain,
+// in place of synchronising with the p2p network.
+type Follower struct {
+	chain  *core.BlockChain
+	leader string     // RPC endpoint of the leader
+	secret string     // Shared secret authenticating with the leader
+	verify VerifyMode // Verification applied to the imported blocks
+
+	dial func() (*rpc.Client, error) // Leader connection factory, overridable in tests
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewFollower creates a follower importing the leader's blocks into chain.
+func NewFollower(chain *core.BlockChain, leader, secret string, verify VerifyMode) *Follower {
+	f := &Follower{
+		chain:  chain,
+		leader: leader,
+		secret: secret,
+		verify: verify,
+		quit:   make(chan struct{}),
+	}
+	f.dial = func() (*rpc.Client, error) { return rpc.Dial(f.leader) }
+	return f
+}
+
+// Start begins following the leader in the background.
+func (f *Follower) Start() {
+	f.wg.Add(1)
+	go f.loop()
+}
+
+// Stop terminates the follower, waiting for any running import to finish.
+func (f *Follower) Stop() {
+	close(f.quit)
+	f.wg.Wait()
+}
+
+// loop keeps following the leader, reconnecting whenever the link fails.
+func (f *Follower) loop() {
+	defer f.wg.Done()
+
+	for {
+		if err := f.follow(); err != nil {
+			log.Warn("Replica leader link failed", "leader", f.leader, "err", err)
+		}
+		select {
+		case <-f.quit:
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// follow connects to the leader and imports its blocks until the link fails or
+// the follower is stopped.
+func (f *Follower) follow() error {
+	client, err := f.dial()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	heads := make(chan *types.Header, headChanSize)
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	sub, err := client.Subscribe(ctx, "replica", heads, "heads", f.secret)
+	cancel()
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	log.Info("Following replica leader", "leader", f.leader, "verify", f.verify)
+	for {
+		if err := f.sync(client); err != nil {
+			return err
+		}
+		select {
+		case <-heads:
+		case err := <-sub.Err():
+			return err
+		case <-f.quit:
+			return nil
+		}
+	}
+}
+
+// sync pulls and imports blocks from the leader until the local chain caught up.
+func (f *Follower) sync(client *rpc.Client) error {
+	for {
+		select {
+		case <-f.quit:
+			return nil
+		default:
+		}
+		var (
+			blobs []hexutil.Bytes
+			head  = f.chain.CurrentBlock().Hash()
+		)
+		ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+		err := client.CallContext(ctx, &blobs, "replica_blocks", f.secret, head, hexutil.Uint64(maxBlocksFetch))
+		cancel()
+		if err != nil {
+			return err
+		}
+		if len(blobs) == 0 {
+			return nil
+		}
+		blocks := make(types.Blocks, len(blobs))
+		for i, blob := range blobs {
+			blocks[i] = new(types.Block)
+			if err := rlp.DecodeBytes(blob, blocks[i]); err != nil {
+				return fmt.Errorf("invalid block %d: %v", i, err)
+			}
+		}
+		if err := f.insert(blocks); err != nil {
+			return err
+		}
+		// Blocks not overtaking the local head leave nothing more to pull for now
+		if f.chain.CurrentBlock().Hash() == head {
+			return nil
+		}
+	}
+}
+
+// insert imports a batch of leader blocks, verifying them as configured.
+func (f *Follower) insert(blocks types.Blocks) error {
+	var (
+		n   int
+		err error
+	)
+	switch f.verify {
+	case VerifyNoSeal:
+		n, err = f.chain.InsertChainTrusted(blocks)
+	default:
+		n, err = f.chain.InsertChain(blocks)
+	}
+	if err != nil {
+		return fmt.Errorf("block #%d [%x…] import failed: %v", blocks[n].Number(), blocks[n].Hash().Bytes()[:4], err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package replica
+
+import (
+	"context"
+	"crypto/subtle"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+
+	// maxBlocksServe is the maximum number of blocks served in a single request.
+	maxBlocksServe = 256
+)
+
+// LeaderAPI serves the canonical chain to authenticated followers.
+type LeaderAPI struct {
+	chain  *core.BlockChain
+	secret []byte
+}
+
+// NewLeaderAPI creates a new replica leader API, authenticating followers with
+// the given shared secret.
+func NewLeaderAPI(chain *core.BlockChain, secret string) *LeaderAPI {
+	return &LeaderAPI{
+		chain:  chain,
+		secret: []byte(secret),
+	}
+}
+
+// authorized checks whether the secret presented by a follower is valid.
+func (api *LeaderAPI) authorized(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(secret), api.secret) == 1
+}
+
+// Heads sends a notification each time the leader's chain head changes, prompting
+// followers to pull the new blocks.
+func (api *LeaderAPI) Heads(ctx context.Context, secret string) (*rpc.Subscription, error) {
+	if !api.authorized(secret) {
+		return nil, errUnauthorized
+	}
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		heads := make(chan core.ChainHeadEvent, chainHeadChanSize)
+		sub := api.chain.SubscribeChainHeadEvent(heads)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-heads:
+				notifier.Notify(rpcSub.ID, ev.Block.Header())
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
+// Blocks retrieves the RLP encoded canonical blocks following the given head of
+// a follower. If the head is not canonical any more, the blocks are served from
+// its closest canonical ancestor, allowing the follower to reorg along.
+func (api *LeaderAPI) Blocks(secret string, head common.Hash, limit hexutil.Uint64) ([]hexutil.Bytes, error) {
+	if !api.authorized(secret) {
+		return nil, errUnauthorized
+	}
+	origin := api.chain.GetHeaderByHash(head)
+	if origin == nil {
+		return nil, errUnknownHead
+	}
+	// Rewind to the canonical ancestor if the follower's head was reorged out
+	for canon := api.chain.GetHeaderByNumber(origin.Number.Uint64()); canon == nil || canon.Hash() != origin.Hash(); canon = api.chain.GetHeaderByNumber(origin.Number.Uint64()) {
+		if origin = api.chain.GetHeader(origin.ParentHash, origin.Number.Uint64()-1); origin == nil {
+			return nil, errUnknownHead
+		}
+	}
+	if limit == 0 || limit > maxBlocksServe {
+		limit = maxBlocksServe
+	}
+	var (
+		blobs  []hexutil.Bytes
+		parent = origin.Hash()
+	)
+	for number := origin.Number.Uint64() + 1; uint64(len(blobs)) < uint64(limit); number++ {
+		block := api.chain.GetBlockByNumber(number)
+		if block == nil || block.ParentHash() != parent {
+			break // end of chain or reorg in progress, follower will come back
+		}
+		blob, err := rlp.EncodeToBytes(block)
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, blob)
+		parent = block.Hash()
+	}
+	return blobs, nil
+}
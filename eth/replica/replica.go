@@ -0,0 +1,83 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package replica implements the leader/follower block stream used to run read
+// replicas of a node across datacenters.
+//
+// A leader exposes the canonical chain over an authenticated RPC subscription,
+// and followers import the streamed blocks in place of syncing from the p2p
+// network, trusting the leader to the extent configured by their VerifyMode.
+package replica
+
+import (
+	"errors"
+	"fmt"
+)
+
+// VerifyMode represents how thoroughly a follower re-verifies streamed blocks.
+type VerifyMode int
+
+const (
+	VerifyFull   VerifyMode = iota // Verify streamed blocks exactly like blocks from the network
+	VerifyNoSeal                   // Trust the leader's consensus seal checks, verify everything else
+)
+
+var (
+	// errUnauthorized is returned if a follower presents an invalid secret.
+	errUnauthorized = errors.New("unauthorized replica")
+
+	// errUnknownHead is returned if the leader doesn't know the follower's head.
+	errUnknownHead = errors.New("unknown follower head")
+)
+
+func (mode VerifyMode) IsValid() bool {
+	return mode >= VerifyFull && mode <= VerifyNoSeal
+}
+
+// String implements the stringer interface.
+func (mode VerifyMode) String() string {
+	switch mode {
+	case VerifyFull:
+		return "full"
+	case VerifyNoSeal:
+		return "noseal"
+	default:
+		return "unknown"
+	}
+}
+
+func (mode VerifyMode) MarshalText() ([]byte, error) {
+	switch mode {
+	case VerifyFull:
+		return []byte("full"), nil
+	case VerifyNoSeal:
+		return []byte("noseal"), nil
+	default:
+		return nil, fmt.Errorf("unknown verify mode %d", mode)
+	}
+}
+
+func (mode *VerifyMode) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "full":
+		*mode = VerifyFull
+	case "noseal":
+		*mode = VerifyNoSeal
+	default:
+		return fmt.Errorf(`unknown verify mode %q, want "full" or "noseal"`, text)
+	}
+	return nil
+}
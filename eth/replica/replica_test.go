@@ -0,0 +1,123 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package replica
+
+import (
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/params"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// newTestChain creates a blockchain on top of a fresh default genesis.
+func newTestChain(t *testing.T) (*core.BlockChain, *types.Block) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis := new(core.Genesis).MustCommit(db)
+
+	chain, err := core.NewBlockChain(db, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{})
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return chain, genesis
+}
+
+// makeBlocks generates n blocks on top of parent, tagged by seed to allow forks.
+// The state of parent must be available in db.
+func makeBlocks(db ethdb.Database, parent *types.Block, n int, seed byte) types.Blocks {
+	blocks, _ := core.GenerateChain(params.TestChainConfig, parent, ethash.NewFaker(), db, n, func(i int, b *core.BlockGen) {
+		b.SetCoinbase(common.Address{seed})
+	})
+	return blocks
+}
+
+// waitHead waits until the chain's head becomes the given hash.
+func waitHead(t *testing.T, chain *core.BlockChain, hash common.Hash) {
+	for i := 0; i < 100; i++ {
+		if chain.CurrentBlock().Hash() == hash {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("head mismatch: have #%d [%x], want [%x]", chain.CurrentBlock().NumberU64(), chain.CurrentBlock().Hash(), hash)
+}
+
+// Tests that a follower catches up with its leader, keeps up with new blocks and
+// follows the leader across reorgs.
+func TestFollowLeader(t *testing.T)       { testFollowLeader(t, VerifyFull) }
+func TestFollowLeaderNoSeal(t *testing.T) { testFollowLeader(t, VerifyNoSeal) }
+
+func testFollowLeader(t *testing.T, verify VerifyMode) {
+	leader, genesis := newTestChain(t)
+	defer leader.Stop()
+
+	gendb, _ := ethdb.NewMemDatabase()
+	new(core.Genesis).MustCommit(gendb)
+
+	main := makeBlocks(gendb, genesis, 20, 1)
+	if _, err := leader.InsertChain(main[:10]); err != nil {
+		t.Fatalf("failed to import leader chain: %v", err)
+	}
+	server := rpc.NewServer()
+	if err := server.RegisterName("replica", NewLeaderAPI(leader, "secret")); err != nil {
+		t.Fatalf("failed to register leader API: %v", err)
+	}
+	defer server.Stop()
+
+	follower, _ := newTestChain(t)
+	defer follower.Stop()
+
+	f := NewFollower(follower, "inproc", "secret", verify)
+	f.dial = func() (*rpc.Client, error) { return rpc.DialInProc(server), nil }
+	f.Start()
+	defer f.Stop()
+
+	// Ensure the follower catches up and then tracks the leader
+	waitHead(t, follower, main[9].Hash())
+	if _, err := leader.InsertChain(main[10:]); err != nil {
+		t.Fatalf("failed to extend leader chain: %v", err)
+	}
+	waitHead(t, follower, main[19].Hash())
+
+	// Reorg the leader onto a longer fork and ensure the follower moves along
+	fork := makeBlocks(gendb, main[14], 10, 2)
+	if _, err := leader.InsertChain(fork); err != nil {
+		t.Fatalf("failed to import leader fork: %v", err)
+	}
+	waitHead(t, follower, fork[9].Hash())
+}
+
+// Tests that the leader rejects followers presenting an invalid secret.
+func TestLeaderAuthentication(t *testing.T) {
+	leader, genesis := newTestChain(t)
+	defer leader.Stop()
+
+	api := NewLeaderAPI(leader, "secret")
+	if _, err := api.Blocks("wrong", genesis.Hash(), hexutil.Uint64(1)); err != errUnauthorized {
+		t.Errorf("invalid secret error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+	if _, err := api.Blocks("secret", common.Hash{1}, hexutil.Uint64(1)); err != errUnknownHead {
+		t.Errorf("unknown head error mismatch: have %v, want %v", err, errUnknownHead)
+	}
+}
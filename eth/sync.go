@@ -18,10 +18,12 @@ package eth
 
 import (
 	"math/rand"
+	"sort"
 	"sync/atomic"
 	"time"
 
 	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/log"
@@ -52,6 +54,10 @@ func (pm *ProtocolManager) syncTransactions(p *peer) {
 	if len(txs) == 0 {
 		return
 	}
+	// Relay system transactions first so they reach block producers promptly
+	sort.SliceStable(txs, func(i, j int) bool {
+		return core.IsSystemTransaction(txs[i]) && !core.IsSystemTransaction(txs[j])
+	})
 	select {
 	case pm.txsyncCh <- &txsync{p, txs}:
 	case <-pm.quitSync:
@@ -0,0 +1,236 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package txtracker follows locally submitted transactions until they are
+// mined, rebroadcasting and resubmitting the ones which seem to be stuck.
+package txtracker
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/event"
+	"github.com/usechain/go-usechain/log"
+)
+
+const (
+	// maxTracked is the maximum number of transactions tracked at once.
+	maxTracked = 4096
+
+	// retainBlocks is the number of blocks the final status of a mined or
+	// dropped transaction is retained for.
+	retainBlocks = 128
+
+	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+	chainHeadChanSize = 10
+)
+
+// ErrDisabled is returned if transaction tracking is not enabled on the node.
+var ErrDisabled = errors.New("local transaction tracking disabled")
+
+// Status values of tracked transactions.
+const (
+	StatusPending = "pending" // Waiting in the pool for inclusion
+	StatusMined   = "mined"   // Included in the canonical chain
+	StatusDropped = "dropped" // Gone from the pool and could not be resubmitted
+)
+
+// TxPool is the subset of the transaction pool used by the tracker.
+type TxPool interface {
+	Get(hash common.Hash) *types.Transaction
+	AddLocal(tx *types.Transaction) error
+}
+
+// Chain is the subset of the blockchain used by the tracker.
+type Chain interface {
+	CurrentBlock() *types.Block
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// BroadcastFn propagates a transaction to the peers not yet knowing about it,
+// returning the number of peers it was sent to.
+type BroadcastFn func(tx *types.Transaction) int
+
+// Status is the tracking report of a local transaction.
+type Status struct {
+	Hash          common.Hash     `json:"hash"`
+	Status        string          `json:"status"`
+	Submitted     hexutil.Uint64  `json:"submitted"`             // Chain head when tracking started
+	Rebroadcasts  int             `json:"rebroadcasts"`          // Number of times propagated again
+	Resubmissions int             `json:"resubmissions"`         // Number of times reinserted into the pool
+	BlockNumber   *hexutil.Uint64 `json:"blockNumber,omitempty"` // Block including the transaction, if mined
+	Error         string          `json:"error,omitempty"`       // Reason of the drop, if dropped
+}
+
+// tracked is the internal bookkeeping of a single local transaction.
+type tracked struct {
+	tx     *types.Transaction
+	status Status
+
+	broadcast uint64 // Chain head at the last (re)broadcast
+	settled   uint64 // Chain head at which the transaction got mined or dropped
+}
+
+// Tracker monitors local transactions, rebroadcasting them if not mined within
+// a number of blocks and resubmitting them if they vanished from the pool.
+type Tracker struct {
+	pool      TxPool
+	chain     Chain
+	db        ethdb.Database
+	broadcast BroadcastFn
+	blocks    uint64 // Blocks without inclusion after which to rebroadcast
+
+	txs  map[common.Hash]*tracked
+	lock sync.RWMutex
+
+	headCh  chan core.ChainHeadEvent
+	headSub event.Subscription
+	wg      sync.WaitGroup
+}
+
+// New creates a local transaction tracker, rebroadcasting transactions not
+// mined within the given number of blocks.
+func New(pool TxPool, chain Chain, db ethdb.Database, broadcast BroadcastFn, blocks uint64) *Tracker {
+	return &Tracker{
+		pool:      pool,
+		chain:     chain,
+		db:        db,
+		broadcast: broadcast,
+		blocks:    blocks,
+		txs:       make(map[common.Hash]*tracked),
+	}
+}
+
+// Start begins following the chain head to check up on tracked transactions.
+func (t *Tracker) Start() {
+	t.headCh = make(chan core.ChainHeadEvent, chainHeadChanSize)
+	t.headSub = t.chain.SubscribeChainHeadEvent(t.headCh)
+
+	t.wg.Add(1)
+	go t.loop()
+
+	log.Info("Local transaction tracker started", "blocks", t.blocks)
+}
+
+// Stop terminates the tracker.
+func (t *Tracker) Stop() {
+	t.headSub.Unsubscribe()
+	t.wg.Wait()
+
+	log.Info("Local transaction tracker stopped")
+}
+
+// Track starts tracking a local transaction. Already tracked transactions are
+// left untouched.
+func (t *Tracker) Track(tx *types.Transaction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	hash := tx.Hash()
+	if _, ok := t.txs[hash]; ok {
+		return
+	}
+	if len(t.txs) >= maxTracked {
+		log.Debug("Local transaction tracker full", "hash", hash)
+		return
+	}
+	head := t.chain.CurrentBlock().NumberU64()
+	t.txs[hash] = &tracked{
+		tx:        tx,
+		status:    Status{Hash: hash, Status: StatusPending, Submitted: hexutil.Uint64(head)},
+		broadcast: head,
+	}
+}
+
+// Status returns the tracking report of a transaction, or nil if the tracker
+// does not know about it.
+func (t *Tracker) Status(hash common.Hash) *Status {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	entry, ok := t.txs[hash]
+	if !ok {
+		return nil
+	}
+	status := entry.status
+	return &status
+}
+
+// loop checks up on the tracked transactions on every new chain head.
+func (t *Tracker) loop() {
+	defer t.wg.Done()
+
+	for {
+		select {
+		case ev := <-t.headCh:
+			t.update(ev.Block.NumberU64())
+		case <-t.headSub.Err():
+			return
+		}
+	}
+}
+
+// update refreshes the status of every tracked transaction against the chain
+// head, rebroadcasting stale ones and resubmitting the ones that vanished.
+func (t *Tracker) update(head uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for hash, entry := range t.txs {
+		// Forget about transactions settled long enough ago
+		if entry.status.Status != StatusPending && head >= entry.settled+retainBlocks {
+			delete(t.txs, hash)
+			continue
+		}
+		if entry.status.Status == StatusDropped {
+			continue
+		}
+		// Check inclusion, reverting mined transactions reorged out
+		if blockHash, number, _ := core.GetTxLookupEntry(t.db, hash); blockHash != (common.Hash{}) {
+			if entry.status.Status != StatusMined {
+				mined := hexutil.Uint64(number)
+				entry.status.Status, entry.status.BlockNumber, entry.settled = StatusMined, &mined, head
+			}
+			continue
+		}
+		if entry.status.Status == StatusMined {
+			entry.status.Status, entry.status.BlockNumber, entry.broadcast = StatusPending, nil, head
+		}
+		// Still pending, resubmit if the pool lost it or rebroadcast if stuck
+		if t.pool.Get(hash) == nil {
+			if err := t.pool.AddLocal(entry.tx); err != nil {
+				log.Debug("Local transaction dropped", "hash", hash, "err", err)
+				entry.status.Status, entry.status.Error, entry.settled = StatusDropped, err.Error(), head
+				continue
+			}
+			log.Debug("Resubmitted local transaction", "hash", hash)
+			entry.status.Resubmissions++
+			entry.broadcast = head
+			continue
+		}
+		if head >= entry.broadcast+t.blocks {
+			peers := t.broadcast(entry.tx)
+			log.Debug("Rebroadcast local transaction", "hash", hash, "peers", peers)
+			entry.status.Rebroadcasts++
+			entry.broadcast = head
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package txtracker
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/event"
+)
+
+// testPool is a mock transaction pool which can be made to lose transactions.
+type testPool struct {
+	txs    map[common.Hash]*types.Transaction
+	reject error
+}
+
+func (p *testPool) Get(hash common.Hash) *types.Transaction { return p.txs[hash] }
+
+func (p *testPool) AddLocal(tx *types.Transaction) error {
+	if p.reject != nil {
+		return p.reject
+	}
+	p.txs[tx.Hash()] = tx
+	return nil
+}
+
+// testChain is a mock chain with a settable head.
+type testChain struct {
+	head *types.Block
+	feed event.Feed
+}
+
+func (c *testChain) CurrentBlock() *types.Block { return c.head }
+
+func (c *testChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	return c.feed.Subscribe(ch)
+}
+
+func newTestTracker(blocks uint64) (*Tracker, *testPool, ethdb.Database, *int) {
+	var (
+		db, _      = ethdb.NewMemDatabase()
+		pool       = &testPool{txs: make(map[common.Hash]*types.Transaction)}
+		chain      = &testChain{head: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)})}
+		broadcasts = new(int)
+	)
+	broadcast := func(tx *types.Transaction) int {
+		*broadcasts++
+		return 1
+	}
+	return New(pool, chain, db, broadcast, blocks), pool, db, broadcasts
+}
+
+// Tests that stuck transactions are rebroadcast after the configured number of
+// blocks, and that lost ones are resubmitted to the pool.
+func TestRebroadcast(t *testing.T) {
+	tracker, pool, _, broadcasts := newTestTracker(3)
+
+	tx := types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	pool.AddLocal(tx)
+	tracker.Track(tx)
+
+	for head := uint64(1); head <= 6; head++ {
+		tracker.update(head)
+	}
+	if *broadcasts != 2 {
+		t.Errorf("rebroadcast count mismatch: have %d, want %d", *broadcasts, 2)
+	}
+	delete(pool.txs, tx.Hash())
+	tracker.update(7)
+
+	status := tracker.Status(tx.Hash())
+	if status.Status != StatusPending || status.Rebroadcasts != 2 || status.Resubmissions != 1 {
+		t.Errorf("status mismatch: have %+v", status)
+	}
+	if pool.Get(tx.Hash()) == nil {
+		t.Errorf("lost transaction not resubmitted")
+	}
+	// Transactions which can't be resubmitted are dropped
+	delete(pool.txs, tx.Hash())
+	pool.reject = errors.New("nonce too low")
+	tracker.update(8)
+
+	if status := tracker.Status(tx.Hash()); status.Status != StatusDropped || status.Error != pool.reject.Error() {
+		t.Errorf("status mismatch: have %+v", status)
+	}
+	// Settled transactions are eventually forgotten
+	tracker.update(8 + retainBlocks)
+	if status := tracker.Status(tx.Hash()); status != nil {
+		t.Errorf("settled transaction retained: %+v", status)
+	}
+}
+
+// Tests that mined transactions are reported along with their block, and are
+// reverted to pending when reorged out.
+func TestMined(t *testing.T) {
+	tracker, pool, db, broadcasts := newTestTracker(1)
+
+	tx := types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+	pool.AddLocal(tx)
+	tracker.Track(tx)
+
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1)}, []*types.Transaction{tx}, nil, nil)
+	if err := core.WriteTxLookupEntries(db, block); err != nil {
+		t.Fatalf("failed to write lookup entries: %v", err)
+	}
+	tracker.update(1)
+
+	status := tracker.Status(tx.Hash())
+	if status.Status != StatusMined || status.BlockNumber == nil || uint64(*status.BlockNumber) != 1 {
+		t.Errorf("status mismatch: have %+v", status)
+	}
+	if *broadcasts != 0 {
+		t.Errorf("mined transaction rebroadcast")
+	}
+	core.DeleteTxLookupEntry(db, tx.Hash())
+	tracker.update(2)
+
+	if status := tracker.Status(tx.Hash()); status.Status != StatusPending || status.BlockNumber != nil {
+		t.Errorf("status mismatch after reorg: have %+v", status)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package clone streams a consistent snapshot of a chain database between two
+// nodes over HTTP, allowing new nodes to be stood up from a running one without
+// copying the database files by hand.
+//
+// The exporting side serves the entries of a database snapshot in ascending key
+// order, each RLP encoded as a [key, value] list and the stream terminated by an
+// empty list. Importers authenticate with a shared secret and may resume an
+// interrupted transfer after the last key they stored, as long as the snapshot
+// served is still the same one.
+package clone
+
+import (
+	"errors"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/crypto"
+)
+
+const (
+	// idHeader is the HTTP header carrying the identifier of the served snapshot.
+	idHeader = "X-Snapshot-Id"
+
+	// fromParam is the query parameter holding the last key already imported,
+	// the stream resuming after it.
+	fromParam = "from"
+
+	// idParam is the query parameter holding the snapshot identifier a resumed
+	// import expects.
+	idParam = "id"
+)
+
+var (
+	errUnauthorized    = errors.New("unauthorized snapshot request")
+	errSnapshotChanged = errors.New("exported snapshot changed since the import started")
+	errNoChain         = errors.New("database contains no chain")
+	errNotEmpty        = errors.New("database already contains a chain")
+)
+
+// progressKey tracks the progress of an unfinished import in the destination
+// database. It is never exported.
+var progressKey = []byte("SnapshotImportProgress")
+
+// snapshotID identifies the contents of a chain database by its head markers,
+// which stay put as long as the database isn't modified by a running node.
+func snapshotID(db core.DatabaseReader) (common.Hash, error) {
+	var (
+		header = core.GetHeadHeaderHash(db)
+		block  = core.GetHeadBlockHash(db)
+		fast   = core.GetHeadFastBlockHash(db)
+	)
+	if header == (common.Hash{}) {
+		return common.Hash{}, errNoChain
+	}
+	return crypto.Keccak256Hash(header[:], block[:], fast[:]), nil
+}
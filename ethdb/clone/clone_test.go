@@ -0,0 +1,202 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package clone
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+const testSecret = "secret"
+
+func init() {
+	retryInterval = 10 * time.Millisecond
+}
+
+// newTestDatabase creates a database with a chain head and a number of entries.
+func newTestDatabase(entries int) *ethdb.MemDatabase {
+	db, _ := ethdb.NewMemDatabase()
+	core.WriteHeadHeaderHash(db, common.HexToHash("0x01"))
+	for i := 0; i < entries; i++ {
+		db.Put([]byte(fmt.Sprintf("key-%05d", i)), make([]byte, 1024))
+	}
+	return db
+}
+
+// checkClone verifies that a database contains exactly the same entries as the
+// original one.
+func checkClone(t *testing.T, have, want *ethdb.MemDatabase) {
+	if have.Len() != want.Len() {
+		t.Fatalf("entry count mismatch: have %d, want %d", have.Len(), want.Len())
+	}
+	for _, key := range want.Keys() {
+		value, _ := want.Get(key)
+		if cloned, err := have.Get(key); err != nil || string(cloned) != string(value) {
+			t.Fatalf("entry %q mismatch: have %x, %v; want %x", key, cloned, err, value)
+		}
+	}
+}
+
+// Tests that a database is cloned completely and remains consistent even if the
+// source is modified while being exported.
+func TestImport(t *testing.T) {
+	source := newTestDatabase(1000)
+	server, err := NewServer(source, testSecret)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer server.Close()
+
+	want := newTestDatabase(1000)
+	source.Put([]byte("late"), []byte("entry"))
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	db, _ := ethdb.NewMemDatabase()
+	if err := Import(context.Background(), httpsrv.URL, testSecret, db); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	checkClone(t, db, want)
+}
+
+// Tests that imports presenting the wrong secret are rejected.
+func TestImportUnauthorized(t *testing.T) {
+	server, err := NewServer(newTestDatabase(10), testSecret)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer server.Close()
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	db, _ := ethdb.NewMemDatabase()
+	if err := Import(context.Background(), httpsrv.URL, "wrong", db); err != errUnauthorized {
+		t.Fatalf("import error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+	if db.Len() != 0 {
+		t.Fatalf("unauthorized import stored %d entries", db.Len())
+	}
+}
+
+// Tests that a database already containing a chain is not overwritten.
+func TestImportNotEmpty(t *testing.T) {
+	server, err := NewServer(newTestDatabase(10), testSecret)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer server.Close()
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	if err := Import(context.Background(), httpsrv.URL, testSecret, newTestDatabase(1)); err != errNotEmpty {
+		t.Fatalf("import error mismatch: have %v, want %v", err, errNotEmpty)
+	}
+}
+
+// truncatedWriter fails all writes beyond a byte limit, cutting the stream.
+type truncatedWriter struct {
+	http.ResponseWriter
+	limit int
+}
+
+func (w *truncatedWriter) Write(b []byte) (int, error) {
+	if len(b) > w.limit {
+		n, _ := w.ResponseWriter.Write(b[:w.limit])
+		w.limit = 0
+		return n, errors.New("connection cut")
+	}
+	w.limit -= len(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// Tests that interrupted transfers are resumed where they left off.
+func TestImportResume(t *testing.T) {
+	source := newTestDatabase(1000)
+	server, err := NewServer(source, testSecret)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer server.Close()
+
+	var (
+		lock     sync.Mutex
+		requests []string
+	)
+	httpsrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests = append(requests, r.URL.RawQuery)
+		cut := len(requests) < 3
+		lock.Unlock()
+
+		if cut {
+			w = &truncatedWriter{ResponseWriter: w, limit: 300 * 1024}
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer httpsrv.Close()
+
+	db, _ := ethdb.NewMemDatabase()
+	if err := Import(context.Background(), httpsrv.URL, testSecret, db); err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	checkClone(t, db, newTestDatabase(1000))
+
+	if len(requests) != 3 {
+		t.Fatalf("request count mismatch: have %d, want 3", len(requests))
+	}
+	if requests[0] != "" {
+		t.Errorf("first request resumed: %s", requests[0])
+	}
+	for i, query := range requests[1:] {
+		if query == "" {
+			t.Errorf("request %d restarted the transfer", i+1)
+		}
+	}
+}
+
+// Tests that an import isn't resumed from a different snapshot than the one it
+// started with.
+func TestImportSnapshotChanged(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	storeProgress(db, &progress{ID: common.HexToHash("0xdead"), Last: []byte("key-00100"), Entries: 101})
+
+	server, err := NewServer(newTestDatabase(10), testSecret)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	defer server.Close()
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	if err := Import(context.Background(), httpsrv.URL, testSecret, db); err != errSnapshotChanged {
+		t.Fatalf("import error mismatch: have %v, want %v", err, errSnapshotChanged)
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package clone
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+var (
+	// retryInterval is the time to wait before resuming a failed transfer.
+	retryInterval = 5 * time.Second
+
+	// maxRetries is the number of consecutive attempts failing without any
+	// progress after which an import is abandoned.
+	maxRetries = 10
+)
+
+// progress is the state of an unfinished import, persisted together with every
+// batch of imported entries.
+type progress struct {
+	ID      common.Hash // Identifier of the snapshot being imported
+	Last    []byte      // Last key imported, meaningless if no entries yet
+	Entries uint64      // Number of entries imported
+}
+
+// Import clones the database snapshot served at the given URL into db, which
+// must not contain a chain yet. An import interrupted by network failures is
+// resumed automatically, and one interrupted by terminating the process can be
+// resumed by calling Import again, as long as the exporting node still serves
+// the same snapshot.
+//
+// The database must not be used by a node until the import completes.
+func Import(ctx context.Context, endpoint string, secret string, db ethdb.Database) error {
+	prog, err := loadProgress(db)
+	if err != nil {
+		return err
+	}
+	if prog == nil {
+		if core.GetHeadHeaderHash(db) != (common.Hash{}) {
+			return errNotEmpty
+		}
+		prog = new(progress)
+	} else {
+		log.Info("Resuming database snapshot import", "id", prog.ID, "entries", prog.Entries)
+	}
+	var (
+		client   = new(http.Client)
+		failures int
+	)
+	for {
+		entries := prog.Entries
+
+		done, err := fetch(ctx, client, endpoint, secret, db, prog)
+		if done {
+			if err := db.Delete(progressKey); err != nil {
+				return err
+			}
+			log.Info("Imported database snapshot", "id", prog.ID, "entries", prog.Entries)
+			return nil
+		}
+		switch {
+		case err == errUnauthorized, err == errSnapshotChanged, ctx.Err() != nil:
+			return err
+		case prog.Entries > entries:
+			failures = 0
+		default:
+			failures++
+		}
+		if failures >= maxRetries {
+			return fmt.Errorf("snapshot import failed %d times without progress: %v", failures, err)
+		}
+		log.Warn("Database snapshot transfer interrupted, resuming", "entries", prog.Entries, "err", err)
+		select {
+		case <-time.After(retryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// fetch requests the remainder of the snapshot from the exporter and stores it
+// in the database, keeping the import progress up to date.
+func fetch(ctx context.Context, client *http.Client, endpoint string, secret string, db ethdb.Database, prog *progress) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	query := url.Values{}
+	if prog.ID != (common.Hash{}) {
+		query.Set(idParam, prog.ID.Hex())
+	}
+	if prog.Entries > 0 {
+		query.Set(fromParam, hexutil.Encode(prog.Last))
+	}
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Authorization", "Bearer "+secret)
+
+	res, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return false, errUnauthorized
+	case http.StatusConflict:
+		return false, errSnapshotChanged
+	default:
+		return false, fmt.Errorf("snapshot export failed: %s", res.Status)
+	}
+	id := common.HexToHash(res.Header.Get(idHeader))
+	if prog.ID == (common.Hash{}) {
+		prog.ID = id
+		log.Info("Importing database snapshot", "id", id)
+	} else if id != prog.ID {
+		return false, errSnapshotChanged
+	}
+	var (
+		stream = rlp.NewStream(bufio.NewReader(res.Body), 0)
+		batch  = db.NewBatch()
+		logged = time.Now()
+	)
+	// commit flushes the imported entries along with the progress made
+	commit := func() error {
+		if err := storeProgress(batch, prog); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	for {
+		size, err := stream.List()
+		if err != nil {
+			return false, commitOr(commit, err)
+		}
+		if size == 0 {
+			break
+		}
+		key, err := stream.Bytes()
+		if err != nil {
+			return false, commitOr(commit, err)
+		}
+		value, err := stream.Bytes()
+		if err != nil {
+			return false, commitOr(commit, err)
+		}
+		if err := stream.ListEnd(); err != nil {
+			return false, commitOr(commit, err)
+		}
+		if err := batch.Put(key, value); err != nil {
+			return false, err
+		}
+		prog.Last, prog.Entries = key, prog.Entries+1
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := commit(); err != nil {
+				return false, err
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Importing database snapshot", "entries", prog.Entries, "key", hexutil.Encode(prog.Last))
+				logged = time.Now()
+			}
+		}
+	}
+	if err := commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// commitOr flushes the entries imported before a stream failure, returning the
+// failure unless flushing failed too.
+func commitOr(commit func() error, err error) error {
+	if cerr := commit(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// loadProgress retrieves the state of an unfinished import, if any.
+func loadProgress(db ethdb.Database) (*progress, error) {
+	blob, err := db.Get(progressKey)
+	if err != nil || len(blob) == 0 {
+		return nil, nil
+	}
+	prog := new(progress)
+	if err := rlp.DecodeBytes(blob, prog); err != nil {
+		return nil, fmt.Errorf("invalid snapshot import progress: %v", err)
+	}
+	return prog, nil
+}
+
+// storeProgress persists the state of an unfinished import.
+func storeProgress(db ethdb.Putter, prog *progress) error {
+	blob, err := rlp.EncodeToBytes(prog)
+	if err != nil {
+		return err
+	}
+	return db.Put(progressKey, blob)
+}
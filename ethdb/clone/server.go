@@ -0,0 +1,165 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package clone
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+// Server serves a snapshot of a database to importing nodes. All requests are
+// streamed from the same snapshot, taken when the server is created, so any
+// number of importers may clone or resume from it while the database changes.
+type Server struct {
+	snap   ethdb.Snapshot
+	id     common.Hash
+	secret []byte
+
+	lock   sync.RWMutex // Protects the snapshot from being released while streamed
+	closed bool
+}
+
+// NewServer takes a snapshot of the database and creates a server streaming it
+// to importers presenting the shared secret.
+func NewServer(db ethdb.Database, secret string) (*Server, error) {
+	snapshotter, ok := db.(ethdb.Snapshotter)
+	if !ok {
+		return nil, fmt.Errorf("database %T doesn't support snapshots", db)
+	}
+	snap, err := snapshotter.NewSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	id, err := snapshotID(snap)
+	if err != nil {
+		snap.Release()
+		return nil, err
+	}
+	return &Server{
+		snap:   snap,
+		id:     id,
+		secret: []byte(secret),
+	}, nil
+}
+
+// ID returns the identifier of the served snapshot.
+func (s *Server) ID() common.Hash {
+	return s.id
+}
+
+// Close waits for the running streams to finish and releases the snapshot.
+func (s *Server) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.closed {
+		s.closed = true
+		s.snap.Release()
+	}
+}
+
+// authorized checks whether the request presents the shared secret.
+func (s *Server) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), s.secret) == 1
+}
+
+// ServeHTTP streams the snapshot entries, starting after the key requested by
+// resuming importers.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.authorized(r) {
+		http.Error(w, errUnauthorized.Error(), http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query()
+	if id := query.Get(idParam); id != "" && common.HexToHash(id) != s.id {
+		http.Error(w, errSnapshotChanged.Error(), http.StatusConflict)
+		return
+	}
+	var from []byte
+	if _, ok := query[fromParam]; ok {
+		var err error
+		if from, err = hexutil.Decode(query.Get(fromParam)); err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s: %v", fromParam, err), http.StatusBadRequest)
+			return
+		}
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	if s.closed {
+		http.Error(w, "snapshot released", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set(idHeader, s.id.Hex())
+
+	logger := log.New("importer", r.RemoteAddr)
+	logger.Info("Streaming database snapshot", "id", s.id, "resume", from != nil)
+
+	var (
+		start   = time.Now()
+		entries uint64
+		size    common.StorageSize
+	)
+	out := bufio.NewWriter(w)
+	it := s.snap.NewIterator(from)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if (from != nil && bytes.Equal(key, from)) || bytes.Equal(key, progressKey) {
+			continue
+		}
+		if err := rlp.Encode(out, [][]byte{key, value}); err != nil {
+			logger.Warn("Database snapshot stream aborted", "entries", entries, "size", size, "err", err)
+			return
+		}
+		entries++
+		size += common.StorageSize(len(key) + len(value))
+	}
+	if err := it.Error(); err != nil {
+		// Cut the stream without terminating it, the importer will retry
+		logger.Error("Failed to iterate database snapshot", "err", err)
+		return
+	}
+	out.Write([]byte{0xc0})
+	if err := out.Flush(); err != nil {
+		logger.Warn("Database snapshot stream aborted", "entries", entries, "size", size, "err", err)
+		return
+	}
+	logger.Info("Streamed database snapshot", "entries", entries, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+}
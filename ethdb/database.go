@@ -29,6 +29,7 @@ import (
 	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 var OpenFileLimit = 64
@@ -55,6 +56,21 @@ type LDBDatabase struct {
 
 // NewLDBDatabase returns a LevelDB wrapped object.
 func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
+	return newLDBDatabase(file, cache, handles, false)
+}
+
+// openLevelDB opens a LevelDB database, implementing Opener.
+func openLevelDB(file string, cache int, handles int, readonly bool) (Database, error) {
+	db, err := newLDBDatabase(file, cache, handles, readonly)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// newLDBDatabase opens a LevelDB database, optionally in read-only mode, in
+// which case all writes are rejected and corruptions are not recovered.
+func newLDBDatabase(file string, cache int, handles int, readonly bool) (*LDBDatabase, error) {
 	logger := log.New("database", file)
 
 	// Ensure we have some minimal caching and file guarantees
@@ -64,7 +80,7 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	if handles < 16 {
 		handles = 16
 	}
-	logger.Info("Allocated cache and file handles", "cache", cache, "handles", handles)
+	logger.Info("Allocated cache and file handles", "cache", cache, "handles", handles, "readonly", readonly)
 
 	// Open the db and recover any potential corruptions
 	db, err := leveldb.OpenFile(file, &opt.Options{
@@ -72,8 +88,9 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 		BlockCacheCapacity:     cache / 2 * opt.MiB,
 		WriteBuffer:            cache / 4 * opt.MiB, // Two of these are used internally
 		Filter:                 filter.NewBloomFilter(10),
+		ReadOnly:               readonly,
 	})
-	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
+	if _, corrupted := err.(*errors.ErrCorrupted); corrupted && !readonly {
 		db, err = leveldb.RecoverFile(file, nil)
 	}
 	// (Re)check for errors and abort if opening of the db failed
@@ -147,6 +164,15 @@ func (db *LDBDatabase) NewIterator() iterator.Iterator {
 	return db.db.NewIterator(nil, nil)
 }
 
+// NewSnapshot creates a consistent view of the current state of the database.
+func (db *LDBDatabase) NewSnapshot() (Snapshot, error) {
+	snap, err := db.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &ldbSnapshot{snap: snap}, nil
+}
+
 func (db *LDBDatabase) Close() {
 	// Stop the metrics collection to avoid internal database races
 	db.quitLock.Lock()
@@ -289,6 +315,11 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
 	return b.db.Write(b.b, nil)
 }
@@ -302,6 +333,26 @@ func (b *ldbBatch) Reset() {
 	b.size = 0
 }
 
+type ldbSnapshot struct {
+	snap *leveldb.Snapshot
+}
+
+func (s *ldbSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snap.Get(key, nil)
+}
+
+func (s *ldbSnapshot) Has(key []byte) (bool, error) {
+	return s.snap.Has(key, nil)
+}
+
+func (s *ldbSnapshot) NewIterator(start []byte) Iterator {
+	return s.snap.NewIterator(&util.Range{Start: start}, nil)
+}
+
+func (s *ldbSnapshot) Release() {
+	s.snap.Release()
+}
+
 type table struct {
 	db     Database
 	prefix string
@@ -354,6 +405,10 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
@@ -44,6 +44,22 @@ func newTestLDB() (*ethdb.LDBDatabase, func()) {
 	}
 }
 
+func newTestPebble() (*ethdb.PebbleDatabase, func()) {
+	dirname, err := ioutil.TempDir(os.TempDir(), "ethdb_test_")
+	if err != nil {
+		panic("failed to create test file: " + err.Error())
+	}
+	db, err := ethdb.NewPebbleDatabase(dirname, 0, 0, false)
+	if err != nil {
+		panic("failed to create test database: " + err.Error())
+	}
+
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dirname)
+	}
+}
+
 var test_values = []string{"", "a", "1251", "\x00123\x00"}
 
 func TestLDB_PutGet(t *testing.T) {
@@ -52,6 +68,12 @@ func TestLDB_PutGet(t *testing.T) {
 	testPutGet(db, t)
 }
 
+func TestPebble_PutGet(t *testing.T) {
+	db, remove := newTestPebble()
+	defer remove()
+	testPutGet(db, t)
+}
+
 func TestMemoryDB_PutGet(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	testPutGet(db, t)
@@ -130,6 +152,12 @@ func TestLDB_ParallelPutGet(t *testing.T) {
 	testParallelPutGet(db, t)
 }
 
+func TestPebble_ParallelPutGet(t *testing.T) {
+	db, remove := newTestPebble()
+	defer remove()
+	testParallelPutGet(db, t)
+}
+
 func TestMemoryDB_ParallelPutGet(t *testing.T) {
 	db, _ := ethdb.NewMemDatabase()
 	testParallelPutGet(db, t)
@@ -190,3 +218,96 @@ func testParallelPutGet(db ethdb.Database, t *testing.T) {
 	}
 	pending.Wait()
 }
+
+func TestLDB_BatchDelete(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testBatchDelete(db, t)
+}
+
+func TestPebble_BatchDelete(t *testing.T) {
+	db, remove := newTestPebble()
+	defer remove()
+	testBatchDelete(db, t)
+}
+
+func TestMemoryDB_BatchDelete(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	testBatchDelete(db, t)
+}
+
+func testBatchDelete(db ethdb.Database, t *testing.T) {
+	for _, k := range []string{"a", "b"} {
+		db.Put([]byte(k), []byte(k))
+	}
+	batch := db.NewBatch()
+	batch.Delete([]byte("a"))
+	batch.Put([]byte("c"), []byte("c"))
+
+	if has, _ := db.Has([]byte("a")); !has {
+		t.Fatalf("entry deleted before the batch was written")
+	}
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	for k, want := range map[string]bool{"a": false, "b": true, "c": true} {
+		if has, _ := db.Has([]byte(k)); has != want {
+			t.Errorf("entry %q: presence mismatch: have %v, want %v", k, has, want)
+		}
+	}
+}
+
+func TestLDB_Snapshot(t *testing.T) {
+	db, remove := newTestLDB()
+	defer remove()
+	testSnapshot(db, t)
+}
+
+func TestPebble_Snapshot(t *testing.T) {
+	db, remove := newTestPebble()
+	defer remove()
+	testSnapshot(db, t)
+}
+
+func TestMemoryDB_Snapshot(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	testSnapshot(db, t)
+}
+
+func testSnapshot(db ethdb.Database, t *testing.T) {
+	for _, k := range []string{"b", "a", "d", "c"} {
+		db.Put([]byte(k), []byte("old"+k))
+	}
+	snap, err := db.(ethdb.Snapshotter).NewSnapshot()
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer snap.Release()
+
+	// Modify the database after the snapshot was taken
+	db.Put([]byte("a"), []byte("new"))
+	db.Put([]byte("bb"), []byte("new"))
+	db.Delete([]byte("c"))
+
+	if data, err := snap.Get([]byte("a")); err != nil || string(data) != "olda" {
+		t.Errorf("get a: have %q, %v; want %q", data, err, "olda")
+	}
+	if has, _ := snap.Has([]byte("bb")); has {
+		t.Errorf("snapshot contains entry added later")
+	}
+	var keys []string
+	it := snap.NewIterator([]byte("b"))
+	for it.Next() {
+		if want := "old" + string(it.Key()); string(it.Value()) != want {
+			t.Errorf("value of %q: have %q, want %q", it.Key(), it.Value(), want)
+		}
+		keys = append(keys, string(it.Key()))
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		t.Fatalf("iteration failed: %v", err)
+	}
+	if fmt.Sprint(keys) != "[b c d]" {
+		t.Errorf("iterated keys mismatch: have %v, want [b c d]", keys)
+	}
+}
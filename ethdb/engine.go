@@ -0,0 +1,264 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/usechain/go-usechain/log"
+)
+
+// Persistent database engines.
+const (
+	EngineLevelDB = "leveldb"
+	EnginePebble  = "pebble"
+)
+
+// DefaultEngine is the engine used to create new databases if none is requested.
+const DefaultEngine = EngineLevelDB
+
+// checkpointAttempts is the number of times a checkpoint of a live database is
+// retried if the database changed while being copied.
+const checkpointAttempts = 3
+
+// errDatabaseChanged is returned if the tables of a database changed while a
+// checkpoint of it was taken.
+var errDatabaseChanged = errors.New("database changed during checkpoint")
+
+// Opener opens a persistent database of a specific engine, creating it if not
+// existing yet (unless opened read-only).
+type Opener func(file string, cache int, handles int, readonly bool) (Database, error)
+
+var (
+	enginesLock sync.RWMutex
+	engines     = map[string]Opener{
+		EngineLevelDB: openLevelDB,
+		EnginePebble:  openPebble,
+	}
+)
+
+// RegisterEngine makes a database engine available to Open.
+func RegisterEngine(name string, opener Opener) {
+	enginesLock.Lock()
+	defer enginesLock.Unlock()
+
+	engines[name] = opener
+}
+
+// Engines returns the names of the database engines supported by this build.
+func Engines() []string {
+	enginesLock.RLock()
+	defer enginesLock.RUnlock()
+
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DetectEngine returns the engine an existing database was created with, or an
+// empty string if there is no database at the given path.
+func DetectEngine(file string) string {
+	// Pebble keeps its options in a dedicated file, LevelDB doesn't
+	if matches, _ := filepath.Glob(filepath.Join(file, "OPTIONS-*")); len(matches) > 0 {
+		return EnginePebble
+	}
+	if _, err := os.Stat(filepath.Join(file, "CURRENT")); err == nil {
+		return EngineLevelDB
+	}
+	return ""
+}
+
+// Open opens a persistent database with the requested engine. An empty engine
+// selects the one an existing database was created with, or DefaultEngine for
+// new ones. As the on-disk formats are incompatible, opening a database with a
+// different engine than it was created with fails.
+//
+// Read-only databases reject all writes and never modify the files on disk. If
+// the database is locked by a running node, a private checkpoint of it is taken
+// and opened instead, giving a consistent view as of the time of opening.
+func Open(engine string, file string, cache int, handles int, readonly bool) (Database, error) {
+	existing := DetectEngine(file)
+	switch {
+	case engine == "" && existing == "":
+		engine = DefaultEngine
+	case engine == "":
+		engine = existing
+	case existing != "" && existing != engine:
+		return nil, fmt.Errorf("database %s was created by %s, cannot open with %s", file, existing, engine)
+	}
+	enginesLock.RLock()
+	opener, ok := engines[engine]
+	enginesLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown database engine %q (supported: %s)", engine, strings.Join(Engines(), ", "))
+	}
+	if !readonly {
+		return opener(file, cache, handles, false)
+	}
+	if existing == "" {
+		return nil, fmt.Errorf("no database to open read-only at %s", file)
+	}
+	db, err := opener(file, cache, handles, true)
+	if err == nil {
+		return db, nil
+	}
+	// Opening failed, most probably the database is in use, try a checkpoint
+	log.Debug("Failed to open database read-only, taking checkpoint", "database", file, "err", err)
+	for i := 0; i < checkpointAttempts; i++ {
+		if db, err = openCheckpoint(opener, file, cache, handles); err == nil {
+			log.Warn("Database in use, opened read-only checkpoint", "database", file)
+			return db, nil
+		}
+		log.Debug("Failed to open database checkpoint", "database", file, "attempt", i, "err", err)
+	}
+	return nil, err
+}
+
+// checkpointDatabase is a read-only database opened from a private copy of a
+// live database, which is deleted when closed.
+type checkpointDatabase struct {
+	Database
+	dir string
+}
+
+// Close closes the checkpoint database and deletes its files.
+func (db *checkpointDatabase) Close() {
+	db.Database.Close()
+	os.RemoveAll(db.dir)
+}
+
+// NewSnapshot creates a consistent view of the checkpoint database, if supported
+// by its engine.
+func (db *checkpointDatabase) NewSnapshot() (Snapshot, error) {
+	snapshotter, ok := db.Database.(Snapshotter)
+	if !ok {
+		return nil, errors.New("database engine doesn't support snapshots")
+	}
+	return snapshotter.NewSnapshot()
+}
+
+// openCheckpoint creates a checkpoint of a live database next to it and opens
+// that read-only.
+func openCheckpoint(opener Opener, file string, cache int, handles int) (Database, error) {
+	dir, err := ioutil.TempDir(filepath.Dir(file), filepath.Base(file)+".readonly-")
+	if err != nil {
+		return nil, err
+	}
+	if err := checkpoint(file, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	db, err := opener(dir, cache, handles, true)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &checkpointDatabase{Database: db, dir: dir}, nil
+}
+
+// checkpoint copies the files of a database into an empty directory. Tables are
+// immutable once written, so they are hard linked when possible, whereas the
+// metadata and write-ahead logs are copied after them, the latter cut to their
+// current length. Both supported engines tolerate a torn log tail.
+//
+// If the set of tables changed while copying, as recorded by the manifest, the
+// copy may be inconsistent and errDatabaseChanged is returned.
+func checkpoint(src, dst string) error {
+	before, err := manifestState(src)
+	if err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	var rest []os.FileInfo
+	for _, file := range files {
+		if !file.Mode().IsRegular() || file.Name() == "LOCK" {
+			continue
+		}
+		if ext := filepath.Ext(file.Name()); ext != ".ldb" && ext != ".sst" {
+			rest = append(rest, file)
+			continue
+		}
+		from, to := filepath.Join(src, file.Name()), filepath.Join(dst, file.Name())
+		if err := os.Link(from, to); err != nil {
+			if err := copyFile(from, to); err != nil {
+				return err
+			}
+		}
+	}
+	for _, file := range rest {
+		if err := copyFile(filepath.Join(src, file.Name()), filepath.Join(dst, file.Name())); err != nil {
+			return err
+		}
+	}
+	after, err := manifestState(src)
+	if err != nil {
+		return err
+	}
+	if after != before {
+		return errDatabaseChanged
+	}
+	return nil
+}
+
+// manifestState returns the name of the current manifest of a database and its
+// size. Both change whenever tables are added or removed.
+func manifestState(dir string) (string, error) {
+	current, err := ioutil.ReadFile(filepath.Join(dir, "CURRENT"))
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(string(current))
+	info, err := os.Stat(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%d", name, info.Size()), nil
+}
+
+// copyFile copies the current contents of a file.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,149 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// Tests that databases are created with the default engine and reopened with
+// the one they were created with, rejecting mismatching requests.
+func TestOpenEngineDetection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb_engine_test_")
+	if err != nil {
+		t.Fatalf("failed to create temporary dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "chaindata")
+
+	if engine := ethdb.DetectEngine(file); engine != "" {
+		t.Fatalf("engine detected for missing database: %s", engine)
+	}
+	if _, err := ethdb.Open("", file, 0, 0, true); err == nil {
+		t.Fatalf("missing database opened read-only")
+	}
+	db, err := ethdb.Open("", file, 0, 0, false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	db.Close()
+
+	if engine := ethdb.DetectEngine(file); engine != ethdb.DefaultEngine {
+		t.Fatalf("engine mismatch: have %s, want %s", engine, ethdb.DefaultEngine)
+	}
+	if _, err := ethdb.Open(ethdb.EnginePebble, file, 0, 0, false); err == nil {
+		t.Fatalf("database opened with a different engine")
+	}
+	if _, err := ethdb.Open("rocksdb", filepath.Join(dir, "other"), 0, 0, false); err == nil {
+		t.Fatalf("database opened with an unknown engine")
+	}
+}
+
+// Tests that Pebble databases are detected as such and can't be reopened with
+// LevelDB.
+func TestOpenPebbleDetection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb_engine_test_")
+	if err != nil {
+		t.Fatalf("failed to create temporary dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "chaindata")
+
+	db, err := ethdb.Open(ethdb.EnginePebble, file, 0, 0, false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	if err := db.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("failed to write database: %v", err)
+	}
+	db.Close()
+
+	if engine := ethdb.DetectEngine(file); engine != ethdb.EnginePebble {
+		t.Fatalf("engine mismatch: have %s, want %s", engine, ethdb.EnginePebble)
+	}
+	if _, err := ethdb.Open(ethdb.EngineLevelDB, file, 0, 0, false); err == nil {
+		t.Fatalf("database opened with a different engine")
+	}
+	db, err = ethdb.Open("", file, 0, 0, true)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	if _, ok := db.(*ethdb.PebbleDatabase); !ok {
+		t.Errorf("database reopened with wrong engine: %T", db)
+	}
+	if value, err := db.Get([]byte("key")); err != nil || !bytes.Equal(value, []byte("value")) {
+		t.Errorf("value mismatch: have %q/%v, want %q", value, err, "value")
+	}
+}
+
+// Tests that read-only databases reject writes, and that databases in use are
+// opened from a checkpoint which is cleaned up on close.
+func TestOpenReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb_readonly_test_")
+	if err != nil {
+		t.Fatalf("failed to create temporary dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "chaindata")
+
+	live, err := ethdb.Open("", file, 0, 0, false)
+	if err != nil {
+		t.Fatalf("failed to create database: %v", err)
+	}
+	if err := live.Put([]byte("key"), []byte("value")); err != nil {
+		t.Fatalf("failed to write database: %v", err)
+	}
+	// Open the live database read-only and check the contents
+	db, err := ethdb.Open("", file, 0, 0, true)
+	if err != nil {
+		t.Fatalf("failed to open live database read-only: %v", err)
+	}
+	if value, err := db.Get([]byte("key")); err != nil || !bytes.Equal(value, []byte("value")) {
+		t.Errorf("value mismatch: have %q/%v, want %q", value, err, "value")
+	}
+	if err := db.Put([]byte("other"), []byte("value")); err == nil {
+		t.Errorf("read-only database accepted write")
+	}
+	db.Close()
+
+	if entries, _ := ioutil.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("checkpoint not cleaned up: %d entries", len(entries))
+	}
+	// Stop the live database and ensure it can be opened read-only directly
+	live.Close()
+
+	db, err = ethdb.Open("", file, 0, 0, true)
+	if err != nil {
+		t.Fatalf("failed to open stopped database read-only: %v", err)
+	}
+	defer db.Close()
+
+	if _, ok := db.(*ethdb.LDBDatabase); !ok {
+		t.Errorf("stopped database opened through checkpoint: %T", db)
+	}
+	if value, err := db.Get([]byte("key")); err != nil || !bytes.Equal(value, []byte("value")) {
+		t.Errorf("value mismatch: have %q/%v, want %q", value, err, "value")
+	}
+}
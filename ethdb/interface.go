@@ -39,8 +39,37 @@ type Database interface {
 // when Write is called. Batch cannot be used concurrently.
 type Batch interface {
 	Putter
+	Delete(key []byte) error
 	ValueSize() int // amount of data in the batch
 	Write() error
 	// Reset resets the batch for reuse
 	Reset()
 }
+
+// Snapshotter is implemented by databases able to provide a consistent view of
+// their contents, unaffected by later writes.
+type Snapshotter interface {
+	NewSnapshot() (Snapshot, error)
+}
+
+// Snapshot is a read-only, point in time view of a database. It must be released
+// after use to free the resources pinned by it.
+type Snapshot interface {
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+
+	// NewIterator iterates over the entries of the snapshot in ascending key
+	// order, starting at the first key not smaller than start.
+	NewIterator(start []byte) Iterator
+	Release()
+}
+
+// Iterator iterates over key/value pairs in ascending key order. The returned
+// slices are only valid until the next call to Next.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
@@ -17,7 +17,9 @@
 package ethdb
 
 import (
+	"bytes"
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/usechain/go-usechain/common"
@@ -96,7 +98,85 @@ func (db *MemDatabase) NewBatch() Batch {
 
 func (db *MemDatabase) Len() int { return len(db.db) }
 
-type kv struct{ k, v []byte }
+// NewSnapshot copies the current contents of the database into a sorted, read
+// only view.
+func (db *MemDatabase) NewSnapshot() (Snapshot, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snap := &memSnapshot{entries: make([]kv, 0, len(db.db))}
+	for key, value := range db.db {
+		snap.entries = append(snap.entries, kv{k: []byte(key), v: value})
+	}
+	sort.Slice(snap.entries, func(i, j int) bool {
+		return bytes.Compare(snap.entries[i].k, snap.entries[j].k) < 0
+	})
+	return snap, nil
+}
+
+type memSnapshot struct {
+	entries []kv
+}
+
+// find returns the index of the first entry with a key not smaller than key.
+func (s *memSnapshot) find(key []byte) int {
+	return sort.Search(len(s.entries), func(i int) bool {
+		return bytes.Compare(s.entries[i].k, key) >= 0
+	})
+}
+
+func (s *memSnapshot) Get(key []byte) ([]byte, error) {
+	if i := s.find(key); i < len(s.entries) && bytes.Equal(s.entries[i].k, key) {
+		return common.CopyBytes(s.entries[i].v), nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (s *memSnapshot) Has(key []byte) (bool, error) {
+	i := s.find(key)
+	return i < len(s.entries) && bytes.Equal(s.entries[i].k, key), nil
+}
+
+func (s *memSnapshot) NewIterator(start []byte) Iterator {
+	return &memIterator{entries: s.entries[s.find(start):], pos: -1}
+}
+
+func (s *memSnapshot) Release() {}
+
+type memIterator struct {
+	entries []kv
+	pos     int
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos < len(it.entries) {
+		it.pos++
+	}
+	return it.pos < len(it.entries)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.entries) {
+		return nil
+	}
+	return it.entries[it.pos].k
+}
+
+func (it *memIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.entries) {
+		return nil
+	}
+	return it.entries[it.pos].v
+}
+
+func (it *memIterator) Error() error { return nil }
+
+func (it *memIterator) Release() { it.pos = len(it.entries) }
+
+type kv struct {
+	k, v []byte
+	del  bool
+}
 
 type memBatch struct {
 	db     *MemDatabase
@@ -105,16 +185,25 @@ type memBatch struct {
 }
 
 func (b *memBatch) Put(key, value []byte) error {
-	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value)})
+	b.writes = append(b.writes, kv{k: common.CopyBytes(key), v: common.CopyBytes(value)})
 	b.size += len(value)
 	return nil
 }
 
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{k: common.CopyBytes(key), del: true})
+	return nil
+}
+
 func (b *memBatch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+			continue
+		}
 		b.db.db[string(kv.k)] = kv.v
 	}
 	return nil
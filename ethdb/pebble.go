@@ -0,0 +1,215 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"github.com/cockroachdb/pebble"
+	"github.com/usechain/go-usechain/log"
+)
+
+func init() {
+	RegisterEngine(EnginePebble, openPebble)
+}
+
+// PebbleDatabase is a persistent database backed by Pebble. Unlike LevelDB, it
+// doesn't stall writes for long periods while compacting.
+type PebbleDatabase struct {
+	fn string     // filename for reporting
+	db *pebble.DB // Pebble instance
+
+	log log.Logger // Contextual logger tracking the database path
+}
+
+// openPebble opens a Pebble database, implementing Opener.
+func openPebble(file string, cache int, handles int, readonly bool) (Database, error) {
+	db, err := NewPebbleDatabase(file, cache, handles, readonly)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// NewPebbleDatabase returns a Pebble wrapped object.
+func NewPebbleDatabase(file string, cache int, handles int, readonly bool) (*PebbleDatabase, error) {
+	logger := log.New("database", file)
+
+	// Ensure we have some minimal caching and file guarantees
+	if cache < 16 {
+		cache = 16
+	}
+	if handles < 16 {
+		handles = 16
+	}
+	logger.Info("Allocated cache and file handles", "engine", EnginePebble, "cache", cache, "handles", handles, "readonly", readonly)
+
+	blockCache := pebble.NewCache(int64(cache) * 1024 * 1024)
+	defer blockCache.Unref()
+
+	db, err := pebble.Open(file, &pebble.Options{
+		Cache:        blockCache,
+		MaxOpenFiles: handles,
+		ReadOnly:     readonly,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &PebbleDatabase{
+		fn:  file,
+		db:  db,
+		log: logger,
+	}, nil
+}
+
+// Path returns the path to the database directory.
+func (db *PebbleDatabase) Path() string {
+	return db.fn
+}
+
+// Put puts the given key / value to the database.
+func (db *PebbleDatabase) Put(key []byte, value []byte) error {
+	return db.db.Set(key, value, pebble.NoSync)
+}
+
+// Has checks whether the key is present in the database.
+func (db *PebbleDatabase) Has(key []byte) (bool, error) {
+	_, closer, err := db.db.Get(key)
+	if err == pebble.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	closer.Close()
+	return true, nil
+}
+
+// Get returns the given key if it's present.
+func (db *PebbleDatabase) Get(key []byte) ([]byte, error) {
+	dat, closer, err := db.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	return append([]byte{}, dat...), nil
+}
+
+// Delete deletes the key from the database.
+func (db *PebbleDatabase) Delete(key []byte) error {
+	return db.db.Delete(key, pebble.NoSync)
+}
+
+// Close flushes and closes the database.
+func (db *PebbleDatabase) Close() {
+	if err := db.db.Close(); err == nil {
+		db.log.Info("Database closed")
+	} else {
+		db.log.Error("Failed to close database", "err", err)
+	}
+}
+
+// NewBatch creates a write-only batch committing into the database.
+func (db *PebbleDatabase) NewBatch() Batch {
+	return &pebbleBatch{db: db.db, b: db.db.NewBatch()}
+}
+
+// NewSnapshot creates a consistent view of the current state of the database.
+func (db *PebbleDatabase) NewSnapshot() (Snapshot, error) {
+	return &pebbleSnapshot{snap: db.db.NewSnapshot()}, nil
+}
+
+type pebbleSnapshot struct {
+	snap *pebble.Snapshot
+}
+
+func (s *pebbleSnapshot) Get(key []byte) ([]byte, error) {
+	dat, closer, err := s.snap.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	return append([]byte{}, dat...), nil
+}
+
+func (s *pebbleSnapshot) Has(key []byte) (bool, error) {
+	_, closer, err := s.snap.Get(key)
+	if err == pebble.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	closer.Close()
+	return true, nil
+}
+
+func (s *pebbleSnapshot) NewIterator(start []byte) Iterator {
+	return &pebbleIterator{it: s.snap.NewIter(&pebble.IterOptions{LowerBound: start})}
+}
+
+func (s *pebbleSnapshot) Release() {
+	s.snap.Close()
+}
+
+// pebbleIterator adapts a Pebble iterator to the LevelDB style of positioning
+// before the first entry until Next is called.
+type pebbleIterator struct {
+	it    *pebble.Iterator
+	moved bool
+}
+
+func (it *pebbleIterator) Next() bool {
+	if !it.moved {
+		it.moved = true
+		return it.it.First()
+	}
+	return it.it.Next()
+}
+
+func (it *pebbleIterator) Key() []byte   { return it.it.Key() }
+func (it *pebbleIterator) Value() []byte { return it.it.Value() }
+func (it *pebbleIterator) Error() error  { return it.it.Error() }
+func (it *pebbleIterator) Release()      { it.it.Close() }
+
+type pebbleBatch struct {
+	db   *pebble.DB
+	b    *pebble.Batch
+	size int
+}
+
+func (b *pebbleBatch) Put(key, value []byte) error {
+	b.size += len(value)
+	return b.b.Set(key, value, nil)
+}
+
+func (b *pebbleBatch) Delete(key []byte) error {
+	return b.b.Delete(key, nil)
+}
+
+func (b *pebbleBatch) Write() error {
+	return b.db.Apply(b.b, pebble.NoSync)
+}
+
+func (b *pebbleBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *pebbleBatch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
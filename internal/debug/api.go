@@ -22,6 +22,7 @@ package debug
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"os/user"
@@ -29,6 +30,7 @@ import (
 	"runtime"
 	"runtime/debug"
 	"runtime/pprof"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -62,6 +64,32 @@ func (*HandlerT) Vmodule(pattern string) error {
 	return glogger.Vmodule(pattern)
 }
 
+// SetLogLevel changes the verbosity of a single module at runtime, leaving the
+// rest of the vmodule rules untouched. The level is either a name (e.g. "debug")
+// or a number as accepted by Verbosity, and applies to the module exactly, even
+// if lower than the verbosity ceiling. An empty pattern sets the ceiling instead.
+func (*HandlerT) SetLogLevel(pattern string, level string) error {
+	lvl, err := log.LvlFromString(strings.ToLower(level))
+	if err != nil {
+		n, nerr := strconv.Atoi(level)
+		if nerr != nil || n < 0 {
+			return fmt.Errorf("invalid log level %q", level)
+		}
+		lvl = log.Lvl(n)
+	}
+	if pattern == "" {
+		glogger.Verbosity(lvl)
+		return nil
+	}
+	return glogger.SetVmodule(pattern, lvl)
+}
+
+// ResetLogLevel drops the runtime verbosity rule of a single module, so that it
+// logs according to the verbosity ceiling again.
+func (*HandlerT) ResetLogLevel(pattern string) error {
+	return glogger.ResetVmodule(pattern)
+}
+
 // BacktraceAt sets the log backtrace location. See package log for details on
 // the pattern syntax.
 func (*HandlerT) BacktraceAt(location string) error {
@@ -0,0 +1,52 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package debug
+
+import "testing"
+
+// Tests that module log levels can be set to any level, including crit, and be
+// reset afterwards.
+func TestSetResetLogLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		rules string
+	}{
+		{"crit", "eth/*=0"},
+		{"0", "eth/*=0"},
+		{"DEBUG", "eth/*=4"},
+		{"5", "eth/*=5"},
+	}
+	for _, tt := range tests {
+		if err := Handler.SetLogLevel("eth/*", tt.level); err != nil {
+			t.Fatalf("level %q: failed to set log level: %v", tt.level, err)
+		}
+		if rules := glogger.Rules(); rules != tt.rules {
+			t.Errorf("level %q: rules mismatch: have %q, want %q", tt.level, rules, tt.rules)
+		}
+	}
+	if err := Handler.ResetLogLevel("eth/*"); err != nil {
+		t.Fatalf("failed to reset log level: %v", err)
+	}
+	if rules := glogger.Rules(); rules != "" {
+		t.Errorf("reset rules mismatch: have %q, want none", rules)
+	}
+	for _, level := range []string{"", "-1", "loud"} {
+		if err := Handler.SetLogLevel("eth/*", level); err == nil {
+			t.Errorf("level %q: expected failure", level)
+		}
+	}
+}
@@ -48,6 +48,26 @@ var (
 		Usage: "Request a stack trace at a specific logging statement (e.g. \"block.go:271\")",
 		Value: "",
 	}
+	logFormatFlag = cli.StringFlag{
+		Name:  "log.format",
+		Usage: "Log output format (terminal, logfmt, json)",
+		Value: "terminal",
+	}
+	logFileFlag = cli.StringFlag{
+		Name:  "log.file",
+		Usage: "Write logs to the given file instead of the standard error",
+		Value: "",
+	}
+	logRotateSizeFlag = cli.IntFlag{
+		Name:  "log.rotate.size",
+		Usage: "Rotate the log file once it grows beyond the given size in megabytes (0 = disabled)",
+		Value: 0,
+	}
+	logRotateBackupsFlag = cli.IntFlag{
+		Name:  "log.rotate.backups",
+		Usage: "Number of rotated log files to retain",
+		Value: 5,
+	}
 	debugFlag = cli.BoolFlag{
 		Name:  "debug",
 		Usage: "Prepends log messages with call-site location (file and line number)",
@@ -88,6 +108,7 @@ var (
 // Flags holds all command-line flags required for debugging.
 var Flags = []cli.Flag{
 	verbosityFlag, vmoduleFlag, backtraceAtFlag, debugFlag,
+	logFormatFlag, logFileFlag, logRotateSizeFlag, logRotateBackupsFlag,
 	pprofFlag, pprofAddrFlag, pprofPortFlag,
 	memprofilerateFlag, blockprofilerateFlag, cpuprofileFlag, traceFlag,
 }
@@ -107,6 +128,9 @@ func init() {
 // It should be called as early as possible in the program.
 func Setup(ctx *cli.Context) error {
 	// logging
+	if err := setupOutput(ctx); err != nil {
+		return err
+	}
 	log.PrintOrigins(ctx.GlobalBool(debugFlag.Name))
 	glogger.Verbosity(log.Lvl(ctx.GlobalInt(verbosityFlag.Name)))
 	glogger.Vmodule(ctx.GlobalString(vmoduleFlag.Name))
@@ -144,6 +168,46 @@ func Setup(ctx *cli.Context) error {
 	return nil
 }
 
+// setupOutput replaces the default terminal log output if a different format
+// or a log file was requested.
+func setupOutput(ctx *cli.Context) error {
+	format, path := ctx.GlobalString(logFormatFlag.Name), ctx.GlobalString(logFileFlag.Name)
+	if format == logFormatFlag.Value && path == "" {
+		return nil
+	}
+	var (
+		fmtr   log.Format
+		output = io.Writer(os.Stderr)
+	)
+	switch format {
+	case "terminal":
+		// Colors are only used on an interactive standard error
+		usecolor := path == "" && term.IsTty(os.Stderr.Fd()) && os.Getenv("TERM") != "dumb"
+		if usecolor {
+			output = colorable.NewColorableStderr()
+		}
+		fmtr = log.TerminalFormat(usecolor)
+	case "logfmt":
+		fmtr = log.LogfmtFormat()
+	case "json":
+		fmtr = log.JsonFormat()
+	default:
+		return fmt.Errorf("unknown log format %q, want terminal, logfmt or json", format)
+	}
+	handler := log.StreamHandler(output, fmtr)
+	if path != "" {
+		var (
+			size = int64(ctx.GlobalInt(logRotateSizeFlag.Name)) * 1024 * 1024
+			err  error
+		)
+		if handler, err = log.RotatingFileHandler(path, size, ctx.GlobalInt(logRotateBackupsFlag.Name), fmtr); err != nil {
+			return err
+		}
+	}
+	glogger.SetHandler(handler)
+	return nil
+}
+
 // Exit stops all running profiles, flushing their output to the
 // respective file.
 func Exit() {
@@ -37,6 +37,9 @@ import (
 	"github.com/usechain/go-usechain/core/vm"
 	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/crypto/ecies"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/eth/txtracker"
+	"github.com/usechain/go-usechain/contracts/delegation"
 	"github.com/usechain/go-usechain/contracts/minerlist"
 	"github.com/usechain/go-usechain/log"
 	"github.com/usechain/go-usechain/p2p"
@@ -74,7 +77,8 @@ func NewPublicEthereumAPI(b Backend) *PublicEthereumAPI {
 	return &PublicEthereumAPI{b}
 }
 
-// GasPrice returns a suggestion for a gas price.
+// GasPrice returns a suggestion for a gas price, based on the prices paid in
+// recent blocks.
 func (s *PublicEthereumAPI) GasPrice(ctx context.Context) (*big.Int, error) {
 	return s.b.SuggestPrice(ctx)
 }
@@ -108,6 +112,153 @@ func (s *PublicEthereumAPI) Syncing() (interface{}, error) {
 	}, nil
 }
 
+// PublicUsechainAPI provides Usechain specific extensions to the standard
+// Ethereum APIs.
+type PublicUsechainAPI struct {
+	b Backend
+}
+
+// NewPublicUsechainAPI creates a new Usechain extension API.
+func NewPublicUsechainAPI(b Backend) *PublicUsechainAPI {
+	return &PublicUsechainAPI{b}
+}
+
+// FeeHistoryResult is the fee statistics of a range of blocks.
+type FeeHistoryResult struct {
+	OldestBlock       *hexutil.Big     `json:"oldestBlock"`
+	Reward            [][]*hexutil.Big `json:"reward,omitempty"`
+	GasUsedRatio      []float64        `json:"gasUsedRatio"`
+	SuggestedGasPrice *hexutil.Big     `json:"suggestedGasPrice"`
+}
+
+// FeeHistory returns the gas prices paid at the given percentiles and the gas
+// utilisation of up to blockCount blocks ending at lastBlock, together with the
+// node's current gas price suggestion.
+func (s *PublicUsechainAPI) FeeHistory(ctx context.Context, blockCount hexutil.Uint64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*FeeHistoryResult, error) {
+	oldest, rewards, ratios, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	price, err := s.b.SuggestPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := &FeeHistoryResult{
+		OldestBlock:       (*hexutil.Big)(oldest),
+		GasUsedRatio:      ratios,
+		SuggestedGasPrice: (*hexutil.Big)(price),
+	}
+	if rewards != nil {
+		result.Reward = make([][]*hexutil.Big, len(rewards))
+		for i, block := range rewards {
+			result.Reward[i] = make([]*hexutil.Big, len(block))
+			for j, reward := range block {
+				result.Reward[i][j] = (*hexutil.Big)(reward)
+			}
+		}
+	}
+	return result, nil
+}
+
+// ReplaceTransaction re-signs a pending transaction originating from one of the
+// local accounts with a higher gas price, replacing it in the transaction pool.
+// If no gas price is given, the larger of the minimum accepted replacement price
+// and the current gas price suggestion is used.
+func (s *PublicUsechainAPI) ReplaceTransaction(ctx context.Context, hash common.Hash, gasPrice *hexutil.Big) (common.Hash, error) {
+	tx, from, err := s.pendingTransaction(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	price, err := s.replacementPrice(ctx, tx, (*big.Int)(gasPrice))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var replacement *types.Transaction
+	if tx.To() == nil {
+		replacement = types.NewContractCreation(tx.Nonce(), tx.Value(), tx.Gas(), price, tx.Data())
+	} else {
+		replacement = types.NewTransaction(tx.Nonce(), *tx.To(), tx.Value(), tx.Gas(), price, tx.Data())
+	}
+	signed, err := signTransaction(s.b, from, replacement)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, signed)
+}
+
+// CancelTransaction replaces a pending transaction originating from one of the
+// local accounts with an empty transfer to the sender itself, using the larger
+// of the minimum accepted replacement price and the current price suggestion.
+func (s *PublicUsechainAPI) CancelTransaction(ctx context.Context, hash common.Hash) (common.Hash, error) {
+	tx, from, err := s.pendingTransaction(hash)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	price, err := s.replacementPrice(ctx, tx, nil)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	signed, err := signTransaction(s.b, from, types.NewTransaction(tx.Nonce(), from, new(big.Int), params.TxGas, price, nil))
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return submitTransaction(ctx, s.b, signed)
+}
+
+// TrackTransaction reports whether a local transaction was mined, is pending or
+// got dropped, along with the rebroadcasts and resubmissions done to get it
+// included. Transactions still in the pool start being tracked when queried.
+func (s *PublicUsechainAPI) TrackTransaction(hash common.Hash) (*txtracker.Status, error) {
+	status, err := s.b.TrackTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, fmt.Errorf("transaction %#x not tracked", hash)
+	}
+	return status, nil
+}
+
+// pendingTransaction retrieves a transaction from the pool along with its sender.
+func (s *PublicUsechainAPI) pendingTransaction(hash common.Hash) (*types.Transaction, common.Address, error) {
+	tx := s.b.GetPoolTransaction(hash)
+	if tx == nil {
+		return nil, common.Address{}, fmt.Errorf("transaction %#x not pending", hash)
+	}
+	var signer types.Signer = types.HomesteadSigner{}
+	if tx.Protected() {
+		signer = types.NewEIP155Signer(tx.ChainId())
+	}
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, common.Address{}, err
+	}
+	return tx, from, nil
+}
+
+// replacementPrice calculates the gas price to use for replacing tx, enforcing
+// the price bump required by the transaction pool. If no explicit price was
+// requested, the current gas price suggestion is used if it's higher.
+func (s *PublicUsechainAPI) replacementPrice(ctx context.Context, tx *types.Transaction, requested *big.Int) (*big.Int, error) {
+	minimum := new(big.Int).Mul(tx.GasPrice(), big.NewInt(100+int64(s.b.PriceBump())))
+	minimum.Div(minimum, big.NewInt(100))
+	if minimum.Cmp(tx.GasPrice()) <= 0 {
+		minimum.Add(tx.GasPrice(), common.Big1)
+	}
+
+	if requested != nil {
+		if requested.Cmp(minimum) < 0 {
+			return nil, fmt.Errorf("gas price %v below replacement minimum %v", requested, minimum)
+		}
+		return requested, nil
+	}
+	suggested, err := s.b.SuggestPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return math.BigMax(minimum, suggested), nil
+}
+
 // PublicTxPoolAPI offers and API for the transaction pool. It only operates on data that is non confidential.
 type PublicTxPoolAPI struct {
 	b Backend
@@ -145,6 +296,47 @@ func (s *PublicTxPoolAPI) Content() map[string]map[string]map[string]*RPCTransac
 	return content
 }
 
+// TxPoolEntry is a transaction of the pool as listed by the paginated content.
+type TxPoolEntry struct {
+	Status string `json:"status"` // Whether the transaction is "pending" or "queued"
+	*RPCTransaction
+}
+
+// ContentPaged returns the transactions contained within the transaction pool
+// page by page, with at most pageSize transactions each (0 = default page size).
+// Pending transactions are listed before the queued ones, ordered by sender and
+// nonce. Every page is read from the live pool, continuing after the last
+// transaction of the previous one.
+func (s *PublicTxPoolAPI) ContentPaged(ctx context.Context, pageSize int) (*rpc.Page, error) {
+	return rpc.Paginate(ctx, "txpool_contentPaged", &txPoolIterator{b: s.b}, pageSize)
+}
+
+// txPoolIterator pages through the content of the transaction pool, retrieving
+// only the transactions of the requested window.
+type txPoolIterator struct {
+	b    Backend
+	next core.TxPoolKey // Position of the next transaction to list
+}
+
+func (it *txPoolIterator) Next(ctx context.Context, limit int) ([]interface{}, bool, error) {
+	pending, queued, next := it.b.TxPoolContentRange(it.next, limit)
+
+	items := make([]interface{}, 0, len(pending)+len(queued))
+	for _, tx := range pending {
+		items = append(items, &TxPoolEntry{Status: "pending", RPCTransaction: newRPCPendingTransaction(tx)})
+	}
+	for _, tx := range queued {
+		items = append(items, &TxPoolEntry{Status: "queued", RPCTransaction: newRPCPendingTransaction(tx)})
+	}
+	if next == nil {
+		return items, true, nil
+	}
+	it.next = *next
+	return items, false, nil
+}
+
+func (it *txPoolIterator) Close() {}
+
 // Status returns the number of pending and queued transaction in the pool.
 func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 	pending, queue := s.b.Stats()
@@ -155,7 +347,7 @@ func (s *PublicTxPoolAPI) Status() map[string]hexutil.Uint {
 }
 
 // Inspect retrieves the content of the transaction pool and flattens it into an
-// easily inspectable list.
+// easily inspectable list, along with a breakdown of the slots used per origin.
 func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	content := map[string]map[string]map[string]string{
 		"pending": make(map[string]map[string]string),
@@ -163,6 +355,12 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	}
 	pending, queue := s.b.TxPoolContent()
 
+	locals := make(map[common.Address]bool)
+	for _, account := range s.b.TxPoolLocals() {
+		locals[account] = true
+	}
+	content["origins"] = inspectOrigins(locals, pending, queue)
+
 	// Define a formatter to flatten a transaction into a string
 	var format = func(tx *types.Transaction) string {
 		if to := tx.To(); to != nil {
@@ -189,6 +387,41 @@ func (s *PublicTxPoolAPI) Inspect() map[string]map[string]map[string]string {
 	return content
 }
 
+// inspectOrigins counts the senders and the pending and queued transactions
+// originating from local and remote accounts.
+func inspectOrigins(locals map[common.Address]bool, pending, queue map[common.Address]types.Transactions) map[string]map[string]string {
+	type counts struct{ senders, pending, queued int }
+	origins := map[string]*counts{"local": new(counts), "remote": new(counts)}
+
+	origin := func(account common.Address) *counts {
+		if locals[account] {
+			return origins["local"]
+		}
+		return origins["remote"]
+	}
+	senders := make(map[common.Address]bool)
+	for account, txs := range pending {
+		origin(account).pending += len(txs)
+		senders[account] = true
+	}
+	for account, txs := range queue {
+		origin(account).queued += len(txs)
+		senders[account] = true
+	}
+	for account := range senders {
+		origin(account).senders++
+	}
+	result := make(map[string]map[string]string)
+	for name, counts := range origins {
+		result[name] = map[string]string{
+			"senders": fmt.Sprintf("%d", counts.senders),
+			"pending": fmt.Sprintf("%d", counts.pending),
+			"queued":  fmt.Sprintf("%d", counts.queued),
+		}
+	}
+	return result
+}
+
 // PublicAccountAPI provides an API to access accounts managed by this node.
 // It offers only methods that can retrieve accounts.
 type PublicAccountAPI struct {
@@ -326,6 +559,21 @@ func (s *PrivateAccountAPI) ImportRawKey(privkey string, password string) (commo
 	return acc.Address, err
 }
 
+// ImportMnemonic derives the account found at path (m/44'/60'/0'/0/0 if nil)
+// of the HD wallet described by the given BIP-39 mnemonic and seed passphrase,
+// and stores it into the key directory, encrypting it with the password.
+func (s *PrivateAccountAPI) ImportMnemonic(mnemonic string, seedPassphrase string, password string, path *string) (common.Address, error) {
+	derivationPath := accounts.DefaultBaseDerivationPath
+	if path != nil {
+		var err error
+		if derivationPath, err = accounts.ParseDerivationPath(*path); err != nil {
+			return common.Address{}, err
+		}
+	}
+	acc, err := fetchKeystore(s.am).ImportMnemonic(mnemonic, seedPassphrase, derivationPath, password)
+	return acc.Address, err
+}
+
 // UnlockAccount will unlock the account associated with the given address with
 // the given password for duration seconds. If duration is nil it will use a
 // default of 300 seconds. It returns an indication if the account was unlocked.
@@ -348,13 +596,50 @@ func (s *PrivateAccountAPI) LockAccount(addr common.Address) bool {
 	return fetchKeystore(s.am).Lock(addr) == nil
 }
 
+// errApprovalDisabled is returned by the approval endpoints if signing requests
+// are not queued for approval.
+var errApprovalDisabled = errors.New("signing approval not enabled")
+
+// PendingRequests returns the signing requests awaiting operator approval.
+func (s *PrivateAccountAPI) PendingRequests() ([]*accounts.SignRequest, error) {
+	queue := s.am.ApprovalQueue()
+	if queue == nil {
+		return nil, errApprovalDisabled
+	}
+	return queue.Pending(), nil
+}
+
+// Approve allows a signing request awaiting approval to proceed.
+func (s *PrivateAccountAPI) Approve(id uint64) (bool, error) {
+	queue := s.am.ApprovalQueue()
+	if queue == nil {
+		return false, errApprovalDisabled
+	}
+	if err := queue.Approve(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reject denies a signing request awaiting approval.
+func (s *PrivateAccountAPI) Reject(id uint64) (bool, error) {
+	queue := s.am.ApprovalQueue()
+	if queue == nil {
+		return false, errApprovalDisabled
+	}
+	if err := queue.Reject(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // signTransactions sets defaults and signs the given transaction
 // NOTE: the caller needs to ensure that the nonceLock is held, if applicable,
 // and release it after the transaction has been submitted to the tx pool
 func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs, passwd string) (*types.Transaction, error) {
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: args.From}
-	wallet, err := s.am.Find(account)
+	wallet, err := findWallet(s.am, account)
 	if err != nil {
 		return nil, err
 	}
@@ -433,7 +718,7 @@ func (s *PrivateAccountAPI) SignTransaction(ctx context.Context, args SendTxArgs
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
 	}
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxFeePerGas == nil && args.MaxPriorityFeePerGas == nil {
 		return nil, fmt.Errorf("gasPrice not specified")
 	}
 	if args.Nonce == nil {
@@ -475,7 +760,7 @@ func (s *PrivateAccountAPI) Sign(ctx context.Context, data hexutil.Bytes, addr c
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: addr}
 
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := findWallet(s.b.AccountManager(), account)
 	if err != nil {
 		return nil, err
 	}
@@ -551,6 +836,22 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Add
 	return b, state.Error()
 }
 
+// GetDelegations returns the delegation table entry of the given candidate miner,
+// or of every candidate if none is given, at the given block.
+func (s *PublicBlockChainAPI) GetDelegations(ctx context.Context, candidate *common.Address, blockNr rpc.BlockNumber) ([]*delegation.Candidate, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	if !s.b.ChainConfig().IsDelegation(header.Number) {
+		return nil, errors.New("stake delegation not active at the requested block")
+	}
+	if candidate != nil {
+		return []*delegation.Candidate{delegation.ReadCandidate(state, *candidate)}, state.Error()
+	}
+	return delegation.ReadCandidates(state), state.Error()
+}
+
 // GetBlockByNumber returns the requested block. When blockNr is -1 the chain head is returned. When fullTx is true all
 // transactions in the block are returned in full detail, otherwise only the transaction hash is returned.
 func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
@@ -650,6 +951,76 @@ func (s *PublicBlockChainAPI) GetStorageAt(ctx context.Context, address common.A
 	return res[:], state.Error()
 }
 
+// ProofArgs selects an account, and the storage slots of it, to prove.
+type ProofArgs struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// StorageResult is a proven storage slot.
+type StorageResult struct {
+	Key   common.Hash `json:"key"`
+	Value common.Hash `json:"value"`
+}
+
+// AccountResult is a proven account along with the requested storage slots.
+type AccountResult struct {
+	Address     common.Address  `json:"address"`
+	Balance     *hexutil.Big    `json:"balance"`
+	Nonce       hexutil.Uint64  `json:"nonce"`
+	CodeHash    common.Hash     `json:"codeHash"`
+	StorageHash common.Hash     `json:"storageHash"`
+	Storage     []StorageResult `json:"storage"`
+}
+
+// ProofResult holds the proven accounts and the single merkle proof covering
+// all of them and their storage against the state root.
+type ProofResult struct {
+	StateRoot common.Hash      `json:"stateRoot"`
+	Accounts  []*AccountResult `json:"accounts"`
+	Proof     []hexutil.Bytes  `json:"proof"`
+}
+
+// GetProof returns the given accounts and storage slots at the given block,
+// along with a merkle proof of all of them. Nodes shared by several accounts
+// or slots are only included once in the proof.
+func (s *PublicBlockChainAPI) GetProof(ctx context.Context, accounts []ProofArgs, blockNr rpc.BlockNumber) (*ProofResult, error) {
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	slots := make(map[common.Address][]common.Hash)
+	for _, account := range accounts {
+		slots[account.Address] = append(slots[account.Address], account.StorageKeys...)
+	}
+	nodes, _ := ethdb.NewMemDatabase()
+	if err := state.ProveAccounts(slots, nodes); err != nil {
+		return nil, err
+	}
+	result := &ProofResult{StateRoot: header.Root}
+	for _, account := range accounts {
+		res := &AccountResult{
+			Address:  account.Address,
+			Balance:  (*hexutil.Big)(state.GetBalance(account.Address)),
+			Nonce:    hexutil.Uint64(state.GetNonce(account.Address)),
+			CodeHash: state.GetCodeHash(account.Address),
+			Storage:  make([]StorageResult, len(account.StorageKeys)),
+		}
+		if storage := state.StorageTrie(account.Address); storage != nil {
+			res.StorageHash = storage.Hash()
+		}
+		for i, key := range account.StorageKeys {
+			res.Storage[i] = StorageResult{Key: key, Value: state.GetState(account.Address, key)}
+		}
+		result.Accounts = append(result.Accounts, res)
+	}
+	for _, key := range nodes.Keys() {
+		node, _ := nodes.Get(key)
+		result.Proof = append(result.Proof, node)
+	}
+	return result, state.Error()
+}
+
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
 	From     common.Address  `json:"from"`
@@ -658,6 +1029,10 @@ type CallArgs struct {
 	GasPrice hexutil.Big     `json:"gasPrice"`
 	Value    hexutil.Big     `json:"value"`
 	Data     hexutil.Bytes   `json:"data"`
+
+	// Dynamic fee fields of wallets, mapped onto the gas price
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
 }
 
 func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
@@ -677,7 +1052,11 @@ func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr
 		}
 	}
 	// Set default gas & gas price if none were set
-	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
+	gasPrice, err := args.callGasPrice()
+	if err != nil {
+		return nil, 0, false, err
+	}
+	gas := uint64(args.Gas)
 	if gas == 0 {
 		gas = math.MaxUint64 / 2
 	}
@@ -1130,25 +1509,55 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Report the category assigned during execution, or a best effort guess based
+	// on the current state if the transaction was not executed locally
+	category, ok := core.GetTxCategory(s.b.ChainDb(), hash)
+	if !ok {
+		hasCode := len(receipt.Logs) > 0
+		if to := tx.To(); to != nil && !hasCode {
+			if state, _, err := s.b.StateAndHeaderByNumber(ctx, rpc.LatestBlockNumber); err == nil && state != nil {
+				hasCode = state.GetCodeSize(*to) > 0
+			}
+		}
+		category = core.ClassifyTransaction(tx, hasCode)
+	}
+	fields["category"] = category
 	return fields, nil
 }
 
 // sign is a helper function that signs a transaction with the private key of the given address.
 func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
+	return signTransaction(s.b, addr, tx)
+}
+
+// findWallet looks up the wallet containing the requested signer. If the operator
+// enabled signing approvals, the wallet's signing methods are gated by the queue.
+func findWallet(am *accounts.Manager, account accounts.Account) (accounts.Wallet, error) {
+	wallet, err := am.Find(account)
+	if err != nil {
+		return nil, err
+	}
+	if queue := am.ApprovalQueue(); queue != nil {
+		wallet = queue.Wrap(wallet)
+	}
+	return wallet, nil
+}
+
+// signTransaction signs a transaction with the private key of the given address,
+// looking up the wallet containing it from the backend's account manager.
+func signTransaction(b Backend, addr common.Address, tx *types.Transaction) (*types.Transaction, error) {
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: addr}
 
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := findWallet(b.AccountManager(), account)
 	if err != nil {
 		return nil, err
 	}
 	// Request the wallet to sign the transaction
 	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+	if config := b.ChainConfig(); config.IsEIP155(b.CurrentBlock().Number()) {
 		chainID = config.ChainId
 	}
-
-
 	return wallet.SignTx(account, tx, chainID)
 }
 
@@ -1165,6 +1574,10 @@ type SendTxArgs struct {
 	Data  *hexutil.Bytes `json:"data"`
 	//Data  []byte `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+
+	// Dynamic fee fields of wallets, mapped onto the gas price
+	MaxFeePerGas         *hexutil.Big `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big `json:"maxPriorityFeePerGas"`
 }
 
 // setDefaults is a helper function that fills in default values for unspecified tx fields.
@@ -1173,6 +1586,16 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 		args.Gas = new(hexutil.Uint64)
 		*(*uint64)(args.Gas) = 90000
 	}
+	if args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil {
+		if args.GasPrice != nil {
+			return errFeeKindsMixed
+		}
+		price, err := dynamicGasPrice(ctx, b, args.MaxFeePerGas, args.MaxPriorityFeePerGas)
+		if err != nil {
+			return err
+		}
+		args.GasPrice, args.MaxFeePerGas, args.MaxPriorityFeePerGas = (*hexutil.Big)(price), nil, nil
+	}
 	if args.GasPrice == nil {
 		price, err := b.SuggestPrice(ctx)
 		if err != nil {
@@ -1247,7 +1670,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: args.From}
 
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := findWallet(s.b.AccountManager(), account)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -1302,7 +1725,7 @@ func (s *PublicTransactionPoolAPI) Sign(addr common.Address, data hexutil.Bytes)
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: addr}
 
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := findWallet(s.b.AccountManager(), account)
 	if err != nil {
 		return nil, err
 	}
@@ -1327,7 +1750,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(ctx context.Context, args Sen
 	if args.Gas == nil {
 		return nil, fmt.Errorf("gas not specified")
 	}
-	if args.GasPrice == nil {
+	if args.GasPrice == nil && args.MaxFeePerGas == nil && args.MaxPriorityFeePerGas == nil {
 		return nil, fmt.Errorf("gasPrice not specified")
 	}
 	if args.Nonce == nil {
@@ -1554,7 +1977,7 @@ func (s *PublicTransactionPoolAPI) SendOneTimeTransaction(ctx context.Context, a
 
 	// Look up the wallet containing the requested signer
 	account := accounts.Account{Address: args.From}
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := findWallet(s.b.AccountManager(), account)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -1718,7 +2141,7 @@ func (s *PublicTransactionPoolAPI) SendMainTransaction(ctx context.Context, addr
 
 	// Look up the wallet containing the requested signer
 	account:= accounts.Account{Address: args.From}
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := findWallet(s.b.AccountManager(), account)
 	if err != nil {
 
 
@@ -1788,7 +2211,7 @@ func (s *PublicTransactionPoolAPI) SendSubTransaction(ctx context.Context, addre
 
 	// Look up the wallet containing the requested signer
 	account:= accounts.Account{Address: args.From}
-	wallet, err := s.b.AccountManager().Find(account)
+	wallet, err := findWallet(s.b.AccountManager(), account)
 	if err != nil {
 		return common.Hash{}, err
 	}
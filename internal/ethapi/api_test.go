@@ -0,0 +1,123 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+)
+
+// poolBackend serves the windows of a fixed transaction pool content, counting
+// the transactions handed out.
+type poolBackend struct {
+	Backend
+
+	pending, queued map[common.Address]types.Transactions
+	locals          []common.Address
+	served          int
+}
+
+func (b *poolBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
+	return b.pending, b.queued
+}
+
+func (b *poolBackend) TxPoolLocals() []common.Address {
+	return b.locals
+}
+
+func (b *poolBackend) TxPoolContentRange(start core.TxPoolKey, limit int) (types.Transactions, types.Transactions, *core.TxPoolKey) {
+	pending, queued, next := core.ContentRange(b.pending, b.queued, start, limit)
+	b.served += len(pending) + len(queued)
+	return pending, queued, next
+}
+
+// Tests that the paged pool content only retrieves the transactions of every
+// page, listing the pending ones first.
+func TestTxPoolIterator(t *testing.T) {
+	tx := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{}, new(big.Int), 21000, big.NewInt(1), nil)
+	}
+	backend := &poolBackend{
+		pending: map[common.Address]types.Transactions{
+			{0x02}: {tx(0), tx(1)},
+			{0x01}: {tx(4)},
+		},
+		queued: map[common.Address]types.Transactions{
+			{0x01}: {tx(6)},
+		},
+	}
+	want := []struct {
+		status string
+		nonce  uint64
+	}{{"pending", 4}, {"pending", 0}, {"pending", 1}, {"queued", 6}}
+
+	it := &txPoolIterator{b: backend}
+	for i := range want {
+		items, done, err := it.Next(context.Background(), 1)
+		if err != nil || len(items) != 1 {
+			t.Fatalf("page %d: have %d items, err %v", i, len(items), err)
+		}
+		if done != (i == len(want)-1) {
+			t.Errorf("page %d: done mismatch: have %v", i, done)
+		}
+		entry := items[0].(*TxPoolEntry)
+		if entry.Status != want[i].status || uint64(entry.Nonce) != want[i].nonce {
+			t.Errorf("page %d: entry mismatch: have %s #%d, want %s #%d", i, entry.Status, entry.Nonce, want[i].status, want[i].nonce)
+		}
+		if backend.served != i+1 {
+			t.Errorf("page %d: served transactions mismatch: have %d, want %d", i, backend.served, i+1)
+		}
+	}
+}
+
+// Tests that the pool inspection breaks the senders and slots down by origin,
+// next to the flattened transactions.
+func TestTxPoolInspectOrigins(t *testing.T) {
+	tx := func(nonce uint64) *types.Transaction {
+		return types.NewTransaction(nonce, common.Address{}, new(big.Int), 21000, big.NewInt(1), nil)
+	}
+	backend := &poolBackend{
+		pending: map[common.Address]types.Transactions{
+			{0x01}: {tx(0), tx(1)},
+			{0x02}: {tx(0)},
+			{0x03}: {tx(0), tx(1), tx(2)},
+		},
+		queued: map[common.Address]types.Transactions{
+			{0x01}: {tx(3)},
+			{0x04}: {tx(5), tx(6)},
+		},
+		locals: []common.Address{{0x01}},
+	}
+	content := NewPublicTxPoolAPI(backend).Inspect()
+
+	want := map[string]map[string]string{
+		"local":  {"senders": "1", "pending": "2", "queued": "1"},
+		"remote": {"senders": "3", "pending": "4", "queued": "2"},
+	}
+	if !reflect.DeepEqual(content["origins"], want) {
+		t.Errorf("origins mismatch: have %v, want %v", content["origins"], want)
+	}
+	if len(content["pending"]) != 3 || len(content["queued"]) != 2 {
+		t.Errorf("content mismatch: have %d pending, %d queued senders, want 3, 2", len(content["pending"]), len(content["queued"]))
+	}
+}
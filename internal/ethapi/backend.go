@@ -19,6 +19,7 @@ package ethapi
 
 import (
 	"context"
+	"errors"
 	"math/big"
 
 	"github.com/usechain/go-usechain/accounts"
@@ -28,6 +29,7 @@ import (
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/core/vm"
 	"github.com/usechain/go-usechain/eth/downloader"
+	"github.com/usechain/go-usechain/eth/txtracker"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
 	"github.com/usechain/go-usechain/params"
@@ -41,6 +43,7 @@ type Backend interface {
 	Downloader() *downloader.Downloader
 	ProtocolVersion() int
 	SuggestPrice(ctx context.Context) (*big.Int, error)
+	FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error)
 	ChainDb() ethdb.Database
 	EventMux() *event.TypeMux
 	AccountManager() *accounts.Manager
@@ -63,9 +66,13 @@ type Backend interface {
 	GetPoolTransactions() (types.Transactions, error)
 	GetPoolTransaction(txHash common.Hash) *types.Transaction
 	GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error)
+	PriceBump() uint64
 	Stats() (pending int, queued int)
 	TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions)
+	TxPoolContentRange(start core.TxPoolKey, limit int) (types.Transactions, types.Transactions, *core.TxPoolKey)
+	TxPoolLocals() []common.Address
 	SubscribeTxPreEvent(chan<- core.TxPreEvent) event.Subscription
+	TrackTransaction(hash common.Hash) (*txtracker.Status, error)
 
 	ChainConfig() *params.ChainConfig
 	CurrentBlock() *types.Block
@@ -128,6 +135,11 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 			Version:   "1.0",
 			Service:   NewPublicAccountAPI(apiBackend.AccountManager()),
 			Public:    true,
+		}, {
+			Namespace: "usx",
+			Version:   "1.0",
+			Service:   NewPublicUsechainAPI(apiBackend),
+			Public:    true,
 		},  {
 			Namespace: "personal",
 			Version:   "1.0",
@@ -136,3 +148,30 @@ func GetAPIs(apiBackend Backend) []rpc.API {
 		},
 	}
 }
+
+// ResolveBlockNumber converts an RPC block number into an absolute one. The
+// safe and finalized tags are resolved through the backend, the latest and
+// pending ones map onto the current head and explicit numbers are capped at it.
+func ResolveBlockNumber(ctx context.Context, b Backend, number rpc.BlockNumber) (uint64, error) {
+	head, err := b.HeaderByNumber(ctx, rpc.LatestBlockNumber)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case number >= 0:
+		if uint64(number) > head.Number.Uint64() {
+			return head.Number.Uint64(), nil
+		}
+		return uint64(number), nil
+	case number == rpc.LatestBlockNumber || number == rpc.PendingBlockNumber:
+		return head.Number.Uint64(), nil
+	}
+	header, err := b.HeaderByNumber(ctx, number)
+	if err != nil {
+		return 0, err
+	}
+	if header == nil {
+		return 0, errors.New("block not found")
+	}
+	return header.Number.Uint64(), nil
+}
@@ -0,0 +1,233 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// maxBundleSize is the maximum number of transactions in a simulated bundle.
+	maxBundleSize = 64
+
+	// bundleTimeout is the maximum time allowed to simulate a whole bundle.
+	bundleTimeout = 5 * time.Second
+)
+
+var errEmptyBundle = errors.New("empty transaction bundle")
+
+// BundleTxArgs is a single transaction of a simulated bundle: either a raw
+// signed transaction, or the fields of an unsigned call.
+type BundleTxArgs struct {
+	CallArgs
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+// BundleTxResult is the outcome of a single transaction of a simulated bundle.
+type BundleTxResult struct {
+	TxHash     *common.Hash    `json:"txHash,omitempty"`
+	From       common.Address  `json:"from"`
+	To         *common.Address `json:"to"`
+	GasUsed    hexutil.Uint64  `json:"gasUsed"`
+	ReturnData hexutil.Bytes   `json:"returnData"`
+	Failed     bool            `json:"failed"`
+	Error      string          `json:"error,omitempty"`
+	Logs       []*types.Log    `json:"logs"`
+}
+
+// BundleResult is the outcome of a simulated bundle.
+type BundleResult struct {
+	BlockNumber hexutil.Uint64    `json:"blockNumber"`
+	Results     []*BundleTxResult `json:"results"`
+	GasUsed     hexutil.Uint64    `json:"gasUsed"`
+	StateRoot   common.Hash       `json:"stateRoot"`
+}
+
+// CallBundle executes an ordered bundle of transactions on top of the state of
+// the given block, each one seeing the effects of the previous ones. Signed
+// transactions are executed as sent, including their nonce, whereas unsigned
+// calls are executed with the current nonce of their sender. Transactions that
+// cannot be executed at all are reported as errors without affecting the rest
+// of the bundle. Nothing is written to the chain or the transaction pool.
+func (s *PublicBlockChainAPI) CallBundle(ctx context.Context, txs []BundleTxArgs, blockNr rpc.BlockNumber) (*BundleResult, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing bundle finished", "txs", len(txs), "runtime", time.Since(start))
+	}(time.Now())
+
+	if len(txs) == 0 {
+		return nil, errEmptyBundle
+	}
+	if len(txs) > maxBundleSize {
+		return nil, fmt.Errorf("bundle too large: %d transactions, max %d", len(txs), maxBundleSize)
+	}
+	state, header, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	// Make sure the EVMs are cancelled once the bundle has finished or timed out
+	ctx, cancel := context.WithTimeout(ctx, bundleTimeout)
+	defer cancel()
+
+	guard := newEVMGuard(ctx)
+
+	var (
+		signer  = types.MakeSigner(s.b.ChainConfig(), header.Number)
+		gp      = new(core.GasPool).AddGas(header.GasLimit)
+		result  = &BundleResult{BlockNumber: hexutil.Uint64(header.Number.Uint64())}
+		eip158  = s.b.ChainConfig().IsEIP158(header.Number)
+		usedGas uint64
+	)
+	for i, args := range txs {
+		msg, tx, err := s.bundleMessage(args, signer, state, gp.Gas())
+		if err != nil {
+			return nil, fmt.Errorf("bundle transaction %d: %v", i, err)
+		}
+		hash := tx.Hash()
+		res := &BundleTxResult{From: msg.From(), To: msg.To(), Logs: []*types.Log{}}
+		if args.Raw != nil {
+			res.TxHash = &hash
+		}
+		result.Results = append(result.Results, res)
+
+		// Execute the transaction, attributing its logs to it
+		state.Prepare(hash, common.Hash{}, i)
+
+		evm, vmError, err := s.b.GetEVM(ctx, msg, state, header, vm.Config{})
+		if err != nil {
+			return nil, err
+		}
+		guard.watch(evm)
+
+		ret, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+		if err := vmError(); err != nil {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("bundle execution aborted (timeout = %v)", bundleTimeout)
+		}
+		if err != nil {
+			res.Error = err.Error()
+			continue
+		}
+		state.Finalise(eip158)
+
+		usedGas += gas
+		res.GasUsed, res.ReturnData, res.Failed = hexutil.Uint64(gas), ret, failed
+		if logs := state.GetLogs(hash); logs != nil {
+			res.Logs = logs
+		}
+	}
+	result.GasUsed = hexutil.Uint64(usedGas)
+	result.StateRoot = state.IntermediateRoot(eip158)
+	return result, nil
+}
+
+// evmGuard cancels the EVM executing when a context is done. A single guard is
+// shared by all the EVMs executed one after the other within the context.
+type evmGuard struct {
+	lock sync.Mutex
+	evm  *vm.EVM // EVM currently executing
+	done bool    // Whether the context is done
+}
+
+// newEVMGuard creates a guard cancelling the EVMs it watches once the context
+// is done.
+func newEVMGuard(ctx context.Context) *evmGuard {
+	guard := new(evmGuard)
+	go func() {
+		<-ctx.Done()
+
+		guard.lock.Lock()
+		defer guard.lock.Unlock()
+
+		guard.done = true
+		if guard.evm != nil {
+			guard.evm.Cancel()
+		}
+	}()
+	return guard
+}
+
+// watch makes evm the one cancelled when the context is done, cancelling it
+// right away if that already happened.
+func (g *evmGuard) watch(evm *vm.EVM) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	g.evm = evm
+	if g.done {
+		evm.Cancel()
+	}
+}
+
+// bundleMessage converts a bundle transaction into the message to execute and
+// the transaction its logs are attributed to.
+func (s *PublicBlockChainAPI) bundleMessage(args BundleTxArgs, signer types.Signer, state *state.StateDB, gasLeft uint64) (types.Message, *types.Transaction, error) {
+	if args.Raw != nil {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(args.Raw, tx); err != nil {
+			return types.Message{}, nil, err
+		}
+		msg, err := tx.AsMessage(signer)
+		return msg, tx, err
+	}
+	// Set sender address or use a default if none specified
+	addr := args.From
+	if addr == (common.Address{}) {
+		if wallets := s.b.AccountManager().Wallets(); len(wallets) > 0 {
+			if accounts := wallets[0].Accounts(); len(accounts) > 0 {
+				addr = accounts[0].Address
+			}
+		}
+	}
+	// Unsigned calls default to all the gas left in the block and the default
+	// gas price, their logs are attributed to the unsigned transaction
+	gasPrice, err := args.callGasPrice()
+	if err != nil {
+		return types.Message{}, nil, err
+	}
+	gas, nonce := uint64(args.Gas), state.GetNonce(addr)
+	if gas == 0 {
+		gas = gasLeft
+	}
+	if gasPrice.Sign() == 0 {
+		gasPrice = new(big.Int).SetUint64(defaultGasPrice)
+	}
+	var tx *types.Transaction
+	if args.To == nil {
+		tx = types.NewContractCreation(nonce, args.Value.ToInt(), gas, gasPrice, args.Data)
+	} else {
+		tx = types.NewTransaction(nonce, *args.To, args.Value.ToInt(), gas, gasPrice, args.Data)
+	}
+	msg := types.NewMessage(addr, args.To, nonce, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+	return msg, tx, nil
+}
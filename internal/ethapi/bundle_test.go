@@ -0,0 +1,206 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/params"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+var (
+	// counterCode increments storage slot 0 and returns its new value.
+	counterCode = common.FromHex("60005460010160005560005460005260206000f3")
+
+	// revertCode reverts unconditionally.
+	revertCode = common.FromHex("60006000fd")
+
+	// loopCode loops forever.
+	loopCode = common.FromHex("5b600056")
+)
+
+// testBackend serves a single head block and its state to the APIs. Only the
+// methods used by the simulations are implemented.
+type testBackend struct {
+	Backend
+
+	db   ethdb.Database
+	root common.Hash
+	head *types.Header
+}
+
+// newTestBackend creates a backend whose head state holds the given accounts.
+func newTestBackend(alloc core.GenesisAlloc) *testBackend {
+	db, _ := ethdb.NewMemDatabase()
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+	for addr, account := range alloc {
+		statedb.SetBalance(addr, account.Balance)
+		statedb.SetNonce(addr, account.Nonce)
+		statedb.SetCode(addr, account.Code)
+		for key, value := range account.Storage {
+			statedb.SetState(addr, key, value)
+		}
+	}
+	root, _ := statedb.Commit(false)
+	statedb.Database().TrieDB().Commit(root, false)
+
+	return &testBackend{
+		db:   db,
+		root: root,
+		head: &types.Header{
+			Number:     big.NewInt(10),
+			Time:       big.NewInt(1000),
+			Difficulty: big.NewInt(1),
+			GasLimit:   math.MaxInt64,
+			Root:       root,
+		},
+	}
+}
+
+func (b *testBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (b *testBackend) StateAndHeaderByNumber(ctx context.Context, number rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	statedb, err := state.New(b.root, state.NewDatabase(b.db))
+	return statedb, b.head, err
+}
+
+func (b *testBackend) GetEVM(ctx context.Context, msg core.Message, statedb *state.StateDB, header *types.Header, vmCfg vm.Config) (*vm.EVM, func() error, error) {
+	statedb.SetBalance(msg.From(), math.MaxBig256)
+	context := vm.Context{
+		CanTransfer: core.CanTransfer,
+		Transfer:    core.Transfer,
+		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		Origin:      msg.From(),
+		Coinbase:    header.Coinbase,
+		BlockNumber: new(big.Int).Set(header.Number),
+		Time:        new(big.Int).Set(header.Time),
+		Difficulty:  new(big.Int).Set(header.Difficulty),
+		GasLimit:    header.GasLimit,
+		GasPrice:    new(big.Int).Set(msg.GasPrice()),
+	}
+	return vm.NewEVM(context, statedb, params.TestChainConfig, vmCfg), func() error { return nil }, nil
+}
+
+// Tests that the transactions of a bundle see the effects of the previous ones,
+// that a revert partway through only fails its own transaction and that
+// transactions which can't be executed at all are reported without aborting.
+func TestCallBundle(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		caller  = common.Address{0xca}
+		counter = common.Address{0xc0}
+		reverts = common.Address{0xee}
+	)
+	api := NewPublicBlockChainAPI(newTestBackend(core.GenesisAlloc{
+		sender:  {Balance: big.NewInt(params.Use)},
+		counter: {Balance: new(big.Int), Code: counterCode},
+		reverts: {Balance: new(big.Int), Code: revertCode},
+	}))
+	signer := types.MakeSigner(params.TestChainConfig, big.NewInt(10))
+
+	// Signed transactions are executed with their own nonce
+	stale, _ := types.SignTx(types.NewTransaction(1, counter, new(big.Int), 100000, big.NewInt(1), nil), signer, key)
+	raw, _ := rlp.EncodeToBytes(stale)
+
+	txs := []BundleTxArgs{
+		{CallArgs: CallArgs{From: caller, To: &counter, Gas: 100000}},
+		{CallArgs: CallArgs{From: caller, To: &reverts, Gas: 100000}},
+		{Raw: raw},
+		{CallArgs: CallArgs{From: caller, To: &counter, Gas: 100000}},
+	}
+	result, err := api.CallBundle(context.Background(), txs, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to call bundle: %v", err)
+	}
+	if len(result.Results) != len(txs) {
+		t.Fatalf("result count mismatch: have %d, want %d", len(result.Results), len(txs))
+	}
+	if res := result.Results[0]; res.Failed || new(big.Int).SetBytes(res.ReturnData).Uint64() != 1 {
+		t.Errorf("first call mismatch: failed %v, returned %x", res.Failed, res.ReturnData)
+	}
+	if res := result.Results[1]; !res.Failed || res.Error != "" {
+		t.Errorf("reverting call mismatch: failed %v, error %q", res.Failed, res.Error)
+	}
+	if res := result.Results[2]; res.TxHash == nil || *res.TxHash != stale.Hash() || !strings.Contains(res.Error, "nonce") {
+		t.Errorf("stale transaction mismatch: hash %v, error %q", res.TxHash, res.Error)
+	}
+	if res := result.Results[3]; res.Failed || new(big.Int).SetBytes(res.ReturnData).Uint64() != 2 {
+		t.Errorf("last call mismatch: failed %v, returned %x", res.Failed, res.ReturnData)
+	}
+	if used := result.Results[0].GasUsed + result.Results[1].GasUsed + result.Results[3].GasUsed; result.GasUsed != used {
+		t.Errorf("gas used mismatch: have %d, want %d", result.GasUsed, used)
+	}
+	if result.BlockNumber != 10 {
+		t.Errorf("block number mismatch: have %d, want %d", result.BlockNumber, 10)
+	}
+	// Invalid bundles are rejected as a whole
+	if _, err := api.CallBundle(context.Background(), nil, rpc.LatestBlockNumber); err != errEmptyBundle {
+		t.Errorf("empty bundle: have %v, want %v", err, errEmptyBundle)
+	}
+	if _, err := api.CallBundle(context.Background(), make([]BundleTxArgs, maxBundleSize+1), rpc.LatestBlockNumber); err == nil {
+		t.Errorf("oversized bundle accepted")
+	}
+	if _, err := api.CallBundle(context.Background(), []BundleTxArgs{{Raw: hexutil.Bytes{0x01}}}, rpc.LatestBlockNumber); err == nil {
+		t.Errorf("undecodable transaction accepted")
+	}
+}
+
+// Tests that a bundle running past its deadline is aborted, even when the time
+// runs out in a later transaction than the first one.
+func TestCallBundleTimeout(t *testing.T) {
+	var (
+		caller  = common.Address{0xca}
+		counter = common.Address{0xc0}
+		loop    = common.Address{0x1f}
+	)
+	api := NewPublicBlockChainAPI(newTestBackend(core.GenesisAlloc{
+		counter: {Balance: new(big.Int), Code: counterCode},
+		loop:    {Balance: new(big.Int), Code: loopCode},
+	}))
+	txs := []BundleTxArgs{
+		{CallArgs: CallArgs{From: caller, To: &counter, Gas: 100000}},
+		{CallArgs: CallArgs{From: caller, To: &loop}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := api.CallBundle(ctx, txs, rpc.LatestBlockNumber); err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Fatalf("error mismatch: have %v, want bundle abort", err)
+	}
+	if elapsed := time.Since(start); elapsed > bundleTimeout {
+		t.Errorf("bundle not cancelled in time: took %v", elapsed)
+	}
+}
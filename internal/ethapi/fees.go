@@ -0,0 +1,131 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// Usechain has no base fee, the dynamic fee fields used by current wallets are
+// mapped onto the legacy gas price: the whole fee is the tip, capped by the fee
+// cap of the request.
+
+var (
+	errFeeKindsMixed = errors.New("both gasPrice and (maxFeePerGas or maxPriorityFeePerGas) specified")
+	errTipAboveCap   = errors.New("maxPriorityFeePerGas bigger than maxFeePerGas")
+)
+
+// MaxPriorityFeePerGas returns a suggestion for the tip of dynamic fee requests,
+// which without a base fee is the suggested gas price itself.
+func (s *PublicEthereumAPI) MaxPriorityFeePerGas(ctx context.Context) (*hexutil.Big, error) {
+	price, err := s.b.SuggestPrice(ctx)
+	return (*hexutil.Big)(price), err
+}
+
+// EthFeeHistoryResult is the fee history in the format of the standard Ethereum
+// API, reporting a zero base fee for every block.
+type EthFeeHistoryResult struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
+// FeeHistory returns the tips paid at the given percentiles and the gas
+// utilisation of up to blockCount blocks ending at lastBlock. The base fees,
+// including the one of the block after the range, are always zero.
+func (s *PublicEthereumAPI) FeeHistory(ctx context.Context, blockCount math.HexOrDecimal64, lastBlock rpc.BlockNumber, rewardPercentiles []float64) (*EthFeeHistoryResult, error) {
+	oldest, rewards, ratios, err := s.b.FeeHistory(ctx, int(blockCount), lastBlock, rewardPercentiles)
+	if err != nil {
+		return nil, err
+	}
+	result := &EthFeeHistoryResult{
+		OldestBlock:  (*hexutil.Big)(oldest),
+		BaseFee:      make([]*hexutil.Big, len(ratios)+1),
+		GasUsedRatio: ratios,
+	}
+	for i := range result.BaseFee {
+		result.BaseFee[i] = new(hexutil.Big)
+	}
+	if rewards != nil {
+		result.Reward = make([][]*hexutil.Big, len(rewards))
+		for i, block := range rewards {
+			result.Reward[i] = make([]*hexutil.Big, len(block))
+			for j, reward := range block {
+				result.Reward[i][j] = (*hexutil.Big)(reward)
+			}
+		}
+	}
+	return result, nil
+}
+
+// ChainId returns the chain id used for replay protected transaction signing.
+func (s *PublicBlockChainAPI) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(s.b.ChainConfig().ChainId)
+}
+
+// dynamicGasPrice maps the dynamic fee fields of a request onto a legacy gas
+// price. The tip defaults to the suggested gas price if not given, nil is
+// returned if neither of the fields are set.
+func dynamicGasPrice(ctx context.Context, b Backend, maxFee, maxTip *hexutil.Big) (*big.Int, error) {
+	if maxFee == nil && maxTip == nil {
+		return nil, nil
+	}
+	if maxFee != nil && maxTip != nil && maxTip.ToInt().Cmp(maxFee.ToInt()) > 0 {
+		return nil, errTipAboveCap
+	}
+	var tip *big.Int
+	if maxTip != nil {
+		tip = new(big.Int).Set(maxTip.ToInt())
+	} else {
+		price, err := b.SuggestPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tip = price
+	}
+	if maxFee != nil && tip.Cmp(maxFee.ToInt()) > 0 {
+		tip = new(big.Int).Set(maxFee.ToInt())
+	}
+	return tip, nil
+}
+
+// callGasPrice returns the gas price of an unsigned call, mapping the dynamic
+// fee fields onto it if given. Calls don't default the tip to the suggestion,
+// a zero price is left for the caller to default.
+func (args *CallArgs) callGasPrice() (*big.Int, error) {
+	if args.MaxFeePerGas == nil && args.MaxPriorityFeePerGas == nil {
+		return args.GasPrice.ToInt(), nil
+	}
+	if args.GasPrice.ToInt().Sign() != 0 {
+		return nil, errFeeKindsMixed
+	}
+	if args.MaxPriorityFeePerGas == nil {
+		return new(big.Int), nil
+	}
+	tip := args.MaxPriorityFeePerGas.ToInt()
+	if args.MaxFeePerGas != nil && tip.Cmp(args.MaxFeePerGas.ToInt()) > 0 {
+		return nil, errTipAboveCap
+	}
+	return new(big.Int).Set(tip), nil
+}
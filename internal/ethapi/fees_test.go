@@ -0,0 +1,197 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/common/math"
+	"github.com/usechain/go-usechain/params"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// feeBackend serves a fixed price suggestion and fee history to the APIs.
+type feeBackend struct {
+	Backend
+
+	price   *big.Int
+	rewards [][]*big.Int
+	ratios  []float64
+	err     error
+}
+
+func (b *feeBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.price), nil
+}
+
+func (b *feeBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	if b.err != nil {
+		return nil, nil, nil, b.err
+	}
+	return big.NewInt(int64(lastBlock) - int64(len(b.ratios)) + 1), b.rewards, b.ratios, nil
+}
+
+func (b *feeBackend) ChainConfig() *params.ChainConfig {
+	return params.TestChainConfig
+}
+
+func (b *feeBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
+	return 0, nil
+}
+
+// Tests that the tip suggestion is the suggested gas price.
+func TestMaxPriorityFeePerGas(t *testing.T) {
+	api := NewPublicEthereumAPI(&feeBackend{price: big.NewInt(18)})
+
+	tip, err := api.MaxPriorityFeePerGas(context.Background())
+	if err != nil {
+		t.Fatalf("failed to suggest tip: %v", err)
+	}
+	if tip.ToInt().Cmp(big.NewInt(18)) != 0 {
+		t.Errorf("tip mismatch: have %v, want %v", tip, 18)
+	}
+}
+
+// Tests that the fee history is reported in the standard format, with a zero
+// base fee for every block of the range and the one after it.
+func TestFeeHistoryResult(t *testing.T) {
+	backend := &feeBackend{
+		rewards: [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3), big.NewInt(4)}},
+		ratios:  []float64{0.5, 0.25},
+	}
+	api := NewPublicEthereumAPI(backend)
+
+	result, err := api.FeeHistory(context.Background(), math.HexOrDecimal64(2), rpc.BlockNumber(7), []float64{10, 90})
+	if err != nil {
+		t.Fatalf("failed to retrieve fee history: %v", err)
+	}
+	if result.OldestBlock.ToInt().Int64() != 6 {
+		t.Errorf("oldest block mismatch: have %v, want %d", result.OldestBlock, 6)
+	}
+	want := [][]*hexutil.Big{
+		{(*hexutil.Big)(big.NewInt(1)), (*hexutil.Big)(big.NewInt(2))},
+		{(*hexutil.Big)(big.NewInt(3)), (*hexutil.Big)(big.NewInt(4))},
+	}
+	if !reflect.DeepEqual(result.Reward, want) {
+		t.Errorf("rewards mismatch: have %v, want %v", result.Reward, want)
+	}
+	if len(result.BaseFee) != 3 {
+		t.Fatalf("base fee count mismatch: have %d, want %d", len(result.BaseFee), 3)
+	}
+	for i, fee := range result.BaseFee {
+		if fee == nil || fee.ToInt().Sign() != 0 {
+			t.Errorf("base fee %d: have %v, want 0", i, fee)
+		}
+	}
+	if !reflect.DeepEqual(result.GasUsedRatio, backend.ratios) {
+		t.Errorf("gas used ratios mismatch: have %v, want %v", result.GasUsedRatio, backend.ratios)
+	}
+	// Rewards are left out if no percentiles were requested
+	backend.rewards = nil
+	if result, err := api.FeeHistory(context.Background(), math.HexOrDecimal64(2), rpc.BlockNumber(7), nil); err != nil || result.Reward != nil {
+		t.Errorf("rewards without percentiles: have %v, err %v", result, err)
+	}
+	// Failures of the oracle are passed on
+	backend.err = errors.New("unknown block")
+	if _, err := api.FeeHistory(context.Background(), math.HexOrDecimal64(2), rpc.BlockNumber(7), nil); err != backend.err {
+		t.Errorf("error mismatch: have %v, want %v", err, backend.err)
+	}
+}
+
+// Tests that the chain id is reported from the chain config.
+func TestChainId(t *testing.T) {
+	api := NewPublicBlockChainAPI(&feeBackend{})
+	if id := api.ChainId(); id.ToInt().Cmp(params.TestChainConfig.ChainId) != 0 {
+		t.Errorf("chain id mismatch: have %v, want %v", id, params.TestChainConfig.ChainId)
+	}
+}
+
+// Tests that the dynamic fee fields of sent transactions are mapped onto the
+// gas price, defaulting the tip to the suggested price and capping it.
+func TestSendTxArgsDynamicFees(t *testing.T) {
+	hexBig := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+
+	tests := []struct {
+		price, maxFee, maxTip *hexutil.Big
+		want                  int64
+		err                   error
+	}{
+		{want: 10},                     // Suggested price
+		{price: hexBig(7), want: 7},    // Legacy price kept
+		{maxFee: hexBig(20), want: 10}, // Tip defaults to the suggestion
+		{maxFee: hexBig(4), want: 4},   // Default tip capped by the fee cap
+		{maxTip: hexBig(3), want: 3},   // Explicit tip
+		{maxFee: hexBig(20), maxTip: hexBig(15), want: 15},
+		{maxFee: hexBig(5), maxTip: hexBig(6), err: errTipAboveCap},
+		{price: hexBig(7), maxTip: hexBig(3), err: errFeeKindsMixed},
+	}
+	for i, tt := range tests {
+		args := &SendTxArgs{To: &common.Address{}, GasPrice: tt.price, MaxFeePerGas: tt.maxFee, MaxPriorityFeePerGas: tt.maxTip}
+		err := args.setDefaults(context.Background(), &feeBackend{price: new(big.Int).SetInt64(10)})
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if args.GasPrice.ToInt().Int64() != tt.want {
+			t.Errorf("test %d: gas price mismatch: have %v, want %d", i, args.GasPrice, tt.want)
+		}
+		if args.MaxFeePerGas != nil || args.MaxPriorityFeePerGas != nil {
+			t.Errorf("test %d: dynamic fee fields left set", i)
+		}
+	}
+}
+
+// Tests that the dynamic fee fields of calls are mapped onto the gas price,
+// leaving a missing tip at zero.
+func TestCallGasPrice(t *testing.T) {
+	hexBig := func(n int64) *hexutil.Big { return (*hexutil.Big)(big.NewInt(n)) }
+
+	tests := []struct {
+		price          hexutil.Big
+		maxFee, maxTip *hexutil.Big
+		want           int64
+		err            error
+	}{
+		{want: 0},
+		{price: *hexBig(7), want: 7},
+		{maxFee: hexBig(20), want: 0},
+		{maxTip: hexBig(3), want: 3},
+		{maxFee: hexBig(20), maxTip: hexBig(15), want: 15},
+		{maxFee: hexBig(5), maxTip: hexBig(6), err: errTipAboveCap},
+		{price: *hexBig(7), maxFee: hexBig(20), err: errFeeKindsMixed},
+	}
+	for i, tt := range tests {
+		args := &CallArgs{GasPrice: tt.price, MaxFeePerGas: tt.maxFee, MaxPriorityFeePerGas: tt.maxTip}
+		price, err := args.callGasPrice()
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+			continue
+		}
+		if err == nil && price.Int64() != tt.want {
+			t.Errorf("test %d: gas price mismatch: have %v, want %d", i, price, tt.want)
+		}
+	}
+}
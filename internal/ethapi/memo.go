@@ -0,0 +1,115 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// maxHistoryRange is the maximum number of blocks a single wallet history query
+// may scan.
+const maxHistoryRange = 10000
+
+// WalletMemos contains all the transaction memos and address labels stored in
+// the local keystore.
+type WalletMemos struct {
+	Transactions map[common.Hash]string    `json:"transactions"`
+	Addresses    map[common.Address]string `json:"addresses"`
+}
+
+// WalletTransaction is a transaction of the wallet history, annotated with the
+// memo attached to it and the labels of its counterparties.
+type WalletTransaction struct {
+	*RPCTransaction
+	Memo      string `json:"memo,omitempty"`
+	FromLabel string `json:"fromLabel,omitempty"`
+	ToLabel   string `json:"toLabel,omitempty"`
+}
+
+// SetTransactionMemo attaches a private memo to a transaction, replacing any
+// previous one. An empty memo removes the annotation.
+func (s *PrivateAccountAPI) SetTransactionMemo(hash common.Hash, memo string) error {
+	return fetchKeystore(s.am).Memos().SetTransactionMemo(hash, memo)
+}
+
+// SetAddressLabel gives a private label to an address, replacing any previous
+// one. An empty label removes it.
+func (s *PrivateAccountAPI) SetAddressLabel(addr common.Address, label string) error {
+	return fetchKeystore(s.am).Memos().SetAddressLabel(addr, label)
+}
+
+// Memos returns all the transaction memos and address labels of the wallet.
+func (s *PrivateAccountAPI) Memos() (*WalletMemos, error) {
+	txs, addrs, err := fetchKeystore(s.am).Memos().Memos()
+	if err != nil {
+		return nil, err
+	}
+	return &WalletMemos{Transactions: txs, Addresses: addrs}, nil
+}
+
+// History returns the transactions sent from or to the given address within a
+// range of blocks, annotated with the memos and labels of the wallet.
+func (s *PrivateAccountAPI) History(ctx context.Context, addr common.Address, fromBlock, toBlock rpc.BlockNumber) ([]*WalletTransaction, error) {
+	from, err := ResolveBlockNumber(ctx, s.b, fromBlock)
+	if err != nil {
+		return nil, err
+	}
+	to, err := ResolveBlockNumber(ctx, s.b, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	if from > to {
+		return nil, fmt.Errorf("invalid block range %d-%d", from, to)
+	}
+	if to-from >= maxHistoryRange {
+		return nil, fmt.Errorf("block range %d-%d exceeds the limit of %d blocks", from, to, maxHistoryRange)
+	}
+	txs, addrs, err := fetchKeystore(s.am).Memos().Memos()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]*WalletTransaction, 0)
+	for number := from; number <= to; number++ {
+		block, err := s.b.BlockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			return nil, err
+		}
+		if block == nil {
+			break
+		}
+		for i := range block.Transactions() {
+			tx := newRPCTransactionFromBlockIndex(block, uint64(i))
+			if tx.From != addr && (tx.To == nil || *tx.To != addr) {
+				continue
+			}
+			entry := &WalletTransaction{
+				RPCTransaction: tx,
+				Memo:           txs[tx.Hash],
+				FromLabel:      addrs[tx.From],
+			}
+			if tx.To != nil {
+				entry.ToLabel = addrs[*tx.To]
+			}
+			history = append(history, entry)
+		}
+	}
+	return history, nil
+}
@@ -0,0 +1,423 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/state"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// maxSimulateBlocks is the maximum number of blocks in a simulation, gaps
+	// filled with empty blocks included.
+	maxSimulateBlocks = 256
+
+	// maxSimulateCalls is the maximum number of calls across all the blocks of
+	// a simulation.
+	maxSimulateCalls = 1000
+
+	// simulateTimeout is the maximum time allowed to run a whole simulation.
+	simulateTimeout = 10 * time.Second
+
+	// simulateTimeIncrement is the number of seconds between simulated blocks
+	// without a timestamp override.
+	simulateTimeIncrement = 12
+)
+
+var errEmptySimulation = errors.New("empty block simulation")
+
+// SimulateBlockOverrides replaces fields of the header of a simulated block.
+type SimulateBlockOverrides struct {
+	Number     *hexutil.Uint64 `json:"number"`
+	Time       *hexutil.Uint64 `json:"time"`
+	GasLimit   *hexutil.Uint64 `json:"gasLimit"`
+	Coinbase   *common.Address `json:"coinbase"` // Validator sealing the block, credited with the fees
+	MinerNum   *hexutil.Big    `json:"minerNum"`
+	Difficulty *hexutil.Big    `json:"difficulty"`
+}
+
+// SimulateAccountOverride replaces parts of the state of an account before the
+// calls of a simulated block are executed.
+type SimulateAccountOverride struct {
+	Nonce     *hexutil.Uint64             `json:"nonce"`
+	Balance   *hexutil.Big                `json:"balance"`
+	Code      *hexutil.Bytes              `json:"code"`
+	StateDiff map[common.Hash]common.Hash `json:"stateDiff"`
+}
+
+// SimulateBlock is a single block of a simulation.
+type SimulateBlock struct {
+	BlockOverrides *SimulateBlockOverrides                    `json:"blockOverrides"`
+	StateOverrides map[common.Address]SimulateAccountOverride `json:"stateOverrides"`
+	Calls          []BundleTxArgs                             `json:"calls"`
+}
+
+// SimulateOpts is the sequence of blocks to simulate.
+type SimulateOpts struct {
+	BlockStateCalls []SimulateBlock `json:"blockStateCalls"`
+
+	// Validation enforces nonces and balances like for real transactions, else
+	// nonces are ignored and senders funded like for eth_call.
+	Validation bool `json:"validation"`
+}
+
+// SimulateCallResult is the outcome of a single call of a simulated block.
+type SimulateCallResult struct {
+	TxHash          common.Hash     `json:"txHash"`
+	From            common.Address  `json:"from"`
+	To              *common.Address `json:"to"`
+	ContractAddress *common.Address `json:"contractAddress,omitempty"`
+	GasUsed         hexutil.Uint64  `json:"gasUsed"`
+	ReturnData      hexutil.Bytes   `json:"returnData"`
+	Status          hexutil.Uint64  `json:"status"`
+	Error           string          `json:"error,omitempty"`
+	Logs            []*types.Log    `json:"logs"`
+}
+
+// SimulateBlockResult is the outcome of a simulated block.
+type SimulateBlockResult struct {
+	Number           hexutil.Uint64        `json:"number"`
+	Hash             common.Hash           `json:"hash"`
+	ParentHash       common.Hash           `json:"parentHash"`
+	Timestamp        hexutil.Uint64        `json:"timestamp"`
+	Miner            common.Address        `json:"miner"`
+	MinerNum         *hexutil.Big          `json:"minerNum"`
+	Difficulty       *hexutil.Big          `json:"difficulty"`
+	GasLimit         hexutil.Uint64        `json:"gasLimit"`
+	GasUsed          hexutil.Uint64        `json:"gasUsed"`
+	StateRoot        common.Hash           `json:"stateRoot"`
+	TransactionsRoot common.Hash           `json:"transactionsRoot"`
+	ReceiptsRoot     common.Hash           `json:"receiptsRoot"`
+	LogsBloom        types.Bloom           `json:"logsBloom"`
+	Calls            []*SimulateCallResult `json:"calls"`
+}
+
+// SimulateV1 executes a sequence of blocks on top of the state of the given
+// block, each one seeing the effects of the previous ones. The header fields
+// and the state of accounts may be overridden per block, and missing block
+// numbers are filled with empty blocks. Calls that cannot be executed at all
+// are reported as errors and left out of their block. Consensus rewards are
+// not applied, and nothing is written to the chain or the transaction pool.
+func (s *PublicBlockChainAPI) SimulateV1(ctx context.Context, opts SimulateOpts, blockNr rpc.BlockNumber) ([]*SimulateBlockResult, error) {
+	defer func(start time.Time) {
+		log.Debug("Executing simulation finished", "blocks", len(opts.BlockStateCalls), "runtime", time.Since(start))
+	}(time.Now())
+
+	if len(opts.BlockStateCalls) == 0 {
+		return nil, errEmptySimulation
+	}
+	calls := 0
+	for _, block := range opts.BlockStateCalls {
+		calls += len(block.Calls)
+	}
+	if calls > maxSimulateCalls {
+		return nil, fmt.Errorf("simulation too large: %d calls, max %d", calls, maxSimulateCalls)
+	}
+	state, base, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	blocks, err := sanitizeSimulation(base, opts.BlockStateCalls)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the EVMs are cancelled once the simulation has finished or timed out
+	ctx, cancel := context.WithTimeout(ctx, simulateTimeout)
+	defer cancel()
+
+	sim := &simulator{
+		api:        s,
+		guard:      newEVMGuard(ctx),
+		state:      state,
+		base:       base,
+		validation: opts.Validation,
+		hashes:     make(map[uint64]common.Hash),
+	}
+	var (
+		parent  = base
+		results = make([]*SimulateBlockResult, 0, len(blocks))
+	)
+	for _, block := range blocks {
+		result, header, err := sim.process(ctx, parent, block)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+		parent = header
+	}
+	return results, nil
+}
+
+// sanitizeSimulation assigns numbers and timestamps to the blocks missing them,
+// checks that both are increasing and fills gaps in the numbers with empty
+// blocks.
+func sanitizeSimulation(base *types.Header, blocks []SimulateBlock) ([]SimulateBlock, error) {
+	var (
+		number = base.Number.Uint64()
+		time   = base.Time.Uint64()
+		res    []SimulateBlock
+	)
+	for i, block := range blocks {
+		overrides := SimulateBlockOverrides{}
+		if block.BlockOverrides != nil {
+			overrides = *block.BlockOverrides
+		}
+		next := number + 1
+		if overrides.Number != nil {
+			next = uint64(*overrides.Number)
+		}
+		if next <= number {
+			return nil, fmt.Errorf("simulated block %d: number %d not above %d", i, next, number)
+		}
+		if next-number > maxSimulateBlocks || len(res)+int(next-number) > maxSimulateBlocks {
+			return nil, fmt.Errorf("simulation too large: more than %d blocks", maxSimulateBlocks)
+		}
+		for number+1 < next {
+			number, time = number+1, time+simulateTimeIncrement
+
+			n, t := hexutil.Uint64(number), hexutil.Uint64(time)
+			res = append(res, SimulateBlock{BlockOverrides: &SimulateBlockOverrides{Number: &n, Time: &t}})
+		}
+		stamp := time + simulateTimeIncrement
+		if overrides.Time != nil {
+			stamp = uint64(*overrides.Time)
+		}
+		if stamp <= time {
+			return nil, fmt.Errorf("simulated block %d: timestamp %d not above %d", i, stamp, time)
+		}
+		number, time = next, stamp
+
+		n, t := hexutil.Uint64(number), hexutil.Uint64(time)
+		overrides.Number, overrides.Time = &n, &t
+
+		block.BlockOverrides = &overrides
+		res = append(res, block)
+	}
+	return res, nil
+}
+
+// simulator executes the blocks of a simulation on a shared state.
+type simulator struct {
+	api        *PublicBlockChainAPI
+	guard      *evmGuard
+	state      *state.StateDB
+	base       *types.Header
+	validation bool
+
+	hashes map[uint64]common.Hash // Hashes of the simulated blocks by number
+}
+
+// process executes a simulated block on top of its parent, returning its result
+// and header.
+func (sim *simulator) process(ctx context.Context, parent *types.Header, block SimulateBlock) (*SimulateBlockResult, *types.Header, error) {
+	header := sim.header(parent, block.BlockOverrides)
+	if err := sim.override(block.StateOverrides); err != nil {
+		return nil, nil, err
+	}
+	var (
+		config   = sim.api.b.ChainConfig()
+		signer   = types.MakeSigner(config, header.Number)
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		result   = &SimulateBlockResult{Calls: make([]*SimulateCallResult, 0, len(block.Calls))}
+		txs      types.Transactions
+		receipts types.Receipts
+	)
+	for i, args := range block.Calls {
+		msg, tx, err := sim.api.bundleMessage(args, signer, sim.state, gp.Gas())
+		if err != nil {
+			return nil, nil, fmt.Errorf("block %d call %d: %v", header.Number, i, err)
+		}
+		if !sim.validation {
+			msg = types.NewMessage(msg.From(), msg.To(), msg.Nonce(), msg.Value(), msg.Gas(), msg.GasPrice(), msg.Data(), false)
+		}
+		res := &SimulateCallResult{TxHash: tx.Hash(), From: msg.From(), To: msg.To(), Logs: []*types.Log{}}
+		result.Calls = append(result.Calls, res)
+
+		receipt, ret, err := sim.apply(ctx, header, msg, tx, gp, len(txs))
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, fmt.Errorf("simulation aborted (timeout = %v)", simulateTimeout)
+			}
+			if _, ok := err.(evmFailure); ok {
+				return nil, nil, err
+			}
+			res.Error = err.Error()
+			continue
+		}
+		header.GasUsed += receipt.GasUsed
+		receipt.CumulativeGasUsed = header.GasUsed
+
+		txs, receipts = append(txs, tx), append(receipts, receipt)
+		res.GasUsed, res.ReturnData, res.Status, res.Logs = hexutil.Uint64(receipt.GasUsed), ret, hexutil.Uint64(receipt.Status), receipt.Logs
+		if msg.To() == nil {
+			res.ContractAddress = &receipt.ContractAddress
+		}
+	}
+	// Seal the simulated block and attribute the logs to it
+	header.Root = sim.state.IntermediateRoot(config.IsEIP158(header.Number))
+	header.TxHash = types.DeriveSha(txs)
+	header.ReceiptHash = types.DeriveSha(receipts)
+	header.Bloom = types.CreateBloom(receipts)
+
+	hash := header.Hash()
+	sim.hashes[header.Number.Uint64()] = hash
+
+	index := uint(0)
+	for _, receipt := range receipts {
+		for _, l := range receipt.Logs {
+			l.BlockNumber, l.BlockHash, l.Index = header.Number.Uint64(), hash, index
+			index++
+		}
+	}
+	result.Number = hexutil.Uint64(header.Number.Uint64())
+	result.Hash, result.ParentHash = hash, header.ParentHash
+	result.Timestamp = hexutil.Uint64(header.Time.Uint64())
+	result.Miner, result.MinerNum, result.Difficulty = header.Coinbase, (*hexutil.Big)(header.MinerNum), (*hexutil.Big)(header.Difficulty)
+	result.GasLimit, result.GasUsed = hexutil.Uint64(header.GasLimit), hexutil.Uint64(header.GasUsed)
+	result.StateRoot, result.TransactionsRoot, result.ReceiptsRoot = header.Root, header.TxHash, header.ReceiptHash
+	result.LogsBloom = header.Bloom
+
+	return result, header, nil
+}
+
+// evmFailure wraps a failure of the EVM itself, aborting the whole simulation.
+type evmFailure struct{ error }
+
+// apply executes a single call of a simulated block, creating its receipt.
+func (sim *simulator) apply(ctx context.Context, header *types.Header, msg types.Message, tx *types.Transaction, gp *core.GasPool, index int) (*types.Receipt, []byte, error) {
+	config := sim.api.b.ChainConfig()
+
+	// Execute the call, attributing its logs to the transaction
+	sim.state.Prepare(tx.Hash(), common.Hash{}, index)
+
+	balance := new(big.Int).Set(sim.state.GetBalance(msg.From()))
+	evm, evmError, err := sim.api.b.GetEVM(ctx, msg, sim.state, header, vm.Config{})
+	if err != nil {
+		return nil, nil, evmFailure{err}
+	}
+	if sim.validation {
+		sim.state.SetBalance(msg.From(), balance)
+	}
+	// The backend knows neither the validator of the simulated block, nor the
+	// hashes of its simulated ancestors
+	getHash := evm.GetHash
+	evm.Coinbase = header.Coinbase
+	evm.GetHash = func(n uint64) common.Hash {
+		if hash, ok := sim.hashes[n]; ok {
+			return hash
+		}
+		if n > sim.base.Number.Uint64() {
+			return common.Hash{}
+		}
+		return getHash(n)
+	}
+	sim.guard.watch(evm)
+
+	ret, gas, failed, err := core.ApplyMessage(evm, msg, gp)
+	if err := evmError(); err != nil {
+		return nil, nil, evmFailure{err}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if ctx.Err() != nil {
+		return nil, nil, ctx.Err()
+	}
+	// Create the receipt the same way block processing does
+	var root []byte
+	if config.IsByzantium(header.Number) {
+		sim.state.Finalise(true)
+	} else {
+		root = sim.state.IntermediateRoot(config.IsEIP158(header.Number)).Bytes()
+	}
+	receipt := types.NewReceipt(root, failed, 0)
+	receipt.TxHash = tx.Hash()
+	receipt.GasUsed = gas
+	if msg.To() == nil {
+		receipt.ContractAddress = crypto.CreateAddress(msg.From(), msg.Nonce())
+	}
+	receipt.Logs = sim.state.GetLogs(tx.Hash())
+	if receipt.Logs == nil {
+		receipt.Logs = []*types.Log{}
+	}
+	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+
+	return receipt, ret, nil
+}
+
+// header creates the header of a simulated block on top of its parent, with the
+// requested fields overridden.
+func (sim *simulator) header(parent *types.Header, overrides *SimulateBlockOverrides) *types.Header {
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		UncleHash:  types.EmptyUncleHash,
+		Coinbase:   parent.Coinbase,
+		MinerNum:   new(big.Int),
+		Difficulty: new(big.Int).Set(parent.Difficulty),
+		Number:     new(big.Int).SetUint64(uint64(*overrides.Number)),
+		GasLimit:   parent.GasLimit,
+		Time:       new(big.Int).SetUint64(uint64(*overrides.Time)),
+	}
+	if parent.MinerNum != nil {
+		header.MinerNum.Set(parent.MinerNum)
+	}
+	if overrides.GasLimit != nil {
+		header.GasLimit = uint64(*overrides.GasLimit)
+	}
+	if overrides.Coinbase != nil {
+		header.Coinbase = *overrides.Coinbase
+	}
+	if overrides.MinerNum != nil {
+		header.MinerNum.Set(overrides.MinerNum.ToInt())
+	}
+	if overrides.Difficulty != nil {
+		header.Difficulty.Set(overrides.Difficulty.ToInt())
+	}
+	return header
+}
+
+// override applies the state overrides of a simulated block.
+func (sim *simulator) override(overrides map[common.Address]SimulateAccountOverride) error {
+	for addr, account := range overrides {
+		if account.Nonce != nil {
+			sim.state.SetNonce(addr, uint64(*account.Nonce))
+		}
+		if account.Balance != nil {
+			sim.state.SetBalance(addr, account.Balance.ToInt())
+		}
+		if account.Code != nil {
+			sim.state.SetCode(addr, *account.Code)
+		}
+		for key, value := range account.StateDiff {
+			sim.state.SetState(addr, key, value)
+		}
+	}
+	return sim.state.Error()
+}
@@ -0,0 +1,170 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/common/hexutil"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/params"
+	"github.com/usechain/go-usechain/rlp"
+	"github.com/usechain/go-usechain/rpc"
+)
+
+// simulateBlock creates a simulated block with the given number, calling the
+// counter contract the given number of times.
+func simulateBlock(number uint64, counter common.Address, calls int) SimulateBlock {
+	n := hexutil.Uint64(number)
+	block := SimulateBlock{BlockOverrides: &SimulateBlockOverrides{Number: &n}}
+	for i := 0; i < calls; i++ {
+		block.Calls = append(block.Calls, BundleTxArgs{CallArgs: CallArgs{From: common.Address{0xca}, To: &counter, Gas: 100000}})
+	}
+	return block
+}
+
+// Tests that simulations are limited in the number of blocks and calls they
+// contain, as well as in their running time.
+func TestSimulateLimits(t *testing.T) {
+	var (
+		counter = common.Address{0xc0}
+		loop    = common.Address{0x1f}
+	)
+	api := NewPublicBlockChainAPI(newTestBackend(core.GenesisAlloc{
+		counter: {Balance: new(big.Int), Code: counterCode},
+		loop:    {Balance: new(big.Int), Code: loopCode},
+	}))
+	tests := []struct {
+		blocks []SimulateBlock
+		count  int  // Number of simulated blocks expected, gaps included
+		fail   bool // Whether the simulation must be rejected
+	}{
+		// Empty simulations are rejected
+		{blocks: nil, fail: true},
+		// Gaps are filled up to the block limit
+		{blocks: []SimulateBlock{simulateBlock(10+maxSimulateBlocks, counter, 1)}, count: maxSimulateBlocks},
+		{blocks: []SimulateBlock{simulateBlock(11+maxSimulateBlocks, counter, 1)}, fail: true},
+		{blocks: []SimulateBlock{simulateBlock(100, counter, 1), simulateBlock(11+maxSimulateBlocks, counter, 1)}, fail: true},
+		// Calls are counted across blocks
+		{blocks: []SimulateBlock{simulateBlock(11, counter, maxSimulateCalls/2), simulateBlock(12, counter, maxSimulateCalls/2)}, count: 2},
+		{blocks: []SimulateBlock{simulateBlock(11, counter, maxSimulateCalls/2), simulateBlock(12, counter, maxSimulateCalls/2+1)}, fail: true},
+		// Block numbers must increase
+		{blocks: []SimulateBlock{simulateBlock(10, counter, 1)}, fail: true},
+		{blocks: []SimulateBlock{simulateBlock(12, counter, 1), simulateBlock(12, counter, 1)}, fail: true},
+	}
+	for i, tt := range tests {
+		results, err := api.SimulateV1(context.Background(), SimulateOpts{BlockStateCalls: tt.blocks}, rpc.LatestBlockNumber)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("test %d: expected failure", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: failed to simulate: %v", i, err)
+			continue
+		}
+		if len(results) != tt.count {
+			t.Errorf("test %d: block count mismatch: have %d, want %d", i, len(results), tt.count)
+		}
+	}
+	// Simulations running past their deadline are aborted
+	block := simulateBlock(11, counter, 1)
+	block.Calls = append(block.Calls, BundleTxArgs{CallArgs: CallArgs{From: common.Address{0xca}, To: &loop}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := api.SimulateV1(ctx, SimulateOpts{BlockStateCalls: []SimulateBlock{block}}, rpc.LatestBlockNumber); err == nil || !strings.Contains(err.Error(), "aborted") {
+		t.Fatalf("error mismatch: have %v, want simulation abort", err)
+	}
+	if elapsed := time.Since(start); elapsed > simulateTimeout {
+		t.Errorf("simulation not cancelled in time: took %v", elapsed)
+	}
+}
+
+// Tests that the state overrides of a simulated block apply to its calls and
+// carry over to the following blocks.
+func TestSimulateStateOverrides(t *testing.T) {
+	var (
+		key, _  = crypto.GenerateKey()
+		sender  = crypto.PubkeyToAddress(key.PublicKey)
+		counter = common.Address{0xc0}
+		funds   = big.NewInt(params.Use)
+	)
+	api := NewPublicBlockChainAPI(newTestBackend(core.GenesisAlloc{
+		sender:               {Balance: funds},
+		common.Address{0xca}: {Balance: funds},
+	}))
+	signer := types.MakeSigner(params.TestChainConfig, big.NewInt(11))
+	signed := func(nonce uint64) BundleTxArgs {
+		tx, _ := types.SignTx(types.NewTransaction(nonce, counter, new(big.Int), 100000, big.NewInt(1), nil), signer, key)
+		raw, _ := rlp.EncodeToBytes(tx)
+		return BundleTxArgs{Raw: raw}
+	}
+	var (
+		code  = hexutil.Bytes(counterCode)
+		nonce = hexutil.Uint64(5)
+	)
+	first := simulateBlock(11, counter, 1)
+	first.StateOverrides = map[common.Address]SimulateAccountOverride{
+		counter: {Code: &code, StateDiff: map[common.Hash]common.Hash{{}: common.BigToHash(big.NewInt(41))}},
+		sender:  {Nonce: &nonce},
+	}
+	first.Calls = append(first.Calls, signed(5))
+
+	second := simulateBlock(12, counter, 1)
+	second.StateOverrides = map[common.Address]SimulateAccountOverride{
+		sender: {Balance: (*hexutil.Big)(new(big.Int))},
+	}
+	second.Calls = append(second.Calls, signed(6))
+
+	results, err := api.SimulateV1(context.Background(), SimulateOpts{BlockStateCalls: []SimulateBlock{first, second}, Validation: true}, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to simulate: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("block count mismatch: have %d, want %d", len(results), 2)
+	}
+	// The overridden code and storage are used, and the overridden nonce accepted
+	calls := results[0].Calls
+	if have := new(big.Int).SetBytes(calls[0].ReturnData).Uint64(); calls[0].Error != "" || have != 42 {
+		t.Errorf("overridden counter mismatch: have %d, error %q, want %d", have, calls[0].Error, 42)
+	}
+	if have := new(big.Int).SetBytes(calls[1].ReturnData).Uint64(); calls[1].Error != "" || calls[1].Status != hexutil.Uint64(types.ReceiptStatusSuccessful) || have != 43 {
+		t.Errorf("overridden nonce call mismatch: have %d, status %d, error %q", have, calls[1].Status, calls[1].Error)
+	}
+	// The overrides carry over, the emptied balance can't pay for the gas
+	calls = results[1].Calls
+	if have := new(big.Int).SetBytes(calls[0].ReturnData).Uint64(); calls[0].Error != "" || have != 44 {
+		t.Errorf("carried over counter mismatch: have %d, error %q, want %d", have, calls[0].Error, 44)
+	}
+	if !strings.Contains(calls[1].Error, "insufficient") {
+		t.Errorf("overridden balance not enforced: error %q", calls[1].Error)
+	}
+	if results[1].ParentHash != results[0].Hash {
+		t.Errorf("parent hash mismatch: have %x, want %x", results[1].ParentHash, results[0].Hash)
+	}
+}
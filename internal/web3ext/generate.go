@@ -0,0 +1,43 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package web3ext
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// Generate assembles a web3.js extension binding the given methods of an RPC
+// namespace, each mapped to the number of arguments it accepts. It is used to
+// make APIs without a hand-written extension above callable from the console.
+// Bindings pass their arguments through unformatted.
+func Generate(namespace string, methods map[string]int) string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "web3._extend({\n\tproperty: '%s',\n\tmethods: [\n", namespace)
+	for _, name := range names {
+		fmt.Fprintf(buf, "\t\tnew web3._extend.Method({\n\t\t\tname: '%s',\n\t\t\tcall: '%s_%s',\n\t\t\tparams: %d\n\t\t}),\n", name, namespace, name, methods[name])
+	}
+	buf.WriteString("\t]\n});\n")
+	return buf.String()
+}
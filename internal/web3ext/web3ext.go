@@ -19,8 +19,10 @@ package web3ext
 
 var Modules = map[string]string{
 	"admin":      Admin_JS,
+	"bridge":     Bridge_JS,
 	"chequebook": Chequebook_JS,
 	"clique":     Clique_JS,
+	"committee":  Committee_JS,
 	"debug":      Debug_JS,
 	"eth":        Eth_JS,
 	"miner":      Miner_JS,
@@ -30,8 +32,59 @@ var Modules = map[string]string{
 	"shh":        Shh_JS,
 	"swarmfs":    SWARMFS_JS,
 	"txpool":     TxPool_JS,
+	"usx":        Usx_JS,
 }
 
+const Bridge_JS = `
+web3._extend({
+	property: 'bridge',
+	methods: [
+		new web3._extend.Method({
+			name: 'getHeaderByNumber',
+			call: 'bridge_headerByNumber',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getHeaderByHash',
+			call: 'bridge_headerByHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'submitHeaders',
+			call: 'bridge_submitHeaders',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'submitDeposit',
+			call: 'bridge_submitDeposit',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getDeposit',
+			call: 'bridge_deposit',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getDeposits',
+			call: 'bridge_deposits',
+			params: 2,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
+		}),
+	],
+	properties: [
+		new web3._extend.Property({
+			name: 'head',
+			getter: 'bridge_head'
+		}),
+		new web3._extend.Property({
+			name: 'signers',
+			getter: 'bridge_signers'
+		}),
+	]
+});
+`
+
 const Chequebook_JS = `
 web3._extend({
 	property: 'chequebook',
@@ -139,6 +192,10 @@ web3._extend({
 			call: 'admin_sleepBlocks',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'upgrade',
+			call: 'admin_upgrade'
+		}),
 		new web3._extend.Method({
 			name: 'startRPC',
 			call: 'admin_startRPC',
@@ -169,10 +226,39 @@ web3._extend({
 			name: 'peers',
 			getter: 'admin_peers'
 		}),
+		new web3._extend.Property({
+			name: 'peerStats',
+			getter: 'admin_peerStats'
+		}),
+		new web3._extend.Property({
+			name: 'effectiveConfig',
+			getter: 'admin_effectiveConfig'
+		}),
+		new web3._extend.Property({
+			name: 'syncStatus',
+			getter: 'admin_syncStatus'
+		}),
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'cursors',
+			getter: 'admin_cursors'
+		}),
+	]
+});
+`
+
+const Committee_JS = `
+web3._extend({
+	property: 'committee',
+	methods: [],
+	properties: [
+		new web3._extend.Property({
+			name: 'liveness',
+			getter: 'committee_liveness'
+		}),
 	]
 });
 `
@@ -206,6 +292,18 @@ web3._extend({
 			call: 'debug_dumpBlock',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'exportIdentities',
+			call: 'debug_exportIdentities',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'exportIdentitiesPaged',
+			call: 'debug_exportIdentitiesPaged',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'chaindbProperty',
 			call: 'debug_chaindbProperty',
@@ -231,6 +329,16 @@ web3._extend({
 			call: 'debug_vmodule',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'setLogLevel',
+			call: 'debug_setLogLevel',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'resetLogLevel',
+			call: 'debug_resetLogLevel',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'backtraceAt',
 			call: 'debug_backtraceAt',
@@ -339,6 +447,12 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceChainPaged',
+			call: 'debug_traceChainPaged',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'traceBlockByNumber',
 			call: 'debug_traceBlockByNumber',
@@ -385,8 +499,18 @@ web3._extend({
 			params: 2,
 			inputFormatter:[null, null],
 		}),
+		new web3._extend.Method({
+			name: 'resetCircuitBreaker',
+			call: 'debug_resetCircuitBreaker',
+			params: 0
+		}),
 	],
-	properties: []
+	properties: [
+		new web3._extend.Property({
+			name: 'circuitBreaker',
+			getter: 'debug_circuitBreaker'
+		}),
+	]
 });
 `
 
@@ -394,6 +518,17 @@ const Eth_JS = `
 web3._extend({
 	property: 'eth',
 	methods: [
+		new web3._extend.Method({
+			name: 'getLogsPaged',
+			call: 'eth_getLogsPaged',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'getDelegations',
+			call: 'eth_getDelegations',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'sign',
 			call: 'eth_sign',
@@ -423,6 +558,30 @@ web3._extend({
 			call: 'eth_getRawTransactionByHash',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'callBundle',
+			call: 'eth_callBundle',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'simulateV1',
+			call: 'eth_simulateV1',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'eth_feeHistory',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'getBalanceChanges',
+			call: 'eth_getBalanceChanges',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 		new web3._extend.Method({
 			name: 'getRawTransactionFromBlock',
 			call: function(args) {
@@ -445,6 +604,16 @@ web3._extend({
 				return formatted;
 			}
 		}),
+		new web3._extend.Property({
+			name: 'maxPriorityFeePerGas',
+			getter: 'eth_maxPriorityFeePerGas',
+			outputFormatter: web3._extend.formatters.outputBigNumberFormatter
+		}),
+		new web3._extend.Property({
+			name: 'chainId',
+			getter: 'eth_chainId',
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
 	]
 });
 `
@@ -525,6 +694,12 @@ web3._extend({
 			call: 'personal_importRawKey',
 			params: 2
 		}),
+		new web3._extend.Method({
+			name: 'importMnemonic',
+			call: 'personal_importMnemonic',
+			params: 4,
+			inputFormatter: [null, null, null, null]
+		}),
 		new web3._extend.Method({
 			name: 'sign',
 			call: 'personal_sign',
@@ -552,12 +727,47 @@ web3._extend({
 			params: 2,
 			inputFormatter: [web3._extend.formatters.inputTransactionFormatter, null]
 		}),
+		new web3._extend.Method({
+			name: 'approve',
+			call: 'personal_approve',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'reject',
+			call: 'personal_reject',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setTransactionMemo',
+			call: 'personal_setTransactionMemo',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'setAddressLabel',
+			call: 'personal_setAddressLabel',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'history',
+			call: 'personal_history',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
 	],
 	properties: [
 		new web3._extend.Property({
 			name: 'listWallets',
 			getter: 'personal_listWallets'
 		}),
+		new web3._extend.Property({
+			name: 'pendingRequests',
+			getter: 'personal_pendingRequests'
+		}),
+		new web3._extend.Property({
+			name: 'memos',
+			getter: 'personal_memos'
+		}),
 	]
 })
 `
@@ -565,12 +775,27 @@ web3._extend({
 const RPC_JS = `
 web3._extend({
 	property: 'rpc',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'nextPage',
+			call: 'rpc_nextPage',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'closeCursor',
+			call: 'rpc_closeCursor',
+			params: 1
+		}),
+	],
 	properties: [
 		new web3._extend.Property({
 			name: 'modules',
 			getter: 'rpc_modules'
 		}),
+		new web3._extend.Property({
+			name: 'methods',
+			getter: 'rpc_methods'
+		}),
 	]
 });
 `
@@ -622,7 +847,13 @@ web3._extend({
 const TxPool_JS = `
 web3._extend({
 	property: 'txpool',
-	methods: [],
+	methods: [
+		new web3._extend.Method({
+			name: 'contentPaged',
+			call: 'txpool_contentPaged',
+			params: 1
+		}),
+	],
 	properties:
 	[
 		new web3._extend.Property({
@@ -645,3 +876,39 @@ web3._extend({
 	]
 });
 `
+
+const Usx_JS = `
+web3._extend({
+	property: 'usx',
+	methods: [
+		new web3._extend.Method({
+			name: 'feeHistory',
+			call: 'usx_feeHistory',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'replaceTransaction',
+			call: 'usx_replaceTransaction',
+			params: 2,
+			inputFormatter: [null, web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'cancelTransaction',
+			call: 'usx_cancelTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'trackTransaction',
+			call: 'usx_trackTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getActivityBlocks',
+			call: 'usx_getActivityBlocks',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+	]
+});
+`
@@ -30,6 +30,7 @@ import (
 	"github.com/usechain/go-usechain/core/vm"
 	"github.com/usechain/go-usechain/eth/downloader"
 	"github.com/usechain/go-usechain/eth/gasprice"
+	"github.com/usechain/go-usechain/eth/txtracker"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
 	"github.com/usechain/go-usechain/light"
@@ -56,10 +57,16 @@ func (b *LesApiBackend) SetHead(number uint64) {
 }
 
 func (b *LesApiBackend) HeaderByNumber(ctx context.Context, blockNr rpc.BlockNumber) (*types.Header, error) {
-	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+	switch blockNr {
+	case rpc.LatestBlockNumber, rpc.PendingBlockNumber:
 		return b.eth.blockchain.CurrentHeader(), nil
+	case rpc.SafeBlockNumber:
+		head := b.eth.blockchain.CurrentHeader().Number.Uint64()
+		return b.eth.blockchain.GetHeaderByNumberOdr(ctx, core.FinalityMarker(head, b.eth.config.SafeDepth))
+	case rpc.FinalizedBlockNumber:
+		head := b.eth.blockchain.CurrentHeader().Number.Uint64()
+		return b.eth.blockchain.GetHeaderByNumberOdr(ctx, core.FinalityMarker(head, b.eth.config.FinalityDepth))
 	}
-
 	return b.eth.blockchain.GetHeaderByNumberOdr(ctx, uint64(blockNr))
 }
 
@@ -121,18 +128,48 @@ func (b *LesApiBackend) GetPoolNonce(ctx context.Context, addr common.Address) (
 	return b.eth.txPool.GetNonce(ctx, addr)
 }
 
+// PriceBump returns the replacement price bump of the default transaction pool
+// configuration, as light clients forward their transactions to remote pools.
+func (b *LesApiBackend) PriceBump() uint64 {
+	return core.DefaultTxPoolConfig.PriceBump
+}
+
 func (b *LesApiBackend) Stats() (pending int, queued int) {
 	return b.eth.txPool.Stats(), 0
 }
 
+func (b *LesApiBackend) TxPoolLocals() []common.Address {
+	return nil
+}
+
 func (b *LesApiBackend) TxPoolContent() (map[common.Address]types.Transactions, map[common.Address]types.Transactions) {
 	return b.eth.txPool.Content()
 }
 
+func (b *LesApiBackend) TxPoolContentRange(start core.TxPoolKey, limit int) (types.Transactions, types.Transactions, *core.TxPoolKey) {
+	pending, queued := b.eth.txPool.Content()
+	return core.ContentRange(pending, queued, start, limit)
+}
+
 func (b *LesApiBackend) SubscribeTxPreEvent(ch chan<- core.TxPreEvent) event.Subscription {
 	return b.eth.txPool.SubscribeTxPreEvent(ch)
 }
 
+// SubscribeTxDropEvent returns a subscription which never fires, as the light
+// pool only tracks locally sent transactions until they are mined.
+func (b *LesApiBackend) SubscribeTxDropEvent(ch chan<- core.TxDropEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		return nil
+	})
+}
+
+// TrackTransaction is not supported by light clients, whose pool resends local
+// transactions on its own until mined.
+func (b *LesApiBackend) TrackTransaction(hash common.Hash) (*txtracker.Status, error) {
+	return nil, txtracker.ErrDisabled
+}
+
 func (b *LesApiBackend) SubscribeChainEvent(ch chan<- core.ChainEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeChainEvent(ch)
 }
@@ -141,6 +178,41 @@ func (b *LesApiBackend) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) e
 	return b.eth.blockchain.SubscribeChainHeadEvent(ch)
 }
 
+// SubscribeFinalizedHeadEvent derives finalized head notifications from the
+// light chain's head events, as the light chain does not track finality itself.
+func (b *LesApiBackend) SubscribeFinalizedHeadEvent(ch chan<- core.FinalizedHeadEvent) event.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		heads := make(chan core.ChainHeadEvent, 10)
+		sub := b.eth.blockchain.SubscribeChainHeadEvent(heads)
+		defer sub.Unsubscribe()
+
+		var last *types.Header
+		for {
+			select {
+			case ev := <-heads:
+				number := core.FinalityMarker(ev.Block.NumberU64(), b.eth.config.FinalityDepth)
+				if last != nil && number <= last.Number.Uint64() {
+					continue
+				}
+				header := b.eth.blockchain.GetHeaderByNumber(number)
+				if header == nil {
+					continue
+				}
+				last = header
+				select {
+				case ch <- core.FinalizedHeadEvent{Block: types.NewBlockWithHeader(header)}:
+				case <-quit:
+					return nil
+				}
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
 func (b *LesApiBackend) SubscribeChainSideEvent(ch chan<- core.ChainSideEvent) event.Subscription {
 	return b.eth.blockchain.SubscribeChainSideEvent(ch)
 }
@@ -165,6 +237,10 @@ func (b *LesApiBackend) SuggestPrice(ctx context.Context) (*big.Int, error) {
 	return b.gpo.SuggestPrice(ctx)
 }
 
+func (b *LesApiBackend) FeeHistory(ctx context.Context, blocks int, lastBlock rpc.BlockNumber, percentiles []float64) (*big.Int, [][]*big.Int, []float64, error) {
+	return b.gpo.FeeHistory(ctx, blocks, lastBlock, percentiles)
+}
+
 func (b *LesApiBackend) ChainDb() ethdb.Database {
 	return b.eth.chainDb
 }
@@ -521,20 +521,19 @@ func (r *BloomRequest) Validate(db ethdb.Database, msg *Msg) error {
 	nodeSet := proofs.NodeSet()
 	reads := &readTraceDB{db: nodeSet}
 
-	r.BloomBits = make([][]byte, len(r.SectionIdxList))
-
-	// Verify the proofs
-	var encNumber [10]byte
-	binary.BigEndian.PutUint16(encNumber[:2], uint16(r.BitIdx))
-
+	// Verify the proofs of all the sections at once, they share most nodes
+	keys := make([][]byte, len(r.SectionIdxList))
 	for i, idx := range r.SectionIdxList {
+		encNumber := make([]byte, 10)
+		binary.BigEndian.PutUint16(encNumber[:2], uint16(r.BitIdx))
 		binary.BigEndian.PutUint64(encNumber[2:], idx)
-		value, err, _ := trie.VerifyProof(r.BloomTrieRoot, encNumber[:], reads)
-		if err != nil {
-			return err
-		}
-		r.BloomBits[i] = value
+		keys[i] = encNumber
+	}
+	values, err := trie.VerifyProofBatch(r.BloomTrieRoot, keys, reads)
+	if err != nil {
+		return err
 	}
+	r.BloomBits = values
 
 	if len(reads.reads) != nodeSet.KeyCount() {
 		return errUselessNodes
@@ -142,20 +142,41 @@ func (self *LightChain) Odr() OdrBackend {
 // loadLastState loads the last known chain state from the database. This method
 // assumes that the chain manager mutex is held.
 func (self *LightChain) loadLastState() error {
-	if head := core.GetHeadHeaderHash(self.chainDb); head == (common.Hash{}) {
-		// Corrupt or empty database, init from scratch
-		self.Reset()
-	} else {
-		if header := self.GetHeaderByHash(head); header != nil {
-			self.hc.SetCurrentHeader(header)
+	head := core.GetHeadHeaderHash(self.chainDb)
+
+	number := core.GetBlockNumber(self.chainDb, head)
+	if !self.complete(head, number) {
+		// Head marker lost or pointing to incomplete data, resume from the last
+		// complete header if there is any, only init from scratch otherwise
+		marker := head
+
+		var ok bool
+		if head, number, ok = self.recoverHead(marker); !ok {
+			self.Reset()
+			return self.loadedLastState()
 		}
+		log.Warn("Recovered light chain head", "number", number, "hash", head, "marker", marker)
 	}
+	if hash, number := self.extendHead(head, number); hash != head {
+		log.Warn("Advanced light chain head over stored headers", "number", number, "hash", hash)
+		head = hash
+	}
+	if header := self.GetHeaderByHash(head); header != nil {
+		self.hc.SetCurrentHeader(header)
+	}
+	self.restoreCht()
+	return self.loadedLastState()
+}
 
-	// Issue a status log and return
+// loadedLastState issues a status log about the loaded head.
+func (self *LightChain) loadedLastState() error {
 	header := self.hc.CurrentHeader()
 	headerTd := self.GetTd(header.Hash(), header.Number.Uint64())
 	log.Info("Loaded most recent local header", "number", header.Number, "hash", header.Hash(), "td", headerTd)
 
+	if progress := ReadSyncProgress(self.chainDb); progress != nil {
+		log.Info("Loaded light sync progress", "head", progress.Head, "cht", progress.ChtSections, "age", common.PrettyDuration(time.Since(time.Unix(int64(progress.Updated), 0))))
+	}
 	return nil
 }
 
@@ -167,6 +188,7 @@ func (bc *LightChain) SetHead(head uint64) {
 
 	bc.hc.SetHead(head, nil)
 	bc.loadLastState()
+	bc.storeProgress()
 }
 
 // GasLimit returns the gas limit of the current HEAD block.
@@ -297,6 +319,7 @@ func (bc *LightChain) Stop() {
 	atomic.StoreInt32(&bc.procInterrupt, 1)
 
 	bc.wg.Wait()
+	bc.storeProgress()
 	log.Info("Blockchain manager stopped")
 }
 
@@ -313,6 +336,7 @@ func (self *LightChain) Rollback(chain []common.Hash) {
 			self.hc.SetCurrentHeader(self.GetHeader(head.ParentHash, head.Number.Uint64()-1))
 		}
 	}
+	self.storeProgress()
 }
 
 // postChainEvents iterates over the events generated by a chain insertion and
@@ -378,6 +402,10 @@ func (self *LightChain) InsertHeaderChain(chain []*types.Header, checkFreq int)
 	}
 	i, err := self.hc.InsertHeaderChain(chain, whFunc, start)
 	self.postChainEvents(events)
+
+	// Record the progress made, to resume from it even if the head marker is lost
+	self.storeProgress()
+
 	return i, err
 }
 
@@ -454,6 +482,7 @@ func (self *LightChain) SyncCht(ctx context.Context) bool {
 			self.mu.Lock()
 			if self.hc.CurrentHeader().Number.Uint64() < header.Number.Uint64() {
 				self.hc.SetCurrentHeader(header)
+				self.storeProgress()
 			}
 			self.mu.Unlock()
 			return true
@@ -275,6 +275,10 @@ func (odr *dummyOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 	return nil
 }
 
+func (odr *dummyOdr) ChtIndexer() *core.ChainIndexer {
+	return nil
+}
+
 // Tests that reorganizing a long difficult chain after a short easy one
 // overwrites the canonical numbers and links in the database.
 func TestReorgLongHeaders(t *testing.T) {
@@ -346,3 +350,65 @@ func TestReorgBadHeaderHashes(t *testing.T) {
 		t.Errorf("last header hash mismatch: have: %x, want %x", ncm.CurrentHeader().Hash(), headers[2].Hash())
 	}
 }
+
+// Tests that a light chain whose head marker got lost resumes from the recorded
+// sync progress instead of restarting from genesis.
+func TestRecoverLostHead(t *testing.T) {
+	db, chain, err := newCanonical(100)
+	if err != nil {
+		t.Fatalf("failed to create canonical chain: %v", err)
+	}
+	head := chain.CurrentHeader()
+	db.Delete([]byte("LastHeader"))
+
+	chain, _ = NewLightChain(&dummyOdr{db: db}, params.TestChainConfig, ethash.NewFaker())
+	if have := chain.CurrentHeader().Hash(); have != head.Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", chain.CurrentHeader().Number, head.Number)
+	}
+}
+
+// Tests that a light chain whose head header is incomplete resumes from the
+// last complete header below it.
+func TestRecoverIncompleteHead(t *testing.T) {
+	db, chain, err := newCanonical(100)
+	if err != nil {
+		t.Fatalf("failed to create canonical chain: %v", err)
+	}
+	head := chain.CurrentHeader()
+	core.DeleteTd(db, head.Hash(), head.Number.Uint64())
+
+	chain, _ = NewLightChain(&dummyOdr{db: db}, params.TestChainConfig, ethash.NewFaker())
+	if have := chain.CurrentHeader().Hash(); have != head.ParentHash {
+		t.Fatalf("head mismatch: have #%d, want #%d", chain.CurrentHeader().Number, head.Number.Uint64()-1)
+	}
+	if progress := chain.SyncProgress(); progress == nil || progress.Head != 100 {
+		t.Fatalf("sync progress rewound before resyncing: %+v", progress)
+	}
+}
+
+// Tests that a light chain whose head marker is stale advances over the headers
+// stored beyond it, but not over rolled back ones.
+func TestRecoverStaleHead(t *testing.T) {
+	db, chain, err := newCanonical(100)
+	if err != nil {
+		t.Fatalf("failed to create canonical chain: %v", err)
+	}
+	head := chain.CurrentHeader()
+	core.WriteHeadHeaderHash(db, chain.GetHeaderByNumber(90).Hash())
+
+	chain, _ = NewLightChain(&dummyOdr{db: db}, params.TestChainConfig, ethash.NewFaker())
+	if have := chain.CurrentHeader().Hash(); have != head.Hash() {
+		t.Fatalf("head mismatch: have #%d, want #%d", chain.CurrentHeader().Number, head.Number)
+	}
+	// Roll back the last headers and make sure they stay rolled back
+	var hashes []common.Hash
+	for i := uint64(96); i <= 100; i++ {
+		hashes = append(hashes, chain.GetHeaderByNumber(i).Hash())
+	}
+	chain.Rollback(hashes)
+
+	chain, _ = NewLightChain(&dummyOdr{db: db}, params.TestChainConfig, ethash.NewFaker())
+	if have := chain.CurrentHeader().Number.Uint64(); have != 95 {
+		t.Fatalf("head mismatch after rollback: have #%d, want #95", have)
+	}
+}
@@ -62,6 +62,10 @@ func (odr *testOdr) Database() ethdb.Database {
 	return odr.ldb
 }
 
+func (odr *testOdr) ChtIndexer() *core.ChainIndexer {
+	return nil
+}
+
 var ErrOdrDisabled = errors.New("ODR disabled")
 
 func (odr *testOdr) Retrieve(ctx context.Context, req OdrRequest) error {
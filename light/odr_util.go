@@ -24,6 +24,7 @@ import (
 	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/core/types"
 	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/log"
 	"github.com/usechain/go-usechain/rlp"
 )
 
@@ -33,12 +34,12 @@ func GetHeaderByNumber(ctx context.Context, odr OdrBackend, number uint64) (*typ
 	db := odr.Database()
 	hash := core.GetCanonicalHash(db, number)
 	if (hash != common.Hash{}) {
-		// if there is a canonical hash, there is a header too
-		header := core.GetHeader(db, hash, number)
-		if header == nil {
-			panic("Canonical hash present but header not found")
+		// if there is a canonical hash, there should be a header too, unless the
+		// write was torn by a crash, in which case it is retrieved again
+		if header := core.GetHeader(db, hash, number); header != nil {
+			return header, nil
 		}
-		return header, nil
+		log.Warn("Canonical header missing, retrieving again", "number", number, "hash", hash)
 	}
 
 	var (
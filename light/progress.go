@@ -0,0 +1,165 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package light
+
+import (
+	"math"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+// syncProgressKey tracks the header sync progress of a light client separately
+// from the head marker, so a client can recover its head instead of restarting
+// the header download if the marker is lost or points to missing data.
+var syncProgressKey = []byte("LightSyncProgress")
+
+// SyncProgress is the persisted state of the light client header sync.
+type SyncProgress struct {
+	Head        uint64      // Number of the last header known to be fully stored
+	HeadHash    common.Hash // Hash of the last header known to be fully stored
+	ChtSections uint64      // Number of CHT sections known to the client
+	ChtHead     common.Hash // Head of the last known CHT section
+	Updated     uint64      // Unix time of the last update
+}
+
+// ReadSyncProgress retrieves the light client sync progress, or nil if none is
+// stored yet.
+func ReadSyncProgress(db ethdb.Database) *SyncProgress {
+	blob, err := db.Get(syncProgressKey)
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	progress := new(SyncProgress)
+	if err := rlp.DecodeBytes(blob, progress); err != nil {
+		log.Warn("Invalid light sync progress", "err", err)
+		return nil
+	}
+	return progress
+}
+
+// WriteSyncProgress stores the light client sync progress.
+func WriteSyncProgress(db ethdb.Putter, progress *SyncProgress) error {
+	blob, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return err
+	}
+	return db.Put(syncProgressKey, blob)
+}
+
+// SyncProgress returns the persisted sync progress of the chain, or nil if the
+// chain never synced.
+func (self *LightChain) SyncProgress() *SyncProgress {
+	return ReadSyncProgress(self.chainDb)
+}
+
+// storeProgress records the current head and CHT state of the chain as the
+// point to resume syncing from.
+func (self *LightChain) storeProgress() {
+	head := self.hc.CurrentHeader()
+
+	progress := &SyncProgress{
+		Head:     head.Number.Uint64(),
+		HeadHash: head.Hash(),
+		Updated:  uint64(time.Now().Unix()),
+	}
+	if self.odr.ChtIndexer() != nil {
+		progress.ChtSections, _, progress.ChtHead = self.odr.ChtIndexer().Sections()
+	}
+	// Never forget about CHT sections learnt earlier, even if the indexer did
+	if old := ReadSyncProgress(self.chainDb); old != nil && old.ChtSections > progress.ChtSections {
+		progress.ChtSections, progress.ChtHead = old.ChtSections, old.ChtHead
+	}
+	if err := WriteSyncProgress(self.chainDb, progress); err != nil {
+		log.Warn("Failed to store light sync progress", "err", err)
+	}
+}
+
+// complete checks whether a header is fully stored and canonical, i.e. whether
+// the chain can be resumed from it.
+func (self *LightChain) complete(hash common.Hash, number uint64) bool {
+	if hash == (common.Hash{}) || core.GetCanonicalHash(self.chainDb, number) != hash {
+		return false
+	}
+	return core.GetHeader(self.chainDb, hash, number) != nil && core.GetTd(self.chainDb, hash, number) != nil
+}
+
+// recoverHead finds the header to resume the chain from if the head marker is
+// missing or points to incomplete data: the highest complete canonical header
+// at or below the last recorded progress. The second return value is false if
+// there is nothing to recover from.
+func (self *LightChain) recoverHead(marker common.Hash) (common.Hash, uint64, bool) {
+	// Start looking from the highest point the chain is known to have reached
+	var start uint64
+	if number := core.GetBlockNumber(self.chainDb, marker); number != math.MaxUint64 {
+		start = number
+	}
+	progress := ReadSyncProgress(self.chainDb)
+	if progress != nil && progress.Head > start {
+		start = progress.Head
+	}
+	for number := start; number > 0; number-- {
+		if hash := core.GetCanonicalHash(self.chainDb, number); self.complete(hash, number) {
+			return hash, number, true
+		}
+	}
+	return common.Hash{}, 0, false
+}
+
+// extendHead advances the head over complete canonical headers stored beyond
+// it, which happens if the head marker update was lost while the headers made
+// it to disk. The head is never advanced past the recorded progress, as headers
+// rolled back for being unreliable are still stored.
+func (self *LightChain) extendHead(hash common.Hash, number uint64) (common.Hash, uint64) {
+	progress := ReadSyncProgress(self.chainDb)
+	if progress == nil {
+		return hash, number
+	}
+	for number < progress.Head {
+		next := core.GetCanonicalHash(self.chainDb, number+1)
+		if !self.complete(next, number+1) {
+			return hash, number
+		}
+		if header := core.GetHeader(self.chainDb, next, number+1); header.ParentHash != hash {
+			return hash, number
+		}
+		hash, number = next, number+1
+	}
+	return hash, number
+}
+
+// restoreCht makes the CHT sections recorded in the sync progress known to the
+// indexer again, if it lost track of them.
+func (self *LightChain) restoreCht() {
+	indexer := self.odr.ChtIndexer()
+	progress := ReadSyncProgress(self.chainDb)
+	if indexer == nil || progress == nil || progress.ChtSections == 0 {
+		return
+	}
+	if sections, _, _ := indexer.Sections(); sections >= progress.ChtSections {
+		return
+	}
+	if GetChtRoot(self.chainDb, progress.ChtSections-1, progress.ChtHead) == (common.Hash{}) {
+		return
+	}
+	indexer.AddKnownSectionHead(progress.ChtSections-1, progress.ChtHead)
+	log.Info("Restored CHT sync progress", "sections", progress.ChtSections, "head", progress.ChtHead)
+}
@@ -145,6 +145,24 @@ func (t *odrTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.Putter) error
 	return errors.New("not implemented, needs client/server interface split")
 }
 
+// ProveBatch retrieves the paths of all the (already hashed) keys on demand, then
+// proves them from the nodes pulled into the local database.
+func (t *odrTrie) ProveBatch(keys [][]byte, fromLevel uint, proofDb ethdb.Putter) error {
+	for _, key := range keys {
+		key := key
+		if err := t.do(key, func() (err error) {
+			_, err = t.trie.TryGet(key)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	if t.trie == nil {
+		return nil
+	}
+	return t.trie.ProveBatch(keys, fromLevel, proofDb)
+}
+
 // do tries and retries to execute a function until it returns with no error or
 // an error type other than MissingNodeError
 func (t *odrTrie) do(key []byte, fn func() error) error {
@@ -27,6 +27,7 @@ import (
 	"github.com/usechain/go-usechain/core"
 	"github.com/usechain/go-usechain/core/state"
 	"github.com/usechain/go-usechain/core/vm"
+	"github.com/usechain/go-usechain/crypto"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/params"
 	"github.com/usechain/go-usechain/trie"
@@ -56,6 +57,37 @@ func TestNodeIterator(t *testing.T) {
 	}
 }
 
+// Tests that light tries retrieve the nodes needed to prove keys on demand.
+func TestProveBatch(t *testing.T) {
+	var (
+		fulldb, _  = ethdb.NewMemDatabase()
+		lightdb, _ = ethdb.NewMemDatabase()
+		gspec      = core.Genesis{Alloc: core.GenesisAlloc{testBankAddress: {Balance: testBankFunds}}}
+		genesis    = gspec.MustCommit(fulldb)
+	)
+	gspec.MustCommit(lightdb)
+	blockchain, _ := core.NewBlockChain(fulldb, nil, params.TestChainConfig, ethash.NewFullFaker(), vm.Config{})
+	gchain, _ := core.GenerateChain(params.TestChainConfig, genesis, ethash.NewFaker(), fulldb, 4, testChainGen)
+	if _, err := blockchain.InsertChain(gchain); err != nil {
+		panic(err)
+	}
+	head := blockchain.CurrentHeader()
+	lightTrie, _ := NewStateDatabase(context.Background(), head, &testOdr{sdb: fulldb, ldb: lightdb}).OpenTrie(head.Root)
+
+	keys := [][]byte{crypto.Keccak256(testBankAddress[:]), crypto.Keccak256(acc1Addr[:]), crypto.Keccak256([]byte{0xde, 0xad})}
+	proof, _ := ethdb.NewMemDatabase()
+	if err := lightTrie.ProveBatch(keys, 0, proof); err != nil {
+		t.Fatalf("failed to prove keys: %v", err)
+	}
+	values, err := trie.VerifyProofBatch(head.Root, keys, proof)
+	if err != nil {
+		t.Fatalf("failed to verify proof: %v", err)
+	}
+	if values[0] == nil || values[1] == nil || values[2] != nil {
+		t.Errorf("proven values mismatch: have %x", values)
+	}
+}
+
 func diffTries(t1, t2 state.Trie) error {
 	i1 := trie.NewIterator(t1.NodeIterator(nil))
 	i2 := trie.NewIterator(t2.NodeIterator(nil))
@@ -19,6 +19,7 @@ package light
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"sync"
 	"time"
 
@@ -382,6 +383,15 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	if err != nil {
 		return err
 	}
+	if tx.To() == nil {
+		next := new(big.Int).Add(header.Number, common.Big1)
+		if limit := pool.config.MaxInitCodeSizeAt(next); limit > 0 {
+			if len(tx.Data()) > limit {
+				return core.ErrMaxInitCodeSizeExceeded
+			}
+			gas += core.InitCodeGas(tx.Data())
+		}
+	}
 	if tx.Gas() < gas {
 		return core.ErrIntrinsicGas
 	}
@@ -41,12 +41,13 @@ type GlogHandler struct {
 
 	level     uint32 // Current log level, atomically accessible
 	override  uint32 // Flag whether overrides are used, atomically accessible
+	exact     uint32 // Flag whether exact overrides are used, atomically accessible
 	backtrace uint32 // Flag whether backtrace location is set
 
-	patterns  []pattern       // Current list of patterns to override with
-	siteCache map[uintptr]Lvl // Cache of callsite pattern evaluations
-	location  string          // file:line location where to do a stackdump at
-	lock      sync.RWMutex    // Lock protecting the override pattern list
+	patterns  []pattern            // Current list of patterns to override with
+	siteCache map[uintptr]siteRule // Cache of callsite pattern evaluations
+	location  string               // file:line location where to do a stackdump at
+	lock      sync.RWMutex         // Lock protecting the override pattern list
 }
 
 // NewGlogHandler creates a new log handler with filtering functionality similar
@@ -57,11 +58,25 @@ func NewGlogHandler(h Handler) *GlogHandler {
 	}
 }
 
+// SetHandler updates the handler to write records to the specified sub-handler.
+func (h *GlogHandler) SetHandler(nh Handler) {
+	h.origin = nh
+}
+
 // pattern contains a filter for the Vmodule option, holding a verbosity level
 // and a file pattern to match.
 type pattern struct {
+	file    string // File pattern as specified by the user
 	pattern *regexp.Regexp
 	level   Lvl
+	exact   bool // Whether the level replaces the ceiling instead of raising it
+}
+
+// siteRule is the cached result of matching a callsite against the patterns.
+type siteRule struct {
+	level   Lvl  // Level of the matching pattern
+	matched bool // Whether any pattern matched the callsite
+	exact   bool // Whether the matching pattern replaces the ceiling
 }
 
 // Verbosity sets the glog verbosity ceiling. The verbosity of individual packages
@@ -110,34 +125,110 @@ func (h *GlogHandler) Vmodule(ruleset string) error {
 		if level <= 0 {
 			continue // Ignore. It's harmless but no point in paying the overhead.
 		}
-		// Compile the rule pattern into a regular expression
-		matcher := ".*"
-		for _, comp := range strings.Split(parts[0], "/") {
-			if comp == "*" {
-				matcher += "(/.*)?"
-			} else if comp != "" {
-				matcher += "/" + regexp.QuoteMeta(comp)
-			}
-		}
-		if !strings.HasSuffix(parts[0], ".go") {
-			matcher += "/[^/]+\\.go"
-		}
-		matcher = matcher + "$"
-
-		re, _ := regexp.Compile(matcher)
-		filter = append(filter, pattern{re, Lvl(level)})
+		filter = append(filter, newPattern(parts[0], Lvl(level), false))
 	}
 	// Swap out the vmodule pattern for the new filter system
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
-	h.patterns = filter
-	h.siteCache = make(map[uintptr]Lvl)
-	atomic.StoreUint32(&h.override, uint32(len(filter)))
+	h.setPatterns(filter)
+	return nil
+}
+
+// SetVmodule sets the verbosity of the files matching a single vmodule pattern
+// (see Vmodule for the syntax), leaving the rules of other patterns untouched.
+// Unlike the rules set by Vmodule, the level applies exactly: it may also lower
+// the verbosity of the files below the global ceiling, down to LvlCrit.
+func (h *GlogHandler) SetVmodule(file string, level Lvl) error {
+	file = strings.TrimSpace(file)
+	if len(file) == 0 || strings.ContainsAny(file, ",=") {
+		return errVmoduleSyntax
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
 
+	h.setPatterns(append(h.dropPattern(file), newPattern(file, level, true)))
 	return nil
 }
 
+// ResetVmodule removes the rule of a single vmodule pattern, leaving the rules
+// of other patterns untouched.
+func (h *GlogHandler) ResetVmodule(file string) error {
+	file = strings.TrimSpace(file)
+	if len(file) == 0 || strings.ContainsAny(file, ",=") {
+		return errVmoduleSyntax
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.setPatterns(h.dropPattern(file))
+	return nil
+}
+
+// dropPattern returns a copy of the patterns without the rule of file. The lock
+// must be held by the caller.
+func (h *GlogHandler) dropPattern(file string) []pattern {
+	filter := make([]pattern, 0, len(h.patterns)+1)
+	for _, p := range h.patterns {
+		if p.file != file {
+			filter = append(filter, p)
+		}
+	}
+	return filter
+}
+
+// Rules returns the current vmodule ruleset in the syntax accepted by Vmodule.
+func (h *GlogHandler) Rules() string {
+	h.lock.RLock()
+	defer h.lock.RUnlock()
+
+	rules := make([]string, len(h.patterns))
+	for i, p := range h.patterns {
+		rules[i] = fmt.Sprintf("%s=%d", p.file, p.level)
+	}
+	return strings.Join(rules, ",")
+}
+
+// GetVerbosity returns the current glog verbosity ceiling.
+func (h *GlogHandler) GetVerbosity() Lvl {
+	return Lvl(atomic.LoadUint32(&h.level))
+}
+
+// newPattern compiles a vmodule file pattern into a filter rule.
+func newPattern(file string, level Lvl, exact bool) pattern {
+	matcher := ".*"
+	for _, comp := range strings.Split(file, "/") {
+		if comp == "*" {
+			matcher += "(/.*)?"
+		} else if comp != "" {
+			matcher += "/" + regexp.QuoteMeta(comp)
+		}
+	}
+	if !strings.HasSuffix(file, ".go") {
+		matcher += "/[^/]+\\.go"
+	}
+	matcher = matcher + "$"
+
+	re, _ := regexp.Compile(matcher)
+	return pattern{file, re, level, exact}
+}
+
+// setPatterns swaps out the vmodule filter, resetting the callsite cache. The
+// lock must be held by the caller.
+func (h *GlogHandler) setPatterns(filter []pattern) {
+	h.patterns = filter
+	h.siteCache = make(map[uintptr]siteRule)
+
+	var exact int
+	for _, p := range filter {
+		if p.exact {
+			exact++
+		}
+	}
+	atomic.StoreUint32(&h.override, uint32(len(filter)))
+	atomic.StoreUint32(&h.exact, uint32(exact))
+}
+
 // BacktraceAt sets the glog backtrace location. When set to a file and line
 // number holding a logging statement, a stack trace will be written to the Info
 // log whenever execution hits that statement.
@@ -192,8 +283,8 @@ func (h *GlogHandler) Log(r *Record) error {
 			r.Msg += "\n\n" + string(buf)
 		}
 	}
-	// If the global log level allows, fast track logging
-	if atomic.LoadUint32(&h.level) >= uint32(r.Lvl) {
+	// If the global log level allows and no override can lower it, fast track logging
+	if atomic.LoadUint32(&h.exact) == 0 && atomic.LoadUint32(&h.level) >= uint32(r.Lvl) {
 		return h.origin.Log(r)
 	}
 	// If no local overrides are present, fast track skipping
@@ -202,7 +293,7 @@ func (h *GlogHandler) Log(r *Record) error {
 	}
 	// Check callsite cache for previously calculated log levels
 	h.lock.RLock()
-	lvl, ok := h.siteCache[r.Call.PC()]
+	site, ok := h.siteCache[r.Call.PC()]
 	h.lock.RUnlock()
 
 	// If we didn't cache the callsite yet, calculate it
@@ -210,17 +301,21 @@ func (h *GlogHandler) Log(r *Record) error {
 		h.lock.Lock()
 		for _, rule := range h.patterns {
 			if rule.pattern.MatchString(fmt.Sprintf("%+s", r.Call)) {
-				h.siteCache[r.Call.PC()], lvl, ok = rule.level, rule.level, true
+				site = siteRule{level: rule.level, matched: true, exact: rule.exact}
 				break
 			}
 		}
-		// If no rule matched, remember to drop log the next time
-		if !ok {
-			h.siteCache[r.Call.PC()] = 0
-		}
+		h.siteCache[r.Call.PC()] = site
 		h.lock.Unlock()
 	}
-	if lvl >= r.Lvl {
+	// Exact rules override the global log level, other rules may only raise it
+	if site.exact {
+		if site.level >= r.Lvl {
+			return h.origin.Log(r)
+		}
+		return nil
+	}
+	if atomic.LoadUint32(&h.level) >= uint32(r.Lvl) || (site.matched && site.level >= r.Lvl) {
 		return h.origin.Log(r)
 	}
 	return nil
@@ -0,0 +1,116 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package log
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-stack/stack"
+)
+
+// newTestGlogger creates a glog handler counting the records passing through.
+func newTestGlogger() (*GlogHandler, *int) {
+	count := new(int)
+	glogger := NewGlogHandler(FuncHandler(func(r *Record) error {
+		*count++
+		return nil
+	}))
+	return glogger, count
+}
+
+// emitAll logs a record at every level from this file, returning the number of
+// records passing the filter.
+func emitAll(glogger *GlogHandler, count *int) int {
+	*count = 0
+	for lvl := LvlCrit; lvl <= LvlTrace; lvl++ {
+		glogger.Log(&Record{Lvl: lvl, Msg: lvl.String(), Call: stack.Caller(0)})
+	}
+	return *count
+}
+
+// Tests that runtime module rules apply their level exactly, including the crit
+// level, and that resetting them restores the verbosity ceiling.
+func TestGlogSetVmodule(t *testing.T) {
+	glogger, count := newTestGlogger()
+	glogger.Verbosity(LvlInfo)
+
+	if n := emitAll(glogger, count); n != 4 {
+		t.Fatalf("ceiling records mismatch: have %d, want %d", n, 4)
+	}
+	tests := []struct {
+		level Lvl
+		want  int
+	}{
+		{LvlCrit, 1},
+		{LvlError, 2},
+		{LvlTrace, 6},
+		{LvlWarn, 3},
+	}
+	for _, tt := range tests {
+		if err := glogger.SetVmodule("log/*", tt.level); err != nil {
+			t.Fatalf("level %v: failed to set rule: %v", tt.level, err)
+		}
+		if n := emitAll(glogger, count); n != tt.want {
+			t.Errorf("level %v: records mismatch: have %d, want %d", tt.level, n, tt.want)
+		}
+		if rules := glogger.Rules(); rules != fmt.Sprintf("log/*=%d", tt.level) {
+			t.Errorf("level %v: rules mismatch: have %q", tt.level, rules)
+		}
+	}
+	// Rules of unrelated modules don't affect the callsite
+	if err := glogger.SetVmodule("p2p", LvlCrit); err != nil {
+		t.Fatalf("failed to set unrelated rule: %v", err)
+	}
+	if err := glogger.ResetVmodule("log/*"); err != nil {
+		t.Fatalf("failed to reset rule: %v", err)
+	}
+	if n := emitAll(glogger, count); n != 4 {
+		t.Errorf("reset records mismatch: have %d, want %d", n, 4)
+	}
+	if rules := glogger.Rules(); rules != "p2p=0" {
+		t.Errorf("reset rules mismatch: have %q, want %q", rules, "p2p=0")
+	}
+	// Invalid patterns are rejected
+	for _, pattern := range []string{"", " ", "a=b", "a,b"} {
+		if err := glogger.SetVmodule(pattern, LvlInfo); err == nil {
+			t.Errorf("pattern %q: expected set failure", pattern)
+		}
+		if err := glogger.ResetVmodule(pattern); err == nil {
+			t.Errorf("pattern %q: expected reset failure", pattern)
+		}
+	}
+}
+
+// Tests that rules set through Vmodule can only raise the verbosity ceiling.
+func TestGlogVmoduleRaisesOnly(t *testing.T) {
+	glogger, count := newTestGlogger()
+	glogger.Verbosity(LvlInfo)
+
+	if err := glogger.Vmodule("log/*=5"); err != nil {
+		t.Fatalf("failed to set vmodule: %v", err)
+	}
+	if n := emitAll(glogger, count); n != 6 {
+		t.Errorf("raised records mismatch: have %d, want %d", n, 6)
+	}
+	if err := glogger.Vmodule("log/*=1"); err != nil {
+		t.Fatalf("failed to set vmodule: %v", err)
+	}
+	if n := emitAll(glogger, count); n != 4 {
+		t.Errorf("lowered records mismatch: have %d, want %d", n, 4)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+package miner
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/usechain/go-usechain/log"
+)
+
+const (
+	// recheckInterval is the period at which held back blocks are re-evaluated
+	// if no recommit interval is configured.
+	recheckInterval = time.Second
+
+	// defaultDeadline is the time after which a sparse block is sealed if the
+	// policy sets minimums without a deadline, so the miner never stalls.
+	defaultDeadline = time.Minute
+)
+
+// Config contains the block production policy of the miner.
+type Config struct {
+	MinTxs   int           `toml:",omitempty"` // Minimum number of transactions before sealing a block
+	MinFees  *big.Int      `toml:",omitempty"` // Minimum total fees (wei) before sealing a block
+	Deadline time.Duration `toml:",omitempty"` // Time after which a block is sealed below the minimums (0 = 1 minute)
+	Recommit time.Duration `toml:",omitempty"` // Interval of rebuilding the sealed block with better paying transactions (0 = on new heads only)
+}
+
+// DefaultConfig contains the default block production policy: every block is
+// sealed as soon as it is assembled.
+var DefaultConfig = Config{}
+
+// holds reports whether the policy may hold back blocks at all.
+func (c *Config) holds() bool {
+	return c.MinTxs > 0 || (c.MinFees != nil && c.MinFees.Sign() > 0)
+}
+
+// sparse reports whether a block assembled into the given work falls short of
+// the minimum transaction count or fees.
+func (c *Config) sparse(work *Work) bool {
+	if work.tcount < c.MinTxs {
+		return true
+	}
+	return c.MinFees != nil && work.fees.Cmp(c.MinFees) < 0
+}
+
+// sanitize checks the provided policy and changes anything that's unworkable.
+func (c *Config) sanitize() Config {
+	conf := *c
+	if conf.Deadline < 0 {
+		log.Warn("Sanitizing invalid miner deadline", "provided", conf.Deadline, "updated", 0)
+		conf.Deadline = 0
+	}
+	if conf.holds() && conf.Deadline == 0 {
+		log.Warn("Sanitizing missing miner deadline", "provided", conf.Deadline, "updated", defaultDeadline)
+		conf.Deadline = defaultDeadline
+	}
+	return conf
+}
@@ -19,6 +19,7 @@ package miner
 
 import (
 	"fmt"
+	"math/big"
 	"sync/atomic"
 
 	"github.com/usechain/go-usechain/accounts"
@@ -57,12 +58,12 @@ type Miner struct {
 	shouldStart int32 // should start indicates whether we should start after sync
 }
 
-func New(eth Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine) *Miner {
+func New(eth Backend, config *params.ChainConfig, mux *event.TypeMux, engine consensus.Engine, policy Config) *Miner {
 	miner := &Miner{
 		eth:      eth,
 		mux:      mux,
 		engine:   engine,
-		worker:   newWorker(config, engine, common.Address{}, eth, mux),
+		worker:   newWorker(config, engine, common.Address{}, eth, mux, policy),
 		canStart: 1,
 	}
 	miner.Register(NewCpuAgent(eth.BlockChain(), engine))
@@ -177,6 +178,12 @@ func (self *Miner) PendingBlock() *types.Block {
 	return self.worker.pendingBlock()
 }
 
+// Template returns the pending block together with the receipts of its
+// transactions and the total fees they pay.
+func (self *Miner) Template() (*types.Block, []*types.Receipt, *big.Int) {
+	return self.worker.template()
+}
+
 func (self *Miner) SetUsebase(addr common.Address) {
 	self.coinbase = addr
 	self.worker.setUsebase(addr)
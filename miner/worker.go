@@ -35,8 +35,10 @@ import (
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
 	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/metrics"
 	"github.com/usechain/go-usechain/params"
 	"gopkg.in/fatih/set.v0"
+	"github.com/usechain/go-usechain/contracts/delegation"
 	"github.com/usechain/go-usechain/contracts/minerlist"
 )
 
@@ -55,6 +57,14 @@ const (
 	genesisTag = "8287dbe2b47bcc884dce4b9ea1a0dc7681a91823af2267fb35f046dfdebaad32"
 )
 
+var (
+	sealedTxsHistogram      = metrics.NewRegisteredHistogram("miner/sealed/txs", nil, metrics.NewExpDecaySample(1028, 0.015))
+	sealedFullnessHistogram = metrics.NewRegisteredHistogram("miner/sealed/fullness", nil, metrics.NewExpDecaySample(1028, 0.015))
+	sealedEmptyMeter        = metrics.NewRegisteredMeter("miner/sealed/empty", nil)
+	heldMeter               = metrics.NewRegisteredMeter("miner/held", nil)
+	recommitMeter           = metrics.NewRegisteredMeter("miner/recommit", nil)
+)
+
 // Agent can register themself with the worker
 type Agent interface {
 	Work() chan<- *Work
@@ -75,6 +85,7 @@ type Work struct {
 	family    *set.Set       // family set (used for checking uncle invalidity)
 	uncles    *set.Set       // uncle set
 	tcount    int            // tx count in cycle
+	fees      *big.Int       // fees paid by the transactions in cycle
 
 	Block *types.Block // the new block
 
@@ -105,6 +116,8 @@ type worker struct {
 	chainHeadSub event.Subscription
 	chainSideCh  chan core.ChainSideEvent
 	chainSideSub event.Subscription
+	standbyCh    chan struct{} // signals the update loop to retry mining after standing by
+	exitCh       chan struct{} // closed once the update loop terminates
 	wg           sync.WaitGroup
 
 	agents map[Agent]struct{}
@@ -121,6 +134,13 @@ type worker struct {
 	currentMu sync.Mutex
 	current   *Work
 
+	policy      Config      // block production policy
+	sealing     *Work       // work handed to the agents on the current parent
+	heightRoot  common.Hash // parent the current height was first worked on
+	heightStart time.Time   // time the current height was first worked on
+	held        bool        // whether the current work is held back as too sparse
+	standby     *time.Timer // retries mining once the elected miners are considered unresponsive
+
 	uncleMu        sync.Mutex
 	possibleUncles map[common.Hash]*types.Block
 
@@ -131,7 +151,7 @@ type worker struct {
 	atWork int32
 }
 
-func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, eth Backend, mux *event.TypeMux) *worker {
+func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase common.Address, eth Backend, mux *event.TypeMux, policy Config) *worker {
 	worker := &worker{
 		config:         config,
 		engine:         engine,
@@ -140,6 +160,8 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		txCh:           make(chan core.TxPreEvent, txChanSize),
 		chainHeadCh:    make(chan core.ChainHeadEvent, chainHeadChanSize),
 		chainSideCh:    make(chan core.ChainSideEvent, chainSideChanSize),
+		standbyCh:      make(chan struct{}, 1),
+		exitCh:         make(chan struct{}),
 		chainDb:        eth.ChainDb(),
 		recv:           make(chan *Result, resultQueueSize),
 		chain:          eth.BlockChain(),
@@ -148,6 +170,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		coinbase:       coinbase,
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
+		policy:         (&policy).sanitize(),
 	}
 	// Subscribe TxPreEvent for tx pool
 	worker.txSub = eth.TxPool().SubscribeTxPreEvent(worker.txCh)
@@ -157,6 +180,9 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 	go worker.update()
 
 	go worker.wait()
+	if worker.policy.holds() || worker.policy.Recommit > 0 {
+		go worker.recommit()
+	}
 	worker.commitNewWork()
 
 	return worker
@@ -204,6 +230,27 @@ func (self *worker) pendingBlock() *types.Block {
 	return self.current.Block
 }
 
+// template returns the pending block along with the receipts of its transactions
+// and the fees they pay, as the assembly context for external block builders.
+func (self *worker) template() (*types.Block, []*types.Receipt, *big.Int) {
+	self.currentMu.Lock()
+	defer self.currentMu.Unlock()
+
+	block := self.current.Block
+	if atomic.LoadInt32(&self.mining) == 0 || block == nil {
+		block = types.NewBlock(
+			self.current.header,
+			self.current.txs,
+			nil,
+			self.current.receipts,
+		)
+	}
+	receipts := make([]*types.Receipt, len(self.current.receipts))
+	copy(receipts, self.current.receipts)
+
+	return block, receipts, new(big.Int).Set(self.current.fees)
+}
+
 func (self *worker) start() {
 	self.mu.Lock()
 	defer self.mu.Unlock()
@@ -226,6 +273,10 @@ func (self *worker) stop() {
 			agent.Stop()
 		}
 	}
+	if self.standby != nil {
+		self.standby.Stop()
+		self.standby = nil
+	}
 	atomic.StoreInt32(&self.mining, 0)
 	atomic.StoreInt32(&self.atWork, 0)
 }
@@ -245,6 +296,7 @@ func (self *worker) unregister(agent Agent) {
 }
 
 func (self *worker) update() {
+	defer close(self.exitCh)
 	defer self.txSub.Unsubscribe()
 	defer self.chainHeadSub.Unsubscribe()
 	defer self.chainSideSub.Unsubscribe()
@@ -256,6 +308,12 @@ func (self *worker) update() {
 		case <-self.chainHeadCh:
 			self.commitNewWork()
 
+		// Retry mining once the elected miners are considered unresponsive
+		case <-self.standbyCh:
+			if atomic.LoadInt32(&self.mining) == 1 {
+				self.commitNewWork()
+			}
+
 		// Handle ChainSideEvent
 		case ev := <-self.chainSideCh:
 			self.uncleMu.Lock()
@@ -338,6 +396,14 @@ func (self *worker) wait() {
 			// Insert the block into the set of pending ones to wait for confirmations
 			self.unconfirmed.Insert(block.NumberU64(), block.Hash())
 
+			sealedTxsHistogram.Update(int64(len(block.Transactions())))
+			if block.GasLimit() > 0 {
+				sealedFullnessHistogram.Update(int64(block.GasUsed() * 100 / block.GasLimit()))
+			}
+			if len(block.Transactions()) == 0 {
+				sealedEmptyMeter.Mark(1)
+			}
+
 			if mustCommitNewWork {
 				self.commitNewWork()
 			}
@@ -364,6 +430,9 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	if err != nil {
 		return err
 	}
+	if self.chain.NeedsBalanceDiffs() {
+		state.RecordBalanceDiffs()
+	}
 	work := &Work{
 		config:    self.config,
 		signer:    types.NewEIP155Signer(self.config.ChainId),
@@ -372,6 +441,7 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 		family:    set.New(),
 		uncles:    set.New(),
 		header:    header,
+		fees:      new(big.Int),
 		createdAt: time.Now(),
 	}
 
@@ -423,12 +493,31 @@ func (self *worker) commitNewWork() {
 
 	// Only set the coinbase if we are mining (avoid spurious block rewards)
 	if atomic.LoadInt32(&self.mining) == 1 {
+		if violation := self.chain.Halted(); violation != nil {
+			log.Warn("Block production halted by the circuit breaker", "number", violation.Number, "invariant", violation.Invariant)
+			return
+		}
 		///TODO: add miner filter, and when there is only one miner, doesn't needs registration
 		if !minerlist.IsMiner(self.current.state, self.coinbase) && minerlist.ReadMinerNum(self.current.state).Int64() > 1  {
 			log.Error("Coinbase should be legal miner address, please register for mining")
 			return
 		}
 
+		// Stand by while the miners elected for the epoch are producing blocks
+		if self.config.IsDelegation(header.Number) {
+			statedb, err := self.chain.StateAt(parent.Root())
+			if err != nil {
+				log.Error("Failed to retrieve the miner election", "err", err)
+				return
+			}
+			if !delegation.IsElected(statedb, self.coinbase) {
+				if wait := time.Until(time.Unix(parent.Time().Int64()+delegation.StandbyDelay, 0)); wait > 0 {
+					log.Debug("Coinbase not elected for the epoch, standing by", "wait", common.PrettyDuration(wait))
+					self.standBy(wait)
+					return
+				}
+			}
+		}
 		header.Coinbase = self.coinbase
 		header.MinerNum = minerlist.ReadMinerNum(self.current.state)
 
@@ -497,18 +586,99 @@ func (self *worker) commitNewWork() {
 		delete(self.possibleUncles, hash)
 	}
 	// Create the new block to seal with the consensus engine
+	work.state.Prepare(common.Hash{}, common.Hash{}, len(work.txs))
 	if work.Block, err = self.engine.Finalize(self.chain, header, work.state, work.txs, uncles, work.receipts); err != nil {
 		log.Error("Failed to finalize block for sealing", "err", err)
 		return
 	}
 	// We only care about logging if we're actually mining.
 	if atomic.LoadInt32(&self.mining) == 1 {
-		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(tstart)))
+		if !self.shouldSeal(work) {
+			return
+		}
+		log.Info("Commit new mining work", "number", work.Block.Number(), "txs", work.tcount, "fees", work.fees, "uncles", len(uncles), "elapsed", common.PrettyDuration(time.Since(tstart)))
 		self.unconfirmed.Shift(work.Block.NumberU64() - 1)
+		self.sealing = work
 	}
 	self.push(work)
 }
 
+// shouldSeal decides whether freshly assembled work is handed to the agents. A
+// block falling short of the policy minimums is held back until the deadline
+// passes, and a rebuilt block replaces the one being sealed on the same parent
+// only if it pays more fees. The current lock must be held.
+func (self *worker) shouldSeal(work *Work) bool {
+	parent := work.header.ParentHash
+	if self.sealing != nil && self.sealing.header.ParentHash == parent {
+		if work.fees.Cmp(self.sealing.fees) <= 0 {
+			return false
+		}
+		recommitMeter.Mark(1)
+		return true
+	}
+	if self.heightRoot != parent {
+		self.heightRoot, self.heightStart = parent, time.Now()
+	}
+	self.held = false
+	if !self.policy.sparse(work) {
+		return true
+	}
+	waited := time.Since(self.heightStart)
+	if waited < self.policy.Deadline {
+		log.Debug("Holding back sparse block", "number", work.header.Number, "txs", work.tcount, "fees", work.fees, "waited", common.PrettyDuration(waited))
+		heldMeter.Mark(1)
+		self.held = true
+		return false
+	}
+	log.Info("Sealing sparse block past deadline", "number", work.header.Number, "txs", work.tcount, "fees", work.fees)
+	return true
+}
+
+// recommit periodically rebuilds the pending block while mining, re-evaluating
+// held back blocks and, if a recommit interval is configured, replacing the
+// block being sealed when the pool offers better paying transactions. It stops
+// along with the update loop.
+func (self *worker) recommit() {
+	interval := self.policy.Recommit
+	if interval == 0 {
+		interval = recheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if atomic.LoadInt32(&self.mining) == 0 {
+				continue
+			}
+			self.currentMu.Lock()
+			held := self.held
+			self.currentMu.Unlock()
+
+			if held || self.policy.Recommit > 0 {
+				self.commitNewWork()
+			}
+		case <-self.exitCh:
+			return
+		}
+	}
+}
+
+// standBy schedules a new mining attempt by the update loop after the given
+// delay, replacing any previously scheduled one. The caller must hold mu.
+func (self *worker) standBy(delay time.Duration) {
+	if self.standby != nil {
+		self.standby.Stop()
+	}
+	self.standby = time.AfterFunc(delay, func() {
+		select {
+		case self.standbyCh <- struct{}{}:
+		default:
+		}
+	})
+}
+
 func (self *worker) commitUncle(work *Work, uncle *types.Header) error {
 	hash := uncle.Hash()
 	if work.uncles.Has(hash) {
@@ -617,6 +787,7 @@ func (env *Work) commitTransaction(tx *types.Transaction, bc *core.BlockChain, c
 	}
 	env.txs = append(env.txs, tx)
 	env.receipts = append(env.receipts, receipt)
+	env.fees.Add(env.fees, new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), tx.GasPrice()))
 
 	return nil, receipt.Logs
 }
@@ -0,0 +1,154 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+package miner
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core/types"
+)
+
+// newTestWork creates work on the given parent with a number of transactions
+// paying the given fees.
+func newTestWork(parent common.Hash, txs int, fees int64) *Work {
+	return &Work{
+		header: &types.Header{ParentHash: parent, Number: big.NewInt(1)},
+		tcount: txs,
+		fees:   big.NewInt(fees),
+	}
+}
+
+// Tests that sparse blocks are held back until the deadline, and that rebuilt
+// blocks only replace the sealed one if paying more fees.
+func TestShouldSeal(t *testing.T) {
+	w := &worker{policy: Config{MinTxs: 2, MinFees: big.NewInt(100), Deadline: 50 * time.Millisecond}}
+	parent := common.Hash{1}
+
+	if w.shouldSeal(newTestWork(parent, 1, 1000)) || !w.held {
+		t.Fatalf("block below minimum transactions sealed")
+	}
+	if w.shouldSeal(newTestWork(parent, 5, 10)) || !w.held {
+		t.Fatalf("block below minimum fees sealed")
+	}
+	time.Sleep(50 * time.Millisecond)
+	sparse := newTestWork(parent, 1, 10)
+	if !w.shouldSeal(sparse) || w.held {
+		t.Fatalf("sparse block not sealed past deadline")
+	}
+	w.sealing = sparse
+
+	if w.shouldSeal(newTestWork(parent, 1, 10)) {
+		t.Errorf("equally paid rebuild replaced sealed block")
+	}
+	if !w.shouldSeal(newTestWork(parent, 1, 11)) {
+		t.Errorf("better paid rebuild didn't replace sealed block")
+	}
+	// A new parent restarts the deadline
+	if w.shouldSeal(newTestWork(common.Hash{2}, 1, 10)) || !w.held {
+		t.Errorf("sparse block on new parent sealed before deadline")
+	}
+	if !w.shouldSeal(newTestWork(common.Hash{2}, 2, 100)) {
+		t.Errorf("block meeting the minimums not sealed")
+	}
+}
+
+// Tests that block templates expose the pending work without sharing the
+// worker's mutable fields.
+func TestTemplate(t *testing.T) {
+	tx := types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(2), nil)
+	work := newTestWork(common.Hash{1}, 1, 42000)
+	work.txs = []*types.Transaction{tx}
+	work.receipts = []*types.Receipt{{GasUsed: 21000}}
+
+	w := &worker{current: work}
+	block, receipts, fees := w.template()
+
+	if block.ParentHash() != (common.Hash{1}) || len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != tx.Hash() {
+		t.Fatalf("template block mismatch: parent %x, txs %d", block.ParentHash(), len(block.Transactions()))
+	}
+	if len(receipts) != 1 || receipts[0].GasUsed != 21000 {
+		t.Fatalf("template receipts mismatch: %v", receipts)
+	}
+	if fees.Cmp(big.NewInt(42000)) != 0 {
+		t.Fatalf("template fees mismatch: have %v, want %v", fees, 42000)
+	}
+	fees.SetInt64(0)
+	receipts[0] = nil
+	if work.fees.Sign() == 0 || work.receipts[0] == nil {
+		t.Errorf("template shares state with the pending work")
+	}
+}
+
+// Tests that a policy holding back blocks always gets a deadline, so sparse
+// blocks are eventually sealed.
+func TestSanitizeDeadline(t *testing.T) {
+	tests := []struct {
+		policy Config
+		want   time.Duration
+	}{
+		{Config{}, 0},
+		{Config{MinTxs: 2}, defaultDeadline},
+		{Config{MinFees: big.NewInt(1), Deadline: -time.Second}, defaultDeadline},
+		{Config{MinTxs: 2, Deadline: time.Second}, time.Second},
+	}
+	for i, tt := range tests {
+		if have := tt.policy.sanitize().Deadline; have != tt.want {
+			t.Errorf("test %d: deadline mismatch: have %v, want %v", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that the recommit loop terminates along with the update loop.
+func TestRecommitExit(t *testing.T) {
+	w := &worker{policy: Config{Recommit: time.Millisecond}, exitCh: make(chan struct{})}
+
+	done := make(chan struct{})
+	go func() {
+		w.recommit()
+		close(done)
+	}()
+	close(w.exitCh)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("recommit loop still running after exit")
+	}
+}
+
+// Tests that standing by signals the update loop once the delay passes, and
+// that stopping the worker cancels a pending retry.
+func TestStandBy(t *testing.T) {
+	w := &worker{standbyCh: make(chan struct{}, 1)}
+
+	w.standBy(time.Millisecond)
+	select {
+	case <-w.standbyCh:
+	case <-time.After(time.Second):
+		t.Fatalf("no retry signalled after standing by")
+	}
+	w.standBy(10 * time.Millisecond)
+	w.stop()
+
+	select {
+	case <-w.standbyCh:
+		t.Fatalf("retry signalled after the worker stopped")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
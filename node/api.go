@@ -18,7 +18,9 @@ package node
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
@@ -229,6 +231,34 @@ func (api *PrivateAdminAPI) StopWS() (bool, error) {
 	return true, nil
 }
 
+// Upgrade hands the node over to a new instance of the binary it was started
+// from, run with the same arguments. The RPC sockets are inherited, so clients
+// only notice a short delay. The binary is never chosen by the caller, lest the
+// admin namespace turns into remote code execution.
+func (api *PrivateAdminAPI) Upgrade() (int, error) {
+	binary, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	return api.node.Handover(binary, os.Args[1:])
+}
+
+// EffectiveConfig retrieves the fully resolved configuration the node is running
+// with, along with the settings where it deviates from the configuration file.
+func (api *PrivateAdminAPI) EffectiveConfig() (*EffectiveConfig, error) {
+	cfg := api.node.EffectiveConfig()
+	if cfg == nil {
+		return nil, errors.New("effective configuration not available")
+	}
+	return cfg, nil
+}
+
+// Cursors returns the resource accounting of the paginated result sets kept open
+// for the connection issuing the request.
+func (api *PrivateAdminAPI) Cursors(ctx context.Context) ([]*rpc.CursorInfo, error) {
+	return rpc.SessionCursors(ctx)
+}
+
 // PublicAdminAPI is the collection of administrative API methods exposed over
 // both secure and unsecure RPC channels.
 type PublicAdminAPI struct {
@@ -251,6 +281,16 @@ func (api *PublicAdminAPI) Peers() ([]*p2p.PeerInfo, error) {
 	return server.PeersInfo(), nil
 }
 
+// PeerStats retrieves the bytes and messages exchanged with each connected peer,
+// broken down by sub-protocol and message type, heaviest peers first.
+func (api *PublicAdminAPI) PeerStats() ([]*p2p.PeerStats, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.PeerStats(), nil
+}
+
 // NodeInfo retrieves all the information we know about the host node at the
 // protocol granularity.
 func (api *PublicAdminAPI) NodeInfo() (*p2p.NodeInfo, error) {
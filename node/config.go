@@ -24,6 +24,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/usechain/go-usechain/accounts"
 	"github.com/usechain/go-usechain/accounts/keystore"
@@ -38,6 +39,7 @@ import (
 const (
 	datadirPrivateKey      = "nodekey"            // Path within the datadir to the node's private key
 	datadirDefaultKeyStore = "keystore"           // Path within the datadir to the keystore
+	datadirCrashReports    = "crashes"            // Path within the datadir to the crash reports
 	datadirStaticNodes     = "static-nodes.json"  // Path within the datadir to the static node list
 	datadirTrustedNodes    = "trusted-nodes.json" // Path within the datadir to the trusted node list
 	datadirNodeDatabase    = "nodes"              // Path within the datadir to store the node infos
@@ -82,9 +84,49 @@ type Config struct {
 	// scrypt KDF at the expense of security.
 	UseLightweightKDF bool `toml:",omitempty"`
 
+	// KeyStoreKDF selects the key derivation function used to encrypt new keys,
+	// either "scrypt" (default) or "argon2id".
+	KeyStoreKDF string `toml:",omitempty"`
+
+	// ScryptN and ScryptP override the scrypt cost parameters of the key store
+	// if set, taking precedence over UseLightweightKDF.
+	ScryptN int `toml:",omitempty"`
+	ScryptP int `toml:",omitempty"`
+
+	// Argon2Time, Argon2Memory (in KiB) and Argon2Threads override the argon2id
+	// cost parameters of the key store if set.
+	Argon2Time    uint32 `toml:",omitempty"`
+	Argon2Memory  uint32 `toml:",omitempty"`
+	Argon2Threads uint8  `toml:",omitempty"`
+
 	// NoUSB disables hardware wallet monitoring and connectivity.
 	NoUSB bool `toml:",omitempty"`
 
+	// SignerApproval queues every signing request made over RPC until the operator
+	// approves or rejects it via personal_approve or personal_reject.
+	SignerApproval bool `toml:",omitempty"`
+
+	// SignerApprovalTimeout is the time after which signing requests not decided
+	// upon are approved or rejected automatically. Zero waits indefinitely.
+	SignerApprovalTimeout time.Duration `toml:",omitempty"`
+
+	// SignerApproveOnTimeout approves signing requests timing out instead of
+	// rejecting them.
+	SignerApproveOnTimeout bool `toml:",omitempty"`
+
+	// DBEngine is the storage engine of new databases (see ethdb.Open). Existing
+	// databases are always opened with the engine they were created with.
+	DBEngine string `toml:",omitempty"`
+
+	// CrashDir is the directory diagnostic bundles are written to when the node
+	// panics or fails fatally. If empty, the "crashes" subdirectory of DataDir is
+	// used, crash reports being disabled for ephemeral nodes.
+	CrashDir string `toml:",omitempty"`
+
+	// DBReadOnly opens all databases read-only, for tooling inspecting the data
+	// of a possibly running node. It is not persisted, being a per-run setting.
+	DBReadOnly bool `toml:"-"`
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the data directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -380,6 +422,12 @@ func (c *Config) AccountConfig() (int, int, string, error) {
 		scryptN = keystore.LightScryptN
 		scryptP = keystore.LightScryptP
 	}
+	if c.ScryptN != 0 {
+		scryptN = c.ScryptN
+	}
+	if c.ScryptP != 0 {
+		scryptP = c.ScryptP
+	}
 
 	var (
 		keydir string
@@ -400,6 +448,47 @@ func (c *Config) AccountConfig() (int, int, string, error) {
 	return scryptN, scryptP, keydir, err
 }
 
+// crashDir resolves the directory of the crash reports, empty if disabled.
+func (c *Config) crashDir() string {
+	switch {
+	case c.CrashDir != "":
+		if dir, err := filepath.Abs(c.CrashDir); err == nil {
+			return dir
+		}
+		return c.CrashDir
+	case c.DataDir != "":
+		return filepath.Join(c.DataDir, datadirCrashReports)
+	}
+	return ""
+}
+
+// Argon2Config returns the argon2id parameters to encrypt new keys with, or nil
+// if the key store is configured to use scrypt.
+func (c *Config) Argon2Config() (*keystore.Argon2Params, error) {
+	switch c.KeyStoreKDF {
+	case "", "scrypt":
+		return nil, nil
+	case "argon2id":
+	default:
+		return nil, fmt.Errorf("unknown key derivation function %q", c.KeyStoreKDF)
+	}
+	params := &keystore.Argon2Params{
+		Time:    keystore.StandardArgon2Time,
+		Memory:  keystore.StandardArgon2Memory,
+		Threads: keystore.StandardArgon2Threads,
+	}
+	if c.Argon2Time != 0 {
+		params.Time = c.Argon2Time
+	}
+	if c.Argon2Memory != 0 {
+		params.Memory = c.Argon2Memory
+	}
+	if c.Argon2Threads != 0 {
+		params.Threads = c.Argon2Threads
+	}
+	return params, nil
+}
+
 func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 	scryptN, scryptP, keydir, err := conf.AccountConfig()
 	var ephemeral string
@@ -409,6 +498,10 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 		ephemeral = keydir
 	}
 
+	if err != nil {
+		return nil, "", err
+	}
+	argon2, err := conf.Argon2Config()
 	if err != nil {
 		return nil, "", err
 	}
@@ -416,8 +509,11 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 		return nil, "", err
 	}
 	// Assemble the account manager and supported backends
-	backends := []accounts.Backend{
-		keystore.NewKeyStore(keydir, scryptN, scryptP),
+	var backends []accounts.Backend
+	if argon2 != nil {
+		backends = append(backends, keystore.NewKeyStoreArgon2(keydir, *argon2))
+	} else {
+		backends = append(backends, keystore.NewKeyStore(keydir, scryptN, scryptP))
 	}
 	if !conf.NoUSB {
 		// Start a USB hub for Ledger hardware wallets
@@ -433,5 +529,25 @@ func makeAccountManager(conf *Config) (*accounts.Manager, string, error) {
 			backends = append(backends, trezorhub)
 		}
 	}
-	return accounts.NewManager(backends...), ephemeral, nil
+	am := accounts.NewManager(backends...)
+	if conf.SignerApproval {
+		am.SetApprovalQueue(accounts.NewApprovalQueue(conf.SignerApprovalTimeout, conf.SignerApproveOnTimeout))
+	}
+	return am, ephemeral, nil
+}
+
+// EffectiveConfig is the fully resolved runtime configuration of a node, built
+// from the defaults, the configuration file and the command line flags.
+type EffectiveConfig struct {
+	File   string                 `json:"file,omitempty"` // Configuration file the node was started with
+	Config map[string]interface{} `json:"config"`         // Resolved settings, with secrets redacted
+	Diff   []ConfigDiff           `json:"diff"`           // Settings deviating from the configuration file
+}
+
+// ConfigDiff is a single setting whose effective value differs from the one
+// specified in the configuration file.
+type ConfigDiff struct {
+	Key       string      `json:"key"`
+	File      interface{} `json:"file"`
+	Effective interface{} `json:"effective"`
 }
@@ -0,0 +1,327 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/usechain/go-usechain/log"
+)
+
+const (
+	// crashLogRecords is the number of recent log records kept for crash reports.
+	crashLogRecords = 1000
+
+	// crashOutputPrefix is the file name prefix of the runtime crash output of
+	// the running processes, suffixed by the process id.
+	crashOutputPrefix = "runtime-"
+)
+
+// errCrashReportsDisabled is returned if a crash report is requested from a node
+// without a crash report directory.
+var errCrashReportsDisabled = errors.New("crash reports disabled")
+
+// CrashReporter is implemented by services able to describe their state in the
+// crash reports of the node, e.g. the chain head and sync progress.
+type CrashReporter interface {
+	CrashInfo() interface{}
+}
+
+// logRing is a log handler retaining the most recent records, to be included
+// in crash reports. Trace records are too noisy to be worth keeping.
+type logRing struct {
+	records []*log.Record
+	next    int
+	lock    sync.Mutex
+}
+
+func newLogRing(size int) *logRing {
+	return &logRing{records: make([]*log.Record, size)}
+}
+
+// Log implements log.Handler, retaining the record.
+func (r *logRing) Log(rec *log.Record) error {
+	if rec.Lvl > log.LvlDebug {
+		return nil
+	}
+	r.lock.Lock()
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	r.lock.Unlock()
+	return nil
+}
+
+// dump writes the retained records to w, oldest first.
+func (r *logRing) dump(w io.Writer) error {
+	r.lock.Lock()
+	records := append(append([]*log.Record{}, r.records[r.next:]...), r.records[:r.next]...)
+	r.lock.Unlock()
+
+	format := log.TerminalFormat(false)
+	for _, rec := range records {
+		if rec == nil {
+			continue
+		}
+		if _, err := w.Write(format.Format(rec)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// crashReporter writes diagnostic bundles into the crash report directory. It
+// covers failures in two ways: panics recovered by RecoverCrash and fatal errors
+// are reported on the spot, whereas the runtime crash output of unrecovered
+// panics is saved to a file and bundled when the node is started next.
+type crashReporter struct {
+	dir      string
+	version  string
+	config   *EffectiveConfig
+	services map[reflect.Type]Service
+
+	logs    *logRing
+	handler log.Handler // Root log handler replaced by the one feeding logs
+	output  *os.File    // Runtime crash output of the current process
+	lock    sync.Mutex  // Serialises the reports
+}
+
+// startCrashReporter creates the crash report directory and starts capturing
+// the logs and the runtime crash output.
+func startCrashReporter(dir, version string, config *EffectiveConfig) (*crashReporter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	c := &crashReporter{
+		dir:     dir,
+		version: version,
+		config:  config,
+		logs:    newLogRing(crashLogRecords),
+		handler: log.Root().GetHandler(),
+	}
+	log.Root().SetHandler(log.MultiHandler(c.handler, c.logs))
+
+	path := filepath.Join(dir, fmt.Sprintf("%s%d.log", crashOutputPrefix, os.Getpid()))
+	output, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		c.stop()
+		return nil, err
+	}
+	if err := setCrashOutput(output); err != nil {
+		log.Debug("Runtime crash output unavailable", "err", err)
+		output.Close()
+		os.Remove(path)
+	} else {
+		c.output = output
+	}
+	return c, nil
+}
+
+// stop releases the runtime crash output and the log handler.
+func (c *crashReporter) stop() {
+	if c.output != nil {
+		setCrashOutput(nil)
+		c.output.Close()
+		os.Remove(c.output.Name())
+		c.output = nil
+	}
+	log.Root().SetHandler(c.handler)
+}
+
+// collect bundles the runtime crash output left behind by earlier processes,
+// removing the empty ones of processes which terminated cleanly.
+func (c *crashReporter) collect() {
+	files, err := filepath.Glob(filepath.Join(c.dir, crashOutputPrefix+"*.log"))
+	if err != nil {
+		return
+	}
+	for _, file := range files {
+		if c.output != nil && file == c.output.Name() {
+			continue
+		}
+		stack, err := ioutil.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		if len(strings.TrimSpace(string(stack))) > 0 {
+			c.report("unrecovered crash of a previous run", stack, false)
+		}
+		os.Remove(file)
+	}
+}
+
+// report writes a crash report bundle and prints its location. Live reports
+// describe a failure of the running process, so include its goroutines, heap
+// profile and recent logs too.
+func (c *crashReporter) report(reason string, stack []byte, live bool) (string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	path, err := ioutil.TempDir(c.dir, "crash-"+now.Format("20060102-150405")+"-")
+	if err != nil {
+		return "", err
+	}
+	files := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{"summary.txt", func(w io.Writer) error {
+			_, err := fmt.Fprintf(w, "Reason:  %s\nTime:    %v\nVersion: %s\nGo:      %s %s/%s\nPID:     %d\n",
+				reason, now, c.version, runtime.Version(), runtime.GOOS, runtime.GOARCH, os.Getpid())
+			return err
+		}},
+		{"stacks.txt", func(w io.Writer) error {
+			_, err := w.Write(stack)
+			return err
+		}},
+		{"config.json", func(w io.Writer) error { return writeJSON(w, c.config) }},
+		{"services.json", func(w io.Writer) error { return writeJSON(w, c.serviceInfos()) }},
+	}
+	if live {
+		files = append(files, []struct {
+			name  string
+			write func(io.Writer) error
+		}{
+			{"goroutines.txt", func(w io.Writer) error { return pprof.Lookup("goroutine").WriteTo(w, 2) }},
+			{"heap.pprof", pprof.WriteHeapProfile},
+			{"logs.txt", c.logs.dump},
+		}...)
+	}
+	for _, file := range files {
+		if err := writeCrashFile(filepath.Join(path, file.name), file.write); err != nil {
+			log.Warn("Failed to write crash report file", "file", file.name, "err", err)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Crash report written to %s\n", path)
+	log.Error("Crash report written", "path", path, "reason", reason)
+	return path, nil
+}
+
+// serviceInfos gathers the self-descriptions of the services supporting it.
+func (c *crashReporter) serviceInfos() map[string]interface{} {
+	infos := make(map[string]interface{})
+	for kind, service := range c.services {
+		if reporter, ok := service.(CrashReporter); ok {
+			infos[kind.String()] = reporter.CrashInfo()
+		}
+	}
+	return infos
+}
+
+// writeCrashFile creates a file of a crash report. The node being in a broken
+// state, a panicking writer only loses its own file.
+func writeCrashFile(path string, write func(io.Writer) error) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return write(f)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	blob, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(blob)
+	return err
+}
+
+// CrashDump writes a diagnostic bundle about a failure of the node into the
+// crash report directory and returns its path. If stack is nil, the stack of
+// the calling goroutine is recorded.
+func (n *Node) CrashDump(reason string, stack []byte) (string, error) {
+	n.crashLock.Lock()
+	crash := n.crash
+	n.crashLock.Unlock()
+
+	if crash == nil {
+		return "", errCrashReportsDisabled
+	}
+	if stack == nil {
+		stack = debug.Stack()
+	}
+	return crash.report(reason, stack, true)
+}
+
+// RecoverCrash writes a crash report if the calling goroutine is panicking and
+// resumes panicking afterwards. It must be deferred directly.
+func (n *Node) RecoverCrash() {
+	if r := recover(); r != nil {
+		if _, err := n.CrashDump(fmt.Sprintf("panic: %v", r), debug.Stack()); err == nil {
+			// Reported already, don't bundle the runtime output again next time
+			n.crashLock.Lock()
+			if n.crash != nil {
+				n.crash.stop()
+			}
+			n.crashLock.Unlock()
+		}
+		panic(r)
+	}
+}
+
+// startCrashReports starts the crash reporter of a node with a crash report
+// directory, bundling the crashes of earlier runs.
+func (n *Node) startCrashReports(services map[reflect.Type]Service) {
+	dir := n.config.crashDir()
+	if dir == "" {
+		return
+	}
+	crash, err := startCrashReporter(dir, n.config.Version, n.effective)
+	if err != nil {
+		n.log.Warn("Failed to enable crash reports", "dir", dir, "err", err)
+		return
+	}
+	crash.services = services
+	crash.collect()
+
+	n.crashLock.Lock()
+	n.crash = crash
+	n.crashLock.Unlock()
+
+	n.log.Info("Crash reports enabled", "dir", dir)
+}
+
+// stopCrashReports terminates the crash reporter of the node, if running.
+func (n *Node) stopCrashReports() {
+	n.crashLock.Lock()
+	defer n.crashLock.Unlock()
+
+	if n.crash != nil {
+		n.crash.stop()
+		n.crash = nil
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !go1.23
+
+package node
+
+import (
+	"errors"
+	"os"
+)
+
+// setCrashOutput is not supported by runtimes older than Go 1.23, leaving the
+// runtime crash output on stderr only.
+func setCrashOutput(f *os.File) error {
+	return errors.New("runtime crash output requires Go 1.23")
+}
@@ -0,0 +1,127 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// crashInfoService is a service describing itself in crash reports.
+type crashInfoService struct{ NoopService }
+
+func (s *crashInfoService) CrashInfo() interface{} { return map[string]int{"head": 42} }
+
+// Tests that crash reports bundle the diagnostics of the node, including the
+// runtime crash output left behind by a previous run.
+func TestCrashDump(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Fake the runtime crash output of a previous process
+	crashdir := filepath.Join(dir, datadirCrashReports)
+	os.MkdirAll(crashdir, 0700)
+	ioutil.WriteFile(filepath.Join(crashdir, crashOutputPrefix+"1.log"), []byte("panic: boom\n\ngoroutine 1 [running]:\n"), 0600)
+	ioutil.WriteFile(filepath.Join(crashdir, crashOutputPrefix+"2.log"), nil, 0600)
+
+	config := testNodeConfig()
+	config.DataDir = dir
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	stack.SetEffectiveConfig(&EffectiveConfig{Config: map[string]interface{}{"Node": "test"}})
+	stack.Register(func(*ServiceContext) (Service, error) { return new(crashInfoService), nil })
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	// The crash of the previous run must have been bundled, empty outputs dropped
+	bundles, _ := filepath.Glob(filepath.Join(crashdir, "crash-*"))
+	if len(bundles) != 1 {
+		t.Fatalf("previous crash bundles mismatch: have %d, want 1", len(bundles))
+	}
+	if stacks, _ := ioutil.ReadFile(filepath.Join(bundles[0], "stacks.txt")); !strings.Contains(string(stacks), "panic: boom") {
+		t.Errorf("previous crash stacks missing: %q", stacks)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(crashdir, crashOutputPrefix+"*.log")); len(leftovers) > 1 {
+		t.Errorf("runtime outputs not cleaned up: %v", leftovers)
+	}
+	// Live reports contain the state of the running process as well
+	path, err := stack.CrashDump("fatal: test failure", nil)
+	if err != nil {
+		t.Fatalf("failed to write crash report: %v", err)
+	}
+	for _, file := range []string{"summary.txt", "stacks.txt", "config.json", "services.json", "goroutines.txt", "heap.pprof", "logs.txt"} {
+		if _, err := os.Stat(filepath.Join(path, file)); err != nil {
+			t.Errorf("crash report file %s missing: %v", file, err)
+		}
+	}
+	if summary, _ := ioutil.ReadFile(filepath.Join(path, "summary.txt")); !strings.Contains(string(summary), "fatal: test failure") {
+		t.Errorf("crash reason missing from summary: %q", summary)
+	}
+	if services, _ := ioutil.ReadFile(filepath.Join(path, "services.json")); !strings.Contains(string(services), `"head": 42`) {
+		t.Errorf("service info missing: %s", services)
+	}
+	if stacks, _ := ioutil.ReadFile(filepath.Join(path, "stacks.txt")); !strings.Contains(string(stacks), "TestCrashDump") {
+		t.Errorf("caller stack missing: %q", stacks)
+	}
+}
+
+// Tests that panics recovered by the node are reported and keep panicking.
+func TestRecoverCrash(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary crash directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := testNodeConfig()
+	config.CrashDir = dir
+	stack, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := stack.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer stack.Stop()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("panic not resumed: %v", r)
+			}
+		}()
+		defer stack.RecoverCrash()
+		panic("boom")
+	}()
+	bundles, _ := filepath.Glob(filepath.Join(dir, "crash-*"))
+	if len(bundles) != 1 {
+		t.Fatalf("crash bundles mismatch: have %d, want 1", len(bundles))
+	}
+	if stacks, _ := ioutil.ReadFile(filepath.Join(bundles[0], "stacks.txt")); !strings.Contains(string(stacks), "TestRecoverCrash") {
+		t.Errorf("panicking stack missing: %q", stacks)
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/usechain/go-usechain/rpc"
+)
+
+const (
+	// handoverEnv is the environment variable listing the RPC listeners passed to
+	// an upgraded binary, as comma separated kind=fd pairs. The "ready" kind is a
+	// pipe reporting the startup progress back to the parent.
+	handoverEnv = "USECHAIN_HANDOVER_LISTENERS"
+
+	handoverReadyTimeout = 30 * time.Second // Maximum time to wait for the successor to come up to the datadir
+	handoverLockTimeout  = time.Minute      // Maximum time to wait for the previous instance to release the datadir
+	handoverDrainTimeout = 10 * time.Second // Maximum time to wait for in-flight RPC requests before stopping
+)
+
+// Startup progress reported by a successor over the handover pipe.
+const (
+	handoverFailed  byte = iota // Startup failed, the pipe is closed
+	handoverWaiting             // Configuration loaded, waiting for the datadir
+	handoverStarted             // Node fully started, the pipe is closed
+)
+
+// inheritListeners recreates the RPC listeners handed over by a parent process
+// upgrading in place, along with the pipe to report the startup progress on. It
+// returns nil if the process wasn't started by a handover.
+func inheritListeners() (map[string]net.Listener, *os.File, error) {
+	spec, ok := os.LookupEnv(handoverEnv)
+	if !ok {
+		return nil, nil, nil
+	}
+	// Don't pass stale descriptors on to any further upgrade
+	os.Unsetenv(handoverEnv)
+
+	var (
+		listeners = make(map[string]net.Listener)
+		ready     *os.File
+	)
+	for _, entry := range strings.Split(spec, ",") {
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, nil, fmt.Errorf("invalid handover listener %q", entry)
+		}
+		fd, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid handover listener %q: %v", entry, err)
+		}
+		file := os.NewFile(uintptr(fd), parts[0])
+		if parts[0] == "ready" {
+			ready = file
+			continue
+		}
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to inherit %s listener: %v", parts[0], err)
+		}
+		listeners[parts[0]] = listener
+	}
+	return listeners, ready, nil
+}
+
+// reportHandover tells the upgrading parent how far the startup got, closing the
+// pipe once the outcome is known.
+func (n *Node) reportHandover(status byte) {
+	if n.parentPipe == nil {
+		return
+	}
+	n.parentPipe.Write([]byte{status})
+	if status != handoverWaiting {
+		n.parentPipe.Close()
+		n.parentPipe = nil
+	}
+}
+
+// inheritedListener takes the handed over listener of the given kind, if any.
+func (n *Node) inheritedListener(kind string) net.Listener {
+	listener := n.inherited[kind]
+	if listener != nil {
+		delete(n.inherited, kind)
+		n.log.Info("Inherited RPC listener", "kind", kind, "addr", listener.Addr())
+	}
+	return listener
+}
+
+// closeInherited closes the handed over listeners which the node didn't take
+// up, e.g. because the endpoint was disabled in the upgraded configuration.
+func (n *Node) closeInherited() {
+	for kind, listener := range n.inherited {
+		n.log.Warn("Closing unused inherited RPC listener", "kind", kind, "addr", listener.Addr())
+		listener.Close()
+	}
+	n.inherited = nil
+}
+
+// Handover starts the given binary as the successor of the running node, passing
+// it the listening RPC sockets so that no connection is refused while upgrading.
+// Once the successor loaded its configuration and waits for the data directory,
+// the node drains its RPC endpoints and stops in the background, handing the data
+// directory over. Should the successor fail to start up after all, the node takes
+// over again in its place. The process id of the successor is returned.
+func (n *Node) Handover(binary string, args []string) (int, error) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.server == nil {
+		return 0, ErrNodeStopped
+	}
+	ready, report, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer report.Close()
+
+	var (
+		fds  = []uintptr{report.Fd()}
+		spec = []string{"ready=3"}
+	)
+	defer func() {
+		for _, fd := range fds[1:] {
+			closeFd(fd)
+		}
+	}()
+	for _, endpoint := range []struct {
+		kind     string
+		listener net.Listener
+	}{{"ipc", n.ipcListener}, {"http", n.httpListener}, {"ws", n.wsListener}} {
+		if endpoint.listener == nil {
+			continue
+		}
+		fd, err := listenerFd(endpoint.listener)
+		if err != nil {
+			ready.Close()
+			return 0, fmt.Errorf("failed to hand over %s listener: %v", endpoint.kind, err)
+		}
+		// Descriptors are numbered after stdin, stdout and stderr
+		spec = append(spec, fmt.Sprintf("%s=%d", endpoint.kind, 3+len(fds)))
+		fds = append(fds, fd)
+	}
+	env := append(os.Environ(), handoverEnv+"="+strings.Join(spec, ","))
+	proc, err := startSuccessor(binary, args, env, fds)
+	if err != nil {
+		ready.Close()
+		return 0, err
+	}
+	go proc.Wait() // Reap the successor if it exits while we're still around
+
+	// Only the successor may report over the pipe, so it reads EOF if that exits
+	report.Close()
+
+	// Keep serving until the successor loaded its configuration successfully
+	if status := readHandover(ready, handoverReadyTimeout); status != handoverWaiting {
+		ready.Close()
+		proc.Kill()
+		return 0, fmt.Errorf("upgraded instance failed to start")
+	}
+	// The successor owns the IPC socket now, don't remove it on close
+	if unix, ok := n.ipcListener.(*net.UnixListener); ok {
+		unix.SetUnlinkOnClose(false)
+	}
+	n.log.Info("Handing over to upgraded instance", "binary", binary, "pid", proc.Pid)
+
+	go n.handOver(ready)
+	return proc.Pid, nil
+}
+
+// handOver drains and stops the node for the successor to take over, waiting for
+// it to report back. If the successor fails to start, the node is started again.
+func (n *Node) handOver(ready *os.File) {
+	defer ready.Close()
+
+	n.drain(handoverDrainTimeout)
+
+	n.lock.Lock()
+	stop, hook := n.stop, n.handoverHook
+	if err := n.terminate(); err != nil {
+		n.log.Error("Failed to stop after handover", "err", err)
+	}
+	n.lock.Unlock()
+
+	if readHandover(ready, 0) == handoverStarted {
+		close(stop)
+		if hook != nil {
+			hook(false)
+		}
+		return
+	}
+	n.log.Error("Upgraded instance failed to start, resuming")
+
+	n.lock.Lock()
+	err := n.start()
+	if err != nil {
+		n.log.Error("Failed to resume after handover", "err", err)
+		close(stop)
+	}
+	n.lock.Unlock()
+
+	if hook != nil {
+		hook(err == nil)
+	}
+}
+
+// readHandover waits for the next startup progress report of the successor, up to
+// the given timeout if non-zero. A closed pipe is reported as a failure.
+func readHandover(ready *os.File, timeout time.Duration) byte {
+	if timeout > 0 {
+		ready.SetReadDeadline(time.Now().Add(timeout))
+	}
+	status := make([]byte, 1)
+	if _, err := io.ReadFull(ready, status); err != nil {
+		return handoverFailed
+	}
+	return status[0]
+}
+
+// drain stops accepting RPC connections and waits for the in-flight requests to
+// finish, up to the given timeout. Requests arriving over already established
+// IPC and websocket connections are still served until the node stops.
+func (n *Node) drain(timeout time.Duration) {
+	n.lock.Lock()
+	servers := []*http.Server{n.httpServer, n.wsServer}
+	handlers := []*rpc.Server{n.ipcHandler, n.httpHandler, n.wsHandler}
+	if n.ipcListener != nil {
+		n.ipcListener.Close()
+		n.ipcListener = nil
+	}
+	n.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, server := range servers {
+		if server == nil {
+			continue
+		}
+		if err := server.Shutdown(ctx); err != nil {
+			n.log.Warn("RPC connections not drained in time", "err", err)
+		}
+	}
+	// Hijacked websocket and IPC connections aren't tracked by the HTTP servers
+	for _, handler := range handlers {
+		if handler == nil {
+			continue
+		}
+		if err := handler.Idle(ctx); err != nil {
+			n.log.Warn("RPC requests not drained in time", "err", err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !windows
+
+package node
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests that a node started by a handover inherits the RPC listeners of its
+// predecessor and waits for it to release the data directory.
+func TestNodeHandover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatalf("failed to create temporary data directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &Config{DataDir: dir, HTTPHost: "127.0.0.1", HTTPPort: 0}
+	original, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create original protocol stack: %v", err)
+	}
+	if err := original.Start(); err != nil {
+		t.Fatalf("failed to start original protocol stack: %v", err)
+	}
+	addr := original.httpListener.Addr().String()
+	// The successor takes ownership of the descriptor it's handed, pass a copy
+	fd, err := listenerFd(original.httpListener)
+	if err != nil {
+		t.Fatalf("failed to duplicate listener descriptor: %v", err)
+	}
+	// Start the successor, which must wait for the datadir to be released
+	os.Setenv(handoverEnv, fmt.Sprintf("http=%d", fd))
+	defer os.Unsetenv(handoverEnv)
+
+	successor, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to create successor protocol stack: %v", err)
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- successor.Start() }()
+
+	select {
+	case err := <-errc:
+		t.Fatalf("successor started before the handover: %v", err)
+	case <-time.After(250 * time.Millisecond):
+	}
+	original.drain(time.Second)
+	if err := original.Stop(); err != nil {
+		t.Fatalf("failed to stop original protocol stack: %v", err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatalf("failed to start successor protocol stack: %v", err)
+	}
+	defer successor.Stop()
+
+	if _, ok := os.LookupEnv(handoverEnv); ok {
+		t.Errorf("handover environment not cleared")
+	}
+	// The inherited socket must be served by the successor
+	res, err := http.Post("http://"+addr, "application/json", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc_modules"}`))
+	if err != nil {
+		t.Fatalf("failed to query inherited endpoint: %v", err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("inherited endpoint status mismatch: have %d, want %d", res.StatusCode, http.StatusOK)
+	}
+}
+
+// Tests that a successor failing to load its configuration aborts the handover,
+// leaving the node running.
+func TestNodeHandoverAbort(t *testing.T) {
+	original, err := New(&Config{HTTPHost: "127.0.0.1", HTTPPort: 0})
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := original.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer original.Stop()
+
+	if _, err := original.Handover("/bin/sh", []string{"-c", "exit 1"}); err == nil {
+		t.Fatalf("handover to failing successor succeeded")
+	}
+	if original.Server() == nil {
+		t.Fatalf("node stopped after aborted handover")
+	}
+}
+
+// Tests that the node resumes if the successor fails after it was handed over.
+func TestNodeHandoverResume(t *testing.T) {
+	original, err := New(&Config{HTTPHost: "127.0.0.1", HTTPPort: 0})
+	if err != nil {
+		t.Fatalf("failed to create protocol stack: %v", err)
+	}
+	if err := original.Start(); err != nil {
+		t.Fatalf("failed to start protocol stack: %v", err)
+	}
+	defer original.Stop()
+
+	original.lock.RLock()
+	stopped := original.stop
+	original.lock.RUnlock()
+
+	resumed := make(chan bool, 1)
+	original.handoverHook = func(ok bool) { resumed <- ok }
+
+	// Report waiting for the datadir, then die before starting up
+	script := fmt.Sprintf("printf '\\%03o' >&3", handoverWaiting)
+	if _, err := original.Handover("/bin/sh", []string{"-c", script}); err != nil {
+		t.Fatalf("failed to hand over: %v", err)
+	}
+	if !<-resumed {
+		t.Fatalf("node not resumed after failed handover")
+	}
+	original.lock.RLock()
+	running := original.server != nil && original.httpListener != nil
+	original.lock.RUnlock()
+	if !running {
+		t.Fatalf("resumed node not running")
+	}
+	// A stopping node closes its stop channel before reporting the outcome
+	select {
+	case <-stopped:
+		t.Fatalf("node reported stopped while resuming")
+	default:
+	}
+}
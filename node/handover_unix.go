@@ -0,0 +1,74 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build !windows
+
+package node
+
+import (
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// listenerFd duplicates the descriptor of a listening socket. Unlike going through
+// File, this leaves the socket in non-blocking mode, which the listener relies on
+// to be closable while accepting.
+func listenerFd(listener net.Listener) (uintptr, error) {
+	conn, ok := listener.(syscall.Conn)
+	if !ok {
+		return 0, errors.New("listener has no descriptor")
+	}
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var (
+		fd     int
+		dupErr error
+	)
+	if err := raw.Control(func(sock uintptr) { fd, dupErr = syscall.Dup(int(sock)) }); err != nil {
+		return 0, err
+	}
+	if dupErr != nil {
+		return 0, dupErr
+	}
+	syscall.CloseOnExec(fd)
+	return uintptr(fd), nil
+}
+
+// closeFd closes a descriptor obtained from listenerFd.
+func closeFd(fd uintptr) {
+	syscall.Close(int(fd))
+}
+
+// startSuccessor runs the given binary, passing it the descriptors after stdin,
+// stdout and stderr. It sidesteps os/exec, which switches the passed descriptors
+// into blocking mode and with them the listeners sharing their sockets.
+func startSuccessor(binary string, args []string, env []string, fds []uintptr) (*os.Process, error) {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return nil, err
+	}
+	files := append([]uintptr{os.Stdin.Fd(), os.Stdout.Fd(), os.Stderr.Fd()}, fds...)
+	pid, err := syscall.ForkExec(path, append([]string{binary}, args...), &syscall.ProcAttr{Env: env, Files: files})
+	if err != nil {
+		return nil, err
+	}
+	return os.FindProcess(pid)
+}
@@ -0,0 +1,39 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package node
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// errHandoverUnsupported is returned when trying to hand over on Windows.
+var errHandoverUnsupported = errors.New("handover not supported on Windows")
+
+// listenerFd is not supported on Windows.
+func listenerFd(listener net.Listener) (uintptr, error) {
+	return 0, errHandoverUnsupported
+}
+
+// closeFd is not supported on Windows.
+func closeFd(fd uintptr) {}
+
+// startSuccessor is not supported on Windows.
+func startSuccessor(binary string, args []string, env []string, fds []uintptr) (*os.Process, error) {
+	return nil, errHandoverUnsupported
+}
@@ -20,12 +20,15 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/prometheus/prometheus/util/flock"
 	"github.com/usechain/go-usechain/accounts"
 	"github.com/usechain/go-usechain/ethdb"
 	"github.com/usechain/go-usechain/event"
@@ -33,7 +36,6 @@ import (
 	"github.com/usechain/go-usechain/log"
 	"github.com/usechain/go-usechain/p2p"
 	"github.com/usechain/go-usechain/rpc"
-	"github.com/prometheus/prometheus/util/flock"
 )
 
 // Node is a container on which services can be registered.
@@ -62,10 +64,22 @@ type Node struct {
 	httpWhitelist []string     // HTTP RPC modules to allow through this endpoint
 	httpListener  net.Listener // HTTP RPC listener socket to server API requests
 	httpHandler   *rpc.Server  // HTTP RPC request handler to process the API requests
+	httpServer    *http.Server // HTTP server serving the RPC handler, drained on handover
 
 	wsEndpoint string       // Websocket endpoint (interface + port) to listen at (empty = websocket disabled)
 	wsListener net.Listener // Websocket RPC listener socket to server API requests
 	wsHandler  *rpc.Server  // Websocket RPC request handler to process the API requests
+	wsServer   *http.Server // Websocket server serving the RPC handler, drained on handover
+
+	inherited  map[string]net.Listener // RPC listeners handed over by an upgrading parent (nil = regular start)
+	parentPipe *os.File                // Pipe reporting the startup progress to an upgrading parent
+
+	handoverHook func(resumed bool) // Method to call upon the outcome of a handover, whether the node resumed
+
+	effective *EffectiveConfig // Resolved configuration reported over RPC
+
+	crash     *crashReporter // Crash report writer, nil if disabled or not running
+	crashLock sync.Mutex     // Protects the crash reporter, independent of lock to report from anywhere
 
 	stop chan struct{} // Channel to wait for termination notifications
 	lock sync.RWMutex
@@ -143,6 +157,26 @@ func (n *Node) Start() error {
 	if n.server != nil {
 		return ErrNodeRunning
 	}
+	if err := n.start(); err != nil {
+		n.reportHandover(handoverFailed)
+		return err
+	}
+	n.stop = make(chan struct{})
+	return nil
+}
+
+// start boots up the P2P node, the services and the RPC endpoints. It assumes
+// that the node lock is held.
+func (n *Node) start() error {
+	inherited, pipe, err := inheritListeners()
+	if err != nil {
+		return err
+	}
+	n.inherited, n.parentPipe = inherited, pipe
+	defer n.closeInherited()
+
+	n.reportHandover(handoverWaiting)
+
 	if err := n.openDataDir(); err != nil {
 		return err
 	}
@@ -222,7 +256,9 @@ func (n *Node) Start() error {
 	// Finish initializing the startup
 	n.services = services
 	n.server = running
-	n.stop = make(chan struct{})
+
+	n.startCrashReports(services)
+	n.reportHandover(handoverStarted)
 
 	return nil
 }
@@ -239,6 +275,14 @@ func (n *Node) openDataDir() error {
 	// Lock the instance directory to prevent concurrent use by another instance as well as
 	// accidental use of the instance directory as a database.
 	release, _, err := flock.New(filepath.Join(instdir, "LOCK"))
+	if err != nil && n.inherited != nil {
+		// The upgrading parent holds the lock until it has drained
+		n.log.Info("Waiting for the previous instance to hand over the data directory")
+		for deadline := time.Now().Add(handoverLockTimeout); err != nil && time.Now().Before(deadline); {
+			time.Sleep(100 * time.Millisecond)
+			release, _, err = flock.New(filepath.Join(instdir, "LOCK"))
+		}
+	}
 	if err != nil {
 		return convertFileLockError(err)
 	}
@@ -320,8 +364,10 @@ func (n *Node) startIPC(apis []rpc.API) error {
 		listener net.Listener
 		err      error
 	)
-	if listener, err = rpc.CreateIPCListener(n.ipcEndpoint); err != nil {
-		return err
+	if listener = n.inheritedListener("ipc"); listener == nil {
+		if listener, err = rpc.CreateIPCListener(n.ipcEndpoint); err != nil {
+			return err
+		}
 	}
 	go func() {
 		n.log.Info("IPC endpoint opened", "url", n.ipcEndpoint)
@@ -390,15 +436,19 @@ func (n *Node) startHTTP(endpoint string, apis []rpc.API, modules []string, cors
 		listener net.Listener
 		err      error
 	)
-	if listener, err = net.Listen("tcp", endpoint); err != nil {
-		return err
+	if listener = n.inheritedListener("http"); listener == nil {
+		if listener, err = net.Listen("tcp", endpoint); err != nil {
+			return err
+		}
 	}
-	go rpc.NewHTTPServer(cors, vhosts, handler).Serve(listener)
+	server := rpc.NewHTTPServer(cors, vhosts, handler)
+	go server.Serve(listener)
 	n.log.Info("HTTP endpoint opened", "url", fmt.Sprintf("http://%s", endpoint), "cors", strings.Join(cors, ","), "vhosts", strings.Join(vhosts, ","))
 	// All listeners booted successfully
 	n.httpEndpoint = endpoint
 	n.httpListener = listener
 	n.httpHandler = handler
+	n.httpServer = server
 
 	return nil
 }
@@ -415,6 +465,7 @@ func (n *Node) stopHTTP() {
 		n.httpHandler.Stop()
 		n.httpHandler = nil
 	}
+	n.httpServer = nil
 }
 
 // startWS initializes and starts the websocket RPC endpoint.
@@ -443,16 +494,20 @@ func (n *Node) startWS(endpoint string, apis []rpc.API, modules []string, wsOrig
 		listener net.Listener
 		err      error
 	)
-	if listener, err = net.Listen("tcp", endpoint); err != nil {
-		return err
+	if listener = n.inheritedListener("ws"); listener == nil {
+		if listener, err = net.Listen("tcp", endpoint); err != nil {
+			return err
+		}
 	}
-	go rpc.NewWSServer(wsOrigins, handler).Serve(listener)
+	server := rpc.NewWSServer(wsOrigins, handler)
+	go server.Serve(listener)
 	n.log.Info("WebSocket endpoint opened", "url", fmt.Sprintf("ws://%s", listener.Addr()))
 
 	// All listeners booted successfully
 	n.wsEndpoint = endpoint
 	n.wsListener = listener
 	n.wsHandler = handler
+	n.wsServer = server
 
 	return nil
 }
@@ -469,6 +524,7 @@ func (n *Node) stopWS() {
 		n.wsHandler.Stop()
 		n.wsHandler = nil
 	}
+	n.wsServer = nil
 }
 
 // Stop terminates a running node along with all it's services. In the node was
@@ -481,7 +537,30 @@ func (n *Node) Stop() error {
 	if n.server == nil {
 		return ErrNodeStopped
 	}
+	failure := n.terminate()
+
+	// unblock n.Wait
+	close(n.stop)
+
+	// Remove the keystore if it was created ephemerally.
+	var keystoreErr error
+	if n.ephemeralKeystore != "" {
+		keystoreErr = os.RemoveAll(n.ephemeralKeystore)
+	}
+
+	if failure != nil {
+		return failure
+	}
+	if keystoreErr != nil {
+		return keystoreErr
+	}
+	return nil
+}
 
+// terminate stops the RPC endpoints, the services and the p2p server, releasing
+// the data directory. It assumes that the node lock is held and leaves n.Wait
+// blocked, so the node may be started again in place.
+func (n *Node) terminate() error {
 	// Terminate the API, services and the p2p server.
 	n.stopWS()
 	n.stopHTTP()
@@ -498,6 +577,7 @@ func (n *Node) Stop() error {
 	n.server.Stop()
 	n.services = nil
 	n.server = nil
+	n.stopCrashReports()
 
 	// Release instance directory lock.
 	if n.instanceDirLock != nil {
@@ -506,22 +586,9 @@ func (n *Node) Stop() error {
 		}
 		n.instanceDirLock = nil
 	}
-
-	// unblock n.Wait
-	close(n.stop)
-
-	// Remove the keystore if it was created ephemerally.
-	var keystoreErr error
-	if n.ephemeralKeystore != "" {
-		keystoreErr = os.RemoveAll(n.ephemeralKeystore)
-	}
-
 	if len(failure.Services) > 0 {
 		return failure
 	}
-	if keystoreErr != nil {
-		return keystoreErr
-	}
 	return nil
 }
 
@@ -583,6 +650,24 @@ func (n *Node) Server() *p2p.Server {
 	return n.server
 }
 
+// SetEffectiveConfig records the fully resolved configuration the node was
+// assembled from, to be reported by admin_effectiveConfig.
+func (n *Node) SetEffectiveConfig(cfg *EffectiveConfig) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	n.effective = cfg
+}
+
+// EffectiveConfig retrieves the resolved configuration recorded for the node,
+// or nil if the embedding application did not provide one.
+func (n *Node) EffectiveConfig() *EffectiveConfig {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	return n.effective
+}
+
 // Service retrieves a currently running service registered of a specific type.
 func (n *Node) Service(service interface{}) error {
 	n.lock.RLock()
@@ -645,7 +730,7 @@ func (n *Node) OpenDatabase(name string, cache, handles int) (ethdb.Database, er
 	if n.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	return ethdb.NewLDBDatabase(n.config.resolvePath(name), cache, handles)
+	return ethdb.Open(n.config.DBEngine, n.config.resolvePath(name), cache, handles, n.config.DBReadOnly)
 }
 
 // ResolvePath returns the absolute path of a resource in the instance directory.
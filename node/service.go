@@ -43,11 +43,7 @@ func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (et
 	if ctx.config.DataDir == "" {
 		return ethdb.NewMemDatabase()
 	}
-	db, err := ethdb.NewLDBDatabase(ctx.config.resolvePath(name), cache, handles)
-	if err != nil {
-		return nil, err
-	}
-	return db, nil
+	return ethdb.Open(ctx.config.DBEngine, ctx.config.resolvePath(name), cache, handles, ctx.config.DBReadOnly)
 }
 
 // ResolvePath resolves a user path into the data directory if that was relative
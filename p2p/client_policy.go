@@ -0,0 +1,175 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ClientPolicy lists the requirements remote clients have to meet to be accepted
+// as peers, allowing operators to shed outdated nodes ahead of a hard fork.
+// Trusted peers are exempt from the policy.
+type ClientPolicy struct {
+	// MinVersions are the minimum accepted versions of the listed clients, each
+	// given as name/version (e.g. used/0.4.0). Clients not listed are accepted
+	// regardless of their version.
+	MinVersions []string `toml:",omitempty"`
+
+	// RequiredCaps are the protocol capabilities every peer has to advertise, at
+	// the given version or higher.
+	RequiredCaps []Cap `toml:",omitempty"`
+}
+
+// ParseClientPolicy creates a policy out of comma separated lists of minimum
+// client versions (name/version) and required capabilities (name/version).
+func ParseClientPolicy(versions string, caps string) (*ClientPolicy, error) {
+	policy := &ClientPolicy{MinVersions: splitList(versions)}
+	for _, rule := range splitList(caps) {
+		parts := strings.Split(rule, "/")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid capability %q, want name/version", rule)
+		}
+		version, err := strconv.ParseUint(parts[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid capability %q: %v", rule, err)
+		}
+		policy.RequiredCaps = append(policy.RequiredCaps, Cap{Name: parts[0], Version: uint(version)})
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// validate checks that all the minimum version rules are well formed.
+func (p *ClientPolicy) validate() error {
+	for _, rule := range p.MinVersions {
+		if _, _, err := parseVersionRule(rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check verifies a remote client, identified by the name and capabilities sent
+// in its protocol handshake, against the policy.
+func (p *ClientPolicy) Check(name string, caps []Cap) error {
+	client, version, ok := parseClientName(name)
+	for _, rule := range p.MinVersions {
+		want, min, err := parseVersionRule(rule)
+		if err != nil || !strings.EqualFold(client, want) {
+			continue
+		}
+		if !ok {
+			return fmt.Errorf("client %s has no version", client)
+		}
+		if compareVersions(version, min) < 0 {
+			return fmt.Errorf("client %s version %s below minimum %s", client, formatVersion(version), formatVersion(min))
+		}
+	}
+	for _, want := range p.RequiredCaps {
+		found := false
+		for _, cap := range caps {
+			if cap.Name == want.Name && cap.Version >= want.Version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("missing capability %v", want)
+		}
+	}
+	return nil
+}
+
+// parseVersionRule splits a name/version minimum version rule.
+func parseVersionRule(rule string) (string, [3]uint64, error) {
+	idx := strings.LastIndex(rule, "/")
+	if idx <= 0 {
+		return "", [3]uint64{}, fmt.Errorf("invalid client version %q, want name/version", rule)
+	}
+	version, ok := parseVersion(rule[idx+1:])
+	if !ok {
+		return "", [3]uint64{}, fmt.Errorf("invalid client version %q", rule)
+	}
+	return rule[:idx], version, nil
+}
+
+// parseClientName extracts the client name and version from a node name of the
+// form name[/identity]/vX.Y.Z[-meta]/os-arch/runtime.
+func parseClientName(name string) (string, [3]uint64, bool) {
+	parts := strings.Split(name, "/")
+	for _, part := range parts[1:] {
+		if version, ok := parseVersion(part); ok {
+			return parts[0], version, true
+		}
+	}
+	return parts[0], [3]uint64{}, false
+}
+
+// parseVersion parses a semantic version with an optional v prefix, missing
+// minor and patch numbers and trailing metadata (e.g. v0.4.0-stable-abcdef).
+func parseVersion(s string) ([3]uint64, bool) {
+	var version [3]uint64
+
+	s = strings.TrimPrefix(s, "v")
+	if idx := strings.IndexAny(s, "-+"); idx >= 0 {
+		s = s[:idx]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return version, false
+	}
+	for i, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
+
+// compareVersions returns -1, 0 or 1 if a is lower, equal or higher than b.
+func compareVersions(a, b [3]uint64) int {
+	for i := range a {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func formatVersion(v [3]uint64) string {
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import "testing"
+
+func TestClientPolicy(t *testing.T) {
+	policy, err := ParseClientPolicy("used/0.4.0, Other/1.2", "eth/63")
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	caps := []Cap{{"eth", 63}}
+
+	tests := []struct {
+		name   string
+		caps   []Cap
+		accept bool
+	}{
+		{"used/v0.4.0-stable/linux-amd64/go1.10", caps, true},
+		{"used/v0.4.1-unstable-abcdef12/linux-amd64/go1.10", caps, true},
+		{"used/v1.0.0/darwin-amd64/go1.10", caps, true},
+		{"used/myname/v0.5.0-stable/linux-amd64/go1.10", caps, true},
+		{"used/v0.3.9-stable/linux-amd64/go1.10", caps, false},
+		{"USED/v0.3.0/linux-amd64/go1.10", caps, false},
+		{"used/linux-amd64/go1.10", caps, false},
+		{"other/v1.2.0/linux-amd64/go1.10", caps, true},
+		{"other/v1.1.7/linux-amd64/go1.10", caps, false},
+		{"Geth/v1.8.0-stable/linux-amd64/go1.10", caps, true},
+		{"used/v0.4.0-stable/linux-amd64/go1.10", []Cap{{"eth", 62}}, false},
+		{"used/v0.4.0-stable/linux-amd64/go1.10", []Cap{{"les", 2}}, false},
+		{"used/v0.4.0-stable/linux-amd64/go1.10", []Cap{{"eth", 62}, {"eth", 64}}, true},
+	}
+	for i, tt := range tests {
+		err := policy.Check(tt.name, tt.caps)
+		if tt.accept && err != nil {
+			t.Errorf("test %d: %s rejected: %v", i, tt.name, err)
+		}
+		if !tt.accept && err == nil {
+			t.Errorf("test %d: %s accepted", i, tt.name)
+		}
+	}
+}
+
+func TestParseClientPolicyInvalid(t *testing.T) {
+	invalid := []struct{ versions, caps string }{
+		{"used", ""},
+		{"used/latest", ""},
+		{"/0.4.0", ""},
+		{"used/0.4.0.1", ""},
+		{"", "eth"},
+		{"", "eth/x"},
+	}
+	for _, tt := range invalid {
+		if _, err := ParseClientPolicy(tt.versions, tt.caps); err == nil {
+			t.Errorf("policy %q %q parsed without error", tt.versions, tt.caps)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// egressLimiter is a token bucket capping the rate at which sub-protocol messages
+// are written to the network. A single limiter is shared by all the peers of a
+// server, so the cap applies to the combined upload bandwidth.
+type egressLimiter struct {
+	rate float64 // Number of bytes allowed per second (also the burst size)
+
+	tokens float64   // Bytes currently available for sending, negative if in debt
+	last   time.Time // Time of the last token refill
+	lock   sync.Mutex
+}
+
+// newEgressLimiter creates a limiter allowing rate bytes per second.
+func newEgressLimiter(rate uint64) *egressLimiter {
+	return &egressLimiter{
+		rate:   float64(rate),
+		tokens: float64(rate),
+		last:   time.Now(),
+	}
+}
+
+// wait blocks until size bytes may be sent, or until the closed channel fires.
+// Messages larger than the allowance are let through, but the resulting debt is
+// paid off by delaying the subsequent ones. A nil limiter never blocks.
+func (l *egressLimiter) wait(size uint32, closed <-chan struct{}) error {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(size)
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.lock.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-closed:
+		return fmt.Errorf("shutting down")
+	}
+}
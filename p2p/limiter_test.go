@@ -0,0 +1,54 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEgressLimiter(t *testing.T) {
+	limiter := newEgressLimiter(1000)
+	closed := make(chan struct{})
+
+	// The initial allowance should be available without delay
+	start := time.Now()
+	if err := limiter.wait(1000, closed); err != nil {
+		t.Fatalf("failed to send within allowance: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Fatalf("send within allowance delayed: %v", elapsed)
+	}
+	// Exceeding the allowance should delay proportionally to the excess
+	start = time.Now()
+	if err := limiter.wait(200, closed); err != nil {
+		t.Fatalf("failed to send over allowance: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Fatalf("send over allowance not throttled: %v", elapsed)
+	}
+	// Shutting down should abort any pending waits
+	close(closed)
+	if err := limiter.wait(10000, closed); err == nil {
+		t.Fatalf("throttled send succeeded after shutdown")
+	}
+	// A nil limiter should never block
+	var nolimit *egressLimiter
+	if err := nolimit.wait(1<<30, closed); err != nil {
+		t.Fatalf("nil limiter failed: %v", err)
+	}
+}
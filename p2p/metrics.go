@@ -19,7 +19,9 @@
 package p2p
 
 import (
+	"fmt"
 	"net"
+	"sync/atomic"
 
 	"github.com/usechain/go-usechain/metrics"
 )
@@ -69,3 +71,104 @@ func (c *meteredConn) Write(b []byte) (n int, err error) {
 	egressTrafficMeter.Mark(int64(n))
 	return
 }
+
+// trafficCounter tracks the number of bytes exchanged with a single peer over
+// a particular sub-protocol, also feeding the protocol wide traffic meters.
+type trafficCounter struct {
+	ingress uint64 // Number of payload bytes received (accessed atomically)
+	egress  uint64 // Number of payload bytes sent (accessed atomically)
+
+	ingressMeter metrics.Meter // Protocol wide inbound traffic meter
+	egressMeter  metrics.Meter // Protocol wide outbound traffic meter
+
+	codes []*codeCounter // Per message code accounting, indexed by relative code
+}
+
+// codeCounter tracks the messages of a single type exchanged with a peer, also
+// feeding the message type wide meters.
+type codeCounter struct {
+	name string // Name of the message type for reporting
+
+	ingress, ingressMsgs uint64 // Bytes and messages received (accessed atomically)
+	egress, egressMsgs   uint64 // Bytes and messages sent (accessed atomically)
+
+	ingressMeter, ingressMsgMeter metrics.Meter // Message type wide inbound meters
+	egressMeter, egressMsgMeter   metrics.Meter // Message type wide outbound meters
+}
+
+// newTrafficCounter creates a traffic counter for a sub-protocol, registering
+// the protocol and message type wide meters if they don't exist yet.
+func newTrafficCounter(proto Protocol) *trafficCounter {
+	counter := &trafficCounter{
+		ingressMeter: metrics.GetOrRegisterMeter(fmt.Sprintf("p2p/%s/InboundTraffic", proto.Name), nil),
+		egressMeter:  metrics.GetOrRegisterMeter(fmt.Sprintf("p2p/%s/OutboundTraffic", proto.Name), nil),
+		codes:        make([]*codeCounter, proto.Length),
+	}
+	for code := range counter.codes {
+		name := fmt.Sprintf("0x%02x", code)
+		if proto.MessageName != nil {
+			if msg := proto.MessageName(uint64(code)); msg != "" {
+				name = msg
+			}
+		}
+		prefix := fmt.Sprintf("p2p/%s/%s/", proto.Name, name)
+		counter.codes[code] = &codeCounter{
+			name:            name,
+			ingressMeter:    metrics.GetOrRegisterMeter(prefix+"InboundTraffic", nil),
+			ingressMsgMeter: metrics.GetOrRegisterMeter(prefix+"InboundMessages", nil),
+			egressMeter:     metrics.GetOrRegisterMeter(prefix+"OutboundTraffic", nil),
+			egressMsgMeter:  metrics.GetOrRegisterMeter(prefix+"OutboundMessages", nil),
+		}
+	}
+	return counter
+}
+
+// markIngress accounts for a message received from the remote peer.
+func (c *trafficCounter) markIngress(code uint64, size uint32) {
+	atomic.AddUint64(&c.ingress, uint64(size))
+	c.ingressMeter.Mark(int64(size))
+
+	if code < uint64(len(c.codes)) {
+		counter := c.codes[code]
+		atomic.AddUint64(&counter.ingress, uint64(size))
+		atomic.AddUint64(&counter.ingressMsgs, 1)
+		counter.ingressMeter.Mark(int64(size))
+		counter.ingressMsgMeter.Mark(1)
+	}
+}
+
+// markEgress accounts for a message sent to the remote peer.
+func (c *trafficCounter) markEgress(code uint64, size uint32) {
+	atomic.AddUint64(&c.egress, uint64(size))
+	c.egressMeter.Mark(int64(size))
+
+	if code < uint64(len(c.codes)) {
+		counter := c.codes[code]
+		atomic.AddUint64(&counter.egress, uint64(size))
+		atomic.AddUint64(&counter.egressMsgs, 1)
+		counter.egressMeter.Mark(int64(size))
+		counter.egressMsgMeter.Mark(1)
+	}
+}
+
+// stats returns a snapshot of the traffic exchanged so far, broken down by the
+// message types exchanged at least once.
+func (c *trafficCounter) stats() *PeerTraffic {
+	stats := &PeerTraffic{
+		Ingress:  atomic.LoadUint64(&c.ingress),
+		Egress:   atomic.LoadUint64(&c.egress),
+		Messages: make(map[string]*MessageTraffic),
+	}
+	for _, counter := range c.codes {
+		msg := &MessageTraffic{
+			Ingress:         atomic.LoadUint64(&counter.ingress),
+			IngressMessages: atomic.LoadUint64(&counter.ingressMsgs),
+			Egress:          atomic.LoadUint64(&counter.egress),
+			EgressMessages:  atomic.LoadUint64(&counter.egressMsgs),
+		}
+		if msg.IngressMessages > 0 || msg.EgressMessages > 0 {
+			stats.Messages[counter.name] = msg
+		}
+	}
+	return stats
+}
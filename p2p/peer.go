@@ -109,6 +109,9 @@ type Peer struct {
 
 	// events receives message send / receive events if set
 	events *event.Feed
+
+	// limiter throttles the outbound sub-protocol traffic if set
+	limiter *egressLimiter
 }
 
 // NewPeer returns a peer for testing purposes.
@@ -286,6 +289,7 @@ func (p *Peer) handle(msg Msg) error {
 		if err != nil {
 			return fmt.Errorf("msg code out of range: %v", msg.Code)
 		}
+		proto.traffic.markIngress(msg.Code-proto.offset, msg.Size)
 		select {
 		case proto.in <- msg:
 			return nil
@@ -323,7 +327,7 @@ outer:
 					offset -= old.Length
 				}
 				// Assign the new match
-				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw}
+				result[cap.Name] = &protoRW{Protocol: proto, offset: offset, in: make(chan Msg), w: rw, traffic: newTrafficCounter(proto)}
 				offset += proto.Length
 
 				continue outer
@@ -340,6 +344,7 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.closed = p.closed
 		proto.wstart = writeStart
 		proto.werr = writeErr
+		proto.limiter = p.limiter
 		var rw MsgReadWriter = proto
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name)
@@ -378,16 +383,26 @@ type protoRW struct {
 	werr   chan<- error    // for write results
 	offset uint64
 	w      MsgWriter
+
+	traffic *trafficCounter // counts the bytes exchanged over this protocol
+	limiter *egressLimiter  // throttles outbound messages if set
 }
 
 func (rw *protoRW) WriteMsg(msg Msg) (err error) {
 	if msg.Code >= rw.Length {
 		return newPeerError(errInvalidMsgCode, "not handled")
 	}
+	code := msg.Code
 	msg.Code += rw.offset
+	if err = rw.limiter.wait(msg.Size, rw.closed); err != nil {
+		return err
+	}
 	select {
 	case <-rw.wstart:
 		err = rw.w.WriteMsg(msg)
+		if err == nil {
+			rw.traffic.markEgress(code, msg.Size)
+		}
 		// Report write status back to Peer.run. It will initiate
 		// shutdown if the error is non-nil and unblock the next write
 		// otherwise. The calling protocol code should exit for errors
@@ -423,7 +438,55 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Protocols map[string]interface{}  `json:"protocols"` // Sub-protocol specific metadata fields
+	Traffic   map[string]*PeerTraffic `json:"traffic"`   // Bytes exchanged with the peer per sub-protocol
+}
+
+// PeerTraffic is the number of payload bytes exchanged with a peer over a single
+// sub-protocol since the connection was established.
+type PeerTraffic struct {
+	Ingress  uint64                     `json:"ingress"`  // Bytes received from the peer
+	Egress   uint64                     `json:"egress"`   // Bytes sent to the peer
+	Messages map[string]*MessageTraffic `json:"messages"` // Traffic per message type
+}
+
+// MessageTraffic is the traffic of a single message type exchanged with a peer.
+type MessageTraffic struct {
+	Ingress         uint64 `json:"ingress"`         // Bytes received from the peer
+	IngressMessages uint64 `json:"ingressMessages"` // Messages received from the peer
+	Egress          uint64 `json:"egress"`          // Bytes sent to the peer
+	EgressMessages  uint64 `json:"egressMessages"`  // Messages sent to the peer
+}
+
+// PeerStats is the traffic accounting of a connected peer.
+type PeerStats struct {
+	ID            string                  `json:"id"`
+	Name          string                  `json:"name"`
+	RemoteAddress string                  `json:"remoteAddress"`
+	Inbound       bool                    `json:"inbound"`
+	Connected     uint64                  `json:"connected"` // Seconds since the connection was established
+	Ingress       uint64                  `json:"ingress"`   // Bytes received from the peer over all sub-protocols
+	Egress        uint64                  `json:"egress"`    // Bytes sent to the peer over all sub-protocols
+	Traffic       map[string]*PeerTraffic `json:"traffic"`   // Traffic per sub-protocol
+}
+
+// Stats gathers the traffic exchanged with the peer so far.
+func (p *Peer) Stats() *PeerStats {
+	stats := &PeerStats{
+		ID:            p.ID().String(),
+		Name:          p.Name(),
+		RemoteAddress: p.RemoteAddr().String(),
+		Inbound:       p.Inbound(),
+		Connected:     uint64(time.Duration(mclock.Now()-p.created) / time.Second),
+		Traffic:       make(map[string]*PeerTraffic),
+	}
+	for _, proto := range p.running {
+		traffic := proto.traffic.stats()
+		stats.Traffic[proto.Name] = traffic
+		stats.Ingress += traffic.Ingress
+		stats.Egress += traffic.Egress
+	}
+	return stats
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -439,6 +502,7 @@ func (p *Peer) Info() *PeerInfo {
 		Name:      p.Name(),
 		Caps:      caps,
 		Protocols: make(map[string]interface{}),
+		Traffic:   make(map[string]*PeerTraffic),
 	}
 	info.Network.LocalAddress = p.LocalAddr().String()
 	info.Network.RemoteAddress = p.RemoteAddr().String()
@@ -457,6 +521,7 @@ func (p *Peer) Info() *PeerInfo {
 			}
 		}
 		info.Protocols[proto.Name] = protoInfo
+		info.Traffic[proto.Name] = proto.traffic.stats()
 	}
 	return info
 }
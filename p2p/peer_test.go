@@ -98,6 +98,66 @@ func TestPeerProtoReadMsg(t *testing.T) {
 	}
 }
 
+func TestPeerTrafficStats(t *testing.T) {
+	done := make(chan struct{})
+	proto := Protocol{
+		Name:   "a",
+		Length: 5,
+		MessageName: func(code uint64) string {
+			if code == 1 {
+				return "Ping"
+			}
+			return ""
+		},
+		Run: func(peer *Peer, rw MsgReadWriter) error {
+			for i := 0; i < 2; i++ {
+				if err := ExpectMsg(rw, 1, []uint{1}); err != nil {
+					t.Error(err)
+				}
+			}
+			if err := SendItems(rw, 3, uint(2)); err != nil {
+				t.Error(err)
+			}
+			close(done)
+			<-peer.closed
+			return nil
+		},
+	}
+	closer, rw, peer, _ := testPeer([]Protocol{proto})
+	defer closer()
+
+	Send(rw, baseProtocolLength+1, []uint{1})
+	Send(rw, baseProtocolLength+1, []uint{1})
+	if err := ExpectMsg(rw, baseProtocolLength+3, []uint{2}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("protocol did not finish")
+	}
+	stats := peer.Stats()
+	traffic := stats.Traffic["a"]
+	if traffic == nil {
+		t.Fatalf("no traffic reported for protocol: %v", stats.Traffic)
+	}
+	if traffic.Ingress != 4 || traffic.Egress != 2 {
+		t.Errorf("protocol traffic mismatch: have %d/%d, want %d/%d", traffic.Ingress, traffic.Egress, 4, 2)
+	}
+	if stats.Ingress != traffic.Ingress || stats.Egress != traffic.Egress {
+		t.Errorf("peer traffic mismatch: have %d/%d, want %d/%d", stats.Ingress, stats.Egress, traffic.Ingress, traffic.Egress)
+	}
+	if len(traffic.Messages) != 2 {
+		t.Fatalf("message type count mismatch: have %d, want %d", len(traffic.Messages), 2)
+	}
+	if msg := traffic.Messages["Ping"]; msg == nil || msg.IngressMessages != 2 || msg.Ingress != 4 || msg.EgressMessages != 0 {
+		t.Errorf("named message traffic mismatch: %+v", msg)
+	}
+	if msg := traffic.Messages["0x03"]; msg == nil || msg.EgressMessages != 1 || msg.Egress != 2 || msg.IngressMessages != 0 {
+		t.Errorf("unnamed message traffic mismatch: %+v", msg)
+	}
+}
+
 func TestPeerProtoEncodeMsg(t *testing.T) {
 	proto := Protocol{
 		Name:   "a",
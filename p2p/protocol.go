@@ -52,6 +52,10 @@ type Protocol struct {
 	// about a certain peer in the network. If an info retrieval function is set,
 	// but returns nil, it is assumed that the protocol handshake is still running.
 	PeerInfo func(id discover.NodeID) interface{}
+
+	// MessageName is an optional helper method to name the message codes of the
+	// protocol in the traffic metrics. Unnamed codes are reported in hex.
+	MessageName func(code uint64) string
 }
 
 func (p Protocol) cap() Cap {
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -106,6 +107,10 @@ type Config struct {
 	// IP networks contained in the list are considered.
 	NetRestrict *netutil.Netlist `toml:",omitempty"`
 
+	// ClientPolicy, if set, rejects peers running outdated client versions or
+	// missing required protocol capabilities at handshake.
+	ClientPolicy *ClientPolicy `toml:",omitempty"`
+
 	// NodeDatabase is the path to the database containing the previously seen
 	// live nodes in the network.
 	NodeDatabase string `toml:",omitempty"`
@@ -135,6 +140,10 @@ type Config struct {
 	// If NoDial is true, the server will not dial any peers.
 	NoDial bool `toml:",omitempty"`
 
+	// MaxEgressBytes caps the outbound sub-protocol traffic of all peers combined,
+	// in bytes per second. Zero means unlimited.
+	MaxEgressBytes uint64 `toml:",omitempty"`
+
 	// If EnableMsgEvents is set then the server will emit PeerEvents
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
@@ -174,6 +183,7 @@ type Server struct {
 	delpeer       chan peerDrop
 	loopWG        sync.WaitGroup // loop, listenLoop
 	peerFeed      event.Feed
+	egress        *egressLimiter
 	log           log.Logger
 }
 
@@ -398,6 +408,11 @@ func (srv *Server) Start() (err error) {
 	if srv.PrivateKey == nil {
 		return fmt.Errorf("Server.PrivateKey must be set to a non-nil key")
 	}
+	if srv.ClientPolicy != nil {
+		if err := srv.ClientPolicy.validate(); err != nil {
+			return err
+		}
+	}
 	if srv.newTransport == nil {
 		srv.newTransport = newRLPX
 	}
@@ -412,6 +427,9 @@ func (srv *Server) Start() (err error) {
 	srv.removestatic = make(chan *discover.Node)
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
+	if srv.MaxEgressBytes > 0 {
+		srv.egress = newEgressLimiter(srv.MaxEgressBytes)
+	}
 
 	var (
 		conn      *net.UDPConn
@@ -641,6 +659,7 @@ running:
 				if srv.EnableMsgEvents {
 					p.events = &srv.peerFeed
 				}
+				p.limiter = srv.egress
 				name := truncateName(c.name)
 				srv.log.Debug("Adding p2p peer", "name", name, "addr", c.fd.RemoteAddr(), "peers", len(peers)+1)
 				go srv.runPeer(p)
@@ -696,6 +715,13 @@ func (srv *Server) protoHandshakeChecks(peers map[discover.NodeID]*Peer, inbound
 	if len(srv.Protocols) > 0 && countMatchingProtocols(srv.Protocols, c.caps) == 0 {
 		return DiscUselessPeer
 	}
+	// Drop outdated clients, unless explicitly trusted.
+	if srv.ClientPolicy != nil && !c.is(trustedConn) {
+		if err := srv.ClientPolicy.Check(c.name, c.caps); err != nil {
+			srv.log.Debug("Rejected peer by client policy", "id", c.id, "name", truncateName(c.name), "err", err)
+			return DiscOutdatedClient
+		}
+	}
 	// Repeat the encryption handshake checks because the
 	// peer set might have changed between the handshakes.
 	return srv.encHandshakeChecks(peers, inboundCount, c)
@@ -967,3 +993,18 @@ func (srv *Server) PeersInfo() []*PeerInfo {
 	}
 	return infos
 }
+
+// PeerStats returns the traffic accounting of the connected peers, ordered by
+// the total number of bytes exchanged, heaviest first.
+func (srv *Server) PeerStats() []*PeerStats {
+	stats := make([]*PeerStats, 0, srv.PeerCount())
+	for _, peer := range srv.Peers() {
+		if peer != nil {
+			stats = append(stats, peer.Stats())
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Ingress+stats[i].Egress > stats[j].Ingress+stats[j].Egress
+	})
+	return stats
+}
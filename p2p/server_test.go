@@ -375,6 +375,7 @@ func TestServerSetupConn(t *testing.T) {
 		tt        *setupTransport
 		flags     connFlag
 		dialDest  *discover.Node
+		policy    *ClientPolicy
 
 		wantCloseErr error
 		wantCalls    string
@@ -424,15 +425,23 @@ func TestServerSetupConn(t *testing.T) {
 			wantCalls:    "doEncHandshake,doProtoHandshake,close,",
 			wantCloseErr: DiscUselessPeer,
 		},
+		{
+			tt:           &setupTransport{id: id, phs: &protoHandshake{ID: id, Name: "used/v0.3.0-stable/linux-amd64/go1.10", Caps: []Cap{discard.cap()}}},
+			flags:        inboundConn,
+			policy:       &ClientPolicy{MinVersions: []string{"used/0.4.0"}},
+			wantCalls:    "doEncHandshake,doProtoHandshake,close,",
+			wantCloseErr: DiscOutdatedClient,
+		},
 	}
 
 	for i, test := range tests {
 		srv := &Server{
 			Config: Config{
-				PrivateKey: srvkey,
-				MaxPeers:   10,
-				NoDial:     true,
-				Protocols:  []Protocol{discard},
+				PrivateKey:   srvkey,
+				MaxPeers:     10,
+				NoDial:       true,
+				Protocols:    []Protocol{discard},
+				ClientPolicy: test.policy,
 			},
 			newTransport: func(fd net.Conn) transport { return test.tt },
 			log:          log.New(),
@@ -0,0 +1,140 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package adapters
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/usechain/go-usechain/p2p/discover"
+)
+
+var errLinkDown = errors.New("simulated link down")
+
+// LinkConditions describes the simulated quality of the link between two
+// in-memory nodes. The zero value is a perfect link.
+type LinkConditions struct {
+	Latency   time.Duration // Delay applied to every write on the link
+	Bandwidth int           // Maximum throughput in bytes per second (0 = unlimited)
+	Down      bool          // Whether the link is severed (dials fail, live connections drop)
+}
+
+// delay returns the time needed to push a write of the given size over the link.
+func (c LinkConditions) delay(size int) time.Duration {
+	delay := c.Latency
+	if c.Bandwidth > 0 {
+		delay += time.Duration(size) * time.Second / time.Duration(c.Bandwidth)
+	}
+	return delay
+}
+
+// linkKey identifies the undirected link between two nodes.
+type linkKey struct {
+	a, b discover.NodeID
+}
+
+func newLinkKey(one, other discover.NodeID) linkKey {
+	if bytes.Compare(one[:], other[:]) > 0 {
+		one, other = other, one
+	}
+	return linkKey{one, other}
+}
+
+// SetDefaultConditions sets the conditions applied to every link which has no
+// specific conditions set.
+func (s *SimAdapter) SetDefaultConditions(cond LinkConditions) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.defaultLink = cond
+}
+
+// SetConditions sets the conditions of the link between two nodes, taking
+// effect on both new and live connections.
+func (s *SimAdapter) SetConditions(one, other discover.NodeID, cond LinkConditions) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.links[newLinkKey(one, other)] = cond
+}
+
+// ResetConditions removes all link specific conditions, restoring every link
+// to the default ones.
+func (s *SimAdapter) ResetConditions() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.links = make(map[linkKey]LinkConditions)
+}
+
+// Conditions returns the conditions currently applied to the link between two
+// nodes.
+func (s *SimAdapter) Conditions(one, other discover.NodeID) LinkConditions {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	if cond, ok := s.links[newLinkKey(one, other)]; ok {
+		return cond
+	}
+	return s.defaultLink
+}
+
+// simDialer dials other simulation nodes on behalf of a single node, so that
+// the conditions of the link in between can be applied.
+type simDialer struct {
+	adapter *SimAdapter
+	id      discover.NodeID
+}
+
+// Dial implements the p2p.NodeDialer interface.
+func (d *simDialer) Dial(dest *discover.Node) (net.Conn, error) {
+	return d.adapter.dial(d.id, dest)
+}
+
+// conditionedConn is one end of an in-memory connection, subject to the link
+// conditions configured between the two nodes.
+type conditionedConn struct {
+	net.Conn
+	adapter       *SimAdapter
+	local, remote discover.NodeID
+}
+
+// Read implements net.Conn, dropping the connection if the link went down.
+func (c *conditionedConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if err == nil && c.adapter.Conditions(c.local, c.remote).Down {
+		c.Conn.Close()
+		return 0, errLinkDown
+	}
+	return n, err
+}
+
+// Write implements net.Conn, delaying the write according to the link latency
+// and bandwidth, or dropping the connection if the link went down.
+func (c *conditionedConn) Write(b []byte) (int, error) {
+	cond := c.adapter.Conditions(c.local, c.remote)
+	if cond.Down {
+		c.Conn.Close()
+		return 0, errLinkDown
+	}
+	if delay := cond.delay(len(b)); delay > 0 {
+		time.Sleep(delay)
+	}
+	return c.Conn.Write(b)
+}
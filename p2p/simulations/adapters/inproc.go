@@ -37,6 +37,9 @@ type SimAdapter struct {
 	mtx      sync.RWMutex
 	nodes    map[discover.NodeID]*SimNode
 	services map[string]ServiceFunc
+
+	links       map[linkKey]LinkConditions // Conditions of specific links
+	defaultLink LinkConditions             // Conditions of all other links
 }
 
 // NewSimAdapter creates a SimAdapter which is capable of running in-memory
@@ -46,6 +49,7 @@ func NewSimAdapter(services map[string]ServiceFunc) *SimAdapter {
 	return &SimAdapter{
 		nodes:    make(map[discover.NodeID]*SimNode),
 		services: services,
+		links:    make(map[linkKey]LinkConditions),
 	}
 }
 
@@ -80,11 +84,12 @@ func (s *SimAdapter) NewNode(config *NodeConfig) (Node, error) {
 			PrivateKey:      config.PrivateKey,
 			MaxPeers:        math.MaxInt32,
 			NoDiscovery:     true,
-			Dialer:          s,
+			Dialer:          &simDialer{adapter: s, id: id},
 			EnableMsgEvents: true,
 		},
-		NoUSB:  true,
-		Logger: log.New("node.id", id.String()),
+		NoUSB:             true,
+		UseLightweightKDF: true,
+		Logger:            log.New("node.id", id.String()),
 	})
 	if err != nil {
 		return nil, err
@@ -117,6 +122,25 @@ func (s *SimAdapter) Dial(dest *discover.Node) (conn net.Conn, err error) {
 	return pipe2, nil
 }
 
+// dial connects the source node to the destination one using an in-memory
+// net.Pipe connection, subject to the conditions of the link in between
+func (s *SimAdapter) dial(src discover.NodeID, dest *discover.Node) (net.Conn, error) {
+	if s.Conditions(src, dest.ID).Down {
+		return nil, errLinkDown
+	}
+	node, ok := s.GetNode(dest.ID)
+	if !ok {
+		return nil, fmt.Errorf("unknown node: %s", dest.ID)
+	}
+	srv := node.Server()
+	if srv == nil {
+		return nil, fmt.Errorf("node not running: %s", dest.ID)
+	}
+	pipe1, pipe2 := net.Pipe()
+	go srv.SetupConn(&conditionedConn{pipe1, s, dest.ID, src}, 0, nil)
+	return &conditionedConn{pipe2, s, src, dest.ID}, nil
+}
+
 // DialRPC implements the RPCDialer interface by creating an in-memory RPC
 // client of the given node
 func (s *SimAdapter) DialRPC(id discover.NodeID) (*rpc.Client, error) {
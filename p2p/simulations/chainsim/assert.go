@@ -0,0 +1,159 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainsim
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/core/types"
+	"github.com/usechain/go-usechain/eth"
+	"github.com/usechain/go-usechain/p2p/discover"
+)
+
+// pollInterval is the period at which the nodes are checked while waiting for
+// an expectation to be met.
+const pollInterval = 10 * time.Millisecond
+
+// Arrivals maps every node meeting an expectation to the time it took since
+// the wait started.
+type Arrivals map[discover.NodeID]time.Duration
+
+// Max returns the time it took for the last node to meet the expectation, i.e.
+// the propagation time across the whole set of nodes.
+func (a Arrivals) Max() time.Duration {
+	var max time.Duration
+	for _, elapsed := range a {
+		if elapsed > max {
+			max = elapsed
+		}
+	}
+	return max
+}
+
+// Mine seals a number of blocks on top of the current head of a node, imports
+// them and announces them to the network as if mined locally. The optional gen
+// callback may add transactions and uncles to the blocks.
+func (h *Harness) Mine(id discover.NodeID, count int, gen func(int, *core.BlockGen)) ([]*types.Block, error) {
+	ethereum, err := h.Ethereum(id)
+	if err != nil {
+		return nil, err
+	}
+	chain := ethereum.BlockChain()
+
+	blocks, _ := core.GenerateChain(chain.Config(), chain.CurrentBlock(), ethereum.Engine(), ethereum.ChainDb(), count, gen)
+	if _, err := chain.InsertChain(blocks); err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		ethereum.EventMux().Post(core.NewMinedBlockEvent{Block: block})
+	}
+	return blocks, nil
+}
+
+// SendTransaction submits a transaction to the pool of a node, from where it is
+// propagated to the network.
+func (h *Harness) SendTransaction(id discover.NodeID, tx *types.Transaction) error {
+	ethereum, err := h.Ethereum(id)
+	if err != nil {
+		return err
+	}
+	return ethereum.TxPool().AddLocal(tx)
+}
+
+// WaitBlock waits until all the given nodes imported the block.
+func (h *Harness) WaitBlock(ctx context.Context, hash common.Hash, ids []discover.NodeID) (Arrivals, error) {
+	return h.await(ctx, ids, func(ethereum *eth.Ethereum) bool {
+		return ethereum.BlockChain().GetBlockByHash(hash) != nil
+	})
+}
+
+// WaitFinalized waits until the block is canonical and finalized on all the
+// given nodes.
+func (h *Harness) WaitFinalized(ctx context.Context, hash common.Hash, ids []discover.NodeID) (Arrivals, error) {
+	return h.await(ctx, ids, func(ethereum *eth.Ethereum) bool {
+		chain := ethereum.BlockChain()
+
+		block := chain.GetBlockByHash(hash)
+		if block == nil || core.GetCanonicalHash(ethereum.ChainDb(), block.NumberU64()) != hash {
+			return false
+		}
+		final := chain.CurrentFinalizedBlock()
+		return final != nil && final.NumberU64() >= block.NumberU64()
+	})
+}
+
+// WaitTransaction waits until all the given nodes know about the transaction,
+// either pending in their pool or already included in their chain.
+func (h *Harness) WaitTransaction(ctx context.Context, hash common.Hash, ids []discover.NodeID) (Arrivals, error) {
+	return h.await(ctx, ids, func(ethereum *eth.Ethereum) bool {
+		if ethereum.TxPool().Get(hash) != nil {
+			return true
+		}
+		blockHash, _, _ := core.GetTxLookupEntry(ethereum.ChainDb(), hash)
+		return blockHash != (common.Hash{})
+	})
+}
+
+// WaitLiveness waits until all the given nodes see the committee member online.
+func (h *Harness) WaitLiveness(ctx context.Context, member common.Address, ids []discover.NodeID) (Arrivals, error) {
+	return h.await(ctx, ids, func(ethereum *eth.Ethereum) bool {
+		for _, status := range ethereum.Heartbeats().Liveness(time.Now()) {
+			if status.Member == member {
+				return status.Online
+			}
+		}
+		return false
+	})
+}
+
+// await polls the given nodes until all of them meet the expectation, or the
+// context is cancelled.
+func (h *Harness) await(ctx context.Context, ids []discover.NodeID, check func(*eth.Ethereum) bool) (Arrivals, error) {
+	var (
+		start    = time.Now()
+		arrivals = make(Arrivals)
+	)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, id := range ids {
+			if _, ok := arrivals[id]; ok {
+				continue
+			}
+			ethereum, err := h.Ethereum(id)
+			if err != nil {
+				return arrivals, err
+			}
+			if check(ethereum) {
+				arrivals[id] = time.Since(start)
+			}
+		}
+		if len(arrivals) == len(ids) {
+			return arrivals, nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return arrivals, fmt.Errorf("%d/%d nodes pending: %v", len(ids)-len(arrivals), len(ids), ctx.Err())
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package chainsim is an in-memory simulation harness of full Usechain nodes.
+//
+// It spins up any number of in-process nodes running the Usechain protocol and
+// the committee heartbeat service, connects them over simulated links whose
+// latency, bandwidth and availability can be programmed, and offers assertions
+// on the propagation and finality of blocks and transactions, so that protocol
+// changes can be exercised in integration tests.
+package chainsim
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/usechain/go-usechain/accounts"
+	"github.com/usechain/go-usechain/accounts/keystore"
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/consensus/ethash"
+	"github.com/usechain/go-usechain/core"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/eth"
+	"github.com/usechain/go-usechain/eth/downloader"
+	"github.com/usechain/go-usechain/eth/heartbeat"
+	"github.com/usechain/go-usechain/node"
+	"github.com/usechain/go-usechain/p2p/discover"
+	"github.com/usechain/go-usechain/p2p/simulations"
+	"github.com/usechain/go-usechain/p2p/simulations/adapters"
+	"github.com/usechain/go-usechain/params"
+)
+
+// serviceName is the name of the Usechain service run by every simulated node.
+const serviceName = "usechain"
+
+// Config is the configuration of a simulated Usechain network.
+type Config struct {
+	Genesis       *core.Genesis       // Genesis of the network (nil = DefaultGenesis)
+	SafeDepth     uint64              // Confirmations after which a block is "safe"
+	FinalityDepth uint64              // Confirmations after which a block is "finalized"
+	Committee     []*ecdsa.PrivateKey // Committee accounts, run by the first len(Committee) nodes
+	Heartbeat     time.Duration       // Heartbeat interval of the committee members (0 = default)
+}
+
+// DefaultGenesis returns the genesis used by simulations not specifying one:
+// an ethash network with all protocol changes enabled from the start.
+func DefaultGenesis() *core.Genesis {
+	return &core.Genesis{
+		Config:     params.TestChainConfig,
+		GasLimit:   params.GenesisGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      core.GenesisAlloc{},
+	}
+}
+
+// Harness is a simulated network of full Usechain nodes.
+type Harness struct {
+	*simulations.Network
+
+	adapter *adapters.SimAdapter
+	config  Config
+	members map[discover.NodeID]*ecdsa.PrivateKey // Committee account of member nodes
+}
+
+// New creates an empty simulated Usechain network.
+func New(config *Config) *Harness {
+	h := &Harness{
+		config:  *config,
+		members: make(map[discover.NodeID]*ecdsa.PrivateKey),
+	}
+	if h.config.Genesis == nil {
+		h.config.Genesis = DefaultGenesis()
+	}
+	if h.config.Heartbeat == 0 {
+		h.config.Heartbeat = heartbeat.DefaultInterval
+	}
+	h.adapter = adapters.NewSimAdapter(adapters.Services{serviceName: h.newService})
+	h.Network = simulations.NewNetwork(h.adapter, &simulations.NetworkConfig{
+		ID:             "usechain",
+		DefaultService: serviceName,
+	})
+	return h
+}
+
+// newService creates the Usechain service of a simulated node.
+func (h *Harness) newService(ctx *adapters.ServiceContext) (node.Service, error) {
+	config := eth.DefaultConfig
+	config.Genesis = h.config.Genesis
+	config.NetworkId = h.config.Genesis.Config.ChainId.Uint64()
+	config.SyncMode = downloader.FullSync
+	config.Ethash.PowMode = ethash.ModeFake
+	config.SafeDepth = h.config.SafeDepth
+	config.FinalityDepth = h.config.FinalityDepth
+
+	for _, key := range h.config.Committee {
+		config.CommitteeMembers = append(config.CommitteeMembers, crypto.PubkeyToAddress(key.PublicKey))
+	}
+	// Committee nodes attest their liveness with an unlocked member account
+	if key, ok := h.members[ctx.Config.ID]; ok {
+		ks := ctx.NodeContext.AccountManager.Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+
+		account := accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey)}
+		if !ks.HasAddress(account.Address) {
+			if _, err := ks.ImportECDSA(key, ""); err != nil {
+				return nil, err
+			}
+		}
+		if err := ks.Unlock(account, ""); err != nil {
+			return nil, err
+		}
+		config.Usebase = account.Address
+		config.CommitteeHeartbeat = h.config.Heartbeat
+	}
+	return eth.New(ctx.NodeContext, &config)
+}
+
+// AddNodes creates and starts the given number of nodes, assigning committee
+// accounts to the first nodes of the network. The new nodes are not connected.
+func (h *Harness) AddNodes(count int) ([]discover.NodeID, error) {
+	ids := make([]discover.NodeID, 0, count)
+	for i := 0; i < count; i++ {
+		conf := adapters.RandomNodeConfig()
+		conf.Services = []string{serviceName}
+		conf.Name = fmt.Sprintf("node%02d", len(h.Nodes))
+
+		if member := len(h.Nodes); member < len(h.config.Committee) {
+			h.members[conf.ID] = h.config.Committee[member]
+		}
+		if _, err := h.NewNodeWithConfig(conf); err != nil {
+			return nil, err
+		}
+		if err := h.Start(conf.ID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, conf.ID)
+	}
+	return ids, nil
+}
+
+// Ethereum retrieves the Usechain service of a running node.
+func (h *Harness) Ethereum(id discover.NodeID) (*eth.Ethereum, error) {
+	node, ok := h.adapter.GetNode(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown node: %s", id)
+	}
+	for _, service := range node.Services() {
+		if ethereum, ok := service.(*eth.Ethereum); ok {
+			return ethereum, nil
+		}
+	}
+	return nil, fmt.Errorf("node not running: %s", id)
+}
+
+// Member returns the committee account run by a node, if any.
+func (h *Harness) Member(id discover.NodeID) (common.Address, bool) {
+	key, ok := h.members[id]
+	if !ok {
+		return common.Address{}, false
+	}
+	return crypto.PubkeyToAddress(key.PublicKey), true
+}
@@ -0,0 +1,140 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainsim
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+	"time"
+
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/p2p/discover"
+	"github.com/usechain/go-usechain/p2p/simulations/adapters"
+)
+
+// newTestHarness creates a simulated network of the given number of nodes,
+// connected in a chain.
+func newTestHarness(t *testing.T, config *Config, nodes int) (*Harness, []discover.NodeID) {
+	h := New(config)
+
+	ids, err := h.AddNodes(nodes)
+	if err != nil {
+		h.Shutdown()
+		t.Fatalf("failed to add nodes: %v", err)
+	}
+	if err := h.ConnectChain(ids); err != nil {
+		h.Shutdown()
+		t.Fatalf("failed to connect nodes: %v", err)
+	}
+	return h, ids
+}
+
+// Tests that mined blocks propagate through the network and finalize on every
+// node once buried deep enough.
+func TestBlockPropagation(t *testing.T) {
+	h, ids := newTestHarness(t, &Config{FinalityDepth: 2}, 4)
+	defer h.Shutdown()
+
+	// Add some latency to make the propagation measurable
+	h.SetDefaultLink(adapters.LinkConditions{Latency: time.Millisecond})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	blocks, err := h.Mine(ids[0], 3, nil)
+	if err != nil {
+		t.Fatalf("failed to mine blocks: %v", err)
+	}
+	arrivals, err := h.WaitBlock(ctx, blocks[2].Hash(), ids)
+	if err != nil {
+		t.Fatalf("block not propagated: %v", err)
+	}
+	if arrivals.Max() == 0 {
+		t.Errorf("no propagation delay measured")
+	}
+	if _, err := h.WaitFinalized(ctx, blocks[0].Hash(), ids); err != nil {
+		t.Fatalf("block not finalized: %v", err)
+	}
+}
+
+// Tests that partitioned nodes don't see each other's blocks until the network
+// is healed and reconnected.
+func TestPartition(t *testing.T) {
+	h, ids := newTestHarness(t, &Config{}, 4)
+	defer h.Shutdown()
+
+	if err := h.Partition(ids[:2], ids[2:]); err != nil {
+		t.Fatalf("failed to partition network: %v", err)
+	}
+	blocks, err := h.Mine(ids[0], 1, nil)
+	if err != nil {
+		t.Fatalf("failed to mine block: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if _, err := h.WaitBlock(ctx, blocks[0].Hash(), ids[:2]); err != nil {
+		t.Fatalf("block not propagated within partition: %v", err)
+	}
+	short, cancelShort := context.WithTimeout(ctx, 500*time.Millisecond)
+	defer cancelShort()
+
+	if _, err := h.WaitBlock(short, blocks[0].Hash(), ids[2:]); err == nil {
+		t.Fatalf("block propagated across partition")
+	}
+	h.Heal()
+	if err := h.Connect(ids[1], ids[2]); err != nil {
+		t.Fatalf("failed to reconnect: %v", err)
+	}
+	// Propagated blocks only trigger a sync when revealing a higher total
+	// difficulty than known for the peer, so a few are needed to cross over
+	for i := 0; i < 3; i++ {
+		if blocks, err = h.Mine(ids[0], 1, nil); err != nil {
+			t.Fatalf("failed to mine block: %v", err)
+		}
+		if _, err := h.WaitBlock(ctx, blocks[0].Hash(), ids[:2]); err != nil {
+			t.Fatalf("block %d not propagated: %v", i, err)
+		}
+	}
+	if _, err := h.WaitBlock(ctx, blocks[0].Hash(), ids); err != nil {
+		t.Fatalf("block not propagated after healing: %v", err)
+	}
+}
+
+// Tests that committee members attest their liveness to the whole network.
+func TestCommitteeLiveness(t *testing.T) {
+	keys := make([]*ecdsa.PrivateKey, 2)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+	}
+	h, ids := newTestHarness(t, &Config{Committee: keys, Heartbeat: 100 * time.Millisecond}, 3)
+	defer h.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, key := range keys {
+		member := crypto.PubkeyToAddress(key.PublicKey)
+		if _, err := h.WaitLiveness(ctx, member, ids); err != nil {
+			t.Fatalf("member %x not seen online: %v", member, err)
+		}
+	}
+	if _, ok := h.Member(ids[2]); ok {
+		t.Errorf("non-committee node reported as member")
+	}
+}
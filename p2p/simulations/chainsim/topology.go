@@ -0,0 +1,172 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package chainsim
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/usechain/go-usechain/eth"
+	"github.com/usechain/go-usechain/p2p/discover"
+	"github.com/usechain/go-usechain/p2p/simulations"
+	"github.com/usechain/go-usechain/p2p/simulations/adapters"
+)
+
+// connTimeout is the maximum time to wait for a connection between two nodes
+// to come up or go down.
+const connTimeout = 10 * time.Second
+
+// Connect connects two nodes and waits until the connection is established and
+// both sides completed the Usechain protocol handshake.
+func (h *Harness) Connect(one, other discover.NodeID) error {
+	if err := h.awaitConn(one, other, true, func() error { return h.Network.Connect(one, other) }); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(connTimeout)
+	for !h.handshaked(one, other) || !h.handshaked(other, one) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("handshake between %s and %s not completed within %v", one.TerminalString(), other.TerminalString(), connTimeout)
+		}
+		time.Sleep(pollInterval)
+	}
+	return nil
+}
+
+// Disconnect disconnects two nodes and waits until the connection is dropped.
+func (h *Harness) Disconnect(one, other discover.NodeID) error {
+	return h.awaitConn(one, other, false, func() error { return h.Network.Disconnect(one, other) })
+}
+
+// awaitConn performs an action on the connection between two nodes, waiting
+// until the network reports it in the requested state.
+func (h *Harness) awaitConn(one, other discover.NodeID, up bool, action func() error) error {
+	events := make(chan *simulations.Event, 16)
+	sub := h.Events().Subscribe(events)
+	defer sub.Unsubscribe()
+
+	// The network feed blocks on delivery, keep consuming while acting
+	errc := make(chan error, 1)
+	go func() { errc <- action() }()
+
+	timeout := time.NewTimer(connTimeout)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case ev := <-events:
+			if ev.Type != simulations.EventTypeConn || ev.Control || ev.Conn.Up != up {
+				continue
+			}
+			if (ev.Conn.One == one && ev.Conn.Other == other) || (ev.Conn.One == other && ev.Conn.Other == one) {
+				return nil
+			}
+		case err := <-sub.Err():
+			return err
+		case <-timeout.C:
+			return fmt.Errorf("connection between %s and %s not changed (up=%v) within %v", one.TerminalString(), other.TerminalString(), up, connTimeout)
+		}
+	}
+}
+
+// handshaked checks whether a node completed the Usechain protocol handshake
+// with a remote peer.
+func (h *Harness) handshaked(id, remote discover.NodeID) bool {
+	node, ok := h.adapter.GetNode(id)
+	if !ok || node.Server() == nil {
+		return false
+	}
+	for _, peer := range node.Server().PeersInfo() {
+		if peer.ID == remote.String() {
+			_, ok := peer.Protocols["eth"].(*eth.PeerInfo)
+			return ok
+		}
+	}
+	return false
+}
+
+// ConnectChain connects the nodes in a line, each to the next one.
+func (h *Harness) ConnectChain(ids []discover.NodeID) error {
+	for i := 1; i < len(ids); i++ {
+		if err := h.Connect(ids[i-1], ids[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectRing connects the nodes in a line, closing it into a ring.
+func (h *Harness) ConnectRing(ids []discover.NodeID) error {
+	if err := h.ConnectChain(ids); err != nil {
+		return err
+	}
+	if len(ids) < 3 {
+		return nil
+	}
+	return h.Connect(ids[len(ids)-1], ids[0])
+}
+
+// ConnectFull connects every node to all the others.
+func (h *Harness) ConnectFull(ids []discover.NodeID) error {
+	for i := 0; i < len(ids); i++ {
+		for j := i + 1; j < len(ids); j++ {
+			if err := h.Connect(ids[i], ids[j]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SetLink sets the conditions of the link between two nodes.
+func (h *Harness) SetLink(one, other discover.NodeID, cond adapters.LinkConditions) {
+	h.adapter.SetConditions(one, other, cond)
+}
+
+// SetDefaultLink sets the conditions of all links without specific ones.
+func (h *Harness) SetDefaultLink(cond adapters.LinkConditions) {
+	h.adapter.SetDefaultConditions(cond)
+}
+
+// Partition splits the network into the given groups of nodes, severing and
+// disconnecting every link crossing between two groups.
+func (h *Harness) Partition(groups ...[]discover.NodeID) error {
+	for i := 0; i < len(groups); i++ {
+		for j := i + 1; j < len(groups); j++ {
+			for _, one := range groups[i] {
+				for _, other := range groups[j] {
+					h.adapter.SetConditions(one, other, adapters.LinkConditions{Down: true})
+					if conn := h.GetConn(one, other); conn != nil && conn.Up {
+						if err := h.Disconnect(conn.One, conn.Other); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Heal restores every link to the default conditions. Severed connections are
+// not reestablished, that is up to the caller.
+func (h *Harness) Heal() {
+	h.adapter.ResetConditions()
+}
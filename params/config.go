@@ -72,6 +72,10 @@ var (
 		ByzantiumBlock:      big.NewInt(4),
 		ConstantinopleBlock: nil,
 		Ethash:              new(EthashConfig),
+		SystemContracts: &SystemContractsConfig{
+			IdentityCodeHash:  common.HexToHash("0x6ff2c36eaa6799937ecf4aec9e9eef339072a37d63f060f47994138ace0bb977"),
+			MinerListCodeHash: common.HexToHash("0x113ead9b9609b76ca52f006b9bc439a0e96d93e88108326c46c878747a9e8545"),
+		},
 	}
 
 	// AllEthashProtocolChanges contains every protocol change (EIPs) introduced
@@ -79,16 +83,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, 0, 0, nil, 0, new(EthashConfig), nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, 0, 0, nil, 0, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, nil, nil, nil, 0, 0, nil, 0, new(EthashConfig), nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -115,9 +119,27 @@ type ChainConfig struct {
 	ByzantiumBlock      *big.Int `json:"byzantiumBlock,omitempty"`      // Byzantium switch block (nil = no fork, 0 = already on byzantium)
 	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"` // Constantinople switch block (nil = no fork, 0 = already activated)
 
+	FixedPointBlock          *big.Int `json:"fixedPointBlock,omitempty"`          // Fixed-point math precompile switch block (nil = no fork, 0 = already activated)
+	IdentityAttestationBlock *big.Int `json:"identityAttestationBlock,omitempty"` // Identity attestation precompile switch block (nil = no fork, 0 = already activated)
+	TransientStorageBlock    *big.Int `json:"transientStorageBlock,omitempty"`    // Transient storage opcodes switch block (nil = no fork, 0 = already activated)
+
+	// Contract size limits replacing the inherited 24KB one, along with initcode
+	// size limiting and per-word initcode gas (https://eips.ethereum.org/EIPS/eip-3860)
+	CodeSizeBlock   *big.Int `json:"codeSizeBlock,omitempty"`   // Code size limits switch block (nil = no fork, 0 = already activated)
+	MaxCodeSize     uint64   `json:"maxCodeSize,omitempty"`     // Maximum deployed code size from the switch block (0 = params.MaxCodeSize)
+	MaxInitCodeSize uint64   `json:"maxInitCodeSize,omitempty"` // Maximum initcode size from the switch block (0 = twice the max code size)
+
+	// Stake delegation to candidate miners, with the delegation weights and miner
+	// commissions taking effect at epoch boundaries
+	DelegationBlock *big.Int `json:"delegationBlock,omitempty"` // Stake delegation switch block (nil = no fork, 0 = already activated)
+	DelegationEpoch uint64   `json:"delegationEpoch,omitempty"` // Number of blocks between delegation weight updates (0 = DefaultDelegationEpoch)
+
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
+
+	// Usechain system contracts the chain relies on (nil = not validated)
+	SystemContracts *SystemContractsConfig `json:"systemContracts,omitempty"`
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -139,6 +161,14 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// SystemContractsConfig pins the code of the Usechain system contracts (the
+// identity registry and the miner list) that a chain expects to find in its
+// genesis state. A zero hash accepts any non-empty code at that address.
+type SystemContractsConfig struct {
+	IdentityCodeHash  common.Hash `json:"identityCodeHash,omitempty"`
+	MinerListCodeHash common.Hash `json:"minerListCodeHash,omitempty"`
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -150,7 +180,7 @@ func (c *ChainConfig) String() string {
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v FixedPoint: %v IdentityAttestation: %v TransientStorage: %v CodeSize: %v Delegation: %v Engine: %v}",
 		c.ChainId,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -160,6 +190,11 @@ func (c *ChainConfig) String() string {
 		c.EIP158Block,
 		c.ByzantiumBlock,
 		c.ConstantinopleBlock,
+		c.FixedPointBlock,
+		c.IdentityAttestationBlock,
+		c.TransientStorageBlock,
+		c.CodeSizeBlock,
+		c.DelegationBlock,
 		engine,
 	)
 }
@@ -194,6 +229,65 @@ func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
 	return isForked(c.ConstantinopleBlock, num)
 }
 
+// IsFixedPoint returns whether num is either equal to the fixed-point math
+// precompile fork block or greater.
+func (c *ChainConfig) IsFixedPoint(num *big.Int) bool {
+	return isForked(c.FixedPointBlock, num)
+}
+
+// IsIdentityAttestation returns whether num is either equal to the identity
+// attestation precompile fork block or greater.
+func (c *ChainConfig) IsIdentityAttestation(num *big.Int) bool {
+	return isForked(c.IdentityAttestationBlock, num)
+}
+
+// IsTransientStorage returns whether num is either equal to the transient
+// storage fork block or greater.
+func (c *ChainConfig) IsTransientStorage(num *big.Int) bool {
+	return isForked(c.TransientStorageBlock, num)
+}
+
+// IsCodeSize returns whether num is either equal to the code size limits fork
+// block or greater.
+func (c *ChainConfig) IsCodeSize(num *big.Int) bool {
+	return isForked(c.CodeSizeBlock, num)
+}
+
+// IsDelegation returns whether num is either equal to the stake delegation fork
+// block or greater.
+func (c *ChainConfig) IsDelegation(num *big.Int) bool {
+	return isForked(c.DelegationBlock, num)
+}
+
+// DelegationEpochLength returns the number of blocks between two updates of the
+// delegation weights.
+func (c *ChainConfig) DelegationEpochLength() uint64 {
+	if c.DelegationEpoch != 0 {
+		return c.DelegationEpoch
+	}
+	return DefaultDelegationEpoch
+}
+
+// MaxCodeSizeAt returns the maximum size of deployed contract code at block num.
+func (c *ChainConfig) MaxCodeSizeAt(num *big.Int) int {
+	if c.IsCodeSize(num) && c.MaxCodeSize != 0 {
+		return int(c.MaxCodeSize)
+	}
+	return MaxCodeSize
+}
+
+// MaxInitCodeSizeAt returns the maximum size of contract initcode at block num,
+// or 0 if initcode is not limited yet.
+func (c *ChainConfig) MaxInitCodeSizeAt(num *big.Int) int {
+	if !c.IsCodeSize(num) {
+		return 0
+	}
+	if c.MaxInitCodeSize != 0 {
+		return int(c.MaxInitCodeSize)
+	}
+	return 2 * c.MaxCodeSizeAt(num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -257,6 +351,27 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
 		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
 	}
+	if isForkIncompatible(c.FixedPointBlock, newcfg.FixedPointBlock, head) {
+		return newCompatError("Fixed-point fork block", c.FixedPointBlock, newcfg.FixedPointBlock)
+	}
+	if isForkIncompatible(c.IdentityAttestationBlock, newcfg.IdentityAttestationBlock, head) {
+		return newCompatError("Identity attestation fork block", c.IdentityAttestationBlock, newcfg.IdentityAttestationBlock)
+	}
+	if isForkIncompatible(c.TransientStorageBlock, newcfg.TransientStorageBlock, head) {
+		return newCompatError("Transient storage fork block", c.TransientStorageBlock, newcfg.TransientStorageBlock)
+	}
+	if isForkIncompatible(c.CodeSizeBlock, newcfg.CodeSizeBlock, head) {
+		return newCompatError("Code size fork block", c.CodeSizeBlock, newcfg.CodeSizeBlock)
+	}
+	if c.IsCodeSize(head) && (c.MaxCodeSize != newcfg.MaxCodeSize || c.MaxInitCodeSize != newcfg.MaxInitCodeSize) {
+		return newCompatError("Code size limits", c.CodeSizeBlock, newcfg.CodeSizeBlock)
+	}
+	if isForkIncompatible(c.DelegationBlock, newcfg.DelegationBlock, head) {
+		return newCompatError("Delegation fork block", c.DelegationBlock, newcfg.DelegationBlock)
+	}
+	if c.IsDelegation(head) && c.DelegationEpochLength() != newcfg.DelegationEpochLength() {
+		return newCompatError("Delegation epoch length", c.DelegationBlock, newcfg.DelegationBlock)
+	}
 	return nil
 }
 
@@ -70,6 +70,23 @@ func TestCheckCompatible(t *testing.T) {
 				RewindTo:     9,
 			},
 		},
+		{
+			stored:  &ChainConfig{CodeSizeBlock: big.NewInt(10), MaxCodeSize: 49152},
+			new:     &ChainConfig{CodeSizeBlock: big.NewInt(10), MaxCodeSize: 65536},
+			head:    9,
+			wantErr: nil,
+		},
+		{
+			stored: &ChainConfig{CodeSizeBlock: big.NewInt(10), MaxCodeSize: 49152},
+			new:    &ChainConfig{CodeSizeBlock: big.NewInt(10), MaxCodeSize: 65536},
+			head:   15,
+			wantErr: &ConfigCompatError{
+				What:         "Code size limits",
+				StoredConfig: big.NewInt(10),
+				NewConfig:    big.NewInt(10),
+				RewindTo:     9,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -60,6 +60,9 @@ const (
 	SuicideRefundGas uint64 = 24000 // Refunded following a suicide operation.
 	MemoryGas        uint64 = 3     // Times the address of the (highest referenced byte in memory + 1). NOTE: referencing happens on read, write and in instructions such as RETURN and CALL.
 	TxDataNonZeroGas uint64 = 68    // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
+	TloadGas         uint64 = 100   // Once per TLOAD operation.
+	TstoreGas        uint64 = 100   // Once per TSTORE operation.
+	InitCodeWordGas  uint64 = 2     // Once per word of initcode when creating a contract, from the code size fork.
 
 	MaxCodeSize = 24576 // Maximum bytecode to permit for a contract
 
@@ -77,6 +80,17 @@ const (
 	Bn256ScalarMulGas       uint64 = 40000  // Gas needed for an elliptic curve scalar multiplication
 	Bn256PairingBaseGas     uint64 = 100000 // Base price for an elliptic curve pairing check
 	Bn256PairingPerPointGas uint64 = 80000  // Per-point price for an elliptic curve pairing check
+	FixedPointExpGas        uint64 = 500    // Gas needed for a fixed-point natural exponentiation
+	FixedPointLnGas         uint64 = 500    // Gas needed for a fixed-point natural logarithm
+	FixedPointPowGas        uint64 = 1000   // Gas needed for a fixed-point power operation
+	AttestationSecp256k1Gas uint64 = 3000   // Gas needed for a secp256k1 identity attestation verification
+	AttestationP256Gas      uint64 = 3450   // Gas needed for a P-256 identity attestation verification
+	AttestationRSA2048Gas   uint64 = 4000   // Gas needed for a 2048 bit RSA identity attestation verification
+	AttestationRSA3072Gas   uint64 = 9000   // Gas needed for a 3072 bit RSA identity attestation verification
+	AttestationRSA4096Gas   uint64 = 16000  // Gas needed for a 4096 bit RSA identity attestation verification
+	DelegationGas           uint64 = 9000   // Base gas of a stake delegation registry operation, storage accesses are charged on top
+
+	DefaultDelegationEpoch uint64 = 1000 // Number of blocks between delegation weight updates if not configured
 
 	GetPublicKeySetMaxSize  uint64 = 20   // Max number of public key set size
 
@@ -220,6 +220,16 @@ func (c *Client) SupportedModules() (map[string]string, error) {
 	return result, err
 }
 
+// SupportedMethods calls the rpc_methods method, retrieving the callable
+// methods of every API available on the server along with their arity.
+func (c *Client) SupportedMethods() (map[string]map[string]int, error) {
+	var result map[string]map[string]int
+	ctx, cancel := context.WithTimeout(context.Background(), subscribeTimeout)
+	defer cancel()
+	err := c.CallContext(ctx, &result, "rpc_methods")
+	return result, err
+}
+
 // Close closes the client, aborting any in-flight requests.
 func (c *Client) Close() {
 	if c.isHTTP {
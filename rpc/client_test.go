@@ -98,6 +98,37 @@ func TestClientBatchRequest(t *testing.T) {
 	}
 }
 
+// customError is an error carrying its own code and structured data.
+type customError struct{}
+
+func (customError) Error() string          { return "custom failure" }
+func (customError) ErrorCode() int         { return -32042 }
+func (customError) ErrorData() interface{} { return map[string]int{"answer": 42} }
+
+type FailingService struct{}
+
+func (s *FailingService) Fail() error { return customError{} }
+
+// Tests that the code and data of errors returned by callbacks reach the client.
+func TestClientErrorData(t *testing.T) {
+	server := newTestServer("service", new(FailingService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	err := client.Call(nil, "service_fail")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if code := err.(Error).ErrorCode(); code != -32042 {
+		t.Errorf("error code mismatch: have %d, want %d", code, -32042)
+	}
+	data, ok := err.(DataError).ErrorData().(map[string]interface{})
+	if !ok || data["answer"] != float64(42) {
+		t.Errorf("error data mismatch: have %v", err.(DataError).ErrorData())
+	}
+}
+
 // func TestClientCancelInproc(t *testing.T) { testClientCancel("inproc", t) }
 func TestClientCancelWebsocket(t *testing.T) { testClientCancel("ws", t) }
 func TestClientCancelHTTP(t *testing.T)      { testClientCancel("http", t) }
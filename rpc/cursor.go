@@ -0,0 +1,454 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// DefaultPageSize is the number of items per page if the client did not
+	// request a size.
+	DefaultPageSize = 100
+
+	// MaxPageSize is the maximum number of items returned in a single page.
+	MaxPageSize = 1000
+
+	maxCursors        = 128             // Maximum number of cursors open on a server
+	maxHostCursors    = 32              // Maximum number of cursors open by a single remote host
+	maxSessionCursors = 16              // Maximum number of cursors open on a single connection
+	cursorIdleTimeout = 5 * time.Minute // Time after which an unused cursor is closed
+)
+
+var (
+	// ErrCursorNotFound is returned when the cursor for the given id is not
+	// found, because it was consumed, expired or belongs to another connection.
+	ErrCursorNotFound = errors.New("cursor not found")
+	// ErrTooManyCursors is returned when opening a cursor would exceed the
+	// number of cursors allowed on the server, the remote host or the connection.
+	ErrTooManyCursors = errors.New("too many open cursors")
+)
+
+// Iterator produces the items of a paginated result set incrementally.
+type Iterator interface {
+	// Next returns at most limit items following the ones returned so far,
+	// and whether the result set is exhausted. Fewer items than requested may
+	// be returned even if more are available.
+	Next(ctx context.Context, limit int) (items []interface{}, done bool, err error)
+
+	// Close releases the resources held by the iterator.
+	Close()
+}
+
+// sliceIterator is an iterator over a precomputed result set.
+type sliceIterator struct {
+	items []interface{}
+}
+
+// NewSliceIterator creates an iterator paging through the given items.
+func NewSliceIterator(items []interface{}) Iterator {
+	return &sliceIterator{items: items}
+}
+
+func (it *sliceIterator) Next(ctx context.Context, limit int) ([]interface{}, bool, error) {
+	if limit > len(it.items) {
+		limit = len(it.items)
+	}
+	items := it.items[:limit]
+	it.items = it.items[limit:]
+	return items, len(it.items) == 0, nil
+}
+
+func (it *sliceIterator) Close() {}
+
+// Page is a chunk of a paginated result set. The cursor retrieves the next page
+// through rpc_nextPage and is omitted on the last one.
+type Page struct {
+	Items  []interface{} `json:"items"`
+	Cursor ID            `json:"cursor,omitempty"`
+}
+
+// CursorInfo is the resource accounting of an open cursor.
+type CursorInfo struct {
+	ID       ID        `json:"id"`
+	Kind     string    `json:"kind"`     // Method which opened the cursor
+	Sticky   bool      `json:"sticky"`   // Whether the cursor is bound to the connection opening it
+	Created  time.Time `json:"created"`  // Time the cursor was opened
+	LastUsed time.Time `json:"lastUsed"` // Time a page was last retrieved
+	Pages    uint64    `json:"pages"`    // Number of pages served
+	Items    uint64    `json:"items"`    // Number of items served
+}
+
+// cursor is a paginated result set kept open on the server.
+type cursor struct {
+	id      ID
+	kind    string    // Method which opened the cursor
+	session uint64    // Connection the cursor is sticky to, 0 for stateless transports
+	host    string    // Remote host which opened the cursor
+	created time.Time // Time the cursor was opened
+
+	used  int64  // Time a page was last retrieved, in unix nanoseconds (atomic access)
+	pages uint64 // Number of pages served (atomic access)
+	items uint64 // Number of items served (atomic access)
+
+	iter   Iterator   // Source of the remaining items
+	closed bool       // Whether the iterator was released
+	lock   sync.Mutex // Lock serialising the access to the iterator
+}
+
+// page retrieves the next page of the cursor, closing the iterator if the
+// result set is exhausted or failed.
+func (c *cursor) page(ctx context.Context, limit int) (*Page, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.closed {
+		return nil, ErrCursorNotFound
+	}
+	// Mark the cursor used up front, so it doesn't expire while serving
+	atomic.StoreInt64(&c.used, time.Now().UnixNano())
+
+	items, done, err := c.iter.Next(ctx, limit)
+	if err != nil {
+		c.close()
+		return nil, err
+	}
+	atomic.StoreInt64(&c.used, time.Now().UnixNano())
+	atomic.AddUint64(&c.pages, 1)
+	atomic.AddUint64(&c.items, uint64(len(items)))
+
+	page := &Page{Items: items, Cursor: c.id}
+	if page.Items == nil {
+		page.Items = []interface{}{}
+	}
+	if done {
+		c.close()
+		page.Cursor = ""
+	}
+	return page, nil
+}
+
+// close releases the iterator of the cursor. The cursor lock must be held.
+func (c *cursor) close() {
+	if !c.closed {
+		c.iter.Close()
+		c.closed = true
+	}
+}
+
+// info assembles the resource accounting of the cursor.
+func (c *cursor) info() *CursorInfo {
+	return &CursorInfo{
+		ID:       c.id,
+		Kind:     c.kind,
+		Sticky:   c.session != 0,
+		Created:  c.created,
+		LastUsed: time.Unix(0, atomic.LoadInt64(&c.used)),
+		Pages:    atomic.LoadUint64(&c.pages),
+		Items:    atomic.LoadUint64(&c.items),
+	}
+}
+
+// closeCursors releases the iterators of the given cursors, waiting for the
+// pages being served to complete.
+func closeCursors(cursors []*cursor) {
+	for _, c := range cursors {
+		c.lock.Lock()
+		c.close()
+		c.lock.Unlock()
+	}
+}
+
+// cursorSet tracks the cursors open on a server.
+type cursorSet struct {
+	cursors map[ID]*cursor
+	lock    sync.Mutex
+}
+
+func newCursorSet() *cursorSet {
+	return &cursorSet{cursors: make(map[ID]*cursor)}
+}
+
+// add registers a new cursor, unless the server, the remote host opening it or
+// the connection it is sticky to already reached their cursor allowance.
+func (cs *cursorSet) add(c *cursor) error {
+	cs.expire()
+
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	owned, hosted := 0, 0
+	for _, other := range cs.cursors {
+		if c.session != 0 && other.session == c.session {
+			owned++
+		}
+		if other.host == c.host {
+			hosted++
+		}
+	}
+	if len(cs.cursors) >= maxCursors || hosted >= maxHostCursors || owned >= maxSessionCursors {
+		return ErrTooManyCursors
+	}
+	cs.cursors[c.id] = c
+	return nil
+}
+
+// get retrieves a cursor accessible from the given connection.
+func (cs *cursorSet) get(session uint64, id ID) (*cursor, error) {
+	cs.expire()
+
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	c, ok := cs.cursors[id]
+	if !ok || (c.session != 0 && c.session != session) {
+		return nil, ErrCursorNotFound
+	}
+	return c, nil
+}
+
+// drop removes a cursor from the set, releasing its resources.
+func (cs *cursorSet) drop(id ID) {
+	cs.lock.Lock()
+	c, ok := cs.cursors[id]
+	delete(cs.cursors, id)
+	cs.lock.Unlock()
+
+	if ok {
+		closeCursors([]*cursor{c})
+	}
+}
+
+// dropSession closes all the cursors sticky to a terminated connection.
+func (cs *cursorSet) dropSession(session uint64) {
+	cs.lock.Lock()
+	var dropped []*cursor
+	for id, c := range cs.cursors {
+		if c.session == session {
+			dropped = append(dropped, c)
+			delete(cs.cursors, id)
+		}
+	}
+	cs.lock.Unlock()
+
+	closeCursors(dropped)
+}
+
+// dropAll closes all the cursors of a stopped server.
+func (cs *cursorSet) dropAll() {
+	cs.lock.Lock()
+	var dropped []*cursor
+	for _, c := range cs.cursors {
+		dropped = append(dropped, c)
+	}
+	cs.cursors = make(map[ID]*cursor)
+	cs.lock.Unlock()
+
+	closeCursors(dropped)
+}
+
+// expire closes the cursors left unused for longer than the idle timeout.
+func (cs *cursorSet) expire() {
+	deadline := time.Now().Add(-cursorIdleTimeout).UnixNano()
+
+	cs.lock.Lock()
+	var expired []*cursor
+	for id, c := range cs.cursors {
+		if atomic.LoadInt64(&c.used) < deadline {
+			expired = append(expired, c)
+			delete(cs.cursors, id)
+		}
+	}
+	cs.lock.Unlock()
+
+	closeCursors(expired)
+}
+
+// infos returns the accounting of the cursors sticky to the given connection,
+// or of all the open ones if session is 0, oldest first.
+func (cs *cursorSet) infos(session uint64) []*CursorInfo {
+	cs.expire()
+
+	cs.lock.Lock()
+	defer cs.lock.Unlock()
+
+	infos := make([]*CursorInfo, 0, len(cs.cursors))
+	for _, c := range cs.cursors {
+		if session == 0 || c.session == session {
+			infos = append(infos, c.info())
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Created.Before(infos[j].Created) })
+	return infos
+}
+
+// cursorKey is used to store the cursor session within the connection context.
+type cursorKey struct{}
+
+// cursorSession is the access of a connection to the cursors of the server.
+type cursorSession struct {
+	set  *cursorSet
+	id   uint64 // Connection identifier, 0 for stateless transports
+	host string // Remote host of the connection
+}
+
+// newCursorID generates a random cursor id. Cursors opened over stateless
+// transports can be accessed by any request presenting their id, so unlike
+// subscription ids these must not be guessable.
+func newCursorID() ID {
+	id := make([]byte, 16)
+	if _, err := crand.Read(id); err != nil {
+		panic("can't read from crypto/rand: " + err.Error())
+	}
+	return ID("0x" + hex.EncodeToString(id))
+}
+
+// remoteHost returns the host at the other end of the connection served by the
+// codec, or an empty string for local and unknown ones.
+func remoteHost(codec ServerCodec) string {
+	json, ok := codec.(*jsonCodec)
+	if !ok {
+		return ""
+	}
+	var addr string
+	switch conn := json.rw.(type) {
+	case *httpReadWriteNopCloser:
+		addr = conn.remote
+	case *websocket.Conn:
+		addr = conn.Request().RemoteAddr
+	case *net.TCPConn:
+		addr = conn.RemoteAddr().String()
+	}
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// pageSize clamps a page size requested by a client to the allowed bounds.
+func pageSize(limit int) int {
+	switch {
+	case limit <= 0:
+		return DefaultPageSize
+	case limit > MaxPageSize:
+		return MaxPageSize
+	default:
+		return limit
+	}
+}
+
+// Paginate returns the first page of the result set produced by the iterator,
+// keeping a cursor open on the server to retrieve the rest through rpc_nextPage.
+// Cursors opened over persistent connections are sticky to them and closed along
+// with the connection, the ones opened over HTTP can be accessed by any request
+// presenting the unguessable cursor id. Unused cursors expire after a while.
+//
+// Outside of an RPC server the entire result set is returned in a single page.
+func Paginate(ctx context.Context, kind string, it Iterator, limit int) (*Page, error) {
+	session, ok := ctx.Value(cursorKey{}).(*cursorSession)
+	if !ok {
+		defer it.Close()
+
+		page := &Page{Items: []interface{}{}}
+		for {
+			items, done, err := it.Next(ctx, MaxPageSize)
+			if err != nil {
+				return nil, err
+			}
+			page.Items = append(page.Items, items...)
+			if done {
+				return page, nil
+			}
+		}
+	}
+	// Serve the first page right away, result sets fitting into it need no cursor
+	now := time.Now()
+	c := &cursor{
+		id:      newCursorID(),
+		kind:    kind,
+		session: session.id,
+		host:    session.host,
+		created: now,
+		used:    now.UnixNano(),
+		iter:    it,
+	}
+	page, err := c.page(ctx, pageSize(limit))
+	if err != nil || page.Cursor == "" {
+		return page, err
+	}
+	if err := session.set.add(c); err != nil {
+		closeCursors([]*cursor{c})
+		return nil, err
+	}
+	return page, nil
+}
+
+// NextPage retrieves the next page of an open cursor, holding at most limit items
+// (0 = default page size). The cursor is closed once its last page was retrieved.
+func (s *RPCService) NextPage(ctx context.Context, id ID, limit int) (*Page, error) {
+	session, ok := ctx.Value(cursorKey{}).(*cursorSession)
+	if !ok {
+		return nil, ErrCursorNotFound
+	}
+	c, err := session.set.get(session.id, id)
+	if err != nil {
+		return nil, err
+	}
+	page, err := c.page(ctx, pageSize(limit))
+	if err != nil || page.Cursor == "" {
+		session.set.drop(id)
+	}
+	return page, err
+}
+
+// CloseCursor closes an open cursor before its result set is exhausted,
+// releasing the resources held by it.
+func (s *RPCService) CloseCursor(ctx context.Context, id ID) (bool, error) {
+	session, ok := ctx.Value(cursorKey{}).(*cursorSession)
+	if !ok {
+		return false, ErrCursorNotFound
+	}
+	if _, err := session.set.get(session.id, id); err != nil {
+		return false, err
+	}
+	session.set.drop(id)
+	return true, nil
+}
+
+// SessionCursors returns the resource accounting of the cursors sticky to the
+// connection serving the request. Cursors opened over stateless transports are
+// not attributable to a caller and never reported.
+func SessionCursors(ctx context.Context) ([]*CursorInfo, error) {
+	session, ok := ctx.Value(cursorKey{}).(*cursorSession)
+	if !ok {
+		return nil, errors.New("cursors not available outside of an RPC server")
+	}
+	if session.id == 0 {
+		return []*CursorInfo{}, nil
+	}
+	return session.set.infos(session.id), nil
+}
@@ -0,0 +1,216 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type PagingService struct{}
+
+// Numbers pages through the numbers from zero up to n.
+func (s *PagingService) Numbers(ctx context.Context, n int, limit int) (*Page, error) {
+	items := make([]interface{}, n)
+	for i := range items {
+		items[i] = i
+	}
+	return Paginate(ctx, "test_numbers", NewSliceIterator(items), limit)
+}
+
+// Cursors reports the cursors of the calling connection.
+func (s *PagingService) Cursors(ctx context.Context) ([]*CursorInfo, error) {
+	return SessionCursors(ctx)
+}
+
+// pageNumbers decodes the items of a page of numbers.
+func pageNumbers(page *Page) []int {
+	numbers := make([]int, len(page.Items))
+	for i, item := range page.Items {
+		numbers[i] = int(item.(float64))
+	}
+	return numbers
+}
+
+// consumeNumbers retrieves all the pages of a number query, checking that
+// they are sized and ordered correctly.
+func consumeNumbers(t *testing.T, client *Client, n, limit int) {
+	var page Page
+	if err := client.Call(&page, "test_numbers", n, limit); err != nil {
+		t.Fatalf("failed to open cursor: %v", err)
+	}
+	for next := 0; ; {
+		numbers := pageNumbers(&page)
+		want := pageSize(limit)
+		if n-next < want {
+			want = n - next
+		}
+		if len(numbers) != want {
+			t.Fatalf("page size mismatch at %d: have %d, want %d", next, len(numbers), want)
+		}
+		for _, number := range numbers {
+			if number != next {
+				t.Fatalf("item mismatch: have %d, want %d", number, next)
+			}
+			next++
+		}
+		if page.Cursor == "" {
+			if next != n {
+				t.Fatalf("cursor exhausted early: have %d items, want %d", next, n)
+			}
+			return
+		}
+		cursor := page.Cursor
+		page = Page{}
+		if err := client.Call(&page, "rpc_nextPage", cursor, limit); err != nil {
+			t.Fatalf("failed to retrieve page at %d: %v", next, err)
+		}
+	}
+}
+
+// Tests that result sets are paginated over persistent connections, with the
+// cursors being sticky to the connection opening them.
+func TestCursorSticky(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+	server.RegisterName("test", new(PagingService))
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	consumeNumbers(t, client, 10, 4)
+	consumeNumbers(t, client, 8, 4)
+	consumeNumbers(t, client, 3, 0)
+
+	// Exhausted cursors must be released
+	if infos := server.cursors.infos(0); len(infos) != 0 {
+		t.Fatalf("exhausted cursors left open: %d", len(infos))
+	}
+	// Open a cursor and check its accounting
+	var page Page
+	if err := client.Call(&page, "test_numbers", 10, 3); err != nil {
+		t.Fatalf("failed to open cursor: %v", err)
+	}
+	var infos []*CursorInfo
+	if err := client.Call(&infos, "test_cursors"); err != nil {
+		t.Fatalf("failed to retrieve cursors: %v", err)
+	}
+	if len(infos) != 1 || infos[0].ID != page.Cursor || infos[0].Kind != "test_numbers" || !infos[0].Sticky || infos[0].Pages != 1 || infos[0].Items != 3 {
+		t.Fatalf("cursor accounting mismatch: %+v", infos[0])
+	}
+	// Other connections must not be able to see or access the cursor
+	other := DialInProc(server)
+	if err := other.Call(&infos, "test_cursors"); err != nil || len(infos) != 0 {
+		t.Fatalf("foreign cursors listed: %v, %v", infos, err)
+	}
+	if err := other.Call(new(Page), "rpc_nextPage", page.Cursor, 3); err == nil || err.Error() != ErrCursorNotFound.Error() {
+		t.Fatalf("foreign cursor accessed: %v", err)
+	}
+	other.Close()
+
+	// Closing the connection must release the cursor
+	client.Close()
+	for i := 0; len(server.cursors.infos(0)) != 0; i++ {
+		if i == 100 {
+			t.Fatalf("cursor left open after disconnect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Tests that cursors opened over HTTP are shared across requests and can be
+// closed early.
+func TestCursorHTTP(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+	server.RegisterName("test", new(PagingService))
+
+	httpsrv := httptest.NewServer(server)
+	defer httpsrv.Close()
+
+	client, err := DialHTTP(httpsrv.URL)
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer client.Close()
+
+	consumeNumbers(t, client, 10, 4)
+
+	var page Page
+	if err := client.Call(&page, "test_numbers", 10, 4); err != nil {
+		t.Fatalf("failed to open cursor: %v", err)
+	}
+	var closed bool
+	if err := client.Call(&closed, "rpc_closeCursor", page.Cursor); err != nil || !closed {
+		t.Fatalf("failed to close cursor: %v", err)
+	}
+	if err := client.Call(new(Page), "rpc_nextPage", page.Cursor, 4); err == nil || err.Error() != ErrCursorNotFound.Error() {
+		t.Fatalf("closed cursor accessed: %v", err)
+	}
+	// Cursors shared over HTTP must not be listed to anyone
+	if err := client.Call(&page, "test_numbers", 10, 4); err != nil {
+		t.Fatalf("failed to open cursor: %v", err)
+	}
+	var infos []*CursorInfo
+	if err := client.Call(&infos, "test_cursors"); err != nil || len(infos) != 0 {
+		t.Fatalf("stateless cursors listed: %v, %v", infos, err)
+	}
+	// Cursor ids act as access tokens, they must not be derivable from each other
+	if len(page.Cursor) != 34 {
+		t.Fatalf("cursor id too short: %s", page.Cursor)
+	}
+}
+
+// Tests that the number of cursors a connection may keep open is bounded.
+func TestCursorLimit(t *testing.T) {
+	server := NewServer()
+	defer server.Stop()
+	server.RegisterName("test", new(PagingService))
+
+	client := DialInProc(server)
+	defer client.Close()
+
+	for i := 0; i < maxSessionCursors; i++ {
+		if err := client.Call(new(Page), "test_numbers", 10, 1); err != nil {
+			t.Fatalf("cursor %d: failed to open: %v", i, err)
+		}
+	}
+	if err := client.Call(new(Page), "test_numbers", 10, 1); err == nil || err.Error() != ErrTooManyCursors.Error() {
+		t.Fatalf("cursor allowance exceeded: %v", err)
+	}
+	// Further connections of the same host share its allowance
+	other := DialInProc(server)
+	defer other.Close()
+
+	for i := maxSessionCursors; i < maxHostCursors; i++ {
+		if err := other.Call(new(Page), "test_numbers", 10, 1); err != nil {
+			t.Fatalf("cursor %d: failed to open: %v", i, err)
+		}
+	}
+	third := DialInProc(server)
+	defer third.Close()
+
+	if err := third.Call(new(Page), "test_numbers", 10, 1); err == nil || err.Error() != ErrTooManyCursors.Error() {
+		t.Fatalf("host cursor allowance exceeded: %v", err)
+	}
+	// Result sets fitting a single page don't hold on to a cursor
+	if err := client.Call(new(Page), "test_numbers", 1, 1); err != nil {
+		t.Fatalf("failed to retrieve single page: %v", err)
+	}
+}
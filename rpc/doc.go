@@ -94,5 +94,21 @@ Subscriptions are deleted when:
  - the connection which was used to create the subscription is closed. This can be initiated
    by the client and server. The server will close the connection on an write error or when
    the queue of buffered notifications gets too big.
+
+Large result sets can be returned page by page through cursors. A method taking a context
+hands an Iterator over its results to Paginate, which returns the first page along with
+the id of a cursor kept open on the server. Clients retrieve the following pages with
+rpc_nextPage and may release the cursor early with rpc_closeCursor.
+
+An example method:
+ func (s *BlockChainService) Blocks(ctx context.Context, pageSize int) (*Page, error) {
+ 	return Paginate(ctx, "chain_blocks", newBlockIterator(s.chain), pageSize)
+ }
+
+Cursors opened over persistent connections are sticky to them and closed along with the
+connection, the ones opened over HTTP can be accessed by any request presenting their random
+id. Cursors left unused for a while expire, and the number of cursors open at once is
+limited per server, remote host and connection. The pages and items served by the cursors
+of a connection are reported by SessionCursors.
 */
 package rpc
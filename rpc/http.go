@@ -139,6 +139,7 @@ func (hc *httpConn) doRequest(ctx context.Context, msg interface{}) (io.ReadClos
 type httpReadWriteNopCloser struct {
 	io.Reader
 	io.Writer
+	remote string // Address of the client issuing the request
 }
 
 // Close does nothing and returns always nil
@@ -169,7 +170,7 @@ func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// All checks passed, create a codec that reads direct from the request body
 	// untilEOF and writes the response to w and order the server to process a
 	// single request.
-	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w})
+	codec := NewJSONCodec(&httpReadWriteNopCloser{r.Body, w, r.RemoteAddr})
 	defer codec.Close()
 
 	w.Header().Set("content-type", contentType)
@@ -24,6 +24,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/usechain/go-usechain/log"
 	"gopkg.in/fatih/set.v0"
@@ -47,6 +48,7 @@ func NewServer() *Server {
 	server := &Server{
 		services: make(serviceRegistry),
 		codecs:   set.New(),
+		cursors:  newCursorSet(),
 		run:      1,
 	}
 
@@ -73,6 +75,20 @@ func (s *RPCService) Modules() map[string]string {
 	return modules
 }
 
+// Methods returns the callable methods of every RPC service, mapped to the
+// number of arguments they accept. Subscriptions are not included.
+func (s *RPCService) Methods() map[string]map[string]int {
+	methods := make(map[string]map[string]int)
+	for name, svc := range s.server.services {
+		callbacks := make(map[string]int)
+		for method, callb := range svc.callbacks {
+			callbacks[method] = len(callb.argTypes)
+		}
+		methods[name] = callbacks
+	}
+	return methods
+}
+
 // RegisterName will create a service for the given rcvr type under the given name. When no methods on the given rcvr
 // match the criteria to be either a RPC method or a subscription an error is returned. Otherwise a new service is
 // created and added to the service collection this server instance serves.
@@ -149,6 +165,15 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 	if options&OptionSubscriptions == OptionSubscriptions {
 		ctx = context.WithValue(ctx, notifierKey{}, newNotifier(codec))
 	}
+	// cursors opened over a persistent connection are sticky to it and closed
+	// along with it, the ones of single shot requests are shared.
+	session := &cursorSession{set: s.cursors, host: remoteHost(codec)}
+	if !singleShot {
+		session.id = atomic.AddUint64(&s.sessions, 1)
+		defer s.cursors.dropSession(session.id)
+	}
+	ctx = context.WithValue(ctx, cursorKey{}, session)
+
 	s.codecsMu.Lock()
 	if atomic.LoadInt32(&s.run) != 1 { // server stopped
 		s.codecsMu.Unlock()
@@ -186,8 +211,11 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 			}
 			return nil
 		}
+		atomic.AddInt64(&s.inflight, 1)
+
 		// If a single shot request is executing, run and return immediately
 		if singleShot {
+			defer atomic.AddInt64(&s.inflight, -1)
 			if batch {
 				s.execBatch(ctx, codec, reqs)
 			} else {
@@ -200,6 +228,7 @@ func (s *Server) serveRequest(codec ServerCodec, singleShot bool, options CodecO
 
 		go func(reqs []*serverRequest, batch bool) {
 			defer pend.Done()
+			defer atomic.AddInt64(&s.inflight, -1)
 			if batch {
 				s.execBatch(ctx, codec, reqs)
 			} else {
@@ -225,6 +254,20 @@ func (s *Server) ServeSingleRequest(codec ServerCodec, options CodecOption) {
 	s.serveRequest(codec, true, options)
 }
 
+// Idle waits until none of the requests read so far is being executed any more,
+// or the context is done. It doesn't stop new requests from arriving, so callers
+// should stop accepting connections first.
+func (s *Server) Idle(ctx context.Context) error {
+	for atomic.LoadInt64(&s.inflight) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
 // Stop will stop reading new requests, wait for stopPendingRequestTimeout to allow pending requests to finish,
 // close all codecs which will cancel pending requests/subscriptions.
 func (s *Server) Stop() {
@@ -236,6 +279,7 @@ func (s *Server) Stop() {
 			c.(ServerCodec).Close()
 			return true
 		})
+		s.cursors.dropAll()
 	}
 }
 
@@ -316,13 +360,25 @@ func (s *Server) handle(ctx context.Context, codec ServerCodec, req *serverReque
 	if req.callb.errPos >= 0 { // test if method returned an error
 		if !reply[req.callb.errPos].IsNil() {
 			e := reply[req.callb.errPos].Interface().(error)
-			res := codec.CreateErrorResponse(&req.id, &callbackError{e.Error()})
-			return res, nil
+			return createErrorResponse(codec, &req.id, e), nil
 		}
 	}
 	return codec.CreateResponse(req.id, reply[0].Interface()), nil
 }
 
+// createErrorResponse creates the response to a failed callback, keeping the
+// code and data of errors supplying them.
+func createErrorResponse(codec ServerCodec, id interface{}, err error) interface{} {
+	rpcErr, ok := err.(Error)
+	if !ok {
+		rpcErr = &callbackError{err.Error()}
+	}
+	if dataErr, ok := err.(DataError); ok {
+		return codec.CreateErrorResponseWithInfo(id, rpcErr, dataErr.ErrorData())
+	}
+	return codec.CreateErrorResponse(id, rpcErr)
+}
+
 // exec executes the given request and writes the result back using the codec.
 func (s *Server) exec(ctx context.Context, codec ServerCodec, req *serverRequest) {
 	var response interface{}
@@ -101,6 +101,22 @@ func TestServerRegisterName(t *testing.T) {
 	}
 }
 
+func TestServerMethods(t *testing.T) {
+	server := NewServer()
+	if err := server.RegisterName("calc", new(Service)); err != nil {
+		t.Fatalf("%v", err)
+	}
+	methods := (&RPCService{server}).Methods()
+
+	want := map[string]int{"noArgsRets": 0, "echo": 3, "echoWithCtx": 3, "sleep": 1, "rets": 0}
+	if !reflect.DeepEqual(methods["calc"], want) {
+		t.Errorf("calc methods mismatch: have %v, want %v", methods["calc"], want)
+	}
+	if _, ok := methods[MetadataApi]["methods"]; !ok {
+		t.Errorf("metadata service does not expose its own methods: %v", methods[MetadataApi])
+	}
+}
+
 func testServerMethodExecution(t *testing.T, method string) {
 	server := NewServer()
 	service := new(Service)
@@ -29,6 +29,10 @@ var (
 	ErrSubscriptionNotFound = errors.New("subscription not found")
 )
 
+// maxBufferedNotifications is the number of notifications held back for an
+// inactive subscription. Exceeding it drops the subscription.
+const maxBufferedNotifications = 10000
+
 // ID defines a pseudo random number that is used to identify RPC subscriptions.
 type ID string
 
@@ -37,7 +41,8 @@ type ID string
 type Subscription struct {
 	ID        ID
 	namespace string
-	err       chan error // closed on unsubscribe
+	err       chan error    // closed on unsubscribe
+	activated chan struct{} // closed on activation
 }
 
 // Err returns a channel that is closed when the client send an unsubscribe request.
@@ -45,6 +50,12 @@ func (s *Subscription) Err() <-chan error {
 	return s.err
 }
 
+// Activated returns a channel that is closed once the subscription ID was sent
+// to the client, from when on notifications are delivered instead of buffered.
+func (s *Subscription) Activated() <-chan struct{} {
+	return s.activated
+}
+
 // notifierKey is used to store a notifier within the connection context.
 type notifierKey struct{}
 
@@ -52,9 +63,10 @@ type notifierKey struct{}
 // Server callbacks use the notifier to send notifications.
 type Notifier struct {
 	codec    ServerCodec
-	subMu    sync.RWMutex // guards active and inactive maps
+	subMu    sync.Mutex // guards active, inactive and buffer maps
 	active   map[ID]*Subscription
 	inactive map[ID]*Subscription
+	buffer   map[ID][]interface{} // notifications sent while inactive
 }
 
 // newNotifier creates a new notifier that can be used to send subscription
@@ -64,6 +76,7 @@ func newNotifier(codec ServerCodec) *Notifier {
 		codec:    codec,
 		active:   make(map[ID]*Subscription),
 		inactive: make(map[ID]*Subscription),
+		buffer:   make(map[ID][]interface{}),
 	}
 }
 
@@ -75,10 +88,10 @@ func NotifierFromContext(ctx context.Context) (*Notifier, bool) {
 
 // CreateSubscription returns a new subscription that is coupled to the
 // RPC connection. By default subscriptions are inactive and notifications
-// are dropped until the subscription is marked as active. This is done
+// are held back until the subscription is marked as active. This is done
 // by the RPC server after the subscription ID is send to the client.
 func (n *Notifier) CreateSubscription() *Subscription {
-	s := &Subscription{ID: NewID(), err: make(chan error)}
+	s := &Subscription{ID: NewID(), err: make(chan error), activated: make(chan struct{})}
 	n.subMu.Lock()
 	n.inactive[s.ID] = s
 	n.subMu.Unlock()
@@ -87,17 +100,35 @@ func (n *Notifier) CreateSubscription() *Subscription {
 
 // Notify sends a notification to the client with the given data as payload.
 // If an error occurs the RPC connection is closed and the error is returned.
+// If too many notifications are buffered for an inactive subscription, it is
+// dropped and ErrSubscriptionQueueOverflow returned.
 func (n *Notifier) Notify(id ID, data interface{}) error {
-	n.subMu.RLock()
-	defer n.subMu.RUnlock()
-
-	sub, active := n.active[id]
-	if active {
-		notification := n.codec.CreateNotification(string(id), sub.namespace, data)
-		if err := n.codec.Write(notification); err != nil {
-			n.codec.Close()
-			return err
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+
+	if sub, inactive := n.inactive[id]; inactive {
+		if len(n.buffer[id]) >= maxBufferedNotifications {
+			close(sub.err)
+			delete(n.inactive, id)
+			delete(n.buffer, id)
+			return ErrSubscriptionQueueOverflow
 		}
+		n.buffer[id] = append(n.buffer[id], data)
+		return nil
+	}
+	if sub, active := n.active[id]; active {
+		return n.send(sub, data)
+	}
+	return nil
+}
+
+// send writes a notification of an active subscription to the client. The
+// subscription lock must be held.
+func (n *Notifier) send(sub *Subscription, data interface{}) error {
+	notification := n.codec.CreateNotification(string(sub.ID), sub.namespace, data)
+	if err := n.codec.Write(notification); err != nil {
+		n.codec.Close()
+		return err
 	}
 	return nil
 }
@@ -107,7 +138,7 @@ func (n *Notifier) Closed() <-chan interface{} {
 	return n.codec.Closed()
 }
 
-// unsubscribe a subscription.
+// unsubscribe a subscription, discarding any notifications buffered for it.
 // If the subscription could not be found ErrSubscriptionNotFound is returned.
 func (n *Notifier) unsubscribe(id ID) error {
 	n.subMu.Lock()
@@ -117,19 +148,36 @@ func (n *Notifier) unsubscribe(id ID) error {
 		delete(n.active, id)
 		return nil
 	}
+	if s, found := n.inactive[id]; found {
+		close(s.err)
+		delete(n.inactive, id)
+		delete(n.buffer, id)
+		return nil
+	}
 	return ErrSubscriptionNotFound
 }
 
 // activate enables a subscription. Until a subscription is enabled all
-// notifications are dropped. This method is called by the RPC server after
+// notifications are buffered. This method is called by the RPC server after
 // the subscription ID was sent to client. This prevents notifications being
-// send to the client before the subscription ID is send to the client.
+// send to the client before the subscription ID is send to the client. If the
+// buffered notifications can't be delivered, the subscription is dropped.
 func (n *Notifier) activate(id ID, namespace string) {
 	n.subMu.Lock()
 	defer n.subMu.Unlock()
 	if sub, found := n.inactive[id]; found {
 		sub.namespace = namespace
-		n.active[id] = sub
 		delete(n.inactive, id)
+
+		buffered := n.buffer[id]
+		delete(n.buffer, id)
+		for _, data := range buffered {
+			if err := n.send(sub, data); err != nil {
+				close(sub.err)
+				return
+			}
+		}
+		n.active[id] = sub
+		close(sub.activated)
 	}
 }
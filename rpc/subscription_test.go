@@ -328,3 +328,56 @@ func TestSubscriptionMultipleNamespaces(t *testing.T) {
 		}
 	}
 }
+
+// Tests that notifications buffered for an inactive subscription are capped,
+// dropping the subscription once exceeded.
+func TestNotifierBufferLimit(t *testing.T) {
+	notifier := newNotifier(nil)
+	sub := notifier.CreateSubscription()
+
+	for i := 0; i < maxBufferedNotifications; i++ {
+		if err := notifier.Notify(sub.ID, i); err != nil {
+			t.Fatalf("notification %d: %v", i, err)
+		}
+	}
+	if err := notifier.Notify(sub.ID, maxBufferedNotifications); err != ErrSubscriptionQueueOverflow {
+		t.Fatalf("overflow error mismatch: have %v, want %v", err, ErrSubscriptionQueueOverflow)
+	}
+	select {
+	case <-sub.Err():
+	default:
+		t.Fatalf("overflowing subscription not dropped")
+	}
+	if len(notifier.buffer) != 0 || len(notifier.inactive) != 0 {
+		t.Fatalf("overflowing subscription retained: %d buffers, %d inactive", len(notifier.buffer), len(notifier.inactive))
+	}
+}
+
+// Tests that unsubscribing an inactive subscription discards its buffered
+// notifications, and that activation is signalled.
+func TestNotifierInactiveLifecycle(t *testing.T) {
+	notifier := newNotifier(nil)
+
+	dropped := notifier.CreateSubscription()
+	notifier.Notify(dropped.ID, 1)
+	if err := notifier.unsubscribe(dropped.ID); err != nil {
+		t.Fatalf("failed to unsubscribe inactive subscription: %v", err)
+	}
+	if len(notifier.buffer) != 0 || len(notifier.inactive) != 0 {
+		t.Fatalf("unsubscribed subscription retained: %d buffers, %d inactive", len(notifier.buffer), len(notifier.inactive))
+	}
+	notifier.activate(dropped.ID, "test")
+	select {
+	case <-dropped.Activated():
+		t.Fatalf("unsubscribed subscription activated")
+	default:
+	}
+
+	live := notifier.CreateSubscription()
+	notifier.activate(live.ID, "test")
+	select {
+	case <-live.Activated():
+	default:
+		t.Fatalf("activation not signalled")
+	}
+}
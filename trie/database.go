@@ -144,19 +144,29 @@ func (db *Database) preimage(hash common.Hash) ([]byte, error) {
 	if preimage != nil {
 		return preimage, nil
 	}
-	// Content unavailable in memory, attempt to retrieve from disk
-	return db.diskdb.Get(db.secureKey(hash[:]))
+	// Content unavailable in memory, attempt to retrieve from disk. The shared
+	// key buffer is reserved for commits, concurrent readers need their own.
+	key := make([]byte, 0, len(secureKeyPrefix)+len(hash))
+	key = append(append(key, secureKeyPrefix...), hash[:]...)
+
+	return db.diskdb.Get(key)
 }
 
 // secureKey returns the database key for the preimage of key, as an ephemeral
 // buffer. The caller must not hold onto the return value because it will become
-// invalid on the next call.
+// invalid on the next call, nor use it outside of a commit.
 func (db *Database) secureKey(key []byte) []byte {
 	buf := append(db.seckeybuf[:0], secureKeyPrefix...)
 	buf = append(buf, key...)
 	return buf
 }
 
+// WritePreimage stores the preimage of a hashed trie key directly into a disk
+// database, for tries built outside of a Database (e.g. stack tries).
+func WritePreimage(db ethdb.Putter, hash common.Hash, preimage []byte) error {
+	return db.Put(append(common.CopyBytes(secureKeyPrefix), hash[:]...), preimage)
+}
+
 // Nodes retrieves the hashes of all the nodes cached within the memory database.
 // This method is extremely expensive and should only be used to validate internal
 // states in test code.
@@ -175,8 +185,8 @@ func (db *Database) Nodes() []common.Hash {
 
 // Reference adds a new reference from a parent node to a child node.
 func (db *Database) Reference(child common.Hash, parent common.Hash) {
-	db.lock.RLock()
-	defer db.lock.RUnlock()
+	db.lock.Lock()
+	defer db.lock.Unlock()
 
 	db.reference(child, parent)
 }
@@ -35,6 +35,31 @@ import (
 // nodes of the longest existing prefix of the key (at least the root node), ending
 // with the node that proves the absence of the key.
 func (t *Trie) Prove(key []byte, fromLevel uint, proofDb ethdb.Putter) error {
+	hasher := newHasher(0, 0, nil)
+	defer returnHasherToPool(hasher)
+
+	return t.prove(key, fromLevel, hasher, proofDb)
+}
+
+// ProveBatch constructs a single merkle proof for all the given keys. Nodes on
+// the paths of several keys are hashed and written to proofDb only once, which
+// makes the proof considerably smaller than the individual ones for keys close
+// to each other in the trie.
+func (t *Trie) ProveBatch(keys [][]byte, fromLevel uint, proofDb ethdb.Putter) error {
+	hasher := newHasher(0, 0, nil)
+	defer returnHasherToPool(hasher)
+
+	written := &dedupPutter{db: proofDb, seen: make(map[string]struct{})}
+	for _, key := range keys {
+		if err := t.prove(key, fromLevel, hasher, written); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prove collects the nodes on the path to key into proofDb.
+func (t *Trie) prove(key []byte, fromLevel uint, hasher *hasher, proofDb ethdb.Putter) error {
 	// Collect all nodes on the path to key.
 	key = keybytesToHex(key)
 	nodes := []node{}
@@ -65,7 +90,6 @@ func (t *Trie) Prove(key []byte, fromLevel uint, proofDb ethdb.Putter) error {
 			panic(fmt.Sprintf("%T: invalid node: %v", tn, tn))
 		}
 	}
-	hasher := newHasher(0, 0, nil)
 	for i, n := range nodes {
 		// Don't bother checking for errors here since hasher panics
 		// if encoding doesn't work and we're not writing to any database.
@@ -99,20 +123,95 @@ func (t *SecureTrie) Prove(key []byte, fromLevel uint, proofDb ethdb.Putter) err
 	return t.trie.Prove(key, fromLevel, proofDb)
 }
 
+// ProveBatch constructs a single merkle proof for all the given keys, writing
+// the nodes shared by several keys only once.
+func (t *SecureTrie) ProveBatch(keys [][]byte, fromLevel uint, proofDb ethdb.Putter) error {
+	return t.trie.ProveBatch(keys, fromLevel, proofDb)
+}
+
+// dedupPutter forwards each proof node to the underlying database only once.
+type dedupPutter struct {
+	db   ethdb.Putter
+	seen map[string]struct{}
+}
+
+func (p *dedupPutter) Put(key []byte, value []byte) error {
+	if _, ok := p.seen[string(key)]; ok {
+		return nil
+	}
+	p.seen[string(key)] = struct{}{}
+	return p.db.Put(key, value)
+}
+
 // VerifyProof checks merkle proofs. The given proof must contain the value for
 // key in a trie with the given root hash. VerifyProof returns an error if the
 // proof contains invalid trie nodes or the wrong value.
 func VerifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader) (value []byte, err error, nodes int) {
+	return verifyProof(rootHash, key, proofDb, nil)
+}
+
+// VerifyProofBatch checks a merkle proof of several keys, as constructed by
+// ProveBatch or by merging individual proofs. The returned values are in the
+// order of the keys, nil for the keys proven absent from the trie. Each proof
+// node is only decoded once, however many keys its path is shared by.
+func VerifyProofBatch(rootHash common.Hash, keys [][]byte, proofDb DatabaseReader) ([][]byte, error) {
+	var (
+		decoded = make(map[common.Hash]node)
+		values  = make([][]byte, len(keys))
+	)
+	for i, key := range keys {
+		value, err, _ := verifyProof(rootHash, key, proofDb, decoded)
+		if err != nil {
+			return nil, fmt.Errorf("key %x: %v", key, err)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// NewProofReader returns a proof database holding the given list of encoded
+// proof nodes, as transferred over RPC, keyed by their hashes.
+func NewProofReader(nodes [][]byte) DatabaseReader {
+	reader := make(proofReader, len(nodes))
+	for _, node := range nodes {
+		reader[string(crypto.Keccak256(node))] = node
+	}
+	return reader
+}
+
+// proofReader is an in-memory set of proof nodes keyed by their hashes.
+type proofReader map[string][]byte
+
+func (r proofReader) Get(key []byte) ([]byte, error) {
+	if node, ok := r[string(key)]; ok {
+		return node, nil
+	}
+	return nil, fmt.Errorf("proof node %x not found", key)
+}
+
+func (r proofReader) Has(key []byte) (bool, error) {
+	_, ok := r[string(key)]
+	return ok, nil
+}
+
+// verifyProof walks the proof of a single key, reusing and filling the cache of
+// decoded nodes if one is given.
+func verifyProof(rootHash common.Hash, key []byte, proofDb DatabaseReader, decoded map[common.Hash]node) (value []byte, err error, nodes int) {
 	key = keybytesToHex(key)
 	wantHash := rootHash
 	for i := 0; ; i++ {
-		buf, _ := proofDb.Get(wantHash[:])
-		if buf == nil {
-			return nil, fmt.Errorf("proof node %d (hash %064x) missing", i, wantHash), i
-		}
-		n, err := decodeNode(wantHash[:], buf, 0)
-		if err != nil {
-			return nil, fmt.Errorf("bad proof node %d: %v", i, err), i
+		n, ok := decoded[wantHash]
+		if !ok {
+			buf, _ := proofDb.Get(wantHash[:])
+			if buf == nil {
+				return nil, fmt.Errorf("proof node %d (hash %064x) missing", i, wantHash), i
+			}
+			if n, err = decodeNode(wantHash[:], buf, 0); err != nil {
+				return nil, fmt.Errorf("bad proof node %d: %v", i, err), i
+			}
+			if decoded != nil {
+				decoded[wantHash] = n
+			}
 		}
 		keyrest, cld := get(n, key)
 		switch cld := cld.(type) {
@@ -88,6 +88,61 @@ func TestVerifyBadProof(t *testing.T) {
 	}
 }
 
+func TestProveBatch(t *testing.T) {
+	trie, vals := randomTrie(500)
+	root := trie.Hash()
+
+	var (
+		keys   [][]byte
+		want   [][]byte
+		single int
+	)
+	for _, kv := range vals {
+		keys, want = append(keys, kv.k), append(want, kv.v)
+		if len(keys) == 50 {
+			break
+		}
+	}
+	for _, key := range keys {
+		proof, _ := ethdb.NewMemDatabase()
+		trie.Prove(key, 0, proof)
+		single += proof.Len()
+	}
+	// Include a key missing from the trie, which must be proven absent
+	keys, want = append(keys, randBytes(32)), append(want, nil)
+
+	proofs, _ := ethdb.NewMemDatabase()
+	if err := trie.ProveBatch(keys, 0, proofs); err != nil {
+		t.Fatalf("failed to construct batch proof: %v", err)
+	}
+	if proofs.Len() >= single {
+		t.Errorf("batch proof not deduplicated: %d nodes, %d in individual proofs", proofs.Len(), single)
+	}
+	var nodes [][]byte
+	for _, key := range proofs.Keys() {
+		node, _ := proofs.Get(key)
+		nodes = append(nodes, node)
+	}
+	values, err := VerifyProofBatch(root, keys, NewProofReader(nodes))
+	if err != nil {
+		t.Fatalf("VerifyProofBatch error: %v", err)
+	}
+	for i := range keys {
+		if !bytes.Equal(values[i], want[i]) {
+			t.Errorf("key %x: value mismatch: have %x, want %x", keys[i], values[i], want[i])
+		}
+	}
+	// Dropping any node must break the proof of some key
+	for _, key := range proofs.Keys() {
+		node, _ := proofs.Get(key)
+		proofs.Delete(key)
+		if _, err := VerifyProofBatch(root, keys, proofs); err == nil {
+			t.Fatalf("expected proof without node %x to fail", key)
+		}
+		proofs.Put(key, node)
+	}
+}
+
 // mutateByte changes one byte in b.
 func mutateByte(b []byte) {
 	for r := mrand.Intn(len(b)); ; {
@@ -137,6 +192,40 @@ func BenchmarkVerifyProof(b *testing.B) {
 	}
 }
 
+func BenchmarkProveBatch(b *testing.B) {
+	trie, vals := randomTrie(100)
+	var keys [][]byte
+	for _, kv := range vals {
+		keys = append(keys, kv.k)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		proofs, _ := ethdb.NewMemDatabase()
+		if trie.ProveBatch(keys, 0, proofs); proofs.Len() == 0 {
+			b.Fatalf("zero length proof")
+		}
+	}
+}
+
+func BenchmarkVerifyProofBatch(b *testing.B) {
+	trie, vals := randomTrie(100)
+	root := trie.Hash()
+	var keys [][]byte
+	for _, kv := range vals {
+		keys = append(keys, kv.k)
+	}
+	proofs, _ := ethdb.NewMemDatabase()
+	trie.ProveBatch(keys, 0, proofs)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := VerifyProofBatch(root, keys, proofs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func randomTrie(n int) (*Trie, map[string]*kv) {
 	trie := new(Trie)
 	vals := make(map[string]*kv)
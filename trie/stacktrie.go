@@ -0,0 +1,276 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/usechain/go-usechain/common"
+	"github.com/usechain/go-usechain/crypto"
+	"github.com/usechain/go-usechain/ethdb"
+	"github.com/usechain/go-usechain/log"
+	"github.com/usechain/go-usechain/rlp"
+)
+
+var (
+	// errStackTrieOrder is returned if a key is inserted into a stack trie which
+	// is not strictly larger than all the previously inserted ones.
+	errStackTrieOrder = errors.New("stack trie keys not in ascending order")
+
+	// errStackTrieEmptyValue is returned if a deletion is attempted on a stack
+	// trie, which it cannot support.
+	errStackTrieEmptyValue = errors.New("stack trie values must not be empty")
+)
+
+// Node kinds a stack trie element can be in.
+const (
+	stackEmpty  = iota // Not yet populated, only the root may be empty
+	stackBranch        // Full node with up to 16 children
+	stackExt           // Short node pointing to a branch
+	stackLeaf          // Short node holding a value
+	stackHashed        // Fully collapsed node, only a reference remains
+)
+
+// StackTrie is a trie builder which accepts its keys in strictly ascending
+// order only. Since no key inserted later can ever end up left of a previous
+// one, every subtrie left of the current insertion path is final and collapsed
+// into its hash immediately, streaming its nodes into the database. Memory use
+// is thus bounded by the depth of the trie instead of its size, and the nodes
+// are never hashed twice, making it much faster than a Trie for bulk imports.
+//
+// All keys must be of the same length. Deletions and updates of existing keys
+// are not supported, and the trie can't be appended to after its root has been
+// hashed.
+type StackTrie struct {
+	kind      int            // Kind of the node (stackBranch, stackLeaf, ...)
+	key       []byte         // Nibbles of the key covered by the node (ext and leaf)
+	val       []byte         // Value held by the node (leaf only)
+	keyOffset int            // Depth of the node in nibbles from the root
+	children  [16]*StackTrie // Child nodes (branch) or the pointed branch in slot 0 (ext)
+	ref       node           // Reference to embed into the parent after hashing
+	db        ethdb.Putter   // Database to stream the collapsed nodes into (optional)
+	tmp       *bytes.Buffer  // Encoding buffer shared by all nodes of the trie
+}
+
+// NewStackTrie creates an empty stack trie, writing the nodes into db as soon as
+// they are collapsed. If db is nil, the trie is only hashed.
+func NewStackTrie(db ethdb.Putter) *StackTrie {
+	return &StackTrie{kind: stackEmpty, db: db, tmp: new(bytes.Buffer)}
+}
+
+// newChild creates a new node in the stack trie below st.
+func (st *StackTrie) newChild(kind int, keyOffset int, key []byte, val []byte) *StackTrie {
+	return &StackTrie{
+		kind:      kind,
+		key:       common.CopyBytes(key),
+		val:       val,
+		keyOffset: keyOffset,
+		db:        st.db,
+		tmp:       st.tmp,
+	}
+}
+
+// Update inserts a key into the trie, logging any error.
+func (st *StackTrie) Update(key, value []byte) {
+	if err := st.TryUpdate(key, value); err != nil {
+		log.Error(fmt.Sprintf("Unhandled trie error: %v", err))
+	}
+}
+
+// TryUpdate inserts a key into the trie. The key must be strictly larger than
+// all the keys inserted before and the value must not be empty.
+func (st *StackTrie) TryUpdate(key, value []byte) error {
+	if len(value) == 0 {
+		return errStackTrieEmptyValue
+	}
+	hex := keybytesToHex(key)
+	return st.insert(hex[:len(hex)-1], common.CopyBytes(value))
+}
+
+// insert places a value at the nibble path key, collapsing every subtrie left
+// of the path into its hash.
+func (st *StackTrie) insert(key, value []byte) error {
+	switch st.kind {
+	case stackEmpty:
+		st.kind, st.key, st.val = stackLeaf, common.CopyBytes(key[st.keyOffset:]), value
+		return nil
+
+	case stackBranch:
+		idx := int(key[st.keyOffset])
+		for i := idx + 1; i < 16; i++ {
+			if st.children[i] != nil {
+				return errStackTrieOrder
+			}
+		}
+		// Any elder sibling is final now, collapse the closest (others already are)
+		for i := idx - 1; i >= 0; i-- {
+			if st.children[i] != nil {
+				if err := st.children[i].hash(false); err != nil {
+					return err
+				}
+				break
+			}
+		}
+		if st.children[idx] == nil {
+			st.children[idx] = st.newChild(stackLeaf, st.keyOffset+1, key[st.keyOffset+1:], value)
+			return nil
+		}
+		return st.children[idx].insert(key, value)
+
+	case stackExt:
+		diff := st.diffIndex(key)
+		if diff == len(st.key) {
+			return st.children[0].insert(key, value)
+		}
+		if key[st.keyOffset+diff] < st.key[diff] {
+			return errStackTrieOrder
+		}
+		// The path diverges within the extension, so whatever it points to is final.
+		// Keep the remainder of the extension (if any) as a left child of a new branch.
+		orig := st.children[0]
+		if diff < len(st.key)-1 {
+			orig = st.newChild(stackExt, st.keyOffset+diff+1, st.key[diff+1:], nil)
+			orig.children[0] = st.children[0]
+		}
+		if err := orig.hash(false); err != nil {
+			return err
+		}
+		branch := st.split(diff)
+		branch.children[st.key[diff]] = orig
+		branch.children[key[branch.keyOffset]] = st.newChild(stackLeaf, branch.keyOffset+1, key[branch.keyOffset+1:], value)
+		st.key = st.key[:diff]
+		return nil
+
+	case stackLeaf:
+		diff := st.diffIndex(key)
+		if diff == len(st.key) || key[st.keyOffset+diff] < st.key[diff] {
+			return errStackTrieOrder
+		}
+		// Push the existing value down into a leaf of a new branch and collapse it
+		orig := st.newChild(stackLeaf, st.keyOffset+diff+1, st.key[diff+1:], st.val)
+		if err := orig.hash(false); err != nil {
+			return err
+		}
+		branch := st.split(diff)
+		branch.children[st.key[diff]] = orig
+		branch.children[key[branch.keyOffset]] = st.newChild(stackLeaf, branch.keyOffset+1, key[branch.keyOffset+1:], value)
+		st.key, st.val = st.key[:diff], nil
+		return nil
+
+	default:
+		return errStackTrieOrder
+	}
+}
+
+// split converts an ext or leaf node diverging from a new key at nibble diff of
+// its own key into a branch (diverging at its first nibble) or an extension to a
+// new branch, returning the branch node.
+func (st *StackTrie) split(diff int) *StackTrie {
+	if diff == 0 {
+		st.kind, st.children[0] = stackBranch, nil
+		return st
+	}
+	st.kind = stackExt
+	st.children[0] = st.newChild(stackBranch, st.keyOffset+diff, nil, nil)
+	return st.children[0]
+}
+
+// diffIndex returns the index of the first nibble of the node's key not matching
+// the given full key.
+func (st *StackTrie) diffIndex(key []byte) int {
+	for i, nibble := range st.key {
+		if nibble != key[st.keyOffset+i] {
+			return i
+		}
+	}
+	return len(st.key)
+}
+
+// hash collapses the node and all its children into a reference embeddable into
+// the parent, writing every node with an encoding of at least 32 bytes (or all,
+// if force is set) into the database.
+func (st *StackTrie) hash(force bool) error {
+	var collapsed node
+	switch st.kind {
+	case stackHashed:
+		return nil
+
+	case stackEmpty:
+		st.kind, st.ref = stackHashed, hashNode(emptyRoot.Bytes())
+		return nil
+
+	case stackBranch:
+		full := new(fullNode)
+		for i, child := range st.children {
+			if child == nil {
+				full.Children[i] = valueNode(nil)
+				continue
+			}
+			if err := child.hash(false); err != nil {
+				return err
+			}
+			full.Children[i] = child.ref
+		}
+		full.Children[16] = valueNode(nil)
+		collapsed = full
+
+	case stackExt:
+		if err := st.children[0].hash(false); err != nil {
+			return err
+		}
+		collapsed = &shortNode{Key: hexToCompact(st.key), Val: st.children[0].ref}
+
+	case stackLeaf:
+		collapsed = &shortNode{Key: hexToCompact(append(st.key, 16)), Val: valueNode(st.val)}
+	}
+	st.tmp.Reset()
+	if err := rlp.Encode(st.tmp, collapsed); err != nil {
+		return err
+	}
+	st.kind, st.key, st.val = stackHashed, nil, nil
+	st.children = [16]*StackTrie{}
+
+	if st.tmp.Len() < 32 && !force {
+		st.ref = collapsed // Nodes smaller than 32 bytes are stored inside their parent
+		return nil
+	}
+	hash := crypto.Keccak256(st.tmp.Bytes())
+	st.ref = hashNode(hash)
+	if st.db != nil {
+		return st.db.Put(hash, st.tmp.Bytes())
+	}
+	return nil
+}
+
+// Hash returns the root hash of the trie. No more keys can be inserted after.
+func (st *StackTrie) Hash() common.Hash {
+	if err := st.hash(true); err != nil {
+		log.Error(fmt.Sprintf("Unhandled trie error: %v", err))
+	}
+	return common.BytesToHash(st.ref.(hashNode))
+}
+
+// Commit collapses the whole trie, writing all remaining nodes into the database
+// and returns the root hash. No more keys can be inserted after.
+func (st *StackTrie) Commit() (common.Hash, error) {
+	if err := st.hash(true); err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(st.ref.(hashNode)), nil
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 The go-usechain Authors
+// This file is part of the go-usechain library.
+//
+// The go-usechain library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-usechain library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-usechain library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/usechain/go-usechain/ethdb"
+)
+
+// Tests that an empty stack trie hashes to the empty root.
+func TestStackTrieEmpty(t *testing.T) {
+	if hash := NewStackTrie(nil).Hash(); hash != emptyRoot {
+		t.Fatalf("empty stack trie hash mismatch: have %x, want %x", hash, emptyRoot)
+	}
+}
+
+// Tests that a stack trie produces the same root and writes exactly the same
+// nodes into the database as a regular trie built from the same data.
+func TestStackTrieEquivalence(t *testing.T) {
+	// Dense keys from a tiny alphabet force deep extensions and embedded nodes
+	alphabet := []byte{0x00, 0x01, 0x10, 0x11, 0xff}
+	dense := func(rnd *rand.Rand) []byte {
+		key := make([]byte, 4)
+		for i := range key {
+			key[i] = alphabet[rnd.Intn(len(alphabet))]
+		}
+		return key
+	}
+	sparse := func(rnd *rand.Rand) []byte {
+		key := make([]byte, 32)
+		rnd.Read(key)
+		return key
+	}
+	for _, gen := range []func(*rand.Rand) []byte{dense, sparse} {
+		for _, size := range []int{1, 2, 3, 16, 100, 1000} {
+			rnd := rand.New(rand.NewSource(int64(size)))
+
+			entries := make(map[string][]byte)
+			for i := 0; i < size; i++ {
+				value := make([]byte, 1+rnd.Intn(40))
+				rnd.Read(value)
+				entries[string(gen(rnd))] = value
+			}
+			keys := make([]string, 0, len(entries))
+			for key := range entries {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+
+			// Build the reference trie and the stack trie
+			refdb, _ := ethdb.NewMemDatabase()
+			triedb := NewDatabase(refdb)
+			ref, _ := New(emptyRoot, triedb)
+
+			stackdb, _ := ethdb.NewMemDatabase()
+			stack := NewStackTrie(stackdb)
+
+			for _, key := range keys {
+				ref.Update([]byte(key), entries[key])
+				if err := stack.TryUpdate([]byte(key), entries[key]); err != nil {
+					t.Fatalf("size %d: failed to insert into stack trie: %v", size, err)
+				}
+			}
+			want, _ := ref.Commit(nil)
+			triedb.Commit(want, false)
+
+			have, err := stack.Commit()
+			if err != nil {
+				t.Fatalf("size %d: failed to commit stack trie: %v", size, err)
+			}
+			if have != want {
+				t.Fatalf("size %d: root mismatch: have %x, want %x", size, have, want)
+			}
+			if stackdb.Len() != refdb.Len() {
+				t.Fatalf("size %d: node count mismatch: have %d, want %d", size, stackdb.Len(), refdb.Len())
+			}
+			for _, key := range refdb.Keys() {
+				blob, _ := refdb.Get(key)
+				if stored, _ := stackdb.Get(key); !bytes.Equal(stored, blob) {
+					t.Fatalf("size %d: node %x mismatch: have %x, want %x", size, key, stored, blob)
+				}
+			}
+		}
+	}
+}
+
+// Tests that keys violating the ascending order requirement are rejected.
+func TestStackTrieOrder(t *testing.T) {
+	tests := []struct {
+		keys []string
+		fail bool
+	}{
+		{keys: []string{"aaaa", "aaab", "abaa", "bbbb"}},
+		{keys: []string{"aaab", "aaaa"}, fail: true},                 // smaller sibling of a leaf
+		{keys: []string{"aaaa", "aaaa"}, fail: true},                 // duplicate key
+		{keys: []string{"aaaa", "aaab", "aaac", "aaaa"}, fail: true}, // key into a collapsed subtrie
+		{keys: []string{"aaaa", "abcd", "abce", "aacd"}, fail: true}, // smaller branch than the last
+		{keys: []string{"aaaa", "aaab", "baaa", "abaa"}, fail: true}, // key left of an extension
+	}
+	for i, tt := range tests {
+		stack := NewStackTrie(nil)
+
+		var err error
+		for _, key := range tt.keys {
+			if err = stack.TryUpdate([]byte(key), []byte("value")); err != nil {
+				break
+			}
+		}
+		if (err != nil) != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want failure %v", i, err, tt.fail)
+		}
+	}
+	if err := NewStackTrie(nil).TryUpdate([]byte("key"), nil); err != errStackTrieEmptyValue {
+		t.Errorf("empty value error mismatch: have %v, want %v", err, errStackTrieEmptyValue)
+	}
+}
+
+func BenchmarkStackTrieCommit(b *testing.B) {
+	keys := make([][]byte, 10000)
+	for i := range keys {
+		keys[i] = make([]byte, 32)
+		rand.Read(keys[i])
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db, _ := ethdb.NewMemDatabase()
+		stack := NewStackTrie(db)
+		for _, key := range keys {
+			stack.Update(key, key)
+		}
+		stack.Commit()
+	}
+}